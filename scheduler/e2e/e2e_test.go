@@ -0,0 +1,134 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"nexus-scheduler/pkg/client"
+)
+
+// checkoutFlow are the services of the checkout-flow experiment group
+var checkoutFlow = []string{"cartservice", "paymentservice", "checkoutservice", "currencyservice"}
+
+func TestSyntheticSpikeColocatesGang(t *testing.T) {
+	ctx := context.Background()
+	eventually(t, time.Minute, "NEXUS to be IDLE", func() bool {
+		status, err := harness.nexus.Status(ctx)
+		return err == nil && status.State == "IDLE"
+	})
+	before := scrape(t)
+
+	// Nodes already running checkout-flow members
+	memberNodes := make(map[string]bool)
+	existing := make(map[string]bool)
+	for _, service := range checkoutFlow {
+		for _, pod := range pods(t, service) {
+			if pod.Spec.NodeName != "" {
+				memberNodes[pod.Spec.NodeName] = true
+			}
+			existing[pod.Name] = true
+		}
+	}
+	if len(memberNodes) == 0 {
+		t.Fatal("no checkout-flow pods running")
+	}
+
+	if _, err := harness.nexus.InjectSpike(ctx, client.InjectedSpike{Class: "traffic"}); err != nil {
+		t.Fatal(err)
+	}
+	var episode string
+	eventually(t, 2*time.Minute, "NEXUS to activate", func() bool {
+		status, err := harness.nexus.Status(ctx)
+		if err != nil || status.State != "ACTIVE" {
+			return false
+		}
+		episode = status.EpisodeID
+		return status.ActiveGangs > 0
+	})
+
+	// Scale up: new replicas must join the nodes the gang already runs on
+	if err := sh(kubectl("scale", "deployment/cartservice", "--replicas=3")...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sh(kubectl("scale", "deployment/cartservice", "--replicas=1")...) })
+
+	var added []v1.Pod
+	eventually(t, 2*time.Minute, "new cartservice replicas to be scheduled", func() bool {
+		added = added[:0]
+		for _, pod := range pods(t, "cartservice") {
+			if !existing[pod.Name] && pod.Spec.NodeName != "" {
+				added = append(added, pod)
+			}
+		}
+		return len(added) == 2
+	})
+	for _, pod := range added {
+		if !memberNodes[pod.Spec.NodeName] {
+			t.Errorf("replica %s placed on %s, want a node running checkout-flow members %v", pod.Name, pod.Spec.NodeName, memberNodes)
+		}
+	}
+
+	// The placements were decided by NEXUS within the episode
+	decisions, err := harness.nexus.Decisions(ctx, episode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decided := make(map[string]bool)
+	for _, d := range decisions {
+		decided[d.Pod] = true
+	}
+	for _, pod := range added {
+		if !decided[pod.Name] {
+			t.Errorf("no Prioritize decision recorded for %s in episode %s", pod.Name, episode)
+		}
+	}
+
+	after := scrape(t)
+	for name, min := range map[string]float64{
+		"nexus_spikes_injected_total":  1,
+		"nexus_spike_events_total":     1,
+		"nexus_filter_calls_total":     2,
+		"nexus_prioritize_calls_total": 2,
+	} {
+		if delta := after[name] - before[name]; delta < min {
+			t.Errorf("%s increased by %v, want at least %v", name, delta, min)
+		}
+	}
+	if after["nexus_scheduler_state"] != 1 {
+		t.Errorf("nexus_scheduler_state = %v, want 1 (ACTIVE)", after["nexus_scheduler_state"])
+	}
+}
+
+// sample matches an unlabelled sample line of the exposition format
+var sample = regexp.MustCompile(`(?m)^(nexus_[a-z_]+) ([0-9.eE+-]+)$`)
+
+// scrape returns the unlabelled NEXUS samples of /metrics
+func scrape(t *testing.T) map[string]float64 {
+	t.Helper()
+	resp, err := http.Get(harness.adminURL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make(map[string]float64)
+	for _, m := range sample.FindAllStringSubmatch(string(body), -1) {
+		if v, err := strconv.ParseFloat(m[2], 64); err == nil {
+			samples[m[1]] = v
+		}
+	}
+	return samples
+}
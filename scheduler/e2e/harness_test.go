@@ -0,0 +1,236 @@
+//go:build e2e
+
+/*
+End-to-End Harness
+==================
+Brings up a kind cluster whose kube-scheduler calls NEXUS as an extender,
+deploys NEXUS (deployment.yaml plus nexus-e2e.yaml) and Online Boutique
+(release/kubernetes-manifests.yaml), and port-forwards the NEXUS admin
+listener for the tests. Requires kind, kubectl and docker on PATH:
+
+  go test -tags e2e -timeout 30m ./e2e
+
+  E2E_CLUSTER        kind cluster name (default nexus-e2e)
+  E2E_REUSE_CLUSTER  true = use an existing cluster, skip create/delete
+  E2E_KEEP_CLUSTER   true = leave the cluster running afterwards
+  E2E_IMAGE          NEXUS image built and loaded (default nexus-scheduler:v2.0)
+
+NEXUS runs without Prometheus, so it stays IDLE until a test injects a
+spike through POST /admin/spike.
+*/
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"nexus-scheduler/pkg/client"
+)
+
+const (
+	nexusNamespace = "nexus-system"
+	adminToken     = "e2e-admin-token"
+	adminPort      = "19100"
+)
+
+// nexusEnv overrides deployment.yaml for deterministic assertions
+var nexusEnv = []string{
+	"PROMETHEUS_URL=http://127.0.0.1:1", // no Prometheus: spikes are injected
+	"GANG_FILTER_STRICT=true",
+	"STATE_RECOVERY_ENABLED=false",
+	"SPIKE_CHECK_JITTER=0s",
+}
+
+// harness is the cluster shared by every test
+var harness struct {
+	cluster  string
+	kubectl  []string // kubectl with the cluster's context
+	nexus    *client.Client
+	adminURL string
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run sets the cluster up, runs the tests and tears everything down
+func run(m *testing.M) int {
+	cluster := envOr("E2E_CLUSTER", "nexus-e2e")
+	harness.cluster = cluster
+	harness.kubectl = []string{"kubectl", "--context", "kind-" + cluster}
+	harness.adminURL = "http://127.0.0.1:" + adminPort
+	harness.nexus = client.New(harness.adminURL, adminToken)
+
+	for _, tool := range []string{"kind", "kubectl", "docker"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: %s not found on PATH\n", tool)
+			return 1
+		}
+	}
+
+	reuse := os.Getenv("E2E_REUSE_CLUSTER") == "true"
+	if !reuse {
+		if err := createCluster(cluster); err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+			return 1
+		}
+		if os.Getenv("E2E_KEEP_CLUSTER") != "true" {
+			defer sh("kind", "delete", "cluster", "--name", cluster)
+		}
+	}
+
+	stop, err := deploy(cluster)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+		return 1
+	}
+	defer stop()
+
+	return m.Run()
+}
+
+// createCluster creates a kind cluster with one control-plane node whose
+// kube-scheduler uses scheduler-config.yaml, and two workers
+func createCluster(cluster string) error {
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+	config := fmt.Sprintf(`kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+nodes:
+  - role: control-plane
+    extraMounts:
+      - hostPath: %s
+        containerPath: /etc/kubernetes/nexus/scheduler-config.yaml
+        readOnly: true
+    kubeadmConfigPatches:
+      - |
+        kind: ClusterConfiguration
+        scheduler:
+          extraArgs:
+            config: /etc/kubernetes/nexus/scheduler-config.yaml
+          extraVolumes:
+            - name: nexus
+              hostPath: /etc/kubernetes/nexus
+              mountPath: /etc/kubernetes/nexus
+              readOnly: true
+              pathType: Directory
+  - role: worker
+  - role: worker
+`, filepath.Join(dir, "scheduler-config.yaml"))
+
+	file := filepath.Join(os.TempDir(), cluster+"-kind.yaml")
+	if err := os.WriteFile(file, []byte(config), 0o644); err != nil {
+		return err
+	}
+	return sh("kind", "create", "cluster", "--name", cluster, "--config", file, "--wait", "5m")
+}
+
+// deploy builds and loads the NEXUS image, deploys NEXUS and Online
+// Boutique and port-forwards the admin listener; stop ends the forward
+func deploy(cluster string) (stop func(), err error) {
+	image := envOr("E2E_IMAGE", "nexus-scheduler:v2.0")
+	steps := [][]string{
+		{"docker", "build", "-t", image, ".."},
+		{"kind", "load", "docker-image", image, "--name", cluster},
+		kubectl("apply", "-f", "../deployment.yaml"),
+		kubectl("apply", "-f", "nexus-e2e.yaml"),
+		kubectl("-n", nexusNamespace, "delete", "secret", "nexus-admin-token", "--ignore-not-found"),
+		kubectl("-n", nexusNamespace, "create", "secret", "generic", "nexus-admin-token", "--from-literal=token="+adminToken),
+		kubectl(append([]string{"-n", nexusNamespace, "set", "env", "deployment/nexus-scheduler"}, nexusEnv...)...),
+		kubectl("-n", nexusNamespace, "set", "image", "deployment/nexus-scheduler", "nexus-scheduler="+image),
+		kubectl("-n", nexusNamespace, "rollout", "status", "deployment/nexus-scheduler", "--timeout=3m"),
+		kubectl("apply", "-f", "../../release/kubernetes-manifests.yaml"),
+		kubectl("wait", "--for=condition=Available", "deployment", "--all", "--timeout=10m"),
+	}
+	for _, step := range steps {
+		if err := sh(step...); err != nil {
+			return nil, err
+		}
+	}
+
+	args := kubectl("-n", nexusNamespace, "port-forward", "svc/nexus-scheduler", adminPort+":9100")
+	forward := exec.Command(args[0], args[1:]...)
+	if err := forward.Start(); err != nil {
+		return nil, fmt.Errorf("port-forward: %w", err)
+	}
+	stop = func() { forward.Process.Kill(); forward.Wait() }
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for {
+		if _, err := harness.nexus.Status(ctx); err == nil {
+			return stop, nil
+		}
+		select {
+		case <-ctx.Done():
+			stop()
+			return nil, fmt.Errorf("NEXUS admin listener not reachable through the port-forward")
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// kubectl returns a kubectl command line against the e2e cluster
+func kubectl(args ...string) []string {
+	return append(append([]string(nil), harness.kubectl...), args...)
+}
+
+// sh runs a command, streaming its stdout; errors carry its stderr
+func sh(args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// pods returns the pods of an Online Boutique service
+func pods(t *testing.T, service string) []v1.Pod {
+	t.Helper()
+	args := kubectl("get", "pods", "-l", "app="+service, "-o", "json")
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		t.Fatalf("listing %s pods: %v", service, err)
+	}
+	var list v1.PodList
+	if err := json.Unmarshal(out, &list); err != nil {
+		t.Fatalf("decoding %s pods: %v", service, err)
+	}
+	return list.Items
+}
+
+// eventually polls cond every second until it holds or timeout passes
+func eventually(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// envOr returns the environment variable or def when unset
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,664 @@
+/*
+NEXUS Client
+============
+Typed HTTP client for the observability and admin endpoints of a running
+NEXUS instance (ADMIN_ADDR), shared by experiment orchestrators and
+tooling instead of ad-hoc HTTP calls:
+
+  Status          → GET /status
+  Config          → GET /config
+  Episodes        → GET /episodes
+  Decisions       → GET /decisions[?episode=<id>]
+  StoredEpisodes  → GET /history/episodes
+  StoredDecisions → GET /history/decisions
+  Sweep           → GET /sweep
+  Policies        → GET /policies
+  Version         → GET /version
+  Simulate        → POST /simulate
+  Profiles        → GET /admin/profiles
+  PinProfile      → PUT /admin/profiles/active (DELETE when name is "")
+  Formation       → GET /admin/formation
+  PinFormation    → PUT /admin/formation (DELETE when name is "")
+  Backoff         → GET /admin/backoff
+  ReEnable        → DELETE /admin/backoff
+  Latency         → GET /admin/latency
+  Resume          → DELETE /admin/latency
+  InjectSpike     → POST /admin/spike
+  Activate        → POST /admin/activate
+  Deactivate      → POST /admin/deactivate
+  Snapshot        → GET /admin/snapshot
+
+Activate and Deactivate are idempotent on the episode ID, so callers may
+retry them after a timeout or a 503 (Retry-After) without starting or
+ending an episode twice.
+
+The package only depends on the standard library and pkg/metrics, so it
+can be imported without pulling in the Kubernetes client.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"nexus-scheduler/pkg/metrics"
+)
+
+// defaultTimeout bounds every request of the default HTTP client
+const defaultTimeout = 10 * time.Second
+
+// Client talks to one NEXUS instance
+type Client struct {
+	// HTTPClient sends the requests (default: 10s timeout)
+	HTTPClient *http.Client
+
+	baseURL    string
+	adminToken string
+}
+
+// New creates a client for the NEXUS instance at baseURL (e.g.
+// http://nexus:9100). adminToken is sent to the /admin endpoints ("" = none).
+func New(baseURL, adminToken string) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		adminToken: adminToken,
+	}
+}
+
+// StatusError is returned for non-2xx responses
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// Status is the /status response
+type Status struct {
+	State         string                            `json:"state"`
+	GangStage     string                            `json:"gangStage"`
+	ActiveGangs   int                               `json:"activeGangs"`
+	DegradedGangs map[string][]string               `json:"degradedGangs"` // gang → missing members
+	GraphBuilt    bool                              `json:"graphBuilt"`
+	APIBreaker    string                            `json:"apiBreaker"`
+	APIAuth       string                            `json:"apiAuth"` // OK or FAILING
+	LastSpikeTime string                            `json:"lastSpikeTime"`
+	Cooldown      string                            `json:"cooldown"` // quiet period before the gangs dissolve
+	EpisodeID     string                            `json:"episodeId"`
+	Profile       string                            `json:"profile"`
+	SpikeClass    string                            `json:"spikeClass"`
+	Level         string                            `json:"level"` // ADVISORY or ENFORCING while a spike lasts
+	Formation     string                            `json:"formation"`
+	GroupOverlaps []GroupOverlap                    `json:"groupOverlaps"`
+	Backoff       bool                              `json:"backoff"` // activation flapping back-off
+	SelfDisabled  bool                              `json:"selfDisabled"`
+	SLO           map[string]metrics.SLOStatus      `json:"slo"`
+	Influence     float64                           `json:"influence"`
+	Protocol      string                            `json:"protocol"`
+	LatencyMs     map[string]metrics.LatencySummary `json:"latencyMs"`
+}
+
+// GroupOverlap is a service declared in several coordination groups and
+// how it was resolved when the gangs formed
+type GroupOverlap struct {
+	Service    string   `json:"service"`
+	Groups     []string `json:"groups"`
+	Resolution string   `json:"resolution"` // merge, priority or error
+	Gang       string   `json:"gang,omitempty"`
+}
+
+// Config is the /config response
+type Config struct {
+	Env      map[string]interface{} `json:"env"`
+	Detector map[string]interface{} `json:"detector"`
+	Timing   map[string]string      `json:"timing"`
+	Warnings []string               `json:"warnings"`
+}
+
+// Episode is one entry of /episodes
+type Episode struct {
+	ID          string     `json:"id"`
+	ActivatedAt time.Time  `json:"activatedAt"`
+	EndedAt     *time.Time `json:"endedAt,omitempty"` // nil while running
+	SpikeClass  string     `json:"spikeClass"`
+	Trigger     *Trigger   `json:"trigger,omitempty"` // signal that caused the activation
+	Level       string     `json:"level,omitempty"`   // highest activation level reached
+	Formation   string     `json:"formation"`
+	Placement   string     `json:"placement"`
+	Gangs       int        `json:"gangs"`
+	Decisions   int        `json:"decisions"`
+	Extensions  int        `json:"extensions,omitempty"`
+
+	// Creation to binding of the gang-member pods bound during the episode
+	SchedulingLatency *metrics.LatencySummary `json:"schedulingLatency,omitempty"`
+}
+
+// Trigger is the signal that caused an episode's activation
+type Trigger struct {
+	Signal    string  `json:"signal"`
+	Source    string  `json:"source"`
+	Query     string  `json:"query,omitempty"`
+	Target    string  `json:"target,omitempty"` // namespace/name of the HPA (hpa)
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
+
+// NodeScore is the scoring breakdown of one candidate node
+type NodeScore struct {
+	Host        string  `json:"host"`
+	Locality    int64   `json:"locality"`
+	Topology    int64   `json:"topology"`
+	Resource    int64   `json:"resource"`
+	Utilization int64   `json:"utilization"`
+	Total       int64   `json:"total"`
+	Normalized  float64 `json:"normalized"`
+	Scanned     bool    `json:"scanned"`
+	Excluded    bool    `json:"excluded,omitempty"`
+	Degraded    bool    `json:"degraded,omitempty"` // scored without a live member count
+
+	Unschedulable bool `json:"unschedulable,omitempty"` // cordoned, tainted or pressured for the pod
+}
+
+// Decision is one entry of /decisions
+type Decision struct {
+	ID        string      `json:"id"` // X-Nexus-Decision-Id of the Prioritize response
+	Time      time.Time   `json:"time"`
+	Episode   string      `json:"episode"`
+	Namespace string      `json:"namespace"`
+	Pod       string      `json:"pod"`
+	Gang      string      `json:"gang"`
+	Node      string      `json:"node"`
+	Scores    []NodeScore `json:"scores"`
+}
+
+// SimulationRequest is the body of POST /simulate; Pod and Nodes are a
+// Pod and NodeList in their Kubernetes JSON form
+type SimulationRequest struct {
+	Pod       json.RawMessage `json:"pod"`
+	Gang      string          `json:"gang,omitempty"` // gang ID or group ("" = by pod)
+	Nodes     json.RawMessage `json:"nodes,omitempty"`
+	NodeNames []string        `json:"nodenames,omitempty"` // neither = every cluster node
+}
+
+// Simulation is what Filter and Prioritize would answer for a pod
+type Simulation struct {
+	State      string            `json:"state"`
+	Level      string            `json:"level,omitempty"`
+	Gang       string            `json:"gang,omitempty"`
+	NoOpinion  string            `json:"noOpinion,omitempty"` // why NEXUS would not influence the pod
+	Eligible   []string          `json:"eligible"`
+	Rejected   map[string]string `json:"rejected,omitempty"` // node → reason
+	Priorities []HostScore       `json:"priorities"`
+	Scores     []NodeScore       `json:"scores,omitempty"`
+}
+
+// HostScore is the Prioritize score of one node
+type HostScore struct {
+	Host  string `json:"host"`
+	Score int64  `json:"score"`
+}
+
+// Sweep is the /sweep response
+type Sweep struct {
+	Enabled        bool                  `json:"enabled"`
+	CurrentFactor  *float64              `json:"currentFactor,omitempty"`
+	Results        []metrics.SweepResult `json:"results"`
+	BestFactor     *float64              `json:"bestFactor,omitempty"`
+	BaselineFactor *float64              `json:"baselineFactor,omitempty"`
+	ImprovementMs  float64               `json:"improvementMs"`
+	ImprovementPct float64               `json:"improvementPct"`
+}
+
+// Shadow is the /shadow response
+type Shadow struct {
+	Enabled bool             `json:"enabled"`
+	Scorers []ShadowScorer   `json:"scorers"`
+	Recent  []ShadowDecision `json:"recent"` // newest first
+}
+
+// ShadowScorer summarises how often one shadow scorer disagreed with the primary
+type ShadowScorer struct {
+	Name           string  `json:"name"`
+	Decisions      int     `json:"decisions"`
+	Divergent      int     `json:"divergent"`
+	DivergenceRate float64 `json:"divergenceRate"`
+}
+
+// ShadowDecision is the node each shadow scorer preferred for one decision
+type ShadowDecision struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Namespace string            `json:"namespace"`
+	Pod       string            `json:"pod"`
+	Gang      string            `json:"gang"`
+	Primary   string            `json:"primary"`
+	Shadows   map[string]string `json:"shadows"`
+	Divergent []string          `json:"divergent,omitempty"`
+}
+
+// Policy is a NexusPolicy loaded for a coordination group
+type Policy struct {
+	Source        string        `json:"source"` // "<namespace>/<name>"
+	Group         string        `json:"group"`
+	SLOP95Ms      float64       `json:"sloP95Ms,omitempty"`
+	Placement     string        `json:"placement,omitempty"`
+	FreshStart    string        `json:"freshStart,omitempty"`
+	LocalityScale float64       `json:"localityScale,omitempty"`
+	Weights       PolicyWeights `json:"weights,omitempty"`
+	PriorityBoost float64       `json:"priorityBoost,omitempty"`
+	Priority      int           `json:"priority,omitempty"`
+	MaxInfluence  int           `json:"maxInfluence,omitempty"`
+	Anchors       []string      `json:"anchors,omitempty"`
+}
+
+// PolicyWeights multiply the scoring components (0 = unset = 1)
+type PolicyWeights struct {
+	Locality    float64 `json:"locality,omitempty"`
+	Resource    float64 `json:"resource,omitempty"`
+	Utilization float64 `json:"utilization,omitempty"`
+}
+
+// Profile is a spike detection threshold profile
+type Profile struct {
+	Name                string  `json:"name"`
+	Schedule            string  `json:"schedule,omitempty"`
+	QPSThreshold        float64 `json:"qpsThreshold,omitempty"`
+	ErrorThreshold      float64 `json:"errorThreshold,omitempty"`
+	P95LatencyThreshold float64 `json:"p95LatencyThresholdMs,omitempty"`
+	ServiceQPSThreshold float64 `json:"serviceQpsThreshold,omitempty"`
+}
+
+// Profiles is the /admin/profiles response
+type Profiles struct {
+	Active   string    `json:"active"`
+	Pinned   string    `json:"pinned,omitempty"`
+	Profiles []Profile `json:"profiles"`
+}
+
+// Formation is the /admin/formation response
+type Formation struct {
+	Current    string   `json:"current,omitempty"` // running episode ("" when IDLE)
+	Next       string   `json:"next"`
+	Configured string   `json:"configured"`
+	Pinned     string   `json:"pinned,omitempty"`
+	Strategies []string `json:"strategies"`
+}
+
+// Backoff is the /admin/backoff response
+type Backoff struct {
+	Active         bool       `json:"active"`
+	Since          *time.Time `json:"since,omitempty"`
+	LastSuppressed *time.Time `json:"lastSuppressed,omitempty"`
+	Suppressed     int        `json:"suppressed"`
+	Activations    int        `json:"activations"` // within the flap window
+	MaxActivations int        `json:"maxActivations"`
+	Window         string     `json:"window"`
+	Stabilization  string     `json:"stabilization"`
+}
+
+// LatencyGuard is the /admin/latency response
+type LatencyGuard struct {
+	Disabled      bool       `json:"disabled"`
+	Since         *time.Time `json:"since,omitempty"`
+	ExceededSince *time.Time `json:"exceededSince,omitempty"`
+	FilterP99     float64    `json:"filterP99Ms"`
+	PrioritizeP99 float64    `json:"prioritizeP99Ms"`
+	Budget        string     `json:"budget"` // "0s" = no budget
+	Window        string     `json:"window"`
+}
+
+// InjectedSpike is a synthetic spike for the next spike check
+type InjectedSpike struct {
+	Class    string   `json:"class,omitempty"` // "" = traffic
+	Services []string `json:"services,omitempty"`
+}
+
+// SpikeInjection is the /admin/spike response
+type SpikeInjection struct {
+	Armed *InjectedSpike `json:"armed"` // nil once a spike check consumed it
+}
+
+// EpisodeRequest starts or ends an episode with an explicit ID
+type EpisodeRequest struct {
+	EpisodeID string   `json:"episodeId"`
+	Class     string   `json:"class,omitempty"` // "" = traffic (activate only)
+	Services  []string `json:"services,omitempty"`
+}
+
+// EpisodeResult is the /admin/activate and /admin/deactivate response
+type EpisodeResult struct {
+	EpisodeID string `json:"episodeId"`
+	Result    string `json:"result"` // created, already-active, deactivated, already-ended, conflict, unknown, backoff or failed
+	State     string `json:"state"`
+	Current   string `json:"current,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Version is the /version response: the build and its enabled subsystems
+type Version struct {
+	Version   string          `json:"version"`
+	GitSHA    string          `json:"gitSha"`
+	BuildDate string          `json:"buildDate"`
+	GoVersion string          `json:"goVersion"`
+	Features  map[string]bool `json:"features"`
+}
+
+// Status returns the current state of the instance
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Config returns the effective configuration of the instance
+func (c *Client) Config(ctx context.Context) (*Config, error) {
+	var cfg Config
+	if err := c.do(ctx, http.MethodGet, "/config", nil, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Episodes returns the recent spike episodes, newest first
+func (c *Client) Episodes(ctx context.Context) ([]Episode, error) {
+	var episodes []Episode
+	if err := c.do(ctx, http.MethodGet, "/episodes", nil, &episodes); err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// Decisions returns the recent Prioritize decisions, newest first, of one
+// episode ("" = all retained decisions)
+func (c *Client) Decisions(ctx context.Context, episodeID string) ([]Decision, error) {
+	path := "/decisions"
+	if episodeID != "" {
+		path += "?episode=" + url.QueryEscape(episodeID)
+	}
+	var decisions []Decision
+	if err := c.do(ctx, http.MethodGet, path, nil, &decisions); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// HistoryQuery filters the records read from the history store (zero
+// fields match everything; Limit 0 = the server default)
+type HistoryQuery struct {
+	Episode string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// path returns the endpoint with the query's parameters
+func (q HistoryQuery) path(endpoint string) string {
+	values := url.Values{}
+	if q.Episode != "" {
+		values.Set("episode", q.Episode)
+	}
+	if !q.Since.IsZero() {
+		values.Set("since", q.Since.UTC().Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		values.Set("until", q.Until.UTC().Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if len(values) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + values.Encode()
+}
+
+// StoredEpisodes returns episodes from the persistent history store,
+// newest first (HISTORY_STORE=true)
+func (c *Client) StoredEpisodes(ctx context.Context, q HistoryQuery) ([]Episode, error) {
+	var episodes []Episode
+	if err := c.do(ctx, http.MethodGet, q.path("/history/episodes"), nil, &episodes); err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// StoredDecisions returns decisions from the persistent history store,
+// newest first (HISTORY_STORE=true)
+func (c *Client) StoredDecisions(ctx context.Context, q HistoryQuery) ([]Decision, error) {
+	var decisions []Decision
+	if err := c.do(ctx, http.MethodGet, q.path("/history/decisions"), nil, &decisions); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// Sweep returns the influence sweep report
+func (c *Client) Sweep(ctx context.Context) (*Sweep, error) {
+	var sweep Sweep
+	if err := c.do(ctx, http.MethodGet, "/sweep", nil, &sweep); err != nil {
+		return nil, err
+	}
+	return &sweep, nil
+}
+
+// Shadow returns the shadow scorer report
+func (c *Client) Shadow(ctx context.Context) (*Shadow, error) {
+	var shadow Shadow
+	if err := c.do(ctx, http.MethodGet, "/shadow", nil, &shadow); err != nil {
+		return nil, err
+	}
+	return &shadow, nil
+}
+
+// Policies returns the loaded NexusPolicies by group
+func (c *Client) Policies(ctx context.Context) (map[string]Policy, error) {
+	var policies map[string]Policy
+	if err := c.do(ctx, http.MethodGet, "/policies", nil, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Version returns the build information and enabled subsystems of the instance
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if err := c.do(ctx, http.MethodGet, "/version", nil, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Simulate returns the Filter and Prioritize results NEXUS would produce
+// for a hypothetical pod now, without counting them or changing state
+func (c *Client) Simulate(ctx context.Context, req SimulationRequest) (*Simulation, error) {
+	var simulation Simulation
+	if err := c.do(ctx, http.MethodPost, "/simulate", req, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// Profiles lists the threshold profiles (admin token required)
+func (c *Client) Profiles(ctx context.Context) (*Profiles, error) {
+	var profiles Profiles
+	if err := c.do(ctx, http.MethodGet, "/admin/profiles", nil, &profiles); err != nil {
+		return nil, err
+	}
+	return &profiles, nil
+}
+
+// PinProfile pins a threshold profile, or clears the pin when name is ""
+// (admin token required)
+func (c *Client) PinProfile(ctx context.Context, name string) (*Profiles, error) {
+	method, body := http.MethodPut, interface{}(map[string]string{"name": name})
+	if name == "" {
+		method, body = http.MethodDelete, nil
+	}
+	var profiles Profiles
+	if err := c.do(ctx, method, "/admin/profiles/active", body, &profiles); err != nil {
+		return nil, err
+	}
+	return &profiles, nil
+}
+
+// Formation returns the gang formation strategies (admin token required)
+func (c *Client) Formation(ctx context.Context) (*Formation, error) {
+	var formation Formation
+	if err := c.do(ctx, http.MethodGet, "/admin/formation", nil, &formation); err != nil {
+		return nil, err
+	}
+	return &formation, nil
+}
+
+// PinFormation pins the gang formation strategy of the next episodes, or
+// clears the pin when name is "" (admin token required)
+func (c *Client) PinFormation(ctx context.Context, name string) (*Formation, error) {
+	method, body := http.MethodPut, interface{}(map[string]string{"name": name})
+	if name == "" {
+		method, body = http.MethodDelete, nil
+	}
+	var formation Formation
+	if err := c.do(ctx, method, "/admin/formation", body, &formation); err != nil {
+		return nil, err
+	}
+	return &formation, nil
+}
+
+// Backoff returns the activation flapping back-off (admin token required)
+func (c *Client) Backoff(ctx context.Context) (*Backoff, error) {
+	var backoff Backoff
+	if err := c.do(ctx, http.MethodGet, "/admin/backoff", nil, &backoff); err != nil {
+		return nil, err
+	}
+	return &backoff, nil
+}
+
+// ReEnable ends the activation flapping back-off (admin token required)
+func (c *Client) ReEnable(ctx context.Context) (*Backoff, error) {
+	var backoff Backoff
+	if err := c.do(ctx, http.MethodDelete, "/admin/backoff", nil, &backoff); err != nil {
+		return nil, err
+	}
+	return &backoff, nil
+}
+
+// Latency returns the latency budget self-disable state (admin token required)
+func (c *Client) Latency(ctx context.Context) (*LatencyGuard, error) {
+	var guard LatencyGuard
+	if err := c.do(ctx, http.MethodGet, "/admin/latency", nil, &guard); err != nil {
+		return nil, err
+	}
+	return &guard, nil
+}
+
+// Resume re-enables NEXUS after a latency self-disable (admin token required)
+func (c *Client) Resume(ctx context.Context) (*LatencyGuard, error) {
+	var guard LatencyGuard
+	if err := c.do(ctx, http.MethodDelete, "/admin/latency", nil, &guard); err != nil {
+		return nil, err
+	}
+	return &guard, nil
+}
+
+// InjectSpike arms a synthetic spike that the next spike check reports
+// (admin token required)
+func (c *Client) InjectSpike(ctx context.Context, spike InjectedSpike) (*SpikeInjection, error) {
+	var injection SpikeInjection
+	if err := c.do(ctx, http.MethodPost, "/admin/spike", spike, &injection); err != nil {
+		return nil, err
+	}
+	return &injection, nil
+}
+
+// Activate starts the episode unless it is running or ended already (admin
+// token required). Refused requests (conflict, unknown, backoff, failed)
+// return their result together with the *StatusError.
+func (c *Client) Activate(ctx context.Context, req EpisodeRequest) (*EpisodeResult, error) {
+	return c.episode(ctx, "/admin/activate", req)
+}
+
+// Deactivate ends the running episode with the ID (admin token required)
+func (c *Client) Deactivate(ctx context.Context, episodeID string) (*EpisodeResult, error) {
+	return c.episode(ctx, "/admin/deactivate", EpisodeRequest{EpisodeID: episodeID})
+}
+
+// episode sends an admin episode request, decoding the result of refused
+// requests from the error body
+func (c *Client) episode(ctx context.Context, path string, req EpisodeRequest) (*EpisodeResult, error) {
+	var result EpisodeResult
+	err := c.do(ctx, http.MethodPost, path, req, &result)
+	if statusErr, ok := err.(*StatusError); ok {
+		if json.Unmarshal([]byte(statusErr.Body), &result) != nil {
+			return nil, err
+		}
+		return &result, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Snapshot returns the sanitized cluster snapshot as raw JSON, to be saved
+// for nexus-scheduler simulate (admin token required)
+func (c *Client) Snapshot(ctx context.Context) (json.RawMessage, error) {
+	var snapshot json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/admin/snapshot", nil, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// do sends a request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding %s %s: %w", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.adminToken != "" && strings.HasPrefix(path, "/admin/") {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &StatusError{Method: method, Path: path, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nexus-scheduler/pkg/client"
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/extender"
+)
+
+// newTestServer serves the observability and admin endpoints of an IDLE extender
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	cfg := config.LoadConfig()
+	cfg.StateRecovery = false
+	cfg.KEDATrigger = false
+	cfg.UtilizationScoring = false
+	cfg.AdminToken = "secret"
+
+	mux := http.NewServeMux()
+	extender.NewNEXUSScheduler(fake.NewSimpleClientset(), nil, cfg).RegisterObservabilityHandlers(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	c := client.New(srv.URL+"/", "secret")
+	ctx := context.Background()
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "IDLE" || status.Influence != 1 {
+		t.Errorf("status = %+v, want IDLE with influence 1", status)
+	}
+
+	cfg, err := c.Config(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Env["ADMIN_TOKEN"] == "secret" {
+		t.Error("config exposes the admin token")
+	}
+
+	episodes, err := c.Episodes(ctx)
+	if err != nil || len(episodes) != 0 {
+		t.Errorf("episodes = %v, %v; want none", episodes, err)
+	}
+	decisions, err := c.Decisions(ctx, "ep-1")
+	if err != nil || len(decisions) != 0 {
+		t.Errorf("decisions = %v, %v; want none", decisions, err)
+	}
+	if sweep, err := c.Sweep(ctx); err != nil || sweep.Enabled {
+		t.Errorf("sweep = %+v, %v; want disabled", sweep, err)
+	}
+	if shadow, err := c.Shadow(ctx); err != nil || shadow.Enabled {
+		t.Errorf("shadow = %+v, %v; want disabled", shadow, err)
+	}
+	if policies, err := c.Policies(ctx); err != nil || len(policies) != 0 {
+		t.Errorf("policies = %v, %v; want none", policies, err)
+	}
+	if version, err := c.Version(ctx); err != nil || version.GoVersion == "" || !version.Features["gang_member_cache"] {
+		t.Errorf("version = %+v, %v; want the build and the member cache enabled", version, err)
+	}
+
+	profiles, err := c.Profiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profiles.Active == "" || len(profiles.Profiles) == 0 {
+		t.Errorf("profiles = %+v, want the default profile", profiles)
+	}
+	if profiles, err = c.PinProfile(ctx, profiles.Active); err != nil || profiles.Pinned == "" {
+		t.Errorf("pin = %+v, %v; want a pinned profile", profiles, err)
+	}
+	if profiles, err = c.PinProfile(ctx, ""); err != nil || profiles.Pinned != "" {
+		t.Errorf("unpin = %+v, %v; want no pin", profiles, err)
+	}
+
+	if backoff, err := c.ReEnable(ctx); err != nil || backoff.Active || backoff.MaxActivations == 0 {
+		t.Errorf("re-enable = %+v, %v; want no back-off", backoff, err)
+	}
+	if guard, err := c.Resume(ctx); err != nil || guard.Disabled || guard.Budget == "0s" {
+		t.Errorf("resume = %+v, %v; want enabled with a budget", guard, err)
+	}
+
+	injection, err := c.InjectSpike(ctx, client.InjectedSpike{Class: "latency", Services: []string{"checkoutservice"}})
+	if err != nil || injection.Armed == nil || injection.Armed.Class != "latency" {
+		t.Errorf("inject = %+v, %v; want an armed latency spike", injection, err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	var statusErr *client.StatusError
+	if _, err := client.New(srv.URL, "wrong").Profiles(ctx); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong token: err = %v, want 401", err)
+	}
+	if _, err := client.New(srv.URL, "secret").PinProfile(ctx, "no-such-profile"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown profile: err = %v, want 404", err)
+	}
+	if result, err := client.New(srv.URL, "secret").Deactivate(ctx, "run-1"); !errors.As(err, &statusErr) || result == nil || result.Result != "unknown" {
+		t.Errorf("unknown episode: result = %+v, err = %v; want 404 with an unknown result", result, err)
+	}
+}
@@ -0,0 +1,1017 @@
+/*
+NEXUS Configuration
+===================
+Runtime configuration for the extender. Every knob is read from an
+environment variable (set in deployment.yaml) with a research-safe
+default, so experiment runs can vary settings without rebuilding.
+
+Each field is tagged with its environment variable; GET /config reports
+the resolved values under those names (secrets redacted) together with
+any validation warnings, so a run can record the exact configuration
+that produced its results.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// Config holds the resolved runtime configuration
+type Config struct {
+	// Client-side rate limiting for the Kubernetes API client
+	KubeAPIQPS   float32 `env:"KUBE_API_QPS"`
+	KubeAPIBurst int     `env:"KUBE_API_BURST"`
+
+	// Exponential backoff for retriable Kubernetes API errors
+	APIRetrySteps          int           `env:"KUBE_API_RETRY_STEPS"`
+	APIRetryInitialBackoff time.Duration `env:"KUBE_API_RETRY_INITIAL_BACKOFF"`
+	APIRetryMaxBackoff     time.Duration `env:"KUBE_API_RETRY_MAX_BACKOFF"`
+
+	// Circuit breaker around Kubernetes API calls
+	APIBreakerThreshold int           `env:"KUBE_API_BREAKER_THRESHOLD"` // consecutive failures before opening
+	APIBreakerCooldown  time.Duration `env:"KUBE_API_BREAKER_COOLDOWN"`  // how long the breaker stays open
+
+	// Consecutive 401s before the API client transport is rebuilt from
+	// reloaded credentials (0 = never rebuild)
+	APIAuthRebuildAfter int `env:"KUBE_API_AUTH_REBUILD_AFTER"`
+
+	// Memory budget for ephemeral state (0 = unlimited)
+	MaxPodsConsidered int `env:"MAX_PODS_CONSIDERED"` // pods read per graph build / member count
+	MaxGangs          int `env:"MAX_GANGS"`           // gangs formed per spike episode
+	MaxNodesScanned   int `env:"MAX_NODES_SCANNED"`   // nodes evaluated per Filter/Prioritize call
+	ListPageSize      int `env:"LIST_PAGE_SIZE"`      // page size for paginated List calls
+
+	// Node scoring split across goroutines by node-name hash (1 = sequential),
+	// once a call has at least ScoringShardMinNodes candidate nodes
+	ScoringShards        int `env:"SCORING_SHARDS"`
+	ScoringShardMinNodes int `env:"SCORING_SHARD_MIN_NODES"`
+
+	// Node label/annotation key whose value "true" keeps NEXUS off the node
+	NodeExcludeLabel string `env:"NODE_EXCLUDE_LABEL"` // "" = no exclusion
+
+	// How long a node's last read gang member count may stand in for a
+	// throttled or failed pod list (0 = no fallback)
+	LastGoodMaxAge time.Duration `env:"SCORE_LAST_GOOD_MAX_AGE"`
+
+	// Activation state recovery across restarts
+	Namespace        string        `env:"POD_NAMESPACE"`          // namespace NEXUS runs in
+	StateRecovery    bool          `env:"STATE_RECOVERY_ENABLED"` // persist/restore the activation record
+	StateConfigMap   string        `env:"STATE_CONFIGMAP"`        // ConfigMap holding the activation record
+	StateRecoveryAge time.Duration `env:"STATE_RECOVERY_MAX_AGE"` // records older than this are discarded
+
+	// Dependency graph scope: "cluster" scans every pod, "spike" only the
+	// spiking services and their transitive dependencies
+	GraphScope        string `env:"GRAPH_SCOPE"`
+	GraphServiceLabel string `env:"GRAPH_SERVICE_LABEL"` // pod label holding the service name
+
+	// Group members NetworkPolicies cut off from every other member:
+	// "off", "warn" (logged and reported) or "enforce" (also removed)
+	GraphNetworkPolicy string `env:"GRAPH_NETWORK_POLICY"`
+
+	// Also read dependencies and groups from Istio VirtualServices and
+	// DestinationRules
+	GraphIstio bool `env:"GRAPH_ISTIO"`
+
+	// Application profiles as JSON, matched to pods by namespace and labels
+	// ("" = the embedded Online Boutique profile, see pkg/graph/profiles.go)
+	AppProfiles string `env:"APP_PROFILES"`
+
+	// Transitive dependency closure: depends-on hops pulled into a gang
+	// (0 = annotated services only, 1 = direct dependencies, ...)
+	DependencyDepth int `env:"DEPENDENCY_DEPTH"`
+
+	// Per-decision score breakdown output: "off", "header" or "log"
+	ScoreDebug string `env:"SCORE_DEBUG"`
+
+	// Per-decision Filter/Prioritize log lines: 1 in DecisionLogSample,
+	// at most DecisionLogRate per second (0 = no rate limit)
+	DecisionLogSample int     `env:"DECISION_LOG_SAMPLE"`
+	DecisionLogRate   float64 `env:"DECISION_LOG_RATE"`
+
+	// Order among nodes with equal Prioritize scores: "name", "hash"
+	// (pod+node) or "random" (seeded, for controlled variance studies)
+	ScoreTieBreak     string `env:"SCORE_TIE_BREAK"`
+	ScoreTieBreakSeed int64  `env:"SCORE_TIE_BREAK_SEED"`
+
+	// KEDA ScaledObject activity as an activation trigger
+	KEDATrigger   bool   `env:"KEDA_TRIGGER_ENABLED"`
+	KEDANamespace string `env:"KEDA_NAMESPACE"` // "" = all namespaces
+
+	// NexusPolicy custom resources applied to gangs at formation
+	NexusPolicies        bool   `env:"NEXUS_POLICIES"`
+	NexusPolicyNamespace string `env:"NEXUS_POLICY_NAMESPACE"` // "" = all namespaces
+
+	// Coscheduling plugin PodGroups for the active gangs
+	PodGroups       bool          `env:"COSCHEDULING_PODGROUPS"`
+	PodGroupLabel   string        `env:"COSCHEDULING_POD_GROUP_LABEL"`  // pod label naming the PodGroup
+	PodGroupTimeout time.Duration `env:"COSCHEDULING_SCHEDULE_TIMEOUT"` // PodGroup scheduleTimeoutSeconds
+
+	// Gang label mutating webhook (served over TLS on its own port)
+	WebhookEnabled  bool   `env:"WEBHOOK_ENABLED"`
+	WebhookAddr     string `env:"WEBHOOK_ADDR"`
+	WebhookCertFile string `env:"WEBHOOK_CERT_FILE"`
+	WebhookKeyFile  string `env:"WEBHOOK_KEY_FILE"`
+
+	// nexus.io annotation validating webhook (same certificate, own port)
+	ValidationWebhookEnabled bool   `env:"VALIDATION_WEBHOOK_ENABLED"`
+	ValidationWebhookAddr    string `env:"VALIDATION_WEBHOOK_ADDR"`
+
+	// Influence pods created before activation (default: new replicas only)
+	InfluencePreexisting bool `env:"INFLUENCE_PREEXISTING_PODS"`
+
+	// Per-episode influence budget: pods influenced per gang (0 = unlimited)
+	MaxInfluencedPods int `env:"MAX_INFLUENCED_PODS_PER_GANG"`
+
+	// Locality scoring curve (hotspot avoidance)
+	LocalityWeight    float64 `env:"LOCALITY_WEIGHT"`     // points per member on the linear curve
+	LocalityCurve     string  `env:"LOCALITY_CURVE"`      // "linear", "sqrt" or "log"
+	LocalityMemberCap int     `env:"LOCALITY_MEMBER_CAP"` // members beyond this add no score (0 = uncapped)
+
+	// Network topology levels (node label key + locality weight), nearest first
+	TopologyLevels []TopologyLevel `env:"TOPOLOGY_LOCALITY_LEVELS"`
+
+	// Alternative scoring formulas evaluated in shadow on every Prioritize
+	// call, keyed by name (empty = off)
+	ShadowScorers map[string]ShadowScorer `env:"SHADOW_SCORERS"`
+
+	// Post-spike drain: keep a reduced locality preference before going IDLE
+	DrainDuration      time.Duration `env:"DRAIN_DURATION"`       // 0 = dissolve immediately
+	DrainLocalityScale float64       `env:"DRAIN_LOCALITY_SCALE"` // locality multiplier while draining
+
+	// Activation flapping back-off: more than FlapMaxActivations within
+	// FlapWindow suppresses activation until FlapStabilization passes
+	// without a spike (0 = manual re-enable only)
+	FlapMaxActivations int           `env:"FLAP_MAX_ACTIVATIONS"` // 0 = no back-off
+	FlapWindow         time.Duration `env:"FLAP_WINDOW"`
+	FlapStabilization  time.Duration `env:"FLAP_STABILIZATION"`
+
+	// Spike window extensions: a still-detected spike extends the episode
+	// only while a fired signal reaches ExtensionThresholdScale times
+	// its threshold, at most SpikeMaxExtensions times per episode
+	SpikeMaxExtensions      int     `env:"SPIKE_MAX_EXTENSIONS"` // 0 = unlimited
+	ExtensionThresholdScale float64 `env:"SPIKE_EXTENSION_THRESHOLD_SCALE"`
+
+	// Latency budget: a Filter or Prioritize p99 above LatencyBudget for
+	// LatencyBudgetWindow makes NEXUS answer with no opinion until re-enabled
+	LatencyBudget       time.Duration `env:"LATENCY_BUDGET"` // 0 = no budget
+	LatencyBudgetWindow time.Duration `env:"LATENCY_BUDGET_WINDOW"`
+
+	// Spike check cycle: detection budget and random start delay
+	SpikeCheckTimeout time.Duration `env:"SPIKE_CHECK_TIMEOUT"` // 0 = no budget
+	SpikeCheckJitter  time.Duration `env:"SPIKE_CHECK_JITTER"`  // 0 = check on the tick
+
+	// Admin API bearer token ("" = admin API disabled)
+	AdminToken string `env:"ADMIN_TOKEN" secret:"true"`
+
+	// Observed node utilization penalty (metrics-server)
+	UtilizationScoring       bool          `env:"UTILIZATION_SCORING"`
+	UtilizationPenaltyWeight float64       `env:"UTILIZATION_PENALTY_WEIGHT"` // points removed at 100% usage
+	UtilizationCacheTTL      time.Duration `env:"UTILIZATION_CACHE_TTL"`      // how long node usage is reused
+
+	// Points for nodes holding all of the pod's container images (0 = off)
+	ImageLocalityWeight float64 `env:"IMAGE_LOCALITY_WEIGHT"`
+
+	// Points removed from pinned-CPU pods on full NUMA-aligned nodes (0 = off)
+	NUMAAlignmentWeight float64 `env:"NUMA_ALIGNMENT_WEIGHT"`
+
+	// VPA target recommendations in the Filter resource-fit check
+	VPARecommendations bool          `env:"VPA_RECOMMENDATIONS"`
+	VPACacheTTL        time.Duration `env:"VPA_CACHE_TTL"` // how long recommendations are reused
+
+	// Resync scoring caches and seed the gangs' node tables on activation
+	ActivationPrewarm bool `env:"ACTIVATION_PREWARM"`
+
+	// Gang cooldown lower-bounded by the members' HPA scale-down stabilization
+	HPAStabilizationCooldown bool   `env:"HPA_STABILIZATION_COOLDOWN"`
+	HPANamespace             string `env:"HPA_NAMESPACE"` // "" = all namespaces
+
+	// Descheduler protection annotations on active gang members
+	EvictionProtection            bool              `env:"EVICTION_PROTECTION"`
+	EvictionProtectionAnnotations map[string]string `env:"EVICTION_PROTECTION_ANNOTATIONS"`
+
+	// nexus.io/gang and nexus.io/episode labels on gang-member pods
+	GangMemberLabels bool `env:"GANG_MEMBER_LABELS"`
+
+	// Soft pod affinity hints on gang-member Deployments (annotation-only arm)
+	AffinityHints         bool   `env:"AFFINITY_HINTS"`
+	AffinityHintWeight    int    `env:"AFFINITY_HINT_WEIGHT"`    // preferred podAffinity weight, 1-100
+	AffinityHintNamespace string `env:"AFFINITY_HINT_NAMESPACE"` // "" = all namespaces
+
+	// HTTP listeners: scheduling path and observability/admin (same address = one listener)
+	ExtenderAddr         string        `env:"EXTENDER_ADDR"`
+	ExtenderReadTimeout  time.Duration `env:"EXTENDER_READ_TIMEOUT"`
+	ExtenderWriteTimeout time.Duration `env:"EXTENDER_WRITE_TIMEOUT"`
+	ExtenderGzip         bool          `env:"EXTENDER_GZIP"` // gzip responses for clients accepting it
+	ExtenderKeepAlive    bool          `env:"EXTENDER_KEEP_ALIVE"`
+	ExtenderIdleTimeout  time.Duration `env:"EXTENDER_IDLE_TIMEOUT"`    // keep-alive connection idle limit
+	ExtenderMaxConns     int           `env:"EXTENDER_MAX_CONNECTIONS"` // 0 = unlimited
+	AdminAddr            string        `env:"ADMIN_ADDR"`
+	AdminReadTimeout     time.Duration `env:"ADMIN_READ_TIMEOUT"`
+	AdminWriteTimeout    time.Duration `env:"ADMIN_WRITE_TIMEOUT"`
+
+	// Synthetic Filter calls to the extender listener (interval 0 = off)
+	SelfProbeInterval time.Duration `env:"SELF_PROBE_INTERVAL"`
+	SelfProbeTimeout  time.Duration `env:"SELF_PROBE_TIMEOUT"`
+	SelfProbeURL      string        `env:"SELF_PROBE_URL"` // "" = loopback to EXTENDER_ADDR
+
+	// Filter out nodes without gang members while a member node fits the pod
+	GangFilterStrict bool `env:"GANG_FILTER_STRICT"`
+
+	// Start each episode ADVISORY (Prioritize only, Filter keeps every
+	// node) and escalate to ENFORCING on a trigger at or above its
+	// signal's multiple of the threshold
+	GradedActivation    bool               `env:"GRADED_ACTIVATION"`
+	EnforcingThresholds map[string]float64 `env:"ENFORCING_THRESHOLDS"`
+
+	// Gang policy per spike class, keyed "<class>" or "<group>/<class>"
+	SpikeClassPolicies map[string]SpikeClassPolicy `env:"SPIKE_CLASS_POLICIES"`
+
+	// Gang formation strategy for new episodes, and K for "top-k"
+	GangFormationStrategy string `env:"GANG_FORMATION_STRATEGY"`
+	GangTopK              int    `env:"GANG_TOP_K"`
+
+	// Resolution of services declared in several coordination groups
+	GroupOverlap string `env:"GROUP_OVERLAP"`
+
+	// Score multiplier of a gang on nodes a higher-priority gang claims
+	ArbitrationYieldScale float64 `env:"ARBITRATION_YIELD_SCALE"`
+
+	// Gang member placement: steer by current member counts ("greedy") or
+	// toward a bin-packing plan computed at formation ("planned"), sized for
+	// GangPlanScale × the current replicas of each member
+	GangPlacement string  `env:"GANG_PLACEMENT"`
+	GangPlanScale float64 `env:"GANG_PLAN_SCALE"`
+
+	// Filter of a pod whose gang has members on no node yet: every node,
+	// the schedulable nodes, or the placement plan's first node
+	FreshGangPlacement string `env:"FRESH_GANG_PLACEMENT"`
+
+	// Count gang members per node from a pod list/watch started at formation
+	// instead of listing pods on the Filter/Prioritize path
+	GangMemberCache bool `env:"GANG_MEMBER_CACHE"`
+
+	// Watch nodes during episodes; members on NotReady nodes stop counting
+	// and placement plans are recomputed
+	NodeFailureWatch bool `env:"NODE_FAILURE_WATCH"`
+
+	// Watch kube-scheduler's Scheduled events and record gang-member pod
+	// creation to binding latency
+	SchedulingLatencyWatch bool `env:"SCHEDULING_LATENCY_WATCH"`
+
+	// Prioritize scoring of pods outside every gang during an episode; the
+	// nexus.io/fallback pod template annotation overrides it per deployment
+	NonGangFallback string `env:"NON_GANG_FALLBACK"`
+
+	// Influence sweep experiment: Prioritize scores are scaled by one factor
+	// per episode, cycling through the list (empty = off)
+	WeightSweep []float64 `env:"WEIGHT_SWEEP"`
+
+	// Per-group latency SLOs tracked during spike episodes
+	SLODefaultP95 float64 `env:"SLO_DEFAULT_P95_MS"` // target for groups without nexus.io/slo-p95-ms (0 = untracked)
+	SLOObjective  float64 `env:"SLO_OBJECTIVE"`      // share of episode time the target must hold
+
+	// Per-decision CSV export for offline analysis ("off" or "csv")
+	DecisionExport       string        `env:"DECISION_EXPORT"`
+	DecisionExportDir    string        `env:"DECISION_EXPORT_DIR"`    // mounted volume receiving the files
+	DecisionExportRotate time.Duration `env:"DECISION_EXPORT_ROTATE"` // how long each file is written to
+	DecisionExportBuffer int           `env:"DECISION_EXPORT_BUFFER"` // decisions queued before dropping
+
+	// Embedded episode and decision store beyond the in-memory history
+	// (see pkg/store), pruned by age and total size
+	HistoryStore          bool          `env:"HISTORY_STORE"`
+	HistoryStoreDir       string        `env:"HISTORY_STORE_DIR"`       // mounted volume holding the segments
+	HistoryStoreSegment   time.Duration `env:"HISTORY_STORE_SEGMENT"`   // how long each segment is written to
+	HistoryStoreRetention time.Duration `env:"HISTORY_STORE_RETENTION"` // segments older than this are deleted (0 = no limit)
+	HistoryStoreMaxMB     int           `env:"HISTORY_STORE_MAX_MB"`    // total size kept (0 = no limit)
+	HistoryStoreBuffer    int           `env:"HISTORY_STORE_BUFFER"`    // entries queued before dropping
+
+	// Counter snapshot file continuing counters across restarts ("" = off)
+	MetricsSnapshotPath     string        `env:"METRICS_SNAPSHOT_PATH"`
+	MetricsSnapshotInterval time.Duration `env:"METRICS_SNAPSHOT_INTERVAL"`
+
+	// Renamed metric families also served under their old names
+	MetricsLegacyNames bool `env:"METRICS_LEGACY_NAMES"`
+
+	// Optional upload of rotated export files to an S3-compatible bucket
+	DecisionExportS3Endpoint  string `env:"DECISION_EXPORT_S3_ENDPOINT"` // "" = keep files on the volume
+	DecisionExportS3Bucket    string `env:"DECISION_EXPORT_S3_BUCKET"`
+	DecisionExportS3Prefix    string `env:"DECISION_EXPORT_S3_PREFIX"`
+	DecisionExportS3Region    string `env:"DECISION_EXPORT_S3_REGION"`
+	DecisionExportS3AccessKey string `env:"DECISION_EXPORT_S3_ACCESS_KEY"`
+	DecisionExportS3SecretKey string `env:"DECISION_EXPORT_S3_SECRET_KEY" secret:"true"`
+
+	// Spike episodes exported as OTLP/HTTP traces ("" = off)
+	OTLPEndpoint    string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"` // e.g. http://tempo.monitoring:4318
+	OTLPServiceName string `env:"OTEL_SERVICE_NAME"`
+
+	// Extender node format kube-scheduler is configured to send: "auto", "nodes" or "nodenames"
+	ExtenderProtocol string `env:"EXTENDER_PROTOCOL"`
+
+	// Answer to Filter calls NEXUS cannot evaluate: "fail-open" or "fail-closed"
+	ExtenderErrorPolicy string `env:"EXTENDER_ERROR_POLICY"`
+
+	// Pod schedulerName values (scheduler profiles) NEXUS influences (empty = all)
+	SchedulerNames []string `env:"SCHEDULER_NAMES"`
+
+	// Services that may become gang members (empty = all) and services that
+	// never do, enforced at graph build and gang formation
+	GangServiceAllowlist []string `env:"GANG_SERVICE_ALLOWLIST"`
+	GangServiceDenylist  []string `env:"GANG_SERVICE_DENYLIST"`
+
+	// Pods NEXUS never influences: system namespaces, critical priority
+	// classes and pods matching the exclusion label selector
+	SystemNamespaces         []string `env:"SYSTEM_NAMESPACES"`
+	SystemPriorityClasses    []string `env:"SYSTEM_PRIORITY_CLASSES"`
+	InfluenceExcludeSelector string   `env:"INFLUENCE_EXCLUDE_SELECTOR"` // "" = none
+}
+
+// TopologyLevel is a node label key (e.g. "rack") and the fraction of a
+// co-located member's locality credited to members in the same domain
+type TopologyLevel struct {
+	Key    string  `json:"key"`
+	Weight float64 `json:"weight"`
+}
+
+// SpikeClassPolicy adjusts gang placement for one class of spike
+type SpikeClassPolicy struct {
+	LocalityScale float64 `json:"localityScale,omitempty"` // locality multiplier (0 = unset = 1)
+	Spread        bool    `json:"spread,omitempty"`        // prefer nodes with fewer gang members
+}
+
+// ShadowScorer overrides scoring knobs for one shadow formula; unset
+// fields keep the primary's value
+type ShadowScorer struct {
+	LocalityWeight    *float64 `json:"localityWeight,omitempty"`
+	LocalityCurve     string   `json:"localityCurve,omitempty"`
+	LocalityMemberCap *int     `json:"localityMemberCap,omitempty"`
+	UtilizationWeight *float64 `json:"utilizationWeight,omitempty"`
+}
+
+// spikeClasses are the detector's spike class names
+var spikeClasses = []string{"traffic", "latency", "error"}
+
+// enforcingSignals are the trigger signals ENFORCING_THRESHOLDS accepts
+var enforcingSignals = []string{"qps", "errors", "p95", "hpa", "pending_pods", "keda", "injected"}
+
+// defaultEnforcingThresholds escalate on errors or p95 at twice their
+// thresholds, QPS at three times, three HPA replicas added, and injected
+// spikes; the pending-pod fallback and KEDA stay advisory
+var defaultEnforcingThresholds = map[string]float64{"errors": 2, "p95": 2, "qps": 3, "hpa": 3, "injected": 0}
+
+// Dependency graph scopes
+const (
+	GraphScopeCluster = "cluster"
+	GraphScopeSpike   = "spike"
+)
+
+// NetworkPolicy checks of coordination groups
+const (
+	NetworkPolicyOff     = "off"
+	NetworkPolicyWarn    = "warn"
+	NetworkPolicyEnforce = "enforce"
+)
+
+// Score breakdown output modes
+const (
+	ScoreDebugOff    = "off"
+	ScoreDebugHeader = "header"
+	ScoreDebugLog    = "log"
+)
+
+// Score tie-breakers
+const (
+	TieBreakName   = "name"
+	TieBreakHash   = "hash"
+	TieBreakRandom = "random"
+)
+
+// Extender node formats (nodeCacheCapable false → nodes, true → nodenames)
+const (
+	ExtenderProtocolAuto      = "auto"
+	ExtenderProtocolNodes     = "nodes"
+	ExtenderProtocolNodeNames = "nodenames"
+)
+
+// Extender error policies
+const (
+	ErrorPolicyFailOpen   = "fail-open"   // keep every candidate node, report the error in a header
+	ErrorPolicyFailClosed = "fail-closed" // populate the Filter result's error field
+)
+
+// Decision export formats
+const (
+	DecisionExportOff = "off"
+	DecisionExportCSV = "csv"
+)
+
+// Gang formation strategies
+const (
+	GangFormationPerGroup     = "per-group"
+	GangFormationMerged       = "merged"
+	GangFormationCriticalPath = "critical-path"
+	GangFormationTopK         = "top-k"
+)
+
+// Group overlap resolutions
+const (
+	GroupOverlapMerge    = "merge"    // merge the overlapping groups into one gang
+	GroupOverlapPriority = "priority" // keep the shared service in the highest-priority group
+	GroupOverlapError    = "error"    // form no gang from the overlapping groups
+)
+
+// Gang member placement modes
+const (
+	GangPlacementGreedy  = "greedy"
+	GangPlacementPlanned = "planned"
+)
+
+// Filter policies for gangs starting fresh (no node has members yet)
+const (
+	FreshPlacementAllNodes    = "all-nodes"       // every node passes, unchecked
+	FreshPlacementSchedulable = "schedulable"     // nodes that can host the pod
+	FreshPlacementPlanFirst   = "plan-first-node" // the plan's first node, else schedulable
+)
+
+// Scoring of pods outside every gang during an episode
+const (
+	FallbackNoOpinion = "no-opinion" // equal scores
+	FallbackSpread    = "spread"     // away from the nodes holding gang capacity
+	FallbackResource  = "resource"   // resource and utilization components only
+)
+
+// Locality scoring curves
+const (
+	LocalityCurveLinear = "linear"
+	LocalityCurveSqrt   = "sqrt"
+	LocalityCurveLog    = "log"
+)
+
+// LoadConfig reads the configuration from environment variables
+func LoadConfig() *Config {
+	cfg := &Config{
+		KubeAPIQPS:               float32(envFloat("KUBE_API_QPS", 10)),
+		KubeAPIBurst:             envInt("KUBE_API_BURST", 20),
+		APIRetrySteps:            envInt("KUBE_API_RETRY_STEPS", 4),
+		APIRetryInitialBackoff:   envDuration("KUBE_API_RETRY_INITIAL_BACKOFF", 100*time.Millisecond),
+		APIRetryMaxBackoff:       envDuration("KUBE_API_RETRY_MAX_BACKOFF", 2*time.Second),
+		APIBreakerThreshold:      envInt("KUBE_API_BREAKER_THRESHOLD", 5),
+		APIBreakerCooldown:       envDuration("KUBE_API_BREAKER_COOLDOWN", 30*time.Second),
+		APIAuthRebuildAfter:      envInt("KUBE_API_AUTH_REBUILD_AFTER", 3),
+		MaxPodsConsidered:        envInt("MAX_PODS_CONSIDERED", 5000),
+		MaxGangs:                 envInt("MAX_GANGS", 20),
+		MaxNodesScanned:          envInt("MAX_NODES_SCANNED", 500),
+		ListPageSize:             envInt("LIST_PAGE_SIZE", 500),
+		ScoringShards:            envInt("SCORING_SHARDS", 1),
+		ScoringShardMinNodes:     envInt("SCORING_SHARD_MIN_NODES", 200),
+		NodeExcludeLabel:         envString("NODE_EXCLUDE_LABEL", "nexus.io/exclude"),
+		LastGoodMaxAge:           envDuration("SCORE_LAST_GOOD_MAX_AGE", 2*time.Minute),
+		Namespace:                envString("POD_NAMESPACE", "nexus-system"),
+		StateRecovery:            envBool("STATE_RECOVERY_ENABLED", true),
+		StateConfigMap:           envString("STATE_CONFIGMAP", "nexus-activation-state"),
+		StateRecoveryAge:         envDuration("STATE_RECOVERY_MAX_AGE", 10*time.Minute),
+		GraphScope:               envString("GRAPH_SCOPE", GraphScopeCluster),
+		GraphServiceLabel:        envString("GRAPH_SERVICE_LABEL", "app"),
+		GraphNetworkPolicy:       envString("GRAPH_NETWORK_POLICY", NetworkPolicyOff),
+		GraphIstio:               envBool("GRAPH_ISTIO", false),
+		AppProfiles:              os.Getenv("APP_PROFILES"),
+		DependencyDepth:          envInt("DEPENDENCY_DEPTH", 1),
+		ScoreDebug:               envString("SCORE_DEBUG", ScoreDebugOff),
+		DecisionLogSample:        envInt("DECISION_LOG_SAMPLE", 1),
+		DecisionLogRate:          envFloat("DECISION_LOG_RATE", 20),
+		ScoreTieBreak:            envString("SCORE_TIE_BREAK", TieBreakHash),
+		ScoreTieBreakSeed:        int64(envInt("SCORE_TIE_BREAK_SEED", 1)),
+		KEDATrigger:              envBool("KEDA_TRIGGER_ENABLED", false),
+		KEDANamespace:            os.Getenv("KEDA_NAMESPACE"),
+		NexusPolicies:            envBool("NEXUS_POLICIES", false),
+		NexusPolicyNamespace:     os.Getenv("NEXUS_POLICY_NAMESPACE"),
+		WebhookEnabled:           envBool("WEBHOOK_ENABLED", false),
+		WebhookAddr:              envString("WEBHOOK_ADDR", ":9443"),
+		WebhookCertFile:          envString("WEBHOOK_CERT_FILE", "/etc/nexus/webhook/tls.crt"),
+		WebhookKeyFile:           envString("WEBHOOK_KEY_FILE", "/etc/nexus/webhook/tls.key"),
+		ValidationWebhookEnabled: envBool("VALIDATION_WEBHOOK_ENABLED", false),
+		ValidationWebhookAddr:    envString("VALIDATION_WEBHOOK_ADDR", ":9444"),
+		InfluencePreexisting:     envBool("INFLUENCE_PREEXISTING_PODS", false),
+		MaxInfluencedPods:        envInt("MAX_INFLUENCED_PODS_PER_GANG", 0),
+		LocalityWeight:           envFloat("LOCALITY_WEIGHT", 100),
+		LocalityCurve:            envString("LOCALITY_CURVE", LocalityCurveLinear),
+		LocalityMemberCap:        envInt("LOCALITY_MEMBER_CAP", 0),
+		TopologyLevels:           envTopologyLevels("TOPOLOGY_LOCALITY_LEVELS"),
+		ShadowScorers:            envShadowScorers("SHADOW_SCORERS"),
+		DrainDuration:            envDuration("DRAIN_DURATION", 0),
+		DrainLocalityScale:       envFloat("DRAIN_LOCALITY_SCALE", 0.3),
+		FlapMaxActivations:       envInt("FLAP_MAX_ACTIVATIONS", 5),
+		FlapWindow:               envDuration("FLAP_WINDOW", 10*time.Minute),
+		FlapStabilization:        envDuration("FLAP_STABILIZATION", 10*time.Minute),
+		SpikeMaxExtensions:       envInt("SPIKE_MAX_EXTENSIONS", 10),
+		ExtensionThresholdScale:  envFloat("SPIKE_EXTENSION_THRESHOLD_SCALE", 1.2),
+		LatencyBudget:            envDuration("LATENCY_BUDGET", 50*time.Millisecond),
+		LatencyBudgetWindow:      envDuration("LATENCY_BUDGET_WINDOW", 5*time.Minute),
+		SpikeCheckTimeout:        envDuration("SPIKE_CHECK_TIMEOUT", 8*time.Second),
+		SpikeCheckJitter:         envDuration("SPIKE_CHECK_JITTER", time.Second),
+		AdminToken:               os.Getenv("ADMIN_TOKEN"),
+		UtilizationScoring:       envBool("UTILIZATION_SCORING", false),
+		UtilizationPenaltyWeight: envFloat("UTILIZATION_PENALTY_WEIGHT", 150),
+		UtilizationCacheTTL:      envDuration("UTILIZATION_CACHE_TTL", 15*time.Second),
+		ImageLocalityWeight:      envFloat("IMAGE_LOCALITY_WEIGHT", 0),
+		NUMAAlignmentWeight:      envFloat("NUMA_ALIGNMENT_WEIGHT", 0),
+		VPARecommendations:       envBool("VPA_RECOMMENDATIONS", false),
+		VPACacheTTL:              envDuration("VPA_CACHE_TTL", 30*time.Second),
+		ActivationPrewarm:        envBool("ACTIVATION_PREWARM", true),
+		HPAStabilizationCooldown: envBool("HPA_STABILIZATION_COOLDOWN", false),
+		HPANamespace:             os.Getenv("HPA_NAMESPACE"),
+		EvictionProtection:       envBool("EVICTION_PROTECTION", false),
+		EvictionProtectionAnnotations: envStringMap("EVICTION_PROTECTION_ANNOTATIONS", map[string]string{
+			"descheduler.alpha.kubernetes.io/prefer-no-eviction": "true",
+			"cluster-autoscaler.kubernetes.io/safe-to-evict":     "false",
+		}),
+		GangMemberLabels:         envBool("GANG_MEMBER_LABELS", false),
+		AffinityHints:            envBool("AFFINITY_HINTS", false),
+		AffinityHintWeight:       envInt("AFFINITY_HINT_WEIGHT", 100),
+		AffinityHintNamespace:    os.Getenv("AFFINITY_HINT_NAMESPACE"),
+		ExtenderAddr:             envString("EXTENDER_ADDR", ":9099"),
+		ExtenderReadTimeout:      envDuration("EXTENDER_READ_TIMEOUT", 5*time.Second),
+		ExtenderWriteTimeout:     envDuration("EXTENDER_WRITE_TIMEOUT", 10*time.Second),
+		ExtenderGzip:             envBool("EXTENDER_GZIP", true),
+		ExtenderKeepAlive:        envBool("EXTENDER_KEEP_ALIVE", true),
+		ExtenderIdleTimeout:      envDuration("EXTENDER_IDLE_TIMEOUT", 120*time.Second),
+		ExtenderMaxConns:         envInt("EXTENDER_MAX_CONNECTIONS", 0),
+		AdminAddr:                envString("ADMIN_ADDR", ":9100"),
+		AdminReadTimeout:         envDuration("ADMIN_READ_TIMEOUT", 10*time.Second),
+		AdminWriteTimeout:        envDuration("ADMIN_WRITE_TIMEOUT", 30*time.Second),
+		SelfProbeInterval:        envDuration("SELF_PROBE_INTERVAL", 0),
+		SelfProbeTimeout:         envDuration("SELF_PROBE_TIMEOUT", 2*time.Second),
+		SelfProbeURL:             os.Getenv("SELF_PROBE_URL"),
+		GangFilterStrict:         envBool("GANG_FILTER_STRICT", false),
+		GradedActivation:         envBool("GRADED_ACTIVATION", false),
+		EnforcingThresholds:      envFloatMap("ENFORCING_THRESHOLDS", defaultEnforcingThresholds),
+		ExtenderProtocol:         envString("EXTENDER_PROTOCOL", ExtenderProtocolAuto),
+		ExtenderErrorPolicy:      envString("EXTENDER_ERROR_POLICY", ErrorPolicyFailOpen),
+		SchedulerNames:           envStringList("SCHEDULER_NAMES", nil),
+		GangServiceAllowlist:     envStringList("GANG_SERVICE_ALLOWLIST", nil),
+		GangServiceDenylist:      envStringList("GANG_SERVICE_DENYLIST", []string{"loadgenerator"}),
+		SystemNamespaces:         envStringList("SYSTEM_NAMESPACES", []string{"kube-system"}),
+		SystemPriorityClasses:    envStringList("SYSTEM_PRIORITY_CLASSES", []string{"system-node-critical", "system-cluster-critical"}),
+		InfluenceExcludeSelector: envString("INFLUENCE_EXCLUDE_SELECTOR", ""),
+		SpikeClassPolicies: envSpikeClassPolicies("SPIKE_CLASS_POLICIES", map[string]SpikeClassPolicy{
+			"latency": {LocalityScale: 1.5},
+			"error":   {Spread: true},
+		}),
+		GangFormationStrategy:     envString("GANG_FORMATION_STRATEGY", GangFormationPerGroup),
+		GangTopK:                  envInt("GANG_TOP_K", 3),
+		GroupOverlap:              envString("GROUP_OVERLAP", GroupOverlapMerge),
+		ArbitrationYieldScale:     envFloat("ARBITRATION_YIELD_SCALE", 0.5),
+		GangPlacement:             envString("GANG_PLACEMENT", GangPlacementGreedy),
+		GangPlanScale:             envFloat("GANG_PLAN_SCALE", 1),
+		FreshGangPlacement:        envString("FRESH_GANG_PLACEMENT", FreshPlacementSchedulable),
+		GangMemberCache:           envBool("GANG_MEMBER_CACHE", true),
+		NodeFailureWatch:          envBool("NODE_FAILURE_WATCH", true),
+		SchedulingLatencyWatch:    envBool("SCHEDULING_LATENCY_WATCH", false),
+		NonGangFallback:           envString("NON_GANG_FALLBACK", FallbackNoOpinion),
+		WeightSweep:               EnvFloatList("WEIGHT_SWEEP", nil),
+		SLODefaultP95:             envFloat("SLO_DEFAULT_P95_MS", 0),
+		SLOObjective:              envFloat("SLO_OBJECTIVE", 0.99),
+		DecisionExport:            envString("DECISION_EXPORT", DecisionExportOff),
+		DecisionExportDir:         envString("DECISION_EXPORT_DIR", "/var/lib/nexus/decisions"),
+		DecisionExportRotate:      envDuration("DECISION_EXPORT_ROTATE", 5*time.Minute),
+		DecisionExportBuffer:      envInt("DECISION_EXPORT_BUFFER", 4096),
+		HistoryStore:              envBool("HISTORY_STORE", false),
+		HistoryStoreDir:           envString("HISTORY_STORE_DIR", "/var/lib/nexus/history"),
+		HistoryStoreSegment:       envDuration("HISTORY_STORE_SEGMENT", time.Hour),
+		HistoryStoreRetention:     envDuration("HISTORY_STORE_RETENTION", 14*24*time.Hour),
+		HistoryStoreMaxMB:         envInt("HISTORY_STORE_MAX_MB", 1024),
+		HistoryStoreBuffer:        envInt("HISTORY_STORE_BUFFER", 4096),
+		MetricsSnapshotPath:       envString("METRICS_SNAPSHOT_PATH", ""),
+		MetricsSnapshotInterval:   envDuration("METRICS_SNAPSHOT_INTERVAL", 30*time.Second),
+		MetricsLegacyNames:        envBool("METRICS_LEGACY_NAMES", true),
+		DecisionExportS3Endpoint:  os.Getenv("DECISION_EXPORT_S3_ENDPOINT"),
+		DecisionExportS3Bucket:    os.Getenv("DECISION_EXPORT_S3_BUCKET"),
+		DecisionExportS3Prefix:    envString("DECISION_EXPORT_S3_PREFIX", "nexus/decisions/"),
+		DecisionExportS3Region:    envString("DECISION_EXPORT_S3_REGION", "us-east-1"),
+		DecisionExportS3AccessKey: os.Getenv("DECISION_EXPORT_S3_ACCESS_KEY"),
+		DecisionExportS3SecretKey: os.Getenv("DECISION_EXPORT_S3_SECRET_KEY"),
+		OTLPEndpoint:              os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		OTLPServiceName:           envString("OTEL_SERVICE_NAME", "nexus-scheduler"),
+		PodGroups:                 envBool("COSCHEDULING_PODGROUPS", false),
+		PodGroupLabel:             envString("COSCHEDULING_POD_GROUP_LABEL", "scheduling.x-k8s.io/pod-group"),
+		PodGroupTimeout:           envDuration("COSCHEDULING_SCHEDULE_TIMEOUT", 60*time.Second),
+	}
+
+	for _, warning := range cfg.Validate() {
+		klog.Warningf("Config: %s", warning)
+	}
+
+	klog.Infof("Kubernetes API client: QPS=%.0f, Burst=%d, retries=%d, breaker=%d failures/%v",
+		cfg.KubeAPIQPS, cfg.KubeAPIBurst, cfg.APIRetrySteps, cfg.APIBreakerThreshold, cfg.APIBreakerCooldown)
+	klog.Infof("Ephemeral state budget: maxPods=%d, maxGangs=%d, maxNodes=%d, pageSize=%d",
+		cfg.MaxPodsConsidered, cfg.MaxGangs, cfg.MaxNodesScanned, cfg.ListPageSize)
+
+	return cfg
+}
+
+// SpikeClassPolicyFor returns the policy for a gang's group during a spike
+// of the given class: "<group>/<class>" first, then "<class>", else neutral
+func (c *Config) SpikeClassPolicyFor(group, class string) SpikeClassPolicy {
+	policy, ok := c.SpikeClassPolicies[group+"/"+class]
+	if !ok {
+		policy = c.SpikeClassPolicies[class]
+	}
+	if policy.LocalityScale == 0 {
+		policy.LocalityScale = 1
+	}
+	return policy
+}
+
+// WithShadowScorer returns a copy of the configuration with a shadow
+// formula's overrides applied
+func (c *Config) WithShadowScorer(shadow ShadowScorer) *Config {
+	copied := *c
+	if shadow.LocalityWeight != nil {
+		copied.LocalityWeight = *shadow.LocalityWeight
+	}
+	if shadow.LocalityCurve != "" {
+		copied.LocalityCurve = shadow.LocalityCurve
+	}
+	if shadow.LocalityMemberCap != nil {
+		copied.LocalityMemberCap = *shadow.LocalityMemberCap
+	}
+	if shadow.UtilizationWeight != nil {
+		copied.UtilizationPenaltyWeight = *shadow.UtilizationWeight
+	}
+	return &copied
+}
+
+// redactedValue replaces secret values in the effective configuration
+const redactedValue = "[redacted]"
+
+// Effective returns the resolved configuration keyed by environment
+// variable, with durations as strings and secrets redacted
+func (c *Config) Effective() map[string]interface{} {
+	effective := make(map[string]interface{})
+
+	val := reflect.ValueOf(*c)
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		value := val.Field(i).Interface()
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case string:
+			if field.Tag.Get("secret") == "true" && v != "" {
+				value = redactedValue
+			}
+		}
+		effective[key] = value
+	}
+	return effective
+}
+
+// Validate reports settings that are out of range or not recognised.
+// Invalid values are not fatal: each knob falls back to safe behaviour.
+func (c *Config) Validate() []string {
+	warnings := make([]string, 0)
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		warnings = append(warnings, fmt.Sprintf("%s=%q is not one of %v", key, value, allowed))
+	}
+	nonNegative := func(key string, value float64) {
+		if value < 0 {
+			warnings = append(warnings, fmt.Sprintf("%s=%v must not be negative", key, value))
+		}
+	}
+
+	oneOf("GRAPH_SCOPE", c.GraphScope, GraphScopeCluster, GraphScopeSpike)
+	oneOf("GRAPH_NETWORK_POLICY", c.GraphNetworkPolicy, NetworkPolicyOff, NetworkPolicyWarn, NetworkPolicyEnforce)
+	if c.AppProfiles != "" && !json.Valid([]byte(c.AppProfiles)) {
+		warnings = append(warnings, "APP_PROFILES is not valid JSON; the Online Boutique profile is used")
+	}
+	oneOf("SCORE_DEBUG", c.ScoreDebug, ScoreDebugOff, ScoreDebugHeader, ScoreDebugLog)
+	oneOf("SCORE_TIE_BREAK", c.ScoreTieBreak, TieBreakName, TieBreakHash, TieBreakRandom)
+	oneOf("LOCALITY_CURVE", c.LocalityCurve, LocalityCurveLinear, LocalityCurveSqrt, LocalityCurveLog)
+	oneOf("EXTENDER_PROTOCOL", c.ExtenderProtocol, ExtenderProtocolAuto, ExtenderProtocolNodes, ExtenderProtocolNodeNames)
+	oneOf("EXTENDER_ERROR_POLICY", c.ExtenderErrorPolicy, ErrorPolicyFailOpen, ErrorPolicyFailClosed)
+	oneOf("DECISION_EXPORT", c.DecisionExport, DecisionExportOff, DecisionExportCSV)
+	oneOf("GANG_FORMATION_STRATEGY", c.GangFormationStrategy,
+		GangFormationPerGroup, GangFormationMerged, GangFormationCriticalPath, GangFormationTopK)
+	oneOf("GROUP_OVERLAP", c.GroupOverlap, GroupOverlapMerge, GroupOverlapPriority, GroupOverlapError)
+	oneOf("GANG_PLACEMENT", c.GangPlacement, GangPlacementGreedy, GangPlacementPlanned)
+	oneOf("FRESH_GANG_PLACEMENT", c.FreshGangPlacement, FreshPlacementAllNodes, FreshPlacementSchedulable, FreshPlacementPlanFirst)
+	oneOf("NON_GANG_FALLBACK", c.NonGangFallback, FallbackNoOpinion, FallbackSpread, FallbackResource)
+
+	nonNegative("KUBE_API_QPS", float64(c.KubeAPIQPS))
+	nonNegative("KUBE_API_AUTH_REBUILD_AFTER", float64(c.APIAuthRebuildAfter))
+	nonNegative("MAX_PODS_CONSIDERED", float64(c.MaxPodsConsidered))
+	nonNegative("MAX_GANGS", float64(c.MaxGangs))
+	if c.GangTopK < 1 {
+		warnings = append(warnings, fmt.Sprintf("GANG_TOP_K=%d is below 1; top-k keeps one service per group", c.GangTopK))
+	}
+	nonNegative("GANG_PLAN_SCALE", c.GangPlanScale)
+	nonNegative("ARBITRATION_YIELD_SCALE", c.ArbitrationYieldScale)
+	if c.ArbitrationYieldScale > 1 {
+		warnings = append(warnings, fmt.Sprintf("ARBITRATION_YIELD_SCALE=%g is above 1; lower-priority gangs are drawn to contested nodes", c.ArbitrationYieldScale))
+	}
+	nonNegative("MAX_NODES_SCANNED", float64(c.MaxNodesScanned))
+	nonNegative("DECISION_LOG_SAMPLE", float64(c.DecisionLogSample))
+	nonNegative("DECISION_LOG_RATE", c.DecisionLogRate)
+	if c.ScoringShards < 1 {
+		warnings = append(warnings, fmt.Sprintf("SCORING_SHARDS=%d is below 1; nodes are scored sequentially", c.ScoringShards))
+	}
+	nonNegative("SCORING_SHARD_MIN_NODES", float64(c.ScoringShardMinNodes))
+	nonNegative("DEPENDENCY_DEPTH", float64(c.DependencyDepth))
+	nonNegative("MAX_INFLUENCED_PODS_PER_GANG", float64(c.MaxInfluencedPods))
+	nonNegative("LOCALITY_WEIGHT", c.LocalityWeight)
+	nonNegative("LOCALITY_MEMBER_CAP", float64(c.LocalityMemberCap))
+	nonNegative("UTILIZATION_PENALTY_WEIGHT", c.UtilizationPenaltyWeight)
+	nonNegative("IMAGE_LOCALITY_WEIGHT", c.ImageLocalityWeight)
+	nonNegative("NUMA_ALIGNMENT_WEIGHT", c.NUMAAlignmentWeight)
+	nonNegative("EXTENDER_READ_TIMEOUT", float64(c.ExtenderReadTimeout))
+	nonNegative("EXTENDER_WRITE_TIMEOUT", float64(c.ExtenderWriteTimeout))
+	nonNegative("EXTENDER_IDLE_TIMEOUT", float64(c.ExtenderIdleTimeout))
+	nonNegative("EXTENDER_MAX_CONNECTIONS", float64(c.ExtenderMaxConns))
+	nonNegative("ADMIN_READ_TIMEOUT", float64(c.AdminReadTimeout))
+	nonNegative("ADMIN_WRITE_TIMEOUT", float64(c.AdminWriteTimeout))
+	nonNegative("SELF_PROBE_INTERVAL", float64(c.SelfProbeInterval))
+	if c.SelfProbeInterval > 0 && c.SelfProbeTimeout <= 0 {
+		warnings = append(warnings, "SELF_PROBE_TIMEOUT must be positive when SELF_PROBE_INTERVAL is set")
+	}
+	nonNegative("DRAIN_DURATION", float64(c.DrainDuration))
+	nonNegative("FLAP_MAX_ACTIVATIONS", float64(c.FlapMaxActivations))
+	nonNegative("FLAP_WINDOW", float64(c.FlapWindow))
+	nonNegative("FLAP_STABILIZATION", float64(c.FlapStabilization))
+	nonNegative("SPIKE_MAX_EXTENSIONS", float64(c.SpikeMaxExtensions))
+	nonNegative("LATENCY_BUDGET", float64(c.LatencyBudget))
+	nonNegative("LATENCY_BUDGET_WINDOW", float64(c.LatencyBudgetWindow))
+	nonNegative("COSCHEDULING_SCHEDULE_TIMEOUT", float64(c.PodGroupTimeout))
+	nonNegative("SPIKE_CHECK_TIMEOUT", float64(c.SpikeCheckTimeout))
+	nonNegative("SPIKE_CHECK_JITTER", float64(c.SpikeCheckJitter))
+	nonNegative("SCORE_LAST_GOOD_MAX_AGE", float64(c.LastGoodMaxAge))
+
+	if c.AffinityHintWeight < 1 || c.AffinityHintWeight > 100 {
+		warnings = append(warnings, fmt.Sprintf("AFFINITY_HINT_WEIGHT=%d should be between 1 and 100", c.AffinityHintWeight))
+	}
+	if c.ListPageSize <= 0 {
+		warnings = append(warnings, fmt.Sprintf("LIST_PAGE_SIZE=%d must be positive", c.ListPageSize))
+	}
+	if c.ExtensionThresholdScale < 1 {
+		warnings = append(warnings, fmt.Sprintf("SPIKE_EXTENSION_THRESHOLD_SCALE=%v should be at least 1", c.ExtensionThresholdScale))
+	}
+	if c.DrainLocalityScale < 0 || c.DrainLocalityScale > 1 {
+		warnings = append(warnings, fmt.Sprintf("DRAIN_LOCALITY_SCALE=%v should be between 0 and 1", c.DrainLocalityScale))
+	}
+	for key, policy := range c.SpikeClassPolicies {
+		_, class, _ := strings.Cut(key, "/")
+		if class == "" {
+			class = key
+		}
+		oneOf("SPIKE_CLASS_POLICIES class", class, spikeClasses...)
+		nonNegative("SPIKE_CLASS_POLICIES "+key+" localityScale", policy.LocalityScale)
+	}
+	for signal, multiple := range c.EnforcingThresholds {
+		oneOf("ENFORCING_THRESHOLDS signal", signal, enforcingSignals...)
+		nonNegative("ENFORCING_THRESHOLDS "+signal, multiple)
+	}
+	for name, shadow := range c.ShadowScorers {
+		if shadow.LocalityCurve != "" {
+			oneOf("SHADOW_SCORERS "+name+" localityCurve", shadow.LocalityCurve, LocalityCurveLinear, LocalityCurveSqrt, LocalityCurveLog)
+		}
+		if shadow.LocalityWeight != nil {
+			nonNegative("SHADOW_SCORERS "+name+" localityWeight", *shadow.LocalityWeight)
+		}
+		if shadow.LocalityMemberCap != nil {
+			nonNegative("SHADOW_SCORERS "+name+" localityMemberCap", float64(*shadow.LocalityMemberCap))
+		}
+		if shadow.UtilizationWeight != nil {
+			nonNegative("SHADOW_SCORERS "+name+" utilizationWeight", *shadow.UtilizationWeight)
+		}
+	}
+	nonNegative("SLO_DEFAULT_P95_MS", c.SLODefaultP95)
+	for _, factor := range c.WeightSweep {
+		nonNegative("WEIGHT_SWEEP factor", factor)
+	}
+	if c.SLOObjective <= 0 || c.SLOObjective >= 1 {
+		warnings = append(warnings, fmt.Sprintf("SLO_OBJECTIVE=%v should be between 0 and 1 (exclusive)", c.SLOObjective))
+	}
+	if c.MetricsSnapshotPath != "" && c.MetricsSnapshotInterval <= 0 {
+		warnings = append(warnings, fmt.Sprintf("METRICS_SNAPSHOT_INTERVAL=%v must be positive", c.MetricsSnapshotInterval))
+	}
+	if c.DecisionExportRotate <= 0 {
+		warnings = append(warnings, fmt.Sprintf("DECISION_EXPORT_ROTATE=%v must be positive", c.DecisionExportRotate))
+	}
+	if c.DecisionExportS3Endpoint != "" && c.DecisionExportS3Bucket == "" {
+		warnings = append(warnings, "DECISION_EXPORT_S3_ENDPOINT is set without DECISION_EXPORT_S3_BUCKET")
+	}
+	if c.HistoryStore && c.HistoryStoreSegment <= 0 {
+		warnings = append(warnings, fmt.Sprintf("HISTORY_STORE_SEGMENT=%v must be positive", c.HistoryStoreSegment))
+	}
+	nonNegative("HISTORY_STORE_RETENTION", c.HistoryStoreRetention.Seconds())
+	nonNegative("HISTORY_STORE_MAX_MB", float64(c.HistoryStoreMaxMB))
+	if _, err := labels.Parse(c.InfluenceExcludeSelector); err != nil {
+		warnings = append(warnings, fmt.Sprintf("INFLUENCE_EXCLUDE_SELECTOR=%q is not a valid label selector: %v", c.InfluenceExcludeSelector, err))
+	}
+	if c.APIRetryInitialBackoff > c.APIRetryMaxBackoff {
+		warnings = append(warnings, "KUBE_API_RETRY_INITIAL_BACKOFF exceeds KUBE_API_RETRY_MAX_BACKOFF")
+	}
+	if c.WebhookEnabled && c.ValidationWebhookEnabled && c.WebhookAddr == c.ValidationWebhookAddr {
+		warnings = append(warnings, fmt.Sprintf("VALIDATION_WEBHOOK_ADDR=%s must differ from WEBHOOK_ADDR", c.ValidationWebhookAddr))
+	}
+	return warnings
+}
+
+// envString reads a string environment variable, falling back to def
+func envString(key, def string) string {
+	if str := os.Getenv(key); str != "" {
+		return str
+	}
+	return def
+}
+
+// envBool reads a boolean environment variable ("true", "1", ...), falling back to def
+func envBool(key string, def bool) bool {
+	if str := os.Getenv(key); str != "" {
+		if val, err := strconv.ParseBool(str); err == nil {
+			return val
+		}
+		klog.Warningf("Invalid value for %s: %q, using default %v", key, str, def)
+	}
+	return def
+}
+
+// envFloat reads a float environment variable, falling back to def
+func envFloat(key string, def float64) float64 {
+	if str := os.Getenv(key); str != "" {
+		if val, err := strconv.ParseFloat(str, 64); err == nil {
+			return val
+		}
+		klog.Warningf("Invalid value for %s: %q, using default %v", key, str, def)
+	}
+	return def
+}
+
+// envInt reads an integer environment variable, falling back to def
+func envInt(key string, def int) int {
+	if str := os.Getenv(key); str != "" {
+		if val, err := strconv.Atoi(str); err == nil {
+			return val
+		}
+		klog.Warningf("Invalid value for %s: %q, using default %v", key, str, def)
+	}
+	return def
+}
+
+// envDuration reads a duration environment variable (e.g. "30s"), falling back to def
+func envDuration(key string, def time.Duration) time.Duration {
+	if str := os.Getenv(key); str != "" {
+		if val, err := time.ParseDuration(str); err == nil {
+			return val
+		}
+		klog.Warningf("Invalid value for %s: %q, using default %v", key, str, def)
+	}
+	return def
+}
+
+// EnvFloatList reads a comma-separated float list (e.g. "0.5,1,5"), falling back to def
+func EnvFloatList(key string, def []float64) []float64 {
+	str := os.Getenv(key)
+	if str == "" {
+		return def
+	}
+
+	vals := make([]float64, 0)
+	for _, part := range strings.Split(str, ",") {
+		val, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			klog.Warningf("Invalid value for %s: %q, using default %v", key, str, def)
+			return def
+		}
+		vals = append(vals, val)
+	}
+	return vals
+}
+
+// envStringList reads a comma-separated list, dropping empty entries
+func envStringList(key string, defaultVal []string) []string {
+	str := os.Getenv(key)
+	if str == "" {
+		return defaultVal
+	}
+
+	var values []string
+	for _, part := range strings.Split(str, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// envStringMap parses a comma-separated key=value list, e.g.
+// "example.com/keep=true,other.io/evict=false"
+func envStringMap(key string, defaultVal map[string]string) map[string]string {
+	str := os.Getenv(key)
+	if str == "" {
+		return defaultVal
+	}
+
+	values := make(map[string]string)
+	for _, part := range strings.Split(str, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || k == "" {
+			klog.Warningf("Invalid value for %s: %q, using default", key, str)
+			return defaultVal
+		}
+		values[k] = v
+	}
+	return values
+}
+
+// envFloatMap parses a comma-separated key=number list, e.g. "errors=2,p95=1.5"
+func envFloatMap(key string, defaultVal map[string]float64) map[string]float64 {
+	str := os.Getenv(key)
+	if str == "" {
+		return defaultVal
+	}
+
+	values := make(map[string]float64)
+	for _, part := range strings.Split(str, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		val, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if !ok || k == "" || err != nil {
+			klog.Warningf("Invalid value for %s: %q, using default", key, str)
+			return defaultVal
+		}
+		values[strings.TrimSpace(k)] = val
+	}
+	return values
+}
+
+// envTopologyLevels reads an ordered "key=weight,key=weight" list
+// (e.g. "topology.example.com/rack=0.8,topology.example.com/switch=0.5")
+func envTopologyLevels(key string) []TopologyLevel {
+	str := os.Getenv(key)
+	if str == "" {
+		return nil
+	}
+
+	levels := make([]TopologyLevel, 0)
+	for _, part := range strings.Split(str, ",") {
+		label, weightStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		weight, err := strconv.ParseFloat(weightStr, 64)
+		if !ok || label == "" || err != nil || weight < 0 {
+			klog.Warningf("Invalid value for %s: %q, topology levels disabled", key, str)
+			return nil
+		}
+		levels = append(levels, TopologyLevel{Key: label, Weight: weight})
+	}
+	return levels
+}
+
+// envShadowScorers reads a JSON object of shadow scoring formulas, e.g.
+// {"sqrt": {"localityCurve": "sqrt"}, "capped": {"localityMemberCap": 3}}
+func envShadowScorers(key string) map[string]ShadowScorer {
+	str := os.Getenv(key)
+	if str == "" {
+		return nil
+	}
+
+	var scorers map[string]ShadowScorer
+	if err := json.Unmarshal([]byte(str), &scorers); err != nil {
+		klog.Warningf("Invalid value for %s: %v, shadow scoring disabled", key, err)
+		return nil
+	}
+	return scorers
+}
+
+// envSpikeClassPolicies reads a JSON object of spike class policies, e.g.
+// {"latency": {"localityScale": 1.5}, "checkout-flow/error": {"spread": true}}
+func envSpikeClassPolicies(key string, defaultVal map[string]SpikeClassPolicy) map[string]SpikeClassPolicy {
+	str := os.Getenv(key)
+	if str == "" {
+		return defaultVal
+	}
+
+	var policies map[string]SpikeClassPolicy
+	if err := json.Unmarshal([]byte(str), &policies); err != nil {
+		klog.Warningf("Invalid value for %s: %v, using default", key, err)
+		return defaultVal
+	}
+	return policies
+}
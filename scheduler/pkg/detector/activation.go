@@ -0,0 +1,200 @@
+/*
+Activation Expressions
+======================
+By default any signal above its threshold activates NEXUS, so one noisy
+metric can form gangs on its own. SPIKE_ACTIVATION_EXPR requires a
+boolean combination of signals instead:
+
+  qps AND p95
+  hpa OR (qps AND errors)
+
+Signals are qps, errors, p95 and hpa; AND binds tighter than OR and both
+are case-insensitive. When the expression is not satisfied the check
+reports no spike, whatever fired. When it is, the spike is classified by
+the most severe signal that fired, as without an expression.
+
+The pending-pod fallback (Prometheus unreachable) and the KEDA trigger
+are not signals and still activate on their own.
+*/
+
+package detector
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"k8s.io/klog/v2"
+)
+
+// Signals an activation expression can reference
+const (
+	SignalQPS    = "qps"
+	SignalErrors = "errors"
+	SignalP95    = "p95"
+	SignalHPA    = "hpa"
+)
+
+// signalClasses maps each signal to the spike class it raises
+var signalClasses = map[string]SpikeClass{
+	SignalQPS:    SpikeClassTraffic,
+	SignalErrors: SpikeClassError,
+	SignalP95:    SpikeClassLatency,
+	SignalHPA:    SpikeClassTraffic,
+}
+
+// ActivationExpr is a parsed boolean expression over signals
+type ActivationExpr struct {
+	op          string // "and", "or", or "" for a signal
+	signal      string
+	left, right *ActivationExpr
+}
+
+// Eval reports whether the expression holds for the fired signals
+func (e *ActivationExpr) Eval(fired map[string]bool) bool {
+	switch e.op {
+	case "and":
+		return e.left.Eval(fired) && e.right.Eval(fired)
+	case "or":
+		return e.left.Eval(fired) || e.right.Eval(fired)
+	}
+	return fired[e.signal]
+}
+
+// Uses reports whether the expression references a signal
+func (e *ActivationExpr) Uses(signal string) bool {
+	if e.op == "" {
+		return e.signal == signal
+	}
+	return e.left.Uses(signal) || e.right.Uses(signal)
+}
+
+// String renders the expression fully parenthesized
+func (e *ActivationExpr) String() string {
+	if e.op == "" {
+		return e.signal
+	}
+	return fmt.Sprintf("(%s %s %s)", e.left, strings.ToUpper(e.op), e.right)
+}
+
+// ParseActivationExpr parses an activation expression such as
+// "hpa OR (qps AND errors)"
+func ParseActivationExpr(s string) (*ActivationExpr, error) {
+	p := &exprParser{tokens: tokenizeExpr(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty activation expression")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// loadActivationExpr reads SPIKE_ACTIVATION_EXPR (nil = any signal)
+func loadActivationExpr() *ActivationExpr {
+	raw := strings.TrimSpace(os.Getenv("SPIKE_ACTIVATION_EXPR"))
+	if raw == "" {
+		return nil
+	}
+	expr, err := ParseActivationExpr(raw)
+	if err != nil {
+		klog.Warningf("Invalid SPIKE_ACTIVATION_EXPR %q, any signal activates: %v", raw, err)
+		return nil
+	}
+	klog.Infof("Spike activation requires %s", expr)
+	return expr
+}
+
+// tokenizeExpr splits an expression into words and parentheses
+func tokenizeExpr(s string) []string {
+	var tokens []string
+	word := strings.Builder{}
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// exprParser is a recursive descent parser over expression tokens
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token lower-cased ("" at the end)
+func (p *exprParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return strings.ToLower(p.tokens[p.pos])
+}
+
+// parseOr parses and-terms joined by OR
+func (p *exprParser) parseOr() (*ActivationExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.peek() == "or" {
+		p.pos++
+		var right *ActivationExpr
+		if right, err = p.parseAnd(); err == nil {
+			left = &ActivationExpr{op: "or", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseAnd parses operands joined by AND
+func (p *exprParser) parseAnd() (*ActivationExpr, error) {
+	left, err := p.parseOperand()
+	for err == nil && p.peek() == "and" {
+		p.pos++
+		var right *ActivationExpr
+		if right, err = p.parseOperand(); err == nil {
+			left = &ActivationExpr{op: "and", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseOperand parses a signal or a parenthesized expression
+func (p *exprParser) parseOperand() (*ActivationExpr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("expression ends early")
+	case token == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return expr, nil
+	case signalClasses[token] != SpikeClassNone:
+		p.pos++
+		return &ActivationExpr{signal: token}, nil
+	}
+	return nil, fmt.Errorf("unknown signal %q (want qps, errors, p95 or hpa)", p.tokens[p.pos])
+}
@@ -0,0 +1,81 @@
+package detector
+
+import "testing"
+
+func TestParseActivationExpr(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string // fully parenthesized
+	}{
+		{"qps", "qps"},
+		{"qps AND p95", "(qps AND p95)"},
+		{"hpa OR qps AND errors", "(hpa OR (qps AND errors))"},
+		{"qps AND errors OR hpa", "((qps AND errors) OR hpa)"},
+		{"(hpa OR qps) AND errors", "((hpa OR qps) AND errors)"},
+		{"hpa OR (qps AND errors)", "(hpa OR (qps AND errors))"},
+		{"qps and p95 or HPA", "((qps AND p95) OR hpa)"},
+		{"((qps))", "qps"},
+		{"qps AND p95 AND errors", "((qps AND p95) AND errors)"},
+		{"  qps\tOr\nerrors ", "(qps OR errors)"},
+	} {
+		expr, err := ParseActivationExpr(tc.expr)
+		if err != nil {
+			t.Errorf("ParseActivationExpr(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := expr.String(); got != tc.want {
+			t.Errorf("ParseActivationExpr(%q) = %s, want %s", tc.expr, got, tc.want)
+		}
+		// String() parses back to the same expression
+		again, err := ParseActivationExpr(expr.String())
+		if err != nil || again.String() != expr.String() {
+			t.Errorf("round trip of %s = %v, %v", expr, again, err)
+		}
+	}
+
+	for _, expr := range []string{
+		"",
+		"   ",
+		"cpu",
+		"qps AND memory",
+		"(qps AND p95",
+		"qps AND p95)",
+		"()",
+		"qps AND",
+		"qps OR",
+		"AND qps",
+		"qps p95",
+	} {
+		if got, err := ParseActivationExpr(expr); err == nil {
+			t.Errorf("ParseActivationExpr(%q) = %s, want an error", expr, got)
+		}
+	}
+}
+
+func TestActivationExprEval(t *testing.T) {
+	expr, err := ParseActivationExpr("hpa OR qps AND errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		fired []string
+		want  bool
+	}{
+		{nil, false},
+		{[]string{SignalHPA}, true},
+		{[]string{SignalQPS}, false},
+		{[]string{SignalQPS, SignalErrors}, true},
+		{[]string{SignalErrors, SignalP95}, false},
+	} {
+		fired := make(map[string]bool)
+		for _, s := range tc.fired {
+			fired[s] = true
+		}
+		if got := expr.Eval(fired); got != tc.want {
+			t.Errorf("%s with %v = %v, want %v", expr, tc.fired, got, tc.want)
+		}
+	}
+	if !expr.Uses(SignalErrors) || expr.Uses(SignalP95) {
+		t.Errorf("%s: Uses(errors) = %v, Uses(p95) = %v", expr, expr.Uses(SignalErrors), expr.Uses(SignalP95))
+	}
+}
@@ -0,0 +1,121 @@
+/*
+HPA Scope
+=========
+The hpa signal fires on any HPA of the cluster adding replicas, including
+ones that have nothing to do with the benchmark application (the
+monitoring stack, ingress controllers). Three variables scope the
+kube-state-metrics series behind it:
+
+  SPIKE_HPA_NAMESPACES          only HPAs in these namespaces count
+  SPIKE_HPA_IGNORE_NAMESPACES   HPAs in these namespaces never count
+  SPIKE_HPA_NAMES               only HPAs of these names count
+
+each a comma-separated list, e.g. SPIKE_HPA_IGNORE_NAMESPACES=monitoring,
+kube-system. Unset, every HPA counts as before.
+
+The query keeps one series per HPA and the check takes the largest
+increase; the HPA behind it (namespace/name) is the Target of the hpa
+trigger, so /status, the episode record and nexus_hpa_activations_total
+show which HPA started an episode.
+*/
+
+package detector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// HPA series labels of kube-state-metrics
+const (
+	hpaNamespaceLabel = "namespace"
+	hpaNameLabel      = "horizontalpodautoscaler"
+)
+
+// hpaScope is the set of HPAs the hpa signal watches (empty = all)
+type hpaScope struct {
+	Namespaces       []string `json:"namespaces,omitempty"`
+	IgnoreNamespaces []string `json:"ignoreNamespaces,omitempty"`
+	Names            []string `json:"names,omitempty"`
+}
+
+// loadHPAScope reads SPIKE_HPA_NAMESPACES, SPIKE_HPA_IGNORE_NAMESPACES and
+// SPIKE_HPA_NAMES
+func loadHPAScope() hpaScope {
+	return hpaScope{
+		Namespaces:       splitList(os.Getenv("SPIKE_HPA_NAMESPACES")),
+		IgnoreNamespaces: splitList(os.Getenv("SPIKE_HPA_IGNORE_NAMESPACES")),
+		Names:            splitList(os.Getenv("SPIKE_HPA_NAMES")),
+	}
+}
+
+// query returns the HPA activity query restricted to the scope
+func (h hpaScope) query() string {
+	var matchers []string
+	if len(h.Namespaces) > 0 {
+		matchers = append(matchers, fmt.Sprintf("%s=~`%s`", hpaNamespaceLabel, alternation(h.Namespaces)))
+	}
+	if len(h.IgnoreNamespaces) > 0 {
+		matchers = append(matchers, fmt.Sprintf("%s!~`%s`", hpaNamespaceLabel, alternation(h.IgnoreNamespaces)))
+	}
+	if len(h.Names) > 0 {
+		matchers = append(matchers, fmt.Sprintf("%s=~`%s`", hpaNameLabel, alternation(h.Names)))
+	}
+	if len(matchers) == 0 {
+		return hpaActivityQuery
+	}
+	return fmt.Sprintf("increase(kube_horizontalpodautoscaler_status_current_replicas{%s}[2m])", strings.Join(matchers, ","))
+}
+
+// queryHPAIncrease retrieves the largest recent replica increase of an HPA
+// in scope (> 0 = an HPA scaled up) and that HPA's namespace/name
+func (sd *SpikeDetector) queryHPAIncrease(ctx context.Context) (float64, string, error) {
+	promResp, err := sd.runQuery(ctx, sd.hpaQuery)
+	if err != nil {
+		return 0, "", err
+	}
+
+	largest, target := 0.0, ""
+	for i, result := range promResp.Data.Result {
+		value, err := parseSampleValue(result.Value)
+		if err != nil {
+			return 0, "", err
+		}
+		if i == 0 || value > largest {
+			largest, target = value, hpaTarget(result.Metric)
+		}
+	}
+	return largest, target, nil
+}
+
+// hpaTarget names the HPA of a series as namespace/name
+func hpaTarget(metric map[string]string) string {
+	namespace, name := metric[hpaNamespaceLabel], metric[hpaNameLabel]
+	if namespace == "" || name == "" {
+		return namespace + name
+	}
+	return namespace + "/" + name
+}
+
+// alternation matches any of the values literally
+func alternation(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	return strings.Join(quoted, "|")
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,97 @@
+/*
+KEDA Activation Trigger
+=======================
+Lets KEDA ScaledObject activity activate NEXUS for the scaled service's
+coordination group, so event-driven autoscaling and dependency-aware
+placement activate together.
+
+A ScaledObject is considered active when KEDA sets its "Active"
+status condition to True (a trigger crossed its activation threshold).
+The ScaledObject's scaleTargetRef name is taken as the service name,
+matching Online Boutique where Deployment name == service name.
+*/
+
+package detector
+
+import (
+	"context"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+// scaledObjectGVR identifies KEDA ScaledObjects
+var scaledObjectGVR = schema.GroupVersionResource{
+	Group:    "keda.sh",
+	Version:  "v1alpha1",
+	Resource: "scaledobjects",
+}
+
+// KEDAWatcher reports services whose KEDA ScaledObjects are actively scaling
+type KEDAWatcher struct {
+	client    dynamic.Interface
+	apiGuard  *kube.APIGuard
+	namespace string // "" = all namespaces
+}
+
+// NewKEDAWatcher creates a KEDA ScaledObject activity watcher
+func NewKEDAWatcher(client dynamic.Interface, apiGuard *kube.APIGuard, namespace string) *KEDAWatcher {
+	return &KEDAWatcher{
+		client:    client,
+		apiGuard:  apiGuard,
+		namespace: namespace,
+	}
+}
+
+// ActiveServices returns the scale targets of all active ScaledObjects
+func (kw *KEDAWatcher) ActiveServices(ctx context.Context) ([]string, error) {
+	var list *unstructured.UnstructuredList
+	err := kw.apiGuard.Do(ctx, "list KEDA scaledobjects", func(ctx context.Context) error {
+		var err error
+		list, err = kw.client.Resource(scaledObjectGVR).Namespace(kw.namespace).List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]string, 0)
+	for _, obj := range list.Items {
+		if !isScaledObjectActive(&obj) {
+			continue
+		}
+		target, found, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name")
+		if !found || target == "" {
+			continue
+		}
+		klog.V(2).Infof("KEDA ScaledObject %s/%s is active (target: %s)", obj.GetNamespace(), obj.GetName(), target)
+		services = append(services, target)
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// isScaledObjectActive checks the ScaledObject "Active" status condition
+func isScaledObjectActive(obj *unstructured.Unstructured) bool {
+	conditions, found, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if !found {
+		return false
+	}
+
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Active" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
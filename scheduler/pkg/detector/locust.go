@@ -0,0 +1,145 @@
+/*
+Locust Spike Source
+===================
+In research clusters the load is synthetic: the Online Boutique
+loadgenerator (Locust) knows exactly how many users it runs and what rate
+it drives, while the Prometheus signals trail it by the scrape interval
+plus the rate() window, which skews activation-latency measurements.
+SPIKE_SOURCE=locust reads the signals from the Locust web API instead
+(LOCUST_URL, GET /stats/requests — the loadgenerator must run with its web
+UI, i.e. without --headless):
+
+  qps    ← total_rps
+  errors ← total_fail_per_sec (fail_ratio × total_rps on older Locust)
+  p95    ← current p95 response time in ms
+  users  ← user_count (observed, not a signal)
+
+The thresholds of the active profile and SPIKE_ACTIVATION_EXPR apply as
+with Prometheus; there is no hpa signal. While Locust is unreachable the
+pending-pod fallback applies. Per-service attribution, SLOs and the
+weight sweep keep querying Prometheus.
+*/
+
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"k8s.io/klog/v2"
+)
+
+// Spike signal sources (SPIKE_SOURCE)
+const (
+	SpikeSourcePrometheus = "prometheus"
+	SpikeSourceLocust     = "locust"
+)
+
+// locustStatsPath is the Locust web API endpoint with the live totals
+const locustStatsPath = "/stats/requests"
+
+// locustStats is the part of the Locust /stats/requests response the
+// detector reads; percentiles are null before the first request
+type locustStats struct {
+	State           string              `json:"state"`
+	UserCount       float64             `json:"user_count"`
+	TotalRPS        float64             `json:"total_rps"`
+	TotalFailPerSec *float64            `json:"total_fail_per_sec"`
+	FailRatio       float64             `json:"fail_ratio"`
+	Percentiles     map[string]*float64 `json:"current_response_time_percentiles"` // Locust 2.x
+	Percentile95    *float64            `json:"current_response_time_percentile_95"`
+}
+
+// failPerSec returns the failure rate in failures per second
+func (ls *locustStats) failPerSec() float64 {
+	if ls.TotalFailPerSec != nil {
+		return *ls.TotalFailPerSec
+	}
+	return ls.FailRatio * ls.TotalRPS
+}
+
+// p95 returns the current p95 response time in ms (0 without requests)
+func (ls *locustStats) p95() float64 {
+	if p := ls.Percentiles["response_time_percentile_0.95"]; p != nil {
+		return *p
+	}
+	if ls.Percentile95 != nil {
+		return *ls.Percentile95
+	}
+	return 0
+}
+
+// loadSpikeSource reads SPIKE_SOURCE and LOCUST_URL
+func loadSpikeSource() (source, locustURL string) {
+	source = os.Getenv("SPIKE_SOURCE")
+	switch source {
+	case "", SpikeSourcePrometheus:
+		source = SpikeSourcePrometheus
+	case SpikeSourceLocust:
+	default:
+		klog.Warningf("Unknown SPIKE_SOURCE %q, using %s", source, SpikeSourcePrometheus)
+		source = SpikeSourcePrometheus
+	}
+
+	locustURL = os.Getenv("LOCUST_URL")
+	if locustURL == "" {
+		locustURL = "http://loadgenerator:8089"
+	}
+	return source, locustURL
+}
+
+// queryLocust fetches the live totals from the Locust web API
+func (sd *SpikeDetector) queryLocust(ctx context.Context) (*locustStats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sd.locustURL+locustStatsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sd.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("locust returned %s", resp.Status)
+	}
+
+	var stats locustStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("decoding locust stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// locustSignals compares the Locust totals with the profile's thresholds
+// and records them in obs; false when Locust did not answer
+func (sd *SpikeDetector) locustSignals(ctx context.Context, profile ThresholdProfile, obs *Observation) (map[string]bool, bool) {
+	stats, err := sd.queryLocust(ctx)
+	if err != nil {
+		klog.V(2).Infof("Locust unreachable (%v), using fallback spike detection", err)
+		return nil, false
+	}
+
+	obs.LocustUp = true
+	obs.Users = stats.UserCount
+	obs.QPS = stats.TotalRPS
+	obs.ErrorRate = stats.failPerSec()
+	obs.P95Ms = stats.p95()
+
+	fired := make(map[string]bool)
+	if obs.QPS > profile.QPSThreshold {
+		klog.Infof("SPIKE DETECTED: Locust RPS %.2f > threshold %.2f (%.0f users, profile %s)", obs.QPS, profile.QPSThreshold, obs.Users, profile.Name)
+		fire(fired, obs, SignalQPS, sd.locustURL+locustStatsPath, obs.QPS, profile.QPSThreshold)
+	}
+	if obs.ErrorRate > profile.ErrorThreshold {
+		klog.Infof("SPIKE DETECTED: Locust failures %.2f/s > threshold %.2f (profile %s)", obs.ErrorRate, profile.ErrorThreshold, profile.Name)
+		fire(fired, obs, SignalErrors, sd.locustURL+locustStatsPath, obs.ErrorRate, profile.ErrorThreshold)
+	}
+	if obs.P95Ms > profile.P95LatencyThreshold {
+		klog.Infof("SPIKE DETECTED: Locust p95 %.2fms > threshold %.2fms (profile %s)", obs.P95Ms, profile.P95LatencyThreshold, profile.Name)
+		fire(fired, obs, SignalP95, sd.locustURL+locustStatsPath, obs.P95Ms, profile.P95LatencyThreshold)
+	}
+	return fired, true
+}
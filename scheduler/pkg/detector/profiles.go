@@ -0,0 +1,213 @@
+/*
+Threshold Profiles
+==================
+Named spike detection profiles (e.g. business hours vs overnight) so
+long-running experiments and production pilots can vary sensitivity
+without redeploying.
+
+Profiles are read from THRESHOLD_PROFILES as a JSON list, in priority
+order. Each profile carries a cron-like schedule (minute hour
+day-of-month month day-of-week, evaluated in PROFILE_TIMEZONE); the
+first profile whose schedule matches the current minute is active.
+Thresholds left at 0 inherit the SPIKE_* defaults. When no profile
+matches, the "default" profile built from the SPIKE_* variables is used.
+
+  [{"name": "business-hours", "schedule": "* 8-17 * * 1-5", "qpsThreshold": 1500},
+   {"name": "overnight",      "schedule": "* 0-5 * * *",    "qpsThreshold": 400}]
+
+The admin API can pin a profile by name, overriding the schedule until
+the pin is cleared.
+*/
+
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// DefaultProfileName names the profile built from the SPIKE_* variables
+const DefaultProfileName = "default"
+
+// ThresholdProfile is a named set of spike detection thresholds
+type ThresholdProfile struct {
+	Name                string  `json:"name"`
+	Schedule            string  `json:"schedule,omitempty"`
+	QPSThreshold        float64 `json:"qpsThreshold,omitempty"`
+	ErrorThreshold      float64 `json:"errorThreshold,omitempty"`
+	P95LatencyThreshold float64 `json:"p95LatencyThresholdMs,omitempty"`
+	ServiceQPSThreshold float64 `json:"serviceQpsThreshold,omitempty"`
+
+	cron *cronSchedule
+}
+
+// withDefaults fills unset thresholds from the default profile
+func (p ThresholdProfile) withDefaults(def ThresholdProfile) ThresholdProfile {
+	if p.QPSThreshold == 0 {
+		p.QPSThreshold = def.QPSThreshold
+	}
+	if p.ErrorThreshold == 0 {
+		p.ErrorThreshold = def.ErrorThreshold
+	}
+	if p.P95LatencyThreshold == 0 {
+		p.P95LatencyThreshold = def.P95LatencyThreshold
+	}
+	if p.ServiceQPSThreshold == 0 {
+		p.ServiceQPSThreshold = def.ServiceQPSThreshold
+	}
+	return p
+}
+
+// loadThresholdProfiles parses THRESHOLD_PROFILES, skipping invalid entries
+func loadThresholdProfiles(def ThresholdProfile) []ThresholdProfile {
+	raw := os.Getenv("THRESHOLD_PROFILES")
+	if raw == "" {
+		return nil
+	}
+
+	var parsed []ThresholdProfile
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		klog.Warningf("Invalid THRESHOLD_PROFILES, using default thresholds only: %v", err)
+		return nil
+	}
+
+	profiles := make([]ThresholdProfile, 0, len(parsed))
+	for _, p := range parsed {
+		if p.Name == "" || p.Name == DefaultProfileName {
+			klog.Warningf("Skipping threshold profile with reserved or empty name %q", p.Name)
+			continue
+		}
+		if p.Schedule != "" {
+			cron, err := parseCronSchedule(p.Schedule)
+			if err != nil {
+				klog.Warningf("Skipping threshold profile %s: %v", p.Name, err)
+				continue
+			}
+			p.cron = cron
+		}
+		p = p.withDefaults(def)
+		profiles = append(profiles, p)
+		klog.Infof("Threshold profile %s: schedule=%q, QPS=%.0f, ErrorRate=%.0f, p95Latency=%.0fms",
+			p.Name, p.Schedule, p.QPSThreshold, p.ErrorThreshold, p.P95LatencyThreshold)
+	}
+	return profiles
+}
+
+// profileLocation returns the time zone schedules are evaluated in
+func profileLocation() *time.Location {
+	name := os.Getenv("PROFILE_TIMEZONE")
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		klog.Warningf("Invalid PROFILE_TIMEZONE %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// --- Cron-like schedules ---
+
+// cronSchedule matches times against the five standard cron fields
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	domAny, dowAny                bool
+}
+
+// parseCronSchedule parses "minute hour day-of-month month day-of-week".
+// Fields support *, lists (1,3), ranges (8-17) and steps (*/15, 0-30/5).
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var err error
+	cs := &cronSchedule{
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+	if cs.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("schedule %q minute: %w", expr, err)
+	}
+	if cs.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("schedule %q hour: %w", expr, err)
+	}
+	if cs.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("schedule %q day-of-month: %w", expr, err)
+	}
+	if cs.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("schedule %q month: %w", expr, err)
+	}
+	if cs.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("schedule %q day-of-week: %w", expr, err)
+	}
+	if cs.dow[7] {
+		cs.dow[0] = true // 7 is Sunday too
+	}
+	return cs, nil
+}
+
+// parseCronField expands one cron field into the set of matching values
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		base, stepStr, stepped := strings.Cut(part, "/")
+		if stepped {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part)
+			}
+			rangePart, step = base, n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loStr, hiStr, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if stepped && !isRange {
+				hi = max // "5/15" = every 15 starting at 5
+			}
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Matches reports whether t falls in the schedule (to the minute).
+// As in cron, if both day fields are restricted either may match.
+func (cs *cronSchedule) Matches(t time.Time) bool {
+	if !cs.minute[t.Minute()] || !cs.hour[t.Hour()] || !cs.month[int(t.Month())] {
+		return false
+	}
+
+	domMatch := cs.dom[t.Day()]
+	dowMatch := cs.dow[int(t.Weekday())]
+	if !cs.domAny && !cs.dowAny {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,178 @@
+/*
+Historical Replay
+=================
+Thresholds are easier to tune against recorded incidents than against
+live traffic. QueryRange reads the spike signals of a past time window
+from the Prometheus range API (GET /api/v1/query_range, one query per
+signal), and ClassifySample runs one step of it through the same checks
+a live spike check uses:
+
+  QPS, error rate, p95   → against the profile active at the sample time
+                           (a pinned profile applies throughout)
+  HPA increase           → only when nothing else fired, or the
+                           activation expression uses hpa
+  SPIKE_ACTIVATION_EXPR  → applied to the fired signals
+
+Steps without data for a signal count as 0, as an empty instant query
+does. Replay needs Prometheus: the Locust web API has no history, and the
+pending-pod fallback has no recorded input.
+*/
+
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Sample is the value of every spike signal at one step of a range
+type Sample struct {
+	At          time.Time
+	QPS         float64
+	ErrorRate   float64
+	P95Ms       float64
+	HPAIncrease float64
+}
+
+// prometheusMatrix is the response of a Prometheus range query
+type prometheusMatrix struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange returns the spike signals at every step between start and end
+func (sd *SpikeDetector) QueryRange(ctx context.Context, start, end time.Time, step time.Duration) ([]Sample, error) {
+	if sd.source == SpikeSourceLocust {
+		return nil, fmt.Errorf("replay needs Prometheus: the Locust web API has no history")
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end %s is not after start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	var samples []Sample
+	index := make(map[int64]int) // unix milliseconds → sample
+	for at := start; !at.After(end); at = at.Add(step) {
+		index[at.UnixMilli()] = len(samples)
+		samples = append(samples, Sample{At: at})
+	}
+
+	setters := map[string]func(*Sample, float64){
+		qpsQuery:        func(s *Sample, v float64) { s.QPS = v },
+		errorRateQuery:  func(s *Sample, v float64) { s.ErrorRate = v },
+		p95LatencyQuery: func(s *Sample, v float64) { s.P95Ms = v },
+		sd.hpaQuery:     func(s *Sample, v float64) { s.HPAIncrease = v },
+	}
+	for _, query := range []string{qpsQuery, errorRateQuery, p95LatencyQuery, sd.hpaQuery} {
+		series, err := sd.runRangeQuery(ctx, query, start, end, step)
+		if err != nil {
+			return nil, fmt.Errorf("range query %q: %w", query, err)
+		}
+		for at, value := range series {
+			if i, ok := index[at]; ok {
+				setters[query](&samples[i], value)
+			}
+		}
+	}
+	return samples, nil
+}
+
+// runRangeQuery sends a range query and returns the first series by unix
+// milliseconds
+func (sd *SpikeDetector) runRangeQuery(ctx context.Context, query string, start, end time.Time, step time.Duration) (map[int64]float64, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", formatUnix(start))
+	params.Set("end", formatUnix(end))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	queryURL := fmt.Sprintf("%s/api/v1/query_range?%s", sd.prometheusURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Prometheus query: %w", err)
+	}
+	resp, err := sd.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var matrix prometheusMatrix
+	if err := json.Unmarshal(body, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if matrix.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", matrix.Status)
+	}
+
+	series := make(map[int64]float64)
+	if len(matrix.Data.Result) == 0 {
+		return series, nil // No data
+	}
+	for _, pair := range matrix.Data.Result[0].Values {
+		value, err := parseSampleValue(pair)
+		if err != nil {
+			return nil, err
+		}
+		at, ok := pair[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid result format")
+		}
+		series[int64(math.Round(at*1000))] = value
+	}
+	return series, nil
+}
+
+// ClassifySample runs one replayed step through the spike checks; the
+// observation is not recorded as the detector's last one
+func (sd *SpikeDetector) ClassifySample(s Sample) (SpikeClass, Observation) {
+	obs := Observation{
+		Source:       SpikeSourcePrometheus,
+		PrometheusUp: true,
+		QPS:          s.QPS,
+		ErrorRate:    s.ErrorRate,
+		P95Ms:        s.P95Ms,
+		HPAIncrease:  math.NaN(),
+		Users:        math.NaN(),
+		Score:        math.NaN(),
+		Profile:      sd.profileAt(s.At),
+		At:           s.At,
+	}
+
+	fired := make(map[string]bool)
+	checkThresholds(fired, &obs)
+	if sd.needsHPA(fired) {
+		obs.HPAIncrease = s.HPAIncrease
+		sd.checkHPA(fired, &obs)
+	}
+	return sd.classify(fired, &obs), obs
+}
+
+// formatUnix formats t as unix seconds for the Prometheus API
+func formatUnix(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
@@ -0,0 +1,205 @@
+/*
+Spike Score
+===========
+Every check also combines the signals into one continuous spike score:
+each signal divided by its threshold (1 = at the threshold, capped at 3
+so one runaway signal cannot mask the others), weighted by
+SPIKE_SCORE_WEIGHTS and summed:
+
+  score = Σ weight(s) · min(value(s) / threshold(s), 3)
+
+The HPA signal counts replicas added (1 replica = 1). Signals that were
+not observed count 0. The score is exported as nexus_detector_spike_score
+in both detection modes.
+
+SPIKE_DETECTION_MODE=score activates on the score instead of on single
+signals, with hysteresis so the episode does not flap around one value:
+
+  not spiking → spiking    once score ≥ SPIKE_SCORE_THRESHOLD (1)
+  spiking     → not        once score < SPIKE_SCORE_RELEASE (0.8)
+
+Two signals at 60% of their thresholds activate together where neither
+would alone, and sensitivity is one number. The spike is classified by
+the most severe signal above its threshold or, when the signals only
+add up, by the largest contribution, which is then the trigger.
+SPIKE_ACTIVATION_EXPR does not apply in score mode. The pending-pod
+fallback keeps working as in threshold mode.
+*/
+
+package detector
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// Spike detection modes (SPIKE_DETECTION_MODE)
+const (
+	DetectionThreshold = "threshold" // any signal above its threshold (default)
+	DetectionScore     = "score"     // weighted spike score with hysteresis
+)
+
+// scoreSignalCap bounds one signal's normalized value
+const scoreSignalCap = 3.0
+
+// spikeScoring holds the score weights and, in score mode, the hysteresis
+type spikeScoring struct {
+	mode     string
+	weights  map[string]float64 // signal → weight
+	activate float64            // SPIKE_SCORE_THRESHOLD
+	release  float64            // SPIKE_SCORE_RELEASE
+
+	mu      sync.Mutex
+	spiking bool // score crossed activate and has not fallen below release
+}
+
+// loadSpikeScoring reads the detection mode and score settings
+func loadSpikeScoring() *spikeScoring {
+	sc := &spikeScoring{
+		mode:     DetectionThreshold,
+		weights:  map[string]float64{SignalQPS: 1, SignalErrors: 1, SignalP95: 1, SignalHPA: 1},
+		activate: 1,
+		release:  0.8,
+	}
+
+	switch mode := os.Getenv("SPIKE_DETECTION_MODE"); mode {
+	case "", DetectionThreshold:
+	case DetectionScore:
+		sc.mode = DetectionScore
+	default:
+		klog.Warningf("Unknown SPIKE_DETECTION_MODE %q, using %s", mode, DetectionThreshold)
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("SPIKE_SCORE_WEIGHTS")); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			signal, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if _, known := sc.weights[strings.TrimSpace(signal)]; !ok || !known || err != nil || weight < 0 {
+				klog.Warningf("Ignoring SPIKE_SCORE_WEIGHTS entry %q (want signal=weight, signals qps, errors, p95, hpa)", part)
+				continue
+			}
+			sc.weights[strings.TrimSpace(signal)] = weight
+		}
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("SPIKE_SCORE_THRESHOLD"), 64); err == nil && v > 0 {
+		sc.activate = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("SPIKE_SCORE_RELEASE"), 64); err == nil && v >= 0 {
+		sc.release = v
+	}
+	if sc.release > sc.activate {
+		klog.Warningf("SPIKE_SCORE_RELEASE %.2f is above SPIKE_SCORE_THRESHOLD %.2f, using the threshold", sc.release, sc.activate)
+		sc.release = sc.activate
+	}
+
+	if sc.mode == DetectionScore {
+		klog.Infof("Spike detection by score: activate at %.2f, release below %.2f, weights %v", sc.activate, sc.release, sc.weights)
+	}
+	return sc
+}
+
+// signalValues returns each score signal's value and threshold in obs
+// (HPA: threshold 0, any scale-up)
+func signalValues(obs *Observation) map[string][2]float64 {
+	return map[string][2]float64{
+		SignalQPS:    {obs.QPS, obs.Profile.QPSThreshold},
+		SignalErrors: {obs.ErrorRate, obs.Profile.ErrorThreshold},
+		SignalP95:    {obs.P95Ms, obs.Profile.P95LatencyThreshold},
+		SignalHPA:    {obs.HPAIncrease, 0},
+	}
+}
+
+// contributions returns each signal's weighted, normalized value in obs
+func (sc *spikeScoring) contributions(obs *Observation) map[string]float64 {
+	parts := make(map[string]float64, 4)
+	for signal, v := range signalValues(obs) {
+		value, unit := v[0], v[1]
+		if signal == SignalHPA {
+			unit = 1 // one replica added
+		}
+		if math.IsNaN(value) || value <= 0 || unit <= 0 {
+			continue
+		}
+		parts[signal] = sc.weights[signal] * math.Min(value/unit, scoreSignalCap)
+	}
+	return parts
+}
+
+// score returns the spike score of obs
+func (sc *spikeScoring) score(obs *Observation) float64 {
+	total := 0.0
+	for _, part := range sc.contributions(obs) {
+		total += part
+	}
+	return total
+}
+
+// update applies the hysteresis to a new score and reports whether the
+// detector is spiking
+func (sc *spikeScoring) update(score float64) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	switch {
+	case !sc.spiking && score >= sc.activate:
+		sc.spiking = true
+	case sc.spiking && score < sc.release:
+		sc.spiking = false
+	}
+	return sc.spiking
+}
+
+// ScoreThreshold returns SPIKE_SCORE_THRESHOLD and whether the detector
+// activates on the spike score
+func (sd *SpikeDetector) ScoreThreshold() (float64, bool) {
+	return sd.scoring.activate, sd.scoring.mode == DetectionScore
+}
+
+// classifyScore is classify for score mode
+func (sd *SpikeDetector) classifyScore(fired map[string]bool, obs *Observation) SpikeClass {
+	if !sd.scoring.update(obs.Score) {
+		klog.V(2).Infof("No spike detected (score %.2f, threshold %.2f, profile %s)", obs.Score, sd.scoring.activate, obs.Profile.Name)
+		obs.Triggers = nil
+		return SpikeClassNone
+	}
+
+	if len(fired) == 0 {
+		// The signals only add up: the largest contribution is the trigger
+		parts := sd.scoring.contributions(obs)
+		signals := make([]string, 0, len(parts))
+		for signal := range parts {
+			signals = append(signals, signal)
+		}
+		sort.Slice(signals, func(i, j int) bool {
+			if parts[signals[i]] != parts[signals[j]] {
+				return parts[signals[i]] > parts[signals[j]]
+			}
+			return signals[i] < signals[j]
+		})
+		if len(signals) == 0 {
+			return SpikeClassNone // spiking on a score of 0 needs a release of 0
+		}
+		top := signals[0]
+		query := SignalQueries()[top]
+		if obs.Source == SpikeSourceLocust {
+			query = sd.locustURL + locustStatsPath
+		}
+		v := signalValues(obs)[top]
+		fire(fired, obs, top, query, v[0], v[1])
+	}
+	klog.Infof("SPIKE DETECTED: score %.2f ≥ %.2f (signals %v, profile %s)", obs.Score, sd.scoring.activate, firedSignals(fired), obs.Profile.Name)
+
+	sortTriggers(obs.Triggers)
+	class := SpikeClassNone
+	for signal := range fired {
+		if c := signalClasses[signal]; severity(c) > severity(class) {
+			class = c
+		}
+	}
+	return class
+}
@@ -0,0 +1,740 @@
+/*
+Spike Detection Module
+=====================
+Monitors Prometheus (or the Locust web API, see locust.go) for traffic
+spike indicators and triggers NEXUS activation. Implements Algorithm 1:
+Traffic Spike Detection.
+
+Required by Section 2A of the professional review:
+"It must trigger only when:
+  - RPS threshold exceeded OR
+  - HPA scaling event observed OR
+  - p95 latency crosses defined bound"
+
+The spike detector is the GATEKEEPER for the entire NEXUS system.
+Without a spike event, NEXUS remains completely dormant.
+SPIKE_ACTIVATION_EXPR can require a combination of signals instead of
+any one of them (see activation.go), and SPIKE_DETECTION_MODE=score
+activates on a weighted spike score instead (see score.go).
+
+Thresholds come from the active threshold profile (see profiles.go);
+without THRESHOLD_PROFILES the SPIKE_* variables apply at all times.
+
+Every signal is evaluated and the spike is classified by the most
+severe one, so gang policy can respond to what is actually going wrong:
+  - error:   5xx rate above threshold (error budget burning)
+  - latency: p95 latency above bound
+  - traffic: QPS above threshold, HPA scale-up, or the pending-pod
+             fallback when Prometheus is unreachable
+*/
+
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// PromQL queries behind each spike indicator
+const (
+	qpsQuery         = "sum(rate(http_server_request_count[1m]))"
+	errorRateQuery   = `sum(rate(http_server_request_count{response_code=~"5.."}[1m]))`
+	p95LatencyQuery  = `histogram_quantile(0.95, sum(rate(http_server_request_duration_seconds_bucket[1m])) by (le)) * 1000`
+	hpaActivityQuery = "increase(kube_horizontalpodautoscaler_status_current_replicas[2m])"
+)
+
+// SignalQueries returns the PromQL query behind each signal, e.g. to
+// program a fake Prometheus (pkg/promtest)
+func SignalQueries() map[string]string {
+	return map[string]string{
+		SignalQPS:    qpsQuery,
+		SignalErrors: errorRateQuery,
+		SignalP95:    p95LatencyQuery,
+		SignalHPA:    loadHPAScope().query(),
+	}
+}
+
+// SpikeClass identifies what kind of spike is happening ("" = none)
+type SpikeClass string
+
+// Spike classes, from least to most severe
+const (
+	SpikeClassNone    SpikeClass = ""
+	SpikeClassTraffic SpikeClass = "traffic"
+	SpikeClassLatency SpikeClass = "latency"
+	SpikeClassError   SpikeClass = "error"
+)
+
+// SpikeClasses lists every spike class, least severe first
+var SpikeClasses = []SpikeClass{SpikeClassTraffic, SpikeClassLatency, SpikeClassError}
+
+// SpikeDetector monitors for traffic spikes using Prometheus metrics
+type SpikeDetector struct {
+	prometheusURL     string
+	fallbackThreshold int
+	client            *http.Client
+
+	// Signal source for spike checks: Prometheus or the Locust web API
+	source    string
+	locustURL string
+
+	// Per-service attribution for spike-scoped graph builds
+	serviceLabel string
+
+	// Threshold profiles: the SPIKE_* defaults plus scheduled named profiles
+	defaultProfile ThresholdProfile
+	profiles       []ThresholdProfile
+	location       *time.Location
+	profileMu      sync.RWMutex
+	pinnedProfile  string // set via the admin API, overrides schedules
+
+	// Per-service QPS thresholds of the application profiles (service →
+	// threshold), replacing the threshold profile's for those services
+	serviceThresholds map[string]float64
+
+	// HPAs the hpa signal watches and the query behind it (see hpascope.go)
+	hpaScope hpaScope
+	hpaQuery string
+
+	// Required combination of signals (nil = any signal activates)
+	activation *ActivationExpr
+
+	// Spike score weights and SPIKE_DETECTION_MODE (see score.go)
+	scoring *spikeScoring
+
+	// Signal values seen by the last check
+	observationMu sync.Mutex
+	observation   Observation
+}
+
+// Observation is what one spike check saw: the signal values (NaN when the
+// query failed or was not evaluated) and the profile they were compared to
+type Observation struct {
+	Source       string // SPIKE_SOURCE the signals came from
+	PrometheusUp bool
+	LocustUp     bool
+	QPS          float64
+	ErrorRate    float64
+	P95Ms        float64
+	HPAIncrease  float64
+	HPATarget    string  // namespace/name of the HPA with the largest increase
+	Users        float64 // Locust users (NaN with Prometheus)
+	Profile      ThresholdProfile
+	Triggers     []Trigger // signals above their thresholds, most severe first (see trigger.go)
+	Score        float64   // weighted spike score (see score.go; NaN = signals not read)
+	At           time.Time
+}
+
+// PrometheusResponse represents the response from Prometheus API
+type PrometheusResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// NewSpikeDetector creates a new spike detector with configurable thresholds
+func NewSpikeDetector() *SpikeDetector {
+	prometheusURL := os.Getenv("PROMETHEUS_URL")
+	if prometheusURL == "" {
+		prometheusURL = "http://prometheus-server.monitoring:80"
+	}
+
+	qpsThreshold := 1000.0
+	if qpsStr := os.Getenv("SPIKE_QPS_THRESHOLD"); qpsStr != "" {
+		if val, err := strconv.ParseFloat(qpsStr, 64); err == nil {
+			qpsThreshold = val
+		}
+	}
+
+	errorThreshold := 50.0
+	if errStr := os.Getenv("SPIKE_ERROR_THRESHOLD"); errStr != "" {
+		if val, err := strconv.ParseFloat(errStr, 64); err == nil {
+			errorThreshold = val
+		}
+	}
+
+	p95LatencyThreshold := 500.0 // 500ms default
+	if latStr := os.Getenv("SPIKE_P95_LATENCY_THRESHOLD"); latStr != "" {
+		if val, err := strconv.ParseFloat(latStr, 64); err == nil {
+			p95LatencyThreshold = val
+		}
+	}
+
+	serviceLabel := os.Getenv("SPIKE_SERVICE_LABEL")
+	if serviceLabel == "" {
+		serviceLabel = "service"
+	}
+
+	serviceQPSThreshold := 100.0
+	if svcStr := os.Getenv("SPIKE_SERVICE_QPS_THRESHOLD"); svcStr != "" {
+		if val, err := strconv.ParseFloat(svcStr, 64); err == nil {
+			serviceQPSThreshold = val
+		}
+	}
+
+	klog.Infof("Spike detector thresholds: QPS=%.0f, ErrorRate=%.0f, p95Latency=%.0fms",
+		qpsThreshold, errorThreshold, p95LatencyThreshold)
+
+	source, locustURL := loadSpikeSource()
+	if source == SpikeSourceLocust {
+		klog.Infof("Spike detector source: Locust web API at %s", locustURL)
+	}
+
+	defaultProfile := ThresholdProfile{
+		Name:                DefaultProfileName,
+		QPSThreshold:        qpsThreshold,
+		ErrorThreshold:      errorThreshold,
+		P95LatencyThreshold: p95LatencyThreshold,
+		ServiceQPSThreshold: serviceQPSThreshold,
+	}
+
+	hpaScope := loadHPAScope()
+
+	return &SpikeDetector{
+		prometheusURL:     prometheusURL,
+		fallbackThreshold: 5,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		source:         source,
+		locustURL:      locustURL,
+		serviceLabel:   serviceLabel,
+		hpaScope:       hpaScope,
+		hpaQuery:       hpaScope.query(),
+		defaultProfile: defaultProfile,
+		profiles:       loadThresholdProfiles(defaultProfile),
+		location:       profileLocation(),
+		activation:     loadActivationExpr(),
+		scoring:        loadSpikeScoring(),
+	}
+}
+
+// ActiveProfile returns the pinned profile, else the first profile whose
+// schedule matches now, else the default profile
+func (sd *SpikeDetector) ActiveProfile() ThresholdProfile {
+	return sd.profileAt(time.Now())
+}
+
+// profileAt returns the profile active at t (the pin applies at any time)
+func (sd *SpikeDetector) profileAt(t time.Time) ThresholdProfile {
+	sd.profileMu.RLock()
+	defer sd.profileMu.RUnlock()
+
+	if sd.pinnedProfile != "" {
+		if p, ok := sd.lookupProfileLocked(sd.pinnedProfile); ok {
+			return p
+		}
+	}
+
+	t = t.In(sd.location)
+	for _, p := range sd.profiles {
+		if p.cron != nil && p.cron.Matches(t) {
+			return p
+		}
+	}
+	return sd.defaultProfile
+}
+
+// Profiles returns all known profiles, default first
+func (sd *SpikeDetector) Profiles() []ThresholdProfile {
+	return append([]ThresholdProfile{sd.defaultProfile}, sd.profiles...)
+}
+
+// PinnedProfile returns the profile pinned via the admin API ("" = schedule)
+func (sd *SpikeDetector) PinnedProfile() string {
+	sd.profileMu.RLock()
+	defer sd.profileMu.RUnlock()
+	return sd.pinnedProfile
+}
+
+// PinProfile forces a profile regardless of schedule; "" clears the pin
+func (sd *SpikeDetector) PinProfile(name string) error {
+	sd.profileMu.Lock()
+	defer sd.profileMu.Unlock()
+
+	if name != "" {
+		if _, ok := sd.lookupProfileLocked(name); !ok {
+			return fmt.Errorf("unknown threshold profile %q", name)
+		}
+	}
+	sd.pinnedProfile = name
+	klog.Infof("Threshold profile pin set to %q", name)
+	return nil
+}
+
+// lookupProfileLocked finds a profile by name (must hold profileMu)
+func (sd *SpikeDetector) lookupProfileLocked(name string) (ThresholdProfile, bool) {
+	if name == DefaultProfileName {
+		return sd.defaultProfile, true
+	}
+	for _, p := range sd.profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ThresholdProfile{}, false
+}
+
+// Detect checks if a spike is currently happening
+// Implements Algorithm 1: Traffic Spike Detection
+// Returns true if ANY spike indicator exceeds its threshold (or, with
+// SPIKE_ACTIVATION_EXPR, the fired indicators satisfy the expression)
+func (sd *SpikeDetector) Detect(pendingPodCount int) bool {
+	return sd.Classify(pendingPodCount) != SpikeClassNone
+}
+
+// Classify evaluates every spike indicator and returns the class of the
+// most severe one exceeding its threshold (error > latency > traffic)
+func (sd *SpikeDetector) Classify(pendingPodCount int) SpikeClass {
+	return sd.ClassifyContext(context.Background(), pendingPodCount)
+}
+
+// ClassifyContext is Classify with the Prometheus queries bound to ctx:
+// once ctx is done the remaining queries fail immediately
+func (sd *SpikeDetector) ClassifyContext(ctx context.Context, pendingPodCount int) SpikeClass {
+	profile := sd.ActiveProfile()
+	obs := Observation{
+		Source:      sd.source,
+		QPS:         math.NaN(),
+		ErrorRate:   math.NaN(),
+		P95Ms:       math.NaN(),
+		HPAIncrease: math.NaN(),
+		Users:       math.NaN(),
+		Score:       math.NaN(),
+		Profile:     profile,
+		At:          time.Now(),
+	}
+	defer sd.observe(&obs)
+
+	if sd.source == SpikeSourceLocust {
+		fired, ok := sd.locustSignals(ctx, profile, &obs)
+		if !ok {
+			return sd.fallbackClass(pendingPodCount, &obs)
+		}
+		return sd.classify(fired, &obs)
+	}
+
+	// Fallback: if Prometheus is unreachable, use pending pod count
+	if !sd.isPrometheusReachable(ctx) {
+		klog.V(2).Info("Prometheus unreachable, using fallback spike detection")
+		return sd.fallbackClass(pendingPodCount, &obs)
+	}
+
+	obs.PrometheusUp = true
+	fired := make(map[string]bool)
+
+	// Check 1: QPS (Queries Per Second)
+	qps, err := sd.queryQPS(ctx)
+	obs.QPS = observed(qps, err)
+	if err != nil {
+		klog.Warningf("Failed to query QPS: %v", err)
+	}
+
+	// Check 2: Error Rate (5xx errors)
+	errorRate, err := sd.queryErrorRate(ctx)
+	obs.ErrorRate = observed(errorRate, err)
+	if err != nil {
+		klog.Warningf("Failed to query error rate: %v", err)
+	}
+
+	// Check 3: p95 Latency (professional requirement 2A)
+	p95, err := sd.queryP95Latency(ctx)
+	obs.P95Ms = observed(p95, err)
+	if err != nil {
+		klog.Warningf("Failed to query p95 latency: %v", err)
+	}
+
+	checkThresholds(fired, &obs)
+	for _, t := range obs.Triggers {
+		klog.Infof("SPIKE DETECTED: %s %.2f > threshold %.2f (profile %s)", t.Signal, t.Value, t.Threshold, profile.Name)
+	}
+
+	// Check 4: HPA scale-up events (only needed if nothing else fired, or
+	// the activation expression asks for them)
+	if sd.needsHPA(fired) {
+		increase, target, err := sd.queryHPAIncrease(ctx)
+		obs.HPAIncrease = observed(increase, err)
+		obs.HPATarget = target
+		if err != nil {
+			klog.Warningf("Failed to check HPA activity: %v", err)
+		} else if sd.checkHPA(fired, &obs) {
+			klog.Infof("SPIKE DETECTED: HPA scale-up event detected (%s)", target)
+		}
+	}
+
+	return sd.classify(fired, &obs)
+}
+
+// checkThresholds fires the QPS, error rate and p95 signals of obs that
+// exceed the thresholds of its profile (NaN values never fire)
+func checkThresholds(fired map[string]bool, obs *Observation) {
+	profile := obs.Profile
+	if obs.QPS > profile.QPSThreshold {
+		fire(fired, obs, SignalQPS, qpsQuery, obs.QPS, profile.QPSThreshold)
+	}
+	if obs.ErrorRate > profile.ErrorThreshold {
+		fire(fired, obs, SignalErrors, errorRateQuery, obs.ErrorRate, profile.ErrorThreshold)
+	}
+	if obs.P95Ms > profile.P95LatencyThreshold {
+		fire(fired, obs, SignalP95, p95LatencyQuery, obs.P95Ms, profile.P95LatencyThreshold)
+	}
+}
+
+// needsHPA reports whether the HPA signal has to be evaluated: nothing
+// else fired, the activation expression asks for it, or it adds to the
+// spike score in score mode
+func (sd *SpikeDetector) needsHPA(fired map[string]bool) bool {
+	if sd.scoring.mode == DetectionScore && sd.scoring.weights[SignalHPA] > 0 {
+		return true
+	}
+	return len(fired) == 0 || (sd.activation != nil && sd.activation.Uses(SignalHPA))
+}
+
+// checkHPA fires the HPA signal when obs saw a scale-up, naming the HPA
+// behind it as the trigger's target
+func (sd *SpikeDetector) checkHPA(fired map[string]bool, obs *Observation) bool {
+	if obs.HPAIncrease > 0 {
+		fire(fired, obs, SignalHPA, sd.hpaQuery, obs.HPAIncrease, 0)
+		obs.Triggers[len(obs.Triggers)-1].Target = obs.HPATarget
+		return true
+	}
+	return false
+}
+
+// classify returns the class of the most severe fired signal, once the
+// fired signals satisfy the activation expression (or, in score mode, the
+// spike score is high enough)
+func (sd *SpikeDetector) classify(fired map[string]bool, obs *Observation) SpikeClass {
+	obs.Score = sd.scoring.score(obs)
+	if sd.scoring.mode == DetectionScore {
+		return sd.classifyScore(fired, obs)
+	}
+	if sd.activation != nil && len(fired) > 0 && !sd.activation.Eval(fired) {
+		klog.Infof("Spike signals %v do not satisfy activation expression %s", firedSignals(fired), sd.activation)
+		obs.Triggers = nil
+		return SpikeClassNone
+	}
+	sortTriggers(obs.Triggers)
+
+	class := SpikeClassNone
+	for signal := range fired {
+		if c := signalClasses[signal]; severity(c) > severity(class) {
+			class = c
+		}
+	}
+	if class == SpikeClassNone {
+		klog.V(2).Infof("No spike detected (QPS: %.2f, ErrorRate: %.2f, p95: %.2fms, profile: %s)", obs.QPS, obs.ErrorRate, obs.P95Ms, obs.Profile.Name)
+	}
+	return class
+}
+
+// fallbackClass is the pending-pod spike check used while the signal
+// source is unreachable
+func (sd *SpikeDetector) fallbackClass(pendingPodCount int, obs *Observation) SpikeClass {
+	if pendingPodCount >= sd.fallbackThreshold {
+		obs.Triggers = []Trigger{{
+			Signal:    SignalPendingPods,
+			Source:    TriggerSourceFallback,
+			Value:     float64(pendingPodCount),
+			Threshold: float64(sd.fallbackThreshold),
+		}}
+		return SpikeClassTraffic
+	}
+	return SpikeClassNone
+}
+
+// firedSignals lists the fired signals in a stable order
+func firedSignals(fired map[string]bool) []string {
+	signals := make([]string, 0, len(fired))
+	for signal := range fired {
+		signals = append(signals, signal)
+	}
+	sort.Strings(signals)
+	return signals
+}
+
+// severity orders spike classes (higher = more severe, 0 = none)
+func severity(c SpikeClass) int {
+	for i, class := range SpikeClasses {
+		if class == c {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// isPrometheusReachable checks if Prometheus is available
+func (sd *SpikeDetector) isPrometheusReachable(ctx context.Context) bool {
+	url := fmt.Sprintf("%s/api/v1/query?query=up", sd.prometheusURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := sd.client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// queryQPS retrieves the current queries per second across all services
+func (sd *SpikeDetector) queryQPS(ctx context.Context) (float64, error) {
+	return sd.queryPrometheus(ctx, qpsQuery)
+}
+
+// queryErrorRate retrieves the current 5xx error rate
+func (sd *SpikeDetector) queryErrorRate(ctx context.Context) (float64, error) {
+	return sd.queryPrometheus(ctx, errorRateQuery)
+}
+
+// queryP95Latency retrieves the p95 request latency in milliseconds
+func (sd *SpikeDetector) queryP95Latency(ctx context.Context) (float64, error) {
+	return sd.queryPrometheus(ctx, p95LatencyQuery)
+}
+
+// SpikingServices returns the services whose individual QPS exceeds the
+// per-service threshold — the services implicated by the current spike.
+// Returns nil if Prometheus is unreachable or no service stands out.
+func (sd *SpikeDetector) SpikingServices() []string {
+	perService, err := sd.ServiceQPS()
+	if err != nil {
+		klog.Warningf("Failed to query per-service QPS: %v", err)
+		return nil
+	}
+
+	threshold := sd.ActiveProfile().ServiceQPSThreshold
+	sd.profileMu.RLock()
+	overrides := sd.serviceThresholds
+	sd.profileMu.RUnlock()
+	services := make([]string, 0)
+	for svc, qps := range perService {
+		limit := threshold
+		if override, ok := overrides[svc]; ok {
+			limit = override
+		}
+		if qps > limit {
+			services = append(services, svc)
+		}
+	}
+	sort.Strings(services)
+
+	klog.V(2).Infof("Spiking services (QPS > %.0f): %v", threshold, services)
+	return services
+}
+
+// SetServiceThresholds sets the per-service QPS thresholds of the
+// application profiles' services
+func (sd *SpikeDetector) SetServiceThresholds(thresholds map[string]float64) {
+	sd.profileMu.Lock()
+	defer sd.profileMu.Unlock()
+	sd.serviceThresholds = thresholds
+}
+
+// ServiceQPS returns the current request rate of every service
+func (sd *SpikeDetector) ServiceQPS() (map[string]float64, error) {
+	return sd.queryPrometheusVector(context.Background(), sd.serviceQPSQuery(), sd.serviceLabel)
+}
+
+// P95Latency returns the current cluster-wide p95 request latency in milliseconds
+func (sd *SpikeDetector) P95Latency() (float64, error) {
+	return sd.queryP95Latency(context.Background())
+}
+
+// GroupP95Latency returns the p95 latency in milliseconds across all requests
+// served by the given services (0 or NaN when they served no traffic)
+func (sd *SpikeDetector) GroupP95Latency(services []string) (float64, error) {
+	return sd.queryPrometheus(context.Background(), sd.groupP95Query(services))
+}
+
+// groupP95Query is the p95 latency query over a set of services
+func (sd *SpikeDetector) groupP95Query(services []string) string {
+	quoted := make([]string, 0, len(services))
+	for _, svc := range services {
+		quoted = append(quoted, regexp.QuoteMeta(svc))
+	}
+	// A backtick PromQL string keeps the regexp escapes literal
+	return fmt.Sprintf("histogram_quantile(0.95, sum(rate(http_server_request_duration_seconds_bucket{%s=~`%s`}[1m])) by (le)) * 1000",
+		sd.serviceLabel, strings.Join(quoted, "|"))
+}
+
+// serviceQPSQuery is the per-service QPS query used for attribution
+func (sd *SpikeDetector) serviceQPSQuery() string {
+	return fmt.Sprintf("sum by (%s) (rate(http_server_request_count[1m]))", sd.serviceLabel)
+}
+
+// Settings describes the detector's effective configuration for /config
+func (sd *SpikeDetector) Settings() map[string]interface{} {
+	prometheusURL := sd.prometheusURL
+	if u, err := url.Parse(sd.prometheusURL); err == nil {
+		prometheusURL = u.Redacted()
+	}
+
+	activation := "any signal"
+	if sd.activation != nil {
+		activation = sd.activation.String()
+	}
+
+	locustURL := sd.locustURL
+	if u, err := url.Parse(sd.locustURL); err == nil {
+		locustURL = u.Redacted()
+	}
+
+	return map[string]interface{}{
+		"source":        sd.source,
+		"locustUrl":     locustURL,
+		"prometheusUrl": prometheusURL,
+		"activation":    activation,
+		"detectionMode": sd.scoring.mode,
+		"spikeScore": map[string]interface{}{
+			"weights":   sd.scoring.weights,
+			"threshold": sd.scoring.activate,
+			"release":   sd.scoring.release,
+		},
+		"fallbackThreshold": sd.fallbackThreshold,
+		"serviceLabel":      sd.serviceLabel,
+		"hpaScope":          sd.hpaScope,
+		"timezone":          sd.location.String(),
+		"activeProfile":     sd.ActiveProfile().Name,
+		"profiles":          sd.Profiles(),
+		"queries": map[string]string{
+			"qps":        qpsQuery,
+			"errorRate":  errorRateQuery,
+			"p95Latency": p95LatencyQuery,
+			"hpa":        sd.hpaQuery,
+			"serviceQps": sd.serviceQPSQuery(),
+		},
+	}
+}
+
+// observed is a queried signal value, NaN when the query failed
+func observed(value float64, err error) float64 {
+	if err != nil {
+		return math.NaN()
+	}
+	return value
+}
+
+// observe records the observation of the last check
+func (sd *SpikeDetector) observe(obs *Observation) {
+	sd.observationMu.Lock()
+	defer sd.observationMu.Unlock()
+	sd.observation = *obs
+}
+
+// LastObservation returns what the last check saw (zero before the first)
+func (sd *SpikeDetector) LastObservation() Observation {
+	sd.observationMu.Lock()
+	defer sd.observationMu.Unlock()
+	return sd.observation
+}
+
+// queryPrometheus executes a PromQL query and returns the numeric result
+func (sd *SpikeDetector) queryPrometheus(ctx context.Context, query string) (float64, error) {
+	promResp, err := sd.runQuery(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(promResp.Data.Result) == 0 {
+		return 0, nil // No data
+	}
+
+	return parseSampleValue(promResp.Data.Result[0].Value)
+}
+
+// queryPrometheusVector executes a PromQL query and returns one value per
+// distinct value of labelKey in the result vector
+func (sd *SpikeDetector) queryPrometheusVector(ctx context.Context, query, labelKey string) (map[string]float64, error) {
+	promResp, err := sd.runQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64, len(promResp.Data.Result))
+	for _, result := range promResp.Data.Result {
+		key := result.Metric[labelKey]
+		if key == "" {
+			continue
+		}
+		value, err := parseSampleValue(result.Value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// runQuery sends an instant query to the Prometheus HTTP API
+func (sd *SpikeDetector) runQuery(ctx context.Context, query string) (*PrometheusResponse, error) {
+	queryURL := fmt.Sprintf("%s/api/v1/query?query=%s", sd.prometheusURL, url.QueryEscape(query))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Prometheus query: %w", err)
+	}
+	resp, err := sd.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var promResp PrometheusResponse
+	if err := json.Unmarshal(body, &promResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if promResp.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", promResp.Status)
+	}
+
+	return &promResp, nil
+}
+
+// parseSampleValue extracts the numeric value from a [timestamp, "value"] pair
+func parseSampleValue(sample []interface{}) (float64, error) {
+	if len(sample) < 2 {
+		return 0, fmt.Errorf("invalid result format")
+	}
+
+	valueStr, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("value is not a string")
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse value: %w", err)
+	}
+
+	return value, nil
+}
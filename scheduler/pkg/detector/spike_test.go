@@ -0,0 +1,401 @@
+package detector
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/promtest"
+)
+
+// newTestDetector returns a detector querying a fake Prometheus with every
+// signal below the default thresholds
+func newTestDetector(t *testing.T) (*SpikeDetector, *promtest.Server) {
+	t.Helper()
+	fake := promtest.NewServer()
+	t.Cleanup(fake.Close)
+	for _, query := range SignalQueries() {
+		fake.Set(query, 0)
+	}
+	t.Setenv("PROMETHEUS_URL", fake.URL)
+	t.Setenv("SPIKE_SOURCE", "")
+	t.Setenv("SPIKE_ACTIVATION_EXPR", "")
+	t.Setenv("THRESHOLD_PROFILES", "")
+	for _, key := range []string{"SPIKE_QPS_THRESHOLD", "SPIKE_ERROR_THRESHOLD", "SPIKE_P95_LATENCY_THRESHOLD", "SPIKE_SERVICE_QPS_THRESHOLD",
+		"SPIKE_DETECTION_MODE", "SPIKE_SCORE_WEIGHTS", "SPIKE_SCORE_THRESHOLD", "SPIKE_SCORE_RELEASE"} {
+		t.Setenv(key, "")
+	}
+	return NewSpikeDetector(), fake
+}
+
+func TestClassifySignals(t *testing.T) {
+	cases := []struct {
+		name   string
+		signal string
+		value  float64
+		want   SpikeClass
+	}{
+		{"quiet", "", 0, SpikeClassNone},
+		{"qps", SignalQPS, 1500, SpikeClassTraffic},
+		{"errors", SignalErrors, 80, SpikeClassError},
+		{"p95", SignalP95, 900, SpikeClassLatency},
+		{"hpa", SignalHPA, 2, SpikeClassTraffic},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sd, fake := newTestDetector(t)
+			if tc.signal != "" {
+				fake.Set(SignalQueries()[tc.signal], tc.value)
+			}
+
+			if got := sd.Classify(0); got != tc.want {
+				t.Errorf("Classify = %q, want %q", got, tc.want)
+			}
+			obs := sd.LastObservation()
+			if !obs.PrometheusUp || obs.Source != SpikeSourcePrometheus {
+				t.Errorf("observation = %+v, want Prometheus up", obs)
+			}
+			values := map[string]float64{SignalQPS: obs.QPS, SignalErrors: obs.ErrorRate, SignalP95: obs.P95Ms, SignalHPA: obs.HPAIncrease}
+			if tc.signal != "" && values[tc.signal] != tc.value {
+				t.Errorf("observed %s = %v, want %v", tc.signal, values[tc.signal], tc.value)
+			}
+		})
+	}
+}
+
+func TestClassifyMostSevereSignal(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.Set(qpsQuery, 1500)
+	fake.Set(p95LatencyQuery, 900)
+	fake.Set(errorRateQuery, 80)
+
+	if got := sd.Classify(0); got != SpikeClassError {
+		t.Errorf("Classify = %q, want error", got)
+	}
+	// HPA activity is not queried once another signal fired
+	if n := fake.Queries(hpaActivityQuery); n != 0 {
+		t.Errorf("HPA query asked %d times, want 0", n)
+	}
+}
+
+func TestClassifyTriggers(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.Set(qpsQuery, 1500)
+	fake.Set(p95LatencyQuery, 900)
+
+	if got := sd.Classify(0); got != SpikeClassLatency {
+		t.Fatalf("Classify = %q, want latency", got)
+	}
+	obs := sd.LastObservation()
+	if len(obs.Triggers) != 2 {
+		t.Fatalf("triggers = %+v, want p95 and qps", obs.Triggers)
+	}
+	cause, ok := obs.Cause()
+	want := Trigger{Signal: SignalP95, Source: SpikeSourcePrometheus, Query: p95LatencyQuery, Value: 900, Threshold: obs.Profile.P95LatencyThreshold}
+	if !ok || cause != want {
+		t.Errorf("cause = %+v, want %+v", cause, want)
+	}
+	if obs.Triggers[1].Signal != SignalQPS || obs.Triggers[1].Value != 1500 {
+		t.Errorf("second trigger = %+v, want qps at 1500", obs.Triggers[1])
+	}
+
+	fake.Set(qpsQuery, 0)
+	fake.Set(p95LatencyQuery, 0)
+	sd.Classify(0)
+	if _, ok := sd.LastObservation().Cause(); ok {
+		t.Error("quiet check has a cause")
+	}
+
+	fake.SetDown(true)
+	sd.Classify(7)
+	cause, ok = sd.LastObservation().Cause()
+	if !ok || cause.Signal != SignalPendingPods || cause.Source != TriggerSourceFallback || cause.Value != 7 || cause.Threshold != 5 {
+		t.Errorf("fallback cause = %+v, want 7 pending pods against 5", cause)
+	}
+}
+
+func TestClassifyFallback(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.Set(qpsQuery, 1500)
+	fake.SetDown(true)
+
+	if got := sd.Classify(4); got != SpikeClassNone {
+		t.Errorf("Classify(4 pending) = %q, want none below the fallback threshold", got)
+	}
+	if got := sd.Classify(5); got != SpikeClassTraffic {
+		t.Errorf("Classify(5 pending) = %q, want traffic", got)
+	}
+	if obs := sd.LastObservation(); obs.PrometheusUp || !math.IsNaN(obs.QPS) {
+		t.Errorf("observation = %+v, want Prometheus down and no QPS", obs)
+	}
+}
+
+func TestClassifyParseFailures(t *testing.T) {
+	cases := []struct {
+		name   string
+		answer func(fake *promtest.Server)
+	}{
+		{"invalid json", func(fake *promtest.Server) { fake.SetRaw(qpsQuery, "not json") }},
+		{"non-numeric value", func(fake *promtest.Server) {
+			fake.SetRaw(qpsQuery, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0,"many"]}]}}`)
+		}},
+		{"short sample", func(fake *promtest.Server) {
+			fake.SetRaw(qpsQuery, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0]}]}}`)
+		}},
+		{"query error", func(fake *promtest.Server) { fake.SetRaw(qpsQuery, `{"status":"error","data":{}}`) }},
+		{"http error", func(fake *promtest.Server) { fake.SetStatus(qpsQuery, http.StatusBadRequest) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sd, fake := newTestDetector(t)
+			tc.answer(fake)
+			fake.Set(p95LatencyQuery, 900)
+
+			// The failed signal is skipped, the others still count
+			if got := sd.Classify(0); got != SpikeClassLatency {
+				t.Errorf("Classify = %q, want latency", got)
+			}
+			if obs := sd.LastObservation(); !math.IsNaN(obs.QPS) || obs.P95Ms != 900 {
+				t.Errorf("observation QPS %v p95 %v, want NaN and 900", obs.QPS, obs.P95Ms)
+			}
+		})
+	}
+}
+
+func TestClassifyNoData(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.Clear(qpsQuery)
+
+	if got := sd.Classify(0); got != SpikeClassNone {
+		t.Errorf("Classify = %q, want none", got)
+	}
+	if obs := sd.LastObservation(); obs.QPS != 0 {
+		t.Errorf("observed QPS = %v, want 0 for an empty result", obs.QPS)
+	}
+}
+
+func TestClassifyLatencyInjection(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.Set(qpsQuery, 1500)
+	fake.SetDelay(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if got := sd.ClassifyContext(ctx, 0); got != SpikeClassNone {
+		t.Errorf("ClassifyContext = %q, want none from the fallback", got)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("check took %v, want it bounded by the context", elapsed)
+	}
+	if sd.LastObservation().PrometheusUp {
+		t.Error("slow Prometheus reported as up")
+	}
+
+	// Within the budget the delayed answers still count
+	fake.SetDelay(5 * time.Millisecond)
+	if got := sd.ClassifyContext(context.Background(), 0); got != SpikeClassTraffic {
+		t.Errorf("ClassifyContext = %q, want traffic", got)
+	}
+}
+
+func TestActivationExpression(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	t.Setenv("SPIKE_ACTIVATION_EXPR", "qps AND p95")
+	sd = NewSpikeDetector()
+	fake.Set(qpsQuery, 1500)
+
+	if got := sd.Classify(0); got != SpikeClassNone {
+		t.Errorf("Classify = %q, want none with only qps", got)
+	}
+	fake.Set(p95LatencyQuery, 900)
+	if got := sd.Classify(0); got != SpikeClassLatency {
+		t.Errorf("Classify = %q, want latency with qps and p95", got)
+	}
+}
+
+func TestSpikingServices(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.SetVector(sd.serviceQPSQuery(), "service", map[string]float64{
+		"frontend":        250,
+		"cartservice":     150,
+		"adservice":       20,
+		"currencyservice": 100, // not above the threshold
+	})
+
+	want := []string{"cartservice", "frontend"}
+	if got := sd.SpikingServices(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SpikingServices = %v, want %v", got, want)
+	}
+
+	// Application profile thresholds replace the default for their services
+	sd.SetServiceThresholds(map[string]float64{"adservice": 10, "frontend": 300})
+	want = []string{"adservice", "cartservice"}
+	if got := sd.SpikingServices(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SpikingServices with profile thresholds = %v, want %v", got, want)
+	}
+}
+
+func TestQueryRange(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	fake.SetRange(qpsQuery, []promtest.Point{
+		{At: start, Value: 200},
+		{At: start.Add(10 * time.Second), Value: 1500},
+		{At: start.Add(20 * time.Second), Value: 300},
+	})
+	fake.Set(p95LatencyQuery, 120) // no range points: repeated at every step
+	fake.Clear(hpaActivityQuery)   // no data at all
+
+	samples, err := sd.QueryRange(context.Background(), start, start.Add(20*time.Second), 10*time.Second)
+	if err != nil {
+		t.Fatalf("QueryRange: %v", err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("samples = %d, want 3", len(samples))
+	}
+	for i, want := range []float64{200, 1500, 300} {
+		s := samples[i]
+		if !s.At.Equal(start.Add(time.Duration(i) * 10 * time.Second)) {
+			t.Errorf("sample %d at %v", i, s.At)
+		}
+		if s.QPS != want || s.P95Ms != 120 || s.HPAIncrease != 0 {
+			t.Errorf("sample %d = %+v, want qps %v, p95 120, hpa 0", i, s, want)
+		}
+	}
+
+	if _, err := sd.QueryRange(context.Background(), start, start, 10*time.Second); err == nil {
+		t.Error("QueryRange accepted an empty window")
+	}
+	fake.SetStatus(errorRateQuery, http.StatusBadRequest)
+	if _, err := sd.QueryRange(context.Background(), start, start.Add(time.Minute), 10*time.Second); err == nil {
+		t.Error("QueryRange ignored a failed range query")
+	}
+}
+
+func TestClassifySample(t *testing.T) {
+	sd, _ := newTestDetector(t)
+	at := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	class, obs := sd.ClassifySample(Sample{At: at, QPS: 1500, P95Ms: 900, HPAIncrease: 3})
+	if class != SpikeClassLatency {
+		t.Errorf("class = %q, want latency", class)
+	}
+	if cause, ok := obs.Cause(); !ok || cause.Signal != SignalP95 || cause.Value != 900 {
+		t.Errorf("cause = %+v, want p95 at 900", cause)
+	}
+	if !math.IsNaN(obs.HPAIncrease) {
+		t.Errorf("HPA increase = %v, want not evaluated once another signal fired", obs.HPAIncrease)
+	}
+	if class, _ := sd.ClassifySample(Sample{At: at, HPAIncrease: 1}); class != SpikeClassTraffic {
+		t.Errorf("HPA-only class = %q, want traffic", class)
+	}
+	if class, _ := sd.ClassifySample(Sample{At: at, QPS: 10}); class != SpikeClassNone {
+		t.Errorf("quiet class = %q, want none", class)
+	}
+	if got := sd.LastObservation(); !got.At.IsZero() {
+		t.Errorf("ClassifySample recorded observation %+v", got)
+	}
+}
+
+func TestClassifySampleProfileAtSampleTime(t *testing.T) {
+	_, _ = newTestDetector(t)
+	t.Setenv("PROFILE_TIMEZONE", "UTC")
+	t.Setenv("THRESHOLD_PROFILES", `[{"name": "overnight", "schedule": "* 0-5 * * *", "qpsThreshold": 400}]`)
+	sd := NewSpikeDetector()
+
+	night := Sample{At: time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC), QPS: 600}
+	day := Sample{At: time.Date(2026, 10, 1, 14, 0, 0, 0, time.UTC), QPS: 600}
+	if class, obs := sd.ClassifySample(night); class != SpikeClassTraffic || obs.Profile.Name != "overnight" {
+		t.Errorf("night = %q with profile %s, want traffic with overnight", class, obs.Profile.Name)
+	}
+	if class, obs := sd.ClassifySample(day); class != SpikeClassNone || obs.Profile.Name != DefaultProfileName {
+		t.Errorf("day = %q with profile %s, want none with default", class, obs.Profile.Name)
+	}
+}
+
+func TestSpikeScore(t *testing.T) {
+	sd, fake := newTestDetector(t)
+	fake.Set(qpsQuery, 600)
+	fake.Set(p95LatencyQuery, 300)
+	fake.Set(errorRateQuery, 1000) // 20× the threshold, capped at 3
+
+	if got := sd.Classify(0); got != SpikeClassError {
+		t.Errorf("Classify = %q, want error", got)
+	}
+	if score := sd.LastObservation().Score; math.Abs(score-4.2) > 1e-9 {
+		t.Errorf("score = %v, want 0.6 + 0.6 + 3", score)
+	}
+
+	// Below every threshold the score still shows how close the signals are
+	fake.Set(errorRateQuery, 0)
+	if got := sd.Classify(0); got != SpikeClassNone {
+		t.Errorf("threshold mode Classify = %q, want no spike", got)
+	}
+	if score := sd.LastObservation().Score; math.Abs(score-1.2) > 1e-9 {
+		t.Errorf("score = %v, want 1.2", score)
+	}
+}
+
+func TestSpikeScoreHysteresis(t *testing.T) {
+	_, fake := newTestDetector(t)
+	t.Setenv("SPIKE_DETECTION_MODE", DetectionScore)
+	t.Setenv("SPIKE_SCORE_WEIGHTS", "qps=1, errors=2")
+	sd := NewSpikeDetector()
+
+	steps := []struct {
+		qps, p95 float64
+		want     SpikeClass
+	}{
+		{500, 260, SpikeClassLatency}, // 0.5 + 0.52 ≥ 1: p95 contributes most
+		{300, 350, SpikeClassLatency}, // 0.3 + 0.7 = 1.0
+		{100, 400, SpikeClassLatency}, // 0.9 ≥ release 0.8: still spiking
+		{0, 350, SpikeClassNone},      // 0.7 < 0.8: released
+		{100, 400, SpikeClassNone},    // 0.9 < 1: not activated again
+		{1500, 0, SpikeClassTraffic},  // one signal above its threshold
+	}
+	for i, step := range steps {
+		fake.Set(qpsQuery, step.qps)
+		fake.Set(p95LatencyQuery, step.p95)
+		if got := sd.Classify(0); got != step.want {
+			t.Errorf("step %d (qps %v, p95 %v): Classify = %q, want %q", i, step.qps, step.p95, got, step.want)
+		}
+	}
+
+	obs := sd.LastObservation()
+	if cause, ok := obs.Cause(); !ok || cause.Signal != SignalQPS || cause.Threshold != 1000 {
+		t.Errorf("cause = %+v, want the qps trigger", cause)
+	}
+	if w := sd.scoring.weights; w[SignalErrors] != 2 || w[SignalP95] != 1 {
+		t.Errorf("weights = %v, want errors=2 and the p95 default", w)
+	}
+}
+
+func TestHPAScope(t *testing.T) {
+	t.Setenv("SPIKE_HPA_NAMESPACES", "")
+	t.Setenv("SPIKE_HPA_IGNORE_NAMESPACES", "monitoring, kube-system")
+	t.Setenv("SPIKE_HPA_NAMES", "")
+	sd, fake := newTestDetector(t)
+
+	want := "increase(kube_horizontalpodautoscaler_status_current_replicas{namespace!~`monitoring|kube-system`}[2m])"
+	if sd.hpaQuery != want || SignalQueries()[SignalHPA] != want {
+		t.Fatalf("hpa query = %s, want %s", sd.hpaQuery, want)
+	}
+	fake.SetRaw(want, `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"namespace":"shop","horizontalpodautoscaler":"cartservice"},"value":[0,"1"]},
+		{"metric":{"namespace":"shop","horizontalpodautoscaler":"frontend"},"value":[0,"3"]}]}}`)
+	if class := sd.Classify(0); class != SpikeClassTraffic {
+		t.Fatalf("Classify = %q, want traffic", class)
+	}
+	cause, _ := sd.LastObservation().Cause()
+	if cause.Signal != SignalHPA || cause.Target != "shop/frontend" || cause.Value != 3 || cause.Query != want {
+		t.Errorf("cause = %+v, want the shop/frontend scale-up", cause)
+	}
+
+	if q := (hpaScope{Namespaces: []string{"shop"}, Names: []string{"frontend"}}).query(); q != "increase(kube_horizontalpodautoscaler_status_current_replicas{namespace=~`shop`,horizontalpodautoscaler=~`frontend`}[2m])" {
+		t.Errorf("scoped query = %s", q)
+	}
+}
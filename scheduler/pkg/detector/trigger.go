@@ -0,0 +1,75 @@
+/*
+Spike Attribution
+=================
+Every signal that exceeds its threshold during a check is recorded in the
+check's Observation as a Trigger: the signal, where it came from, the
+query behind it, and the observed value against the threshold. The
+trigger that decided the spike class (the most severe one, first in
+Triggers) is the cause of an activation, so the evaluation can separate
+latency-triggered from HPA-triggered episodes.
+*/
+
+package detector
+
+import "sort"
+
+// Trigger sources besides SPIKE_SOURCE (prometheus, locust)
+const (
+	TriggerSourceFallback = "fallback" // pending pods while the signal source is unreachable
+	TriggerSourceKEDA     = "keda"     // active KEDA ScaledObjects
+	TriggerSourceAdmin    = "admin"    // spike injected through the admin API
+)
+
+// Trigger signals besides the detector's spike signals
+const (
+	SignalPendingPods = "pending_pods" // pending-pod fallback check
+	SignalKEDA        = "keda"         // value = active ScaledObject services
+	SignalInjected    = "injected"
+)
+
+// Trigger is one signal that exceeded its threshold
+type Trigger struct {
+	Signal    string  `json:"signal"`           // qps, errors, p95, hpa, pending_pods, keda, injected
+	Source    string  `json:"source"`           // prometheus, locust, fallback, keda, admin
+	Query     string  `json:"query,omitempty"`  // PromQL query or Locust endpoint
+	Target    string  `json:"target,omitempty"` // namespace/name of the HPA (hpa)
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
+
+// Class returns the spike class the trigger raises
+func (t Trigger) Class() SpikeClass {
+	if class, ok := signalClasses[t.Signal]; ok {
+		return class
+	}
+	return SpikeClassTraffic // pending pods, KEDA
+}
+
+// fire records a signal exceeding its threshold
+func fire(fired map[string]bool, obs *Observation, signal, query string, value, threshold float64) {
+	fired[signal] = true
+	obs.Triggers = append(obs.Triggers, Trigger{
+		Signal:    signal,
+		Source:    obs.Source,
+		Query:     query,
+		Value:     value,
+		Threshold: threshold,
+	})
+}
+
+// sortTriggers orders the triggers most severe first, keeping check order
+// within a class
+func sortTriggers(triggers []Trigger) {
+	sort.SliceStable(triggers, func(i, j int) bool {
+		return severity(triggers[i].Class()) > severity(triggers[j].Class())
+	})
+}
+
+// Cause returns the trigger that decided the observation's spike class
+// (false when nothing fired)
+func (o Observation) Cause() (Trigger, bool) {
+	if len(o.Triggers) == 0 {
+		return Trigger{}, false
+	}
+	return o.Triggers[0], true
+}
@@ -0,0 +1,232 @@
+/*
+Decision Export
+===============
+Streams every Prioritize decision to CSV files so the research analysis
+pipeline (pandas.read_csv) can work on the raw decision data instead of
+aggregated metrics. Each decision becomes one row per candidate node:
+
+  timestamp, decision, episode, state, spike_class, namespace, pod, gang,
+  node, locality, topology, resource, utilization, total, normalized, top,
+  decision_id, image, numa
+
+"decision" numbers the decisions since startup and "top" marks the node(s)
+with the highest total, i.e. NEXUS's preferred placement (kube-scheduler
+still combines it with its own scores). "decision_id" is the correlation
+ID returned in the Prioritize response's X-Nexus-Decision-Id header.
+
+Rows are written to DECISION_EXPORT_DIR (a mounted volume) in files
+rotated every DECISION_EXPORT_ROTATE. With DECISION_EXPORT_S3_ENDPOINT
+set, each closed file is uploaded to the S3-compatible bucket and removed
+locally once the upload succeeded; failed uploads stay on the volume.
+
+Recording never blocks the scheduling path: decisions go through a
+bounded buffer and are dropped (and counted) when the writer falls behind.
+Parquet is not produced — it would need a new module dependency, and
+pandas reads the CSV files directly.
+*/
+
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// csvHeader is the column layout of every exported file
+var csvHeader = []string{
+	"timestamp", "decision", "episode", "state", "spike_class", "namespace", "pod", "gang",
+	"node", "locality", "topology", "resource", "utilization", "total", "normalized", "top",
+	"decision_id", "image", "numa",
+}
+
+// Decision is one Prioritize decision with the scores of every candidate node
+type Decision struct {
+	ID         string // correlation ID of the Prioritize response
+	Time       time.Time
+	Episode    string
+	State      string
+	SpikeClass string
+	Namespace  string
+	Pod        string
+	Gang       string
+	Scores     []scorer.ScoreBreakdown
+}
+
+// DecisionExporter writes decisions to rotated CSV files
+type DecisionExporter struct {
+	dir      string
+	rotate   time.Duration
+	records  chan Decision
+	uploader *S3Uploader
+	metrics  *metrics.NEXUSMetrics
+
+	// Writer state (owned by Run)
+	seq    uint64
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewDecisionExporter creates the export directory and, if configured, the S3 uploader
+func NewDecisionExporter(cfg *config.Config, metrics *metrics.NEXUSMetrics) (*DecisionExporter, error) {
+	if err := os.MkdirAll(cfg.DecisionExportDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create decision export directory: %w", err)
+	}
+
+	buffer := cfg.DecisionExportBuffer
+	if buffer <= 0 {
+		buffer = 1
+	}
+	exporter := &DecisionExporter{
+		dir:     cfg.DecisionExportDir,
+		rotate:  cfg.DecisionExportRotate,
+		records: make(chan Decision, buffer),
+		metrics: metrics,
+	}
+	if cfg.DecisionExportS3Endpoint != "" {
+		exporter.uploader = NewS3Uploader(cfg)
+	}
+	return exporter, nil
+}
+
+// Record queues a decision for export, dropping it if the buffer is full
+func (de *DecisionExporter) Record(d Decision) {
+	select {
+	case de.records <- d:
+	default:
+		de.metrics.IncrementCounter("decisions_dropped")
+	}
+}
+
+// Run writes queued decisions until ctx is done, rotating files on schedule
+func (de *DecisionExporter) Run(ctx context.Context) {
+	rotate := de.rotate
+	if rotate <= 0 {
+		rotate = 5 * time.Minute
+	}
+	ticker := time.NewTicker(rotate)
+	defer ticker.Stop()
+
+	klog.Infof("Decision export: writing CSV to %s (rotating every %v)", de.dir, rotate)
+
+	for {
+		select {
+		case <-ctx.Done():
+			de.drain()
+			de.closeFile(context.Background())
+			return
+		case d := <-de.records:
+			de.write(d)
+		case <-ticker.C:
+			de.closeFile(ctx)
+		}
+	}
+}
+
+// drain writes whatever is still buffered
+func (de *DecisionExporter) drain() {
+	for {
+		select {
+		case d := <-de.records:
+			de.write(d)
+		default:
+			return
+		}
+	}
+}
+
+// write appends one decision's rows, opening a new file if needed
+func (de *DecisionExporter) write(d Decision) {
+	if de.writer == nil {
+		if err := de.openFile(d.Time); err != nil {
+			klog.Warningf("Decision export: %v", err)
+			de.metrics.IncrementCounter("decisions_dropped")
+			return
+		}
+	}
+
+	de.seq++
+	top := int64(0)
+	for _, b := range d.Scores {
+		if b.Total > top {
+			top = b.Total
+		}
+	}
+
+	timestamp := d.Time.UTC().Format(time.RFC3339Nano)
+	decision := strconv.FormatUint(de.seq, 10)
+	for _, b := range d.Scores {
+		de.writer.Write([]string{
+			timestamp, decision, d.Episode, d.State, d.SpikeClass, d.Namespace, d.Pod, d.Gang,
+			b.Host,
+			strconv.FormatInt(b.Locality, 10),
+			strconv.FormatInt(b.Topology, 10),
+			strconv.FormatInt(b.Resource, 10),
+			strconv.FormatInt(b.Utilization, 10),
+			strconv.FormatInt(b.Total, 10),
+			strconv.FormatFloat(b.Normalized, 'f', 2, 64),
+			strconv.FormatBool(b.Scanned && b.Total == top),
+			d.ID,
+			strconv.FormatInt(b.Image, 10),
+			strconv.FormatInt(b.NUMA, 10),
+		})
+	}
+	de.writer.Flush()
+	if err := de.writer.Error(); err != nil {
+		klog.Warningf("Decision export: failed to write %s: %v", de.file.Name(), err)
+		de.metrics.IncrementCounter("decisions_dropped")
+		return
+	}
+	de.metrics.IncrementCounter("decisions_exported")
+}
+
+// openFile starts a new CSV file named after the first decision's time
+func (de *DecisionExporter) openFile(start time.Time) error {
+	name := filepath.Join(de.dir, "decisions-"+start.UTC().Format("20060102T150405.000Z")+".csv")
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+
+	de.file = file
+	de.writer = csv.NewWriter(file)
+	de.writer.Write(csvHeader)
+	return nil
+}
+
+// closeFile closes the current file and uploads it when S3 is configured
+func (de *DecisionExporter) closeFile(ctx context.Context) {
+	if de.file == nil {
+		return
+	}
+
+	name := de.file.Name()
+	de.writer.Flush()
+	if err := de.file.Close(); err != nil {
+		klog.Warningf("Decision export: failed to close %s: %v", name, err)
+	}
+	de.file, de.writer = nil, nil
+
+	if de.uploader == nil {
+		return
+	}
+	if err := de.uploader.UploadFile(ctx, name); err != nil {
+		klog.Warningf("Decision export: upload of %s failed, keeping it on the volume: %v", name, err)
+		de.metrics.IncrementCounter("decision_upload_failures")
+		return
+	}
+	de.metrics.IncrementCounter("decision_files_uploaded")
+	if err := os.Remove(name); err != nil {
+		klog.Warningf("Decision export: failed to remove uploaded %s: %v", name, err)
+	}
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// testDecision scores node-2 above node-1
+var testDecision = Decision{
+	ID:         "kx2f9c-1",
+	Time:       time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+	Episode:    "episode-1",
+	State:      "ACTIVE",
+	SpikeClass: "traffic",
+	Namespace:  "default",
+	Pod:        "checkoutservice-7d9f8c6b5-x2k4p",
+	Gang:       "gang-checkout-flow-1",
+	Scores: []scorer.ScoreBreakdown{
+		{Host: "node-1", Resource: 40, Total: 40, Normalized: 28.57, Scanned: true},
+		{Host: "node-2", Locality: 100, Resource: 40, Total: 140, Normalized: 100, Scanned: true},
+	},
+}
+
+func newTestExporter(t *testing.T, s3Endpoint string) *DecisionExporter {
+	t.Helper()
+	cfg := &config.Config{
+		DecisionExportDir:         t.TempDir(),
+		DecisionExportBuffer:      8,
+		DecisionExportS3Endpoint:  s3Endpoint,
+		DecisionExportS3Bucket:    "research",
+		DecisionExportS3Prefix:    "runs/",
+		DecisionExportS3Region:    "us-east-1",
+		DecisionExportS3AccessKey: "AKIDEXAMPLE",
+		DecisionExportS3SecretKey: "secret",
+	}
+	exporter, err := NewDecisionExporter(cfg, metrics.NewNEXUSMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exporter
+}
+
+func TestDecisionExportCSV(t *testing.T) {
+	de := newTestExporter(t, "")
+	de.write(testDecision)
+	de.write(testDecision)
+	name := de.file.Name()
+	de.closeFile(context.Background())
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 5 || strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		t.Fatalf("rows = %v, want header plus 2 decisions × 2 nodes", rows)
+	}
+	want := []string{"2026-10-14T12:00:00Z", "1", "episode-1", "ACTIVE", "traffic", "default",
+		"checkoutservice-7d9f8c6b5-x2k4p", "gang-checkout-flow-1", "node-2", "100", "0", "40", "0", "140", "100.00", "true", "kx2f9c-1", "0", "0"}
+	if got := strings.Join(rows[2], ","); got != strings.Join(want, ",") {
+		t.Errorf("row = %s\nwant  %s", got, strings.Join(want, ","))
+	}
+	if rows[1][15] != "false" || rows[3][1] != "2" {
+		t.Errorf("top/decision columns wrong: %v", rows)
+	}
+}
+
+func TestDecisionExportUpload(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	s3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotAuth, gotBody = r.URL.Path, r.Header.Get("Authorization"), string(body)
+	}))
+	defer s3.Close()
+
+	de := newTestExporter(t, s3.URL)
+	de.write(testDecision)
+	name := de.file.Name()
+	de.closeFile(context.Background())
+
+	if want := "/research/runs/" + filepath.Base(name); gotPath != want {
+		t.Errorf("uploaded to %s, want %s", gotPath, want)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20") || !strings.Contains(gotAuth, "Signature=") {
+		t.Errorf("Authorization = %q, want a SigV4 signature", gotAuth)
+	}
+	if !strings.HasPrefix(gotBody, strings.Join(csvHeader, ",")) {
+		t.Errorf("uploaded body does not start with the CSV header: %q", gotBody)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("uploaded file still on the volume (stat err = %v)", err)
+	}
+}
+
+func TestDecisionExportDropsWhenFull(t *testing.T) {
+	de := newTestExporter(t, "")
+	for i := 0; i < cap(de.records)+3; i++ {
+		de.Record(testDecision)
+	}
+
+	out := httptest.NewRecorder()
+	de.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_decisions_dropped_total 3") {
+		t.Errorf("dropped decisions not counted:\n%s", out.Body.String())
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Episode Traces (OTLP)
+=====================
+Sends a finished spike episode as one trace to an OpenTelemetry collector,
+Jaeger or Tempo over OTLP/HTTP with JSON encoding
+(POST <OTEL_EXPORTER_OTLP_ENDPOINT>/v1/traces), so episode timelines can
+be read next to the application traces of Online Boutique. The first span
+is the episode itself, every other span is one of its stages:
+
+  nexus.episode
+  ├── nexus.detection
+  ├── nexus.graph_build
+  ├── nexus.gang_formation
+  ├── nexus.scheduling   (once per ACTIVE window)
+  ├── nexus.draining
+  └── nexus.dissolution
+
+Trace and span IDs are derived from the episode ID, so re-sending an
+episode yields the same trace. Like the S3 upload, the wire format is
+written by hand instead of pulling the OpenTelemetry SDK into the module.
+*/
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// otlpSpanKindInternal is SPAN_KIND_INTERNAL
+const otlpSpanKindInternal = 1
+
+// Span is one finished span of an episode trace
+type Span struct {
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+}
+
+// TraceExporter posts episode traces to an OTLP/HTTP endpoint
+type TraceExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+// NewTraceExporter creates an exporter from the OTEL_* settings
+func NewTraceExporter(cfg *config.Config) *TraceExporter {
+	return &TraceExporter{
+		endpoint:    strings.TrimSuffix(cfg.OTLPEndpoint, "/"),
+		serviceName: cfg.OTLPServiceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OTLP/JSON request layout (opentelemetry-proto, trace/v1)
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// Export sends the spans as one trace identified by episode; spans[0] is
+// the root and the parent of every other span
+func (te *TraceExporter) Export(ctx context.Context, episode string, spans []Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(traceRequest(te.serviceName, episode, spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, te.endpoint+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := te.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("OTLP export of %s returned %s: %s", episode, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// traceRequest builds the OTLP/JSON request body of an episode trace
+func traceRequest(serviceName, episode string, spans []Span) otlpRequest {
+	traceID := traceHash(episode)[:16]
+	rootID := traceHash(episode, "0")[:8]
+
+	encoded := make([]otlpSpan, 0, len(spans))
+	for i, span := range spans {
+		out := otlpSpan{
+			TraceID:    hex.EncodeToString(traceID),
+			SpanID:     hex.EncodeToString(traceHash(episode, strconv.Itoa(i))[:8]),
+			Name:       span.Name,
+			Kind:       otlpSpanKindInternal,
+			Start:      strconv.FormatInt(span.Start.UnixNano(), 10),
+			End:        strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes: otlpAttributes(span.Attributes),
+		}
+		if i > 0 {
+			out.ParentSpanID = hex.EncodeToString(rootID)
+		}
+		encoded = append(encoded, out)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]string{"service.name": serviceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "nexus-scheduler"}, Spans: encoded}},
+	}}}
+}
+
+// traceHash returns the SHA-256 of the joined parts
+func traceHash(parts ...string) []byte {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "/")))
+	return sum[:]
+}
+
+// otlpAttributes converts attributes to string key-values, sorted by key
+func otlpAttributes(attributes map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]otlpAttribute, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, otlpAttribute{Key: key, Value: otlpValue{StringValue: attributes[key]}})
+	}
+	return out
+}
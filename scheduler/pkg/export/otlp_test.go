@@ -0,0 +1,73 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestTraceExport(t *testing.T) {
+	var got otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request %s %s (%s), want POST /v1/traces JSON", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding OTLP body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	spans := []Span{
+		{Name: "nexus.episode", Start: start, End: start.Add(time.Minute), Attributes: map[string]string{"nexus.episode_id": "episode-1"}},
+		{Name: "nexus.detection", Start: start, End: start.Add(time.Second)},
+	}
+	te := NewTraceExporter(&config.Config{OTLPEndpoint: srv.URL + "/", OTLPServiceName: "nexus-scheduler"})
+	if err := te.Export(context.Background(), "episode-1", spans); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("request = %+v, want one resource and scope", got)
+	}
+	if attrs := got.ResourceSpans[0].Resource.Attributes; len(attrs) != 1 || attrs[0].Value.StringValue != "nexus-scheduler" {
+		t.Errorf("resource attributes = %+v, want service.name", attrs)
+	}
+	sent := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(sent) != 2 {
+		t.Fatalf("%d spans sent, want 2", len(sent))
+	}
+	root, stage := sent[0], sent[1]
+	if len(root.TraceID) != 32 || len(root.SpanID) != 16 || root.ParentSpanID != "" {
+		t.Errorf("root = %+v, want a 16-byte trace and 8-byte span ID without parent", root)
+	}
+	if stage.TraceID != root.TraceID || stage.ParentSpanID != root.SpanID || stage.SpanID == root.SpanID {
+		t.Errorf("stage = %+v, want a child of the root", stage)
+	}
+	if root.Start != "1791979200000000000" || root.End != "1791979260000000000" {
+		t.Errorf("root times = %s..%s", root.Start, root.End)
+	}
+
+	// The same episode yields the same trace
+	if again := traceRequest("nexus-scheduler", "episode-1", spans); again.ResourceSpans[0].ScopeSpans[0].Spans[0].TraceID != root.TraceID {
+		t.Error("trace ID not derived from the episode")
+	}
+}
+
+func TestTraceExportRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unsupported", http.StatusUnsupportedMediaType)
+	}))
+	defer srv.Close()
+
+	te := NewTraceExporter(&config.Config{OTLPEndpoint: srv.URL})
+	if err := te.Export(context.Background(), "episode-1", []Span{{Name: "nexus.episode"}}); err == nil {
+		t.Error("rejected export returned no error")
+	}
+}
@@ -0,0 +1,138 @@
+/*
+S3-Compatible Upload
+====================
+Uploads rotated decision files with a single path-style PUT
+(<endpoint>/<bucket>/<prefix><file>), signed with AWS Signature V4 when
+credentials are configured. This covers AWS S3, MinIO and Ceph RGW
+without pulling an SDK into the module; anonymous PUTs are sent when no
+access key is set (e.g. a MinIO bucket with a write policy).
+*/
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// S3Uploader PUTs files into an S3-compatible bucket
+type S3Uploader struct {
+	endpoint  string
+	bucket    string
+	prefix    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3Uploader creates an uploader from the DECISION_EXPORT_S3_* settings
+func NewS3Uploader(cfg *config.Config) *S3Uploader {
+	return &S3Uploader{
+		endpoint:  strings.TrimSuffix(cfg.DecisionExportS3Endpoint, "/"),
+		bucket:    cfg.DecisionExportS3Bucket,
+		prefix:    cfg.DecisionExportS3Prefix,
+		region:    cfg.DecisionExportS3Region,
+		accessKey: cfg.DecisionExportS3AccessKey,
+		secretKey: cfg.DecisionExportS3SecretKey,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// UploadFile uploads a local file under the configured prefix
+func (u *S3Uploader) UploadFile(ctx context.Context, path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	target, err := url.Parse(u.endpoint + "/" + u.bucket + "/" + u.prefix + filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	if u.accessKey != "" {
+		u.sign(req, body, time.Now().UTC())
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("PUT %s returned %d: %s", target.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign adds AWS Signature V4 headers for a single-chunk payload
+func (u *S3Uploader) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + u.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+u.secretKey), date)
+	key = hmacSHA256(key, u.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.accessKey, scope, signedHeaders, signature))
+}
+
+// sha256Hex returns the lowercase hex SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns HMAC-SHA256(key, data)
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,122 @@
+/*
+Admin API
+=========
+Operator endpoints for changing NEXUS behaviour at runtime without a
+redeploy. Every request must carry "Authorization: Bearer <ADMIN_TOKEN>";
+when ADMIN_TOKEN is unset the admin API is disabled (403).
+
+  GET    /admin/profiles        → Threshold profiles, active and pinned profile
+  PUT    /admin/profiles/active → Pin a profile: {"name": "overnight"}
+  DELETE /admin/profiles/active → Clear the pin (back to schedules)
+  GET|PUT|DELETE /admin/formation → Gang formation strategy (see formation.go)
+  GET|DELETE     /admin/backoff   → Activation flapping back-off (see flap.go)
+  GET|DELETE     /admin/latency   → Latency budget self-disable (see latencyguard.go)
+  GET|POST|DELETE /admin/spike    → Synthetic spike for the next check (see inject.go)
+  POST /admin/activate, /admin/deactivate → Idempotent episode start and end (see episodeadmin.go)
+  GET  /admin/snapshot → Sanitized cluster snapshot for offline simulation (see snapshot.go)
+*/
+
+package extender
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+// adminProfilesResponse is returned by GET /admin/profiles
+type adminProfilesResponse struct {
+	Active   string                      `json:"active"`
+	Pinned   string                      `json:"pinned,omitempty"`
+	Profiles []detector.ThresholdProfile `json:"profiles"`
+}
+
+// adminProfileRequest is the body of PUT /admin/profiles/active
+type adminProfileRequest struct {
+	Name string `json:"name"`
+}
+
+// RegisterAdminHandlers adds the admin endpoints to mux
+func (s *NEXUSScheduler) RegisterAdminHandlers(mux *http.ServeMux, token string) {
+	if token == "" {
+		klog.Info("Admin API disabled (ADMIN_TOKEN not set)")
+	}
+
+	mux.HandleFunc("/admin/profiles", requireAdminToken(token, s.handleAdminProfiles))
+	mux.HandleFunc("/admin/profiles/active", requireAdminToken(token, s.handleAdminActiveProfile))
+	mux.HandleFunc("/admin/formation", requireAdminToken(token, s.handleAdminFormation))
+	mux.HandleFunc("/admin/backoff", requireAdminToken(token, s.handleAdminBackoff))
+	mux.HandleFunc("/admin/latency", requireAdminToken(token, s.handleAdminLatency))
+	mux.HandleFunc("/admin/spike", requireAdminToken(token, s.handleAdminSpike))
+	mux.HandleFunc("/admin/activate", requireAdminToken(token, s.handleAdminActivate))
+	mux.HandleFunc("/admin/deactivate", requireAdminToken(token, s.handleAdminDeactivate))
+	mux.HandleFunc("/admin/snapshot", requireAdminToken(token, s.SnapshotHandler))
+}
+
+// requireAdminToken rejects requests without the admin bearer token
+func requireAdminToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.Error(w, "admin API disabled", http.StatusForbidden)
+			return
+		}
+
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAdminProfiles lists the threshold profiles
+func (s *NEXUSScheduler) handleAdminProfiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeProfiles(w)
+}
+
+// handleAdminActiveProfile pins (PUT) or unpins (DELETE) a threshold profile
+func (s *NEXUSScheduler) handleAdminActiveProfile(w http.ResponseWriter, r *http.Request) {
+	var name string
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		var req adminProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		name = req.Name
+	case http.MethodDelete:
+		name = ""
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.spikeDetector.PinProfile(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.metrics.SetThresholdProfile(s.spikeDetector.ActiveProfile().Name)
+	s.writeProfiles(w)
+}
+
+// writeProfiles encodes the current profile state
+func (s *NEXUSScheduler) writeProfiles(w http.ResponseWriter) {
+	resp := adminProfilesResponse{
+		Active:   s.spikeDetector.ActiveProfile().Name,
+		Pinned:   s.spikeDetector.PinnedProfile(),
+		Profiles: s.spikeDetector.Profiles(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
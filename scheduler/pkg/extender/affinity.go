@@ -0,0 +1,225 @@
+/*
+Affinity Hints
+==============
+An "annotation-only" comparison arm: with AFFINITY_HINTS=true NEXUS turns
+its gangs into plain Kubernetes scheduling hints that the default
+scheduler honours without the extender being registered at all. On
+activation every gang-member Deployment (Deployment name == service name)
+gets, in its pod template:
+
+  label        nexus.io/gang-group: <group>
+  annotation   nexus.io/affinity-hint: <group>
+  affinity     preferred podAffinity (AFFINITY_HINT_WEIGHT) towards pods
+               labelled nexus.io/gang-group=<group>, per node
+
+The Deployment itself is labelled nexus.io/affinity-hinted=true so the
+hints can be found again; they are removed when the gangs dissolve, and
+left-overs from a restart outside a spike are removed at startup. Only
+the affinity term NEXUS added is removed, the Deployment's own affinity
+is kept.
+
+Changing the pod template rolls the Deployment, both when the hints are
+added and when they are removed: replicas created by the spike's scale-up
+carry the hints from the start, existing ones are replaced with them.
+That cost is part of what the comparison arm measures.
+*/
+
+package extender
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/metrics"
+)
+
+const (
+	// labelGangGroup marks the pods of a hinted member Deployment with its gang's group
+	labelGangGroup = "nexus.io/gang-group"
+
+	// annotationAffinityHint records on the pod template that NEXUS added a hint
+	annotationAffinityHint = "nexus.io/affinity-hint"
+
+	// labelAffinityHinted marks Deployments NEXUS has hinted
+	labelAffinityHinted = "nexus.io/affinity-hinted"
+)
+
+// AffinityHinter adds and removes soft pod affinity hints on gang-member Deployments
+type AffinityHinter struct {
+	clientset kubernetes.Interface
+	apiGuard  *kube.APIGuard
+	metrics   *metrics.NEXUSMetrics
+	weight    int32
+	namespace string // "" = all namespaces
+}
+
+// NewAffinityHinter creates a hinter adding preferred affinity terms of the given weight
+func NewAffinityHinter(clientset kubernetes.Interface, apiGuard *kube.APIGuard, metrics *metrics.NEXUSMetrics, weight int32, namespace string) *AffinityHinter {
+	return &AffinityHinter{
+		clientset: clientset,
+		apiGuard:  apiGuard,
+		metrics:   metrics,
+		weight:    weight,
+		namespace: namespace,
+	}
+}
+
+// Hint adds the affinity hint to every Deployment of an active gang member
+// that is not hinted yet and returns the number of Deployments hinted
+func (ah *AffinityHinter) Hint(ctx context.Context, gangs *gang.GangManager) int {
+	deployments, err := ah.list(ctx, "list deployments for affinity hints", metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Affinity hints: failed to list deployments: %v", err)
+		return 0
+	}
+
+	hinted := 0
+	for i := range deployments {
+		d := &deployments[i]
+		g := gangs.GetGangForService(d.Name)
+		if g == nil || d.Labels[labelAffinityHinted] == "true" {
+			continue
+		}
+
+		setLabel(&d.ObjectMeta, labelAffinityHinted, "true")
+		setLabel(&d.Spec.Template.ObjectMeta, labelGangGroup, g.Group)
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = make(map[string]string)
+		}
+		d.Spec.Template.Annotations[annotationAffinityHint] = g.Group
+		addAffinityTerm(&d.Spec.Template.Spec, ah.affinityTerm(g.Group))
+
+		if ah.update(ctx, d) {
+			hinted++
+			ah.metrics.IncrementCounter("affinity_hints_added")
+		}
+	}
+
+	if hinted > 0 {
+		klog.Infof("Affinity hints: hinted %d gang member deployments", hinted)
+	}
+	return hinted
+}
+
+// Release removes the hints from every Deployment NEXUS hinted and returns
+// the number of Deployments released
+func (ah *AffinityHinter) Release(ctx context.Context) int {
+	deployments, err := ah.list(ctx, "list hinted deployments", metav1.ListOptions{
+		LabelSelector: labelAffinityHinted + "=true",
+	})
+	if err != nil {
+		klog.Warningf("Affinity hints: failed to list hinted deployments: %v", err)
+		return 0
+	}
+
+	released := 0
+	for i := range deployments {
+		d := &deployments[i]
+		delete(d.Labels, labelAffinityHinted)
+		delete(d.Spec.Template.Labels, labelGangGroup)
+		delete(d.Spec.Template.Annotations, annotationAffinityHint)
+		removeAffinityTerms(&d.Spec.Template.Spec)
+
+		if ah.update(ctx, d) {
+			released++
+			ah.metrics.IncrementCounter("affinity_hints_removed")
+		}
+	}
+
+	if released > 0 {
+		klog.Infof("Affinity hints: released %d deployments", released)
+	}
+	return released
+}
+
+// affinityTerm is the preferred podAffinity term towards a group's pods
+func (ah *AffinityHinter) affinityTerm(group string) v1.WeightedPodAffinityTerm {
+	return v1.WeightedPodAffinityTerm{
+		Weight: ah.weight,
+		PodAffinityTerm: v1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{labelGangGroup: group}},
+			TopologyKey:   v1.LabelHostname,
+		},
+	}
+}
+
+// addAffinityTerm appends a preferred podAffinity term to a pod spec
+func addAffinityTerm(spec *v1.PodSpec, term v1.WeightedPodAffinityTerm) {
+	if spec.Affinity == nil {
+		spec.Affinity = &v1.Affinity{}
+	}
+	if spec.Affinity.PodAffinity == nil {
+		spec.Affinity.PodAffinity = &v1.PodAffinity{}
+	}
+	pa := spec.Affinity.PodAffinity
+	pa.PreferredDuringSchedulingIgnoredDuringExecution = append(pa.PreferredDuringSchedulingIgnoredDuringExecution, term)
+}
+
+// removeAffinityTerms drops the preferred podAffinity terms NEXUS added,
+// and the affinity structs left empty by that
+func removeAffinityTerms(spec *v1.PodSpec) {
+	if spec.Affinity == nil || spec.Affinity.PodAffinity == nil {
+		return
+	}
+	pa := spec.Affinity.PodAffinity
+	kept := pa.PreferredDuringSchedulingIgnoredDuringExecution[:0]
+	for _, term := range pa.PreferredDuringSchedulingIgnoredDuringExecution {
+		if sel := term.PodAffinityTerm.LabelSelector; sel != nil && sel.MatchLabels[labelGangGroup] != "" {
+			continue
+		}
+		kept = append(kept, term)
+	}
+	pa.PreferredDuringSchedulingIgnoredDuringExecution = kept
+	if len(kept) == 0 {
+		pa.PreferredDuringSchedulingIgnoredDuringExecution = nil
+		if len(pa.RequiredDuringSchedulingIgnoredDuringExecution) == 0 {
+			spec.Affinity.PodAffinity = nil
+		}
+	}
+	if a := spec.Affinity; a.PodAffinity == nil && a.PodAntiAffinity == nil && a.NodeAffinity == nil {
+		spec.Affinity = nil
+	}
+}
+
+// setLabel sets a label, creating the label map if needed
+func setLabel(meta *metav1.ObjectMeta, key, value string) {
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string)
+	}
+	meta.Labels[key] = value
+}
+
+// list lists Deployments through the API guard
+func (ah *AffinityHinter) list(ctx context.Context, name string, opts metav1.ListOptions) ([]appsv1.Deployment, error) {
+	var list *appsv1.DeploymentList
+	err := ah.apiGuard.Do(ctx, name, func(ctx context.Context) error {
+		var err error
+		list, err = ah.clientset.AppsV1().Deployments(ah.namespace).List(ctx, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// update writes a modified Deployment back through the API guard; a
+// conflict leaves it for the next refresh
+func (ah *AffinityHinter) update(ctx context.Context, d *appsv1.Deployment) bool {
+	err := ah.apiGuard.Do(ctx, "update deployment affinity hints", func(ctx context.Context) error {
+		_, err := ah.clientset.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Warningf("Affinity hints: failed to update %s/%s: %v", d.Namespace, d.Name, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// memberDeployment returns a Deployment named after a service
+func memberDeployment(name string, affinity *v1.Affinity) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec:       v1.PodSpec{Affinity: affinity},
+			},
+		},
+	}
+}
+
+func TestAffinityHintsLifecycle(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+
+	ownAffinity := &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+			Weight:          10,
+			PodAffinityTerm: v1.PodAffinityTerm{TopologyKey: v1.LabelTopologyZone},
+		}},
+	}}
+	clientset := fake.NewSimpleClientset(
+		memberDeployment("cartservice", nil),
+		memberDeployment("checkoutservice", ownAffinity),
+		memberDeployment("frontend", nil),
+	)
+	hinter := NewAffinityHinter(clientset, s.apiGuard, s.metrics, 80, "")
+
+	ctx := context.Background()
+	get := func(name string) *appsv1.Deployment {
+		d, err := clientset.AppsV1().Deployments("default").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	if got := hinter.Hint(ctx, s.gangManager); got != 2 {
+		t.Fatalf("Hint hinted %d deployments, want the 2 checkout-flow members", got)
+	}
+	if again := hinter.Hint(ctx, s.gangManager); again != 0 {
+		t.Errorf("second Hint hinted %d deployments, want 0 (already hinted)", again)
+	}
+
+	cart := get("cartservice")
+	if cart.Spec.Template.Labels[labelGangGroup] != "checkout-flow" || cart.Spec.Template.Annotations[annotationAffinityHint] != "checkout-flow" {
+		t.Errorf("cartservice template = %v %v, want the checkout-flow hint", cart.Spec.Template.Labels, cart.Spec.Template.Annotations)
+	}
+	terms := cart.Spec.Template.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(terms) != 1 || terms[0].Weight != 80 || terms[0].PodAffinityTerm.TopologyKey != v1.LabelHostname ||
+		terms[0].PodAffinityTerm.LabelSelector.MatchLabels[labelGangGroup] != "checkout-flow" {
+		t.Errorf("cartservice affinity terms = %+v, want one hostname term towards checkout-flow", terms)
+	}
+	if frontend := get("frontend"); frontend.Spec.Template.Spec.Affinity != nil || frontend.Labels[labelAffinityHinted] != "" {
+		t.Errorf("non-member frontend was modified: %v %+v", frontend.Labels, frontend.Spec.Template.Spec.Affinity)
+	}
+
+	if got := hinter.Release(ctx); got != 2 {
+		t.Fatalf("Release released %d deployments, want 2", got)
+	}
+
+	cart = get("cartservice")
+	if cart.Spec.Template.Spec.Affinity != nil || cart.Labels[labelAffinityHinted] != "" ||
+		cart.Spec.Template.Labels[labelGangGroup] != "" || cart.Spec.Template.Annotations[annotationAffinityHint] != "" {
+		t.Errorf("cartservice still hinted after release: %v %v %+v", cart.Labels, cart.Spec.Template.ObjectMeta, cart.Spec.Template.Spec.Affinity)
+	}
+
+	// The Deployment's own affinity is kept on release
+	checkout := get("checkoutservice")
+	if a := checkout.Spec.Template.Spec.Affinity; a == nil || a.PodAffinity != nil || len(a.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Errorf("checkoutservice affinity after release = %+v, want only its own anti-affinity", a)
+	}
+}
@@ -0,0 +1,73 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/scorer"
+)
+
+func TestAnchorMembers(t *testing.T) {
+	pods := []v1.Pod{
+		requestingPod("cartservice-6d5c7b8f9-abcde", "anchor-node", "100m"),
+		requestingPod("checkoutservice-7d9f8c6b5-klmno", "peer-node", "100m"),
+		requestingPod("checkoutservice-7d9f8c6b5-pqrst", "peer-node", "100m"),
+	}
+	for i := range pods {
+		pods[i].UID = types.UID(pods[i].Name)
+	}
+	s := newTestScheduler(t, StateActive, pods...)
+	nodes := &v1.NodeList{Items: []v1.Node{testNode("anchor-node"), testNode("peer-node"), testNode("empty-node")}}
+	checkout := requestingPod("checkoutservice-7d9f8c6b5-new", "", "100m")
+	cart := requestingPod("cartservice-6d5c7b8f9-new", "", "100m")
+	g := s.gangManager.GetGangForPod(&checkout)
+
+	localities := func(pod *v1.Pod) map[string]int64 {
+		got := make(map[string]int64)
+		for _, b := range s.nodeScorer.Score(context.Background(), pod, nodes, g, scorer.Locality{Scale: 1}) {
+			got[b.Host] = b.Locality
+		}
+		return got
+	}
+
+	// Symmetric: the node with the most members wins
+	if got := localities(&checkout); got["peer-node"] <= got["anchor-node"] {
+		t.Fatalf("without anchors: locality = %v, want peer-node ahead", got)
+	}
+
+	s.gangManager.ApplyPolicies(map[string]gang.Policy{"checkout-flow": {Group: "checkout-flow", Anchors: []string{"cartservice", "redis-cart"}}})
+	for _, warm := range []bool{false, true} {
+		if warm {
+			s.gangManager.WarmMemberCounts(pods)
+		}
+		got := localities(&checkout)
+		if got["anchor-node"] != 100 || got["peer-node"] != 0 || got["empty-node"] != 0 {
+			t.Errorf("warm=%v: checkoutservice locality = %v, want only anchor-node", warm, got)
+		}
+		for host, locality := range localities(&cart) {
+			if locality != 0 {
+				t.Errorf("warm=%v: anchor pod scored locality %d on %s", warm, locality, host)
+			}
+		}
+	}
+
+	breakdown := s.nodeScorer.Score(context.Background(), &checkout, nodes, g, scorer.Locality{Scale: 1})
+	if !breakdown[0].Anchored {
+		t.Error("breakdown not marked anchored")
+	}
+	// Spreading ignores anchors
+	if breakdown := s.nodeScorer.Score(context.Background(), &checkout, nodes, g, scorer.Locality{Scale: 1, Spread: true}); breakdown[0].Anchored {
+		t.Error("spread placement used the anchors")
+	}
+
+	// Without a gang there is nothing to be local to
+	for _, b := range s.nodeScorer.Score(context.Background(), &checkout, nodes, nil, scorer.Locality{Scale: 1}) {
+		if b.Locality != 0 || b.Anchored {
+			t.Errorf("scored without a gang: %+v", b)
+		}
+	}
+}
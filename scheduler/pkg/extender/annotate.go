@@ -0,0 +1,193 @@
+/*
+Deployment Annotation Tool
+==========================
+`nexus-scheduler annotate` writes the nexus.io/service-group and
+nexus.io/depends-on annotations of the embedded Online Boutique profile
+(pkg/graph) onto the pod templates of the application's Deployments, so
+the experiment setup is one command:
+
+  nexus-scheduler annotate -namespace default
+  nexus-scheduler annotate -dry-run     → show what would change
+  nexus-scheduler annotate -profile bookinfo   → an APP_PROFILES profile
+                                                 (in its first namespace)
+
+The cluster is reached through KUBECONFIG (or ~/.kube/config), or the
+in-cluster service account. Annotations the profile does not set for a
+service (e.g. depends-on of cartservice) are removed, so re-running is
+idempotent; Deployments already annotated are left untouched.
+
+Changing a pod template rolls the Deployment: its pods are replaced with
+annotated ones. Deployments of the profile that do not exist in the
+namespace are reported and skipped.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+// Annotation outcomes of one Deployment
+const (
+	annotatePatched   = "patched"
+	annotateWould     = "would patch"
+	annotateUnchanged = "unchanged"
+	annotateMissing   = "missing"
+	annotateFailed    = "failed"
+)
+
+// AnnotateResult is the outcome of annotating one Deployment
+type AnnotateResult struct {
+	Deployment  string
+	Annotations map[string]string // annotations set by the profile
+	Action      string
+	Err         error
+}
+
+// RunAnnotate implements the annotate subcommand and returns the exit code;
+// connect builds the Kubernetes client once the flags are valid
+func RunAnnotate(args []string, connect func() (kubernetes.Interface, error)) int {
+	fs := flag.NewFlagSet("annotate", flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "namespace of the Deployments (default: the profile's first, else default)")
+	profileName := fs.String("profile", graph.OnlineBoutique.Name, "application profile (embedded or APP_PROFILES)")
+	dryRun := fs.Bool("dry-run", false, "report the changes without patching")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	loadAppProfiles(os.Getenv("APP_PROFILES"))
+	profile, ok := graph.LookupProfile(*profileName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown application profile %q\n", *profileName)
+		return 2
+	}
+	if *namespace == "" {
+		*namespace = "default"
+		if len(profile.Namespaces) > 0 {
+			*namespace = profile.Namespaces[0]
+		}
+	}
+
+	clientset, err := connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build Kubernetes client: %v\n", err)
+		return 1
+	}
+
+	results := AnnotateDeployments(context.Background(), clientset, *namespace, profile, *dryRun)
+
+	code := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "deployment\tservice-group\tdepends-on\taction")
+	for _, r := range results {
+		action := r.Action
+		if r.Err != nil {
+			action = fmt.Sprintf("%s: %v", action, r.Err)
+			code = 1
+		}
+		fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\n", *namespace, r.Deployment,
+			dashIfEmpty(r.Annotations[graph.AnnotationServiceGroup]),
+			dashIfEmpty(r.Annotations[graph.AnnotationDependsOn]), action)
+	}
+	tw.Flush()
+	return code
+}
+
+// AnnotateDeployments brings the pod template annotations of every
+// Deployment of the profile in line with it
+func AnnotateDeployments(ctx context.Context, clientset kubernetes.Interface, namespace string, profile graph.AppProfile, dryRun bool) []AnnotateResult {
+	results := make([]AnnotateResult, 0, len(profile.Services))
+	for _, svc := range profile.Services {
+		want := svc.Annotations()
+		result := AnnotateResult{Deployment: svc.Name, Annotations: want}
+
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, svc.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			result.Action = annotateMissing
+		case err != nil:
+			result.Action, result.Err = annotateFailed, err
+		}
+		if result.Action != "" {
+			results = append(results, result)
+			continue
+		}
+
+		patch := annotationPatch(deployment.Spec.Template.Annotations, want)
+		switch {
+		case len(patch) == 0:
+			result.Action = annotateUnchanged
+		case dryRun:
+			result.Action = annotateWould
+		default:
+			result.Action = annotatePatched
+			result.Err = patchTemplateAnnotations(ctx, clientset, namespace, svc.Name, patch)
+			if result.Err != nil {
+				result.Action = annotateFailed
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// annotationPatch returns the annotation changes needed to reach want; ""
+// in want means the annotation must be absent (null in the merge patch)
+func annotationPatch(current, want map[string]string) map[string]interface{} {
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	patch := make(map[string]interface{})
+	for _, key := range keys {
+		value, present := current[key]
+		switch {
+		case want[key] == "" && present:
+			patch[key] = nil
+		case want[key] != "" && value != want[key]:
+			patch[key] = want[key]
+		}
+	}
+	return patch
+}
+
+// patchTemplateAnnotations applies a JSON merge patch of a Deployment's
+// pod template annotations
+func patchTemplateAnnotations(ctx context.Context, clientset kubernetes.Interface, namespace, name string, annotations map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": annotations},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode patch: %w", err)
+	}
+	_, err = clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, body, metav1.PatchOptions{})
+	return err
+}
+
+// dashIfEmpty renders an unset table cell
+func dashIfEmpty(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+// annotatedDeployment is a Deployment whose pod template carries annotations
+func annotatedDeployment(name string, annotations map[string]string) *appsv1.Deployment {
+	d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	d.Spec.Template.Annotations = annotations
+	return d
+}
+
+func TestAnnotateDeployments(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		annotatedDeployment("checkoutservice", nil),
+		annotatedDeployment("cartservice", map[string]string{ // stale depends-on is removed
+			graph.AnnotationServiceGroup: "checkout-flow",
+			graph.AnnotationDependsOn:    "redis-cart",
+			"sidecar.istio.io/inject":    "true",
+		}),
+		annotatedDeployment("paymentservice", map[string]string{graph.AnnotationServiceGroup: "checkout-flow"}),
+	)
+	profile := graph.AppProfile{Services: []graph.ServiceProfile{
+		graph.OnlineBoutique.Services[0], // cartservice
+		graph.OnlineBoutique.Services[1], // paymentservice
+		graph.OnlineBoutique.Services[2], // checkoutservice
+		{Name: "adservice"},
+	}}
+	ctx := context.Background()
+
+	actions := func(results []AnnotateResult) map[string]string {
+		out := make(map[string]string, len(results))
+		for _, r := range results {
+			if r.Err != nil {
+				t.Fatalf("%s: %v", r.Deployment, r.Err)
+			}
+			out[r.Deployment] = r.Action
+		}
+		return out
+	}
+
+	// A dry run reports without patching
+	got := actions(AnnotateDeployments(ctx, clientset, "default", profile, true))
+	want := map[string]string{"cartservice": annotateWould, "paymentservice": annotateUnchanged, "checkoutservice": annotateWould, "adservice": annotateMissing}
+	for name, action := range want {
+		if got[name] != action {
+			t.Errorf("dry run: %s = %q, want %q", name, got[name], action)
+		}
+	}
+	if d, _ := clientset.AppsV1().Deployments("default").Get(ctx, "checkoutservice", metav1.GetOptions{}); len(d.Spec.Template.Annotations) != 0 {
+		t.Fatalf("dry run patched checkoutservice: %v", d.Spec.Template.Annotations)
+	}
+
+	got = actions(AnnotateDeployments(ctx, clientset, "default", profile, false))
+	if got["cartservice"] != annotatePatched || got["checkoutservice"] != annotatePatched {
+		t.Errorf("actions = %v, want cartservice and checkoutservice patched", got)
+	}
+
+	checkout, _ := clientset.AppsV1().Deployments("default").Get(ctx, "checkoutservice", metav1.GetOptions{})
+	if a := checkout.Spec.Template.Annotations; a[graph.AnnotationServiceGroup] != "checkout-flow" || a[graph.AnnotationDependsOn] != "cartservice,paymentservice,currencyservice" {
+		t.Errorf("checkoutservice annotations = %v", a)
+	}
+	cart, _ := clientset.AppsV1().Deployments("default").Get(ctx, "cartservice", metav1.GetOptions{})
+	if a := cart.Spec.Template.Annotations; a["sidecar.istio.io/inject"] != "true" {
+		t.Errorf("cartservice annotations = %v, want other annotations kept", a)
+	}
+	if _, ok := cart.Spec.Template.Annotations[graph.AnnotationDependsOn]; ok {
+		t.Errorf("cartservice still has %s", graph.AnnotationDependsOn)
+	}
+
+	// Re-running changes nothing
+	for name, action := range actions(AnnotateDeployments(ctx, clientset, "default", profile, false)) {
+		if action != annotateUnchanged && action != annotateMissing {
+			t.Errorf("second run: %s = %q, want unchanged", name, action)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Gang Priority Arbitration
+=========================
+Gangs of one episode compete for the same nodes: checkout-flow and
+product-browsing both pull their members toward the nodes already
+holding them, and whichever gang's pods arrive first takes the capacity.
+A NexusPolicy's priority (spec.priority, default 0) settles the contest
+deterministically. After scoring, every node another active gang claims
+(members on it, or planned for it) is arbitrated against the strongest
+such rival:
+
+  rival priority higher  score × ARBITRATION_YIELD_SCALE  (yielded)
+  own priority higher    score unchanged, the rival's pods yield (won)
+  equal priorities       no arbitration
+
+Outcomes are logged and counted in nexus_gang_arbitrations_total. With
+every gang at the same priority (no policies) scoring is unchanged.
+*/
+
+package extender
+
+import (
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+)
+
+// Arbitration outcomes
+const (
+	arbitrationWon     = "won"
+	arbitrationYielded = "yielded"
+)
+
+// arbitration is the outcome of one contested node score
+type arbitration struct {
+	host    string
+	rival   *gang.Gang
+	outcome string
+	before  int64
+	after   int64
+}
+
+// arbitrate applies the gang priority arbitration to the scores of the
+// nodes other gangs claim and returns the outcomes
+func (s *NEXUSScheduler) arbitrate(g *gang.Gang, priorities []HostPriority) []arbitration {
+	if s.gangManager.GetActiveGangCount() < 2 {
+		return nil
+	}
+	var outcomes []arbitration
+	for i := range priorities {
+		if priorities[i].Score <= 0 {
+			continue
+		}
+		rival := s.gangManager.NodeRival(g, priorities[i].Host)
+		if rival == nil || rival.Priority() == g.Priority() {
+			continue
+		}
+		a := arbitration{host: priorities[i].Host, rival: rival, outcome: arbitrationWon, before: priorities[i].Score, after: priorities[i].Score}
+		if rival.Priority() > g.Priority() {
+			a.outcome = arbitrationYielded
+			a.after = int64(math.Round(float64(a.before) * s.cfg.ArbitrationYieldScale))
+			priorities[i].Score = a.after
+		}
+		outcomes = append(outcomes, a)
+	}
+	return outcomes
+}
+
+// recordArbitrations logs and counts the arbitration outcomes of a pod
+func (s *NEXUSScheduler) recordArbitrations(pod *v1.Pod, g *gang.Gang, outcomes []arbitration) {
+	for _, a := range outcomes {
+		klog.V(2).Infof("Arbitration: node %s contested by gang %s (priority %d) — pod %s of gang %s (priority %d) %s, score %d → %d",
+			a.host, a.rival.ID, a.rival.Priority(), pod.Name, g.ID, g.Priority(), a.outcome, a.before, a.after)
+		s.metrics.RecordArbitration(a.outcome)
+	}
+}
@@ -0,0 +1,57 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+)
+
+func TestGangPriorityArbitration(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.gangManager.FormGangs([]graph.RuntimeGroup{
+		{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice"}},
+		{Name: "product-browsing", Services: []string{"frontend", "productcatalogservice"}},
+	})
+	s.gangManager.UpdateNodePreference("cartservice", "node-1")
+	s.gangManager.UpdateNodePreference("frontend", "node-2")
+
+	checkout := s.gangManager.GetGangForService("checkoutservice")
+	browsing := s.gangManager.GetGangForService("productcatalogservice")
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-abc"}}
+	scores := func(g *gang.Gang) []HostPriority {
+		priorities := []HostPriority{{Host: "node-1", Score: 80}, {Host: "node-2", Score: 60}, {Host: "node-3", Score: 40}}
+		s.recordArbitrations(pod, g, s.arbitrate(g, priorities))
+		return priorities
+	}
+
+	// Equal priorities: no arbitration
+	if got := scores(checkout); got[1].Score != 60 {
+		t.Errorf("equal priorities: node-2 score = %d, want 60", got[1].Score)
+	}
+
+	s.gangManager.ApplyPolicies(map[string]gang.Policy{"product-browsing": {Group: "product-browsing", Priority: 5}})
+	got := scores(checkout)
+	if got[0].Score != 80 || got[1].Score != 30 || got[2].Score != 40 {
+		t.Errorf("lower-priority gang scores = %+v, want node-2 halved", got)
+	}
+	if got := scores(browsing); got[0].Score != 80 {
+		t.Errorf("higher-priority gang: node-1 score = %d, want 80", got[0].Score)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_gang_arbitrations_total{outcome="won"} 1`,
+		`nexus_gang_arbitrations_total{outcome="yielded"} 1`,
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
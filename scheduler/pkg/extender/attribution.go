@@ -0,0 +1,71 @@
+/*
+Spike Attribution
+=================
+Each activation records the signal that caused it: the detector's most
+severe trigger (signal, query, observed value and threshold), the KEDA
+check when ScaledObjects activated NEXUS, or the admin API for injected
+spikes. The cause is stored in the episode record (GET /history) and in
+the activation record, so a recovered episode keeps it, and every
+activation is counted by cause:
+
+  nexus_spike_triggers_total{signal="p95",source="prometheus"}
+
+Evaluation can then separate latency-triggered from HPA-triggered
+episodes. Re-detections that extend an episode or end a drain do not
+change its cause.
+*/
+
+package extender
+
+import (
+	"nexus-scheduler/pkg/detector"
+)
+
+// noteSpikeTrigger remembers the cause of the last check that detected a
+// spike and whether the cause or another fired signal was severe or
+// strong enough to extend the episode
+func (s *NEXUSScheduler) noteSpikeTrigger(trigger detector.Trigger, fired []detector.Trigger) {
+	triggers := append([]detector.Trigger{trigger}, fired...)
+	severe, extendable := s.severeSpike(triggers), s.extendableSpike(triggers)
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.lastTrigger = trigger
+	s.lastSevere = severe
+	s.lastExtendable = extendable
+}
+
+// lastSpikeTrigger returns the cause of the last check that detected a spike
+func (s *NEXUSScheduler) lastSpikeTrigger() detector.Trigger {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.lastTrigger
+}
+
+// SpikeTrigger returns the cause of the current spike episode (nil when IDLE
+// or unknown)
+func (s *NEXUSScheduler) SpikeTrigger() *detector.Trigger {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.spikeTrigger
+}
+
+// setSpikeTrigger records the cause of the current spike episode
+func (s *NEXUSScheduler) setSpikeTrigger(trigger *detector.Trigger) {
+	s.stateMu.Lock()
+	s.spikeTrigger = trigger
+	s.stateMu.Unlock()
+
+	if trigger != nil {
+		s.updateEpisode(func(ep *EpisodeRecord) { ep.Trigger = trigger })
+	}
+}
+
+// attributeActivation records the cause of a new activation and counts it
+func (s *NEXUSScheduler) attributeActivation() {
+	trigger := s.lastSpikeTrigger()
+	s.metrics.IncrementSpikeTrigger(trigger.Signal, trigger.Source)
+	if trigger.Signal == detector.SignalHPA && trigger.Target != "" {
+		s.metrics.IncrementHPAActivation(trigger.Target)
+	}
+	s.setSpikeTrigger(&trigger)
+}
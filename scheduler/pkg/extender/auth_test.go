@@ -0,0 +1,113 @@
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+// authAPIServer accepts only the given bearer token
+func authAPIServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Unauthorized","code":401}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","items":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAuthRefresherRebuildsAfterPersistentUnauthorized(t *testing.T) {
+	srv := authAPIServer(t, "rotated")
+	auth := kube.NewAuthRefresher(func() (*rest.Config, error) {
+		return &rest.Config{Host: srv.URL, BearerToken: "rotated"}, nil
+	}, 2)
+
+	restConfig := &rest.Config{Host: srv.URL, BearerToken: "expired"}
+	auth.Wrap(restConfig)
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := func() error {
+		_, err := clientset.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if err := list(); err == nil {
+			t.Fatalf("call %d with the expired token succeeded", i+1)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := list(); err != nil {
+			t.Fatalf("call after the rebuild: %v", err)
+		}
+	}
+
+	s := newTestScheduler(t, StateIdle)
+	s.SetAuthRefresher(auth)
+	out := httptest.NewRecorder()
+	s.MetricsHandler(out, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		"nexus_api_auth_ok 1",
+		"nexus_api_unauthorized_total 2",
+		"nexus_api_auth_rebuilds_total 1",
+		"nexus_api_auth_rebuild_failures_total 0",
+	} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestAuthRefresherReportsFailingCredentials(t *testing.T) {
+	srv := authAPIServer(t, "rotated")
+	auth := kube.NewAuthRefresher(func() (*rest.Config, error) {
+		return nil, errors.New("token file missing")
+	}, 2)
+
+	restConfig := &rest.Config{Host: srv.URL, BearerToken: "expired"}
+	auth.Wrap(restConfig)
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		clientset.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+	}
+
+	s := newTestScheduler(t, StateIdle)
+	s.SetAuthRefresher(auth)
+	rec := httptest.NewRecorder()
+	s.StatusHandler(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var status map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status["apiAuth"] != "FAILING" {
+		t.Errorf("apiAuth = %v, want FAILING", status["apiAuth"])
+	}
+
+	out := httptest.NewRecorder()
+	s.MetricsHandler(out, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{"nexus_api_auth_ok 0", "nexus_api_auth_rebuild_failures_total 1"} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
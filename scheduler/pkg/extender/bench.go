@@ -0,0 +1,362 @@
+/*
+Self-Benchmark
+==============
+`nexus-scheduler bench` runs the Filter and Prioritize handlers
+in-process against generated clusters of varying node/pod counts and
+prints latency percentiles and allocations per call — the overhead
+tables for the paper, without a cluster.
+
+  nexus-scheduler bench -nodes 10,100,500 -pods 100,1000,5000 -iterations 500
+  MAX_NODES_SCANNED=0 nexus-scheduler bench -nodes 1000,5000 -pods 10000 -shards 1,4,16
+  nexus-scheduler bench -nodes 100 -pods 1000 -transport inproc,tcp,unix
+
+-shards measures each size once per SCORING_SHARDS value, sharding every
+size whatever SCORING_SHARD_MIN_NODES says. The node budget comes from
+the environment like the other settings; lift it to score every node.
+
+-transport measures each call in-process (inproc, the default), or as an
+HTTP round trip from a keep-alive client over loopback TCP (tcp) or a
+Unix socket (unix, see socket.go), so the tables show what the listener
+adds. Over a socket the allocations include the client's.
+
+Each size is measured with NEXUS IDLE (the steady-state "no opinion"
+path) and ACTIVE (gangs formed, locality scoring). Kubernetes API calls
+are served by an in-memory fake clientset, so the numbers are the
+extender's own overhead; a real cluster adds API round trips on top.
+*/
+
+package extender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+)
+
+// benchServices are the Online Boutique services generated pods belong to
+var benchServices = []string{
+	"frontend", "cartservice", "productcatalogservice", "currencyservice",
+	"paymentservice", "shippingservice", "emailservice", "checkoutservice",
+	"recommendationservice", "adservice",
+}
+
+// benchResult is the measured overhead of one endpoint at one size
+type benchResult struct {
+	Nodes, Pods   int
+	Shards        int
+	Transport     string
+	State         SchedulerState
+	Endpoint      string
+	P50, P95, P99 time.Duration
+	AllocsPerOp   uint64
+	BytesPerOp    uint64
+}
+
+// RunBench implements the bench subcommand and returns the exit code
+func RunBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	nodesFlag := fs.String("nodes", "10,100,500", "comma-separated node counts")
+	podsFlag := fs.String("pods", "100,1000,5000", "comma-separated pod counts")
+	shardsFlag := fs.String("shards", "1", "comma-separated SCORING_SHARDS values")
+	transportFlag := fs.String("transport", benchInProcess, "comma-separated transports: inproc, tcp, unix")
+	iterations := fs.Int("iterations", 200, "calls per endpoint and size")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	nodeCounts, err := parseIntList(*nodesFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -nodes: %v\n", err)
+		return 2
+	}
+	podCounts, err := parseIntList(*podsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -pods: %v\n", err)
+		return 2
+	}
+	shardCounts, err := parseIntList(*shardsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -shards: %v\n", err)
+		return 2
+	}
+	transports := strings.Split(*transportFlag, ",")
+	for i, transport := range transports {
+		transports[i] = strings.TrimSpace(transport)
+		if transports[i] != benchInProcess && transports[i] != "tcp" && transports[i] != "unix" {
+			fmt.Fprintf(os.Stderr, "invalid -transport: %q is not inproc, tcp or unix\n", transport)
+			return 2
+		}
+	}
+	if *iterations <= 0 {
+		fmt.Fprintln(os.Stderr, "-iterations must be positive")
+		return 2
+	}
+
+	silenceLogs()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "nodes\tpods\tshards\ttransport\tstate\tendpoint\tp50\tp95\tp99\tallocs/op\tbytes/op\t")
+	for _, nodes := range nodeCounts {
+		for _, pods := range podCounts {
+			for _, shards := range shardCounts {
+				for _, r := range benchSize(nodes, pods, shards, transports, *iterations) {
+					fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t\n",
+						r.Nodes, r.Pods, r.Shards, r.Transport, r.State, r.Endpoint,
+						formatBenchDuration(r.P50), formatBenchDuration(r.P95), formatBenchDuration(r.P99),
+						r.AllocsPerOp, r.BytesPerOp)
+				}
+			}
+		}
+	}
+	tw.Flush()
+	return 0
+}
+
+// benchSize measures both endpoints in IDLE and ACTIVE state for one size,
+// over every transport
+func benchSize(nodes, pods, shards int, transports []string, iterations int) []benchResult {
+	nodeList, podsByNode, allPods := generateBenchCluster(nodes, pods)
+
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		// The object tracker ignores field selectors; emulate spec.nodeName
+		fields := action.(k8stesting.ListAction).GetListRestrictions().Fields
+		if nodeName, ok := fields.RequiresExactMatch("spec.nodeName"); ok {
+			return true, &v1.PodList{Items: podsByNode[nodeName]}, nil
+		}
+		return true, &v1.PodList{Items: allPods}, nil
+	})
+
+	cfg := config.LoadConfig()
+	cfg.StateRecovery = false
+	cfg.KEDATrigger = false
+	cfg.UtilizationScoring = false
+	cfg.MaxInfluencedPods = 0
+	cfg.ScoreDebug = config.ScoreDebugOff
+	cfg.ScoringShards = shards
+	cfg.ScoringShardMinNodes = 0
+
+	scheduler := NewNEXUSScheduler(clientset, nil, cfg)
+
+	body, err := json.Marshal(ExtenderArgs{
+		Pod: &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "checkoutservice-bench-new",
+				Namespace: "default",
+				UID:       "bench-new-replica",
+			},
+		},
+		Nodes: nodeList,
+	})
+	if err != nil {
+		klog.Fatalf("Failed to encode bench request: %v", err)
+	}
+
+	results := make([]benchResult, 0, 4)
+	for _, state := range []SchedulerState{StateIdle, StateActive} {
+		if state == StateActive {
+			ctx := context.Background()
+			if err := scheduler.depGraph.BuildFromAnnotations(ctx); err != nil {
+				klog.Fatalf("Failed to build bench dependency graph: %v", err)
+			}
+			scheduler.gangManager.SetStage(gang.GangStageDetected)
+			scheduler.gangManager.SetStage(gang.GangStageGraphBuilt)
+			scheduler.gangManager.FormGangs(scheduler.depGraph.GetGroups())
+			scheduler.startEpisode(newEpisodeID(time.Now()), time.Now())
+		}
+		scheduler.SetState(state)
+
+		for _, endpoint := range []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{"filter", scheduler.HandleFilter},
+			{"prioritize", scheduler.HandlePrioritize},
+		} {
+			for _, transport := range transports {
+				call, stop, err := benchCaller(transport, endpoint.handler)
+				if err != nil {
+					klog.Fatalf("Failed to serve the bench %s transport: %v", transport, err)
+				}
+				r := measureCalls(call, body, iterations)
+				stop()
+				r.Nodes, r.Pods, r.Shards, r.Transport, r.State, r.Endpoint = nodes, pods, shards, transport, state, endpoint.name
+				results = append(results, r)
+			}
+		}
+	}
+	return results
+}
+
+// benchInProcess is the transport calling the handler directly
+const benchInProcess = "inproc"
+
+// benchCaller returns a function making one call to handler over the
+// transport, and a function stopping the listener it serves
+func benchCaller(transport string, handler http.HandlerFunc) (func(body []byte), func(), error) {
+	if transport == benchInProcess {
+		call := func(body []byte) {
+			handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+		}
+		return call, func() {}, nil
+	}
+
+	addr, dir := "127.0.0.1:0", ""
+	if transport == "unix" {
+		var err error
+		if dir, err = os.MkdirTemp("", "nexus-bench-"); err != nil {
+			return nil, nil, err
+		}
+		addr = unixAddrPrefix + filepath.Join(dir, "extender.sock")
+	}
+	ln, err := listen(addr)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(ln)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, ln.Addr().Network(), ln.Addr().String())
+		},
+	}}
+	call := func(body []byte) {
+		resp, err := client.Post("http://nexus/", "application/json", bytes.NewReader(body))
+		if err != nil {
+			klog.Fatalf("Bench call over %s failed: %v", transport, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	stop := func() {
+		client.CloseIdleConnections()
+		server.Close()
+		os.RemoveAll(dir)
+	}
+	return call, stop, nil
+}
+
+// measureCalls makes iterations calls and records latency percentiles and
+// average allocations per call
+func measureCalls(call func(body []byte), body []byte, iterations int) benchResult {
+	// Warm up caches, lazily initialised state and the client connection
+	for i := 0; i < 5; i++ {
+		call(body)
+	}
+
+	durations := make([]time.Duration, iterations)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		call(body)
+		durations[i] = time.Since(start)
+	}
+	runtime.ReadMemStats(&after)
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	percentile := func(p float64) time.Duration {
+		return durations[int(p*float64(iterations-1))]
+	}
+
+	return benchResult{
+		P50:         percentile(0.50),
+		P95:         percentile(0.95),
+		P99:         percentile(0.99),
+		AllocsPerOp: (after.Mallocs - before.Mallocs) / uint64(iterations),
+		BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(iterations),
+	}
+}
+
+// generateBenchCluster creates ready nodes and Online Boutique pods spread
+// round-robin across them
+func generateBenchCluster(nodes, pods int) (*v1.NodeList, map[string][]v1.Pod, []v1.Pod) {
+	nodeList := &v1.NodeList{Items: make([]v1.Node, 0, nodes)}
+	for i := 0; i < nodes; i++ {
+		nodeList.Items = append(nodeList.Items, v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("bench-node-%d", i)},
+			Status: v1.NodeStatus{
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("4"),
+					v1.ResourceMemory: resource.MustParse("16Gi"),
+				},
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		})
+	}
+
+	podsByNode := make(map[string][]v1.Pod, nodes)
+	allPods := make([]v1.Pod, 0, pods)
+	for i := 0; i < pods; i++ {
+		service := benchServices[i%len(benchServices)]
+		nodeName := nodeList.Items[i%nodes].Name
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-bench-%d", service, i),
+				Namespace: "default",
+				Labels:    map[string]string{"app": service},
+			},
+			Spec: v1.PodSpec{NodeName: nodeName},
+		}
+		podsByNode[nodeName] = append(podsByNode[nodeName], pod)
+		allPods = append(allPods, pod)
+	}
+	return nodeList, podsByNode, allPods
+}
+
+// silenceLogs discards klog output so per-call logging does not dominate
+func silenceLogs() {
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	fs.Set("logtostderr", "false")
+	fs.Set("alsologtostderr", "false")
+	fs.Set("stderrthreshold", "FATAL")
+	klog.SetOutput(io.Discard)
+}
+
+// parseIntList parses a comma-separated list of positive integers
+func parseIntList(str string) ([]int, error) {
+	vals := make([]int, 0)
+	for _, part := range strings.Split(str, ",") {
+		val, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || val <= 0 {
+			return nil, fmt.Errorf("%q is not a positive integer", part)
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// formatBenchDuration prints a duration in milliseconds with µs precision
+func formatBenchDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,114 @@
+/*
+Extender Protocol Compatibility
+===============================
+kube-scheduler sends Filter/Prioritize arguments in one of two shapes,
+chosen by the extender's nodeCacheCapable setting. The JSON field names
+have not changed from the legacy Policy API (scheduler/api/v1) to
+kube-scheduler/extender/v1:
+
+  nodeCacheCapable: false → "nodes":     full v1.NodeList, reply in "nodes"
+  nodeCacheCapable: true  → "nodenames": node names only,  reply in "nodenames"
+
+kube-scheduler only reads the field matching its own setting, so a reply in
+the other shape silently filters out every node. Responses therefore always
+mirror the shape of the request. Name-only requests are scored against
+name-only nodes: gang locality still applies, while topology levels and the
+resource/utilization components have no node data to work with.
+
+EXTENDER_PROTOCOL records the shape the cluster is configured for. Requests
+in the other shape (e.g. after a scheduler upgrade rewrote the extender
+config) are still answered correctly, but are counted and logged so the
+drift is visible.
+*/
+
+package extender
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// requestProtocol returns the node format used by a Filter/Prioritize request
+// ("" when the request carries no candidate nodes)
+func requestProtocol(args *ExtenderArgs) string {
+	switch {
+	case args.Nodes != nil:
+		return config.ExtenderProtocolNodes
+	case args.NodeNames != nil:
+		return config.ExtenderProtocolNodeNames
+	default:
+		return ""
+	}
+}
+
+// observeProtocol records the request's node format and reports requests that
+// do not match EXTENDER_PROTOCOL, logging once per change of format
+func (s *NEXUSScheduler) observeProtocol(args *ExtenderArgs) {
+	protocol := requestProtocol(args)
+	if protocol == "" {
+		return
+	}
+
+	mismatch := s.extenderProtocol != config.ExtenderProtocolAuto && protocol != s.extenderProtocol
+	if mismatch {
+		s.metrics.IncrementCounter("protocol_mismatches")
+	}
+
+	s.protocolMu.Lock()
+	changed := protocol != s.lastProtocol
+	s.lastProtocol = protocol
+	s.protocolMu.Unlock()
+
+	if changed && mismatch {
+		klog.Warningf("Extender: kube-scheduler sent %q but EXTENDER_PROTOCOL=%s — check nodeCacheCapable in the scheduler config",
+			protocol, s.extenderProtocol)
+	} else if changed {
+		klog.Infof("Extender: kube-scheduler is sending %q", protocol)
+	}
+}
+
+// LastProtocol returns the node format of the most recent request ("" before the first one)
+func (s *NEXUSScheduler) LastProtocol() string {
+	s.protocolMu.Lock()
+	defer s.protocolMu.Unlock()
+	return s.lastProtocol
+}
+
+// candidateNodes returns the request's candidate nodes, building name-only
+// nodes when kube-scheduler sent node names (nil when it sent neither)
+func candidateNodes(args *ExtenderArgs) *v1.NodeList {
+	if args.Nodes != nil || args.NodeNames == nil {
+		return args.Nodes
+	}
+
+	nodes := &v1.NodeList{Items: make([]v1.Node, 0, len(*args.NodeNames))}
+	for _, name := range *args.NodeNames {
+		nodes.Items = append(nodes.Items, v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return nodes
+}
+
+// newFilterResult builds a Filter response in the same node format as the
+// request. Rejected nodes are unresolvable and also listed in failedNodes
+// for schedulers that predate failedAndUnresolvableNodes.
+func newFilterResult(args *ExtenderArgs, eligible []v1.Node, rejected map[string]string) ExtenderFilterResult {
+	result := ExtenderFilterResult{}
+	if len(rejected) > 0 {
+		result.FailedNodes = rejected
+		result.FailedAndUnresolvableNodes = rejected
+	}
+	if args.Nodes != nil {
+		result.Nodes = &v1.NodeList{Items: eligible}
+	}
+	if args.NodeNames != nil {
+		names := make([]string, 0, len(eligible))
+		for _, node := range eligible {
+			names = append(names, node.Name)
+		}
+		result.NodeNames = &names
+	}
+	return result
+}
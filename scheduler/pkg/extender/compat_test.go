@@ -0,0 +1,185 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+const compatPod = `{"metadata":{"name":"checkoutservice-7d9f8c6b5-x2k4p","namespace":"default","uid":"u1"}}`
+
+const compatNodes = `{"metadata":{},"items":[` +
+	`{"metadata":{"name":"node-1"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"},"conditions":[{"type":"Ready","status":"True"}]}},` +
+	`{"metadata":{"name":"node-2"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"},"conditions":[{"type":"Ready","status":"True"}]}}]}`
+
+// compatRequests are Filter/Prioritize bodies as sent by different
+// kube-scheduler versions and extender configurations
+var compatRequests = []struct {
+	name          string
+	body          string
+	wantNodes     bool
+	wantNodeNames bool
+}{
+	{
+		name:      "schedulerapi/v1 (<=1.6, nodes by value)",
+		body:      `{"pod":` + compatPod + `,"nodes":` + compatNodes + `}`,
+		wantNodes: true,
+	},
+	{
+		name:          "schedulerapi/v1 nodeCacheCapable (1.7-1.18)",
+		body:          `{"pod":` + compatPod + `,"nodenames":["node-1","node-2"]}`,
+		wantNodeNames: true,
+	},
+	{
+		name:      "extender/v1 (>=1.19)",
+		body:      `{"pod":` + compatPod + `,"nodes":` + compatNodes + `,"nodenames":null}`,
+		wantNodes: true,
+	},
+	{
+		name:          "extender/v1 nodeCacheCapable (>=1.19)",
+		body:          `{"pod":` + compatPod + `,"nodes":null,"nodenames":["node-1","node-2"]}`,
+		wantNodeNames: true,
+	},
+	{
+		name:          "untagged Go field names (simulators)",
+		body:          `{"Pod":` + compatPod + `,"NodeNames":["node-1","node-2"]}`,
+		wantNodeNames: true,
+	},
+	{
+		name:          "nodes and nodenames",
+		body:          `{"pod":` + compatPod + `,"nodes":` + compatNodes + `,"nodenames":["node-1","node-2"]}`,
+		wantNodes:     true,
+		wantNodeNames: true,
+	},
+}
+
+// compatMember is a running cartservice pod (checkout-flow gang) on node-2
+var compatMember = v1.Pod{
+	ObjectMeta: metav1.ObjectMeta{Name: "cartservice-6d5c7b8f9-abcde", Namespace: "default"},
+	Spec:       v1.PodSpec{NodeName: "node-2"},
+	Status:     v1.PodStatus{Phase: v1.PodRunning},
+}
+
+func TestFilterMirrorsRequestFormat(t *testing.T) {
+	for _, state := range []SchedulerState{StateIdle, StateActive} {
+		for _, tc := range compatRequests {
+			t.Run(state.String()+"/"+tc.name, func(t *testing.T) {
+				s := newTestScheduler(t, state, compatMember)
+
+				rec := httptest.NewRecorder()
+				s.HandleFilter(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(tc.body)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("filter answered %d: %s", rec.Code, rec.Body)
+				}
+
+				var result ExtenderFilterResult
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+					t.Fatalf("decoding filter response: %v", err)
+				}
+
+				if (result.Nodes != nil) != tc.wantNodes {
+					t.Errorf("nodes present = %v, want %v", result.Nodes != nil, tc.wantNodes)
+				}
+				if (result.NodeNames != nil) != tc.wantNodeNames {
+					t.Errorf("nodenames present = %v, want %v", result.NodeNames != nil, tc.wantNodeNames)
+				}
+
+				// Both candidate nodes are schedulable and must pass in every format
+				if result.Nodes != nil {
+					names := make([]string, 0, len(result.Nodes.Items))
+					for _, node := range result.Nodes.Items {
+						names = append(names, node.Name)
+					}
+					assertNodeNames(t, "nodes", names)
+				}
+				if result.NodeNames != nil {
+					assertNodeNames(t, "nodenames", *result.NodeNames)
+				}
+			})
+		}
+	}
+}
+
+func TestPrioritizeAcceptsEveryFormat(t *testing.T) {
+	for _, state := range []SchedulerState{StateIdle, StateActive} {
+		for _, tc := range compatRequests {
+			t.Run(state.String()+"/"+tc.name, func(t *testing.T) {
+				s := newTestScheduler(t, state, compatMember)
+
+				rec := httptest.NewRecorder()
+				s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(tc.body)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("prioritize answered %d: %s", rec.Code, rec.Body)
+				}
+
+				var priorities []HostPriority
+				if err := json.Unmarshal(rec.Body.Bytes(), &priorities); err != nil {
+					t.Fatalf("decoding prioritize response: %v", err)
+				}
+
+				scores := make(map[string]int64)
+				names := make([]string, 0, len(priorities))
+				for _, p := range priorities {
+					scores[p.Host] = p.Score
+					names = append(names, p.Host)
+				}
+				assertNodeNames(t, "hosts", names)
+
+				// Gang locality needs only node names, so it works in every format
+				if state == StateActive && scores["node-2"] <= scores["node-1"] {
+					t.Errorf("node-2 (hosts a gang member) scored %d, node-1 scored %d", scores["node-2"], scores["node-1"])
+				}
+				if state == StateIdle && (scores["node-1"] != 0 || scores["node-2"] != 0) {
+					t.Errorf("IDLE scores = %v, want all 0", scores)
+				}
+			})
+		}
+	}
+}
+
+func TestProtocolMismatchCounted(t *testing.T) {
+	tests := []struct {
+		protocol     string
+		body         string
+		wantMismatch bool
+	}{
+		{config.ExtenderProtocolAuto, compatRequests[0].body, false},
+		{config.ExtenderProtocolAuto, compatRequests[1].body, false},
+		{config.ExtenderProtocolNodes, compatRequests[0].body, false},
+		{config.ExtenderProtocolNodes, compatRequests[1].body, true},
+		{config.ExtenderProtocolNodeNames, compatRequests[1].body, false},
+		{config.ExtenderProtocolNodeNames, compatRequests[0].body, true},
+	}
+
+	for _, tt := range tests {
+		s := newTestScheduler(t, StateIdle)
+		s.extenderProtocol = tt.protocol
+
+		s.HandleFilter(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(tt.body)))
+
+		out := httptest.NewRecorder()
+		s.metrics.WriteAllMetrics(out)
+		counted := strings.Contains(out.Body.String(), "nexus_extender_protocol_mismatches_total 1\n")
+		if counted != tt.wantMismatch {
+			t.Errorf("EXTENDER_PROTOCOL=%s, request %s: mismatch counted = %v, want %v",
+				tt.protocol, s.LastProtocol(), counted, tt.wantMismatch)
+		}
+	}
+}
+
+// assertNodeNames checks that exactly node-1 and node-2 were returned
+func assertNodeNames(t *testing.T, field string, names []string) {
+	t.Helper()
+	sort.Strings(names)
+	if strings.Join(names, ",") != "node-1,node-2" {
+		t.Errorf("%s = %v, want [node-1 node-2]", field, names)
+	}
+}
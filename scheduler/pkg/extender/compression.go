@@ -0,0 +1,159 @@
+/*
+Extender Payload Compression
+============================
+On big clusters the NodeList in every Filter/Prioritize request, and the
+node list Filter echoes back, dominate the extender's latency. The
+extender endpoints therefore speak gzip, negotiated through the standard
+headers:
+
+  Content-Encoding: gzip  on a request → its body is decompressed
+  Accept-Encoding: gzip   on a request → the response is compressed
+                                         (EXTENDER_GZIP, default on)
+
+Go HTTP clients, kube-scheduler's included, send Accept-Encoding: gzip and
+decompress transparently unless compression is disabled on their
+transport. A request body that is not valid gzip fails like any other
+undecodable request (no opinion). Responses use the fastest compression
+level, trading some ratio for the latency the compression is meant to
+save.
+
+Wire and decoded bytes are counted per endpoint and direction
+(nexus_extender_payload_bytes_total, nexus_extender_payload_decoded_bytes_total).
+*/
+
+package extender
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Content encodings of extender payloads
+const (
+	encodingIdentity = "identity"
+	encodingGzip     = "gzip"
+)
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// errReader fails every read with err
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
+
+// payloadWriter compresses a response body when negotiated and counts its
+// wire and decoded bytes
+type payloadWriter struct {
+	http.ResponseWriter
+	wire        *countingWriter
+	gz          *gzip.Writer // nil = identity
+	decoded     int64
+	wroteHeader bool
+}
+
+func (pw *payloadWriter) WriteHeader(code int) {
+	if !pw.wroteHeader {
+		pw.wroteHeader = true
+		if pw.gz != nil && pw.Header().Get("Content-Encoding") != encodingGzip {
+			pw.gz = nil // the handler reset the encoding (e.g. http.Error)
+		}
+		if pw.gz != nil {
+			pw.Header().Del("Content-Length")
+		}
+	}
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *payloadWriter) Write(p []byte) (int, error) {
+	if !pw.wroteHeader {
+		pw.WriteHeader(http.StatusOK)
+	}
+	pw.decoded += int64(len(p))
+	if pw.gz != nil {
+		return pw.gz.Write(p)
+	}
+	return pw.wire.Write(p)
+}
+
+// encoding returns the content encoding the body was written with
+func (pw *payloadWriter) encoding() string {
+	if pw.gz != nil {
+		return encodingGzip
+	}
+	return encodingIdentity
+}
+
+// withPayloadEncoding wraps an extender endpoint with gzip negotiation and
+// payload byte accounting
+func (s *NEXUSScheduler) withPayloadEncoding(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Request body: decompress gzip, count wire and decoded bytes
+		requestEncoding := encodingIdentity
+		wireBody := &countingReader{r: r.Body}
+		decodedBody := &countingReader{r: wireBody}
+		if strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), encodingGzip) {
+			requestEncoding = encodingGzip
+			zr, err := gzip.NewReader(wireBody)
+			if err != nil {
+				decodedBody.r = errReader{err: err}
+			} else {
+				defer zr.Close()
+				decodedBody.r = zr
+			}
+		}
+		r.Body = io.NopCloser(decodedBody)
+
+		// Response body: compress when the client accepts gzip
+		pw := &payloadWriter{ResponseWriter: w, wire: &countingWriter{w: w}}
+		if s.cfg.ExtenderGzip && acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			w.Header().Set("Content-Encoding", encodingGzip)
+			w.Header().Add("Vary", "Accept-Encoding")
+			pw.gz, _ = gzip.NewWriterLevel(pw.wire, gzip.BestSpeed)
+		}
+
+		handler(pw, r)
+
+		if pw.gz != nil {
+			pw.gz.Close()
+		}
+		s.metrics.ObservePayload(endpoint, "request", requestEncoding, wireBody.n, decodedBody.n)
+		s.metrics.ObservePayload(endpoint, "response", pw.encoding(), pw.wire.n, pw.decoded)
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), encodingGzip) && strings.TrimSpace(coding) != "*" {
+			continue
+		}
+		q := strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package extender
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// gzipped compresses s
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtenderGzipNegotiation(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	mux := http.NewServeMux()
+	s.RegisterExtenderHandlers(mux)
+	body := `{"pod":` + compatPod + `,"nodes":` + compatNodes + `}`
+
+	// Compressed request, compressed response
+	compressed := gzipped(t, body)
+	req := httptest.NewRequest(http.MethodPost, "/filter", bytes.NewReader(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+	wireResponse := rec.Body.Len()
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	decoded, _ := io.ReadAll(zr)
+	var result ExtenderFilterResult
+	if err := json.Unmarshal(decoded, &result); err != nil || result.Nodes == nil || len(result.Nodes.Items) != 2 {
+		t.Fatalf("filter result = %s (%v), want both nodes", decoded, err)
+	}
+
+	// Identity request without Accept-Encoding: plain JSON
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(body)))
+	if rec.Header().Get("Content-Encoding") != "" || !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("identity response: encoding %q, body %q", rec.Header().Get("Content-Encoding"), rec.Body.String())
+	}
+
+	// A corrupt gzip body fails like any undecodable request
+	req = httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Error == "" {
+		t.Errorf("corrupt gzip answered %q, want a filter error result", rec.Body.String())
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_extender_payload_bytes_total{endpoint="filter",direction="request",encoding="gzip"} ` + strconv.Itoa(len(compressed)+len("not gzip")),
+		`nexus_extender_payload_bytes_total{endpoint="filter",direction="response",encoding="gzip"} ` + strconv.Itoa(wireResponse),
+		`nexus_extender_payload_bytes_total{endpoint="prioritize",direction="request",encoding="identity"} ` + strconv.Itoa(len(body)),
+		`nexus_extender_payload_decoded_bytes_total{endpoint="prioritize",direction="request"} ` + strconv.Itoa(len(body)),
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                    false,
+		"gzip":                true,
+		"deflate, gzip;q=1.0": true,
+		"br, GZIP":            true,
+		"gzip;q=0":            false,
+		"*":                   true,
+		"identity":            false,
+	} {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Extender Connections
+====================
+kube-scheduler's extender client keeps idle connections for 90s
+(IdleConnTimeout of the client-go transport defaults). A server that
+closes idle connections sooner races the client reusing them, and every
+lost race is a fresh TCP connection inside a scheduling cycle. The
+extender listener is therefore tuned for that client:
+
+  EXTENDER_KEEP_ALIVE       → keep connections open between calls (default on)
+  EXTENDER_IDLE_TIMEOUT     → close idle connections after this (120s, past
+                              the client's 90s so the client closes first)
+  EXTENDER_MAX_CONNECTIONS  → accept at most this many connections at once;
+                              further ones wait for a slot (0 = unlimited)
+
+Connections opened and open, requests on new and reused connections, and
+accepts that waited for a slot are exported (pkg/metrics/connections.go).
+The observability/admin listener keeps Go's defaults.
+*/
+
+package extender
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// connTracker counts extender connections and their reuse from the
+// server's connection state changes
+type connTracker struct {
+	metrics *metrics.NEXUSMetrics
+	mu      sync.Mutex
+	served  map[net.Conn]bool // connection → served a request already
+}
+
+// newConnTracker returns a tracker reporting to m
+func newConnTracker(m *metrics.NEXUSMetrics) *connTracker {
+	return &connTracker{metrics: m, served: make(map[net.Conn]bool)}
+}
+
+// connState is the http.Server ConnState hook
+func (t *connTracker) connState(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch state {
+	case http.StateNew:
+		t.served[conn] = false
+		t.metrics.ConnectionOpened()
+	case http.StateActive:
+		t.metrics.ConnectionRequest(t.served[conn])
+		t.served[conn] = true
+	case http.StateClosed, http.StateHijacked:
+		if _, ok := t.served[conn]; ok {
+			delete(t.served, conn)
+			t.metrics.ConnectionClosed()
+		}
+	}
+}
+
+// tuneExtenderServer applies the keep-alive and idle settings to the
+// extender listener and tracks its connections
+func (s *NEXUSScheduler) tuneExtenderServer(server *http.Server, cfg *config.Config) {
+	server.IdleTimeout = cfg.ExtenderIdleTimeout
+	server.SetKeepAlivesEnabled(cfg.ExtenderKeepAlive)
+	server.ConnState = newConnTracker(s.metrics).connState
+
+	idle := cfg.ExtenderIdleTimeout
+	if !cfg.ExtenderKeepAlive {
+		idle = 0
+	}
+	s.metrics.SetConnectionLimits(cfg.ExtenderMaxConns, idle.Seconds())
+}
+
+// ListenAndServe serves server on its address (a TCP address, or a Unix
+// socket, see socket.go); the extender listener accepts at most
+// EXTENDER_MAX_CONNECTIONS connections at once
+func (s *NEXUSScheduler) ListenAndServe(server *http.Server, cfg *config.Config) error {
+	ln, err := listen(server.Addr)
+	if err != nil {
+		return err
+	}
+	if server.Addr == cfg.ExtenderAddr && cfg.ExtenderMaxConns > 0 {
+		ln = newLimitListener(ln, cfg.ExtenderMaxConns, s.metrics.ConnectionLimitWait)
+	}
+	return server.Serve(ln)
+}
+
+// limitListener accepts at most cap(slots) connections at once
+type limitListener struct {
+	net.Listener
+	slots chan struct{}
+	wait  func() // called when an accept has to wait for a slot
+}
+
+// newLimitListener wraps ln to accept at most n connections at once
+func newLimitListener(ln net.Listener, n int, wait func()) net.Listener {
+	return &limitListener{Listener: ln, slots: make(chan struct{}, n), wait: wait}
+}
+
+// Accept waits for a free slot, then for a connection
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		l.wait()
+		l.slots <- struct{}{}
+	}
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.slots }}, nil
+}
+
+// limitConn frees its listener slot once, on the first Close
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the connection and frees its slot
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
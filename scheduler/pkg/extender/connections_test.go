@@ -0,0 +1,125 @@
+package extender
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveExtender serves the extender listener of s with cfg on a local
+// port and returns its URL
+func serveExtender(t *testing.T, s *NEXUSScheduler, keepAlive bool) string {
+	t.Helper()
+	cfg := *s.cfg
+	cfg.ExtenderKeepAlive = keepAlive
+	server := s.NewServers(&cfg)[0]
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+	return "http://" + ln.Addr().String()
+}
+
+// getHealthz sends n GET /healthz requests over one client
+func getHealthz(t *testing.T, url string, n int) {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{}}
+	defer client.CloseIdleConnections()
+	for i := 0; i < n; i++ {
+		resp, err := client.Get(url + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+func TestExtenderConnectionReuse(t *testing.T) {
+	for _, tc := range []struct {
+		keepAlive bool
+		want      []string
+	}{
+		{true, []string{
+			"nexus_extender_connections_opened_total 1",
+			`nexus_extender_connection_requests_total{connection="new"} 1`,
+			`nexus_extender_connection_requests_total{connection="reused"} 2`,
+			"nexus_extender_idle_timeout_seconds 120.000",
+		}},
+		{false, []string{
+			"nexus_extender_connections_opened_total 3",
+			`nexus_extender_connection_requests_total{connection="new"} 3`,
+			`nexus_extender_connection_requests_total{connection="reused"} 0`,
+			"nexus_extender_idle_timeout_seconds 0.000",
+		}},
+	} {
+		s := newTestScheduler(t, StateIdle)
+		s.cfg.ExtenderIdleTimeout = 120 * time.Second
+		getHealthz(t, serveExtender(t, s, tc.keepAlive), 3)
+
+		out := httptest.NewRecorder()
+		s.metrics.WriteAllMetrics(out)
+		for _, line := range tc.want {
+			if !strings.Contains(out.Body.String(), line+"\n") {
+				t.Errorf("keep-alive %v: metrics missing %q", tc.keepAlive, line)
+			}
+		}
+	}
+}
+
+func TestLimitListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inner.Close()
+	waits := make(chan struct{}, 1)
+	ln := newLimitListener(inner, 1, func() { waits <- struct{}{} })
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+
+	first, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	select {
+	case <-waits:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second accept did not wait for a slot")
+	}
+	select {
+	case <-accepted:
+		t.Fatal("second connection accepted past the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first.Close()
+	first.Close() // the slot is freed once
+	select {
+	case second := <-accepted:
+		second.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("second connection not accepted after the first closed")
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Cooldown
+========
+The spike must stay quiet for cooldownDuration before NEXUS drains or
+dissolves the gangs. With HPA_STABILIZATION_COOLDOWN=true the gangs'
+HPA scale-down stabilization windows raise that bound, read once when
+the gangs form, so gangs are not dissolved while the autoscaler is still
+holding or removing their members' replicas. Listing the HPAs failing
+leaves the default cooldown.
+*/
+
+package extender
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// applyHPACooldowns sets the gangs' cooldowns from the HPA scale-down
+// stabilization windows of their members
+func (s *NEXUSScheduler) applyHPACooldowns(ctx context.Context) {
+	if s.hpa == nil {
+		return
+	}
+	windows, err := s.hpa.ScaleDownWindows(ctx)
+	if err != nil {
+		klog.Warningf("Failed to read HPA stabilization windows (using the default cooldown): %v", err)
+		return
+	}
+	if longest := s.gangManager.ApplyCooldowns(windows); longest > cooldownDuration {
+		klog.Infof("Cooldown raised to %v by HPA scale-down stabilization", longest)
+	}
+	s.metrics.SetCooldown(s.cooldown().Seconds())
+}
+
+// cooldown returns how long the spike must stay quiet before the gangs
+// drain or dissolve
+func (s *NEXUSScheduler) cooldown() time.Duration {
+	if gangs := s.gangManager.Cooldown(); gangs > cooldownDuration {
+		return gangs
+	}
+	return cooldownDuration
+}
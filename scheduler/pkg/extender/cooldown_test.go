@@ -0,0 +1,78 @@
+package extender
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+)
+
+func TestHPAStabilizationCooldown(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	window := int32(600)
+	hpas := []autoscalingv2.HorizontalPodAutoscaler{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cartservice", Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "cartservice"},
+				Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+					ScaleDown: &autoscalingv2.HPAScalingRules{StabilizationWindowSeconds: &window},
+				},
+			},
+		},
+		{
+			// No behavior: the controller default applies
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "frontend"},
+			},
+		},
+	}
+	for i := range hpas {
+		if _, err := s.clientset.AutoscalingV2().HorizontalPodAutoscalers("default").Create(context.Background(), &hpas[i], metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.hpa = kube.NewHPAStabilization(s.clientset, s.apiGuard, "")
+
+	groups := []graph.RuntimeGroup{
+		{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice"}},
+		{Name: "product-browsing", Services: []string{"frontend", "productcatalogservice"}},
+	}
+	s.depGraph.Restore(groups)
+	s.formGangs(context.Background(), groups, s.nextFormationStrategy())
+
+	if got := s.gangManager.GetGangForService("checkoutservice").Cooldown; got != 10*time.Minute {
+		t.Errorf("checkout-flow cooldown = %v, want 10m", got)
+	}
+	if got := s.gangManager.GetGangForService("frontend").Cooldown; got != kube.DefaultScaleDownStabilization {
+		t.Errorf("product-browsing cooldown = %v, want the controller default", got)
+	}
+	if got := s.cooldown(); got != 10*time.Minute {
+		t.Errorf("episode cooldown = %v, want 10m", got)
+	}
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_cooldown_seconds 600\n") {
+		t.Error("nexus_cooldown_seconds does not report 600")
+	}
+
+	s.dissolveGangs(context.Background())
+	if got := s.cooldown(); got != cooldownDuration {
+		t.Errorf("cooldown after dissolution = %v, want %v", got, cooldownDuration)
+	}
+}
+
+func TestCooldownWithoutHPAs(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	if got := s.cooldown(); got != cooldownDuration {
+		t.Errorf("cooldown = %v, want %v", got, cooldownDuration)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Counter Snapshots
+=================
+With METRICS_SNAPSHOT_PATH set (a file on a volume that survives the
+container, e.g. a PVC), the counter and histogram series of /metrics are
+written to that file every METRICS_SNAPSHOT_INTERVAL and once more on
+SIGTERM. At startup the file is read back and the series continue from
+the restored values, so a restart in the middle of an experiment run
+does not reset the totals it is measured by. Gauges describe current
+state and start fresh.
+
+Delete the file between experiment runs to start the series from zero.
+*/
+
+package extender
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/metrics"
+)
+
+// restoreCounters continues the counters from the snapshot file
+func (s *NEXUSScheduler) restoreCounters() {
+	snapshot, err := metrics.LoadCounterSnapshot(s.cfg.MetricsSnapshotPath)
+	if err != nil {
+		klog.Warningf("Counter snapshot: %v — counters start from zero", err)
+		return
+	}
+	if snapshot == nil {
+		klog.Infof("Counter snapshot: no snapshot at %s yet, counters start from zero", s.cfg.MetricsSnapshotPath)
+		return
+	}
+	s.metrics.RestoreCounters(snapshot)
+	klog.Infof("Counter snapshot: restored %d metric families from %s", len(snapshot), s.cfg.MetricsSnapshotPath)
+}
+
+// SnapshotCounters writes the counter snapshot file once (no-op when
+// METRICS_SNAPSHOT_PATH is unset)
+func (s *NEXUSScheduler) SnapshotCounters() {
+	if s.cfg.MetricsSnapshotPath == "" {
+		return
+	}
+	if err := metrics.SaveCounterSnapshot(s.cfg.MetricsSnapshotPath, s.metrics.CounterSnapshot()); err != nil {
+		klog.Warningf("Counter snapshot: %v", err)
+		s.metrics.IncrementCounter("counter_snapshot_failures")
+	}
+}
+
+// PersistCounters snapshots the counters periodically until ctx is done
+// (returns immediately when METRICS_SNAPSHOT_PATH is unset)
+func (s *NEXUSScheduler) PersistCounters(ctx context.Context) {
+	if s.cfg.MetricsSnapshotPath == "" {
+		return
+	}
+	interval := s.cfg.MetricsSnapshotInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.SnapshotCounters()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Decision Log Sampling
+=====================
+Every Filter and Prioritize call logs one line at INFO. During a spike
+kube-scheduler sends hundreds per second, and the logging itself adds
+latency and floods the log pipeline. Those per-decision lines are
+therefore sampled:
+
+  DECISION_LOG_SAMPLE  log 1 decision in N (1 = every one, 0 = none)
+  DECISION_LOG_RATE    and at most this many lines per second (0 = no limit)
+
+Dropped lines are counted in nexus_decision_logs_suppressed_total and
+still written at verbosity 4. The full detail of every decision stays
+available from GET /decisions and the decision export.
+*/
+
+package extender
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// decisionLogSampler decides which per-decision log lines are written
+type decisionLogSampler struct {
+	mu     sync.Mutex
+	seen   uint64    // decisions offered since start
+	tokens float64   // rate limit budget (lines)
+	last   time.Time // last token refill
+}
+
+// allow reports whether the next decision line is written: one in every,
+// at most rate lines per second (rate token bucket, burst of one second)
+func (l *decisionLogSampler) allow(every int, rate float64, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seen++
+	if every <= 0 || (l.seen-1)%uint64(every) != 0 {
+		return false
+	}
+	if rate <= 0 {
+		return true
+	}
+	if l.last.IsZero() {
+		l.tokens = rate
+	} else if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * rate
+		if l.tokens > rate {
+			l.tokens = rate
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// logDecision writes a per-decision line at INFO when sampled, or at
+// verbosity 4 and counted as suppressed otherwise
+func (s *NEXUSScheduler) logDecision(format string, args ...interface{}) {
+	if s.decisionLog.allow(s.cfg.DecisionLogSample, s.cfg.DecisionLogRate, time.Now()) {
+		klog.InfoDepth(1, fmt.Sprintf(format, args...))
+		return
+	}
+	s.metrics.IncrementCounter("decision_logs_suppressed")
+	if v := klog.V(4); v.Enabled() {
+		v.InfoDepth(1, fmt.Sprintf(format, args...))
+	}
+}
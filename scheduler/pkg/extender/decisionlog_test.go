@@ -0,0 +1,46 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecisionLogSampling(t *testing.T) {
+	now := time.Now()
+
+	var sampled decisionLogSampler
+	written := 0
+	for i := 0; i < 10; i++ {
+		if sampled.allow(4, 0, now) {
+			written++
+		}
+	}
+	if written != 3 { // decisions 1, 5 and 9
+		t.Errorf("1-in-4 sampling wrote %d of 10 lines, want 3", written)
+	}
+
+	var limited decisionLogSampler
+	written = 0
+	for i := 0; i < 10; i++ {
+		if limited.allow(1, 5, now) {
+			written++
+		}
+	}
+	if written != 5 {
+		t.Errorf("rate limit wrote %d lines in one instant, want 5", written)
+	}
+	if !limited.allow(1, 5, now.Add(time.Second/2)) {
+		t.Error("rate limit did not refill after half a second")
+	}
+
+	s := newTestScheduler(t, StateActive)
+	s.cfg.DecisionLogSample = 0
+	s.logDecision("Filter: Pod %s", "checkoutservice-abc")
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_decision_logs_suppressed_total 1\n") {
+		t.Error("suppressed decision line not counted")
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Admin Episode Control
+=====================
+Orchestration scripts start and end episodes explicitly, and retry when a
+response is lost. Both endpoints are therefore idempotent on an episode
+ID chosen by the caller:
+
+  POST /admin/activate   → Start episode: {"episodeId": "run-7", "class": "traffic", "services": ["checkoutservice"]}
+  POST /admin/deactivate → End episode:   {"episodeId": "run-7"}
+
+and answer with a structured result instead of repeating the action:
+
+  created         201  NEXUS activated with the episode ID
+  already-active  200  The episode is running (ACTIVE or DRAINING)
+  deactivated     200  The episode ended, its gangs were dissolved
+  already-ended   200  The episode already ended (retried deactivate, or
+                       activate after the episode ended)
+  conflict        409  Another episode is running
+  unknown         404  Deactivate of an episode NEXUS never started
+  backoff         503  Activation suppressed by the flapping back-off
+                       (Retry-After: FLAP_STABILIZATION)
+  failed          500  The dependency graph could not be built
+
+Activation happens within the request, like a spike check detecting an
+injected spike (class defaults to traffic, services seed the graph), and
+the episode then ends through the cooldown like any other unless it is
+deactivated first. Episode IDs are 1–63 letters, digits, '.', '_' or '-'.
+Starts and ends are serialized with the spike and cooldown checks, and
+counted in nexus_admin_episode_requests_total{action,result}.
+
+Only the last 256 ended episode IDs (and those still in /episodes) are
+remembered: an ID that ended before them is treated as unknown, so a
+retried activate of it starts a new episode and a deactivate answers 404.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+// Admin episode request results
+const (
+	episodeCreated       = "created"
+	episodeAlreadyActive = "already-active"
+	episodeDeactivated   = "deactivated"
+	episodeAlreadyEnded  = "already-ended"
+	episodeConflict      = "conflict"
+	episodeUnknown       = "unknown"
+	episodeBackoff       = "backoff"
+	episodeFailed        = "failed"
+)
+
+// endedEpisodesSize is the number of ended episode IDs remembered
+const endedEpisodesSize = 256
+
+// episodeTransitions serializes episode starts and ends between the spike
+// check, the cooldown check and the admin API
+type episodeTransitions struct {
+	mu        sync.Mutex
+	ended     map[string]bool           // the last ended episode IDs
+	endedRing [endedEpisodesSize]string // the same IDs in end order
+	next      int                       // ring slot of the next ended ID
+}
+
+// adminEpisodeRequest is the body of POST /admin/activate and /admin/deactivate
+type adminEpisodeRequest struct {
+	EpisodeID string              `json:"episodeId"`
+	Class     detector.SpikeClass `json:"class,omitempty"`
+	Services  []string            `json:"services,omitempty"`
+}
+
+// adminEpisodeResult is the response of the admin episode endpoints
+type adminEpisodeResult struct {
+	EpisodeID string `json:"episodeId"`
+	Result    string `json:"result"`
+	State     string `json:"state"`
+	Current   string `json:"current,omitempty"` // running episode ("" = none)
+	Error     string `json:"error,omitempty"`
+}
+
+// validEpisodeID reports whether id is a usable explicit episode ID
+func validEpisodeID(id string) bool {
+	if id == "" || len(id) > 63 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// episodeEnded reports whether the episode ran and ended (transitions.mu held)
+func (s *NEXUSScheduler) episodeEnded(id string) bool {
+	if s.transitions.ended[id] {
+		return true
+	}
+	for _, ep := range s.Episodes() {
+		if ep.ID == id && ep.EndedAt != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// markEpisodeEnded remembers the ID of an episode that ended, forgetting
+// the oldest once endedEpisodesSize are remembered
+func (s *NEXUSScheduler) markEpisodeEnded(id string) {
+	t := &s.transitions
+	if id == "" || t.ended[id] {
+		return
+	}
+	if t.ended == nil {
+		t.ended = make(map[string]bool, endedEpisodesSize)
+	}
+	delete(t.ended, t.endedRing[t.next])
+	t.endedRing[t.next] = id
+	t.next = (t.next + 1) % endedEpisodesSize
+	t.ended[id] = true
+}
+
+// activateEpisode starts the episode unless it is running or ended already
+func (s *NEXUSScheduler) activateEpisode(ctx context.Context, req adminEpisodeRequest) adminEpisodeResult {
+	s.transitions.mu.Lock()
+	defer s.transitions.mu.Unlock()
+
+	result := adminEpisodeResult{EpisodeID: req.EpisodeID}
+	current := ""
+	if s.GetState() != StateIdle {
+		current = s.EpisodeID()
+	}
+	switch {
+	case current == req.EpisodeID:
+		result.Result = episodeAlreadyActive
+	case current != "":
+		result.Result = episodeConflict
+	case s.episodeEnded(req.EpisodeID):
+		result.Result = episodeAlreadyEnded
+	default:
+		class := req.Class
+		if class == detector.SpikeClassNone {
+			class = detector.SpikeClassTraffic
+		}
+		activationStart := time.Now()
+		if !s.allowActivation(activationStart) {
+			result.Result = episodeBackoff
+			break
+		}
+		klog.Infof("Admin: activating episode %s (%s spike, services %v)", req.EpisodeID, class, req.Services)
+		s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalInjected, Source: detector.TriggerSourceAdmin}, nil)
+		if err := s.activate(ctx, class, req.Services, req.EpisodeID, activationStart, activationStart); err != nil {
+			result.Result, result.Error = episodeFailed, err.Error()
+			break
+		}
+		result.Result = episodeCreated
+	}
+	return s.episodeResult(result, "activate")
+}
+
+// deactivateEpisode ends the episode if it is running
+func (s *NEXUSScheduler) deactivateEpisode(ctx context.Context, id string) adminEpisodeResult {
+	s.transitions.mu.Lock()
+	defer s.transitions.mu.Unlock()
+
+	result := adminEpisodeResult{EpisodeID: id}
+	current := ""
+	if s.GetState() != StateIdle {
+		current = s.EpisodeID()
+	}
+	switch {
+	case current == id:
+		klog.Infof("Admin: deactivating episode %s", id)
+		s.dissolveGangs(ctx)
+		result.Result = episodeDeactivated
+	case s.episodeEnded(id):
+		result.Result = episodeAlreadyEnded
+	case current != "":
+		result.Result = episodeConflict
+	default:
+		result.Result = episodeUnknown
+	}
+	return s.episodeResult(result, "deactivate")
+}
+
+// episodeResult completes and counts an admin episode result
+func (s *NEXUSScheduler) episodeResult(result adminEpisodeResult, action string) adminEpisodeResult {
+	result.State = s.GetState().String()
+	if s.GetState() != StateIdle {
+		result.Current = s.EpisodeID()
+	}
+	s.metrics.RecordAdminEpisodeRequest(action, result.Result)
+	return result
+}
+
+// episodeResultStatus is the HTTP status of an admin episode result
+func episodeResultStatus(result string) int {
+	switch result {
+	case episodeCreated:
+		return http.StatusCreated
+	case episodeConflict:
+		return http.StatusConflict
+	case episodeUnknown:
+		return http.StatusNotFound
+	case episodeBackoff:
+		return http.StatusServiceUnavailable
+	case episodeFailed:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusOK
+	}
+}
+
+// handleAdminActivate starts an episode with an explicit ID (POST)
+func (s *NEXUSScheduler) handleAdminActivate(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeEpisodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Class != detector.SpikeClassNone && !validSpikeClass(req.Class) {
+		http.Error(w, "unknown spike class", http.StatusBadRequest)
+		return
+	}
+	s.writeEpisodeResult(w, s.activateEpisode(r.Context(), req))
+}
+
+// handleAdminDeactivate ends an episode by ID (POST)
+func (s *NEXUSScheduler) handleAdminDeactivate(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeEpisodeRequest(w, r)
+	if !ok {
+		return
+	}
+	s.writeEpisodeResult(w, s.deactivateEpisode(r.Context(), req.EpisodeID))
+}
+
+// decodeEpisodeRequest reads and validates an admin episode request body
+func decodeEpisodeRequest(w http.ResponseWriter, r *http.Request) (adminEpisodeRequest, bool) {
+	var req adminEpisodeRequest
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	if !validEpisodeID(req.EpisodeID) {
+		http.Error(w, "episodeId must be 1-63 letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// writeEpisodeResult encodes an admin episode result with its status
+func (s *NEXUSScheduler) writeEpisodeResult(w http.ResponseWriter, result adminEpisodeResult) {
+	w.Header().Set("Content-Type", "application/json")
+	if result.Result == episodeBackoff && s.cfg.FlapStabilization > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(s.cfg.FlapStabilization.Seconds())))
+	}
+	w.WriteHeader(episodeResultStatus(result.Result))
+	json.NewEncoder(w).Encode(result)
+}
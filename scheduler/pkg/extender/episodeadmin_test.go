@@ -0,0 +1,89 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func episodeRequest(t *testing.T, s *NEXUSScheduler, path, body string) (int, adminEpisodeResult) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	s.RegisterAdminHandlers(mux, "secret")
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var result adminEpisodeResult
+	if rec.Code != http.StatusBadRequest {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("%s: decoding response: %v", path, err)
+		}
+	}
+	return rec.Code, result
+}
+
+func TestAdminEpisodeIdempotent(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+
+	steps := []struct {
+		path, body string
+		code       int
+		result     string
+	}{
+		{"/admin/deactivate", `{"episodeId":"run-1"}`, http.StatusNotFound, episodeUnknown},
+		{"/admin/activate", `{"episodeId":"run-1","services":["checkoutservice"]}`, http.StatusCreated, episodeCreated},
+		{"/admin/activate", `{"episodeId":"run-1"}`, http.StatusOK, episodeAlreadyActive}, // retried
+		{"/admin/activate", `{"episodeId":"run-2"}`, http.StatusConflict, episodeConflict},
+		{"/admin/deactivate", `{"episodeId":"run-2"}`, http.StatusConflict, episodeConflict},
+		{"/admin/deactivate", `{"episodeId":"run-1"}`, http.StatusOK, episodeDeactivated},
+		{"/admin/deactivate", `{"episodeId":"run-1"}`, http.StatusOK, episodeAlreadyEnded}, // retried
+		{"/admin/activate", `{"episodeId":"run-1"}`, http.StatusOK, episodeAlreadyEnded},   // late retry
+		{"/admin/activate", `{"episodeId":"bad id"}`, http.StatusBadRequest, ""},
+	}
+	for _, step := range steps {
+		code, result := episodeRequest(t, s, step.path, step.body)
+		if code != step.code || result.Result != step.result {
+			t.Errorf("%s %s = %d %q, want %d %q", step.path, step.body, code, result.Result, step.code, step.result)
+		}
+	}
+
+	episodes := s.Episodes()
+	if len(episodes) != 1 || episodes[0].ID != "run-1" || episodes[0].EndedAt == nil {
+		t.Errorf("episodes = %+v, want run-1 recorded once and ended", episodes)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_admin_episode_requests_total{action="activate",result="created"} 1`,
+		`nexus_admin_episode_requests_total{action="deactivate",result="already-ended"} 1`,
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
+
+func TestEndedEpisodesBounded(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	for i := 0; i < endedEpisodesSize+10; i++ {
+		s.markEpisodeEnded("run-" + strconv.Itoa(i))
+	}
+	s.markEpisodeEnded("run-20") // already remembered: not added twice
+
+	if n := len(s.transitions.ended); n != endedEpisodesSize {
+		t.Errorf("%d ended IDs remembered, want %d", n, endedEpisodesSize)
+	}
+	if s.episodeEnded("run-9") {
+		t.Error("oldest ended ID still remembered")
+	}
+	if !s.episodeEnded("run-10") || !s.episodeEnded("run-"+strconv.Itoa(endedEpisodesSize+9)) {
+		t.Error("recent ended IDs forgotten")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Extender Error Responses
+========================
+A Filter or Prioritize call NEXUS cannot evaluate is always answered with
+a well-formed response, never a bare http.Error body, and is counted in
+nexus_extender_errors_total{endpoint,class}:
+
+  decode      the body is not valid ExtenderArgs
+  incomplete  the request carries no pod or no candidate nodes
+  panic       the handler panicked (recovered, stack logged)
+
+EXTENDER_ERROR_POLICY decides what kube-scheduler sees:
+
+  fail-open   (default) Filter keeps every candidate node and Prioritize
+              answers no-opinion scores; the error is reported in the
+              X-Nexus-Error header only, so scheduling carries on
+  fail-closed Filter returns an ExtenderFilterResult with the error field
+              populated (kube-scheduler fails the attempt unless the
+              extender is ignorable) and Prioritize answers 500 with a
+              JSON error body
+
+A body that cannot be decoded has no candidates to keep, so its Filter
+result carries the error under both policies.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// Extender error classes
+const (
+	errClassDecode     = "decode"
+	errClassIncomplete = "incomplete"
+	errClassPanic      = "panic"
+)
+
+// errIncompleteArgs is reported for requests without pod or candidate nodes
+var errIncompleteArgs = errors.New("request has no pod or no candidate nodes")
+
+// errorHeader carries the error of a fail-open answer
+const errorHeader = "X-Nexus-Error"
+
+// extenderErrorResponse is the JSON body of a fail-closed Prioritize error
+type extenderErrorResponse struct {
+	Error string `json:"error"`
+	Class string `json:"class"`
+}
+
+// failClosed reports whether errors must reach kube-scheduler
+func (s *NEXUSScheduler) failClosed() bool {
+	return s.cfg.ExtenderErrorPolicy == config.ErrorPolicyFailClosed
+}
+
+// extenderError logs and counts a call that could not be evaluated and
+// returns the message reported to kube-scheduler
+func (s *NEXUSScheduler) extenderError(endpoint, class string, err error) string {
+	message := fmt.Sprintf("nexus %s: %s: %v", endpoint, class, err)
+	klog.Errorf("%s", message)
+	s.metrics.IncrementExtenderError(endpoint, class)
+	return message
+}
+
+// writeFilterError answers a Filter call that could not be evaluated
+// (args is nil when the body could not be decoded)
+func (s *NEXUSScheduler) writeFilterError(w http.ResponseWriter, args *ExtenderArgs, class string, err error, startTime time.Time) {
+	message := s.extenderError("filter", class, err)
+
+	result := ExtenderFilterResult{Error: message}
+	if args != nil && !s.failClosed() {
+		w.Header().Set(errorHeader, message)
+		result = ExtenderFilterResult{Nodes: args.Nodes, NodeNames: args.NodeNames}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+	s.metrics.ExtenderFilterLatency.TimeSince(startTime)
+}
+
+// writePrioritizeError answers a Prioritize call that could not be evaluated
+func (s *NEXUSScheduler) writePrioritizeError(w http.ResponseWriter, args *ExtenderArgs, class string, err error, startTime time.Time) {
+	message := s.extenderError("prioritize", class, err)
+
+	if s.failClosed() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(extenderErrorResponse{Error: message, Class: class})
+		s.metrics.ExtenderPrioritizeLatency.TimeSince(startTime)
+		return
+	}
+	if args == nil {
+		args = &ExtenderArgs{}
+	}
+	w.Header().Set(errorHeader, message)
+	s.writePrioritizeNoOpinion(w, args, startTime)
+}
+
+// recoverFilter turns a Filter handler panic into an error response
+func (s *NEXUSScheduler) recoverFilter(w http.ResponseWriter, args **ExtenderArgs, startTime time.Time) {
+	if r := recover(); r != nil {
+		klog.Errorf("Filter panic: %v\n%s", r, debug.Stack())
+		s.writeFilterError(w, *args, errClassPanic, fmt.Errorf("%v", r), startTime)
+	}
+}
+
+// recoverPrioritize turns a Prioritize handler panic into an error response
+func (s *NEXUSScheduler) recoverPrioritize(w http.ResponseWriter, args **ExtenderArgs, startTime time.Time) {
+	if r := recover(); r != nil {
+		klog.Errorf("Prioritize panic: %v\n%s", r, debug.Stack())
+		s.writePrioritizeError(w, *args, errClassPanic, fmt.Errorf("%v", r), startTime)
+	}
+}
@@ -0,0 +1,85 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestFilterErrorPolicies(t *testing.T) {
+	incomplete := `{"nodes":` + compatNodes + `}` // no pod
+
+	for _, tc := range []struct {
+		policy, body string
+		wantNodes    int
+		wantError    bool
+	}{
+		{config.ErrorPolicyFailOpen, incomplete, 2, false},
+		{config.ErrorPolicyFailClosed, incomplete, 0, true},
+		{config.ErrorPolicyFailOpen, `not json`, 0, true}, // nothing to keep
+		{config.ErrorPolicyFailClosed, `not json`, 0, true},
+	} {
+		s := newTestScheduler(t, StateActive)
+		s.cfg.ExtenderErrorPolicy = tc.policy
+
+		rec := httptest.NewRecorder()
+		s.HandleFilter(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(tc.body)))
+		var result ExtenderFilterResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s %q: %d %s", tc.policy, tc.body, rec.Code, rec.Body.String())
+		}
+		if got := nodeCount(&ExtenderArgs{Nodes: result.Nodes}); got != tc.wantNodes || (result.Error != "") != tc.wantError {
+			t.Errorf("%s %q: %d nodes, error %q; want %d nodes, error %v", tc.policy, tc.body, got, result.Error, tc.wantNodes, tc.wantError)
+		}
+		if tc.policy == config.ErrorPolicyFailOpen && rec.Header().Get(errorHeader) == "" && !tc.wantError {
+			t.Errorf("fail-open %q: no %s header", tc.body, errorHeader)
+		}
+	}
+}
+
+func TestPrioritizeErrorPolicies(t *testing.T) {
+	body := `{"nodes":` + compatNodes + `}`
+
+	s := newTestScheduler(t, StateActive)
+	rec := httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(body)))
+	var priorities []HostPriority
+	if err := json.Unmarshal(rec.Body.Bytes(), &priorities); err != nil || len(priorities) != 2 {
+		t.Errorf("fail-open: %d %s, want no-opinion scores for 2 nodes", rec.Code, rec.Body.String())
+	}
+
+	s.cfg.ExtenderErrorPolicy = config.ErrorPolicyFailClosed
+	rec = httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(body)))
+	var resp extenderErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusInternalServerError || resp.Class != errClassIncomplete {
+		t.Errorf("fail-closed: %d %s, want 500 with class %s", rec.Code, rec.Body.String(), errClassIncomplete)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if want := `nexus_extender_errors_total{endpoint="prioritize",class="incomplete"} 2`; !strings.Contains(out.Body.String(), want) {
+		t.Errorf("missing %s", want)
+	}
+}
+
+func TestFilterRecoversPanic(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.nodeScorer = nil // Filter dereferences the scorer for gang pods
+
+	rec := httptest.NewRecorder()
+	body := `{"pod":` + compatPod + `,"nodes":` + compatNodes + `}`
+	s.HandleFilter(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(body)))
+
+	var result ExtenderFilterResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("panic answered %q: %v", rec.Body.String(), err)
+	}
+	if nodeCount(&ExtenderArgs{Nodes: result.Nodes}) != 2 || !strings.Contains(rec.Header().Get(errorHeader), errClassPanic) {
+		t.Errorf("fail-open panic: %+v (header %q), want every node kept", result, rec.Header().Get(errorHeader))
+	}
+}
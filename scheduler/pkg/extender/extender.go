@@ -0,0 +1,1386 @@
+/*
+NEXUS Scheduler Extender
+========================
+Research-faithful implementation of the NEXUS Event-Driven
+Dependency-Aware Scheduler as a Kubernetes Scheduler Extender.
+
+KEY ARCHITECTURAL PRINCIPLES (from professional review):
+1. NEXUS is NOT a custom scheduler — it's an Extender
+2. kube-scheduler ALWAYS makes the final bind decision
+3. NEXUS only ADVISES via HTTP Filter + Prioritize endpoints
+4. NEXUS remains DORMANT (returns "no opinion") during steady state
+5. NEXUS activates ONLY on spike detection events
+6. Gangs are TEMPORARY — created on spike, dissolved on cooldown
+   (optionally after a DRAINING period with reduced locality weight)
+7. NO pod migration — only newly-created replicas are influenced
+
+Extender API (EXTENDER_ADDR):
+  POST /filter     → Remove nodes that violate gang co-location
+  POST /prioritize → Score nodes by gang member locality
+  GET  /healthz    → Health check
+
+Observability API (ADMIN_ADDR):
+  GET  /metrics    → Prometheus research metrics
+  GET  /config     → Effective configuration (secrets redacted)
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/export"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/metrics"
+	"nexus-scheduler/pkg/scorer"
+	"nexus-scheduler/pkg/store"
+)
+
+const (
+	schedulerName = "nexus-scheduler"
+
+	// Spike detection defaults
+	cooldownDuration = 30 * time.Second
+
+	// Spike detection polling interval
+	spikeCheckInterval = 10 * time.Second
+
+	// Response header carrying the score breakdown (SCORE_DEBUG=header)
+	scoreBreakdownHeader = "X-Nexus-Score-Breakdown"
+
+	// Response header carrying the decision's correlation ID
+	decisionIDHeader = "X-Nexus-Decision-Id"
+)
+
+// SchedulerState represents the current mode of the scheduler
+type SchedulerState int
+
+// Scheduler states: IDLE (no opinion), ACTIVE (gangs influence placement),
+// DRAINING (gangs kept with reduced locality before going IDLE)
+const (
+	StateIdle SchedulerState = iota
+	StateActive
+	StateDraining
+)
+
+// String returns the scheduler state name used in logs, metrics and /status
+func (s SchedulerState) String() string {
+	switch s {
+	case StateIdle:
+		return "IDLE"
+	case StateActive:
+		return "ACTIVE"
+	case StateDraining:
+		return "DRAINING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// --- Kubernetes Extender API Types ---
+
+// ExtenderArgs represents the arguments passed to the extender
+type ExtenderArgs struct {
+	Pod       *v1.Pod      `json:"pod"`
+	Nodes     *v1.NodeList `json:"nodes,omitempty"`
+	NodeNames *[]string    `json:"nodenames,omitempty"`
+}
+
+// ExtenderFilterResult represents the filter response
+type ExtenderFilterResult struct {
+	Nodes       *v1.NodeList      `json:"nodes,omitempty"`
+	NodeNames   *[]string         `json:"nodenames,omitempty"`
+	FailedNodes map[string]string `json:"failedNodes,omitempty"`
+	Error       string            `json:"error,omitempty"`
+
+	// Nodes preemption cannot make schedulable (kube-scheduler ≥ 1.20)
+	FailedAndUnresolvableNodes map[string]string `json:"failedAndUnresolvableNodes,omitempty"`
+}
+
+// DecodeExtenderArgs parses a Filter/Prioritize request body
+func DecodeExtenderArgs(body io.Reader) (*ExtenderArgs, error) {
+	var args ExtenderArgs
+	if err := json.NewDecoder(body).Decode(&args); err != nil {
+		return nil, err
+	}
+	return &args, nil
+}
+
+// HostPriority represents a node priority score
+type HostPriority struct {
+	Host  string `json:"host"`
+	Score int64  `json:"score"`
+}
+
+// --- NEXUS Scheduler Extender ---
+
+// NEXUSScheduler is the main scheduler extender
+type NEXUSScheduler struct {
+	clientset     kubernetes.Interface
+	state         SchedulerState
+	stateMu       sync.RWMutex
+	lastSpikeTime time.Time
+
+	// Current spike episode (persisted for restart recovery)
+	episodeID   string
+	activatedAt time.Time
+	spikeClass  detector.SpikeClass
+	stateStore  *StateStore
+
+	// Scored gang member pods waiting for their binding (influence.go)
+	influence influenceTracker
+
+	// Activation level of the current episode and whether the last
+	// positive spike check was severe enough to enforce (levels.go)
+	activationLevel ActivationLevel
+	lastSevere      bool
+
+	// Cause of the current episode and of the last positive spike check
+	spikeTrigger *detector.Trigger
+	lastTrigger  detector.Trigger
+
+	// Extensions of the current episode and whether the last positive
+	// spike check reached the extension threshold (extension.go)
+	extensions     int
+	lastExtendable bool
+
+	// Core modules
+	spikeDetector *detector.SpikeDetector
+	depGraph      *graph.DependencyGraph
+	gangManager   *gang.GangManager
+	nodeScorer    *scorer.NodeScorer
+	metrics       *metrics.NEXUSMetrics
+	apiGuard      *kube.APIGuard
+
+	// Memory budget: nodes evaluated per Filter call
+	maxNodesScanned int
+
+	// Dependency graph scope (cluster-wide or spike-implicated services)
+	graphScope string
+
+	// Per-decision score breakdown output (off, header, log)
+	scoreDebug string
+
+	// Correlation IDs of Prioritize decisions (X-Nexus-Decision-Id)
+	decisionIDs *decisionIDs
+
+	// Alternative formulas scored in shadow (nil = SHADOW_SCORERS unset)
+	shadows *shadowScoring
+
+	// Order among nodes with equal Prioritize scores (SCORE_TIE_BREAK)
+	ties *tieBreaker
+
+	// Optional KEDA ScaledObject activation trigger
+	kedaWatcher *detector.KEDAWatcher
+
+	// Pods never influenced (system namespaces, critical priority classes)
+	systemPods *systemPodGuard
+
+	// Pod label holding the service name (admission-time lookups)
+	serviceLabel string
+
+	// Influence pods created before activation too (default: new replicas only)
+	influencePreexisting bool
+
+	// Resolved configuration, reported at /config
+	cfg *config.Config
+
+	// Post-spike drain period (0 = dissolve as soon as the spike ends)
+	drainDuration      time.Duration
+	drainLocalityScale float64
+	drainStartedAt     time.Time
+
+	// Reject nodes without gang members (default: locality is only scored)
+	gangFilterStrict bool
+
+	// Optional VPA recommendations for the resource-fit check (nil = disabled)
+	vpa *kube.VPAProvider
+
+	// Optional HPA stabilization windows raising the gang cooldown (nil = disabled)
+	hpa *kube.HPAStabilization
+
+	// Optional descheduler protection for active gang members (nil = disabled)
+	protector *EvictionProtector
+
+	// Optional gang and episode labels on gang-member pods (nil = disabled)
+	labeler *MemberLabeler
+
+	// Optional soft pod affinity hints on gang-member Deployments (nil = disabled)
+	hinter *AffinityHinter
+
+	// Coscheduling PodGroups of the active gangs (nil = disabled)
+	podGroups *PodGroupManager
+
+	// Optional per-decision CSV export (nil = disabled)
+	decisions *export.DecisionExporter
+
+	// Optional OTLP trace per spike episode (nil = disabled)
+	traces *export.TraceExporter
+	trace  episodeTrace
+
+	// Per-group latency SLO accounting for the current episode
+	slo sloTracker
+
+	// Influence sweep experiment (WEIGHT_SWEEP)
+	sweep weightSweep
+
+	// Recent episodes and decisions for /episodes and /decisions
+	history history
+
+	// Optional persistent episode and decision store (nil = disabled)
+	store *store.Store
+
+	// Gang-member pod scheduling latency from Scheduled events
+	schedLatency schedulingLatencyState
+
+	// Gang formation strategy of the running and the next episodes
+	formation formationState
+
+	// Activation flapping back-off (FLAP_MAX_ACTIVATIONS)
+	flap flapGuard
+
+	// Self-disable on a sustained latency regression (LATENCY_BUDGET)
+	latency latencyGuard
+
+	// NexusPolicies by group (NEXUS_POLICIES)
+	policies policyStore
+
+	// Background spike check cycle (SPIKE_CHECK_TIMEOUT, SPIKE_CHECK_JITTER)
+	spikeCheck spikeCheckState
+
+	// Synthetic spike armed through POST /admin/spike
+	injected injectedSpikeState
+
+	// Episode starts and ends, serialized with the admin API (episodeadmin.go)
+	transitions episodeTransitions
+
+	// Sampling of the per-decision Filter/Prioritize log lines
+	decisionLog decisionLogSampler
+
+	// Credential refresh of the Kubernetes clients (nil = not installed)
+	auth *kube.AuthRefresher
+
+	// Budgeted pod lists, and the pod watch behind warm gang member counts
+	podLister   *kube.PodLister
+	memberCache memberCacheState
+
+	// Node availability watch of the running episode (NODE_FAILURE_WATCH)
+	nodeWatch nodeWatchState
+
+	// Last synthetic Filter call to the extender listener (SELF_PROBE_INTERVAL)
+	selfProbe selfProbeState
+
+	// Extender node format expected from kube-scheduler, and the last one seen
+	extenderProtocol string
+	protocolMu       sync.Mutex
+	lastProtocol     string
+}
+
+// NewNEXUSScheduler creates a new scheduler extender instance
+func NewNEXUSScheduler(clientset kubernetes.Interface, dynamicClient dynamic.Interface, cfg *config.Config) *NEXUSScheduler {
+	metrics := metrics.NewNEXUSMetrics()
+	apiGuard := kube.NewAPIGuard(cfg, metrics)
+	podLister := kube.NewPodLister(clientset, apiGuard, metrics, cfg)
+	spikeDetector := detector.NewSpikeDetector()
+	loadAppProfiles(cfg.AppProfiles)
+	spikeDetector.SetServiceThresholds(graph.ServiceQPSThresholds())
+	depGraph := graph.NewDependencyGraph(podLister, cfg.GraphServiceLabel, cfg.DependencyDepth)
+	gangManager := gang.NewGangManager(metrics, cfg.MaxGangs, cfg.MaxInfluencedPods)
+	serviceFilter := graph.NewServiceFilter(cfg.GangServiceAllowlist, cfg.GangServiceDenylist)
+	depGraph.SetServiceFilter(serviceFilter)
+	if cfg.GraphNetworkPolicy == config.NetworkPolicyWarn || cfg.GraphNetworkPolicy == config.NetworkPolicyEnforce {
+		depGraph.SetNetworkPolicies(kube.NewNetworkPolicyLister(clientset, apiGuard), cfg.GraphNetworkPolicy == config.NetworkPolicyEnforce)
+	}
+	if cfg.GraphIstio && dynamicClient != nil {
+		depGraph.SetMeshRoutes(kube.NewIstioRouteLister(dynamicClient, apiGuard))
+	}
+	gangManager.SetServiceFilter(serviceFilter)
+	metrics.SetInfluenceBudget(cfg.MaxInfluencedPods)
+	metrics.SetLegacyNames(cfg.MetricsLegacyNames)
+
+	scheduler := &NEXUSScheduler{
+		clientset:     clientset,
+		apiGuard:      apiGuard,
+		state:         StateIdle,
+		spikeDetector: spikeDetector,
+		depGraph:      depGraph,
+		gangManager:   gangManager,
+		metrics:       metrics,
+		podLister:     podLister,
+	}
+
+	// Node scorer needs gang manager for locality scoring
+	var utilization *kube.UtilizationProvider
+	if cfg.UtilizationScoring {
+		utilization = kube.NewUtilizationProvider(clientset.Discovery().RESTClient(), apiGuard, cfg.UtilizationCacheTTL)
+		klog.Info("  Scoring: metrics-server utilization penalty enabled")
+	}
+	scheduler.nodeScorer = scorer.NewNodeScorer(gangManager, podLister, utilization, cfg)
+	if len(cfg.ShadowScorers) > 0 {
+		scheduler.shadows = newShadowScoring(gangManager, podLister, utilization, cfg)
+		klog.Infof("  Scoring: %d shadow scorers evaluated alongside the primary", len(scheduler.shadows.scorers))
+	}
+	scheduler.maxNodesScanned = cfg.MaxNodesScanned
+	scheduler.graphScope = cfg.GraphScope
+	scheduler.scoreDebug = cfg.ScoreDebug
+	scheduler.decisionIDs = newDecisionIDs(time.Now())
+	scheduler.ties = newTieBreaker(cfg.ScoreTieBreak, cfg.ScoreTieBreakSeed)
+	scheduler.serviceLabel = cfg.GraphServiceLabel
+	scheduler.systemPods = newSystemPodGuard(cfg)
+	scheduler.influencePreexisting = cfg.InfluencePreexisting
+	scheduler.cfg = cfg
+	scheduler.drainDuration = cfg.DrainDuration
+	scheduler.drainLocalityScale = cfg.DrainLocalityScale
+	scheduler.extenderProtocol = cfg.ExtenderProtocol
+	scheduler.gangFilterStrict = cfg.GangFilterStrict
+	scheduler.sweep.factors = sweepFactors(cfg.WeightSweep)
+
+	if cfg.MetricsSnapshotPath != "" {
+		scheduler.restoreCounters()
+	}
+
+	if cfg.StateRecovery {
+		scheduler.stateStore = NewStateStore(clientset, apiGuard, cfg)
+	}
+
+	if cfg.VPARecommendations {
+		scheduler.vpa = kube.NewVPAProvider(dynamicClient, apiGuard, cfg.VPACacheTTL)
+		klog.Info("  Filter: VPA target recommendations used for resource fit")
+	}
+
+	if cfg.GangMemberLabels {
+		scheduler.labeler = NewMemberLabeler(clientset, apiGuard, podLister, metrics)
+		klog.Info("  Labels: gang-member pods labelled with nexus.io/gang and nexus.io/episode")
+	}
+
+	if cfg.AffinityHints {
+		weight := cfg.AffinityHintWeight
+		if weight < 1 || weight > 100 {
+			weight = 100
+		}
+		scheduler.hinter = NewAffinityHinter(clientset, apiGuard, metrics, int32(weight), cfg.AffinityHintNamespace)
+		klog.Infof("  Affinity hints: gang-member Deployments get preferred podAffinity (weight %d)", weight)
+	}
+
+	if cfg.HPAStabilizationCooldown {
+		scheduler.hpa = kube.NewHPAStabilization(clientset, apiGuard, cfg.HPANamespace)
+		klog.Info("  Cooldown: raised to the gangs' HPA scale-down stabilization windows")
+	}
+
+	if cfg.EvictionProtection {
+		scheduler.protector = NewEvictionProtector(clientset, apiGuard, podLister, metrics, cfg.EvictionProtectionAnnotations)
+		klog.Info("  Eviction protection: gang members annotated while gangs are active")
+	}
+
+	if cfg.PodGroups && dynamicClient != nil {
+		scheduler.podGroups = NewPodGroupManager(dynamicClient, apiGuard, podLister, metrics, cfg.PodGroupLabel, cfg.PodGroupTimeout)
+		klog.Info("  Coscheduling: PodGroups created for active gangs")
+	}
+
+	if cfg.DecisionExport == config.DecisionExportCSV {
+		exporter, err := export.NewDecisionExporter(cfg, metrics)
+		if err != nil {
+			klog.Warningf("Decision export disabled: %v", err)
+		} else {
+			scheduler.decisions = exporter
+			klog.Infof("  Decision export: CSV files in %s", cfg.DecisionExportDir)
+		}
+	}
+
+	if cfg.HistoryStore {
+		historyStore, err := store.Open(cfg, metrics)
+		if err != nil {
+			klog.Warningf("History store disabled: %v", err)
+		} else {
+			scheduler.store = historyStore
+			klog.Infof("  History store: segments in %s (retention %v)", cfg.HistoryStoreDir, cfg.HistoryStoreRetention)
+		}
+	}
+
+	if cfg.OTLPEndpoint != "" {
+		scheduler.traces = export.NewTraceExporter(cfg)
+		klog.Infof("  Episode traces: OTLP to %s", cfg.OTLPEndpoint)
+	}
+
+	if len(cfg.WeightSweep) > 0 {
+		klog.Infof("  Weight sweep: influence factors %v cycled across episodes", cfg.WeightSweep)
+	}
+
+	if cfg.NexusPolicies && dynamicClient != nil {
+		scheduler.policies.client = dynamicClient
+		scheduler.policies.namespace = cfg.NexusPolicyNamespace
+		klog.Info("  Policies: NexusPolicy resources applied at gang formation")
+	}
+
+	if cfg.KEDATrigger {
+		scheduler.kedaWatcher = detector.NewKEDAWatcher(dynamicClient, apiGuard, cfg.KEDANamespace)
+		klog.Info("  Trigger: KEDA ScaledObject activity enabled")
+	}
+
+	metrics.SetFeatures(scheduler.Features())
+	metrics.SetCooldown(cooldownDuration.Seconds())
+
+	klog.Info("NEXUS Scheduler Extender initialized")
+	klog.Info("  Mode: Cooperative (Extender, NOT replacement)")
+	klog.Info("  State: IDLE (dormant until spike detected)")
+	klog.Info("  Endpoints: /filter, /prioritize, /metrics, /healthz")
+
+	return scheduler
+}
+
+// --- State Management ---
+
+// GetState returns the current scheduler state (thread-safe)
+func (s *NEXUSScheduler) GetState() SchedulerState {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.state
+}
+
+// ActivatedAt returns when the current spike episode activated (thread-safe)
+func (s *NEXUSScheduler) ActivatedAt() time.Time {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.activatedAt
+}
+
+// EpisodeID returns the current spike episode ID (thread-safe)
+func (s *NEXUSScheduler) EpisodeID() string {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.episodeID
+}
+
+// startEpisode records the activation time and ID of a new spike episode
+func (s *NEXUSScheduler) startEpisode(episodeID string, activatedAt time.Time) {
+	s.stateMu.Lock()
+	s.episodeID = episodeID
+	s.activatedAt = activatedAt
+	s.extensions = 0
+	s.stateMu.Unlock()
+
+	s.recordEpisodeStart(episodeID, activatedAt)
+}
+
+// SetState sets the scheduler state (thread-safe)
+func (s *NEXUSScheduler) SetState(state SchedulerState) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.state != state {
+		klog.Infof("NEXUS state change: %s → %s", s.state, state)
+		s.state = state
+		s.metrics.SetState(state.String())
+		s.metrics.IncrementCounter("state_changes")
+	}
+}
+
+// --- Extender HTTP Endpoints ---
+
+// HandleFilter processes Filter requests from kube-scheduler
+// When IDLE: returns all nodes (no opinion — zero overhead)
+// When ACTIVE: removes nodes that violate gang co-location requirements
+func (s *NEXUSScheduler) HandleFilter(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	s.metrics.IncrementCounter("filter_calls")
+
+	var args *ExtenderArgs
+	defer s.recoverFilter(w, &args, startTime)
+
+	// Parse request
+	args, err := DecodeExtenderArgs(r.Body)
+	if err != nil {
+		s.writeFilterError(w, nil, errClassDecode, err, startTime)
+		return
+	}
+	s.observeProtocol(args)
+
+	// Self-disabled after a latency regression: no opinion until re-enabled
+	if s.SelfDisabled() {
+		klog.V(3).Info("Filter: self-disabled — returning all nodes (no opinion)")
+		s.metrics.IncrementCounter("self_disabled_calls")
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	// IDLE state: return all nodes (no opinion)
+	if s.GetState() == StateIdle {
+		klog.V(3).Info("Filter: IDLE state — returning all nodes (no opinion)")
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	// ADVISORY level: locality is only a score, every node stays eligible
+	if s.ActivationLevel() == LevelAdvisory {
+		klog.V(3).Info("Filter: ADVISORY level — returning all nodes (no opinion)")
+		s.metrics.IncrementCounter("advisory_filters")
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	// ACTIVE state: filter based on gang co-location
+	pod := args.Pod
+	nodes := candidateNodes(args)
+	if pod == nil || nodes == nil {
+		s.writeFilterError(w, args, errClassIncomplete, errIncompleteArgs, startTime)
+		return
+	}
+
+	if s.systemPod("Filter", pod) {
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	if !s.schedulerAllowed(pod) {
+		klog.V(2).Infof("Filter: Pod %s uses scheduler %q — returning all nodes", pod.Name, pod.Spec.SchedulerName)
+		s.metrics.IncrementCounter("scheduler_skipped")
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	gang := s.gangManager.GetGangForPod(pod)
+	if gang == nil {
+		// Pod not in any gang — return all nodes (no opinion)
+		klog.V(2).Infof("Filter: Pod %s not in any gang — returning all nodes", pod.Name)
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+	s.gangManager.ObserveMember(gang, graph.ExtractServiceName(pod.Name))
+
+	if !s.isNewReplica(pod) {
+		// Pre-existing pod being rescheduled (eviction, node failure) — no opinion
+		klog.V(2).Infof("Filter: Pod %s created before activation — returning all nodes", pod.Name)
+		s.metrics.IncrementCounter("preexisting_skipped")
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	if !s.gangManager.ConsumeInfluence(gang, pod) {
+		klog.V(2).Infof("Filter: gang %s influence budget spent — returning all nodes", gang.ID)
+		s.writeFilterNoOpinion(w, args, startTime)
+		return
+	}
+
+	// Find nodes with gang members
+	nodesWithMembers := make(map[string]bool)
+	ctx := context.Background()
+	scanned, _ := kube.CapNodes(nodes.Items, s.maxNodesScanned)
+	memberCounts := make(map[string]int, len(scanned))
+	candidates := make([]string, 0, len(scanned))
+	for _, node := range scanned {
+		if kube.NodeExcluded(&node, s.cfg.NodeExcludeLabel) {
+			continue // never a gang member node
+		}
+		memberCount := s.nodeScorer.CountGangMembersOnNode(ctx, &node, gang)
+		memberCounts[node.Name] = memberCount
+		candidates = append(candidates, node.Name)
+		if memberCount > 0 {
+			nodesWithMembers[node.Name] = true
+		}
+	}
+	fresh := len(nodesWithMembers) == 0
+	// With a placement plan, the nodes it still has slots on take their place
+	if planned, ok := s.planNodesWithSlots(gang, memberCounts, candidates); ok {
+		nodesWithMembers = planned
+	}
+
+	// Reject nodes that cannot host the pod; gang members only narrow the
+	// set further with GANG_FILTER_STRICT (locality is otherwise a score),
+	// and a gang starting fresh is filtered by FRESH_GANG_PLACEMENT
+	verdict := s.filterNodes(ctx, pod, nodes.Items, args.Nodes == nil, gang, nodesWithMembers, fresh)
+	for node, message := range verdict.rejected {
+		klog.V(2).Infof("Filter: Pod %s rejected on %s — %s", pod.Name, node, message)
+		s.metrics.IncrementFilterRejection(string(reasonOf(message)))
+	}
+	eligibleNodes := verdict.eligible
+	result := newFilterResult(args, eligibleNodes, verdict.rejected)
+
+	s.logDecision("Filter: Pod %s (gang: %s) → %d/%d nodes eligible",
+		pod.Name, gang.ID, len(eligibleNodes), len(nodes.Items))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+	s.metrics.ExtenderFilterLatency.TimeSince(startTime)
+}
+
+// HandlePrioritize processes Prioritize requests from kube-scheduler
+// When IDLE: returns equal scores (no opinion — zero overhead)
+// When ACTIVE: scores nodes based on gang member locality
+func (s *NEXUSScheduler) HandlePrioritize(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	s.metrics.IncrementCounter("prioritize_calls")
+
+	var args *ExtenderArgs
+	defer s.recoverPrioritize(w, &args, startTime)
+
+	// Parse request
+	args, err := DecodeExtenderArgs(r.Body)
+	if err != nil {
+		s.writePrioritizeError(w, nil, errClassDecode, err, startTime)
+		return
+	}
+	s.observeProtocol(args)
+
+	// Self-disabled after a latency regression: no opinion until re-enabled
+	if s.SelfDisabled() {
+		klog.V(3).Info("Prioritize: self-disabled — returning equal scores (no opinion)")
+		s.metrics.IncrementCounter("self_disabled_calls")
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	// IDLE state: return equal scores (no opinion)
+	if s.GetState() == StateIdle {
+		klog.V(3).Info("Prioritize: IDLE state — returning equal scores (no opinion)")
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	// ACTIVE state: score based on gang locality
+	pod := args.Pod
+	nodes := candidateNodes(args)
+	if pod == nil || nodes == nil {
+		s.writePrioritizeError(w, args, errClassIncomplete, errIncompleteArgs, startTime)
+		return
+	}
+
+	if s.systemPod("Prioritize", pod) {
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	if !s.schedulerAllowed(pod) {
+		klog.V(2).Infof("Prioritize: Pod %s uses scheduler %q — returning equal scores", pod.Name, pod.Spec.SchedulerName)
+		s.metrics.IncrementCounter("scheduler_skipped")
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	gang := s.gangManager.GetGangForPod(pod)
+	if gang == nil {
+		// Pod not in any gang — scored by its fallback policy (see fallback.go)
+		s.prioritizeNonMember(w, args, pod, startTime)
+		return
+	}
+	s.gangManager.ObserveMember(gang, graph.ExtractServiceName(pod.Name))
+
+	if !s.isNewReplica(pod) {
+		// Pre-existing pod being rescheduled (eviction, node failure) — no opinion
+		klog.V(2).Infof("Prioritize: Pod %s created before activation — returning equal scores", pod.Name)
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	if !s.gangManager.ConsumeInfluence(gang, pod) {
+		klog.V(2).Infof("Prioritize: gang %s influence budget spent — returning equal scores", gang.ID)
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	// Score nodes by gang locality (reduced while draining)
+	localityScale := 1.0
+	if s.GetState() == StateDraining {
+		localityScale = s.drainLocalityScale
+		s.metrics.IncrementCounter("drain_decisions")
+	}
+	locality := s.localityFor(gang, localityScale)
+	schedulable, skipped := s.splitSchedulable(context.Background(), pod, args, nodes)
+	breakdown := withUnschedulable(s.nodeScorer.Score(context.Background(), pod, schedulable, gang, locality), nodes, skipped)
+	s.countDegraded(pod, breakdown)
+	if len(breakdown) > 0 && breakdown[0].Planned {
+		s.metrics.IncrementCounter("planned_decisions")
+	}
+	if len(breakdown) > 0 && breakdown[0].Anchored {
+		s.metrics.IncrementCounter("anchored_decisions")
+	}
+	priorities := scaleInfluence(hostPriorities(breakdown), s.influenceFactor()*priorityBoost(gang))
+	s.recordArbitrations(pod, gang, s.arbitrate(gang, priorities))
+	if s.ties.apply(pod, priorities) {
+		s.metrics.IncrementCounter("score_ties_broken")
+	}
+	s.notePlacement(pod, gang, priorities)
+
+	decisionID := s.decisionIDs.next()
+	w.Header().Set(decisionIDHeader, decisionID)
+	s.logDecision("Prioritize: Pod %s (gang: %s, decision: %s) → scores: %+v", pod.Name, gang.ID, decisionID, priorities)
+	s.reportScoreBreakdown(w, decisionID, pod, gang, breakdown)
+	s.exportDecision(decisionID, pod, gang, breakdown)
+	s.recordDecision(decisionID, pod, gang, breakdown)
+	s.scoreShadows(decisionID, pod, schedulable, gang, locality, priorities)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priorities)
+	s.metrics.ExtenderPrioritizeLatency.TimeSince(startTime)
+}
+
+// countDegraded counts a decision scored without live gang member counts
+func (s *NEXUSScheduler) countDegraded(pod *v1.Pod, breakdown []scorer.ScoreBreakdown) {
+	degraded := 0
+	for _, b := range breakdown {
+		if b.Degraded {
+			degraded++
+		}
+	}
+	if degraded > 0 {
+		klog.Warningf("Prioritize: Pod %s scored with %d of %d nodes degraded (API throttled or failing)", pod.Name, degraded, len(breakdown))
+		s.metrics.IncrementCounter("degraded_decisions")
+	}
+}
+
+// hostPriorities converts a score breakdown into extender HostPriority scores
+func hostPriorities(breakdown []scorer.ScoreBreakdown) []HostPriority {
+	priorities := make([]HostPriority, 0, len(breakdown))
+	for _, b := range breakdown {
+		priorities = append(priorities, HostPriority{Host: b.Host, Score: b.Total})
+	}
+	return priorities
+}
+
+// writeFilterNoOpinion returns every candidate node unchanged, in the request's format
+func (s *NEXUSScheduler) writeFilterNoOpinion(w http.ResponseWriter, args *ExtenderArgs, startTime time.Time) {
+	result := ExtenderFilterResult{Nodes: args.Nodes, NodeNames: args.NodeNames}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+	s.metrics.ExtenderFilterLatency.TimeSince(startTime)
+}
+
+// writePrioritizeNoOpinion returns an equal score of 0 for every candidate node
+func (s *NEXUSScheduler) writePrioritizeNoOpinion(w http.ResponseWriter, args *ExtenderArgs, startTime time.Time) {
+	priorities := make([]HostPriority, 0)
+	if nodes := candidateNodes(args); nodes != nil {
+		for _, node := range nodes.Items {
+			priorities = append(priorities, HostPriority{
+				Host:  node.Name,
+				Score: 0, // Equal score = no preference
+			})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priorities)
+	s.metrics.ExtenderPrioritizeLatency.TimeSince(startTime)
+}
+
+// isNewReplica reports whether NEXUS may influence the pod: only replicas
+// created after the current episode activated are influenced, so pre-existing
+// pods rescheduled after evictions or node failures keep default placement.
+// INFLUENCE_PREEXISTING_PODS=true disables the check.
+func (s *NEXUSScheduler) isNewReplica(pod *v1.Pod) bool {
+	if s.influencePreexisting || pod.CreationTimestamp.IsZero() {
+		return true
+	}
+	return !pod.CreationTimestamp.Time.Before(s.ActivatedAt())
+}
+
+// schedulerAllowed reports whether the pod's scheduler profile is one NEXUS
+// influences (SCHEDULER_NAMES; an unset schedulerName is the default scheduler)
+func (s *NEXUSScheduler) schedulerAllowed(pod *v1.Pod) bool {
+	if len(s.cfg.SchedulerNames) == 0 {
+		return true
+	}
+	name := pod.Spec.SchedulerName
+	if name == "" {
+		name = v1.DefaultSchedulerName
+	}
+	for _, allowed := range s.cfg.SchedulerNames {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// reportScoreBreakdown exposes the per-node scoring components of a decision
+// SCORE_DEBUG=header: JSON in the X-Nexus-Score-Breakdown response header
+// SCORE_DEBUG=log:    one structured log line per decision (no V(3) needed),
+// keyed by the decision ID and the pod UID for joining with scheduler events
+func (s *NEXUSScheduler) reportScoreBreakdown(w http.ResponseWriter, decisionID string, pod *v1.Pod, gang *gang.Gang, breakdown []scorer.ScoreBreakdown) {
+	switch s.scoreDebug {
+	case config.ScoreDebugHeader:
+		data, err := json.Marshal(breakdown)
+		if err != nil {
+			klog.Warningf("Failed to encode score breakdown: %v", err)
+			return
+		}
+		w.Header().Set(scoreBreakdownHeader, string(data))
+	case config.ScoreDebugLog:
+		klog.InfoS("NEXUS decision",
+			"decision", decisionID,
+			"pod", klog.KObj(pod),
+			"podUID", pod.UID,
+			"gang", gang.ID,
+			"breakdown", breakdown)
+	}
+}
+
+// exportDecision queues a decision for the CSV export, if enabled
+func (s *NEXUSScheduler) exportDecision(decisionID string, pod *v1.Pod, gang *gang.Gang, breakdown []scorer.ScoreBreakdown) {
+	if s.decisions == nil {
+		return
+	}
+	s.decisions.Record(export.Decision{
+		ID:         decisionID,
+		Time:       time.Now(),
+		Episode:    s.EpisodeID(),
+		State:      s.GetState().String(),
+		SpikeClass: string(s.SpikeClass()),
+		Namespace:  pod.Namespace,
+		Pod:        pod.Name,
+		Gang:       gang.ID,
+		Scores:     breakdown,
+	})
+}
+
+// ExportDecisions runs the decision export writer until ctx is done
+// (returns immediately when DECISION_EXPORT is off)
+func (s *NEXUSScheduler) ExportDecisions(ctx context.Context) {
+	if s.decisions != nil {
+		s.decisions.Run(ctx)
+	}
+}
+
+// RunHistoryStore runs the history store writer until ctx is done
+// (returns immediately when HISTORY_STORE is off)
+func (s *NEXUSScheduler) RunHistoryStore(ctx context.Context) {
+	if s.store != nil {
+		s.store.Run(ctx)
+	}
+}
+
+// --- Spike Detection Loop ---
+
+// SpikeWatcher periodically checks Prometheus for spikes
+// This is EVENT-DRIVEN, not continuous: it only checks at intervals
+func (s *NEXUSScheduler) SpikeWatcher(ctx context.Context) {
+	ticker := time.NewTicker(spikeCheckInterval)
+	defer ticker.Stop()
+
+	klog.Infof("Spike watcher started (checking every %v)", spikeCheckInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			klog.Info("Spike watcher shutting down")
+			return
+		case <-ticker.C:
+			s.startSpikeCheck(ctx)
+		}
+	}
+}
+
+// checkForSpike evaluates spike conditions and transitions state
+func (s *NEXUSScheduler) checkForSpike(ctx context.Context) {
+	s.transitions.mu.Lock()
+	defer s.transitions.mu.Unlock()
+
+	currentState := s.GetState()
+	s.metrics.SetThresholdProfile(s.spikeDetector.ActiveProfile().Name)
+	s.checkStabilization(time.Now())
+	s.checkLatencyBudget(time.Now())
+
+	if currentState == StateIdle {
+		// Check for spike
+		detectionStart := time.Now()
+		if class, triggerServices, err := s.detectSpike(ctx); err == nil && class != detector.SpikeClassNone {
+			activationStart := time.Now()
+			if !s.allowActivation(activationStart) {
+				return
+			}
+
+			s.activate(ctx, class, triggerServices, newEpisodeID(activationStart), detectionStart, activationStart)
+		}
+	}
+
+	if currentState == StateDraining {
+		// New spike during the drain: keep the existing gangs, back to full weight
+		if class, _, err := s.detectSpike(ctx); err == nil && class != detector.SpikeClassNone && s.allowActivation(time.Now()) && s.allowExtension() {
+			klog.Info("Spike detected while draining — returning to ACTIVE with existing gangs")
+			s.metrics.IncrementCounter("drain_reactivations")
+			s.gangManager.SetStage(gang.GangStageScheduling)
+			s.setSpikeClass(class)
+			s.SetState(StateActive)
+			s.updateActivationLevel()
+			s.lastSpikeTime = time.Now()
+			s.persistActivation(ctx)
+			s.traceWindow(spanScheduling, s.lastSpikeTime)
+		}
+	}
+}
+
+// activate builds the dependency graph, forms the gangs and starts the
+// episode for a detected spike
+func (s *NEXUSScheduler) activate(ctx context.Context, class detector.SpikeClass, triggerServices []string, episodeID string, detectionStart, activationStart time.Time) error {
+	klog.Info("═══════════════════════════════════════════")
+	klog.Infof("  SPIKE DETECTED (%s) — Activating NEXUS", class)
+	klog.Info("═══════════════════════════════════════════")
+
+	// Stage 1: Spike detected
+	s.gangManager.SetStage(gang.GangStageDetected)
+	s.metrics.IncrementCounter("spike_events")
+	s.metrics.IncrementSpikeClass(string(class))
+
+	// Stage 2: Build dependency graph
+	s.gangManager.SetStage(gang.GangStageGraphBuilt)
+	graphStart := time.Now()
+	prewarm := s.startPrewarm(ctx)
+	if err := s.buildDependencyGraph(ctx, triggerServices); err != nil {
+		klog.Errorf("Failed to build dependency graph: %v", err)
+		s.gangManager.SetStage(gang.GangStageNone)
+		return err
+	}
+	s.countIsolatedMembers()
+
+	// Stage 3 & 4: Form gangs from the graph
+	formationStart := time.Now()
+	s.formGangs(ctx, s.depGraph.GetGroups(), s.nextFormationStrategy())
+	formationEnd := time.Now()
+	s.finishPrewarm(ctx, prewarm)
+
+	// Transition to ACTIVE
+	s.startEpisode(episodeID, activationStart)
+	s.setSpikeClass(class)
+	s.attributeActivation()
+	s.startSweepEpisode()
+	s.SetState(StateActive)
+	s.updateActivationLevel()
+	s.lastSpikeTime = time.Now()
+	s.persistActivation(ctx)
+
+	s.beginTrace(detectionStart)
+	s.traceStage(spanDetection, detectionStart, activationStart, map[string]string{"nexus.spike_class": string(class)})
+	s.traceStage(spanGraphBuild, graphStart, formationStart, map[string]string{"nexus.groups": strconv.Itoa(len(s.depGraph.GetGroups()))})
+	s.traceStage(spanGangFormation, formationStart, formationEnd, map[string]string{"nexus.gangs": strconv.Itoa(s.gangManager.GetActiveGangCount())})
+	s.traceWindow(spanScheduling, formationEnd)
+
+	// Record activation latency
+	latencyMs := s.metrics.ActivationLatency.TimeSince(activationStart)
+	klog.Infof("NEXUS activated in %.2fms (gangs: %d)", latencyMs, s.gangManager.GetActiveGangCount())
+
+	s.protectGangMembers(ctx)
+	s.labelGangMembers(ctx)
+	s.hintGangAffinity(ctx)
+	s.createPodGroups(ctx)
+	return nil
+}
+
+// detectSpike checks the spike detector and, if enabled, KEDA ScaledObject
+// activity, returning the spike class (SpikeClassNone = no spike). When KEDA
+// triggers activation, the scaled services are returned so the graph is
+// built around their coordination groups. The check is bounded by
+// SPIKE_CHECK_TIMEOUT; an error means the result is inconclusive.
+func (s *NEXUSScheduler) detectSpike(ctx context.Context) (detector.SpikeClass, []string, error) {
+	if spike := s.takeInjectedSpike(); spike != nil {
+		klog.Infof("SPIKE DETECTED: synthetic %s spike injected through the admin API", spike.Class)
+		s.metrics.IncrementCounter("spikes_injected")
+		s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalInjected, Source: detector.TriggerSourceAdmin}, nil)
+		return spike.Class, spike.Services, nil
+	}
+
+	ctx, cancel := s.spikeCheckContext(ctx)
+	defer cancel()
+
+	class := s.spikeDetector.ClassifyContext(ctx, 0)
+	s.recordDetectorSignals()
+	if err := s.spikeCheckExpired(ctx); err != nil {
+		return detector.SpikeClassNone, nil, err
+	}
+	if class != detector.SpikeClassNone {
+		obs := s.spikeDetector.LastObservation()
+		if cause, ok := obs.Cause(); ok {
+			s.noteSpikeTrigger(cause, obs.Triggers)
+		}
+		return class, nil, nil
+	}
+
+	if s.kedaWatcher != nil {
+		services, err := s.kedaWatcher.ActiveServices(ctx)
+		if expired := s.spikeCheckExpired(ctx); expired != nil {
+			return detector.SpikeClassNone, nil, expired
+		}
+		if err != nil {
+			klog.Warningf("Failed to check KEDA ScaledObject activity: %v", err)
+		} else if len(services) > 0 {
+			klog.Infof("SPIKE DETECTED: KEDA ScaledObjects active for %v", services)
+			s.metrics.IncrementCounter("keda_triggers")
+			s.noteSpikeTrigger(detector.Trigger{
+				Signal: detector.SignalKEDA,
+				Source: detector.TriggerSourceKEDA,
+				Value:  float64(len(services)),
+			}, nil)
+			return detector.SpikeClassTraffic, services, nil
+		}
+	}
+
+	return detector.SpikeClassNone, nil, nil
+}
+
+// recordDetectorSignals exports what the detector's last check observed
+func (s *NEXUSScheduler) recordDetectorSignals() {
+	obs := s.spikeDetector.LastObservation()
+	thresholds := map[string]float64{
+		detector.SignalQPS:    obs.Profile.QPSThreshold,
+		detector.SignalErrors: obs.Profile.ErrorThreshold,
+		detector.SignalP95:    obs.Profile.P95LatencyThreshold,
+	}
+	if threshold, scoreMode := s.spikeDetector.ScoreThreshold(); scoreMode {
+		thresholds["score"] = threshold
+	}
+	s.metrics.SetDetectorSignals(metrics.DetectorSignals{
+		PrometheusUp: obs.PrometheusUp,
+		LocustUp:     obs.LocustUp,
+		QPS:          obs.QPS,
+		ErrorRate:    obs.ErrorRate,
+		P95Ms:        obs.P95Ms,
+		HPAIncrease:  obs.HPAIncrease,
+		Users:        obs.Users,
+		Score:        obs.Score,
+		Thresholds:   thresholds,
+		At:           obs.At,
+	})
+}
+
+// buildDependencyGraph builds the graph around the trigger services (KEDA),
+// cluster-wide, or scoped to the services implicated by the spike when
+// GRAPH_SCOPE=spike
+func (s *NEXUSScheduler) buildDependencyGraph(ctx context.Context, triggerServices []string) error {
+	if len(triggerServices) > 0 {
+		return s.depGraph.BuildForServices(ctx, triggerServices)
+	}
+	if s.graphScope == config.GraphScopeSpike {
+		if services := s.spikeDetector.SpikingServices(); len(services) > 0 {
+			return s.depGraph.BuildForServices(ctx, services)
+		}
+		klog.Info("No individual spiking service identified, falling back to cluster-wide graph")
+	}
+	return s.depGraph.BuildFromAnnotations(ctx)
+}
+
+// countIsolatedMembers counts the group members the graph build found
+// without a network path to the rest of their group
+func (s *NEXUSScheduler) countIsolatedMembers() {
+	isolated := 0
+	for _, group := range s.depGraph.GetGroups() {
+		isolated += len(group.Isolated)
+	}
+	if isolated > 0 {
+		s.metrics.AddIsolatedMembers(isolated)
+	}
+}
+
+// CooldownChecker monitors for returning to IDLE state
+func (s *NEXUSScheduler) CooldownChecker(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkCooldown(ctx)
+		}
+	}
+}
+
+// checkCooldown dissolves the gangs after the drain, and drains or extends
+// an ACTIVE episode once its cooldown has elapsed
+func (s *NEXUSScheduler) checkCooldown(ctx context.Context) {
+	s.transitions.mu.Lock()
+	defer s.transitions.mu.Unlock()
+
+	if s.GetState() == StateDraining && time.Since(s.drainStartedAt) > s.drainDuration {
+		s.dissolveGangs(ctx)
+	}
+	if s.GetState() == StateActive {
+		// Check if cooldown has elapsed
+		if time.Since(s.lastSpikeTime) > s.cooldown() {
+			// Check if spike is still ongoing
+			class, _, err := s.detectSpike(ctx)
+			if err != nil {
+				klog.V(2).Infof("Cooldown check inconclusive: %v", err)
+			} else if class == detector.SpikeClassNone || !s.allowExtension() {
+				if s.drainDuration > 0 {
+					s.startDrain()
+				} else {
+					s.dissolveGangs(ctx)
+				}
+			} else {
+				// Spike still ongoing — extend the window
+				s.setSpikeClass(class)
+				s.updateActivationLevel()
+				s.lastSpikeTime = time.Now()
+				s.persistActivation(ctx)
+				klog.V(2).Info("Spike still ongoing, extending active window")
+				s.protectGangMembers(ctx)
+				s.labelGangMembers(ctx)
+				s.hintGangAffinity(ctx)
+				s.createPodGroups(ctx)
+			}
+		}
+	}
+}
+
+// startDrain keeps the gangs for DRAIN_DURATION with reduced locality weight,
+// so the next scale cycle does not immediately scatter members
+func (s *NEXUSScheduler) startDrain() {
+	klog.Info("═══════════════════════════════════════════")
+	klog.Infof("  SPIKE ENDED — Draining for %v before returning to IDLE", s.drainDuration)
+	klog.Info("═══════════════════════════════════════════")
+
+	s.gangManager.SetStage(gang.GangStageCooldown)
+	s.drainStartedAt = time.Now()
+	s.metrics.IncrementCounter("drains_started")
+	s.SetState(StateDraining)
+	s.traceWindow(spanDraining, s.drainStartedAt)
+}
+
+// dissolveGangs dissolves all gangs, clears the graph and returns to IDLE
+func (s *NEXUSScheduler) dissolveGangs(ctx context.Context) {
+	klog.Info("═══════════════════════════════════════════")
+	klog.Info("  SPIKE ENDED — Dissolving gangs, returning to IDLE")
+	klog.Info("═══════════════════════════════════════════")
+
+	// Stage 6 & 7: Dissolve gangs and clear graph
+	dissolveStart := time.Now()
+	s.traceWindow("", dissolveStart)
+	episodeAttributes := map[string]string{
+		"nexus.episode_id":  s.EpisodeID(),
+		"nexus.spike_class": string(s.SpikeClass()),
+		"nexus.gangs":       strconv.Itoa(s.gangManager.GetActiveGangCount()),
+	}
+	s.gangManager.SetStage(gang.GangStageCooldown)
+	s.recordEpisodeEnd(s.gangManager.GetActiveGangCount())
+	s.markEpisodeEnded(s.EpisodeID())
+	s.stopMemberCache()
+	s.stopNodeWatch()
+	s.gangManager.DissolveAll()
+	s.metrics.SetCooldown(cooldownDuration.Seconds())
+	s.depGraph.Clear()
+	s.nodeScorer.ResetMemberCounts()
+	s.gangManager.SetStage(gang.GangStageNone)
+	s.clearActivation(ctx)
+	s.releaseGangMembers(ctx)
+	s.unlabelGangMembers(ctx)
+	s.releaseAffinityHints(ctx)
+	s.deletePodGroups(ctx)
+	s.setSpikeClass(detector.SpikeClassNone)
+	s.setSpikeTrigger(nil)
+	s.resetSLOs()
+	s.endSweepEpisode()
+	s.clearFormation()
+	s.resetPlacements()
+
+	// Return to IDLE (dormant)
+	s.SetState(StateIdle)
+	s.setActivationLevel(LevelNone)
+	dissolveEnd := time.Now()
+	s.traceStage(spanDissolution, dissolveStart, dissolveEnd, nil)
+	s.exportTrace(episodeAttributes["nexus.episode_id"], dissolveEnd, episodeAttributes)
+
+	klog.Info("NEXUS is now DORMANT — zero scheduling overhead")
+}
+
+// protectGangMembers annotates active gang members against descheduler eviction
+func (s *NEXUSScheduler) protectGangMembers(ctx context.Context) {
+	if s.protector != nil {
+		s.protector.Protect(ctx, s.gangManager)
+	}
+}
+
+// releaseGangMembers removes the eviction protection added by NEXUS
+func (s *NEXUSScheduler) releaseGangMembers(ctx context.Context) {
+	if s.protector != nil {
+		s.protector.Release(ctx)
+	}
+}
+
+// labelGangMembers labels gang-member pods with their gang and episode
+func (s *NEXUSScheduler) labelGangMembers(ctx context.Context) {
+	if s.labeler != nil {
+		s.labeler.Label(ctx, s.gangManager, s.EpisodeID())
+	}
+}
+
+// unlabelGangMembers removes the gang and episode labels added by NEXUS
+func (s *NEXUSScheduler) unlabelGangMembers(ctx context.Context) {
+	if s.labeler != nil {
+		s.labeler.Release(ctx)
+	}
+}
+
+// hintGangAffinity adds soft pod affinity hints to gang-member Deployments
+func (s *NEXUSScheduler) hintGangAffinity(ctx context.Context) {
+	if s.hinter != nil {
+		s.hinter.Hint(ctx, s.gangManager)
+	}
+}
+
+// releaseAffinityHints removes the affinity hints added by NEXUS
+func (s *NEXUSScheduler) releaseAffinityHints(ctx context.Context) {
+	if s.hinter != nil {
+		s.hinter.Release(ctx)
+	}
+}
+
+// loadAppProfiles installs the APP_PROFILES application profiles, or the
+// Online Boutique profile alone if they are invalid
+func loadAppProfiles(raw string) {
+	profiles, err := graph.ParseProfiles(raw)
+	if err != nil {
+		klog.Warningf("%v; using the Online Boutique profile only", err)
+		profiles, _ = graph.ParseProfiles("")
+	}
+	graph.SetProfiles(profiles)
+	for _, p := range profiles {
+		klog.Infof("Application profile %s: %d services, namespaces %v, selector %v", p.Name, len(p.Services), p.Namespaces, p.Selector)
+	}
+}
+
+// --- HTTP Handlers ---
+
+// SetAuthRefresher reports the credential refresher installed in the
+// Kubernetes clients in /status and the metrics
+func (s *NEXUSScheduler) SetAuthRefresher(auth *kube.AuthRefresher) {
+	s.auth = auth
+}
+
+// recordAPIAuth copies the credential state into the metrics
+func (s *NEXUSScheduler) recordAPIAuth() {
+	if s.auth == nil {
+		return
+	}
+	status := s.auth.Status()
+	s.metrics.SetAPIAuth(metrics.APIAuth{
+		OK:              status.OK,
+		Unauthorized:    status.Unauthorized,
+		Rebuilds:        status.Rebuilds,
+		RebuildFailures: status.RebuildFailures,
+	})
+}
+
+// recordColocationPressure copies the gang members' co-location pressure
+// into the metrics
+func (s *NEXUSScheduler) recordColocationPressure() {
+	s.metrics.SetColocationPressure(s.gangManager.ColocationPressure())
+}
+
+// recordGangDensity copies the warm gangs' member counts by node into the
+// metrics
+func (s *NEXUSScheduler) recordGangDensity() {
+	s.metrics.SetGangDensity(s.gangManager.MemberDensity())
+}
+
+// apiAuthState is "OK" or "FAILING" (persistent 401s)
+func (s *NEXUSScheduler) apiAuthState() string {
+	if s.auth != nil && !s.auth.Status().OK {
+		return "FAILING"
+	}
+	return "OK"
+}
+
+// MetricsHandler returns all NEXUS Prometheus metrics
+func (s *NEXUSScheduler) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	s.recordAPIAuth()
+	s.recordColocationPressure()
+	s.recordGangDensity()
+	s.metrics.WriteExposition(w)
+}
+
+// HealthHandler returns health status, degraded while the self-probe fails
+func (s *NEXUSScheduler) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	health := map[string]interface{}{"status": "healthy"}
+	if probe := s.selfProbeStatus(); probe != nil {
+		health["selfProbe"] = probe
+		if probe.ConsecutiveFailures > 0 {
+			health["status"] = "degraded"
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(health)
+}
+
+// StatusHandler returns detailed NEXUS status
+func (s *NEXUSScheduler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := map[string]interface{}{
+		"state":         s.GetState().String(),
+		"gangStage":     s.gangManager.GetStage().String(),
+		"activeGangs":   s.gangManager.GetActiveGangCount(),
+		"degradedGangs": s.gangManager.DegradedGangs(),
+		"graphBuilt":    s.depGraph.IsBuilt(),
+		"apiBreaker":    s.apiGuard.State().String(),
+		"apiAuth":       s.apiAuthState(),
+		"lastSpikeTime": s.lastSpikeTime.Format(time.RFC3339),
+		"cooldown":      s.cooldown().String(),
+		"episodeId":     s.EpisodeID(),
+		"profile":       s.spikeDetector.ActiveProfile().Name,
+		"spikeClass":    s.SpikeClass(),
+		"level":         s.ActivationLevel(),
+		"formation":     s.FormationStrategy(),
+		"groupOverlaps": s.GroupOverlaps(),
+		"backoff":       s.Backoff().Active,
+		"selfDisabled":  s.SelfDisabled(),
+		"slo":           s.metrics.SLOStatuses(),
+		"influence":     s.influenceFactor(),
+		"protocol":      s.LastProtocol(),
+		"latencyMs": map[string]metrics.LatencySummary{
+			"filter":     s.metrics.ExtenderFilterLatency.Quantiles(),
+			"prioritize": s.metrics.ExtenderPrioritizeLatency.Quantiles(),
+			"activation": s.metrics.ActivationLatency.Quantiles(),
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// ConfigHandler returns the effective configuration with secrets redacted
+func (s *NEXUSScheduler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	config := map[string]interface{}{
+		"env":      s.cfg.Effective(),
+		"detector": s.spikeDetector.Settings(),
+		"timing": map[string]string{
+			"cooldown":           s.cooldown().String(),
+			"spikeCheckInterval": spikeCheckInterval.String(),
+		},
+		"warnings": s.cfg.Validate(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// emitEvent creates a Kubernetes event for observability
+func (s *NEXUSScheduler) emitEvent(namespace, objectName, eventType, reason, message string) {
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("nexus-%s.%x", objectName, time.Now().UnixNano()),
+			Namespace: namespace,
+		},
+		Reason:  reason,
+		Message: message,
+		Source: v1.EventSource{
+			Component: schedulerName,
+		},
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Type:           eventType,
+	}
+
+	_, err := s.clientset.CoreV1().Events(namespace).Create(context.TODO(), event, metav1.CreateOptions{})
+	if err != nil {
+		klog.Warningf("Failed to emit event: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package extender
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/promtest"
+)
+
+// newTestScheduler returns a scheduler backed by a fake clientset holding
+// the given pods; non-IDLE schedulers have the checkout-flow gang formed
+func newTestScheduler(t testing.TB, state SchedulerState, pods ...v1.Pod) *NEXUSScheduler {
+	t.Helper()
+
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		// The object tracker ignores field selectors; emulate spec.nodeName
+		nodeName, byNode := action.(k8stesting.ListAction).GetListRestrictions().Fields.RequiresExactMatch("spec.nodeName")
+		list := &v1.PodList{}
+		for _, pod := range pods {
+			if !byNode || pod.Spec.NodeName == nodeName {
+				list.Items = append(list.Items, pod)
+			}
+		}
+		return true, list, nil
+	})
+
+	cfg := config.LoadConfig()
+	cfg.StateRecovery = false
+	cfg.KEDATrigger = false
+	cfg.UtilizationScoring = false
+
+	s := NewNEXUSScheduler(clientset, nil, cfg)
+	t.Cleanup(s.stopMemberCache)
+	t.Cleanup(s.stopNodeWatch)
+	if state != StateIdle {
+		s.depGraph.Restore([]graph.RuntimeGroup{{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice"}}})
+		s.gangManager.FormGangs(s.depGraph.GetGroups())
+	}
+	s.SetState(state)
+	return s
+}
+
+// fakePrometheus points PROMETHEUS_URL at a fake answering every query
+// with value
+func fakePrometheus(t testing.TB, value float64) *promtest.Server {
+	t.Helper()
+	prom := promtest.NewServer()
+	t.Cleanup(prom.Close)
+	prom.SetDefault(value)
+	t.Setenv("PROMETHEUS_URL", prom.URL)
+	return prom
+}
+
+// extenderSeeds are representative Filter/Prioritize request bodies
+var extenderSeeds = []string{
+	`{"pod":{"metadata":{"name":"checkoutservice-7d9f8c6b5-x2k4p","uid":"u1"}},"nodes":{"items":[{"metadata":{"name":"node-1"}},{"metadata":{"name":"node-2"}}]}}`,
+	`{"pod":{"metadata":{"name":"frontend-1-2"}},"nodes":{"items":[]}}`,
+	`{"pod":null,"nodes":null}`,
+	`{"nodenames":["node-1"]}`,
+	`{}`,
+	`[]`,
+	`{"pod":{"metadata":{"labels":{"nexus.io/gang-id":"x"}}}}`,
+	`not json`,
+}
+
+func FuzzDecodeExtenderArgs(f *testing.F) {
+	for _, seed := range extenderSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		args, err := DecodeExtenderArgs(bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+
+		// Anything we accept must survive a round trip unchanged in shape
+		encoded, err := json.Marshal(args)
+		if err != nil {
+			t.Fatalf("re-encoding decoded args: %v", err)
+		}
+		again, err := DecodeExtenderArgs(bytes.NewReader(encoded))
+		if err != nil {
+			t.Fatalf("decoding re-encoded args %s: %v", encoded, err)
+		}
+		if nodeCount(args) != nodeCount(again) || (args.Pod == nil) != (again.Pod == nil) {
+			t.Fatalf("round trip changed args: %s", encoded)
+		}
+	})
+}
+
+func FuzzHandleFilter(f *testing.F) {
+	for _, seed := range extenderSeeds {
+		f.Add([]byte(seed), false)
+		f.Add([]byte(seed), true)
+	}
+
+	idle := newTestScheduler(f, StateIdle)
+	active := newTestScheduler(f, StateActive)
+
+	f.Fuzz(func(t *testing.T, body []byte, isActive bool) {
+		s := idle
+		if isActive {
+			s = active
+		}
+
+		rec := httptest.NewRecorder()
+		s.HandleFilter(rec, httptest.NewRequest(http.MethodPost, "/filter", bytes.NewReader(body)))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("filter answered %d", rec.Code)
+		}
+		var result ExtenderFilterResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("filter response is not valid JSON: %v", err)
+		}
+
+		args, decodeErr := DecodeExtenderArgs(bytes.NewReader(body))
+		if decodeErr != nil {
+			if result.Error == "" {
+				t.Fatal("undecodable body answered without an error")
+			}
+			return
+		}
+
+		// The extender may only remove candidate nodes, never add them,
+		// and must not express any opinion while IDLE
+		got := nodeCount(&ExtenderArgs{Nodes: result.Nodes, NodeNames: result.NodeNames})
+		if got > nodeCount(args) {
+			t.Fatalf("filter returned %d nodes for %d candidates", got, nodeCount(args))
+		}
+		if !isActive && got != nodeCount(args) {
+			t.Fatalf("IDLE filter returned %d of %d nodes", got, nodeCount(args))
+		}
+	})
+}
+
+func FuzzHandlePrioritize(f *testing.F) {
+	for _, seed := range extenderSeeds {
+		f.Add([]byte(seed), false)
+		f.Add([]byte(seed), true)
+	}
+
+	idle := newTestScheduler(f, StateIdle)
+	active := newTestScheduler(f, StateActive)
+
+	f.Fuzz(func(t *testing.T, body []byte, isActive bool) {
+		s := idle
+		if isActive {
+			s = active
+		}
+
+		rec := httptest.NewRecorder()
+		s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", bytes.NewReader(body)))
+
+		var priorities []HostPriority
+		if err := json.Unmarshal(rec.Body.Bytes(), &priorities); err != nil {
+			t.Fatalf("prioritize response is not valid JSON: %v", err)
+		}
+
+		args, decodeErr := DecodeExtenderArgs(bytes.NewReader(body))
+		if decodeErr != nil {
+			if rec.Header().Get(errorHeader) == "" || len(priorities) != 0 {
+				t.Fatalf("undecodable body answered %v without the error header", priorities)
+			}
+			return
+		}
+
+		// At most one score per candidate node, never negative, all 0 while IDLE
+		if len(priorities) > nodeCount(args) {
+			t.Fatalf("prioritize returned %d scores for %d candidates", len(priorities), nodeCount(args))
+		}
+		for _, p := range priorities {
+			if p.Score < 0 || (!isActive && p.Score != 0) {
+				t.Fatalf("unexpected score %d for %s (active=%v)", p.Score, p.Host, isActive)
+			}
+		}
+	})
+}
+
+// nodeCount returns the number of candidate nodes in a request
+func nodeCount(args *ExtenderArgs) int {
+	nodes := candidateNodes(args)
+	if nodes == nil {
+		return 0
+	}
+	return len(nodes.Items)
+}
+
+func TestSchedulerNamesAllowlist(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.gangFilterStrict = true
+	s.cfg.SchedulerNames = []string{"nexus-profile"}
+
+	// compatPod has no schedulerName, i.e. it runs on default-scheduler
+	if scores := prioritize(t, s); scores["node-1"] != scores["node-2"] {
+		t.Errorf("scores = %v, want no opinion for another scheduler profile", scores)
+	}
+	if result := filter(t, s, "500m", testNode("node-1"), testNode("node-2")); len(result.Nodes.Items) != 2 {
+		t.Errorf("eligible nodes = %v, want every node for another scheduler profile", result.Nodes.Items)
+	}
+
+	s.cfg.SchedulerNames = []string{"nexus-profile", v1.DefaultSchedulerName}
+	if scores := prioritize(t, s); scores["node-2"] <= scores["node-1"] {
+		t.Errorf("scores = %v, want node-2 preferred for an allowed profile", scores)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Spike Window Extensions
+=======================
+While ACTIVE, a cooldown check that still detects a spike extends the
+episode by another cooldown, and a spike detected while DRAINING returns
+it to ACTIVE. On a metric hovering around its threshold this could keep
+the gangs alive indefinitely, so extending takes more than activating:
+
+  - SPIKE_EXTENSION_THRESHOLD_SCALE: a fired signal must reach this
+    multiple of its threshold (1 = the activation threshold). Signals
+    without a threshold (KEDA, injected spikes) always qualify.
+  - SPIKE_MAX_EXTENSIONS: extensions per episode (0 = unlimited)
+
+A refused extension ends the spike as if none was detected: the episode
+drains for DRAIN_DURATION or dissolves, and a DRAINING episode keeps
+draining. nexus_spike_extensions_total counts the extensions and
+nexus_spike_extensions_refused_total{reason} the refusals.
+*/
+
+package extender
+
+import (
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+// Reasons an extension is refused
+const (
+	extensionRefusedLimit     = "limit"
+	extensionRefusedThreshold = "threshold"
+)
+
+// extendableSpike reports whether one of the triggers reaches
+// SPIKE_EXTENSION_THRESHOLD_SCALE times its threshold
+func (s *NEXUSScheduler) extendableSpike(triggers []detector.Trigger) bool {
+	for _, t := range triggers {
+		if t.Threshold <= 0 || t.Value >= t.Threshold*s.cfg.ExtensionThresholdScale {
+			return true
+		}
+	}
+	return false
+}
+
+// allowExtension reports whether the last positive spike check may extend
+// the running episode, counting the extension when it does
+func (s *NEXUSScheduler) allowExtension() bool {
+	s.stateMu.Lock()
+	reason := ""
+	switch {
+	case s.cfg.SpikeMaxExtensions > 0 && s.extensions >= s.cfg.SpikeMaxExtensions:
+		reason = extensionRefusedLimit
+	case !s.lastExtendable:
+		reason = extensionRefusedThreshold
+	default:
+		s.extensions++
+	}
+	extensions := s.extensions
+	s.stateMu.Unlock()
+
+	if reason != "" {
+		klog.Infof("Spike still detected, not extending the episode after %d extensions (%s)", extensions, reason)
+		s.metrics.RecordExtensionRefused(reason)
+		return false
+	}
+	s.metrics.IncrementCounter("spike_extensions")
+	s.updateEpisode(func(ep *EpisodeRecord) { ep.Extensions = extensions })
+	return true
+}
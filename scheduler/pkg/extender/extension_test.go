@@ -0,0 +1,72 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+func TestSpikeExtensionThreshold(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.startEpisode("ep-1", time.Now())
+	s.cfg.ExtensionThresholdScale = 1.5
+
+	// Above the activation threshold but below 1.5× of it
+	borderline := detector.Trigger{Signal: detector.SignalQPS, Value: 120, Threshold: 100}
+	s.noteSpikeTrigger(borderline, []detector.Trigger{borderline})
+	if s.allowExtension() {
+		t.Error("borderline spike extended the episode")
+	}
+
+	// Another fired signal clears the bar
+	strong := detector.Trigger{Signal: detector.SignalErrors, Value: 0.2, Threshold: 0.1}
+	s.noteSpikeTrigger(borderline, []detector.Trigger{borderline, strong})
+	if !s.allowExtension() {
+		t.Error("strong spike did not extend the episode")
+	}
+
+	// Signals without a threshold always extend
+	s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalKEDA, Source: detector.TriggerSourceKEDA, Value: 1}, nil)
+	if !s.allowExtension() {
+		t.Error("KEDA spike did not extend the episode")
+	}
+	if episodes := s.Episodes(); episodes[len(episodes)-1].Extensions != 2 {
+		t.Errorf("episode extensions = %d, want 2", episodes[len(episodes)-1].Extensions)
+	}
+}
+
+func TestSpikeExtensionLimit(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.cfg.SpikeMaxExtensions = 2
+	s.startEpisode("ep-1", time.Now())
+	s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalInjected, Source: detector.TriggerSourceAdmin}, nil)
+
+	for i := 0; i < 2; i++ {
+		if !s.allowExtension() {
+			t.Fatalf("extension %d refused", i+1)
+		}
+	}
+	if s.allowExtension() {
+		t.Error("extension beyond SPIKE_MAX_EXTENSIONS allowed")
+	}
+
+	// A new episode starts counting again
+	s.startEpisode("ep-2", time.Now())
+	if !s.allowExtension() {
+		t.Error("first extension of a new episode refused")
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		"nexus_spike_extensions_total 3",
+		`nexus_spike_extensions_refused_total{reason="limit"} 1`,
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Non-Gang Fallback Policies
+==========================
+While NEXUS is ACTIVE, pods that belong to no gang still compete for the
+capacity the gangs are filling. NON_GANG_FALLBACK decides how Prioritize
+scores them (pkg/scorer/fallback.go), and the nexus.io/fallback
+annotation on a Deployment's pod template overrides it per deployment:
+
+  no-opinion → equal scores (default, the behavior before fallbacks)
+  spread     → keep off the nodes holding gang members or planned slots,
+               protecting the capacity reserved for the gangs
+  resource   → resource and utilization scoring only
+
+Filter keeps every node for these pods whatever the policy: the
+fallbacks only shape scores. Pods created before the episode activated
+keep default placement, as gang members do. Decisions are counted per
+policy (nexus_fallback_decisions_total).
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// annotationFallback selects the fallback policy of a deployment's pods
+const annotationFallback = "nexus.io/fallback"
+
+// nonGangFallback returns the fallback policy of a pod outside every gang
+func (s *NEXUSScheduler) nonGangFallback(pod *v1.Pod) string {
+	if policy, ok := pod.Annotations[annotationFallback]; ok {
+		switch policy {
+		case config.FallbackNoOpinion, config.FallbackSpread, config.FallbackResource:
+			return policy
+		}
+		klog.V(2).Infof("Pod %s: unknown %s %q, using NON_GANG_FALLBACK=%s", pod.Name, annotationFallback, policy, s.cfg.NonGangFallback)
+	}
+	return s.cfg.NonGangFallback
+}
+
+// prioritizeNonMember scores a pod outside every gang by its fallback policy
+func (s *NEXUSScheduler) prioritizeNonMember(w http.ResponseWriter, args *ExtenderArgs, pod *v1.Pod, startTime time.Time) {
+	policy := s.nonGangFallback(pod)
+	if policy != config.FallbackSpread && policy != config.FallbackResource {
+		klog.V(2).Infof("Prioritize: Pod %s not in any gang — returning equal scores", pod.Name)
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+	if !s.isNewReplica(pod) {
+		klog.V(2).Infof("Prioritize: Pod %s not in any gang, created before activation — returning equal scores", pod.Name)
+		s.writePrioritizeNoOpinion(w, args, startTime)
+		return
+	}
+
+	nodes := candidateNodes(args)
+	schedulable, skipped := s.splitSchedulable(context.Background(), pod, args, nodes)
+	breakdown := withUnschedulable(s.nodeScorer.ScoreNonMember(context.Background(), pod, schedulable, policy == config.FallbackSpread), nodes, skipped)
+	s.countDegraded(pod, breakdown)
+	priorities := scaleInfluence(hostPriorities(breakdown), s.influenceFactor())
+	s.metrics.IncrementFallbackDecision(policy)
+	s.logDecision("Prioritize: Pod %s (no gang, fallback: %s) → scores: %+v", pod.Name, policy, priorities)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priorities)
+	s.metrics.ExtenderPrioritizeLatency.TimeSince(startTime)
+}
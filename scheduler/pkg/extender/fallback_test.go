@@ -0,0 +1,113 @@
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// prioritizeNonMember posts a Prioritize request for a frontend replica,
+// which belongs to no gang, over the named nodes
+func prioritizeNonMember(t *testing.T, s *NEXUSScheduler, annotations map[string]string, nodes ...string) map[string]int64 {
+	t.Helper()
+
+	args := ExtenderArgs{
+		Pod:   &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "frontend-5f6d7c8b9-qwert", Namespace: "default", Annotations: annotations}},
+		Nodes: &v1.NodeList{},
+	}
+	for _, name := range nodes {
+		args.Nodes.Items = append(args.Nodes.Items, testNode(name))
+	}
+	body, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(string(body))))
+
+	var priorities []HostPriority
+	if err := json.Unmarshal(rec.Body.Bytes(), &priorities); err != nil {
+		t.Fatalf("decoding prioritize response: %v", err)
+	}
+	scores := make(map[string]int64, len(priorities))
+	for _, p := range priorities {
+		scores[p.Host] = p.Score
+	}
+	return scores
+}
+
+func TestNonGangFallback(t *testing.T) {
+	// The checkout-flow gang runs on node-1
+	s := newTestScheduler(t, StateActive,
+		*runningPod("cartservice-6d5c7b8f9-abcde", nil),
+		*runningPod("checkoutservice-7d9f8c6b5-klmno", nil),
+	)
+
+	if scores := prioritizeNonMember(t, s, nil, "node-1", "node-2"); scores["node-1"] != 0 || scores["node-2"] != 0 {
+		t.Errorf("no-opinion scores = %v, want all 0", scores)
+	}
+
+	s.cfg.NonGangFallback = config.FallbackResource
+	scores := prioritizeNonMember(t, s, nil, "node-1", "node-2")
+	if scores["node-1"] == 0 || scores["node-1"] != scores["node-2"] {
+		t.Errorf("resource scores = %v, want equal resource scores", scores)
+	}
+
+	s.cfg.NonGangFallback = config.FallbackSpread
+	spread := prioritizeNonMember(t, s, nil, "node-1", "node-2")
+	if spread["node-2"] <= spread["node-1"] || spread["node-1"] != scores["node-1"] {
+		t.Errorf("spread scores = %v, want node-2 above node-1 at resource score %d", spread, scores["node-1"])
+	}
+
+	// The deployment's annotation overrides NON_GANG_FALLBACK; unknown
+	// values fall back to it
+	s.cfg.NonGangFallback = config.FallbackNoOpinion
+	if got := prioritizeNonMember(t, s, map[string]string{annotationFallback: config.FallbackSpread}, "node-1", "node-2"); got["node-2"] <= got["node-1"] {
+		t.Errorf("annotated spread scores = %v, want node-2 above node-1", got)
+	}
+	if got := prioritizeNonMember(t, s, map[string]string{annotationFallback: "sideways"}, "node-1", "node-2"); got["node-1"] != 0 || got["node-2"] != 0 {
+		t.Errorf("unknown fallback scores = %v, want NON_GANG_FALLBACK (no opinion)", got)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_fallback_decisions_total{policy="resource"} 1`,
+		`nexus_fallback_decisions_total{policy="spread"} 2`,
+	} {
+		if !strings.Contains(out.Body.String(), line) {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
+
+func TestNonGangSpreadAvoidsPlannedSlots(t *testing.T) {
+	// node-1 is full; the plan reserves node-2 for the gang's new replicas
+	s := newTestScheduler(t, StateActive,
+		requestingPod("cartservice-6d5c7b8f9-abcde", "node-1", "1500m"),
+		requestingPod("checkoutservice-7d9f8c6b5-klmno", "node-1", "500m"),
+	)
+	s.cfg.GangPlacement = config.GangPlacementPlanned
+	s.cfg.NonGangFallback = config.FallbackSpread
+	ctx := context.Background()
+	for _, node := range []v1.Node{testNode("node-1"), testNode("node-2"), testNode("node-3")} {
+		if _, err := s.clientset.CoreV1().Nodes().Create(ctx, &node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.planPlacements(ctx)
+
+	// node-1 holds the members, node-2 the planned slots
+	scores := prioritizeNonMember(t, s, nil, "node-1", "node-2", "node-3")
+	if scores["node-3"] <= scores["node-2"] || scores["node-2"] != scores["node-1"] {
+		t.Errorf("spread scores = %v, want node-3 above node-1 and node-2 (gang capacity)", scores)
+	}
+}
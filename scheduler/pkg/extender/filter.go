@@ -0,0 +1,302 @@
+/*
+Filter Verdicts
+===============
+Every candidate node ends up in exactly one place of the Filter response:
+
+  nodes / nodenames            → node passed
+  failedAndUnresolvableNodes   → node rejected (kube-scheduler ≥ 1.20)
+  failedNodes                  → node rejected (all versions)
+
+NEXUS rejections cannot be fixed by preempting pods on the node (a cordoned
+node stays cordoned, a pod larger than the node never fits, evicting pods
+adds no gang members), so rejected nodes are reported as unresolvable.
+They are listed in failedNodes as well: older schedulers only read that
+field, newer ones skip failedNodes entries that are also unresolvable.
+
+Messages start with a reason code so pod events and logs can be grouped:
+
+  NodeUnschedulable     node is cordoned
+  NodeNotReady          node Ready condition is not True
+  NodePressure          memory, disk or PID pressure the pod does not tolerate
+  UntoleratedTaint      NoSchedule/NoExecute taint the pod does not tolerate
+                        (see kube.Unschedulable)
+  IncompatiblePlatform  node os/arch cannot run the pod (see kube.PlatformMismatch)
+  InsufficientCapacity  pod requests exceed the node's allocatable resources
+  GangColocation        gang members run elsewhere (GANG_FILTER_STRICT=true)
+  FreshGangPlacement    the gang starts on the plan's first node
+                        (FRESH_GANG_PLACEMENT=plan-first-node)
+
+While no node runs members of the pod's gang yet, FRESH_GANG_PLACEMENT
+(or the freshStart of the gang's NexusPolicy) decides what passes:
+
+  all-nodes        every candidate, without the checks above
+  schedulable      the candidates that can host the pod (default)
+  plan-first-node  only the node the placement plan (GANG_PLACEMENT=planned)
+                   gives the most members, when it can host the pod;
+                   otherwise as schedulable
+
+and the choice is counted in nexus_fresh_gang_filters_total{policy}.
+
+With VPA_RECOMMENDATIONS=true the capacity check uses, per container, the
+larger of the current request and the VPA target recommendation.
+
+Nodes excluded from NEXUS influence (NODE_EXCLUDE_LABEL) always pass.
+
+Name-only requests (nodeCacheCapable) carry no node status or labels, so
+only the gang check applies to them.
+*/
+
+package extender
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+)
+
+// FilterReason is the code that prefixes a rejected node's message
+type FilterReason string
+
+// Filter rejection reasons
+const (
+	ReasonNodeUnschedulable    FilterReason = "NodeUnschedulable"
+	ReasonNodeNotReady         FilterReason = "NodeNotReady"
+	ReasonNodePressure         FilterReason = "NodePressure"
+	ReasonUntoleratedTaint     FilterReason = "UntoleratedTaint"
+	ReasonIncompatiblePlatform FilterReason = "IncompatiblePlatform"
+	ReasonInsufficientCapacity FilterReason = "InsufficientCapacity"
+	ReasonGangColocation       FilterReason = "GangColocation"
+	ReasonFreshGangPlacement   FilterReason = "FreshGangPlacement"
+)
+
+// unschedulableReasons maps kube.Unschedulable problems to reason codes
+var unschedulableReasons = map[string]FilterReason{
+	kube.NodeCordoned:         ReasonNodeUnschedulable,
+	kube.NodeNotReady:         ReasonNodeNotReady,
+	kube.NodeUnderPressure:    ReasonNodePressure,
+	kube.NodeUntoleratedTaint: ReasonUntoleratedTaint,
+}
+
+// filterVerdict is the outcome of Filter for one request's candidate nodes
+type filterVerdict struct {
+	eligible []v1.Node
+	rejected map[string]string // node name → "<reason>: <detail>"
+}
+
+// reject records a rejected node (first reason wins)
+func (v *filterVerdict) reject(node string, reason FilterReason, detail string) {
+	if _, ok := v.rejected[node]; !ok {
+		v.rejected[node] = fmt.Sprintf("%s: %s", reason, detail)
+	}
+}
+
+// filterNodes evaluates each candidate node for a gang pod. withMembers
+// holds the nodes already running gang members or with planned slots;
+// fresh is set when no node runs members yet.
+func (s *NEXUSScheduler) filterNodes(ctx context.Context, pod *v1.Pod, nodes []v1.Node, nameOnly bool, g *gang.Gang, withMembers map[string]bool, fresh bool) filterVerdict {
+	v := filterVerdict{rejected: make(map[string]string)}
+
+	policy := ""
+	if fresh {
+		policy = s.freshPlacement(g)
+		if !isSimulation(ctx) {
+			s.metrics.RecordFreshGangFilter(policy)
+		}
+	}
+	if policy == config.FreshPlacementAllNodes {
+		v.eligible = nodes
+		return v
+	}
+
+	var requests v1.ResourceList
+	if !nameOnly {
+		requests = s.fitRequests(ctx, pod)
+	}
+
+	fit := make([]v1.Node, 0, len(nodes))
+	excluded := make(map[string]bool)
+	memberNodeFits := false
+	for i := range nodes {
+		node := &nodes[i]
+		if kube.NodeExcluded(node, s.cfg.NodeExcludeLabel) {
+			excluded[node.Name] = true
+			fit = append(fit, *node)
+			continue
+		}
+		if !nameOnly {
+			if reason, detail, ok := checkNode(pod, node, requests); !ok {
+				v.reject(node.Name, reason, detail)
+				continue
+			}
+		}
+		fit = append(fit, *node)
+		memberNodeFits = memberNodeFits || withMembers[node.Name]
+	}
+
+	// Strict gang co-location only applies while a member node can take the
+	// pod, so the gang check never leaves kube-scheduler without a node.
+	// A spread policy for the current spike class turns it off.
+	strict := s.gangFilterStrict && memberNodeFits && !s.spikePolicy(g).Spread
+	for _, node := range fit {
+		if strict && !withMembers[node.Name] && !excluded[node.Name] {
+			v.reject(node.Name, ReasonGangColocation, "no members of gang "+g.ID+" on this node")
+		}
+	}
+
+	if policy == config.FreshPlacementPlanFirst {
+		if first := s.planFirstNode(g, fit, excluded); first != "" {
+			for _, node := range fit {
+				if node.Name != first && !excluded[node.Name] {
+					v.reject(node.Name, ReasonFreshGangPlacement, "gang "+g.ID+" starts on planned node "+first)
+				}
+			}
+		}
+	}
+
+	// A node listed twice is only kept if none of its entries was rejected
+	v.eligible = make([]v1.Node, 0, len(fit))
+	for _, node := range fit {
+		if _, ok := v.rejected[node.Name]; !ok {
+			v.eligible = append(v.eligible, node)
+		}
+	}
+
+	return v
+}
+
+// freshPlacement returns the Filter policy for a gang no node runs
+// members of yet: its NexusPolicy's freshStart, else FRESH_GANG_PLACEMENT
+func (s *NEXUSScheduler) freshPlacement(g *gang.Gang) string {
+	if g != nil && g.Policy != nil && g.Policy.FreshStart != "" {
+		return g.Policy.FreshStart
+	}
+	return s.cfg.FreshGangPlacement
+}
+
+// planFirstNode returns the fitting node the gang's placement plan gives
+// the most members ("" = no plan, a spread policy, or no such node)
+func (s *NEXUSScheduler) planFirstNode(g *gang.Gang, fit []v1.Node, excluded map[string]bool) string {
+	plan := s.gangManager.Plan(g)
+	if plan == nil || s.spikePolicy(g).Spread {
+		return ""
+	}
+	candidates := make([]string, 0, len(fit))
+	for _, node := range fit {
+		if !excluded[node.Name] {
+			candidates = append(candidates, node.Name)
+		}
+	}
+	return plan.FirstNode(nil, candidates)
+}
+
+// checkNode reports whether a node can host the pod with the given requests at all
+func checkNode(pod *v1.Pod, node *v1.Node, requests v1.ResourceList) (FilterReason, string, bool) {
+	if problem, detail := kube.Unschedulable(pod, node); problem != "" {
+		return unschedulableReasons[problem], detail, false
+	}
+
+	if mismatch := kube.PlatformMismatch(pod, node); mismatch != "" {
+		return ReasonIncompatiblePlatform, mismatch, false
+	}
+
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		request, ok := requests[name]
+		if !ok {
+			continue
+		}
+		allocatable, ok := node.Status.Allocatable[name]
+		if ok && request.Cmp(allocatable) > 0 {
+			return ReasonInsufficientCapacity,
+				fmt.Sprintf("pod requests %s %s, node allocatable is %s", request.String(), name, allocatable.String()), false
+		}
+	}
+
+	return "", "", true
+}
+
+// reasonOf returns the reason code of a rejection message
+func reasonOf(message string) FilterReason {
+	code, _, _ := strings.Cut(message, ":")
+	return FilterReason(code)
+}
+
+// isNodeReady reports whether the node's Ready condition is True
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// fitRequests returns the requests the capacity check uses for the pod,
+// raised to the VPA target recommendation when that is larger
+func (s *NEXUSScheduler) fitRequests(ctx context.Context, pod *v1.Pod) v1.ResourceList {
+	if s.vpa == nil {
+		return podRequests(pod, nil)
+	}
+
+	recs, ok := s.vpa.Recommendations(ctx, pod.Namespace, graph.ExtractServiceName(pod.Name))
+	if !ok {
+		return podRequests(pod, nil)
+	}
+
+	requests := podRequests(pod, recs)
+	current := podRequests(pod, nil)
+	for name, quantity := range requests {
+		if base := current[name]; quantity.Cmp(base) > 0 {
+			klog.V(2).Infof("Filter: Pod %s %s request raised to VPA target %s (current %s)",
+				pod.Name, name, quantity.String(), base.String())
+			if !isSimulation(ctx) {
+				s.metrics.IncrementCounter("vpa_adjusted_checks")
+			}
+			break
+		}
+	}
+	return requests
+}
+
+// podRequests returns the pod's effective CPU/memory requests: the sum over
+// containers, or the largest init container request if that is higher.
+// Container requests below their recommendation in recs are raised to it.
+func podRequests(pod *v1.Pod, recs kube.ContainerRecommendations) v1.ResourceList {
+	requests := v1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		effective := container.Resources.Requests.DeepCopy()
+		if effective == nil {
+			effective = v1.ResourceList{}
+		}
+		for name, recommended := range recs[container.Name] {
+			if current, ok := effective[name]; !ok || recommended.Cmp(current) > 0 {
+				effective[name] = recommended.DeepCopy()
+			}
+		}
+		for name, quantity := range effective {
+			sum := requests[name]
+			sum.Add(quantity)
+			requests[name] = sum
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	for name, quantity := range pod.Spec.Overhead {
+		sum := requests[name]
+		sum.Add(quantity)
+		requests[name] = sum
+	}
+	return requests
+}
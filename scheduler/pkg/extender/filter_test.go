@@ -0,0 +1,340 @@
+package extender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+)
+
+// testNode returns a Ready node with 2 CPUs and 4Gi of allocatable memory
+func testNode(name string, mutate ...func(*v1.Node)) v1.Node {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+	for _, m := range mutate {
+		m(&node)
+	}
+	return node
+}
+
+// filter posts a Filter request for a checkoutservice replica requesting cpu
+func filter(t *testing.T, s *NEXUSScheduler, cpu string, nodes ...v1.Node) ExtenderFilterResult {
+	t.Helper()
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-x2k4p", Namespace: "default"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name:      "server",
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
+		}}},
+	}
+	body, err := json.Marshal(ExtenderArgs{Pod: pod, Nodes: &v1.NodeList{Items: nodes}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.HandleFilter(rec, httptest.NewRequest(http.MethodPost, "/filter", bytes.NewReader(body)))
+	var result ExtenderFilterResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decoding filter response: %v", err)
+	}
+	return result
+}
+
+func TestFilterReasonCodes(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+
+	result := filter(t, s, "500m",
+		testNode("node-1"),
+		testNode("node-2"),
+		testNode("cordoned", func(n *v1.Node) { n.Spec.Unschedulable = true }),
+		testNode("tainted", func(n *v1.Node) {
+			n.Spec.Taints = []v1.Taint{{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}}
+		}),
+		testNode("not-ready", func(n *v1.Node) { n.Status.Conditions[0].Status = v1.ConditionFalse }),
+		testNode("pressure", func(n *v1.Node) {
+			n.Status.Conditions = append(n.Status.Conditions, v1.NodeCondition{Type: v1.NodeDiskPressure, Status: v1.ConditionTrue})
+		}),
+		testNode("gpu", func(n *v1.Node) {
+			n.Spec.Taints = []v1.Taint{{Key: "nvidia.com/gpu", Value: "true", Effect: v1.TaintEffectNoSchedule}}
+		}),
+		testNode("preferred", func(n *v1.Node) {
+			n.Spec.Taints = []v1.Taint{{Key: "spot", Effect: v1.TaintEffectPreferNoSchedule}}
+		}),
+		testNode("small", func(n *v1.Node) { n.Status.Allocatable[v1.ResourceCPU] = resource.MustParse("250m") }),
+	)
+
+	want := map[string]FilterReason{
+		"cordoned":  ReasonNodeUnschedulable,
+		"tainted":   ReasonNodeUnschedulable,
+		"not-ready": ReasonNodeNotReady,
+		"pressure":  ReasonNodePressure,
+		"gpu":       ReasonUntoleratedTaint,
+		"small":     ReasonInsufficientCapacity,
+	}
+	for node, reason := range want {
+		if got := reasonOf(result.FailedAndUnresolvableNodes[node]); got != reason {
+			t.Errorf("failedAndUnresolvableNodes[%s] = %q, want reason %s", node, result.FailedAndUnresolvableNodes[node], reason)
+		}
+		if result.FailedNodes[node] != result.FailedAndUnresolvableNodes[node] {
+			t.Errorf("failedNodes[%s] = %q, want it mirrored for older schedulers", node, result.FailedNodes[node])
+		}
+	}
+	if len(result.FailedNodes) != len(want) {
+		t.Errorf("failedNodes = %v, want %d entries", result.FailedNodes, len(want))
+	}
+
+	// Rejected nodes must never also be returned as eligible
+	for _, node := range result.Nodes.Items {
+		if _, failed := result.FailedNodes[node.Name]; failed {
+			t.Errorf("node %s is both eligible and failed", node.Name)
+		}
+	}
+	if len(result.Nodes.Items) != 3 {
+		t.Errorf("eligible nodes = %d, want node-1, node-2 and preferred", len(result.Nodes.Items))
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), `nexus_filter_rejections_total{reason="NodeUnschedulable"} 2`) {
+		t.Errorf("rejections by reason not exported:\n%s", out.Body.String())
+	}
+}
+
+func TestUnschedulableTolerations(t *testing.T) {
+	node := testNode("node-1", func(n *v1.Node) {
+		n.Spec.Unschedulable = true
+		n.Spec.Taints = []v1.Taint{{Key: "dedicated", Value: "batch", Effect: v1.TaintEffectNoExecute}}
+		n.Status.Conditions = append(n.Status.Conditions, v1.NodeCondition{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue})
+	})
+	pod := &v1.Pod{}
+
+	for _, tc := range []struct {
+		toleration v1.Toleration
+		want       string
+	}{
+		{v1.Toleration{Key: "other", Operator: v1.TolerationOpExists}, kube.NodeCordoned},
+		{v1.Toleration{Key: v1.TaintNodeUnschedulable, Operator: v1.TolerationOpExists}, kube.NodeUnderPressure},
+		{v1.Toleration{Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}, kube.NodeUntoleratedTaint},
+		{v1.Toleration{Operator: v1.TolerationOpExists}, ""},
+	} {
+		pod.Spec.Tolerations = []v1.Toleration{tc.toleration}
+		if got, detail := kube.Unschedulable(pod, &node); got != tc.want {
+			t.Errorf("toleration %+v: got %q (%s), want %q", tc.toleration, got, detail, tc.want)
+		}
+	}
+}
+
+func TestPrioritizeZeroesUnschedulableNodes(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+
+	// compatMember runs on node-2, which would win on locality
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-x2k4p", Namespace: "default"}}
+	body, err := json.Marshal(ExtenderArgs{Pod: pod, Nodes: &v1.NodeList{Items: []v1.Node{
+		testNode("node-1"),
+		testNode("node-2", func(n *v1.Node) { n.Spec.Unschedulable = true }),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", bytes.NewReader(body)))
+
+	var priorities []HostPriority
+	if err := json.Unmarshal(rec.Body.Bytes(), &priorities); err != nil {
+		t.Fatalf("decoding prioritize response: %v", err)
+	}
+	scores := make(map[string]int64)
+	for _, p := range priorities {
+		scores[p.Host] = p.Score
+	}
+	if len(scores) != 2 || scores["node-2"] != 0 || scores["node-1"] <= 0 {
+		t.Errorf("scores = %v, want the cordoned node-2 at 0 and node-1 positive", scores)
+	}
+
+	decisions := s.Decisions("")
+	if len(decisions) != 1 {
+		t.Fatalf("decisions = %d, want 1", len(decisions))
+	}
+	for _, b := range decisions[0].Scores {
+		if b.Unschedulable != (b.Host == "node-2") {
+			t.Errorf("breakdown %s unschedulable = %v", b.Host, b.Unschedulable)
+		}
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_prioritize_unschedulable_nodes_total 1\n") {
+		t.Error("unschedulable nodes scored in Prioritize not counted")
+	}
+}
+
+func TestFilterGangStrict(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.gangFilterStrict = true
+
+	// compatMember runs on node-2, so node-1 violates co-location
+	result := filter(t, s, "500m", testNode("node-1"), testNode("node-2"))
+	if len(result.Nodes.Items) != 1 || result.Nodes.Items[0].Name != "node-2" {
+		t.Fatalf("eligible nodes = %v, want only node-2", result.Nodes.Items)
+	}
+	if got := reasonOf(result.FailedNodes["node-1"]); got != ReasonGangColocation {
+		t.Errorf("failedNodes[node-1] = %q, want reason %s", result.FailedNodes["node-1"], ReasonGangColocation)
+	}
+
+	// With the member node unable to take the pod, co-location is not enforced
+	small := func(n *v1.Node) { n.Status.Allocatable[v1.ResourceCPU] = resource.MustParse("250m") }
+	result = filter(t, s, "500m", testNode("node-1"), testNode("node-2", small))
+	if len(result.Nodes.Items) != 1 || result.Nodes.Items[0].Name != "node-1" {
+		t.Fatalf("eligible nodes = %v, want only node-1", result.Nodes.Items)
+	}
+	if got := reasonOf(result.FailedNodes["node-2"]); got != ReasonInsufficientCapacity {
+		t.Errorf("failedNodes[node-2] = %q, want reason %s", result.FailedNodes["node-2"], ReasonInsufficientCapacity)
+	}
+}
+
+func TestExcludedNodesPassThrough(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.gangFilterStrict = true
+
+	// node-2 runs compatMember but has opted out, so it is neither a member
+	// node nor checked: even a cordoned node-2 passes
+	exclude := func(n *v1.Node) {
+		n.Labels = map[string]string{"nexus.io/exclude": "true"}
+		n.Spec.Unschedulable = true
+	}
+	result := filter(t, s, "500m", testNode("node-1"), testNode("node-2", exclude))
+	if len(result.Nodes.Items) != 2 || len(result.FailedNodes) != 0 {
+		t.Fatalf("eligible nodes = %v, failed = %v, want both nodes eligible", result.Nodes.Items, result.FailedNodes)
+	}
+
+	// An annotation works the same way, and excluded nodes score 0
+	annotated := testNode("node-2", func(n *v1.Node) { n.Annotations = map[string]string{"nexus.io/exclude": "true"} })
+	g := s.gangManager.GetGangForService("checkoutservice")
+	breakdown := s.nodeScorer.Score(context.Background(), &v1.Pod{}, &v1.NodeList{Items: []v1.Node{testNode("node-1"), annotated}}, g, s.localityFor(g, 1))
+	if !breakdown[1].Excluded || breakdown[1].Total != 0 {
+		t.Errorf("node-2 breakdown = %+v, want excluded with score 0", breakdown[1])
+	}
+	if breakdown[0].Excluded || !breakdown[0].Scanned {
+		t.Errorf("node-1 breakdown = %+v, want scanned", breakdown[0])
+	}
+}
+
+func TestFilterIncompatiblePlatform(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	g := s.gangManager.GetGangForService("checkoutservice")
+	platform := func(os, arch string) func(*v1.Node) {
+		return func(n *v1.Node) { n.Labels = map[string]string{v1.LabelOSStable: os, v1.LabelArchStable: arch} }
+	}
+	nodes := []v1.Node{
+		testNode("amd64", platform("linux", "amd64")),
+		testNode("arm64", platform("linux", "arm64")),
+		testNode("windows", platform("windows", "amd64")),
+		testNode("unlabelled"),
+	}
+
+	cases := []struct {
+		name     string
+		pod      v1.Pod
+		eligible []string
+	}{
+		{"unconstrained", v1.Pod{}, []string{"amd64", "arm64", "windows", "unlabelled"}},
+		{"spec.os", v1.Pod{Spec: v1.PodSpec{OS: &v1.PodOS{Name: v1.Linux}}}, []string{"amd64", "arm64", "unlabelled"}},
+		{"nodeSelector", v1.Pod{Spec: v1.PodSpec{NodeSelector: map[string]string{v1.LabelArchStable: "arm64"}}}, []string{"arm64", "unlabelled"}},
+		{"affinity", v1.Pod{Spec: v1.PodSpec{Affinity: &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{{
+				MatchExpressions: []v1.NodeSelectorRequirement{{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpNotIn, Values: []string{"arm64"}}},
+			}}},
+		}}}}, []string{"amd64", "windows", "unlabelled"}},
+		{"image platforms", v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"nexus.io/platforms": "linux/amd64, windows/arm64"}}},
+			[]string{"amd64", "unlabelled"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			verdict := s.filterNodes(context.Background(), &tc.pod, nodes, false, g, nil, true)
+			var eligible []string
+			for _, node := range verdict.eligible {
+				eligible = append(eligible, node.Name)
+			}
+			if strings.Join(eligible, ",") != strings.Join(tc.eligible, ",") {
+				t.Errorf("eligible = %v, want %v", eligible, tc.eligible)
+			}
+			for node, message := range verdict.rejected {
+				if reasonOf(message) != ReasonIncompatiblePlatform {
+					t.Errorf("rejected[%s] = %q, want reason %s", node, message, ReasonIncompatiblePlatform)
+				}
+			}
+		})
+	}
+}
+
+func TestFreshGangPlacement(t *testing.T) {
+	s := newTestScheduler(t, StateActive) // no members anywhere yet
+	cordoned := func(n *v1.Node) { n.Spec.Unschedulable = true }
+	nodes := []v1.Node{testNode("node-1", cordoned), testNode("node-2"), testNode("node-3")}
+
+	// schedulable (default): the cordoned node is rejected
+	result := filter(t, s, "500m", nodes...)
+	if got := reasonOf(result.FailedNodes["node-1"]); got != ReasonNodeUnschedulable || len(result.Nodes.Items) != 2 {
+		t.Errorf("schedulable: eligible = %d, failed = %v; want node-1 rejected", len(result.Nodes.Items), result.FailedNodes)
+	}
+
+	s.cfg.FreshGangPlacement = config.FreshPlacementAllNodes
+	if result = filter(t, s, "500m", nodes...); len(result.Nodes.Items) != 3 || len(result.FailedNodes) != 0 {
+		t.Errorf("all-nodes: eligible = %d, failed = %v; want every node", len(result.Nodes.Items), result.FailedNodes)
+	}
+
+	// plan-first-node without a plan filters as schedulable, with one it
+	// keeps the node the plan gives the most members
+	s.cfg.FreshGangPlacement = config.FreshPlacementPlanFirst
+	if result = filter(t, s, "500m", nodes...); len(result.Nodes.Items) != 2 {
+		t.Errorf("plan-first-node without a plan: eligible = %d, want 2", len(result.Nodes.Items))
+	}
+	g := s.gangManager.GetGangForService("checkoutservice")
+	s.gangManager.SetPlans(map[string]*gang.PlacementPlan{g.ID: {Targets: map[string]int{"node-1": 4, "node-2": 1, "node-3": 2}}})
+	result = filter(t, s, "500m", nodes...)
+	if len(result.Nodes.Items) != 1 || result.Nodes.Items[0].Name != "node-3" {
+		t.Fatalf("plan-first-node: eligible = %v, want only node-3 (node-1 is cordoned)", result.Nodes.Items)
+	}
+	if got := reasonOf(result.FailedNodes["node-2"]); got != ReasonFreshGangPlacement {
+		t.Errorf("failedNodes[node-2] = %q, want reason %s", result.FailedNodes["node-2"], ReasonFreshGangPlacement)
+	}
+
+	// The gang's NexusPolicy overrides FRESH_GANG_PLACEMENT
+	s.gangManager.ApplyPolicies(map[string]gang.Policy{"checkout-flow": {Group: "checkout-flow", FreshStart: config.FreshPlacementAllNodes}})
+	if result = filter(t, s, "500m", nodes...); len(result.Nodes.Items) != 3 {
+		t.Errorf("policy all-nodes: eligible = %d, want 3", len(result.Nodes.Items))
+	}
+
+	rec := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(rec)
+	for _, line := range []string{
+		`nexus_fresh_gang_filters_total{policy="all-nodes"} 2`,
+		`nexus_fresh_gang_filters_total{policy="plan-first-node"} 2`,
+		`nexus_fresh_gang_filters_total{policy="schedulable"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("metrics lack %s", line)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Activation Flapping Back-off
+============================
+Misconfigured thresholds can make NEXUS flip between IDLE and ACTIVE on
+every spike check, forming and dissolving gangs on the scheduling path
+each time. Every activation (including a return to ACTIVE while draining)
+is counted; more than FLAP_MAX_ACTIVATIONS within FLAP_WINDOW puts NEXUS
+into back-off:
+
+  - further activations are suppressed (NEXUS stays on its current state)
+  - a Warning event (reason ActivationFlapping) and nexus_flap_backoff
+    report the back-off
+  - back-off ends once no activation was suppressed for
+    FLAP_STABILIZATION (0 = never), or on manual re-enable:
+
+  GET    /admin/backoff → Back-off state and activations in the window
+  DELETE /admin/backoff → Re-enable activation now
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// flapGuard counts recent activations and holds the back-off state
+type flapGuard struct {
+	mu             sync.Mutex
+	activations    []time.Time // within the flap window, oldest first
+	backoffSince   time.Time   // zero = not backing off
+	lastSuppressed time.Time
+	suppressed     int // activations suppressed by the running back-off
+}
+
+// BackoffStatus reports the activation back-off
+type BackoffStatus struct {
+	Active         bool       `json:"active"`
+	Since          *time.Time `json:"since,omitempty"`
+	LastSuppressed *time.Time `json:"lastSuppressed,omitempty"`
+	Suppressed     int        `json:"suppressed"`
+	Activations    int        `json:"activations"` // within the flap window
+	MaxActivations int        `json:"maxActivations"`
+	Window         string     `json:"window"`
+	Stabilization  string     `json:"stabilization"`
+}
+
+// allowActivation records an activation at now, or refuses it while backing
+// off or when it exceeds FLAP_MAX_ACTIVATIONS within FLAP_WINDOW
+func (s *NEXUSScheduler) allowActivation(now time.Time) bool {
+	s.flap.mu.Lock()
+	defer s.flap.mu.Unlock()
+
+	if !s.flap.backoffSince.IsZero() {
+		s.flap.lastSuppressed = now
+		s.flap.suppressed++
+		s.metrics.IncrementCounter("flap_suppressed")
+		klog.V(2).Info("Activation suppressed: flapping back-off")
+		return false
+	}
+	if s.cfg.FlapMaxActivations <= 0 {
+		return true
+	}
+
+	s.pruneActivations(now)
+	if len(s.flap.activations) < s.cfg.FlapMaxActivations {
+		s.flap.activations = append(s.flap.activations, now)
+		return true
+	}
+
+	s.flap.backoffSince = now
+	s.flap.lastSuppressed = now
+	s.flap.suppressed = 1
+	s.metrics.SetFlapBackoff(true)
+	s.metrics.IncrementCounter("flap_backoffs")
+	s.metrics.IncrementCounter("flap_suppressed")
+	message := fmt.Sprintf("More than %d activations within %s: activation suppressed until stable for %s or re-enabled",
+		s.cfg.FlapMaxActivations, s.cfg.FlapWindow, s.cfg.FlapStabilization)
+	klog.Warningf("ACTIVATION FLAPPING: %s", message)
+	go s.emitEvent(s.cfg.Namespace, schedulerName, v1.EventTypeWarning, "ActivationFlapping", message)
+	return false
+}
+
+// pruneActivations drops activations older than the flap window (flap.mu held)
+func (s *NEXUSScheduler) pruneActivations(now time.Time) {
+	keep := 0
+	for keep < len(s.flap.activations) && now.Sub(s.flap.activations[keep]) > s.cfg.FlapWindow {
+		keep++
+	}
+	s.flap.activations = s.flap.activations[keep:]
+}
+
+// checkStabilization ends the back-off once no activation was suppressed
+// for FLAP_STABILIZATION
+func (s *NEXUSScheduler) checkStabilization(now time.Time) {
+	s.flap.mu.Lock()
+	stable := !s.flap.backoffSince.IsZero() && s.cfg.FlapStabilization > 0 &&
+		now.Sub(s.flap.lastSuppressed) >= s.cfg.FlapStabilization
+	s.flap.mu.Unlock()
+
+	if stable {
+		klog.Infof("Activation stable for %s — leaving flapping back-off", s.cfg.FlapStabilization)
+		s.ReEnableActivation()
+	}
+}
+
+// ReEnableActivation ends the back-off and forgets the counted activations
+func (s *NEXUSScheduler) ReEnableActivation() {
+	s.flap.mu.Lock()
+	defer s.flap.mu.Unlock()
+	s.flap.activations = nil
+	s.flap.backoffSince = time.Time{}
+	s.flap.lastSuppressed = time.Time{}
+	s.flap.suppressed = 0
+	s.metrics.SetFlapBackoff(false)
+}
+
+// Backoff returns the activation back-off state
+func (s *NEXUSScheduler) Backoff() BackoffStatus {
+	s.flap.mu.Lock()
+	defer s.flap.mu.Unlock()
+	s.pruneActivations(time.Now())
+
+	status := BackoffStatus{
+		Active:         !s.flap.backoffSince.IsZero(),
+		Suppressed:     s.flap.suppressed,
+		Activations:    len(s.flap.activations),
+		MaxActivations: s.cfg.FlapMaxActivations,
+		Window:         s.cfg.FlapWindow.String(),
+		Stabilization:  s.cfg.FlapStabilization.String(),
+	}
+	if status.Active {
+		since, last := s.flap.backoffSince, s.flap.lastSuppressed
+		status.Since, status.LastSuppressed = &since, &last
+	}
+	return status
+}
+
+// handleAdminBackoff reports (GET) or ends (DELETE) the activation back-off
+func (s *NEXUSScheduler) handleAdminBackoff(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		if s.Backoff().Active {
+			klog.Info("Admin: activation re-enabled, leaving flapping back-off")
+		}
+		s.ReEnableActivation()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Backoff())
+}
@@ -0,0 +1,79 @@
+package extender
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlappingBackoff(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	s.cfg.FlapMaxActivations = 2
+	s.cfg.FlapWindow = time.Minute
+	s.cfg.FlapStabilization = 5 * time.Minute
+	now := time.Now()
+
+	if !s.allowActivation(now) || !s.allowActivation(now.Add(10*time.Second)) {
+		t.Fatal("activations within the limit were refused")
+	}
+	if s.allowActivation(now.Add(20 * time.Second)) {
+		t.Fatal("third activation within the window was allowed")
+	}
+	if s.allowActivation(now.Add(2 * time.Minute)) {
+		t.Error("activation allowed while backing off")
+	}
+	if b := s.Backoff(); !b.Active || b.Suppressed != 2 {
+		t.Errorf("backoff = %+v, want active with 2 suppressed", b)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{"nexus_flap_backoff 1", "nexus_flap_backoffs_total 1", "nexus_flap_suppressed_activations_total 2"} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	// Stabilization counts from the last suppressed activation
+	s.checkStabilization(now.Add(6 * time.Minute))
+	if !s.Backoff().Active {
+		t.Fatal("back-off ended before stabilization")
+	}
+	s.checkStabilization(now.Add(7 * time.Minute))
+	if s.Backoff().Active {
+		t.Fatal("back-off still active after stabilization")
+	}
+	if !s.allowActivation(now.Add(7 * time.Minute)) {
+		t.Error("activation refused after stabilization")
+	}
+}
+
+func TestFlappingWindowAndReEnable(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	s.cfg.FlapMaxActivations = 1
+	s.cfg.FlapWindow = time.Minute
+	s.cfg.FlapStabilization = 0 // manual re-enable only
+	now := time.Now()
+
+	if !s.allowActivation(now) || !s.allowActivation(now.Add(2*time.Minute)) {
+		t.Fatal("activations outside the window were refused")
+	}
+	if s.allowActivation(now.Add(2*time.Minute + time.Second)) {
+		t.Fatal("second activation within the window was allowed")
+	}
+	s.checkStabilization(now.Add(time.Hour))
+	if !s.Backoff().Active {
+		t.Fatal("back-off ended without manual re-enable")
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleAdminBackoff(rec, httptest.NewRequest(http.MethodDelete, "/admin/backoff", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"active":false`) {
+		t.Fatalf("re-enable: %d %s", rec.Code, rec.Body.String())
+	}
+	if !s.allowActivation(now.Add(2 * time.Hour)) {
+		t.Error("activation refused after re-enable")
+	}
+}
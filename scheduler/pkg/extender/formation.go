@@ -0,0 +1,182 @@
+/*
+Gang Formation per Episode
+==========================
+Each new episode forms its gangs with one GangFormationStrategy
+(pkg/gang): GANG_FORMATION_STRATEGY by default, or the strategy pinned
+through the admin API, which applies from the next episode on so a
+running episode is never re-formed mid-way:
+
+  GET    /admin/formation → Current, next and configured strategy
+  PUT    /admin/formation → Pin for next episodes: {"name": "merged"}
+  DELETE /admin/formation → Clear the pin (back to the configured one)
+
+The strategy of the running episode is persisted with the activation
+record, reported in /status and /episodes, and exported as
+nexus_gang_formation_strategy.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+)
+
+// formationState tracks the pinned and the running formation strategy
+type formationState struct {
+	mu       sync.Mutex
+	pinned   string              // admin override for new episodes ("" = configured)
+	current  string              // strategy of the running episode ("" = none)
+	overlaps []gang.GroupOverlap // services shared by groups of the running episode
+}
+
+// adminFormationResponse is returned by the /admin/formation endpoints
+type adminFormationResponse struct {
+	Current    string   `json:"current,omitempty"`
+	Next       string   `json:"next"`
+	Configured string   `json:"configured"`
+	Pinned     string   `json:"pinned,omitempty"`
+	Strategies []string `json:"strategies"`
+}
+
+// formationStrategies lists the strategy names accepted by the admin API
+var formationStrategies = []string{
+	config.GangFormationPerGroup, config.GangFormationMerged, config.GangFormationCriticalPath, config.GangFormationTopK,
+}
+
+// nextFormationStrategy is the strategy a new episode forms its gangs with
+func (s *NEXUSScheduler) nextFormationStrategy() string {
+	s.formation.mu.Lock()
+	defer s.formation.mu.Unlock()
+	if s.formation.pinned != "" {
+		return s.formation.pinned
+	}
+	return s.cfg.GangFormationStrategy
+}
+
+// FormationStrategy returns the strategy of the running episode ("" when IDLE)
+func (s *NEXUSScheduler) FormationStrategy() string {
+	s.formation.mu.Lock()
+	defer s.formation.mu.Unlock()
+	return s.formation.current
+}
+
+// formGangs forms the episode's gangs from the graph groups with the named
+// strategy (unknown names fall back to one gang per group) and warms their
+// member counts
+func (s *NEXUSScheduler) formGangs(ctx context.Context, groups []graph.RuntimeGroup, name string) {
+	strategy, err := gang.NewFormationStrategy(name, s.cfg.GangTopK)
+	if err != nil {
+		klog.Warningf("%v — forming one gang per group", err)
+		strategy, _ = gang.NewFormationStrategy(config.GangFormationPerGroup, 0)
+	}
+
+	formed := strategy.Form(groups, gang.FormationInput{
+		Dependencies: s.depGraph.Dependencies(),
+		Traffic: func() map[string]float64 {
+			qps, err := s.spikeDetector.ServiceQPS()
+			if err != nil {
+				klog.V(2).Infof("Gang formation: per-service QPS unavailable: %v", err)
+			}
+			return qps
+		},
+	})
+	formed, overlaps := gang.ResolveOverlaps(formed, s.cfg.GroupOverlap, s.groupPriority)
+	for _, overlap := range overlaps {
+		klog.Warningf("Service %s is in groups %v: resolved by %s", overlap.Service, overlap.Groups, overlap.Resolution)
+	}
+	if len(overlaps) > 0 {
+		s.metrics.AddGroupOverlaps(overlaps[0].Resolution, len(overlaps))
+	}
+	if len(formed) > 0 {
+		s.gangManager.FormGangs(formed)
+		s.applyPolicies()
+		s.applyHPACooldowns(ctx)
+		s.startMemberCache(ctx)
+		s.planPlacements(ctx)
+		s.startNodeWatch()
+		s.gangManager.SetStage(gang.GangStageScheduling)
+	}
+
+	s.formation.mu.Lock()
+	s.formation.current = strategy.Name()
+	s.formation.overlaps = overlaps
+	s.formation.mu.Unlock()
+	s.metrics.SetFormationStrategy(strategy.Name())
+	klog.Infof("Gangs formed with the %s strategy", strategy.Name())
+}
+
+// GroupOverlaps returns the services shared by coordination groups when
+// the running episode formed its gangs, with their resolution
+func (s *NEXUSScheduler) GroupOverlaps() []gang.GroupOverlap {
+	s.formation.mu.Lock()
+	defer s.formation.mu.Unlock()
+	return s.formation.overlaps
+}
+
+// groupPriority ranks a group by the priority of its NexusPolicy when a
+// shared service must stay in one group (GROUP_OVERLAP=priority)
+func (s *NEXUSScheduler) groupPriority(group string) float64 {
+	s.policies.mu.RLock()
+	defer s.policies.mu.RUnlock()
+	return float64(s.policies.byGroup[group].Priority)
+}
+
+// clearFormation ends the running episode's formation strategy
+func (s *NEXUSScheduler) clearFormation() {
+	s.formation.mu.Lock()
+	s.formation.current = ""
+	s.formation.overlaps = nil
+	s.formation.mu.Unlock()
+	s.metrics.SetFormationStrategy("")
+}
+
+// handleAdminFormation reports (GET), pins (PUT) or unpins (DELETE) the
+// formation strategy of the next episodes
+func (s *NEXUSScheduler) handleAdminFormation(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req adminProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if _, err := gang.NewFormationStrategy(req.Name, s.cfg.GangTopK); err != nil || req.Name == "" {
+			http.Error(w, "unknown gang formation strategy", http.StatusNotFound)
+			return
+		}
+		s.formation.mu.Lock()
+		s.formation.pinned = req.Name
+		s.formation.mu.Unlock()
+		klog.Infof("Admin: gang formation strategy %s pinned for next episodes", req.Name)
+	case http.MethodDelete:
+		s.formation.mu.Lock()
+		s.formation.pinned = ""
+		s.formation.mu.Unlock()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.formation.mu.Lock()
+	resp := adminFormationResponse{
+		Current:    s.formation.current,
+		Configured: s.cfg.GangFormationStrategy,
+		Pinned:     s.formation.pinned,
+		Strategies: formationStrategies,
+	}
+	s.formation.mu.Unlock()
+	resp.Next = s.nextFormationStrategy()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,83 @@
+package extender
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/graph"
+)
+
+func TestFormationStrategyPinnedForNextEpisode(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	groups := []graph.RuntimeGroup{
+		{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice"}},
+		{Name: "product-browsing", Services: []string{"frontend", "productcatalogservice"}},
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleAdminFormation(rec, httptest.NewRequest(http.MethodPut, "/admin/formation", strings.NewReader(`{"name":"merged"}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"next":"merged"`) {
+		t.Fatalf("pin: %d %s", rec.Code, rec.Body.String())
+	}
+
+	s.depGraph.Restore(groups)
+	s.formGangs(context.Background(), groups, s.nextFormationStrategy())
+	s.startEpisode("ep-1", time.Now())
+	if got := s.gangManager.GetActiveGangCount(); got != 1 {
+		t.Errorf("merged strategy formed %d gangs, want 1", got)
+	}
+	if ep := s.Episodes()[0]; ep.Formation != config.GangFormationMerged {
+		t.Errorf("episode formation = %q, want merged", ep.Formation)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if want := `nexus_gang_formation_strategy{strategy="merged"} 1`; !strings.Contains(out.Body.String(), want) {
+		t.Errorf("missing %s", want)
+	}
+
+	// Unpinning applies to the next episode, not the running one
+	s.handleAdminFormation(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/admin/formation", nil))
+	if got := s.FormationStrategy(); got != config.GangFormationMerged {
+		t.Errorf("running episode strategy = %q after unpin, want merged", got)
+	}
+	s.dissolveGangs(context.Background())
+	if got := s.FormationStrategy(); got != "" {
+		t.Errorf("strategy after dissolution = %q, want none", got)
+	}
+
+	s.formGangs(context.Background(), groups, s.nextFormationStrategy())
+	if got := s.gangManager.GetActiveGangCount(); got != 2 {
+		t.Errorf("per-group strategy formed %d gangs, want 2", got)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleAdminFormation(rec, httptest.NewRequest(http.MethodPut, "/admin/formation", strings.NewReader(`{"name":"random"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown strategy: status %d, want 404", rec.Code)
+	}
+}
+
+func TestGangFormationEnforcesServiceDenylist(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	s.gangManager.FormGangs([]graph.RuntimeGroup{ // e.g. restored after a restart
+		{Name: "product-browsing", Services: []string{"frontend", "loadgenerator"}},
+	})
+
+	if s.gangManager.GetGangForService("loadgenerator") != nil {
+		t.Error("loadgenerator joined a gang despite the default denylist")
+	}
+	if s.gangManager.GetGangForService("frontend") == nil {
+		t.Error("frontend has no gang")
+	}
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if want := "nexus_gang_services_filtered_total 1\n"; !strings.Contains(out.Body.String(), want) {
+		t.Errorf("metrics missing %q", want)
+	}
+}
@@ -0,0 +1,341 @@
+/*
+Episode and Decision History
+============================
+In-memory ring buffers of the most recent spike episodes and Prioritize
+decisions, so orchestrators can read what an experiment run produced
+without scraping logs or enabling the CSV export:
+
+  GET /episodes                 → Last 32 episodes, newest first (the
+                                  running one has no endedAt)
+  GET /decisions[?episode=<id>] → Last 256 Prioritize decisions, newest
+                                  first, optionally for one episode
+
+Each decision carries the correlation ID returned to kube-scheduler in
+the X-Nexus-Decision-Id response header ("<instance>-<sequence>", the
+instance part derived from the start time so IDs stay unique across
+restarts).
+
+The rings do not survive a restart. With HISTORY_STORE=true every
+episode (when it starts and ends) and every decision is also written to
+the persistent store (see pkg/store), which keeps them by age and size
+and answers over the same record shapes:
+
+  GET /history/episodes   → Stored episodes, newest first, one per ID
+  GET /history/decisions  → Stored decisions, newest first
+
+both filtered by ?episode=<id>, ?since= and ?until= (RFC 3339) and
+?limit= (default 1000). Without the store they return 503.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/metrics"
+	"nexus-scheduler/pkg/scorer"
+	"nexus-scheduler/pkg/store"
+)
+
+const (
+	episodeHistorySize  = 32
+	decisionHistorySize = 256
+	storedHistoryLimit  = 1000
+)
+
+// EpisodeRecord summarises one spike episode
+type EpisodeRecord struct {
+	ID          string              `json:"id"`
+	ActivatedAt time.Time           `json:"activatedAt"`
+	EndedAt     *time.Time          `json:"endedAt,omitempty"` // nil while running
+	SpikeClass  detector.SpikeClass `json:"spikeClass"`
+	Trigger     *detector.Trigger   `json:"trigger,omitempty"` // signal that caused the activation
+	Level       ActivationLevel     `json:"level,omitempty"`   // highest activation level reached
+	Formation   string              `json:"formation"`         // gang formation strategy
+	Placement   string              `json:"placement"`         // gang member placement (greedy or planned)
+	Gangs       int                 `json:"gangs"`
+	Decisions   int                 `json:"decisions"`
+	Extensions  int                 `json:"extensions,omitempty"` // spike window extensions
+
+	// Creation to binding of the gang-member pods bound during the episode
+	SchedulingLatency *metrics.LatencySummary `json:"schedulingLatency,omitempty"`
+}
+
+// DecisionRecord is one Prioritize decision
+type DecisionRecord struct {
+	ID        string                  `json:"id"` // X-Nexus-Decision-Id of the response
+	Time      time.Time               `json:"time"`
+	Episode   string                  `json:"episode"`
+	Namespace string                  `json:"namespace"`
+	Pod       string                  `json:"pod"`
+	Gang      string                  `json:"gang"`
+	Node      string                  `json:"node"` // highest-scored node ("" if none)
+	Scores    []scorer.ScoreBreakdown `json:"scores"`
+}
+
+// decisionIDs issues the correlation IDs of Prioritize decisions
+type decisionIDs struct {
+	instance string
+	seq      atomic.Uint64
+}
+
+// newDecisionIDs creates the ID source of an instance started at start
+func newDecisionIDs(start time.Time) *decisionIDs {
+	return &decisionIDs{instance: strconv.FormatInt(start.UnixMilli(), 36)}
+}
+
+// next returns the next decision ID
+func (d *decisionIDs) next() string {
+	return d.instance + "-" + strconv.FormatUint(d.seq.Add(1), 10)
+}
+
+// history holds the recent episodes and decisions (oldest first)
+type history struct {
+	mu        sync.Mutex
+	episodes  []EpisodeRecord
+	decisions []DecisionRecord
+}
+
+// recordEpisodeStart appends a running episode to the history
+func (s *NEXUSScheduler) recordEpisodeStart(episodeID string, activatedAt time.Time) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	if n := len(s.history.episodes); n > 0 && s.history.episodes[n-1].EndedAt == nil {
+		s.history.episodes[n-1].EndedAt = &activatedAt // superseded without dissolving
+		s.storeEpisode(s.history.episodes[n-1])
+	}
+	episode := EpisodeRecord{
+		ID:          episodeID,
+		ActivatedAt: activatedAt,
+		Formation:   s.FormationStrategy(), // gangs are formed before the episode starts
+		Placement:   s.cfg.GangPlacement,
+	}
+	s.history.episodes = append(s.history.episodes, episode)
+	if len(s.history.episodes) > episodeHistorySize {
+		s.history.episodes = s.history.episodes[len(s.history.episodes)-episodeHistorySize:]
+	}
+	s.storeEpisode(episode)
+}
+
+// updateEpisode applies fn to the running episode, if any
+func (s *NEXUSScheduler) updateEpisode(fn func(*EpisodeRecord)) {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	if n := len(s.history.episodes); n > 0 && s.history.episodes[n-1].EndedAt == nil {
+		fn(&s.history.episodes[n-1])
+	}
+}
+
+// recordEpisodeEnd closes the running episode with its final gang count
+func (s *NEXUSScheduler) recordEpisodeEnd(gangs int) {
+	ended := time.Now()
+	s.updateEpisode(func(ep *EpisodeRecord) {
+		ep.EndedAt = &ended
+		ep.Gangs = gangs
+		s.storeEpisode(*ep)
+	})
+}
+
+// recordDecision appends a Prioritize decision to the history
+func (s *NEXUSScheduler) recordDecision(decisionID string, pod *v1.Pod, g *gang.Gang, breakdown []scorer.ScoreBreakdown) {
+	record := DecisionRecord{
+		ID:        decisionID,
+		Time:      time.Now(),
+		Episode:   s.EpisodeID(),
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Gang:      g.ID,
+		Scores:    breakdown,
+	}
+	best := int64(0)
+	for _, b := range breakdown {
+		if record.Node == "" || b.Total > best {
+			record.Node, best = b.Host, b.Total
+		}
+	}
+
+	s.updateEpisode(func(ep *EpisodeRecord) { ep.Decisions++ })
+	if s.store != nil {
+		data, _ := json.Marshal(record)
+		s.store.Append(store.Entry{Kind: store.KindDecision, Time: record.Time, Episode: record.Episode, Data: data})
+	}
+
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	s.history.decisions = append(s.history.decisions, record)
+	if len(s.history.decisions) > decisionHistorySize {
+		s.history.decisions = s.history.decisions[len(s.history.decisions)-decisionHistorySize:]
+	}
+}
+
+// storeEpisode queues an episode for the history store (latest entry wins)
+func (s *NEXUSScheduler) storeEpisode(ep EpisodeRecord) {
+	if s.store == nil {
+		return
+	}
+	at := ep.ActivatedAt
+	if ep.EndedAt != nil {
+		at = *ep.EndedAt
+	}
+	data, _ := json.Marshal(ep)
+	s.store.Append(store.Entry{Kind: store.KindEpisode, Time: at, Episode: ep.ID, Data: data})
+}
+
+// Episodes returns the recent episodes, newest first
+func (s *NEXUSScheduler) Episodes() []EpisodeRecord {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	episodes := make([]EpisodeRecord, 0, len(s.history.episodes))
+	for i := len(s.history.episodes) - 1; i >= 0; i-- {
+		ep := s.history.episodes[i]
+		if ep.EndedAt == nil {
+			ep.Gangs = s.gangManager.GetActiveGangCount()
+		}
+		episodes = append(episodes, ep)
+	}
+	return episodes
+}
+
+// Decisions returns the recent decisions, newest first ("" = all episodes)
+func (s *NEXUSScheduler) Decisions(episodeID string) []DecisionRecord {
+	s.history.mu.Lock()
+	defer s.history.mu.Unlock()
+	decisions := make([]DecisionRecord, 0, len(s.history.decisions))
+	for i := len(s.history.decisions) - 1; i >= 0; i-- {
+		if d := s.history.decisions[i]; episodeID == "" || d.Episode == episodeID {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions
+}
+
+// EpisodesHandler returns the recent spike episodes
+func (s *NEXUSScheduler) EpisodesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Episodes())
+}
+
+// DecisionsHandler returns the recent Prioritize decisions
+func (s *NEXUSScheduler) DecisionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Decisions(r.URL.Query().Get("episode")))
+}
+
+// historyQuery parses the filters of the /history endpoints
+func historyQuery(r *http.Request, kind string) (store.Query, error) {
+	values := r.URL.Query()
+	q := store.Query{Kind: kind, Episode: values.Get("episode"), Limit: storedHistoryLimit}
+	for _, bound := range []struct {
+		name string
+		into *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		if v := values.Get(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC 3339 time", bound.name)
+			}
+			*bound.into = t
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return q, fmt.Errorf("limit must be a positive integer")
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+// StoredEpisodes returns the stored episodes matching q, newest first, with
+// the latest entry of each episode and the running one as it is now
+func (s *NEXUSScheduler) StoredEpisodes(q store.Query) ([]EpisodeRecord, error) {
+	limit := q.Limit
+	q.Limit = 0 // each episode has up to two entries
+	entries, err := s.store.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	running := map[string]EpisodeRecord{}
+	for _, ep := range s.Episodes() {
+		if ep.EndedAt == nil {
+			running[ep.ID] = ep
+		}
+	}
+	seen := map[string]bool{}
+	episodes := []EpisodeRecord{}
+	for _, e := range entries {
+		if seen[e.Episode] {
+			continue
+		}
+		var ep EpisodeRecord
+		if err := json.Unmarshal(e.Data, &ep); err != nil {
+			continue
+		}
+		seen[e.Episode] = true
+		if live, ok := running[ep.ID]; ok && ep.EndedAt == nil {
+			ep = live
+		}
+		episodes = append(episodes, ep)
+		if limit > 0 && len(episodes) >= limit {
+			break
+		}
+	}
+	return episodes, nil
+}
+
+// StoredDecisions returns the stored decisions matching q, newest first
+func (s *NEXUSScheduler) StoredDecisions(q store.Query) ([]DecisionRecord, error) {
+	entries, err := s.store.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	decisions := make([]DecisionRecord, 0, len(entries))
+	for _, e := range entries {
+		var d DecisionRecord
+		if err := json.Unmarshal(e.Data, &d); err == nil {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, nil
+}
+
+// StoredEpisodesHandler returns episodes from the history store
+func (s *NEXUSScheduler) StoredEpisodesHandler(w http.ResponseWriter, r *http.Request) {
+	s.serveStored(w, r, store.KindEpisode, func(q store.Query) (interface{}, error) { return s.StoredEpisodes(q) })
+}
+
+// StoredDecisionsHandler returns decisions from the history store
+func (s *NEXUSScheduler) StoredDecisionsHandler(w http.ResponseWriter, r *http.Request) {
+	s.serveStored(w, r, store.KindDecision, func(q store.Query) (interface{}, error) { return s.StoredDecisions(q) })
+}
+
+// serveStored answers a /history query with the records read by fetch
+func (s *NEXUSScheduler) serveStored(w http.ResponseWriter, r *http.Request, kind string, fetch func(store.Query) (interface{}, error)) {
+	if s.store == nil {
+		http.Error(w, "history store disabled (HISTORY_STORE=false)", http.StatusServiceUnavailable)
+		return
+	}
+	q, err := historyQuery(r, kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	records, err := fetch(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
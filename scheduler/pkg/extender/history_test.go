@@ -0,0 +1,163 @@
+package extender
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/store"
+)
+
+func TestEpisodeAndDecisionHistory(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.startEpisode("ep-1", time.Now())
+	s.setSpikeClass(detector.SpikeClassLatency)
+
+	prioritize(t, s)
+	episodes := s.Episodes()
+	if len(episodes) != 1 || episodes[0].EndedAt != nil || episodes[0].Decisions != 1 || episodes[0].Gangs != 1 {
+		t.Fatalf("episodes = %+v, want one running episode with 1 gang and 1 decision", episodes)
+	}
+	if episodes[0].SpikeClass != detector.SpikeClassLatency {
+		t.Errorf("spike class = %q, want latency", episodes[0].SpikeClass)
+	}
+
+	decisions := s.Decisions("ep-1")
+	if len(decisions) != 1 || decisions[0].Node != "node-2" || decisions[0].Gang == "" {
+		t.Errorf("decisions = %+v, want one decision preferring node-2", decisions)
+	}
+	if other := s.Decisions("ep-0"); len(other) != 0 {
+		t.Errorf("decisions of another episode = %+v, want none", other)
+	}
+
+	s.dissolveGangs(context.Background())
+	if ended := s.Episodes()[0]; ended.EndedAt == nil || ended.Gangs != 1 {
+		t.Errorf("after dissolution = %+v, want ended with its gang count kept", ended)
+	}
+
+	for i := 0; i < episodeHistorySize+1; i++ {
+		s.startEpisode(newEpisodeID(time.Now()), time.Now())
+	}
+	if n := len(s.Episodes()); n != episodeHistorySize {
+		t.Errorf("history holds %d episodes, want %d", n, episodeHistorySize)
+	}
+
+	// Episodes starting within one second are told apart
+	second := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	if a, b := newEpisodeID(second), newEpisodeID(second.Add(time.Millisecond)); a == b {
+		t.Errorf("episodes 1ms apart share the ID %s", a)
+	}
+}
+
+func TestEpisodeRecordsTrigger(t *testing.T) {
+	fakePrometheus(t, 0)
+	s := newTestScheduler(t, StateIdle)
+	s.handleAdminSpike(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/admin/spike", strings.NewReader(`{"class":"latency"}`)))
+
+	if class, _, err := s.detectSpike(context.Background()); err != nil || class != detector.SpikeClassLatency {
+		t.Fatalf("detectSpike = %q, %v; want the injected latency spike", class, err)
+	}
+	s.startEpisode("ep-1", time.Now())
+	s.setSpikeClass(detector.SpikeClassLatency)
+	s.attributeActivation()
+
+	want := detector.Trigger{Signal: detector.SignalInjected, Source: detector.TriggerSourceAdmin}
+	if trigger := s.Episodes()[0].Trigger; trigger == nil || *trigger != want {
+		t.Errorf("episode trigger = %+v, want %+v", trigger, want)
+	}
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), `nexus_spike_triggers_total{signal="injected",source="admin"} 1`) {
+		t.Error("activation not counted by trigger")
+	}
+
+	s.dissolveGangs(context.Background())
+	if s.SpikeTrigger() != nil {
+		t.Errorf("trigger after dissolution = %+v, want none", s.SpikeTrigger())
+	}
+	if trigger := s.Episodes()[0].Trigger; trigger == nil || *trigger != want {
+		t.Errorf("ended episode trigger = %+v, want it kept", trigger)
+	}
+}
+
+func TestDecisionIDCorrelatesResponseAndHistory(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.scoreDebug = config.ScoreDebugHeader
+	s.startEpisode("ep-1", time.Now())
+
+	ids := make([]string, 0, 2)
+	for i := 0; i < 2; i++ {
+		body := `{"pod":` + compatPod + `,"nodes":` + compatNodes + `}`
+		rec := httptest.NewRecorder()
+		s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(body)))
+		if rec.Header().Get(scoreBreakdownHeader) == "" {
+			t.Error("score breakdown header missing")
+		}
+		ids = append(ids, rec.Header().Get(decisionIDHeader))
+	}
+	if ids[0] == "" || ids[0] == ids[1] {
+		t.Fatalf("decision IDs = %v, want two distinct IDs", ids)
+	}
+
+	// Newest first
+	decisions := s.Decisions("ep-1")
+	if len(decisions) != 2 || decisions[0].ID != ids[1] || decisions[1].ID != ids[0] {
+		t.Errorf("decisions = %+v, want the IDs %v", decisions, ids)
+	}
+
+	// No opinion, no decision
+	s.SetState(StateIdle)
+	rec := httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(`{"pod":`+compatPod+`,"nodes":`+compatNodes+`}`)))
+	if id := rec.Header().Get(decisionIDHeader); id != "" {
+		t.Errorf("no-opinion response has decision ID %q", id)
+	}
+}
+
+func TestStoredHistory(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux := http.NewServeMux()
+		s.RegisterObservabilityHandlers(mux)
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := get("/history/episodes"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without the store: status %d, want 503", rec.Code)
+	}
+
+	cfg := *s.cfg
+	cfg.HistoryStoreDir, cfg.HistoryStoreBuffer = t.TempDir(), 16
+	historyStore, err := store.Open(&cfg, s.metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.store = historyStore
+	s.startEpisode("ep-1", time.Now())
+	prioritize(t, s)
+	s.dissolveGangs(context.Background())
+	s.startEpisode("ep-2", time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.RunHistoryStore(ctx) // drains the buffer
+
+	episodes, err := s.StoredEpisodes(store.Query{Kind: store.KindEpisode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(episodes) != 2 || episodes[0].ID != "ep-2" || episodes[0].EndedAt != nil || episodes[1].EndedAt == nil || episodes[1].Decisions != 1 {
+		t.Errorf("stored episodes = %+v, want running ep-2 and ended ep-1 with its decision", episodes)
+	}
+	if rec := get("/history/decisions?episode=ep-1&limit=5"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"episode":"ep-1"`) {
+		t.Errorf("stored decisions: %d %s, want the ep-1 decision", rec.Code, rec.Body)
+	}
+	if rec := get("/history/decisions?since=yesterday"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/scorer"
+)
+
+func TestImageLocalityScore(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-new", Namespace: "default"},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+			Containers:     []v1.Container{{Name: "server", Image: "gcr.io/google-samples/microservices-demo/checkoutservice:v0.10.0"}},
+		},
+	}
+	images := func(names ...string) func(*v1.Node) {
+		return func(n *v1.Node) {
+			for _, name := range names {
+				n.Status.Images = append(n.Status.Images, v1.ContainerImage{Names: []string{name}})
+			}
+		}
+	}
+	nodes := &v1.NodeList{Items: []v1.Node{
+		testNode("warm", images("docker.io/library/busybox:latest", "gcr.io/google-samples/microservices-demo/checkoutservice:v0.10.0")),
+		testNode("partial", images("docker.io/library/busybox:latest", "gcr.io/google-samples/microservices-demo/checkoutservice:v0.9.0")),
+		testNode("cold"),
+	}}
+
+	g := s.gangManager.GetGangForPod(pod)
+	cfg := *s.cfg
+	cfg.ImageLocalityWeight = 60
+	breakdown := scorer.NewNodeScorer(s.gangManager, s.podLister, nil, &cfg).Score(context.Background(), pod, nodes, g, scorer.Locality{Scale: 1})
+	want := map[string]int64{"warm": 60, "partial": 30, "cold": 0}
+	for _, b := range breakdown {
+		if b.Image != want[b.Host] {
+			t.Errorf("%s: image score = %d, want %d", b.Host, b.Image, want[b.Host])
+		}
+	}
+	if breakdown[0].Total != breakdown[2].Total+60 {
+		t.Errorf("warm total = %d, want cold total %d + 60", breakdown[0].Total, breakdown[2].Total)
+	}
+
+	// Off by default
+	for _, b := range s.nodeScorer.Score(context.Background(), pod, nodes, g, scorer.Locality{Scale: 1}) {
+		if b.Image != 0 {
+			t.Errorf("%s: image score = %d with IMAGE_LOCALITY_WEIGHT unset", b.Host, b.Image)
+		}
+	}
+}
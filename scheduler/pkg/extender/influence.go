@@ -0,0 +1,142 @@
+/*
+Influenced Pods
+===============
+Every gang member pod Prioritize scores is remembered with the scores
+kube-scheduler received (after the influence factor and tie-breaking).
+When the gang member pod watch (GANG_MEMBER_CACHE) sees the pod bound,
+the node it landed on decides the outcome (pkg/metrics/influence.go):
+influenced when it is the node NEXUS scored strictly highest, overridden
+when NEXUS scored another node higher, indifferent when it tied for the
+highest score. A pod scored again is judged by its last scores.
+
+At most maxPendingPlacements pods wait for their binding, oldest dropped
+first, and pending pods are forgotten when the gangs dissolve.
+*/
+
+package extender
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// maxPendingPlacements bounds the scored pods waiting for their binding
+const maxPendingPlacements = 4096
+
+// pendingPlacement is a scored pod waiting for its binding
+type pendingPlacement struct {
+	group   string
+	service string
+	scores  map[string]int64 // node → score sent to kube-scheduler
+}
+
+// influenceTracker holds the scored pods until they are seen bound
+type influenceTracker struct {
+	mu      sync.Mutex
+	pending map[string]pendingPlacement // placement key → scores
+	order   []string                    // placement keys, oldest first
+}
+
+// placementKey identifies a pod across Prioritize and the pod watch
+func placementKey(pod *v1.Pod) string {
+	if pod.UID != "" {
+		return string(pod.UID)
+	}
+	return pod.Namespace + "/" + pod.Name
+}
+
+// notePlacement counts a scored gang member pod and remembers its scores
+func (s *NEXUSScheduler) notePlacement(pod *v1.Pod, g *gang.Gang, priorities []HostPriority) {
+	placement := pendingPlacement{
+		group:   g.Group,
+		service: graph.ExtractServiceName(pod.Name),
+		scores:  make(map[string]int64, len(priorities)),
+	}
+	for _, p := range priorities {
+		placement.scores[p.Host] = p.Score
+	}
+	s.metrics.IncrementPodScored(placement.group, placement.service)
+
+	t := &s.influence
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = make(map[string]pendingPlacement)
+	}
+	key := placementKey(pod)
+	if _, ok := t.pending[key]; !ok {
+		t.order = append(t.order, key)
+	}
+	t.pending[key] = placement
+	for len(t.order) > maxPendingPlacements {
+		delete(t.pending, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// observeBinding counts the outcome of a scored pod once it is bound
+func (s *NEXUSScheduler) observeBinding(pod *v1.Pod) {
+	if pod.Spec.NodeName == "" {
+		return
+	}
+	t := &s.influence
+	t.mu.Lock()
+	key := placementKey(pod)
+	placement, ok := t.pending[key]
+	if ok {
+		delete(t.pending, key)
+		for i, k := range t.order {
+			if k == key {
+				t.order = append(t.order[:i], t.order[i+1:]...)
+				break
+			}
+		}
+	}
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	outcome := placement.outcome(pod.Spec.NodeName)
+	klog.V(2).Infof("Pod %s bound to %s: %s", pod.Name, pod.Spec.NodeName, outcome)
+	s.metrics.IncrementPodBound(placement.group, placement.service, outcome)
+}
+
+// outcome classifies a binding to node against the pod's scores
+func (p pendingPlacement) outcome(node string) string {
+	bound, scored := p.scores[node]
+	higher, tied := false, false
+	for host, score := range p.scores {
+		if host == node {
+			continue
+		}
+		switch {
+		case !scored || score > bound:
+			higher = true
+		case score == bound:
+			tied = true
+		}
+	}
+	switch {
+	case higher:
+		return metrics.InfluenceOverridden
+	case tied || len(p.scores) < 2:
+		return metrics.InfluenceIndifferent
+	default:
+		return metrics.InfluenceInfluenced
+	}
+}
+
+// resetPlacements forgets the pods waiting for their binding
+func (s *NEXUSScheduler) resetPlacements() {
+	s.influence.mu.Lock()
+	defer s.influence.mu.Unlock()
+	s.influence.pending = nil
+	s.influence.order = nil
+}
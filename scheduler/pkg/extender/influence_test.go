@@ -0,0 +1,81 @@
+package extender
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"nexus-scheduler/pkg/metrics"
+)
+
+// boundPod is the compatPod replica bound to node
+func boundPod(node string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-x2k4p", Namespace: "default", UID: "u1"},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+}
+
+func TestPodsInfluenced(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+
+	// node-2 runs a gang member and scores higher
+	prioritize(t, s)
+	s.observeBinding(boundPod("node-2"))
+	prioritize(t, s)
+	s.observeBinding(boundPod("node-1"))
+	s.observeBinding(boundPod("node-1")) // already counted
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_pods_scored_total{gang="checkout-flow",service="checkoutservice"} 2`,
+		`nexus_pods_bound_total{gang="checkout-flow",service="checkoutservice",outcome="influenced"} 1`,
+		`nexus_pods_bound_total{gang="checkout-flow",service="checkoutservice",outcome="overridden"} 1`,
+		`nexus_pods_bound_total{gang="checkout-flow",service="checkoutservice",outcome="indifferent"} 0`,
+		"nexus_influence_rate 0.500",
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
+
+func TestPlacementOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		scores map[string]int64
+		node   string
+		want   string
+	}{
+		{map[string]int64{"a": 100, "b": 0}, "a", metrics.InfluenceInfluenced},
+		{map[string]int64{"a": 100, "b": 0}, "b", metrics.InfluenceOverridden},
+		{map[string]int64{"a": 50, "b": 50, "c": 0}, "a", metrics.InfluenceIndifferent},
+		{map[string]int64{"a": 0}, "a", metrics.InfluenceIndifferent},
+		{map[string]int64{"a": 10}, "unscored", metrics.InfluenceOverridden},
+	} {
+		if got := (pendingPlacement{scores: tc.scores}).outcome(tc.node); got != tc.want {
+			t.Errorf("outcome(%v, %s) = %s, want %s", tc.scores, tc.node, got, tc.want)
+		}
+	}
+}
+
+func TestPendingPlacementsBounded(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	g := s.gangManager.GetGangForService("checkoutservice")
+	for i := 0; i < maxPendingPlacements+10; i++ {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-x", UID: types.UID(fmt.Sprintf("u%d", i))}}
+		s.notePlacement(pod, g, []HostPriority{{Host: "node-1", Score: 1}})
+	}
+	if n := len(s.influence.pending); n > maxPendingPlacements {
+		t.Errorf("%d pending placements, want at most %d", n, maxPendingPlacements)
+	}
+	s.resetPlacements()
+	if n := len(s.influence.pending); n != 0 {
+		t.Errorf("%d pending placements after reset, want 0", n)
+	}
+}
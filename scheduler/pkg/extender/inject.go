@@ -0,0 +1,107 @@
+/*
+Synthetic Spikes
+================
+Drills and the end-to-end suite need NEXUS to activate without first
+driving real traffic past the detector thresholds:
+
+  POST   /admin/spike → Arm a spike: {"class": "traffic", "services": ["checkoutservice"]}
+  GET    /admin/spike → The armed spike, if any
+  DELETE /admin/spike → Disarm
+
+The next spike check reports the armed spike (class defaults to traffic;
+services, when given, seed the dependency graph like a KEDA trigger) and
+disarms it, so activation still goes through the flapping back-off and
+every later check sees real signals again.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+// InjectedSpike is a synthetic spike armed through the admin API
+type InjectedSpike struct {
+	Class    detector.SpikeClass `json:"class"`
+	Services []string            `json:"services,omitempty"`
+}
+
+// injectedSpikeState holds the armed synthetic spike
+type injectedSpikeState struct {
+	mu    sync.Mutex
+	spike *InjectedSpike // nil = none armed
+}
+
+// InjectSpike arms a synthetic spike for the next spike check
+func (s *NEXUSScheduler) InjectSpike(spike InjectedSpike) error {
+	if spike.Class == detector.SpikeClassNone {
+		spike.Class = detector.SpikeClassTraffic
+	}
+	if !validSpikeClass(spike.Class) {
+		return fmt.Errorf("unknown spike class %q", spike.Class)
+	}
+
+	s.injected.mu.Lock()
+	defer s.injected.mu.Unlock()
+	s.injected.spike = &spike
+	klog.Infof("Synthetic %s spike armed for the next spike check (services %v)", spike.Class, spike.Services)
+	return nil
+}
+
+// takeInjectedSpike returns and disarms the armed synthetic spike
+func (s *NEXUSScheduler) takeInjectedSpike() *InjectedSpike {
+	s.injected.mu.Lock()
+	defer s.injected.mu.Unlock()
+	spike := s.injected.spike
+	s.injected.spike = nil
+	return spike
+}
+
+// validSpikeClass reports whether class is a known spike class
+func validSpikeClass(class detector.SpikeClass) bool {
+	for _, c := range detector.SpikeClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// handleAdminSpike arms (POST/PUT), shows (GET) or disarms (DELETE) a synthetic spike
+func (s *NEXUSScheduler) handleAdminSpike(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		var spike InjectedSpike
+		if err := json.NewDecoder(r.Body).Decode(&spike); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := s.InjectSpike(spike); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status = http.StatusAccepted
+	case http.MethodGet:
+	case http.MethodDelete:
+		s.takeInjectedSpike()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.injected.mu.Lock()
+	defer s.injected.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Armed *InjectedSpike `json:"armed"`
+	}{s.injected.spike})
+}
@@ -0,0 +1,41 @@
+package extender
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+func TestInjectedSpikeActivatesOnce(t *testing.T) {
+	fakePrometheus(t, 0)
+	s := newTestScheduler(t, StateIdle)
+
+	rec := httptest.NewRecorder()
+	s.handleAdminSpike(rec, httptest.NewRequest(http.MethodPost, "/admin/spike", strings.NewReader(`{"class":"bogus"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown class: status = %d, want 400", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.handleAdminSpike(rec, httptest.NewRequest(http.MethodPost, "/admin/spike", strings.NewReader(`{"services":["checkoutservice"]}`)))
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"class":"traffic"`) {
+		t.Fatalf("inject: %d %s, want an armed traffic spike", rec.Code, rec.Body.String())
+	}
+
+	class, services, err := s.detectSpike(context.Background())
+	if err != nil || class != detector.SpikeClassTraffic || len(services) != 1 || services[0] != "checkoutservice" {
+		t.Fatalf("detectSpike = %q, %v, %v; want the injected spike", class, services, err)
+	}
+	if class, _, _ := s.detectSpike(context.Background()); class != detector.SpikeClassNone {
+		t.Errorf("second check = %q, want the injected spike consumed", class)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_spikes_injected_total 1") {
+		t.Error("injected spike not counted")
+	}
+}
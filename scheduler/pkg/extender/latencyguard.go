@@ -0,0 +1,167 @@
+/*
+Latency Budget Self-Disable
+===========================
+NEXUS sits on kube-scheduler's critical path: a slow extender slows every
+placement in the cluster. Every spike check compares the rolling p99 of
+Filter and Prioritize (the /status latencyMs quantiles) against
+LATENCY_BUDGET:
+
+  - a p99 above the budget sets nexus_latency_budget_exceeded
+  - still above it after LATENCY_BUDGET_WINDOW, NEXUS disables itself:
+    Filter keeps every node and Prioritize returns equal scores,
+    nexus_self_disabled goes to 1 and a Warning event (reason
+    LatencyBudgetExceeded) is emitted
+  - a p99 back within the budget before the window ends clears the breach
+
+Answers while disabled are cheap and say nothing about the regression, so
+NEXUS stays disabled until re-enabled manually (or restarted):
+
+  GET    /admin/latency → Budget, current p99s and self-disable state
+  DELETE /admin/latency → Re-enable NEXUS now
+
+Quantiles over fewer than minLatencyBudgetSamples calls are not judged.
+LATENCY_BUDGET=0 turns the check off.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/metrics"
+)
+
+// minLatencyBudgetSamples is the fewest calls a p99 is judged on
+const minLatencyBudgetSamples = 20
+
+// latencyGuard holds the latency budget breach and self-disable state
+type latencyGuard struct {
+	mu            sync.Mutex
+	exceededSince time.Time // zero = within budget
+	disabledSince time.Time // zero = scheduling normally
+}
+
+// LatencyGuardStatus reports the latency budget self-disable
+type LatencyGuardStatus struct {
+	Disabled      bool       `json:"disabled"`
+	Since         *time.Time `json:"since,omitempty"`
+	ExceededSince *time.Time `json:"exceededSince,omitempty"`
+	FilterP99     float64    `json:"filterP99Ms"`
+	PrioritizeP99 float64    `json:"prioritizeP99Ms"`
+	Budget        string     `json:"budget"`
+	Window        string     `json:"window"`
+}
+
+// worstP99 returns the highest judged p99 of Filter and Prioritize in ms
+func worstP99(summaries ...metrics.LatencySummary) float64 {
+	worst := 0.0
+	for _, summary := range summaries {
+		if summary.Samples >= minLatencyBudgetSamples && summary.P99 > worst {
+			worst = summary.P99
+		}
+	}
+	return worst
+}
+
+// checkLatencyBudget tracks a p99 above LATENCY_BUDGET and disables NEXUS
+// once it lasted LATENCY_BUDGET_WINDOW
+func (s *NEXUSScheduler) checkLatencyBudget(now time.Time) {
+	if s.cfg.LatencyBudget <= 0 {
+		return
+	}
+	budgetMs := float64(s.cfg.LatencyBudget) / float64(time.Millisecond)
+	worst := worstP99(s.metrics.ExtenderFilterLatency.Quantiles(), s.metrics.ExtenderPrioritizeLatency.Quantiles())
+
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	if !s.latency.disabledSince.IsZero() {
+		return
+	}
+	if worst <= budgetMs {
+		if !s.latency.exceededSince.IsZero() {
+			klog.Infof("Extender p99 %.1fms back within the %s latency budget", worst, s.cfg.LatencyBudget)
+		}
+		s.latency.exceededSince = time.Time{}
+		s.metrics.SetLatencyBudget(false, false)
+		return
+	}
+	if s.latency.exceededSince.IsZero() {
+		klog.Warningf("Extender p99 %.1fms exceeds the %s latency budget", worst, s.cfg.LatencyBudget)
+		s.latency.exceededSince = now
+		s.metrics.SetLatencyBudget(true, false)
+	}
+	if now.Sub(s.latency.exceededSince) < s.cfg.LatencyBudgetWindow {
+		return
+	}
+
+	s.latency.disabledSince = now
+	s.metrics.SetLatencyBudget(true, true)
+	s.metrics.IncrementCounter("self_disables")
+	message := fmt.Sprintf("Extender p99 %.1fms above the %s latency budget for %s: answering with no opinion until re-enabled",
+		worst, s.cfg.LatencyBudget, s.cfg.LatencyBudgetWindow)
+	klog.Warningf("LATENCY BUDGET EXCEEDED: %s", message)
+	go s.emitEvent(s.cfg.Namespace, schedulerName, v1.EventTypeWarning, "LatencyBudgetExceeded", message)
+}
+
+// SelfDisabled reports whether a latency regression disabled NEXUS
+func (s *NEXUSScheduler) SelfDisabled() bool {
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	return !s.latency.disabledSince.IsZero()
+}
+
+// ResumeScheduling re-enables NEXUS and forgets the budget breach
+func (s *NEXUSScheduler) ResumeScheduling() {
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	s.latency.exceededSince = time.Time{}
+	s.latency.disabledSince = time.Time{}
+	s.metrics.SetLatencyBudget(false, false)
+}
+
+// LatencyGuard returns the latency budget self-disable state
+func (s *NEXUSScheduler) LatencyGuard() LatencyGuardStatus {
+	status := LatencyGuardStatus{
+		FilterP99:     s.metrics.ExtenderFilterLatency.Quantiles().P99,
+		PrioritizeP99: s.metrics.ExtenderPrioritizeLatency.Quantiles().P99,
+		Budget:        s.cfg.LatencyBudget.String(),
+		Window:        s.cfg.LatencyBudgetWindow.String(),
+	}
+
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	if !s.latency.disabledSince.IsZero() {
+		since := s.latency.disabledSince
+		status.Disabled, status.Since = true, &since
+	}
+	if !s.latency.exceededSince.IsZero() {
+		exceeded := s.latency.exceededSince
+		status.ExceededSince = &exceeded
+	}
+	return status
+}
+
+// handleAdminLatency reports (GET) or ends (DELETE) the latency self-disable
+func (s *NEXUSScheduler) handleAdminLatency(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		if s.SelfDisabled() {
+			klog.Info("Admin: NEXUS re-enabled after a latency self-disable")
+		}
+		s.ResumeScheduling()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.LatencyGuard())
+}
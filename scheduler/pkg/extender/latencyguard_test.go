@@ -0,0 +1,87 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestLatencyBudgetSelfDisable(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.cfg.LatencyBudget = 50 * time.Millisecond
+	s.cfg.LatencyBudgetWindow = 5 * time.Minute
+	for i := 0; i < 100; i++ {
+		s.metrics.ExtenderPrioritizeLatency.Observe(80)
+	}
+
+	now := time.Now()
+	s.checkLatencyBudget(now)
+	s.checkLatencyBudget(now.Add(4 * time.Minute))
+	if s.SelfDisabled() {
+		t.Fatal("self-disabled before the window passed")
+	}
+	if status := s.LatencyGuard(); status.ExceededSince == nil || !status.ExceededSince.Equal(now) {
+		t.Errorf("exceeded since = %v, want %v", status.ExceededSince, now)
+	}
+
+	s.checkLatencyBudget(now.Add(5 * time.Minute))
+	if !s.SelfDisabled() {
+		t.Fatal("not self-disabled after the window")
+	}
+	if scores := prioritize(t, s); scores["node-1"] != scores["node-2"] {
+		t.Errorf("self-disabled scores = %v, want equal", scores)
+	}
+	small := testNode("small", func(n *v1.Node) { n.Status.Allocatable[v1.ResourceCPU] = resource.MustParse("250m") })
+	if result := filter(t, s, "500m", testNode("node-1"), small); result.Nodes == nil || len(result.Nodes.Items) != 2 {
+		t.Errorf("self-disabled filter = %+v, want every node kept", result)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		"nexus_latency_budget_exceeded 1",
+		"nexus_self_disabled 1",
+		"nexus_self_disables_total 1",
+		"nexus_self_disabled_calls_total 2",
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+
+	s.ResumeScheduling()
+	if s.SelfDisabled() {
+		t.Fatal("still self-disabled after resume")
+	}
+	if scores := prioritize(t, s); scores["node-2"] <= scores["node-1"] {
+		t.Errorf("resumed scores = %v, want node-2 preferred", scores)
+	}
+}
+
+func TestLatencyBudgetRecovers(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.cfg.LatencyBudget = 50 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		s.metrics.ExtenderFilterLatency.Observe(80)
+	}
+	now := time.Now()
+	s.checkLatencyBudget(now)
+
+	// A p99 back within budget clears the breach
+	for i := 0; i < 1024; i++ {
+		s.metrics.ExtenderFilterLatency.Observe(1)
+	}
+	s.checkLatencyBudget(now.Add(10 * time.Minute))
+	if s.SelfDisabled() || s.LatencyGuard().ExceededSince != nil {
+		t.Errorf("latency guard = %+v, want the breach cleared", s.LatencyGuard())
+	}
+
+	// Too few calls are not judged
+	if worst := worstP99(s.metrics.ActivationLatency.Quantiles()); worst != 0 {
+		t.Errorf("worst p99 without samples = %v, want 0", worst)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Activation Levels
+=================
+With GRADED_ACTIVATION=true an episode is ACTIVE at one of two levels:
+
+  ADVISORY   Prioritize scores gang locality as usual; Filter keeps every
+             node (no opinion), so NEXUS can only reorder kube-scheduler's
+             choice and never makes a pod unschedulable
+  ENFORCING  Filter applies its checks (resource fit, node checks,
+             GANG_FILTER_STRICT) as well
+
+Every episode starts ADVISORY. A detector check escalates it to ENFORCING
+once one of its triggers reaches the signal's multiple in
+ENFORCING_THRESHOLDS (value ÷ threshold; the value itself for signals
+without a threshold: HPA replicas added, KEDA services, injected spikes):
+
+  ENFORCING_THRESHOLDS="errors=2,p95=2,qps=3,hpa=3,injected=0"
+
+Signals not listed never escalate. The level only goes up during an
+episode (a drain keeps it) and is reset at dissolution. It is exported
+as nexus_activation_level{level}, in /status and /episodes, and persisted
+for restart recovery. Without GRADED_ACTIVATION every episode enforces.
+*/
+
+package extender
+
+import (
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+// ActivationLevel is how far NEXUS intervenes during an episode
+type ActivationLevel string
+
+// Activation levels
+const (
+	LevelNone      ActivationLevel = ""          // IDLE
+	LevelAdvisory  ActivationLevel = "ADVISORY"  // Prioritize only
+	LevelEnforcing ActivationLevel = "ENFORCING" // Prioritize and Filter
+)
+
+// ActivationLevel returns the level of the current episode (LevelNone when
+// IDLE)
+func (s *NEXUSScheduler) ActivationLevel() ActivationLevel {
+	if s.GetState() == StateIdle {
+		return LevelNone
+	}
+	if !s.cfg.GradedActivation {
+		return LevelEnforcing
+	}
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	if s.activationLevel == LevelNone {
+		return LevelAdvisory
+	}
+	return s.activationLevel
+}
+
+// severeSpike reports whether one of the triggers reaches its signal's
+// ENFORCING_THRESHOLDS multiple
+func (s *NEXUSScheduler) severeSpike(triggers []detector.Trigger) bool {
+	for _, t := range triggers {
+		multiple, ok := s.cfg.EnforcingThresholds[t.Signal]
+		if !ok {
+			continue
+		}
+		ratio := t.Value
+		if t.Threshold > 0 {
+			ratio = t.Value / t.Threshold
+		}
+		if ratio >= multiple {
+			return true
+		}
+	}
+	return false
+}
+
+// updateActivationLevel escalates the episode to ENFORCING after a severe
+// spike check and exports the level
+func (s *NEXUSScheduler) updateActivationLevel() {
+	s.stateMu.Lock()
+	escalate := s.cfg.GradedActivation && s.lastSevere && s.activationLevel != LevelEnforcing
+	if escalate {
+		s.activationLevel = LevelEnforcing
+	}
+	s.stateMu.Unlock()
+
+	level := s.ActivationLevel()
+	if escalate {
+		klog.Infof("Severe spike (%s) — escalating to %s", s.lastSpikeTrigger().Signal, level)
+		s.metrics.IncrementCounter("activation_escalations")
+	}
+	s.metrics.SetActivationLevel(string(level))
+	if level != LevelNone {
+		s.updateEpisode(func(ep *EpisodeRecord) { ep.Level = level })
+	}
+}
+
+// setActivationLevel restores the level of a recovered episode, or resets
+// it with LevelNone
+func (s *NEXUSScheduler) setActivationLevel(level ActivationLevel) {
+	s.stateMu.Lock()
+	s.activationLevel = level
+	s.lastSevere = false
+	s.stateMu.Unlock()
+
+	level = s.ActivationLevel()
+	s.metrics.SetActivationLevel(string(level))
+	if level != LevelNone {
+		s.updateEpisode(func(ep *EpisodeRecord) { ep.Level = level })
+	}
+}
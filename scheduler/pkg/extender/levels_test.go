@@ -0,0 +1,92 @@
+package extender
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"nexus-scheduler/pkg/detector"
+)
+
+func TestAdvisoryFilterKeepsEveryNode(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.cfg.GradedActivation = true
+	small := testNode("small", func(n *v1.Node) { n.Status.Allocatable[v1.ResourceCPU] = resource.MustParse("250m") })
+
+	if level := s.ActivationLevel(); level != LevelAdvisory {
+		t.Fatalf("level = %q, want %s", level, LevelAdvisory)
+	}
+	result := filter(t, s, "500m", testNode("node-1"), small)
+	if result.Nodes == nil || len(result.Nodes.Items) != 2 || len(result.FailedNodes) != 0 {
+		t.Fatalf("advisory filter = %+v, want every node kept", result)
+	}
+
+	// A p95 at 2.5× its threshold reaches the default multiple of 2
+	s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalP95, Value: 1250, Threshold: 500}, nil)
+	s.updateActivationLevel()
+	if level := s.ActivationLevel(); level != LevelEnforcing {
+		t.Fatalf("level after severe spike = %q, want %s", level, LevelEnforcing)
+	}
+	result = filter(t, s, "500m", testNode("node-1"), small)
+	if reasonOf(result.FailedAndUnresolvableNodes["small"]) != ReasonInsufficientCapacity {
+		t.Errorf("enforcing filter = %+v, want small rejected", result)
+	}
+
+	// A milder check later in the episode does not step back
+	s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalP95, Value: 600, Threshold: 500}, nil)
+	s.updateActivationLevel()
+	if level := s.ActivationLevel(); level != LevelEnforcing {
+		t.Errorf("level after mild spike = %q, want %s kept", level, LevelEnforcing)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_activation_level{level="enforcing"} 1`,
+		"nexus_activation_escalations_total 1",
+		"nexus_advisory_filter_calls_total 1",
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+
+	s.dissolveGangs(context.Background())
+	if level := s.ActivationLevel(); level != LevelNone {
+		t.Errorf("level after dissolution = %q, want none", level)
+	}
+}
+
+func TestSevereSpike(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	s.cfg.EnforcingThresholds = map[string]float64{"errors": 2, "hpa": 3, "injected": 0}
+
+	for _, tc := range []struct {
+		trigger detector.Trigger
+		want    bool
+	}{
+		{detector.Trigger{Signal: detector.SignalErrors, Value: 0.1, Threshold: 0.05}, true},
+		{detector.Trigger{Signal: detector.SignalErrors, Value: 0.06, Threshold: 0.05}, false},
+		{detector.Trigger{Signal: detector.SignalHPA, Value: 3}, true},
+		{detector.Trigger{Signal: detector.SignalHPA, Value: 2}, false},
+		{detector.Trigger{Signal: detector.SignalInjected}, true},
+		{detector.Trigger{Signal: detector.SignalQPS, Value: 1000, Threshold: 10}, false}, // not listed
+	} {
+		if got := s.severeSpike([]detector.Trigger{tc.trigger}); got != tc.want {
+			t.Errorf("severeSpike(%+v) = %v, want %v", tc.trigger, got, tc.want)
+		}
+	}
+}
+
+func TestActivationLevelWithoutGrading(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.noteSpikeTrigger(detector.Trigger{Signal: detector.SignalQPS, Value: 11, Threshold: 10}, nil)
+	s.updateActivationLevel()
+	if level := s.ActivationLevel(); level != LevelEnforcing {
+		t.Errorf("level = %q, want %s without GRADED_ACTIVATION", level, LevelEnforcing)
+	}
+}
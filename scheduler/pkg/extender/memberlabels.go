@@ -0,0 +1,158 @@
+/*
+Gang Member Labels
+==================
+The evaluation segments request telemetry by spike episode, which needs
+the workloads themselves to know whether they are gang members. With
+GANG_MEMBER_LABELS=true, NEXUS labels the gang-member pods of an episode:
+
+  nexus.io/gang:    <coordination group>
+  nexus.io/episode: <episode ID>
+
+Labels reach the application through a downward API volume (labels in a
+volume follow later changes, environment variables do not), and meshes
+and tracing pipelines can copy them onto spans and metrics.
+
+Labels are applied on activation and refreshed whenever the active window
+is extended, so replicas placed during the spike are labelled too, and
+removed when the gangs dissolve. Labels left behind by a restart outside
+a spike are cleared at startup. A group name that is not a valid label
+value is left out; the episode label is still set.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/metrics"
+)
+
+const (
+	// LabelGang holds the coordination group of a gang-member pod
+	LabelGang = "nexus.io/gang"
+
+	// LabelEpisode holds the spike episode a gang-member pod took part in
+	LabelEpisode = "nexus.io/episode"
+)
+
+// MemberLabeler adds and removes the gang and episode labels on gang members
+type MemberLabeler struct {
+	clientset kubernetes.Interface
+	apiGuard  *kube.APIGuard
+	podLister *kube.PodLister
+	metrics   *metrics.NEXUSMetrics
+}
+
+// NewMemberLabeler creates a labeler for gang-member pods
+func NewMemberLabeler(clientset kubernetes.Interface, apiGuard *kube.APIGuard, podLister *kube.PodLister, metrics *metrics.NEXUSMetrics) *MemberLabeler {
+	return &MemberLabeler{
+		clientset: clientset,
+		apiGuard:  apiGuard,
+		podLister: podLister,
+		metrics:   metrics,
+	}
+}
+
+// Label labels the pods of active gang members that do not carry the
+// episode's labels yet and returns the number of pods labelled
+func (ml *MemberLabeler) Label(ctx context.Context, gangs *gang.GangManager, episodeID string) int {
+	if episodeID == "" {
+		return 0
+	}
+	pods, _, err := ml.podLister.List(ctx, "list pods for gang member labels", metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Gang member labels: failed to list pods: %v", err)
+		return 0
+	}
+
+	labelled := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		g := gangs.GetGangForPod(pod)
+		if g == nil {
+			continue
+		}
+
+		group := g.Group
+		if len(validation.IsValidLabelValue(group)) > 0 {
+			group = ""
+		}
+		if pod.Labels[LabelEpisode] == episodeID && pod.Labels[LabelGang] == group {
+			continue
+		}
+
+		// null removes a key in a JSON merge patch
+		labels := map[string]interface{}{LabelEpisode: episodeID, LabelGang: nil}
+		if group != "" {
+			labels[LabelGang] = group
+		}
+
+		if ml.patch(ctx, pod, labels) {
+			labelled++
+			ml.metrics.IncrementCounter("member_pods_labeled")
+		}
+	}
+
+	if labelled > 0 {
+		klog.Infof("Gang member labels: labelled %d pods for %s", labelled, episodeID)
+	}
+	return labelled
+}
+
+// Release removes the labels from every pod NEXUS labelled and returns the
+// number of pods released
+func (ml *MemberLabeler) Release(ctx context.Context) int {
+	pods, _, err := ml.podLister.List(ctx, "list labelled gang member pods", metav1.ListOptions{
+		LabelSelector: LabelEpisode,
+	})
+	if err != nil {
+		klog.Warningf("Gang member labels: failed to list labelled pods: %v", err)
+		return 0
+	}
+
+	released := 0
+	for i := range pods {
+		if ml.patch(ctx, &pods[i], map[string]interface{}{LabelGang: nil, LabelEpisode: nil}) {
+			released++
+		}
+	}
+
+	if released > 0 {
+		klog.Infof("Gang member labels: released %d pods", released)
+	}
+	return released
+}
+
+// patch applies a JSON merge patch of pod labels through the API guard
+func (ml *MemberLabeler) patch(ctx context.Context, pod *v1.Pod, labels map[string]interface{}) bool {
+	body, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		klog.Errorf("Gang member labels: failed to encode patch for %s/%s: %v", pod.Namespace, pod.Name, err)
+		return false
+	}
+
+	err = ml.apiGuard.Do(ctx, "patch pod gang member labels", func(ctx context.Context) error {
+		_, err := ml.clientset.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, body, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Warningf("Gang member labels: failed to patch %s/%s: %v", pod.Namespace, pod.Name, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+func TestGangMemberLabelsLifecycle(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+
+	stale := runningPod("checkoutservice-7d9f8c6b5-x2k4p", nil)
+	stale.Labels = map[string]string{LabelEpisode: "episode-1", LabelGang: "checkout-flow", "app": "checkoutservice"}
+	clientset := fake.NewSimpleClientset(
+		runningPod("cartservice-6d5c7b8f9-abcde", nil),
+		stale,
+		runningPod("frontend-5f6d7c8b9-qwert", nil),
+	)
+	lister := kube.NewPodLister(clientset, s.apiGuard, s.metrics, s.cfg)
+	labeler := NewMemberLabeler(clientset, s.apiGuard, lister, s.metrics)
+
+	ctx := context.Background()
+	get := func(name string) *v1.Pod {
+		pod, err := clientset.CoreV1().Pods("default").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod
+	}
+
+	if got := labeler.Label(ctx, s.gangManager, "episode-2"); got != 2 {
+		t.Fatalf("Label labelled %d pods, want the 2 checkout-flow members", got)
+	}
+	if again := labeler.Label(ctx, s.gangManager, "episode-2"); again != 0 {
+		t.Errorf("second Label labelled %d pods, want 0 (already labelled)", again)
+	}
+
+	for _, name := range []string{"cartservice-6d5c7b8f9-abcde", "checkoutservice-7d9f8c6b5-x2k4p"} {
+		if labels := get(name).Labels; labels[LabelGang] != "checkout-flow" || labels[LabelEpisode] != "episode-2" {
+			t.Errorf("%s labels = %v, want checkout-flow in episode-2", name, labels)
+		}
+	}
+	if frontend := get("frontend-5f6d7c8b9-qwert"); len(frontend.Labels) != 0 {
+		t.Errorf("non-member frontend was labelled: %v", frontend.Labels)
+	}
+
+	if got := labeler.Release(ctx); got != 2 {
+		t.Fatalf("Release released %d pods, want 2", got)
+	}
+	checkout := get("checkoutservice-7d9f8c6b5-x2k4p")
+	if _, ok := checkout.Labels[LabelEpisode]; ok || checkout.Labels[LabelGang] != "" || checkout.Labels["app"] != "checkoutservice" {
+		t.Errorf("checkoutservice labels after release = %v, want only its own", checkout.Labels)
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Warm Gang Member Counts
+=======================
+With GANG_MEMBER_CACHE=true (default) the gangs' node → member counts are
+computed once when they form, from one budgeted list of all pods, and then
+kept current by a pod watch started at that list's resource version, so
+Filter and Prioritize never list pods: counting members is a map lookup
+(see pkg/gang/members.go). The same watch reports the bindings of scored
+pods (influence.go).
+
+When the list is incomplete (MAX_PODS_CONSIDERED) or fails, the gangs stay
+cold and are counted live as before. When the watch ends, the gangs go
+cold until a fresh list warms them again. The watch stops when the gangs
+dissolve.
+*/
+
+package extender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+// memberCacheRetry is the pause before re-listing after a failed list
+const memberCacheRetry = 5 * time.Second
+
+// memberCacheState holds the pod watch behind the warm member counts
+type memberCacheState struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc // nil = no watch running
+}
+
+// startMemberCache warms the member counts of the newly formed gangs and
+// keeps them current from pod events until stopMemberCache
+func (s *NEXUSScheduler) startMemberCache(ctx context.Context) {
+	if !s.cfg.GangMemberCache {
+		return
+	}
+	s.stopMemberCache()
+
+	resourceVersion, warm := s.warmMemberCounts(ctx)
+	watchCtx, cancel := context.WithCancel(context.Background())
+	s.memberCache.mu.Lock()
+	s.memberCache.cancel = cancel
+	s.memberCache.mu.Unlock()
+	go s.watchMemberPods(watchCtx, resourceVersion, warm)
+}
+
+// stopMemberCache stops the pod watch (gangs dissolved or re-formed)
+func (s *NEXUSScheduler) stopMemberCache() {
+	s.memberCache.mu.Lock()
+	defer s.memberCache.mu.Unlock()
+	if s.memberCache.cancel != nil {
+		s.memberCache.cancel()
+		s.memberCache.cancel = nil
+	}
+}
+
+// warmMemberCounts lists all pods into the gangs' member counts and returns
+// the list's resource version; false when the gangs stay cold
+func (s *NEXUSScheduler) warmMemberCounts(ctx context.Context) (string, bool) {
+	start := time.Now()
+	pods, resourceVersion, truncated, err := s.podLister.ListVersioned(ctx, "list pods for gang member counts", metav1.ListOptions{})
+	switch {
+	case err != nil:
+		klog.Warningf("Gang member counts not warmed, counting live: %v", err)
+		return "", false
+	case truncated:
+		klog.Warningf("Gang member counts not warmed: more than %d pods (MAX_PODS_CONSIDERED), counting live", len(pods))
+		return "", false
+	case ctx.Err() != nil:
+		return "", false
+	}
+
+	s.gangManager.WarmMemberCounts(pods)
+	s.metrics.IncrementCounter("member_cache_warmups")
+	klog.Infof("Gang member counts warmed from %d pods in %v", len(pods), time.Since(start).Round(time.Millisecond))
+	return resourceVersion, true
+}
+
+// watchMemberPods applies pod events to the warm member counts until ctx
+// is done, re-listing whenever the watch ends
+func (s *NEXUSScheduler) watchMemberPods(ctx context.Context, resourceVersion string, warm bool) {
+	for ctx.Err() == nil {
+		if !warm {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(memberCacheRetry):
+			}
+		} else {
+			err := s.applyPodEvents(ctx, resourceVersion)
+			if ctx.Err() != nil {
+				return
+			}
+			s.gangManager.CoolMemberCounts()
+			s.metrics.IncrementCounter("member_cache_resyncs")
+			klog.Warningf("Gang member pod watch ended (%v), re-listing", err)
+		}
+		resourceVersion, warm = s.warmMemberCounts(ctx)
+	}
+}
+
+// applyPodEvents watches all pods from resourceVersion and applies the
+// events to the member counts until the watch ends
+func (s *NEXUSScheduler) applyPodEvents(ctx context.Context, resourceVersion string) error {
+	var watcher watch.Interface
+	err := s.apiGuard.Do(ctx, "watch pods for gang member counts", func(ctx context.Context) error {
+		var err error
+		watcher, err = s.clientset.CoreV1().Pods("").Watch(ctx, metav1.ListOptions{ResourceVersion: resourceVersion})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			return fmt.Errorf("watch error: %v", event.Object)
+		}
+		pod, ok := event.Object.(*v1.Pod)
+		if !ok {
+			continue
+		}
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			s.gangManager.ObservePod(pod)
+			s.observeBinding(pod)
+		case watch.Deleted:
+			s.gangManager.ForgetPod(pod)
+		}
+	}
+	return nil
+}
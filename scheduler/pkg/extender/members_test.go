@@ -0,0 +1,156 @@
+package extender
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestWarmMemberCountsFollowPodEvents(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	clientset := s.clientset.(*fake.Clientset)
+	ctx := context.Background()
+
+	s.formGangs(ctx, s.depGraph.GetGroups(), config.GangFormationPerGroup)
+	watching := func() bool {
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "watch" && action.GetResource().Resource == "pods" {
+				return true
+			}
+		}
+		return false
+	}
+	waitFor(t, "the pod watch", watching)
+
+	// Warm counts: the request path no longer lists pods
+	clientset.PrependReactor("list", "pods", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		t.Error("pods listed on the request path")
+		return true, nil, errors.New("unexpected list")
+	})
+	if scores := prioritize(t, s); scores["node-2"] <= scores["node-1"] {
+		t.Errorf("warm scores = %v, want node-2 (gang member) preferred", scores)
+	}
+
+	gang := s.gangManager.GetGangForService("cartservice")
+	count := func(node string) int {
+		n, warm := s.gangManager.WarmMemberCount(gang, node)
+		if !warm {
+			t.Fatal("gang member counts went cold")
+		}
+		return n
+	}
+
+	member := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7c9f8d6b5-xyz12", Namespace: "default", UID: "checkout-1"},
+		Spec:       v1.PodSpec{NodeName: "node-1"},
+	}
+	if _, err := clientset.CoreV1().Pods("default").Create(ctx, member, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the new member on node-1", func() bool { return count("node-1") == 1 })
+
+	if err := clientset.CoreV1().Pods("default").Delete(ctx, member.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the deleted member to leave node-1", func() bool { return count("node-1") == 0 })
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if want := "nexus_member_cache_warmups_total 1"; !strings.Contains(out.Body.String(), want) {
+		t.Errorf("missing %s", want)
+	}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestColocationPressure(t *testing.T) {
+	pods := []v1.Pod{
+		requestingPod("cartservice-6d5c7b8f9-abcde", "node-1", "100m"),
+		requestingPod("checkoutservice-7d9f8c6b5-klmno", "node-1", "100m"),
+		requestingPod("checkoutservice-7d9f8c6b5-pqrst", "node-2", "100m"),
+	}
+	pods[0].UID, pods[1].UID, pods[2].UID = "cart-1", "checkout-1", "checkout-2"
+	s := newTestScheduler(t, StateActive, pods...)
+	s.gangManager.WarmMemberCounts(pods)
+
+	scrape := func() string {
+		out := httptest.NewRecorder()
+		s.MetricsHandler(out, httptest.NewRequest("GET", "/metrics", nil))
+		return out.Body.String()
+	}
+	// One of cartservice's two peers runs on a node without a cart replica
+	body := scrape()
+	for _, want := range []string{
+		`nexus_gang_colocation_pressure{service="cartservice"} 0.5`,
+		`nexus_gang_colocation_pressure{service="checkoutservice"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+
+	// Dissolved gangs keep their series at 0
+	s.dissolveGangs(context.Background())
+	if want := `nexus_gang_colocation_pressure{service="cartservice"} 0`; !strings.Contains(scrape(), want) {
+		t.Errorf("metrics missing %s after the gangs dissolved", want)
+	}
+}
+
+func TestGangMembersOnNode(t *testing.T) {
+	pods := []v1.Pod{
+		requestingPod("cartservice-6d5c7b8f9-abcde", "node-1", "100m"),
+		requestingPod("checkoutservice-7d9f8c6b5-klmno", "node-1", "100m"),
+		requestingPod("checkoutservice-7d9f8c6b5-pqrst", "node-2", "100m"),
+	}
+	pods[0].UID, pods[1].UID, pods[2].UID = "cart-1", "checkout-1", "checkout-2"
+	s := newTestScheduler(t, StateActive, pods...)
+	s.gangManager.WarmMemberCounts(pods)
+	gangID := s.gangManager.GetGangForService("cartservice").ID
+
+	scrape := func() string {
+		out := httptest.NewRecorder()
+		s.MetricsHandler(out, httptest.NewRequest("GET", "/metrics", nil))
+		return out.Body.String()
+	}
+	body := scrape()
+	for _, want := range []string{
+		`nexus_gang_members_on_node{node="node-1",gang="` + gangID + `"} 2`,
+		`nexus_gang_members_on_node{node="node-2",gang="` + gangID + `"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+
+	// A member moving off node-2 updates the gauge on the next scrape
+	s.gangManager.ForgetPod(&pods[2])
+	if strings.Contains(scrape(), `nexus_gang_members_on_node{node="node-2"`) {
+		t.Error("node-2 still reported after its member left")
+	}
+
+	s.dissolveGangs(context.Background())
+	if strings.Contains(scrape(), "nexus_gang_members_on_node{") {
+		t.Error("dissolved gang still reported")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Node Failure Re-planning
+========================
+While gangs are active the extender watches the cluster's nodes
+(NODE_FAILURE_WATCH, default on). When a node goes NotReady or is deleted
+mid-spike:
+
+  - its gang members stop counting (warm and live counts, see
+    pkg/gang/nodes.go) and its last known good counts are dropped, so
+    neither the node nor its topology neighbours keep attracting members
+    on stale counts
+  - if a gang preferred the node (warm members on it, or placement plan
+    slots), the placement plans are recomputed from the cluster's
+    current state (GANG_PLACEMENT=planned)
+
+A node that is Ready again counts normally; plans are not recomputed for
+it. The watch starts when the gangs form, re-lists after it ends and
+stops when they dissolve.
+*/
+
+package extender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// nodeWatchState holds the node watch of the running episode
+type nodeWatchState struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc // nil = no watch running
+}
+
+// startNodeWatch watches node availability until stopNodeWatch
+func (s *NEXUSScheduler) startNodeWatch() {
+	if !s.cfg.NodeFailureWatch {
+		return
+	}
+	s.stopNodeWatch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.nodeWatch.mu.Lock()
+	s.nodeWatch.cancel = cancel
+	s.nodeWatch.mu.Unlock()
+	go s.watchNodes(ctx)
+}
+
+// stopNodeWatch stops the node watch (gangs dissolved or re-formed)
+func (s *NEXUSScheduler) stopNodeWatch() {
+	s.nodeWatch.mu.Lock()
+	defer s.nodeWatch.mu.Unlock()
+	if s.nodeWatch.cancel != nil {
+		s.nodeWatch.cancel()
+		s.nodeWatch.cancel = nil
+	}
+}
+
+// watchNodes lists the nodes, then applies node events until ctx is done,
+// re-listing whenever the watch ends
+func (s *NEXUSScheduler) watchNodes(ctx context.Context) {
+	for ctx.Err() == nil {
+		resourceVersion, err := s.syncNodes(ctx)
+		if err == nil {
+			err = s.applyNodeEvents(ctx, resourceVersion)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		klog.Warningf("Node watch ended (%v), re-listing in %v", err, memberCacheRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(memberCacheRetry):
+		}
+	}
+}
+
+// syncNodes applies the availability of every listed node and returns the
+// list's resource version
+func (s *NEXUSScheduler) syncNodes(ctx context.Context) (string, error) {
+	var nodes *v1.NodeList
+	err := s.apiGuard.Do(ctx, "list nodes for availability", func(ctx context.Context) error {
+		var err error
+		nodes, err = s.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	for i := range nodes.Items {
+		s.observeNode(ctx, &nodes.Items[i], false)
+	}
+	return nodes.ResourceVersion, nil
+}
+
+// applyNodeEvents watches the nodes from resourceVersion until the watch ends
+func (s *NEXUSScheduler) applyNodeEvents(ctx context.Context, resourceVersion string) error {
+	var watcher watch.Interface
+	err := s.apiGuard.Do(ctx, "watch nodes for availability", func(ctx context.Context) error {
+		var err error
+		watcher, err = s.clientset.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{ResourceVersion: resourceVersion})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			return fmt.Errorf("watch error: %v", event.Object)
+		}
+		if node, ok := event.Object.(*v1.Node); ok {
+			s.observeNode(ctx, node, event.Type == watch.Deleted)
+		}
+	}
+	return nil
+}
+
+// observeNode applies a node's availability to the gangs, re-planning when
+// a node the gangs prefer becomes unavailable
+func (s *NEXUSScheduler) observeNode(ctx context.Context, node *v1.Node, deleted bool) {
+	available := !deleted && isNodeReady(node)
+	preferred := s.gangManager.NodePreferred(node.Name)
+	if !s.gangManager.SetNodeAvailable(node.Name, available) {
+		return
+	}
+	if available {
+		klog.Infof("Node %s is available again, counting its gang members", node.Name)
+		return
+	}
+
+	klog.Warningf("Node %s unavailable mid-episode (deleted: %v), ignoring its gang members", node.Name, deleted)
+	s.metrics.IncrementCounter("node_failures")
+	s.nodeScorer.ForgetNode(node.Name)
+	if preferred && s.cfg.GangPlacement == config.GangPlacementPlanned {
+		s.planPlacements(ctx)
+		s.metrics.IncrementCounter("placement_replans")
+	}
+}
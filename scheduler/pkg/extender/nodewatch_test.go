@@ -0,0 +1,68 @@
+package extender
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestNodeFailureReplans(t *testing.T) {
+	pods := []v1.Pod{
+		requestingPod("cartservice-6d5c7b8f9-abcde", "node-1", "500m"),
+		requestingPod("checkoutservice-7d9f8c6b5-klmno", "node-1", "500m"),
+	}
+	pods[0].UID, pods[1].UID = "cart-1", "checkout-1"
+	s := newTestScheduler(t, StateActive, pods...)
+	s.cfg.GangPlacement = config.GangPlacementPlanned
+	ctx := context.Background()
+	nodes := []v1.Node{testNode("node-1"), testNode("node-2")}
+	for i := range nodes {
+		if _, err := s.clientset.CoreV1().Nodes().Create(ctx, &nodes[i], metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.gangManager.WarmMemberCounts(pods)
+	s.planPlacements(ctx)
+	g := s.gangManager.GetGangForService("cartservice")
+	if want := map[string]int{"node-1": 2}; !reflect.DeepEqual(s.gangManager.Plan(g).Targets, want) {
+		t.Fatalf("initial targets = %v, want %v", s.gangManager.Plan(g).Targets, want)
+	}
+
+	// node-1 goes NotReady: its members stop counting and the plan moves
+	notReady := testNode("node-1", func(n *v1.Node) { n.Status.Conditions[0].Status = v1.ConditionFalse })
+	if _, err := s.clientset.CoreV1().Nodes().Update(ctx, &notReady, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s.observeNode(ctx, &notReady, false)
+	if n, _ := s.gangManager.WarmMemberCount(g, "node-1"); n != 0 {
+		t.Errorf("members on the NotReady node = %d, want 0", n)
+	}
+	if n := s.nodeScorer.CountGangMembersOnNode(ctx, &notReady, g); n != 0 {
+		t.Errorf("scorer count on the NotReady node = %d, want 0", n)
+	}
+	if want := map[string]int{"node-2": 2}; !reflect.DeepEqual(s.gangManager.Plan(g).Targets, want) {
+		t.Errorf("re-planned targets = %v, want %v", s.gangManager.Plan(g).Targets, want)
+	}
+
+	// A repeated NotReady event changes nothing; Ready again counts again
+	s.observeNode(ctx, &notReady, false)
+	s.observeNode(ctx, &nodes[0], false)
+	if n, _ := s.gangManager.WarmMemberCount(g, "node-1"); n != 2 {
+		t.Errorf("members on the recovered node = %d, want 2", n)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{"nexus_node_failures_total 1", "nexus_placement_replans_total 1"} {
+		if !strings.Contains(out.Body.String(), line) {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/scorer"
+)
+
+func TestNUMAAlignmentScore(t *testing.T) {
+	member := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cartservice-6d5c7b8f9-abcde", Namespace: "default", UID: "cart-1"},
+		Spec:       v1.PodSpec{NodeName: "aligned"},
+	}
+	s := newTestScheduler(t, StateActive, member)
+	s.gangManager.WarmMemberCounts([]v1.Pod{member})
+
+	resources := v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("1Gi")}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-new", Namespace: "default"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name:      "server",
+			Resources: v1.ResourceRequirements{Requests: resources, Limits: resources},
+		}}},
+	}
+	topology := func(policy, cells, cpu string) func(*v1.Node) {
+		return func(n *v1.Node) {
+			n.Labels = map[string]string{scorer.LabelTopologyManagerPolicy: policy, scorer.LabelNUMANodes: cells}
+			n.Status.Allocatable[v1.ResourceCPU] = resource.MustParse(cpu)
+		}
+	}
+	nodes := &v1.NodeList{Items: []v1.Node{
+		testNode("aligned", topology("single-numa-node", "2", "16")),
+		testNode("small-cells", topology("restricted", "4", "8")),
+		testNode("best-effort", topology("best-effort", "4", "8")),
+		testNode("plain"),
+	}}
+
+	g := s.gangManager.GetGangForPod(pod)
+	cfg := *s.cfg
+	cfg.NUMAAlignmentWeight = 100
+	numa := scorer.NewNodeScorer(s.gangManager, s.podLister, nil, &cfg)
+	breakdown := numa.Score(context.Background(), pod, nodes, g, scorer.Locality{Scale: 1})
+
+	// aligned: 4 CPUs for the pod and 4 for the cartservice member of 16
+	want := map[string]int64{"aligned": -50, "small-cells": -100, "best-effort": 0, "plain": 0}
+	for _, b := range breakdown {
+		if b.NUMA != want[b.Host] {
+			t.Errorf("%s: numa score = %d, want %d", b.Host, b.NUMA, want[b.Host])
+		}
+	}
+	if breakdown[0].Locality == 0 {
+		t.Error("aligned node lost its locality")
+	}
+
+	// Burstable pods pin no CPUs
+	burstable := pod.DeepCopy()
+	burstable.Spec.Containers[0].Resources.Limits = nil
+	for _, b := range numa.Score(context.Background(), burstable, nodes, g, scorer.Locality{Scale: 1}) {
+		if b.NUMA != 0 {
+			t.Errorf("%s: numa score = %d for a burstable pod", b.Host, b.NUMA)
+		}
+	}
+
+	// Off by default
+	for _, b := range s.nodeScorer.Score(context.Background(), pod, nodes, g, scorer.Locality{Scale: 1}) {
+		if b.NUMA != 0 {
+			t.Errorf("%s: numa score = %d with NUMA_ALIGNMENT_WEIGHT unset", b.Host, b.NUMA)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+/*
+OpenAPI Document
+================
+GET /openapi (ADMIN_ADDR) serves an OpenAPI 3.0 description of the NEXUS
+HTTP API, so clients — a kubectl plugin, dashboards, experiment scripts —
+can be generated instead of handwritten:
+
+  extender → /filter, /prioritize (EXTENDER_ADDR)
+  status   → /status, /config, /version, /metrics, /healthz, /readyz, /simulate
+  history  → /episodes, /decisions, /history/*, /sweep, /shadow, /policies
+  admin    → /admin/* (bearer ADMIN_TOKEN)
+
+The document is generated from the Go types the endpoints encode: the
+response types of pkg/client for the observability and admin endpoints,
+the extender protocol types for Filter/Prioritize. Kubernetes objects
+(Pod, NodeList) are referenced as opaque objects rather than expanded.
+Adding an endpoint means adding it to apiOperations;
+`nexus-scheduler --export-openapi` writes the same document to stdout.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nexus-scheduler/pkg/client"
+	"nexus-scheduler/pkg/version"
+)
+
+// apiOperation is one method of one documented endpoint
+type apiOperation struct {
+	Method   string
+	Path     string
+	Tag      string
+	Summary  string
+	Query    map[string]string // query parameter → description
+	Request  interface{}       // JSON request body (nil = none)
+	Response interface{}       // JSON response body (nil = text/plain)
+	Status   int               // success status (0 = 200)
+	Admin    bool              // requires the admin bearer token
+}
+
+// adminNameRequest pins a profile or formation strategy by name
+type adminNameRequest struct {
+	Name string `json:"name"`
+}
+
+// healthResponse is the /healthz and /readyz response
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// storedHistoryParams are the filters of the /history endpoints
+var storedHistoryParams = map[string]string{
+	"episode": "only the records of this episode",
+	"since":   "oldest record time (RFC 3339)",
+	"until":   "newest record time (RFC 3339)",
+	"limit":   "maximum number of records (default 1000)",
+}
+
+// apiOperations lists every documented endpoint
+var apiOperations = []apiOperation{
+	{Method: http.MethodPost, Path: "/filter", Tag: "extender", Summary: "Filter candidate nodes for a pod (kube-scheduler extender)",
+		Request: ExtenderArgs{}, Response: ExtenderFilterResult{}},
+	{Method: http.MethodPost, Path: "/prioritize", Tag: "extender", Summary: "Score candidate nodes for a pod (kube-scheduler extender)",
+		Request: ExtenderArgs{}, Response: []HostPriority{}},
+
+	{Method: http.MethodGet, Path: "/status", Tag: "status", Summary: "Scheduler state, gangs and spike detection", Response: client.Status{}},
+	{Method: http.MethodGet, Path: "/config", Tag: "status", Summary: "Effective configuration and validation warnings", Response: client.Config{}},
+	{Method: http.MethodGet, Path: "/version", Tag: "status", Summary: "Build information and enabled subsystems", Response: client.Version{}},
+	{Method: http.MethodGet, Path: "/metrics", Tag: "status", Summary: "Prometheus metrics (text exposition format)"},
+	{Method: http.MethodGet, Path: "/healthz", Tag: "status", Summary: "Liveness probe", Response: healthResponse{}},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "status", Summary: "Readiness probe", Response: healthResponse{}},
+	{Method: http.MethodGet, Path: "/openapi", Tag: "status", Summary: "This document", Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/simulate", Tag: "status", Summary: "Filter and Prioritize results for a hypothetical pod, without side effects",
+		Request: SimulationRequest{}, Response: client.Simulation{}},
+
+	{Method: http.MethodGet, Path: "/episodes", Tag: "history", Summary: "Spike episodes, oldest first", Response: []client.Episode{}},
+	{Method: http.MethodGet, Path: "/decisions", Tag: "history", Summary: "Recent Prioritize decisions, newest first",
+		Query: map[string]string{"episode": "only the decisions of this episode"}, Response: []client.Decision{}},
+	{Method: http.MethodGet, Path: "/history/episodes", Tag: "history", Summary: "Episodes from the persistent history store, newest first",
+		Query: storedHistoryParams, Response: []client.Episode{}},
+	{Method: http.MethodGet, Path: "/history/decisions", Tag: "history", Summary: "Decisions from the persistent history store, newest first",
+		Query: storedHistoryParams, Response: []client.Decision{}},
+	{Method: http.MethodGet, Path: "/sweep", Tag: "history", Summary: "Influence weight sweep results", Response: client.Sweep{}},
+	{Method: http.MethodGet, Path: "/shadow", Tag: "history", Summary: "Shadow scorer divergence", Response: client.Shadow{}},
+	{Method: http.MethodGet, Path: "/policies", Tag: "history", Summary: "Loaded NexusPolicies, by coordination group", Response: map[string]client.Policy{}},
+
+	{Method: http.MethodGet, Path: "/admin/profiles", Tag: "admin", Summary: "List the threshold profiles", Response: client.Profiles{}, Admin: true},
+	{Method: http.MethodPut, Path: "/admin/profiles/active", Tag: "admin", Summary: "Pin a threshold profile",
+		Request: adminNameRequest{}, Response: client.Profiles{}, Admin: true},
+	{Method: http.MethodDelete, Path: "/admin/profiles/active", Tag: "admin", Summary: "Clear the threshold profile pin", Response: client.Profiles{}, Admin: true},
+	{Method: http.MethodGet, Path: "/admin/formation", Tag: "admin", Summary: "Gang formation strategies", Response: client.Formation{}, Admin: true},
+	{Method: http.MethodPut, Path: "/admin/formation", Tag: "admin", Summary: "Pin the formation strategy of the next episodes",
+		Request: adminNameRequest{}, Response: client.Formation{}, Admin: true},
+	{Method: http.MethodDelete, Path: "/admin/formation", Tag: "admin", Summary: "Clear the formation strategy pin", Response: client.Formation{}, Admin: true},
+	{Method: http.MethodGet, Path: "/admin/backoff", Tag: "admin", Summary: "Activation flapping back-off", Response: client.Backoff{}, Admin: true},
+	{Method: http.MethodDelete, Path: "/admin/backoff", Tag: "admin", Summary: "End the activation back-off", Response: client.Backoff{}, Admin: true},
+	{Method: http.MethodGet, Path: "/admin/latency", Tag: "admin", Summary: "Latency budget and self-disable state", Response: client.LatencyGuard{}, Admin: true},
+	{Method: http.MethodDelete, Path: "/admin/latency", Tag: "admin", Summary: "Re-enable NEXUS after a latency self-disable", Response: client.LatencyGuard{}, Admin: true},
+	{Method: http.MethodGet, Path: "/admin/spike", Tag: "admin", Summary: "Show the armed synthetic spike", Response: client.SpikeInjection{}, Admin: true},
+	{Method: http.MethodPost, Path: "/admin/spike", Tag: "admin", Summary: "Arm a synthetic spike for the next spike check",
+		Request: client.InjectedSpike{}, Response: client.SpikeInjection{}, Status: http.StatusAccepted, Admin: true},
+	{Method: http.MethodDelete, Path: "/admin/spike", Tag: "admin", Summary: "Disarm the synthetic spike", Response: client.SpikeInjection{}, Admin: true},
+	{Method: http.MethodPost, Path: "/admin/activate", Tag: "admin", Summary: "Start an episode with an explicit ID (idempotent)",
+		Request: client.EpisodeRequest{}, Response: client.EpisodeResult{}, Status: http.StatusCreated, Admin: true},
+	{Method: http.MethodPost, Path: "/admin/deactivate", Tag: "admin", Summary: "End the episode with the ID (idempotent)",
+		Request: client.EpisodeRequest{}, Response: client.EpisodeResult{}, Admin: true},
+	{Method: http.MethodGet, Path: "/admin/snapshot", Tag: "admin", Summary: "Sanitized cluster and gang snapshot, loadable by nexus-scheduler simulate",
+		Response: map[string]interface{}{}, Admin: true},
+}
+
+// OpenAPIHandler serves the OpenAPI document
+func (s *NEXUSScheduler) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	WriteOpenAPI(w)
+}
+
+// WriteOpenAPI writes the OpenAPI document as indented JSON
+func WriteOpenAPI(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(OpenAPIDocument())
+}
+
+// OpenAPIDocument builds the OpenAPI 3.0 document of apiOperations
+func OpenAPIDocument() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{
+			"tags":        []string{op.Tag},
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+			"responses":   operationResponses(op, schemas),
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaOf(reflect.TypeOf(op.Request), schemas)),
+			}
+		}
+		if len(op.Query) > 0 {
+			names := make([]string, 0, len(op.Query))
+			for name := range op.Query {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			params := make([]interface{}, 0, len(names))
+			for _, name := range names {
+				params = append(params, map[string]interface{}{
+					"name": name, "in": "query", "required": false,
+					"description": op.Query[name], "schema": map[string]string{"type": "string"},
+				})
+			}
+			operation["parameters"] = params
+		}
+		if op.Admin {
+			operation["security"] = []map[string][]string{{"adminToken": {}}}
+		}
+		if paths[op.Path] == nil {
+			paths[op.Path] = make(map[string]interface{})
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "NEXUS scheduler extender",
+			"version":     version.Get().Version,
+			"description": "Filter/Prioritize are served on EXTENDER_ADDR, everything else on ADMIN_ADDR.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"adminToken": map[string]string{"type": "http", "scheme": "bearer", "description": "ADMIN_TOKEN"},
+			},
+		},
+	}
+}
+
+// operationResponses describes the success and error responses of op
+func operationResponses(op apiOperation, schemas map[string]interface{}) map[string]interface{} {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = jsonContent(schemaOf(reflect.TypeOf(op.Response), schemas))
+	} else {
+		success["content"] = map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]string{"type": "string"}}}
+	}
+
+	responses := map[string]interface{}{strconv.Itoa(status): success}
+	if op.Request != nil {
+		responses["400"] = map[string]string{"description": "Invalid request body"}
+	}
+	if op.Admin {
+		responses["401"] = map[string]string{"description": "Missing or wrong admin token"}
+		responses["403"] = map[string]string{"description": "Admin API disabled (no ADMIN_TOKEN)"}
+	}
+	return responses
+}
+
+// operationID names an operation, e.g. GET /admin/profiles/active → getAdminProfilesActive
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, part := range strings.Split(op.Path, "/") {
+		if part != "" {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return id
+}
+
+// jsonContent is an application/json content map for a schema
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// timeType is encoded as an RFC 3339 string
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the JSON schema of t; named structs are added to
+// schemas and referenced
+func schemaOf(t reflect.Type, schemas map[string]interface{}) interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]string{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]string{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]string{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]string{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]string{"type": "number"}
+	case reflect.String:
+		return map[string]string{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		return structSchema(t, schemas)
+	default:
+		return map[string]interface{}{} // interface{}: any value
+	}
+}
+
+// structSchema returns a reference to the component schema of a struct,
+// adding it on first use; Kubernetes types are opaque objects
+func structSchema(t reflect.Type, schemas map[string]interface{}) interface{} {
+	if strings.HasPrefix(t.PkgPath(), "k8s.io/") {
+		return map[string]string{"type": "object", "description": "Kubernetes " + t.String()}
+	}
+	name := t.Name()
+	if name == "" {
+		return objectSchema(t, schemas) // anonymous struct
+	}
+	ref := map[string]string{"$ref": "#/components/schemas/" + name}
+	if _, ok := schemas[name]; !ok {
+		schemas[name] = nil // placeholder against recursion
+		schemas[name] = objectSchema(t, schemas)
+	}
+	return ref
+}
+
+// objectSchema lists the JSON properties of a struct type
+func objectSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				walk(field.Type) // embedded fields are promoted
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type, schemas)
+		}
+	}
+	walk(t)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
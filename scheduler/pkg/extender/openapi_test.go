@@ -0,0 +1,60 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	rec := httptest.NewRecorder()
+	s.OpenAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/openapi", nil))
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q, want 3.0.3", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/admin/spike"]["post"]["responses"].(map[string]interface{})["202"]; !ok {
+		t.Errorf("POST /admin/spike lacks its 202 response: %v", doc.Paths["/admin/spike"]["post"])
+	}
+	if _, ok := doc.Paths["/admin/formation"]["get"]["security"]; !ok {
+		t.Error("GET /admin/formation is not marked as needing the admin token")
+	}
+	if _, ok := doc.Components.Schemas["Status"].Properties["state"]; !ok {
+		t.Errorf("Status schema lacks state: %v", doc.Components.Schemas["Status"])
+	}
+	for name, schema := range doc.Components.Schemas {
+		if schema.Properties == nil {
+			t.Errorf("schema %s has no properties", name)
+		}
+	}
+}
+
+func TestOpenAPICoversServedPaths(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	cfg := *s.cfg
+	cfg.ExtenderAddr, cfg.AdminAddr = ":9099", ":9099"
+	server := s.NewServers(&cfg)[0]
+
+	for _, op := range apiOperations {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest(op.Method, op.Path, strings.NewReader("{}")))
+		if rec.Code == http.StatusNotFound {
+			t.Errorf("%s %s is documented but not served", op.Method, op.Path)
+		}
+	}
+}
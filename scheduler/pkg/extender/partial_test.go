@@ -0,0 +1,43 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+func TestDegradedGangCompletesWhenMemberArrives(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.gangManager.FormGangs([]graph.RuntimeGroup{{
+		Name:     "checkout-flow",
+		Services: []string{"checkoutservice", "cartservice", "currencyservice"},
+		Missing:  []string{"checkoutservice", "currencyservice"},
+	}})
+
+	degraded := s.gangManager.DegradedGangs()
+	if len(degraded) != 1 {
+		t.Fatalf("degraded gangs = %v, want one", degraded)
+	}
+
+	prioritize(t, s) // compatPod is the first checkoutservice pod
+
+	for _, missing := range s.gangManager.DegradedGangs() {
+		if len(missing) != 1 || missing[0] != "currencyservice" {
+			t.Errorf("missing = %v, want only currencyservice left", missing)
+		}
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{
+		"nexus_degraded_gangs 1",
+		"nexus_gang_missing_members_total 2",
+		"nexus_gang_members_arrived_total 1",
+	} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s in metrics", want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Planned Gang Placement
+======================
+With GANG_PLACEMENT=planned every gang gets a placement plan when it
+forms (pkg/gang/plan.go): the expected new replicas of its members,
+GANG_PLAN_SCALE × the replicas running at formation (at least one per
+member), bin-packed onto the schedulable nodes by the capacity their
+running pods leave free. Gangs are planned in gang ID order against the
+same nodes, each consuming the capacity of the ones before it.
+
+While a plan has slots left on the candidate nodes, Prioritize scores
+locality by the node's remaining planned slots instead of its member
+count, and GANG_FILTER_STRICT keeps only the nodes with slots left.
+Once the plan is spent, placement is greedy again. The plan is not used
+while a spread policy applies.
+
+Member requests are those of a running replica of the service; nodes
+that are cordoned, not Ready or excluded from NEXUS influence are not
+planned. A plan is recomputed when the gangs form again, including on
+restart recovery.
+*/
+
+package extender
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+)
+
+// planPlacements attaches a placement plan to every active gang
+// (GANG_PLACEMENT=planned); on failure the gangs are placed greedily
+func (s *NEXUSScheduler) planPlacements(ctx context.Context) {
+	if s.cfg.GangPlacement != config.GangPlacementPlanned {
+		return
+	}
+
+	var nodes *v1.NodeList
+	err := s.apiGuard.Do(ctx, "list nodes for placement plans", func(ctx context.Context) error {
+		var err error
+		nodes, err = s.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Warningf("Placement plans: failed to list nodes (%v), placing gangs greedily", err)
+		s.metrics.IncrementCounter("placement_plan_failures")
+		return
+	}
+	pods, _, err := s.podLister.List(ctx, "list pods for placement plans", metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Placement plans: failed to list pods (%v), placing gangs greedily", err)
+		s.metrics.IncrementCounter("placement_plan_failures")
+		return
+	}
+
+	plans := placementPlans(s.gangManager.GetGangForService, nodes.Items, pods, s.cfg.GangPlanScale, s.cfg.NodeExcludeLabel)
+	s.gangManager.SetPlans(plans)
+	for id, plan := range plans {
+		klog.Infof("Placement plan for %s: %v (unplaced replicas: %d)", id, plan.Targets, plan.Unplaced)
+		s.metrics.IncrementCounter("placement_plans")
+		s.metrics.AddUnplacedReplicas(plan.Unplaced)
+	}
+}
+
+// placementPlans plans the new replicas of every gang with running
+// members; gangFor maps a service to its gang (nil = not a member)
+func placementPlans(gangFor func(string) *gang.Gang, nodes []v1.Node, pods []v1.Pod, scale float64, excludeLabel string) map[string]*gang.PlacementPlan {
+	free := make(map[string]*gang.PlanNode, len(nodes))
+	planNodes := make([]gang.PlanNode, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable || !isNodeReady(node) || kube.NodeExcluded(node, excludeLabel) {
+			continue
+		}
+		planNodes = append(planNodes, gang.PlanNode{
+			Name:     node.Name,
+			MilliCPU: node.Status.Allocatable.Cpu().MilliValue(),
+			Memory:   node.Status.Allocatable.Memory().Value(),
+		})
+	}
+	for i := range planNodes {
+		free[planNodes[i].Name] = &planNodes[i]
+	}
+
+	// Capacity used by running pods, and the replicas and placement of each member
+	type memberStats struct {
+		replicas         int
+		milliCPU, memory int64
+	}
+	members := make(map[string]map[string]*memberStats) // gang ID → service → stats
+	placed := make(map[string]map[string]int)           // gang ID → node → members
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		requests := podRequests(pod, nil)
+		cpu, memory := requests.Cpu().MilliValue(), requests.Memory().Value()
+		if node, ok := free[pod.Spec.NodeName]; ok {
+			node.MilliCPU -= cpu
+			node.Memory -= memory
+		}
+
+		service := graph.ExtractServiceName(pod.Name)
+		g := gangFor(service)
+		if g == nil {
+			continue
+		}
+		if members[g.ID] == nil {
+			members[g.ID] = make(map[string]*memberStats)
+			placed[g.ID] = make(map[string]int)
+		}
+		stats, ok := members[g.ID][service]
+		if !ok {
+			stats = &memberStats{milliCPU: cpu, memory: memory}
+			members[g.ID][service] = stats
+		}
+		stats.replicas++
+		placed[g.ID][pod.Spec.NodeName]++
+	}
+
+	gangIDs := make([]string, 0, len(members))
+	for id := range members {
+		gangIDs = append(gangIDs, id)
+	}
+	sort.Strings(gangIDs)
+
+	plans := make(map[string]*gang.PlacementPlan, len(gangIDs))
+	for _, id := range gangIDs {
+		services := make([]string, 0, len(members[id]))
+		for service := range members[id] {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+
+		planMembers := make([]gang.PlanMember, 0, len(services))
+		for _, service := range services {
+			stats := members[id][service]
+			replicas := int(math.Ceil(scale * float64(stats.replicas)))
+			if replicas < 1 {
+				replicas = 1
+			}
+			planMembers = append(planMembers, gang.PlanMember{
+				Service:  service,
+				Replicas: replicas,
+				MilliCPU: stats.milliCPU,
+				Memory:   stats.memory,
+			})
+		}
+		for i := range planNodes {
+			planNodes[i].Members = placed[id][planNodes[i].Name]
+		}
+		plans[id] = gang.PlanPlacement(planMembers, planNodes)
+	}
+	return plans
+}
+
+// planNodesWithSlots returns the candidate nodes the gang's plan still has
+// slots on, and false when the gang is placed greedily
+func (s *NEXUSScheduler) planNodesWithSlots(g *gang.Gang, counts map[string]int, candidates []string) (map[string]bool, bool) {
+	plan := s.gangManager.Plan(g)
+	if plan == nil || s.spikePolicy(g).Spread {
+		return nil, false
+	}
+	slots, ok := plan.Steer(counts, candidates)
+	if !ok {
+		return nil, false
+	}
+	nodes := make(map[string]bool, len(slots))
+	for node := range slots {
+		nodes[node] = true
+	}
+	return nodes, true
+}
@@ -0,0 +1,77 @@
+package extender
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// requestingPod is a running pod on node requesting cpu
+func requestingPod(name, node, cpu string) v1.Pod {
+	pod := runningPod(name, nil)
+	pod.Spec.NodeName = node
+	pod.Spec.Containers = []v1.Container{{
+		Name:      "server",
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
+	}}
+	return *pod
+}
+
+func TestPlannedPlacement(t *testing.T) {
+	// node-1 is full, node-2 has room for three members, node-3 for four
+	s := newTestScheduler(t, StateActive,
+		requestingPod("cartservice-6d5c7b8f9-abcde", "node-1", "500m"),
+		requestingPod("redis-cart-5b6f7d8c9-fghij", "node-1", "1500m"),
+		requestingPod("checkoutservice-7d9f8c6b5-klmno", "node-2", "500m"),
+	)
+	s.cfg.GangPlacement = config.GangPlacementPlanned
+	s.cfg.GangPlanScale = 2
+	s.gangFilterStrict = true
+	nodes := []v1.Node{
+		testNode("node-1"),
+		testNode("node-2"),
+		testNode("node-3"),
+		testNode("node-4", func(n *v1.Node) { n.Spec.Unschedulable = true }),
+	}
+	for i := range nodes {
+		if _, err := s.clientset.CoreV1().Nodes().Create(context.Background(), &nodes[i], metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.planPlacements(context.Background())
+	g := s.gangManager.GetGangForService("checkoutservice")
+	plan := s.gangManager.Plan(g)
+	if plan == nil {
+		t.Fatal("no placement plan for the checkout gang")
+	}
+	// Two new replicas per member: node-2 (already hosting a member) fills
+	// first, the rest spills onto node-3; node-1 has no room
+	if want := map[string]int{"node-2": 3, "node-3": 1}; !reflect.DeepEqual(plan.Targets, want) || plan.Unplaced != 0 {
+		t.Errorf("targets = %v (unplaced %d), want %v", plan.Targets, plan.Unplaced, want)
+	}
+
+	// Strict filtering keeps the nodes with planned slots, not the member nodes
+	result := filter(t, s, "100m", nodes[0], nodes[1], nodes[2])
+	if got := reasonOf(result.FailedNodes["node-1"]); got != ReasonGangColocation || len(result.Nodes.Items) != 2 {
+		t.Errorf("eligible = %v, failed = %v; want node-1 rejected for co-location", result.Nodes.Items, result.FailedNodes)
+	}
+
+	breakdown := s.nodeScorer.Score(context.Background(), &v1.Pod{}, &v1.NodeList{Items: nodes[:3]}, g, scorer.Locality{Scale: 1})
+	locality := []int64{breakdown[0].Locality, breakdown[1].Locality, breakdown[2].Locality}
+	if want := []int64{0, 300, 100}; !reflect.DeepEqual(locality, want) || !breakdown[0].Planned {
+		t.Errorf("locality = %v (planned %v), want %v from the planned slots", locality, breakdown[0].Planned, want)
+	}
+
+	// Once every slot is taken, placement is greedy again
+	if _, ok := plan.Steer(map[string]int{"node-1": 1, "node-2": 4, "node-3": 1}, []string{"node-1", "node-2", "node-3"}); ok {
+		t.Error("spent plan still steers")
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Coscheduling PodGroups
+======================
+NEXUS gangs only bias placement; a gang member can still be scheduled
+while its peers stay pending. When the scheduler-plugins coscheduling
+plugin is installed, COSCHEDULING_PODGROUPS=true gives the spike's gangs
+all-or-nothing admission: on activation NEXUS creates a temporary
+PodGroup (scheduling.x-k8s.io/v1alpha1) per gang and namespace
+
+  metadata: {name: <gang id>, labels: {nexus.io/gang-id: <gang id>}}
+  spec:     {minMember: <member services in the namespace>,
+             scheduleTimeoutSeconds: COSCHEDULING_SCHEDULE_TIMEOUT}
+
+and deletes them when the gangs dissolve (and at startup outside a
+spike, for groups left behind by a restart).
+
+Pods join a PodGroup through the COSCHEDULING_POD_GROUP_LABEL label,
+which must be present when they are scheduled: the gang label webhook
+(WEBHOOK_ENABLED) adds it to new gang-member pods next to
+nexus.io/gang-id. Only schedulers running the coscheduling plugin
+enforce the group.
+*/
+
+package extender
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// podGroupGVR identifies coscheduling PodGroups
+var podGroupGVR = schema.GroupVersionResource{
+	Group:    "scheduling.x-k8s.io",
+	Version:  "v1alpha1",
+	Resource: "podgroups",
+}
+
+// PodGroupManager creates and deletes the temporary PodGroups of the gangs
+type PodGroupManager struct {
+	client    dynamic.Interface
+	apiGuard  *kube.APIGuard
+	podLister *kube.PodLister
+	metrics   *metrics.NEXUSMetrics
+	label     string // pod label naming the PodGroup
+	timeout   time.Duration
+}
+
+// NewPodGroupManager creates a PodGroup manager; pods join a group through label
+func NewPodGroupManager(client dynamic.Interface, apiGuard *kube.APIGuard, podLister *kube.PodLister, metrics *metrics.NEXUSMetrics, label string, timeout time.Duration) *PodGroupManager {
+	return &PodGroupManager{
+		client:    client,
+		apiGuard:  apiGuard,
+		podLister: podLister,
+		metrics:   metrics,
+		label:     label,
+		timeout:   timeout,
+	}
+}
+
+// podGroupKey names a PodGroup: one per gang and namespace
+type podGroupKey struct {
+	namespace string
+	gangID    string
+}
+
+// Create creates a PodGroup for every gang and namespace its member pods run
+// in and returns the number created (existing groups are left as they are)
+func (pm *PodGroupManager) Create(ctx context.Context, gangs *gang.GangManager) int {
+	pods, _, err := pm.podLister.List(ctx, "list pods for podgroups", metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("PodGroups: failed to list pods: %v", err)
+		return 0
+	}
+
+	members := make(map[podGroupKey]map[string]bool)
+	for i := range pods {
+		pod := &pods[i]
+		g := gangs.GetGangForPod(pod)
+		if g == nil || pod.DeletionTimestamp != nil {
+			continue
+		}
+		key := podGroupKey{namespace: pod.Namespace, gangID: g.ID}
+		if members[key] == nil {
+			members[key] = make(map[string]bool)
+		}
+		members[key][graph.ExtractServiceName(pod.Name)] = true
+	}
+
+	keys := make([]podGroupKey, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace != keys[j].namespace {
+			return keys[i].namespace < keys[j].namespace
+		}
+		return keys[i].gangID < keys[j].gangID
+	})
+
+	created := 0
+	for _, key := range keys {
+		if pm.create(ctx, key, len(members[key])) {
+			created++
+			pm.metrics.IncrementCounter("podgroups_created")
+		}
+	}
+	if created > 0 {
+		klog.Infof("PodGroups: created %d coscheduling groups for %d gangs", created, gangs.GetActiveGangCount())
+	}
+	return created
+}
+
+// create creates one PodGroup, reporting whether it is new
+func (pm *PodGroupManager) create(ctx context.Context, key podGroupKey, minMember int) bool {
+	name := podGroupName(key.gangID)
+	podGroup := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": podGroupGVR.GroupVersion().String(),
+		"kind":       "PodGroup",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": key.namespace,
+			"labels":    map[string]interface{}{gang.LabelGangID: key.gangID},
+		},
+		"spec": map[string]interface{}{
+			"minMember":              int64(minMember),
+			"scheduleTimeoutSeconds": int64(pm.timeout.Seconds()),
+		},
+	}}
+
+	err := pm.apiGuard.Do(ctx, "create podgroup", func(ctx context.Context) error {
+		_, err := pm.client.Resource(podGroupGVR).Namespace(key.namespace).Create(ctx, podGroup, metav1.CreateOptions{})
+		return err
+	})
+	switch {
+	case err == nil:
+		klog.V(2).Infof("PodGroups: created %s/%s (minMember %d)", key.namespace, name, minMember)
+		return true
+	case apierrors.IsAlreadyExists(err):
+		return false
+	default:
+		klog.Warningf("PodGroups: failed to create %s/%s: %v", key.namespace, name, err)
+		return false
+	}
+}
+
+// Delete deletes every PodGroup NEXUS created and returns the number deleted
+func (pm *PodGroupManager) Delete(ctx context.Context) int {
+	var list *unstructured.UnstructuredList
+	err := pm.apiGuard.Do(ctx, "list podgroups", func(ctx context.Context) error {
+		var err error
+		list, err = pm.client.Resource(podGroupGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: gang.LabelGangID})
+		return err
+	})
+	if err != nil {
+		klog.Warningf("PodGroups: failed to list groups to delete: %v", err)
+		return 0
+	}
+
+	deleted := 0
+	for _, item := range list.Items {
+		namespace, name := item.GetNamespace(), item.GetName()
+		err := pm.apiGuard.Do(ctx, "delete podgroup", func(ctx context.Context) error {
+			return pm.client.Resource(podGroupGVR).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("PodGroups: failed to delete %s/%s: %v", namespace, name, err)
+			continue
+		}
+		deleted++
+		pm.metrics.IncrementCounter("podgroups_deleted")
+	}
+	if deleted > 0 {
+		klog.Infof("PodGroups: deleted %d coscheduling groups", deleted)
+	}
+	return deleted
+}
+
+// podGroupName is the PodGroup (and pod label value) of a gang
+func podGroupName(gangID string) string {
+	return strings.ToLower(gangID)
+}
+
+// createPodGroups gives the active gangs coscheduling PodGroups
+func (s *NEXUSScheduler) createPodGroups(ctx context.Context) {
+	if s.podGroups != nil {
+		s.podGroups.Create(ctx, s.gangManager)
+	}
+}
+
+// deletePodGroups removes the PodGroups created for the gangs
+func (s *NEXUSScheduler) deletePodGroups(ctx context.Context) {
+	if s.podGroups != nil {
+		s.podGroups.Delete(ctx)
+	}
+}
@@ -0,0 +1,81 @@
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+)
+
+// memberPod is a running gang member pod
+func memberPod(namespace, name string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1.PodSpec{NodeName: "node-1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestPodGroupsFollowGangs(t *testing.T) {
+	s := newTestScheduler(t, StateActive,
+		memberPod("default", "checkoutservice-7d9f8c6b5-x2k4p"),
+		memberPod("default", "cartservice-6d5c7b8f9-abcde"),
+		memberPod("shop", "checkoutservice-7d9f8c6b5-q8w3e"),
+		memberPod("default", "frontend-5f6d7c8b9-zzzzz"), // not a gang member
+	)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podGroupGVR: "PodGroupList"})
+	s.podGroups = NewPodGroupManager(client, s.apiGuard, kube.NewPodLister(s.clientset, s.apiGuard, s.metrics, s.cfg), s.metrics, s.cfg.PodGroupLabel, s.cfg.PodGroupTimeout)
+	ctx := context.Background()
+
+	if created := s.podGroups.Create(ctx, s.gangManager); created != 2 {
+		t.Fatalf("created %d PodGroups, want one per namespace (2)", created)
+	}
+	if created := s.podGroups.Create(ctx, s.gangManager); created != 0 {
+		t.Errorf("re-created %d existing PodGroups", created)
+	}
+
+	g := s.gangManager.GetGangForService("checkoutservice")
+	for namespace, want := range map[string]int64{"default": 2, "shop": 1} {
+		pg, err := client.Resource(podGroupGVR).Namespace(namespace).Get(ctx, podGroupName(g.ID), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", namespace, err)
+		}
+		if minMember, _, _ := unstructured.NestedInt64(pg.Object, "spec", "minMember"); minMember != want {
+			t.Errorf("%s: minMember = %d, want %d", namespace, minMember, want)
+		}
+		if pg.GetLabels()[gang.LabelGangID] != g.ID {
+			t.Errorf("%s: labels = %v, want the gang id", namespace, pg.GetLabels())
+		}
+	}
+
+	// New member pods are labelled into the group by the webhook
+	raw, _ := json.Marshal(v1.Pod{ObjectMeta: metav1.ObjectMeta{GenerateName: "cartservice-6d5c7b8f9-", Labels: map[string]string{"app": "cartservice"}}})
+	patch := s.gangLabelPatch(&admissionv1.AdmissionRequest{Operation: admissionv1.Create, Object: runtime.RawExtension{Raw: raw}})
+	labels := make(map[string]interface{})
+	for _, op := range patch {
+		labels[op.Path] = op.Value
+	}
+	if labels["/metadata/labels/scheduling.x-k8s.io~1pod-group"] != podGroupName(g.ID) {
+		t.Errorf("webhook patch %+v does not join the PodGroup", patch)
+	}
+
+	s.dissolveGangs(ctx)
+	list, err := client.Resource(podGroupGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("%d PodGroups left after dissolution", len(list.Items))
+	}
+}
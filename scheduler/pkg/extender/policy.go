@@ -0,0 +1,337 @@
+/*
+NexusPolicy Custom Resources
+============================
+Teams declare the scheduling policy of their coordination group in a
+namespaced NexusPolicy (nexus.io/v1alpha1, see nexuspolicy-crd.yaml)
+instead of asking for global environment changes:
+
+  apiVersion: nexus.io/v1alpha1
+  kind: NexusPolicy
+  metadata: {name: checkout, namespace: shop}
+  spec:
+    group: checkout-flow     # coordination group (required)
+    sloP95Ms: 400            # latency SLO threshold
+    placement: colocate      # or spread
+    freshStart: schedulable  # Filter while no node has members (FRESH_GANG_PLACEMENT)
+    localityScale: 1.5
+    weights: {locality: 1, resource: 0.5, utilization: 2}
+    priorityBoost: 1.5       # multiplier on the Prioritize scores
+    maxInfluence: 50         # pods influenced per episode
+    anchors: [redis-cart]    # members the others are scored toward (pkg/scorer/anchor.go)
+
+With NEXUS_POLICIES=true a list/watch loop keeps the policies of
+NEXUS_POLICY_NAMESPACE (all namespaces by default) in memory. They are
+attached to the gangs at formation and stay fixed for the episode;
+placement and localityScale override SPIKE_CLASS_POLICIES, maxInfluence
+overrides MAX_INFLUENCED_PODS_PER_GANG. Invalid policies are ignored
+with a warning; when several policies name the same group, the first by
+"<namespace>/<name>" wins.
+
+  GET /policies → Loaded policies, by group
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+)
+
+// nexusPolicyGVR identifies NexusPolicies
+var nexusPolicyGVR = schema.GroupVersionResource{
+	Group:    "nexus.io",
+	Version:  "v1alpha1",
+	Resource: "nexuspolicies",
+}
+
+// policyRetry is the pause before re-listing after a failed watch
+const policyRetry = 10 * time.Second
+
+// policyStore holds the valid NexusPolicies by group
+type policyStore struct {
+	mu       sync.RWMutex
+	byGroup  map[string]gang.Policy
+	rejected map[string]string // "<namespace>/<name>" → resourceVersion reported invalid
+
+	client    dynamic.Interface // nil = NEXUS_POLICIES off
+	namespace string
+}
+
+// WatchPolicies lists and then watches the NexusPolicies until ctx is
+// done, re-listing whenever the watch ends (returns immediately with
+// NEXUS_POLICIES=false)
+func (s *NEXUSScheduler) WatchPolicies(ctx context.Context) {
+	if s.policies.client == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		if err := s.syncPolicies(ctx); err != nil && ctx.Err() == nil {
+			klog.Warningf("NexusPolicy watch failed (retrying in %s): %v", policyRetry, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(policyRetry):
+			}
+		}
+	}
+}
+
+// syncPolicies lists the NexusPolicies, then applies watch events to the
+// store until the watch ends
+func (s *NEXUSScheduler) syncPolicies(ctx context.Context) error {
+	resource := s.policies.client.Resource(nexusPolicyGVR).Namespace(s.policies.namespace)
+
+	var list *unstructured.UnstructuredList
+	err := s.apiGuard.Do(ctx, "list nexuspolicies", func(ctx context.Context) error {
+		var err error
+		list, err = resource.List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	objects := make(map[string]*unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		objects[policySource(&list.Items[i])] = &list.Items[i]
+	}
+	s.setPolicies(objects)
+
+	watcher, err := resource.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			return fmt.Errorf("watch error: %v", event.Object)
+		}
+		u, ok := event.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			objects[policySource(u)] = u
+		case watch.Deleted:
+			delete(objects, policySource(u))
+		}
+		s.setPolicies(objects)
+	}
+	return nil
+}
+
+// setPolicies replaces the store with the valid policies among objects
+// ("<namespace>/<name>" → NexusPolicy)
+func (s *NEXUSScheduler) setPolicies(objects map[string]*unstructured.Unstructured) {
+	sources := make([]string, 0, len(objects))
+	for source := range objects {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	s.policies.mu.Lock()
+	defer s.policies.mu.Unlock()
+	if s.policies.rejected == nil {
+		s.policies.rejected = make(map[string]string)
+	}
+
+	byGroup := make(map[string]gang.Policy, len(objects))
+	rejected := make(map[string]string)
+	for _, source := range sources {
+		u := objects[source]
+		policy, err := parsePolicy(u)
+		if err != nil {
+			// Reported once per version, not on every reload
+			if version, seen := s.policies.rejected[source]; !seen || version != u.GetResourceVersion() {
+				klog.Warningf("NexusPolicy %s ignored: %v", source, err)
+				s.metrics.IncrementCounter("policy_rejected")
+			}
+			rejected[source] = u.GetResourceVersion()
+			continue
+		}
+		if existing, ok := byGroup[policy.Group]; ok {
+			klog.V(2).Infof("NexusPolicy %s ignored: group %s already has policy %s", source, policy.Group, existing.Source)
+			continue
+		}
+		byGroup[policy.Group] = policy
+	}
+
+	s.policies.byGroup = byGroup
+	s.policies.rejected = rejected
+	s.metrics.SetPolicies(len(byGroup))
+}
+
+// Policies returns the loaded policies by group
+func (s *NEXUSScheduler) Policies() map[string]gang.Policy {
+	s.policies.mu.RLock()
+	defer s.policies.mu.RUnlock()
+	policies := make(map[string]gang.Policy, len(s.policies.byGroup))
+	for group, policy := range s.policies.byGroup {
+		policies[group] = policy
+	}
+	return policies
+}
+
+// applyPolicies attaches the loaded policies to the newly formed gangs
+func (s *NEXUSScheduler) applyPolicies() {
+	if applied := s.gangManager.ApplyPolicies(s.Policies()); applied > 0 {
+		s.metrics.AddPoliciesApplied(applied)
+	}
+}
+
+// withGroupPolicy overrides a spike class policy with the placement
+// preferences of a gang's NexusPolicy (nil = none)
+func withGroupPolicy(policy config.SpikeClassPolicy, p *gang.Policy) config.SpikeClassPolicy {
+	if p == nil {
+		return policy
+	}
+	if p.LocalityScale > 0 {
+		policy.LocalityScale = p.LocalityScale
+	}
+	switch p.Placement {
+	case gang.PlacementColocate:
+		policy.Spread = false
+	case gang.PlacementSpread:
+		policy.Spread = true
+	}
+	return policy
+}
+
+// priorityBoost is the Prioritize score multiplier of a gang's NexusPolicy
+func priorityBoost(g *gang.Gang) float64 {
+	if g.Policy == nil || g.Policy.PriorityBoost == 0 {
+		return 1
+	}
+	return g.Policy.PriorityBoost
+}
+
+// policySource names a NexusPolicy as "<namespace>/<name>"
+func policySource(u *unstructured.Unstructured) string {
+	return u.GetNamespace() + "/" + u.GetName()
+}
+
+// parsePolicy reads and validates the spec of a NexusPolicy
+func parsePolicy(u *unstructured.Unstructured) (gang.Policy, error) {
+	policy := gang.Policy{Source: policySource(u)}
+
+	group, _, err := unstructured.NestedString(u.Object, "spec", "group")
+	if err != nil {
+		return policy, err
+	}
+	if group == "" {
+		return policy, fmt.Errorf("spec.group is required")
+	}
+	policy.Group = group
+
+	placement, _, err := unstructured.NestedString(u.Object, "spec", "placement")
+	if err != nil {
+		return policy, err
+	}
+	if placement != "" && placement != gang.PlacementColocate && placement != gang.PlacementSpread {
+		return policy, fmt.Errorf("spec.placement %q is not colocate or spread", placement)
+	}
+	policy.Placement = placement
+
+	freshStart, _, err := unstructured.NestedString(u.Object, "spec", "freshStart")
+	if err != nil {
+		return policy, err
+	}
+	switch freshStart {
+	case "", config.FreshPlacementAllNodes, config.FreshPlacementSchedulable, config.FreshPlacementPlanFirst:
+		policy.FreshStart = freshStart
+	default:
+		return policy, fmt.Errorf("spec.freshStart %q is not all-nodes, schedulable or plan-first-node", freshStart)
+	}
+
+	anchors, _, err := unstructured.NestedStringSlice(u.Object, "spec", "anchors")
+	if err != nil {
+		return policy, err
+	}
+	for _, anchor := range anchors {
+		if anchor == "" {
+			return policy, fmt.Errorf("spec.anchors has an empty service name")
+		}
+	}
+	policy.Anchors = anchors
+
+	for _, field := range []struct {
+		path []string
+		dst  *float64
+	}{
+		{[]string{"sloP95Ms"}, &policy.SLOP95Ms},
+		{[]string{"localityScale"}, &policy.LocalityScale},
+		{[]string{"priorityBoost"}, &policy.PriorityBoost},
+		{[]string{"weights", "locality"}, &policy.Weights.Locality},
+		{[]string{"weights", "resource"}, &policy.Weights.Resource},
+		{[]string{"weights", "utilization"}, &policy.Weights.Utilization},
+	} {
+		if err := specNumber(u, field.dst, field.path...); err != nil {
+			return policy, err
+		}
+	}
+
+	var maxInfluence float64
+	if err := specNumber(u, &maxInfluence, "maxInfluence"); err != nil {
+		return policy, err
+	}
+	if maxInfluence != float64(int(maxInfluence)) {
+		return policy, fmt.Errorf("spec.maxInfluence must be an integer")
+	}
+	policy.MaxInfluence = int(maxInfluence)
+
+	var priority float64
+	if err := specNumber(u, &priority, "priority"); err != nil {
+		return policy, err
+	}
+	if priority != float64(int(priority)) {
+		return policy, fmt.Errorf("spec.priority must be an integer")
+	}
+	policy.Priority = int(priority)
+
+	return policy, nil
+}
+
+// specNumber reads a non-negative number from the spec into dst (unset = 0)
+func specNumber(u *unstructured.Unstructured, dst *float64, path ...string) error {
+	raw, found, err := unstructured.NestedFieldNoCopy(u.Object, append([]string{"spec"}, path...)...)
+	if err != nil || !found {
+		return err
+	}
+
+	var value float64
+	switch v := raw.(type) {
+	case int64:
+		value = float64(v)
+	case float64:
+		value = v
+	default:
+		return fmt.Errorf("spec.%s: %v is not a number", strings.Join(path, "."), raw)
+	}
+	if value < 0 {
+		return fmt.Errorf("spec.%s must not be negative", strings.Join(path, "."))
+	}
+	*dst = value
+	return nil
+}
+
+// PoliciesHandler returns the loaded NexusPolicies by group
+func (s *NEXUSScheduler) PoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Policies())
+}
@@ -0,0 +1,145 @@
+package extender
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+)
+
+// nexusPolicy builds a NexusPolicy object with the given spec
+func nexusPolicy(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "nexus.io/v1alpha1",
+		"kind":       "NexusPolicy",
+		"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+		"spec":       spec,
+	}}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := parsePolicy(nexusPolicy("shop", "checkout", map[string]interface{}{
+		"group":         "checkout-flow",
+		"placement":     "spread",
+		"freshStart":    "plan-first-node",
+		"sloP95Ms":      int64(400),
+		"priorityBoost": 1.5,
+		"weights":       map[string]interface{}{"resource": 0.5},
+		"maxInfluence":  int64(2),
+		"anchors":       []interface{}{"redis-cart"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gang.Policy{
+		Source: "shop/checkout", Group: "checkout-flow", Placement: gang.PlacementSpread, FreshStart: "plan-first-node",
+		SLOP95Ms: 400, PriorityBoost: 1.5, Weights: gang.Weights{Resource: 0.5}, MaxInfluence: 2,
+		Anchors: []string{"redis-cart"},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("policy = %+v, want %+v", policy, want)
+	}
+
+	for name, spec := range map[string]map[string]interface{}{
+		"no group":          {"placement": "spread"},
+		"unknown placement": {"group": "g", "placement": "pack"},
+		"unknown start":     {"group": "g", "freshStart": "anywhere"},
+		"negative weight":   {"group": "g", "weights": map[string]interface{}{"locality": -1.0}},
+		"not a number":      {"group": "g", "localityScale": "high"},
+		"fractional budget": {"group": "g", "maxInfluence": 1.5},
+		"empty anchor":      {"group": "g", "anchors": []interface{}{""}},
+	} {
+		if _, err := parsePolicy(nexusPolicy("shop", "p", spec)); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestPoliciesAppliedAtGangFormation(t *testing.T) {
+	s := newTestScheduler(t, StateIdle, compatMember)
+	s.setPolicies(map[string]*unstructured.Unstructured{
+		"shop/b-other": nexusPolicy("shop", "b-other", map[string]interface{}{"group": "checkout-flow", "maxInfluence": int64(9)}),
+		"shop/a-checkout": nexusPolicy("shop", "a-checkout", map[string]interface{}{
+			"group": "checkout-flow", "placement": "spread", "priorityBoost": int64(2), "maxInfluence": int64(1),
+		}),
+		"shop/invalid": nexusPolicy("shop", "invalid", map[string]interface{}{"placement": "spread"}),
+	})
+	if policies := s.Policies(); len(policies) != 1 || policies["checkout-flow"].Source != "shop/a-checkout" {
+		t.Fatalf("policies = %+v, want shop/a-checkout for checkout-flow", policies)
+	}
+
+	groups := []graph.RuntimeGroup{{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice"}}}
+	s.depGraph.Restore(groups)
+	s.formGangs(context.Background(), groups, s.nextFormationStrategy())
+	s.SetState(StateActive)
+
+	g := s.gangManager.GetGangForService("checkoutservice")
+	if g.Policy == nil || !s.spikePolicy(g).Spread {
+		t.Fatalf("gang policy = %+v, want the spread policy", g.Policy)
+	}
+
+	// Applied at formation: later changes wait for the next episode
+	s.setPolicies(nil)
+	scores := prioritize(t, s)
+	if scores["node-1"] <= scores["node-2"] {
+		t.Errorf("spread policy: scores %v, want node-1 (no cartservice) first", scores)
+	}
+	if scores["node-1"]%2 != 0 {
+		t.Errorf("node-1 score %d not boosted ×2", scores["node-1"])
+	}
+
+	for host, score := range prioritize(t, s) { // same pod: budget charged once
+		if score != scores[host] {
+			t.Errorf("repeat decision for %s = %d, want %d", host, score, scores[host])
+		}
+	}
+	if s.gangManager.ConsumeInfluence(g, &compatMember) {
+		t.Error("second pod influenced beyond the policy's maxInfluence of 1")
+	}
+}
+
+func TestWatchPolicies(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{nexusPolicyGVR: "NexusPolicyList"},
+		nexusPolicy("shop", "checkout", map[string]interface{}{"group": "checkout-flow"}))
+
+	s := newTestScheduler(t, StateIdle)
+	s.policies.client = client
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.WatchPolicies(ctx)
+
+	waitFor := func(what string, cond func(map[string]gang.Policy) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond(s.Policies()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: policies = %+v", what, s.Policies())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("listed policy", func(p map[string]gang.Policy) bool { return len(p) == 1 })
+
+	// Watch events: a new policy appears, a deleted one disappears
+	resource := client.Resource(nexusPolicyGVR).Namespace("shop")
+	browse := nexusPolicy("shop", "browse", map[string]interface{}{"group": "product-browsing", "placement": "spread"})
+	if _, err := resource.Create(ctx, browse, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("created policy", func(p map[string]gang.Policy) bool { return p["product-browsing"].Placement == gang.PlacementSpread })
+
+	if err := resource.Delete(ctx, "checkout", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("deleted policy", func(p map[string]gang.Policy) bool { _, ok := p["checkout-flow"]; return !ok })
+}
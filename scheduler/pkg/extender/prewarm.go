@@ -0,0 +1,145 @@
+/*
+Activation Pre-Warm
+===================
+The first decisions of an episode were the slowest: the node utilization
+and VPA caches had gone stale while IDLE and were re-read by whichever
+Filter or Prioritize call hit them first, and a throttled first pod list
+found no last known good member count to fall back on. With
+ACTIVATION_PREWARM=true (default) activation warms them itself:
+
+  Stage 2  the utilization (UTILIZATION_SCORING) and VPA recommendation
+           (VPA_RECOMMENDATIONS) caches are re-read in the background
+           while the dependency graph builds
+  Stage 4  once the gangs formed, the resync is awaited and every gang's
+           node → member table is seeded into the scorer's last known
+           good counts (SCORE_LAST_GOOD_MAX_AGE), from the warm member
+           counts or, for cold gangs, from one budgeted pod list
+
+The warm-up time (resync plus seeding) is reported separately in
+nexus_activation_prewarm_ms and the nexus.prewarm span of the episode
+trace, so it is not attributed to the extender latency of the first pods.
+It remains part of nexus_activation_latency_ms, which ends at ACTIVE.
+*/
+
+package extender
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+// prewarmState is an activation pre-warm in progress
+type prewarmState struct {
+	start    time.Time
+	resynced chan struct{}
+	resync   time.Duration // set before resynced closes
+}
+
+// startPrewarm re-reads the scoring caches in the background (nil when
+// ACTIVATION_PREWARM=false)
+func (s *NEXUSScheduler) startPrewarm(ctx context.Context) *prewarmState {
+	if !s.cfg.ActivationPrewarm {
+		return nil
+	}
+	pw := &prewarmState{start: time.Now(), resynced: make(chan struct{})}
+	go func() {
+		defer close(pw.resynced)
+		if err := s.nodeScorer.RefreshUtilization(ctx); err != nil {
+			klog.Warningf("Pre-warm: failed to refresh node utilization: %v", err)
+		}
+		if s.vpa != nil {
+			if err := s.vpa.Refresh(ctx); err != nil {
+				klog.Warningf("Pre-warm: failed to refresh VPA recommendations: %v", err)
+			}
+		}
+		pw.resync = time.Since(pw.start)
+	}()
+	return pw
+}
+
+// finishPrewarm waits for the cache resync, seeds the gangs' node tables
+// and reports the warm-up time
+func (s *NEXUSScheduler) finishPrewarm(ctx context.Context, pw *prewarmState) {
+	if pw == nil {
+		return
+	}
+	select {
+	case <-pw.resynced:
+	case <-ctx.Done():
+		return
+	}
+
+	seedStart := time.Now()
+	tables := s.seedNodeTables(ctx)
+	end := time.Now()
+
+	warmup := pw.resync + end.Sub(seedStart)
+	s.metrics.ActivationPrewarm.Observe(float64(warmup.Microseconds()) / 1000)
+	s.traceStage(spanPrewarm, pw.start, end, map[string]string{"nexus.tables": strconv.Itoa(tables)})
+	klog.Infof("Pre-warm: caches resynced in %v, %d gang node tables seeded in %v",
+		pw.resync.Round(time.Millisecond), tables, end.Sub(seedStart).Round(time.Millisecond))
+}
+
+// seedNodeTables seeds every active gang's member counts by node into the
+// scorer's last known good counts and returns the number of gangs seeded
+func (s *NEXUSScheduler) seedNodeTables(ctx context.Context) int {
+	if s.cfg.LastGoodMaxAge <= 0 {
+		return 0
+	}
+	var listed map[string]map[string]int // service → node → pods, listed once for cold gangs
+	seeded := 0
+	for _, g := range s.gangManager.ActiveGangs() {
+		counts, warm := s.gangManager.WarmNodeCounts(g)
+		if !warm {
+			if listed == nil {
+				var err error
+				if listed, err = s.listPodsByService(ctx); err != nil {
+					klog.Warningf("Pre-warm: failed to list pods (%v), gang node tables not seeded", err)
+					return seeded
+				}
+			}
+			counts = memberTable(listed, g.Members)
+		}
+		s.nodeScorer.SeedMemberCounts(g.ID, counts)
+		seeded++
+	}
+	return seeded
+}
+
+// listPodsByService lists all pods into per-service node counts
+func (s *NEXUSScheduler) listPodsByService(ctx context.Context) (map[string]map[string]int, error) {
+	pods, _, err := s.podLister.List(ctx, "list pods for gang node tables", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	byService := make(map[string]map[string]int)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		service := strings.ToLower(graph.ExtractServiceName(pod.Name))
+		if byService[service] == nil {
+			byService[service] = make(map[string]int)
+		}
+		byService[service][pod.Spec.NodeName]++
+	}
+	return byService, nil
+}
+
+// memberTable sums the node counts of a gang's member services
+func memberTable(byService map[string]map[string]int, members []string) map[string]int {
+	counts := make(map[string]int)
+	for _, member := range members {
+		for node, n := range byService[strings.ToLower(member)] {
+			counts[node] += n
+		}
+	}
+	return counts
+}
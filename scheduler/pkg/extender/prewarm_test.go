@@ -0,0 +1,53 @@
+package extender
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"nexus-scheduler/pkg/scorer"
+)
+
+func TestActivationPrewarmSeedsNodeTables(t *testing.T) {
+	t.Setenv("KUBE_API_RETRY_STEPS", "1") // fail throttled calls without backoff
+	s := newTestScheduler(t, StateActive,
+		requestingPod("cartservice-6d5c7b8f9-abcde", "member-node", "100m"),
+		requestingPod("frontend-5f7b9c8d6-fghij", "other-node", "100m"))
+	s.finishPrewarm(context.Background(), s.startPrewarm(context.Background()))
+
+	// The first decision is throttled: the seeded table stands in for the list
+	s.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, apierrors.NewTooManyRequests("slow down", 1)
+	})
+	pod := requestingPod("checkoutservice-7d9f8c6b5-new", "", "100m")
+	nodes := &v1.NodeList{Items: []v1.Node{testNode("member-node"), testNode("other-node")}}
+	locality := make(map[string]int64)
+	for _, b := range s.nodeScorer.Score(context.Background(), &pod, nodes, s.gangManager.GetGangForPod(&pod), scorer.Locality{Scale: 1}) {
+		if !b.Degraded {
+			t.Errorf("%s scored live under a throttled list", b.Host)
+		}
+		locality[b.Host] = b.Locality
+	}
+	if locality["member-node"] <= locality["other-node"] {
+		t.Errorf("locality = %v, want member-node ahead from the seeded table", locality)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_activation_prewarm_ms_count 1") {
+		t.Error("warm-up time not reported")
+	}
+
+	// Disabled, activation does not pre-warm
+	s.cfg.ActivationPrewarm = false
+	if pw := s.startPrewarm(context.Background()); pw != nil {
+		t.Error("pre-warm started with ACTIVATION_PREWARM=false")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Eviction Protection
+===================
+Descheduler and rebalancer tools evict pods to even out node usage, which
+is exactly what undoes gang co-location right after a spike. With
+EVICTION_PROTECTION=true, NEXUS annotates running gang-member pods while
+their gang is active and removes the annotations when the gangs dissolve:
+
+  descheduler.alpha.kubernetes.io/prefer-no-eviction: "true"   (descheduler)
+  cluster-autoscaler.kubernetes.io/safe-to-evict:     "false"  (cluster-autoscaler)
+
+The set is configurable (EVICTION_PROTECTION_ANNOTATIONS). Pods that
+already carry one of the annotation keys keep their own value, and only
+the keys NEXUS added are removed again: they are recorded on the pod in
+nexus.io/eviction-protection, next to the nexus.io/eviction-protected=true
+label used to find protected pods on release.
+
+Protection is applied on activation and refreshed whenever the active
+window is extended, so replicas placed during the spike are covered too.
+Labels left behind by a restart outside a spike are cleared at startup.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/metrics"
+)
+
+const (
+	// labelEvictionProtected marks pods NEXUS has protected
+	labelEvictionProtected = "nexus.io/eviction-protected"
+
+	// annotationEvictionProtection lists the annotation keys NEXUS added
+	annotationEvictionProtection = "nexus.io/eviction-protection"
+)
+
+// EvictionProtector adds and removes eviction protection annotations on gang members
+type EvictionProtector struct {
+	clientset   kubernetes.Interface
+	apiGuard    *kube.APIGuard
+	podLister   *kube.PodLister
+	metrics     *metrics.NEXUSMetrics
+	annotations map[string]string
+}
+
+// NewEvictionProtector creates a protector applying the given annotations
+func NewEvictionProtector(clientset kubernetes.Interface, apiGuard *kube.APIGuard, podLister *kube.PodLister, metrics *metrics.NEXUSMetrics, annotations map[string]string) *EvictionProtector {
+	return &EvictionProtector{
+		clientset:   clientset,
+		apiGuard:    apiGuard,
+		podLister:   podLister,
+		metrics:     metrics,
+		annotations: annotations,
+	}
+}
+
+// Protect annotates running pods that belong to an active gang and are not
+// protected yet. It returns the number of pods newly protected.
+func (ep *EvictionProtector) Protect(ctx context.Context, gangs *gang.GangManager) int {
+	pods, _, err := ep.podLister.List(ctx, "list pods for eviction protection", metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Eviction protection: failed to list pods: %v", err)
+		return 0
+	}
+
+	protected := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Labels[labelEvictionProtected] == "true" || pod.DeletionTimestamp != nil ||
+			pod.Spec.NodeName == "" || gangs.GetGangForPod(pod) == nil {
+			continue
+		}
+
+		added := make([]string, 0, len(ep.annotations))
+		annotations := make(map[string]interface{}, len(ep.annotations)+1)
+		for key, value := range ep.annotations {
+			if _, exists := pod.Annotations[key]; exists {
+				continue // the pod owner's value wins
+			}
+			annotations[key] = value
+			added = append(added, key)
+		}
+		sort.Strings(added)
+		annotations[annotationEvictionProtection] = strings.Join(added, ",")
+
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":      map[string]interface{}{labelEvictionProtected: "true"},
+				"annotations": annotations,
+			},
+		}
+		if ep.patch(ctx, pod, patch) {
+			protected++
+			ep.metrics.IncrementCounter("pods_protected")
+		}
+	}
+
+	if protected > 0 {
+		klog.Infof("Eviction protection: protected %d gang member pods", protected)
+	}
+	return protected
+}
+
+// Release removes the protection from every pod NEXUS protected and
+// returns the number of pods released
+func (ep *EvictionProtector) Release(ctx context.Context) int {
+	pods, _, err := ep.podLister.List(ctx, "list protected pods", metav1.ListOptions{
+		LabelSelector: labelEvictionProtected + "=true",
+	})
+	if err != nil {
+		klog.Warningf("Eviction protection: failed to list protected pods: %v", err)
+		return 0
+	}
+
+	released := 0
+	for i := range pods {
+		pod := &pods[i]
+
+		// null removes a key in a JSON merge patch
+		annotations := map[string]interface{}{annotationEvictionProtection: nil}
+		for _, key := range strings.Split(pod.Annotations[annotationEvictionProtection], ",") {
+			if key != "" {
+				annotations[key] = nil
+			}
+		}
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":      map[string]interface{}{labelEvictionProtected: nil},
+				"annotations": annotations,
+			},
+		}
+		if ep.patch(ctx, pod, patch) {
+			released++
+		}
+	}
+
+	if released > 0 {
+		klog.Infof("Eviction protection: released %d pods", released)
+	}
+	return released
+}
+
+// patch applies a JSON merge patch to a pod through the API guard
+func (ep *EvictionProtector) patch(ctx context.Context, pod *v1.Pod, patch map[string]interface{}) bool {
+	body, err := json.Marshal(patch)
+	if err != nil {
+		klog.Errorf("Eviction protection: failed to encode patch for %s/%s: %v", pod.Namespace, pod.Name, err)
+		return false
+	}
+
+	err = ep.apiGuard.Do(ctx, "patch pod eviction protection", func(ctx context.Context) error {
+		_, err := ep.clientset.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, body, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Warningf("Eviction protection: failed to patch %s/%s: %v", pod.Namespace, pod.Name, err)
+		return false
+	}
+	return true
+}
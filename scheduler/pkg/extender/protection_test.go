@@ -0,0 +1,85 @@
+package extender
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+const (
+	deschedulerAnnotation = "descheduler.alpha.kubernetes.io/prefer-no-eviction"
+	autoscalerAnnotation  = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+)
+
+// runningPod returns a pod scheduled on node-1 with the given annotations
+func runningPod(name string, annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		Spec:       v1.PodSpec{NodeName: "node-1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestEvictionProtectionLifecycle(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+
+	clientset := fake.NewSimpleClientset(
+		runningPod("cartservice-6d5c7b8f9-abcde", nil),
+		runningPod("checkoutservice-7d9f8c6b5-x2k4p", map[string]string{autoscalerAnnotation: "true"}),
+		runningPod("frontend-5f6d7c8b9-qwert", nil),
+	)
+	lister := kube.NewPodLister(clientset, s.apiGuard, s.metrics, s.cfg)
+	protector := NewEvictionProtector(clientset, s.apiGuard, lister, s.metrics, map[string]string{
+		deschedulerAnnotation: "true",
+		autoscalerAnnotation:  "false",
+	})
+
+	ctx := context.Background()
+	get := func(name string) *v1.Pod {
+		pod, err := clientset.CoreV1().Pods("default").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod
+	}
+
+	if got := protector.Protect(ctx, s.gangManager); got != 2 {
+		t.Fatalf("Protect protected %d pods, want the 2 checkout-flow members", got)
+	}
+	if again := protector.Protect(ctx, s.gangManager); again != 0 {
+		t.Errorf("second Protect protected %d pods, want 0 (already protected)", again)
+	}
+
+	cart := get("cartservice-6d5c7b8f9-abcde")
+	if cart.Annotations[deschedulerAnnotation] != "true" || cart.Annotations[autoscalerAnnotation] != "false" {
+		t.Errorf("cartservice annotations = %v, want both protection annotations", cart.Annotations)
+	}
+
+	// The owner's own safe-to-evict value is kept
+	checkout := get("checkoutservice-7d9f8c6b5-x2k4p")
+	if checkout.Annotations[autoscalerAnnotation] != "true" {
+		t.Errorf("checkoutservice %s = %q, want the owner's value kept", autoscalerAnnotation, checkout.Annotations[autoscalerAnnotation])
+	}
+
+	if frontend := get("frontend-5f6d7c8b9-qwert"); len(frontend.Annotations) != 0 || len(frontend.Labels) != 0 {
+		t.Errorf("non-member frontend was modified: %v %v", frontend.Labels, frontend.Annotations)
+	}
+
+	if got := protector.Release(ctx); got != 2 {
+		t.Fatalf("Release released %d pods, want 2", got)
+	}
+
+	cart = get("cartservice-6d5c7b8f9-abcde")
+	if len(cart.Annotations) != 0 || cart.Labels[labelEvictionProtected] != "" {
+		t.Errorf("cartservice still protected after release: %v %v", cart.Labels, cart.Annotations)
+	}
+	checkout = get("checkoutservice-7d9f8c6b5-x2k4p")
+	if checkout.Annotations[autoscalerAnnotation] != "true" || checkout.Annotations[deschedulerAnnotation] != "" {
+		t.Errorf("checkoutservice annotations after release = %v, want only the owner's value", checkout.Annotations)
+	}
+}
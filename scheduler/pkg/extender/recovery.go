@@ -0,0 +1,237 @@
+/*
+Activation State Recovery
+=========================
+Persists a minimal activation record while NEXUS is ACTIVE so that a
+restart in the middle of a spike does not come back IDLE and drop the
+gang context.
+
+The record lives in a ConfigMap in the NEXUS namespace:
+  - Written when a spike activates NEXUS (and refreshed on extension)
+  - Deleted when gangs are dissolved and NEXUS returns to IDLE
+  - Read once at startup; if present and recent, gangs are rebuilt
+    immediately from the recorded groups
+
+Only the groups are stored — node preferences and per-pod state are
+recomputed from the cluster, keeping the "ephemeral gang" guarantee.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+)
+
+// activationRecordKey is the ConfigMap data key holding the JSON record
+const activationRecordKey = "activation.json"
+
+// ActivationRecord is the minimal state needed to resume a spike episode
+type ActivationRecord struct {
+	EpisodeID     string               `json:"episodeId"`
+	ActivatedAt   time.Time            `json:"activatedAt"`
+	LastSpikeTime time.Time            `json:"lastSpikeTime"`
+	SpikeClass    detector.SpikeClass  `json:"spikeClass,omitempty"`
+	Trigger       *detector.Trigger    `json:"trigger,omitempty"`   // signal that caused the activation
+	Level         ActivationLevel      `json:"level,omitempty"`     // "" = written before activation levels
+	Formation     string               `json:"formation,omitempty"` // gang formation strategy ("" = per-group)
+	Groups        []graph.RuntimeGroup `json:"groups"`
+}
+
+// StateStore saves and loads the activation record from a ConfigMap
+type StateStore struct {
+	clientset kubernetes.Interface
+	apiGuard  *kube.APIGuard
+	namespace string
+	name      string
+}
+
+// NewStateStore creates a ConfigMap-backed activation state store
+func NewStateStore(clientset kubernetes.Interface, apiGuard *kube.APIGuard, cfg *config.Config) *StateStore {
+	return &StateStore{
+		clientset: clientset,
+		apiGuard:  apiGuard,
+		namespace: cfg.Namespace,
+		name:      cfg.StateConfigMap,
+	}
+}
+
+// Save creates or updates the activation record
+func (st *StateStore) Save(ctx context.Context, record *ActivationRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode activation record: %w", err)
+	}
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      st.name,
+			Namespace: st.namespace,
+			Labels:    map[string]string{"app": schedulerName},
+		},
+		Data: map[string]string{activationRecordKey: string(data)},
+	}
+
+	return st.apiGuard.Do(ctx, "save activation record", func(ctx context.Context) error {
+		configMaps := st.clientset.CoreV1().ConfigMaps(st.namespace)
+		_, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		}
+		return err
+	})
+}
+
+// Load returns the stored activation record, or nil if none exists
+func (st *StateStore) Load(ctx context.Context) (*ActivationRecord, error) {
+	var cm *v1.ConfigMap
+	err := st.apiGuard.Do(ctx, "load activation record", func(ctx context.Context) error {
+		var err error
+		cm, err = st.clientset.CoreV1().ConfigMaps(st.namespace).Get(ctx, st.name, metav1.GetOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := cm.Data[activationRecordKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var record ActivationRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("failed to decode activation record: %w", err)
+	}
+	return &record, nil
+}
+
+// Clear deletes the activation record (no-op if it does not exist)
+func (st *StateStore) Clear(ctx context.Context) error {
+	err := st.apiGuard.Do(ctx, "clear activation record", func(ctx context.Context) error {
+		return st.clientset.CoreV1().ConfigMaps(st.namespace).Delete(ctx, st.name, metav1.DeleteOptions{})
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// newEpisodeID returns a unique identifier for a spike episode; nanosecond
+// resolution keeps an activation in the same second as the previous
+// episode's end from reusing its ID
+func newEpisodeID(start time.Time) string {
+	return fmt.Sprintf("episode-%d", start.UnixNano())
+}
+
+// --- Scheduler integration ---
+
+// persistActivation writes the current episode to the state store
+func (s *NEXUSScheduler) persistActivation(ctx context.Context) {
+	if s.stateStore == nil {
+		return
+	}
+
+	record := &ActivationRecord{
+		EpisodeID:     s.EpisodeID(),
+		ActivatedAt:   s.ActivatedAt(),
+		LastSpikeTime: s.lastSpikeTime,
+		SpikeClass:    s.SpikeClass(),
+		Trigger:       s.SpikeTrigger(),
+		Level:         s.ActivationLevel(),
+		Formation:     s.FormationStrategy(),
+		Groups:        s.depGraph.GetGroups(),
+	}
+	if err := s.stateStore.Save(ctx, record); err != nil {
+		klog.Warningf("Failed to persist activation record: %v", err)
+	}
+}
+
+// clearActivation removes the persisted episode after dissolution
+func (s *NEXUSScheduler) clearActivation(ctx context.Context) {
+	if s.stateStore == nil {
+		return
+	}
+	if err := s.stateStore.Clear(ctx); err != nil {
+		klog.Warningf("Failed to clear activation record: %v", err)
+	}
+}
+
+// RecoverState restores an in-flight episode after a restart.
+// Records older than maxAge are discarded instead of resurrected.
+//
+// Eviction protection, member labels, affinity hints and PodGroups left
+// by a previous instance are released unless the episode is resumed.
+func (s *NEXUSScheduler) RecoverState(ctx context.Context, maxAge time.Duration) {
+	defer func() {
+		if s.GetState() == StateIdle {
+			s.releaseGangMembers(ctx)
+			s.unlabelGangMembers(ctx)
+			s.releaseAffinityHints(ctx)
+			s.deletePodGroups(ctx)
+		}
+	}()
+
+	if s.stateStore == nil {
+		return
+	}
+
+	record, err := s.stateStore.Load(ctx)
+	if err != nil {
+		klog.Warningf("Failed to load activation record, starting IDLE: %v", err)
+		return
+	}
+	if record == nil {
+		return
+	}
+
+	if time.Since(record.LastSpikeTime) > maxAge {
+		klog.Infof("Discarding stale activation record %s (last spike %v ago)",
+			record.EpisodeID, time.Since(record.LastSpikeTime).Round(time.Second))
+		s.clearActivation(ctx)
+		return
+	}
+
+	klog.Info("═══════════════════════════════════════════")
+	klog.Infof("  RECOVERING EPISODE %s — Rebuilding gangs", record.EpisodeID)
+	klog.Info("═══════════════════════════════════════════")
+
+	// The recovered episode re-enters the lifecycle at detection
+	s.gangManager.SetStage(gang.GangStageDetected)
+	s.depGraph.Restore(record.Groups)
+	s.gangManager.SetStage(gang.GangStageGraphBuilt)
+	s.formGangs(ctx, record.Groups, record.Formation)
+
+	s.startEpisode(record.EpisodeID, record.ActivatedAt)
+	if record.SpikeClass == detector.SpikeClassNone {
+		record.SpikeClass = detector.SpikeClassTraffic // written before spike classes
+	}
+	s.setSpikeClass(record.SpikeClass)
+	s.setSpikeTrigger(record.Trigger)
+	s.startSweepEpisode()
+	s.lastSpikeTime = record.LastSpikeTime
+	s.SetState(StateActive)
+	if record.Level == LevelNone {
+		record.Level = LevelEnforcing // written before activation levels
+	}
+	s.setActivationLevel(record.Level)
+	s.metrics.IncrementCounter("state_recoveries")
+
+	klog.Infof("Episode %s recovered (gangs: %d)", record.EpisodeID, s.gangManager.GetActiveGangCount())
+}
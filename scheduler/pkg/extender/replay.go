@@ -0,0 +1,203 @@
+/*
+Activation Replay
+=================
+`nexus-scheduler replay` runs a recorded time window of the spike
+signals through the detector and the activation state machine, offline,
+and reports when NEXUS would have activated and gone IDLE again — for
+tuning thresholds against recorded incidents without a cluster:
+
+  nexus-scheduler replay -start 2026-10-01T09:00:00Z -end 2026-10-01T12:00:00Z
+  SPIKE_QPS_THRESHOLD=800 nexus-scheduler replay -start ... -step 30s -speed 60
+
+The signals come from PROMETHEUS_URL's range API (pkg/detector/replay.go)
+and are classified with the thresholds, THRESHOLD_PROFILES and
+SPIKE_ACTIVATION_EXPR of the environment, as the scheduler would. Every
+step is one spike check:
+
+  IDLE      → ACTIVE on a spike
+  ACTIVE    → once -cooldown has passed since the last spike, a spike
+              extends the window; a quiet check drains (-drain > 0) or
+              goes IDLE
+  DRAINING  → ACTIVE again on a spike, IDLE once -drain has passed
+
+-speed paces the replay at that multiple of real time (0 = as fast as
+possible). The activation back-off (FLAP_MAX_ACTIVATIONS), HPA
+stabilization windows and KEDA triggers are not replayed; an episode
+still open at -end counts until -end.
+*/
+
+package extender
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+)
+
+// Replayed state changes
+const (
+	replayActivate   = "activate"
+	replayDrain      = "drain"
+	replayReactivate = "reactivate"
+	replayDeactivate = "deactivate"
+)
+
+// replayEvent is one state change of a replayed window
+type replayEvent struct {
+	At    time.Time
+	Event string
+	Class detector.SpikeClass // class of the spike that caused it ("" = none)
+	Cause *detector.Trigger   // most severe trigger (nil = none)
+}
+
+// replayer runs samples through the activation state machine
+type replayer struct {
+	classify func(detector.Sample) (detector.SpikeClass, detector.Observation)
+	cooldown time.Duration
+	drain    time.Duration
+	speed    float64   // multiple of real time (0 = no pacing)
+	out      io.Writer // events as they happen (nil = none)
+}
+
+// replaySummary is the outcome of a replayed window
+type replaySummary struct {
+	Events      []replayEvent
+	Activations int
+	Active      time.Duration // time spent ACTIVE or DRAINING
+	OpenAtEnd   bool
+}
+
+// RunReplay implements the replay subcommand and returns the exit code
+func RunReplay(args []string) int {
+	silenceLogs()
+	cfg := config.LoadConfig()
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	startFlag := fs.String("start", "", "start of the window (RFC3339, required)")
+	endFlag := fs.String("end", "", "end of the window (RFC3339, default now)")
+	step := fs.Duration("step", spikeCheckInterval, "interval between replayed spike checks")
+	cooldown := fs.Duration("cooldown", cooldownDuration, "quiet time before the cooldown check")
+	drain := fs.Duration("drain", cfg.DrainDuration, "drain period before returning to IDLE")
+	speed := fs.Float64("speed", 0, "multiple of real time to replay at (0 = as fast as possible)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	start, err := time.Parse(time.RFC3339, *startFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -start: %v\n", err)
+		return 2
+	}
+	end := time.Now()
+	if *endFlag != "" {
+		if end, err = time.Parse(time.RFC3339, *endFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -end: %v\n", err)
+			return 2
+		}
+	}
+	if *step <= 0 || *cooldown < 0 || *drain < 0 || *speed < 0 {
+		fmt.Fprintln(os.Stderr, "-step must be positive; -cooldown, -drain and -speed must not be negative")
+		return 2
+	}
+
+	sd := detector.NewSpikeDetector()
+	samples, err := sd.QueryRange(context.Background(), start, end, *step)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read the window: %v\n", err)
+		return 1
+	}
+
+	r := &replayer{classify: sd.ClassifySample, cooldown: *cooldown, drain: *drain, speed: *speed, out: os.Stdout}
+	fmt.Printf("Replaying %d checks from %s to %s (step %v, cooldown %v, drain %v)\n\n",
+		len(samples), start.Format(time.RFC3339), end.Format(time.RFC3339), *step, *cooldown, *drain)
+	summary := r.run(samples)
+
+	open := ""
+	if summary.OpenAtEnd {
+		open = ", the last still open"
+	}
+	fmt.Printf("\n%d activation(s)%s, active for %v of %v\n", summary.Activations, open, summary.Active, end.Sub(start))
+	return 0
+}
+
+// run replays the samples in order and returns what happened
+func (r *replayer) run(samples []detector.Sample) replaySummary {
+	var summary replaySummary
+	state := StateIdle
+	var lastSpike, drainStart, activeSince time.Time
+
+	emit := func(at time.Time, event string, class detector.SpikeClass, obs detector.Observation) {
+		e := replayEvent{At: at, Event: event, Class: class}
+		if cause, ok := obs.Cause(); ok {
+			e.Cause = &cause
+		}
+		summary.Events = append(summary.Events, e)
+		if r.out != nil {
+			fmt.Fprintln(r.out, formatReplayEvent(e))
+		}
+	}
+	deactivate := func(at time.Time, obs detector.Observation) {
+		state = StateIdle
+		summary.Active += at.Sub(activeSince)
+		emit(at, replayDeactivate, detector.SpikeClassNone, obs)
+	}
+
+	for i, sample := range samples {
+		if i > 0 && r.speed > 0 {
+			time.Sleep(time.Duration(float64(sample.At.Sub(samples[i-1].At)) / r.speed))
+		}
+		class, obs := r.classify(sample)
+		at := sample.At
+
+		switch state {
+		case StateIdle:
+			if class != detector.SpikeClassNone {
+				state, lastSpike, activeSince = StateActive, at, at
+				summary.Activations++
+				emit(at, replayActivate, class, obs)
+			}
+		case StateActive:
+			if at.Sub(lastSpike) <= r.cooldown {
+				continue
+			}
+			switch {
+			case class != detector.SpikeClassNone:
+				lastSpike = at
+			case r.drain > 0:
+				state, drainStart = StateDraining, at
+				emit(at, replayDrain, class, obs)
+			default:
+				deactivate(at, obs)
+			}
+		case StateDraining:
+			switch {
+			case class != detector.SpikeClassNone:
+				state, lastSpike = StateActive, at
+				emit(at, replayReactivate, class, obs)
+			case at.Sub(drainStart) > r.drain:
+				deactivate(at, obs)
+			}
+		}
+	}
+
+	if state != StateIdle && len(samples) > 0 {
+		summary.OpenAtEnd = true
+		summary.Active += samples[len(samples)-1].At.Sub(activeSince)
+	}
+	return summary
+}
+
+// formatReplayEvent renders one event line: time, event, class, cause
+func formatReplayEvent(e replayEvent) string {
+	line := fmt.Sprintf("%s  %-10s  %-7s", e.At.Format(time.RFC3339), e.Event, dashIfEmpty(string(e.Class)))
+	if e.Cause != nil {
+		line += fmt.Sprintf("  %s %.2f > %.2f", e.Cause.Signal, e.Cause.Value, e.Cause.Threshold)
+	}
+	return strings.TrimRight(line, " ")
+}
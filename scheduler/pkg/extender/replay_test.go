@@ -0,0 +1,112 @@
+package extender
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/promtest"
+)
+
+// replaySamples returns one sample every 10s with the given QPS values
+func replaySamples(start time.Time, qps ...float64) []detector.Sample {
+	samples := make([]detector.Sample, len(qps))
+	for i, v := range qps {
+		samples[i] = detector.Sample{At: start.Add(time.Duration(i) * 10 * time.Second), QPS: v}
+	}
+	return samples
+}
+
+// replayedEvents lists the event names of a summary
+func replayedEvents(summary replaySummary) []string {
+	events := make([]string, len(summary.Events))
+	for i, e := range summary.Events {
+		events[i] = e.Event
+	}
+	return events
+}
+
+func TestReplayActivations(t *testing.T) {
+	t.Setenv("SPIKE_QPS_THRESHOLD", "1000")
+	t.Setenv("THRESHOLD_PROFILES", "")
+	t.Setenv("SPIKE_ACTIVATION_EXPR", "")
+	sd := detector.NewSpikeDetector()
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	//                              0s   10s   20s  30s  40s  50s   60s  70s  80s  90s  100s
+	samples := replaySamples(start, 100, 1500, 100, 100, 100, 1500, 100, 100, 100, 100, 1200)
+	var out bytes.Buffer
+	r := &replayer{classify: sd.ClassifySample, cooldown: 30 * time.Second, out: &out}
+	summary := r.run(samples)
+
+	// Active at 10s; quiet checks inside the cooldown are ignored, the
+	// spike at 50s extends the window, the quiet check at 90s ends it
+	want := []string{replayActivate, replayDeactivate, replayActivate}
+	if got := replayedEvents(summary); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if at := summary.Events[1].At; !at.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("deactivated at %v, want 90s", at.Sub(start))
+	}
+	cause := summary.Events[0].Cause
+	if cause == nil || cause.Signal != detector.SignalQPS || cause.Value != 1500 || summary.Events[0].Class != detector.SpikeClassTraffic {
+		t.Errorf("activation = %+v (cause %+v), want traffic caused by qps 1500", summary.Events[0], cause)
+	}
+	if summary.Activations != 2 || !summary.OpenAtEnd || summary.Active != 80*time.Second {
+		t.Errorf("summary = %d activations, open %v, active %v; want 2, open, 80s", summary.Activations, summary.OpenAtEnd, summary.Active)
+	}
+	if !strings.Contains(out.String(), "qps 1500.00 > 1000.00") {
+		t.Errorf("output does not show the cause:\n%s", out.String())
+	}
+}
+
+func TestReplayDrain(t *testing.T) {
+	t.Setenv("SPIKE_QPS_THRESHOLD", "1000")
+	t.Setenv("THRESHOLD_PROFILES", "")
+	t.Setenv("SPIKE_ACTIVATION_EXPR", "")
+	sd := detector.NewSpikeDetector()
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	samples := replaySamples(start, 1500, 100, 100, 1500, 100, 100, 100, 100)
+	r := &replayer{classify: sd.ClassifySample, cooldown: 5 * time.Second, drain: 20 * time.Second}
+	summary := r.run(samples)
+
+	// Drain at 10s, a spike at 30s reactivates, drain again at 40s and
+	// IDLE once the 20s drain has passed (70s)
+	want := []string{replayActivate, replayDrain, replayReactivate, replayDrain, replayDeactivate}
+	if got := replayedEvents(summary); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if summary.Activations != 1 || summary.OpenAtEnd || summary.Active != 70*time.Second {
+		t.Errorf("summary = %d activations, open %v, active %v; want 1, closed, 70s", summary.Activations, summary.OpenAtEnd, summary.Active)
+	}
+}
+
+func TestRunReplay(t *testing.T) {
+	fake := promtest.NewServer()
+	t.Cleanup(fake.Close)
+	for _, query := range detector.SignalQueries() {
+		fake.Set(query, 0)
+	}
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	fake.SetRange(detector.SignalQueries()[detector.SignalErrors], []promtest.Point{{At: start.Add(10 * time.Second), Value: 80}})
+	t.Setenv("PROMETHEUS_URL", fake.URL)
+	t.Setenv("SPIKE_SOURCE", "")
+	t.Setenv("THRESHOLD_PROFILES", "")
+	t.Setenv("SPIKE_ACTIVATION_EXPR", "")
+
+	args := []string{"-start", start.Format(time.RFC3339), "-end", start.Add(time.Minute).Format(time.RFC3339)}
+	if code := RunReplay(args); code != 0 {
+		t.Errorf("RunReplay = %d, want 0", code)
+	}
+	if code := RunReplay([]string{"-end", start.Format(time.RFC3339)}); code != 2 {
+		t.Errorf("RunReplay without -start = %d, want 2", code)
+	}
+	fake.SetDown(true)
+	if code := RunReplay(args); code != 1 {
+		t.Errorf("RunReplay against a down Prometheus = %d, want 1", code)
+	}
+}
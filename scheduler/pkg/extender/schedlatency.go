@@ -0,0 +1,219 @@
+/*
+End-to-End Scheduling Latency
+=============================
+The extender's own histograms only cover the time NEXUS spends inside
+Filter and Prioritize. The experiment's headline result is the time a
+gang-member pod waits from creation until kube-scheduler binds it, with
+and without NEXUS active. With SCHEDULING_LATENCY_WATCH=true the extender
+watches kube-scheduler's Scheduled events and records, for every pod of a
+gang-member service (a service with a gang, or in a group of the
+application profile):
+
+  creation → binding   event time of the Scheduled event minus the pod's
+                       creationTimestamp
+
+in nexus_pod_scheduling_latency_ms{state}, labelled with the scheduler
+state at binding (IDLE, ACTIVE, DRAINING), so the IDLE series is the
+baseline of the same cluster. Pods bound during an episode also feed the
+episode's schedulingLatency quantiles in /episodes.
+
+Only events received after the watch started are counted; each pod is
+counted once. The creation time is read with one GET per gang-member
+pod, so non-member pods cost nothing beyond the event.
+*/
+
+package extender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/metrics"
+)
+
+const (
+	// scheduledEventSelector selects kube-scheduler's binding events of pods
+	scheduledEventSelector = "reason=Scheduled,involvedObject.kind=Pod"
+
+	// schedulingLatencySeen bounds the pod UIDs remembered for de-duplication
+	schedulingLatencySeen = 10000
+)
+
+// schedulingLatencyState holds the de-duplication set and the running
+// episode's latency histogram
+type schedulingLatencyState struct {
+	mu      sync.Mutex
+	seen    map[types.UID]bool
+	episode string
+	latency *metrics.LatencyHistogram
+}
+
+// WatchSchedulingLatency watches Scheduled events until ctx is done,
+// re-listing whenever the watch ends (returns immediately with
+// SCHEDULING_LATENCY_WATCH=false)
+func (s *NEXUSScheduler) WatchSchedulingLatency(ctx context.Context) {
+	if !s.cfg.SchedulingLatencyWatch {
+		return
+	}
+	klog.Info("Scheduling latency: watching kube-scheduler Scheduled events")
+	since := time.Now()
+	for ctx.Err() == nil {
+		err := s.applyScheduledEvents(ctx, since)
+		if ctx.Err() != nil {
+			return
+		}
+		klog.Warningf("Scheduled event watch ended (%v), re-listing in %v", err, memberCacheRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(memberCacheRetry):
+		}
+	}
+}
+
+// applyScheduledEvents lists the Scheduled events, then watches them until
+// the watch ends; events from before since are ignored
+func (s *NEXUSScheduler) applyScheduledEvents(ctx context.Context, since time.Time) error {
+	opts := metav1.ListOptions{FieldSelector: scheduledEventSelector}
+	var events *v1.EventList
+	err := s.apiGuard.Do(ctx, "list scheduled events", func(ctx context.Context) error {
+		var err error
+		events, err = s.clientset.CoreV1().Events(metav1.NamespaceAll).List(ctx, opts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for i := range events.Items {
+		s.observeScheduled(ctx, &events.Items[i], since)
+	}
+
+	var watcher watch.Interface
+	opts.ResourceVersion = events.ResourceVersion
+	err = s.apiGuard.Do(ctx, "watch scheduled events", func(ctx context.Context) error {
+		var err error
+		watcher, err = s.clientset.CoreV1().Events(metav1.NamespaceAll).Watch(ctx, opts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			return fmt.Errorf("watch error: %v", event.Object)
+		}
+		if e, ok := event.Object.(*v1.Event); ok && event.Type != watch.Deleted {
+			s.observeScheduled(ctx, e, since)
+		}
+	}
+	return nil
+}
+
+// observeScheduled records the scheduling latency of the pod a Scheduled
+// event reports, if it is a gang member seen for the first time
+func (s *NEXUSScheduler) observeScheduled(ctx context.Context, e *v1.Event, since time.Time) {
+	if e.Reason != "Scheduled" || e.InvolvedObject.Kind != "Pod" {
+		return
+	}
+	bound := scheduledTime(e)
+	if bound.Before(since) || !s.memberService(graph.ExtractServiceName(e.InvolvedObject.Name)) {
+		return
+	}
+	if !s.firstScheduled(e.InvolvedObject.UID) {
+		return
+	}
+
+	var pod *v1.Pod
+	err := s.apiGuard.Do(ctx, "get scheduled pod", func(ctx context.Context) error {
+		var err error
+		pod, err = s.clientset.CoreV1().Pods(e.InvolvedObject.Namespace).Get(ctx, e.InvolvedObject.Name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		klog.V(2).Infof("Scheduling latency: skipping %s/%s: %v", e.InvolvedObject.Namespace, e.InvolvedObject.Name, err)
+		return
+	}
+	if e.InvolvedObject.UID != "" && pod.UID != e.InvolvedObject.UID {
+		return // replaced by a pod of the same name
+	}
+	ms := float64(bound.Sub(pod.CreationTimestamp.Time).Nanoseconds()) / 1e6
+	if ms < 0 {
+		ms = 0 // creation and event timestamps come from different clocks
+	}
+	s.recordSchedulingLatency(s.GetState(), ms)
+}
+
+// recordSchedulingLatency records one pod's latency under state, and in
+// the running episode's quantiles outside IDLE
+func (s *NEXUSScheduler) recordSchedulingLatency(state SchedulerState, ms float64) {
+	s.metrics.ObserveSchedulingLatency(state.String(), ms)
+	if state == StateIdle {
+		return
+	}
+
+	episode := s.EpisodeID()
+	s.schedLatency.mu.Lock()
+	if s.schedLatency.latency == nil || s.schedLatency.episode != episode {
+		s.schedLatency.episode = episode
+		s.schedLatency.latency = metrics.NewLatencyHistogram("nexus_episode_scheduling_latency_ms", "", metrics.SchedulingLatencyBuckets)
+	}
+	latency := s.schedLatency.latency
+	s.schedLatency.mu.Unlock()
+
+	latency.Observe(ms)
+	summary := latency.Quantiles()
+	s.updateEpisode(func(ep *EpisodeRecord) {
+		if ep.ID == episode {
+			ep.SchedulingLatency = &summary
+		}
+	})
+}
+
+// firstScheduled reports whether the pod's binding was not counted yet
+func (s *NEXUSScheduler) firstScheduled(uid types.UID) bool {
+	s.schedLatency.mu.Lock()
+	defer s.schedLatency.mu.Unlock()
+	if s.schedLatency.seen[uid] {
+		return false
+	}
+	if s.schedLatency.seen == nil || len(s.schedLatency.seen) >= schedulingLatencySeen {
+		s.schedLatency.seen = make(map[types.UID]bool)
+	}
+	s.schedLatency.seen[uid] = true
+	return true
+}
+
+// memberService reports whether a service's pods are gang members: it has
+// a gang now, or belongs to a group of the application profile
+func (s *NEXUSScheduler) memberService(service string) bool {
+	if s.gangManager.GetGangForService(service) != nil {
+		return true
+	}
+	profile := graph.ProfileService(service)
+	return profile != nil && profile.Group != ""
+}
+
+// scheduledTime is when a Scheduled event reports the binding
+func scheduledTime(e *v1.Event) time.Time {
+	switch {
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
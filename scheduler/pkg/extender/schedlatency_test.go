@@ -0,0 +1,70 @@
+package extender
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSchedulingLatencyFromScheduledEvents(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.startEpisode("ep-1", time.Now())
+	created := time.Now().Add(-2 * time.Second)
+	for _, name := range []string{"cartservice-6d5c7b8f9-abcde", "adservice-5f6d7c8b9-fghij"} {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", UID: types.UID("uid-" + name), CreationTimestamp: metav1.NewTime(created),
+		}}
+		if _, err := s.clientset.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scheduled := func(name string, at time.Time) *v1.Event {
+		return &v1.Event{
+			Reason:         "Scheduled",
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: name, UID: types.UID("uid-" + name)},
+			EventTime:      metav1.NewMicroTime(at),
+		}
+	}
+	since := created
+
+	bound := created.Add(1500 * time.Millisecond)
+	s.observeScheduled(context.Background(), scheduled("cartservice-6d5c7b8f9-abcde", bound), since)
+	s.observeScheduled(context.Background(), scheduled("cartservice-6d5c7b8f9-abcde", bound), since) // updated event
+	s.observeScheduled(context.Background(), scheduled("adservice-5f6d7c8b9-fghij", bound), since)   // not a gang member
+	s.observeScheduled(context.Background(), scheduled("cartservice-6d5c7b8f9-zzzzz", created.Add(-time.Second)), since)
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	body := out.Body.String()
+	for _, want := range []string{
+		`nexus_pod_scheduling_latency_ms_bucket{state="ACTIVE",le="1000"} 0`,
+		`nexus_pod_scheduling_latency_ms_bucket{state="ACTIVE",le="2500"} 1`,
+		`nexus_pod_scheduling_latency_ms_count{state="ACTIVE"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+
+	latency := s.Episodes()[0].SchedulingLatency
+	if latency == nil || latency.Samples != 1 || latency.P50 != 1500 {
+		t.Errorf("episode scheduling latency = %+v, want one 1500ms sample", latency)
+	}
+
+	// Baseline outside episodes
+	s.recordSchedulingLatency(StateIdle, 200)
+	out = httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), `nexus_pod_scheduling_latency_ms_count{state="IDLE"} 1`) {
+		t.Error("IDLE latency not recorded")
+	}
+	if latency := s.Episodes()[0].SchedulingLatency; latency.Samples != 1 {
+		t.Errorf("IDLE latency counted in the episode: %+v", latency)
+	}
+}
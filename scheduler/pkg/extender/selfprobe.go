@@ -0,0 +1,229 @@
+/*
+Self-Probe
+==========
+/healthz only shows that the process answers; it does not show that
+kube-scheduler's Filter calls reach a working handler. With
+SELF_PROBE_INTERVAL set, NEXUS POSTs a synthetic Filter request to its
+own extender listener on every interval, the way kube-scheduler does:
+
+  pod   nexus-self-probe (POD_NAMESPACE), in no gang
+  nodes NodeNames ["nexus-self-probe"]
+
+A probe passes when the listener answers 200 with an ExtenderFilterResult
+that carries no error and keeps the probe node (the pod is in no gang, so
+every state answers no opinion). Failures are classed as unreachable (dial,
+TLS or timeout), http_error (a non-200 status, e.g. a mux without /filter)
+or invalid_response (an undecodable result, an error or a dropped node).
+
+The probe dials EXTENDER_ADDR over loopback (or its Unix socket), through
+the connection limits, gzip negotiation and handler chain of a real call.
+SELF_PROBE_URL replaces it with the urlPrefix kube-scheduler uses, e.g.
+https://nexus-scheduler.kube-system.svc:9099, to also cover the Service,
+its port mapping and TLS (the CA bundle is read from SSL_CERT_FILE).
+
+Probe calls are real Filter calls: they count in nexus_filter_calls_total
+and nexus_extender_filter_latency_ms like kube-scheduler's. The last result
+is reported in the selfProbe field of /healthz and /readyz, whose status
+becomes "degraded" (still 200: the process is alive and restarting it
+does not fix a Service) while the probe fails.
+*/
+
+package extender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// selfProbeName names the synthetic pod and node of the self-probe
+const selfProbeName = "nexus-self-probe"
+
+// Self-probe results
+const (
+	probeOK              = "ok"
+	probeUnreachable     = "unreachable"
+	probeHTTPError       = "http_error"
+	probeInvalidResponse = "invalid_response"
+)
+
+// selfProbeState holds the outcome of the last self-probe
+type selfProbeState struct {
+	mu                  sync.Mutex
+	enabled             bool
+	last                time.Time
+	result              string
+	err                 string
+	latency             time.Duration
+	consecutiveFailures int
+}
+
+// SelfProbeStatus is the self-probe detail of /healthz
+type SelfProbeStatus struct {
+	LastProbe           time.Time `json:"lastProbe"`
+	Result              string    `json:"result"`
+	Error               string    `json:"error,omitempty"`
+	LatencyMs           float64   `json:"latencyMs"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+}
+
+// RunSelfProbe probes the extender listener every SELF_PROBE_INTERVAL
+func (s *NEXUSScheduler) RunSelfProbe(ctx context.Context) {
+	if s.cfg.SelfProbeInterval <= 0 {
+		return
+	}
+	url, client, err := selfProbeTarget(s.cfg.ExtenderAddr, s.cfg.SelfProbeURL, s.cfg.SelfProbeTimeout)
+	if err != nil {
+		klog.Errorf("Self-probe disabled: %v", err)
+		return
+	}
+	defer client.CloseIdleConnections()
+	s.selfProbe.mu.Lock()
+	s.selfProbe.enabled = true
+	s.selfProbe.mu.Unlock()
+	klog.Infof("Self-probe: POST %s every %v", url, s.cfg.SelfProbeInterval)
+
+	ticker := time.NewTicker(s.cfg.SelfProbeInterval)
+	defer ticker.Stop()
+	for {
+		s.probeFilter(ctx, client, url)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeFilter sends one synthetic Filter request and records its outcome
+func (s *NEXUSScheduler) probeFilter(ctx context.Context, client *http.Client, url string) {
+	start := time.Now()
+	result, err := s.callFilter(ctx, client, url)
+	latency := time.Since(start)
+
+	s.metrics.RecordSelfProbe(result)
+	s.metrics.SelfProbeLatency.Observe(float64(latency.Microseconds()) / 1000)
+
+	s.selfProbe.mu.Lock()
+	defer s.selfProbe.mu.Unlock()
+	s.selfProbe.last = start
+	s.selfProbe.result = result
+	s.selfProbe.latency = latency
+	s.selfProbe.err = ""
+	if err == nil {
+		if s.selfProbe.consecutiveFailures > 0 {
+			klog.Infof("Self-probe: Filter answers again after %d failures", s.selfProbe.consecutiveFailures)
+		}
+		s.selfProbe.consecutiveFailures = 0
+		return
+	}
+	s.selfProbe.err = err.Error()
+	s.selfProbe.consecutiveFailures++
+	klog.Warningf("Self-probe failed (%s): %v", result, err)
+}
+
+// callFilter POSTs the synthetic Filter request and classifies the answer
+func (s *NEXUSScheduler) callFilter(ctx context.Context, client *http.Client, url string) (string, error) {
+	body, err := json.Marshal(selfProbeArgs(s.cfg.Namespace))
+	if err != nil {
+		return probeInvalidResponse, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return probeUnreachable, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return probeUnreachable, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return probeHTTPError, fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+
+	var result ExtenderFilterResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return probeInvalidResponse, fmt.Errorf("undecodable Filter result: %w", err)
+	}
+	if result.Error != "" {
+		return probeInvalidResponse, fmt.Errorf("filter returned an error: %s", result.Error)
+	}
+	if result.NodeNames == nil || len(*result.NodeNames) != 1 || (*result.NodeNames)[0] != selfProbeName {
+		return probeInvalidResponse, fmt.Errorf("filter dropped the probe node")
+	}
+	return probeOK, nil
+}
+
+// selfProbeArgs builds the synthetic Filter request of a pod in no gang
+func selfProbeArgs(namespace string) *ExtenderArgs {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &ExtenderArgs{
+		Pod: &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: selfProbeName, Namespace: namespace},
+		},
+		NodeNames: &[]string{selfProbeName},
+	}
+}
+
+// selfProbeTarget returns the Filter URL of the extender listener and the
+// client dialling it: SELF_PROBE_URL if set, else loopback to EXTENDER_ADDR
+func selfProbeTarget(addr, override string, timeout time.Duration) (string, *http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if override != "" {
+		return strings.TrimRight(override, "/") + "/filter", client, nil
+	}
+	if path, ok := unixSocketPath(addr); ok {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+		return "http://nexus/filter", client, nil
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid EXTENDER_ADDR %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/filter", client, nil
+}
+
+// selfProbeStatus returns the last self-probe outcome (nil when disabled)
+func (s *NEXUSScheduler) selfProbeStatus() *SelfProbeStatus {
+	s.selfProbe.mu.Lock()
+	defer s.selfProbe.mu.Unlock()
+	if !s.selfProbe.enabled {
+		return nil
+	}
+	status := &SelfProbeStatus{
+		LastProbe:           s.selfProbe.last,
+		Result:              s.selfProbe.result,
+		Error:               s.selfProbe.err,
+		LatencyMs:           float64(s.selfProbe.latency.Microseconds()) / 1000,
+		ConsecutiveFailures: s.selfProbe.consecutiveFailures,
+	}
+	if status.Result == "" {
+		status.Result = "pending"
+	}
+	return status
+}
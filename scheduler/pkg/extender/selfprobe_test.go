@@ -0,0 +1,91 @@
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfProbe(t *testing.T) {
+	s := newTestScheduler(t, StateActive)
+	s.selfProbe.enabled = true
+	health := func() map[string]interface{} {
+		rec := httptest.NewRecorder()
+		s.HealthHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("/healthz status = %d, want 200", rec.Code)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	if probe := health()["selfProbe"].(map[string]interface{}); probe["result"] != "pending" {
+		t.Errorf("selfProbe before the first probe = %v", probe)
+	}
+
+	cfg := *s.cfg
+	extender := httptest.NewServer(s.NewServers(&cfg)[0].Handler)
+	defer extender.Close()
+	client := &http.Client{Timeout: time.Second}
+	s.probeFilter(context.Background(), client, extender.URL+"/filter")
+	if body := health(); body["status"] != "healthy" || body["selfProbe"].(map[string]interface{})["result"] != probeOK {
+		t.Errorf("/healthz after a passing probe = %v", body)
+	}
+
+	// A mux without the extender endpoints
+	healthOnly := http.NewServeMux()
+	s.RegisterHealthHandlers(healthOnly)
+	misrouted := httptest.NewServer(healthOnly)
+	defer misrouted.Close()
+	s.probeFilter(context.Background(), client, misrouted.URL+"/filter")
+	body := health()
+	probe := body["selfProbe"].(map[string]interface{})
+	if body["status"] != "degraded" || probe["result"] != probeHTTPError || probe["consecutiveFailures"] != 1.0 {
+		t.Errorf("/healthz after a 404 = %v", body)
+	}
+
+	// A listener nobody serves
+	misrouted.Close()
+	s.probeFilter(context.Background(), client, misrouted.URL+"/filter")
+	if probe := health()["selfProbe"].(map[string]interface{}); probe["result"] != probeUnreachable || probe["consecutiveFailures"] != 2.0 {
+		t.Errorf("selfProbe after a refused dial = %v", probe)
+	}
+
+	rec := httptest.NewRecorder()
+	s.MetricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`nexus_self_probes_total{result="ok"} 1`,
+		`nexus_self_probes_total{result="http_error"} 1`,
+		`nexus_self_probes_total{result="unreachable"} 1`,
+		"nexus_self_probe_up 0",
+		"nexus_self_probe_latency_ms_count 3",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
+
+func TestSelfProbeTarget(t *testing.T) {
+	for _, tc := range []struct{ addr, override, want string }{
+		{":9099", "", "http://127.0.0.1:9099/filter"},
+		{"0.0.0.0:9099", "", "http://127.0.0.1:9099/filter"},
+		{"10.0.0.5:9099", "", "http://10.0.0.5:9099/filter"},
+		{"unix:///run/nexus/extender.sock", "", "http://nexus/filter"},
+		{":9099", "https://nexus-scheduler.kube-system.svc:9099/", "https://nexus-scheduler.kube-system.svc:9099/filter"},
+	} {
+		url, _, err := selfProbeTarget(tc.addr, tc.override, time.Second)
+		if err != nil || url != tc.want {
+			t.Errorf("selfProbeTarget(%q, %q) = %q, %v, want %q", tc.addr, tc.override, url, err, tc.want)
+		}
+	}
+	if _, _, err := selfProbeTarget("9099", "", time.Second); err == nil {
+		t.Error("selfProbeTarget accepted an address without a port")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+HTTP Listeners
+==============
+Scheduling-path traffic and observability/admin traffic are served by
+separate listeners, each with its own timeouts, so a slow metrics scrape
+or status poll can never hold a connection slot or goroutine the
+kube-scheduler Filter/Prioritize calls are waiting on:
+
+  EXTENDER_ADDR (:9099) → /filter, /prioritize, /healthz, /readyz
+  ADMIN_ADDR    (:9100) → /metrics, /status, /config, /version, /openapi, /simulate,
+                          /admin/*, /healthz, /readyz
+
+Setting both to the same address serves everything on one listener
+(the pre-split layout), using the extender timeouts. The extender
+listener's keep-alive and connection limits are in connections.go;
+either address can be a Unix socket (socket.go).
+*/
+
+package extender
+
+import (
+	"net/http"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// RegisterExtenderHandlers adds the kube-scheduler extender endpoints to mux
+func (s *NEXUSScheduler) RegisterExtenderHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/filter", s.withPayloadEncoding("filter", s.HandleFilter))
+	mux.HandleFunc("/prioritize", s.withPayloadEncoding("prioritize", s.HandlePrioritize))
+}
+
+// RegisterObservabilityHandlers adds metrics, status, config, history and admin endpoints to mux
+func (s *NEXUSScheduler) RegisterObservabilityHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/metrics", s.MetricsHandler)
+	mux.HandleFunc("/status", s.StatusHandler)
+	mux.HandleFunc("/config", s.ConfigHandler)
+	mux.HandleFunc("/sweep", s.SweepHandler)
+	mux.HandleFunc("/shadow", s.ShadowHandler)
+	mux.HandleFunc("/episodes", s.EpisodesHandler)
+	mux.HandleFunc("/decisions", s.DecisionsHandler)
+	mux.HandleFunc("/history/episodes", s.StoredEpisodesHandler)
+	mux.HandleFunc("/history/decisions", s.StoredDecisionsHandler)
+	mux.HandleFunc("/policies", s.PoliciesHandler)
+	mux.HandleFunc("/version", s.VersionHandler)
+	mux.HandleFunc("/openapi", s.OpenAPIHandler)
+	mux.HandleFunc("/simulate", s.SimulateHandler)
+	s.RegisterAdminHandlers(mux, s.cfg.AdminToken)
+}
+
+// RegisterHealthHandlers adds the liveness and readiness endpoints to mux
+func (s *NEXUSScheduler) RegisterHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", s.HealthHandler)
+	mux.HandleFunc("/readyz", s.HealthHandler)
+}
+
+// NewServers builds the extender and observability HTTP servers. When both
+// addresses are equal a single server carrying every endpoint is returned.
+func (s *NEXUSScheduler) NewServers(cfg *config.Config) []*http.Server {
+	extenderMux := http.NewServeMux()
+	s.RegisterExtenderHandlers(extenderMux)
+	s.RegisterHealthHandlers(extenderMux)
+
+	if cfg.AdminAddr == cfg.ExtenderAddr {
+		s.RegisterObservabilityHandlers(extenderMux)
+	}
+	extenderServer := newServer(cfg.ExtenderAddr, extenderMux, cfg.ExtenderReadTimeout, cfg.ExtenderWriteTimeout)
+	s.tuneExtenderServer(extenderServer, cfg)
+	if cfg.AdminAddr == cfg.ExtenderAddr {
+		return []*http.Server{extenderServer}
+	}
+
+	adminMux := http.NewServeMux()
+	s.RegisterObservabilityHandlers(adminMux)
+	s.RegisterHealthHandlers(adminMux)
+
+	return []*http.Server{
+		extenderServer,
+		newServer(cfg.AdminAddr, adminMux, cfg.AdminReadTimeout, cfg.AdminWriteTimeout),
+	}
+}
+
+// newServer returns an HTTP server with the given read/write timeouts
+func newServer(addr string, handler http.Handler, readTimeout, writeTimeout time.Duration) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+}
@@ -0,0 +1,64 @@
+package extender
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// statusOf returns the status code server answers for a GET of path
+func statusOf(server *http.Server, path string) int {
+	rec := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, strings.NewReader("{}")))
+	return rec.Code
+}
+
+func TestNewServersSplitsListeners(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	cfg := *s.cfg
+	cfg.ExtenderAddr, cfg.AdminAddr = ":9099", ":9100"
+
+	servers := s.NewServers(&cfg)
+	if len(servers) != 2 {
+		t.Fatalf("got %d servers, want 2", len(servers))
+	}
+	extenderServer, adminServer := servers[0], servers[1]
+
+	for _, path := range []string{"/metrics", "/status", "/config"} {
+		if code := statusOf(extenderServer, path); code != http.StatusNotFound {
+			t.Errorf("extender listener answered %s with %d, want 404", path, code)
+		}
+		if code := statusOf(adminServer, path); code != http.StatusOK {
+			t.Errorf("admin listener answered %s with %d, want 200", path, code)
+		}
+	}
+	if code := statusOf(adminServer, "/filter"); code != http.StatusNotFound {
+		t.Errorf("admin listener answered /filter with %d, want 404", code)
+	}
+	for _, server := range servers {
+		if code := statusOf(server, "/healthz"); code != http.StatusOK {
+			t.Errorf("%s answered /healthz with %d, want 200", server.Addr, code)
+		}
+	}
+	if extenderServer.WriteTimeout != cfg.ExtenderWriteTimeout || adminServer.WriteTimeout != cfg.AdminWriteTimeout {
+		t.Errorf("write timeouts = %v/%v, want %v/%v",
+			extenderServer.WriteTimeout, adminServer.WriteTimeout, cfg.ExtenderWriteTimeout, cfg.AdminWriteTimeout)
+	}
+}
+
+func TestNewServersSharedAddress(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	cfg := *s.cfg
+	cfg.ExtenderAddr, cfg.AdminAddr = ":9099", ":9099"
+
+	servers := s.NewServers(&cfg)
+	if len(servers) != 1 {
+		t.Fatalf("got %d servers, want 1", len(servers))
+	}
+	for _, path := range []string{"/filter", "/metrics", "/healthz"} {
+		if code := statusOf(servers[0], path); code != http.StatusOK {
+			t.Errorf("shared listener answered %s with %d, want 200", path, code)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Shadow Scorers
+==============
+Alternative scoring formulas evaluated on live traffic without affecting
+placement. SHADOW_SCORERS names one or more variants of the primary
+formula, each overriding some of its knobs:
+
+  SHADOW_SCORERS='{"sqrt": {"localityCurve": "sqrt"},
+                   "capped": {"localityMemberCap": 3, "localityWeight": 150}}'
+
+Overridable: localityWeight, localityCurve, localityMemberCap and
+utilizationWeight; everything else (topology levels, node budget, spike
+class policy, drain scale, group weights) is shared with the primary.
+
+Every Prioritize call NEXUS scores is scored again by each shadow after
+the primary's answer is computed, in the background, so shadows add no
+latency to the response. The node each shadow would have preferred is
+compared with the primary's preferred node; a decision diverges when the
+primary's node is not among the shadow's top-scored nodes.
+
+  GET /shadow → per-scorer decision and divergence counts, and the last
+                64 decisions with each shadow's choice
+
+Member counts come from the warm gang member cache when it is enabled;
+with GANG_MEMBER_CACHE=false every shadow lists the node's pods again.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// shadowHistorySize is the number of recent shadow decisions kept
+const shadowHistorySize = 64
+
+// shadowScorer is one named alternative formula
+type shadowScorer struct {
+	name   string
+	scorer *scorer.NodeScorer
+
+	decisions int
+	divergent int
+}
+
+// shadowScoring runs the shadow scorers and keeps their outcomes
+type shadowScoring struct {
+	scorers []*shadowScorer // sorted by name
+	wg      sync.WaitGroup  // in-flight evaluations
+
+	mu     sync.Mutex
+	recent []ShadowDecision // oldest first
+}
+
+// ShadowDecision compares the primary's preferred node with each shadow's
+type ShadowDecision struct {
+	ID        string            `json:"id"` // decision ID of the primary's response
+	Time      time.Time         `json:"time"`
+	Namespace string            `json:"namespace"`
+	Pod       string            `json:"pod"`
+	Gang      string            `json:"gang"`
+	Primary   string            `json:"primary"` // primary's preferred node
+	Shadows   map[string]string `json:"shadows"` // scorer → preferred node
+	Divergent []string          `json:"divergent,omitempty"`
+}
+
+// ShadowScorerStats summarises one shadow scorer
+type ShadowScorerStats struct {
+	Name           string  `json:"name"`
+	Decisions      int     `json:"decisions"`
+	Divergent      int     `json:"divergent"`
+	DivergenceRate float64 `json:"divergenceRate"`
+}
+
+// ShadowReport is the /shadow response
+type ShadowReport struct {
+	Enabled bool                `json:"enabled"`
+	Scorers []ShadowScorerStats `json:"scorers"`
+	Recent  []ShadowDecision    `json:"recent"` // newest first
+}
+
+// newShadowScoring builds one node scorer per configured shadow formula
+func newShadowScoring(gangManager *gang.GangManager, podLister *kube.PodLister, utilization *kube.UtilizationProvider, cfg *config.Config) *shadowScoring {
+	names := make([]string, 0, len(cfg.ShadowScorers))
+	for name := range cfg.ShadowScorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ss := &shadowScoring{}
+	for _, name := range names {
+		shadowCfg := cfg.WithShadowScorer(cfg.ShadowScorers[name])
+		ss.scorers = append(ss.scorers, &shadowScorer{
+			name:   name,
+			scorer: scorer.NewNodeScorer(gangManager, podLister, utilization, shadowCfg),
+		})
+	}
+	return ss
+}
+
+// scoreShadows evaluates the shadow scorers for a decision in the background
+func (s *NEXUSScheduler) scoreShadows(decisionID string, pod *v1.Pod, nodes *v1.NodeList, g *gang.Gang, locality scorer.Locality, priorities []HostPriority) {
+	if s.shadows == nil {
+		return
+	}
+	primary := preferredHost(priorities)
+
+	s.shadows.wg.Add(1)
+	go func() {
+		defer s.shadows.wg.Done()
+
+		decision := ShadowDecision{
+			ID:        decisionID,
+			Time:      time.Now(),
+			Namespace: pod.Namespace,
+			Pod:       pod.Name,
+			Gang:      g.ID,
+			Primary:   primary,
+			Shadows:   make(map[string]string, len(s.shadows.scorers)),
+		}
+		for _, shadow := range s.shadows.scorers {
+			top := topHosts(shadow.scorer.Score(context.Background(), pod, nodes, g, locality))
+			divergent := len(top) > 0 && !containsString(top, primary)
+			decision.Shadows[shadow.name] = primary
+			if divergent {
+				decision.Shadows[shadow.name] = top[0]
+				decision.Divergent = append(decision.Divergent, shadow.name)
+			}
+			s.metrics.ObserveShadowDecision(shadow.name, divergent)
+
+			s.shadows.mu.Lock()
+			shadow.decisions++
+			if divergent {
+				shadow.divergent++
+			}
+			s.shadows.mu.Unlock()
+		}
+		if len(decision.Divergent) > 0 {
+			klog.V(2).Infof("Shadow: Pod %s → primary %s, shadows %v", pod.Name, primary, decision.Shadows)
+		}
+
+		s.shadows.mu.Lock()
+		defer s.shadows.mu.Unlock()
+		s.shadows.recent = append(s.shadows.recent, decision)
+		if len(s.shadows.recent) > shadowHistorySize {
+			s.shadows.recent = s.shadows.recent[len(s.shadows.recent)-shadowHistorySize:]
+		}
+	}()
+}
+
+// preferredHost returns the first host with the highest extender score ("" if none)
+func preferredHost(priorities []HostPriority) string {
+	host, best := "", int64(0)
+	for _, p := range priorities {
+		if host == "" || p.Score > best {
+			host, best = p.Host, p.Score
+		}
+	}
+	return host
+}
+
+// topHosts returns every host sharing the highest total, in node order
+func topHosts(breakdown []scorer.ScoreBreakdown) []string {
+	var top []string
+	best := int64(0)
+	for _, b := range breakdown {
+		switch {
+		case len(top) == 0 || b.Total > best:
+			top, best = []string{b.Host}, b.Total
+		case b.Total == best:
+			top = append(top, b.Host)
+		}
+	}
+	return top
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ShadowReport summarises the shadow scorers' agreement with the primary
+func (s *NEXUSScheduler) ShadowReport() ShadowReport {
+	report := ShadowReport{Scorers: []ShadowScorerStats{}, Recent: []ShadowDecision{}}
+	if s.shadows == nil {
+		return report
+	}
+	report.Enabled = true
+
+	s.shadows.mu.Lock()
+	defer s.shadows.mu.Unlock()
+	for _, shadow := range s.shadows.scorers {
+		stats := ShadowScorerStats{Name: shadow.name, Decisions: shadow.decisions, Divergent: shadow.divergent}
+		if shadow.decisions > 0 {
+			stats.DivergenceRate = float64(shadow.divergent) / float64(shadow.decisions)
+		}
+		report.Scorers = append(report.Scorers, stats)
+	}
+	for i := len(s.shadows.recent) - 1; i >= 0; i-- {
+		report.Recent = append(report.Recent, s.shadows.recent[i])
+	}
+	return report
+}
+
+// ShadowHandler returns the shadow scorer report
+func (s *NEXUSScheduler) ShadowHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.ShadowReport())
+}
@@ -0,0 +1,71 @@
+package extender
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// shadowNodes gives node-1 more allocatable CPU, so it wins without locality
+const shadowNodes = `{"metadata":{},"items":[` +
+	`{"metadata":{"name":"node-1"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"},"conditions":[{"type":"Ready","status":"True"}]}},` +
+	`{"metadata":{"name":"node-2"},"status":{"allocatable":{"cpu":"500m","memory":"4Gi"},"conditions":[{"type":"Ready","status":"True"}]}}]}`
+
+func TestShadowScorers(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	zero, capped := 0.0, 1
+	s.cfg.ShadowScorers = map[string]config.ShadowScorer{
+		"no-locality": {LocalityWeight: &zero},
+		"capped":      {LocalityMemberCap: &capped},
+	}
+	s.shadows = newShadowScoring(s.gangManager, s.podLister, nil, s.cfg)
+
+	body := `{"pod":` + compatPod + `,"nodes":` + shadowNodes + `}`
+	rec := httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(body)))
+	s.shadows.wg.Wait()
+
+	report := s.ShadowReport()
+	if !report.Enabled || len(report.Scorers) != 2 || len(report.Recent) != 1 {
+		t.Fatalf("report = %+v, want two scorers and one decision", report)
+	}
+	stats := map[string]ShadowScorerStats{}
+	for _, st := range report.Scorers {
+		stats[st.Name] = st
+	}
+	if st := stats["capped"]; st.Decisions != 1 || st.Divergent != 0 {
+		t.Errorf("capped = %+v, want one agreeing decision", st)
+	}
+	if st := stats["no-locality"]; st.Decisions != 1 || st.Divergent != 1 || st.DivergenceRate != 1 {
+		t.Errorf("no-locality = %+v, want one divergent decision", st)
+	}
+
+	// compatMember runs on node-2: the primary keeps preferring it
+	d := report.Recent[0]
+	if d.Primary != "node-2" || d.Shadows["capped"] != "node-2" || d.Shadows["no-locality"] != "node-1" {
+		t.Errorf("decision = %+v, want primary and capped on node-2, no-locality on node-1", d)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		`nexus_shadow_decisions_total{scorer="no-locality"} 1`,
+		`nexus_shadow_divergent_decisions_total{scorer="no-locality"} 1`,
+		`nexus_shadow_divergent_decisions_total{scorer="capped"} 0`,
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
+
+func TestShadowScorersDisabled(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	prioritize(t, s)
+	if report := s.ShadowReport(); report.Enabled || len(report.Recent) != 0 {
+		t.Errorf("report = %+v, want disabled", report)
+	}
+}
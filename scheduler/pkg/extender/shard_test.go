@@ -0,0 +1,71 @@
+package extender
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/scorer"
+)
+
+func TestShardedScoringMatchesSequential(t *testing.T) {
+	// 40 nodes in 4 racks, one excluded, gang members spread over some of them
+	nodes := &v1.NodeList{}
+	var pods []v1.Pod
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		nodes.Items = append(nodes.Items, testNode(name, func(n *v1.Node) {
+			n.Labels = map[string]string{"rack": fmt.Sprintf("rack-%d", i%4)}
+			if i == 7 {
+				n.Labels["nexus.io/exclude"] = "true"
+			}
+		}))
+		for j := 0; j < i%3; j++ {
+			pod := runningPod(fmt.Sprintf("cartservice-6d5c7b8f9-%d%d", i, j), nil)
+			pod.Spec.NodeName = name
+			pod.UID = types.UID(pod.Name)
+			pods = append(pods, *pod)
+		}
+	}
+	s := newTestScheduler(t, StateActive, pods...)
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-new", Namespace: "default"}}
+	g := s.gangManager.GetGangForPod(pod)
+	if g == nil {
+		t.Fatal("no gang for checkoutservice")
+	}
+
+	score := func(shards int) []scorer.ScoreBreakdown {
+		cfg := *s.cfg
+		cfg.ScoringShards, cfg.ScoringShardMinNodes = shards, 0
+		cfg.MaxNodesScanned = 30
+		cfg.TopologyLevels = []config.TopologyLevel{{Key: "rack", Weight: 0.5}}
+		return scorer.NewNodeScorer(s.gangManager, s.podLister, nil, &cfg).Score(context.Background(), pod, nodes, g, scorer.Locality{Scale: 1})
+	}
+
+	sequential := score(1)
+	if got := score(4); !reflect.DeepEqual(got, sequential) {
+		t.Errorf("sharded breakdown (live counts) differs:\n got %+v\nwant %+v", got, sequential)
+	}
+
+	// Warm counts are copied once per call instead of read per node
+	s.gangManager.WarmMemberCounts(pods)
+	if got := score(4); !reflect.DeepEqual(got, sequential) {
+		t.Errorf("sharded breakdown (warm counts) differs:\n got %+v\nwant %+v", got, sequential)
+	}
+
+	scored := 0
+	for _, b := range sequential {
+		if b.Scanned {
+			scored++
+		}
+	}
+	if scored != 30 || !sequential[7].Excluded {
+		t.Errorf("scored %d nodes (excluded node-7: %v), want the 30-node budget without node-7", scored, sequential[7].Excluded)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+What-If Scoring
+===============
+POST /simulate (ADMIN_ADDR) answers "what would NEXUS do with this pod
+right now?" so operators can sanity-check policies and gangs before a
+spike hits:
+
+  {"pod": {...}, "gang": "checkout-flow", "nodes": {...}}
+
+"gang" (optional) evaluates the pod as a member of the active gang with
+that ID or coordination group instead of the gang its name maps to.
+"nodes" or "nodenames" are the candidates as kube-scheduler would send
+them; without either every cluster node is a candidate.
+
+The response carries the Filter verdict (eligible and rejected nodes,
+with reasons) and the Prioritize scores with their breakdown, or the
+reason NEXUS would answer with no opinion. A pod outside every gang is
+scored by its NON_GANG_FALLBACK policy (reported in "fallback") as
+Prioritize would. The evaluation follows the Filter/Prioritize paths but
+changes nothing: no call, rejection or decision counters, no influence budget charged, no decision history,
+export or shadow scoring, and scores are shown before tie-breaking.
+Only nexus_simulations_total counts the requests.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// SimulationRequest is the body of POST /simulate
+type SimulationRequest struct {
+	Pod       *v1.Pod      `json:"pod"`
+	Gang      string       `json:"gang,omitempty"` // gang ID or group ("" = by pod)
+	Nodes     *v1.NodeList `json:"nodes,omitempty"`
+	NodeNames *[]string    `json:"nodenames,omitempty"`
+}
+
+// SimulationResult is what Filter and Prioritize would answer for the pod
+type SimulationResult struct {
+	State      string                  `json:"state"`
+	Level      ActivationLevel         `json:"level,omitempty"`
+	Gang       string                  `json:"gang,omitempty"`
+	Fallback   string                  `json:"fallback,omitempty"`  // policy scoring a pod outside every gang
+	NoOpinion  string                  `json:"noOpinion,omitempty"` // why NEXUS would not influence the pod
+	Eligible   []string                `json:"eligible"`
+	Rejected   map[string]string       `json:"rejected,omitempty"` // node → reason
+	Priorities []HostPriority          `json:"priorities"`
+	Scores     []scorer.ScoreBreakdown `json:"scores,omitempty"`
+}
+
+// simulationKey marks the context of a simulated evaluation
+type simulationKey struct{}
+
+// simulationContext returns ctx marked as a simulation
+func simulationContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, simulationKey{}, true)
+}
+
+// isSimulation reports whether ctx belongs to a simulated evaluation,
+// whose activity must not be counted
+func isSimulation(ctx context.Context) bool {
+	simulated, _ := ctx.Value(simulationKey{}).(bool)
+	return simulated
+}
+
+// SimulateHandler evaluates a hypothetical pod against the current gangs
+func (s *NEXUSScheduler) SimulateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req SimulationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid simulation request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Pod == nil {
+		http.Error(w, "invalid simulation request: pod is required", http.StatusBadRequest)
+		return
+	}
+	s.metrics.IncrementCounter("simulations")
+
+	ctx := r.Context()
+	args := &ExtenderArgs{Pod: req.Pod, Nodes: req.Nodes, NodeNames: req.NodeNames}
+	if args.Nodes == nil && args.NodeNames == nil {
+		nodes, err := s.listSimulationNodes(ctx)
+		if err != nil {
+			http.Error(w, "listing nodes: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		args.Nodes = nodes
+	}
+
+	result, err := s.Simulate(ctx, args, req.Gang)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// listSimulationNodes returns every cluster node as the candidates
+func (s *NEXUSScheduler) listSimulationNodes(ctx context.Context) (*v1.NodeList, error) {
+	var nodes *v1.NodeList
+	err := s.apiGuard.Do(ctx, "list nodes for a simulation", func(ctx context.Context) error {
+		var err error
+		nodes, err = s.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		return err
+	})
+	return nodes, err
+}
+
+// Simulate returns the Filter verdict and Prioritize scores NEXUS would
+// produce for the pod now; gangName ("" = by pod) selects an active gang
+func (s *NEXUSScheduler) Simulate(ctx context.Context, args *ExtenderArgs, gangName string) (*SimulationResult, error) {
+	ctx = simulationContext(ctx)
+	pod := args.Pod
+	nodes := candidateNodes(args)
+	if nodes == nil {
+		nodes = &v1.NodeList{}
+	}
+	result := &SimulationResult{State: s.GetState().String(), Level: s.ActivationLevel()}
+
+	g := s.gangManager.GetGangForPod(pod)
+	if gangName != "" {
+		if g = s.activeGang(gangName); g == nil {
+			return nil, fmt.Errorf("no active gang %q", gangName)
+		}
+	}
+	if g != nil {
+		result.Gang = g.ID
+	}
+
+	if reason := s.simulatedNoOpinion(pod, g); reason != "" {
+		klog.V(2).Infof("Simulate: Pod %s → no opinion (%s)", pod.Name, reason)
+		result.NoOpinion = reason
+		result.Eligible = nodeNames(nodes.Items)
+		result.Priorities = equalPriorities(nodes.Items)
+		return result, nil
+	}
+	if g == nil {
+		s.simulateNonMember(ctx, pod, args, nodes, result)
+		return result, nil
+	}
+
+	// Filter (every node kept at the ADVISORY level)
+	if result.Level == LevelAdvisory {
+		result.Eligible = nodeNames(nodes.Items)
+	} else {
+		scanned, _ := kube.CapNodes(nodes.Items, s.maxNodesScanned)
+		withMembers := make(map[string]bool)
+		memberCounts := make(map[string]int, len(scanned))
+		candidates := make([]string, 0, len(scanned))
+		for _, node := range scanned {
+			if kube.NodeExcluded(&node, s.cfg.NodeExcludeLabel) {
+				continue
+			}
+			count := s.nodeScorer.CountGangMembersOnNode(ctx, &node, g)
+			memberCounts[node.Name] = count
+			candidates = append(candidates, node.Name)
+			if count > 0 {
+				withMembers[node.Name] = true
+			}
+		}
+		fresh := len(withMembers) == 0
+		if planned, ok := s.planNodesWithSlots(g, memberCounts, candidates); ok {
+			withMembers = planned
+		}
+		verdict := s.filterNodes(ctx, pod, nodes.Items, args.Nodes == nil, g, withMembers, fresh)
+		result.Eligible = nodeNames(verdict.eligible)
+		if len(verdict.rejected) > 0 {
+			result.Rejected = verdict.rejected
+		}
+	}
+
+	// Prioritize
+	localityScale := 1.0
+	if s.GetState() == StateDraining {
+		localityScale = s.drainLocalityScale
+	}
+	locality := s.localityFor(g, localityScale)
+	schedulable, skipped := s.splitSchedulable(ctx, pod, args, nodes)
+	result.Scores = withUnschedulable(s.nodeScorer.Score(ctx, pod, schedulable, g, locality), nodes, skipped)
+	result.Priorities = scaleInfluence(hostPriorities(result.Scores), s.influenceFactor()*priorityBoost(g))
+	s.arbitrate(g, result.Priorities)
+
+	klog.V(2).Infof("Simulate: Pod %s (gang: %s) → %d/%d nodes eligible, scores: %+v",
+		pod.Name, g.ID, len(result.Eligible), len(nodes.Items), result.Priorities)
+	return result, nil
+}
+
+// simulatedNoOpinion returns why Filter and Prioritize would answer with
+// no opinion for the pod ("" = NEXUS would influence it)
+func (s *NEXUSScheduler) simulatedNoOpinion(pod *v1.Pod, g *gang.Gang) string {
+	switch {
+	case s.SelfDisabled():
+		return "self-disabled after a latency regression"
+	case s.GetState() == StateIdle:
+		return "IDLE"
+	case s.systemPods.reason(pod) != "":
+		return "system pod (" + s.systemPods.reason(pod) + ")"
+	case !s.schedulerAllowed(pod):
+		return fmt.Sprintf("scheduler %q not in SCHEDULER_NAMES", pod.Spec.SchedulerName)
+	case g == nil:
+		policy := s.nonGangFallback(pod)
+		if policy != config.FallbackSpread && policy != config.FallbackResource {
+			return "not in any gang (Prioritize fallback: " + policy + ")"
+		}
+		if !s.isNewReplica(pod) {
+			return "not in any gang, created before the episode activated"
+		}
+	case !s.isNewReplica(pod):
+		return "created before the episode activated"
+	case !s.gangManager.InfluenceLeft(g, pod):
+		return "influence budget of gang " + g.ID + " spent"
+	}
+	return ""
+}
+
+// simulateNonMember fills in what Filter and Prioritize would answer for a
+// pod outside every gang scored by its fallback policy (prioritizeNonMember)
+func (s *NEXUSScheduler) simulateNonMember(ctx context.Context, pod *v1.Pod, args *ExtenderArgs, nodes *v1.NodeList, result *SimulationResult) {
+	policy := s.nonGangFallback(pod)
+	result.Fallback = policy
+	result.Eligible = nodeNames(nodes.Items)
+
+	schedulable, skipped := s.splitSchedulable(ctx, pod, args, nodes)
+	result.Scores = withUnschedulable(s.nodeScorer.ScoreNonMember(ctx, pod, schedulable, policy == config.FallbackSpread), nodes, skipped)
+	result.Priorities = scaleInfluence(hostPriorities(result.Scores), s.influenceFactor())
+	klog.V(2).Infof("Simulate: Pod %s (no gang, fallback: %s) → scores: %+v", pod.Name, policy, result.Priorities)
+}
+
+// activeGang returns the active gang with the given ID or group
+func (s *NEXUSScheduler) activeGang(name string) *gang.Gang {
+	for _, g := range s.gangManager.ActiveGangs() {
+		if g.ID == name || g.Group == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// nodeNames returns the names of the nodes
+func nodeNames(nodes []v1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+// equalPriorities scores every node 0, the Prioritize no-opinion answer
+func equalPriorities(nodes []v1.Node) []HostPriority {
+	priorities := make([]HostPriority, 0, len(nodes))
+	for _, node := range nodes {
+		priorities = append(priorities, HostPriority{Host: node.Name})
+	}
+	return priorities
+}
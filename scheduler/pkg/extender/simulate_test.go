@@ -0,0 +1,118 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// simulate posts a what-if request to /simulate
+func simulate(t *testing.T, s *NEXUSScheduler, body string) (*httptest.ResponseRecorder, SimulationResult) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.SimulateHandler(rec, httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(body)))
+	var result SimulationResult
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("decoding simulation: %v", err)
+		}
+	}
+	return rec, result
+}
+
+func TestSimulate(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+
+	rec, result := simulate(t, s, `{"pod":`+compatPod+`,"nodes":`+compatNodes+`}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if result.NoOpinion != "" || result.Gang == "" || len(result.Eligible) != 2 {
+		t.Fatalf("simulation = %+v, want both nodes eligible for the gang", result)
+	}
+	scores := map[string]int64{}
+	for _, p := range result.Priorities {
+		scores[p.Host] = p.Score
+	}
+	if scores["node-2"] <= scores["node-1"] || len(result.Scores) != 2 {
+		t.Errorf("simulated scores = %v, want node-2 (gang member) preferred", scores)
+	}
+
+	// Nothing counted as scheduling activity
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, line := range []string{
+		"nexus_filter_calls_total 0",
+		"nexus_prioritize_calls_total 0",
+		"nexus_simulations_total 1",
+	} {
+		if !strings.Contains(out.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+	if decisions := s.Decisions(""); len(decisions) != 0 {
+		t.Errorf("%d decisions recorded, want none", len(decisions))
+	}
+	if g := s.gangManager.GetGangForService("checkoutservice"); len(g.Influenced) != 0 {
+		t.Errorf("influence budget charged: %v", g.Influenced)
+	}
+
+	// An explicit gang must be active
+	if rec, _ := simulate(t, s, `{"pod":`+compatPod+`,"gang":"no-such-gang","nodes":`+compatNodes+`}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown gang: status %d, want 404", rec.Code)
+	}
+	if rec, _ := simulate(t, s, `{"nodes":`+compatNodes+`}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing pod: status %d, want 400", rec.Code)
+	}
+}
+
+func TestSimulateNoOpinion(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	_, result := simulate(t, s, `{"pod":`+compatPod+`,"nodenames":["node-1","node-2"]}`)
+	if result.NoOpinion != "IDLE" || len(result.Eligible) != 2 || len(result.Priorities) != 2 {
+		t.Errorf("idle simulation = %+v, want every node kept with no opinion", result)
+	}
+}
+
+func TestSimulateNonMemberFallback(t *testing.T) {
+	s := newTestScheduler(t, StateActive,
+		*runningPod("cartservice-6d5c7b8f9-abcde", nil),
+		*runningPod("checkoutservice-7d9f8c6b5-klmno", nil),
+	)
+	s.cfg.NonGangFallback = config.FallbackResource
+
+	want := prioritizeNonMember(t, s, nil, "node-1", "node-2")
+	body, err := json.Marshal(SimulationRequest{
+		Pod:   &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "frontend-5f6d7c8b9-qwert", Namespace: "default"}},
+		Nodes: &v1.NodeList{Items: []v1.Node{testNode("node-1"), testNode("node-2")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, result := simulate(t, s, string(body))
+	if result.NoOpinion != "" || result.Fallback != config.FallbackResource || len(result.Eligible) != 2 || len(result.Scores) != 2 {
+		t.Fatalf("simulation = %+v, want both nodes scored by the resource fallback", result)
+	}
+	for _, p := range result.Priorities {
+		if p.Score != want[p.Host] {
+			t.Errorf("simulated %s = %d, Prioritize = %d", p.Host, p.Score, want[p.Host])
+		}
+	}
+	if want["node-1"] == 0 {
+		t.Errorf("Prioritize scores = %v, want resource scores", want)
+	}
+
+	// Only the real Prioritize call is counted
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), `nexus_fallback_decisions_total{policy="resource"} 1`+"\n") {
+		t.Error("simulation counted as a fallback decision")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Coordination Group SLOs
+=======================
+Groups declare a p95 latency target with the nexus.io/slo-p95-ms pod
+annotation (the lowest value among a group's pods wins; groups without
+one use SLO_DEFAULT_P95_MS, 0 = not tracked). While a spike episode is
+running, every spike check samples each group's p95 latency across its
+member services and accounts the time since the previous sample as
+compliant or violating.
+
+Burn rate is the share of the episode spent over target divided by the
+error budget (1 − SLO_OBJECTIVE): 1 spends the budget exactly, above 1
+exhausts it early. Violation seconds are also counted per group with an
+influenced="true|false" label — whether NEXUS had placed any of the
+group's pods yet — so evaluations can report SLO-violation minutes with
+and without NEXUS influence. Gauges are cleared when the gangs dissolve.
+*/
+
+package extender
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/metrics"
+)
+
+// sloEpisode accumulates one group's SLO accounting for the current episode
+type sloEpisode struct {
+	evaluated float64 // seconds
+	violated  float64 // seconds
+}
+
+// sloTracker holds the per-episode SLO state of every tracked group
+type sloTracker struct {
+	mu         sync.Mutex
+	lastSample time.Time
+	episodes   map[string]*sloEpisode // group → totals this episode
+}
+
+// trackSLOs samples the latency SLO of every group with a target while an
+// episode is running
+func (s *NEXUSScheduler) trackSLOs() {
+	if s.GetState() == StateIdle {
+		return
+	}
+
+	s.slo.mu.Lock()
+	defer s.slo.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(s.slo.lastSample).Seconds()
+	if s.slo.lastSample.IsZero() || elapsed > 3*spikeCheckInterval.Seconds() {
+		elapsed = spikeCheckInterval.Seconds() // first sample, or the loop stalled
+	}
+	s.slo.lastSample = now
+	if s.slo.episodes == nil {
+		s.slo.episodes = make(map[string]*sloEpisode)
+	}
+
+	for _, group := range s.depGraph.GetGroups() {
+		target := group.SLOP95Ms
+		if policy := s.gangManager.GroupPolicy(group.Name); policy != nil && policy.SLOP95Ms > 0 {
+			target = policy.SLOP95Ms
+		}
+		if target == 0 {
+			target = s.cfg.SLODefaultP95
+		}
+		if target == 0 || len(group.Services) == 0 {
+			continue
+		}
+
+		p95, err := s.spikeDetector.GroupP95Latency(group.Services)
+		if err != nil {
+			klog.V(2).Infof("SLO: failed to query p95 latency of group %s: %v", group.Name, err)
+			continue
+		}
+		if p95 == 0 || math.IsNaN(p95) {
+			continue // no traffic in the window: nothing to account
+		}
+
+		ep := s.slo.episodes[group.Name]
+		if ep == nil {
+			ep = &sloEpisode{}
+			s.slo.episodes[group.Name] = ep
+		}
+		compliant := p95 <= target
+		violated := 0.0
+		if !compliant {
+			violated = elapsed
+		}
+		ep.evaluated += elapsed
+		ep.violated += violated
+
+		influenced := s.gangManager.InfluencedPods(group.Name) > 0
+		s.metrics.AddSLOSeconds(group.Name, influenced, elapsed, violated)
+		s.metrics.SetSLOStatus(group.Name, metrics.SLOStatus{
+			TargetMs:         target,
+			P95Ms:            p95,
+			Compliant:        compliant,
+			BurnRate:         burnRate(ep, s.cfg.SLOObjective),
+			ViolationMinutes: ep.violated / 60,
+		})
+		if !compliant {
+			klog.V(2).Infof("SLO: group %s p95 %.1fms over target %.0fms", group.Name, p95, target)
+		}
+	}
+}
+
+// burnRate is the episode's violating share divided by the error budget
+func burnRate(ep *sloEpisode, objective float64) float64 {
+	budget := 1 - objective
+	if ep.evaluated == 0 || budget <= 0 {
+		return 0
+	}
+	return (ep.violated / ep.evaluated) / budget
+}
+
+// resetSLOs ends SLO accounting for the episode
+func (s *NEXUSScheduler) resetSLOs() {
+	s.slo.mu.Lock()
+	s.slo.episodes = nil
+	s.slo.lastSample = time.Time{}
+	s.slo.mu.Unlock()
+
+	s.metrics.ClearSLOStatus()
+}
@@ -0,0 +1,76 @@
+package extender
+
+import (
+	"context"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+func TestTrackSLOs(t *testing.T) {
+	prom := fakePrometheus(t, 250)
+	s := newTestScheduler(t, StateActive)
+	s.cfg.SLOObjective = 0.9
+	s.depGraph.Restore([]graph.RuntimeGroup{
+		{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice"}, SLOP95Ms: 300},
+		{Name: "product-browsing", Services: []string{"frontend"}}, // no SLO declared
+	})
+
+	s.trackSLOs() // compliant
+	prom.SetDefault(450)
+	s.slo.lastSample = time.Now().Add(-spikeCheckInterval)
+	s.trackSLOs() // violating, one check interval later
+
+	status, ok := s.metrics.SLOStatuses()["checkout-flow"]
+	if !ok {
+		t.Fatalf("checkout-flow not tracked: %v", s.metrics.SLOStatuses())
+	}
+	if status.Compliant || status.P95Ms != 450 || status.TargetMs != 300 {
+		t.Errorf("status = %+v, want the violating 450ms sample against 300ms", status)
+	}
+	// Half the episode over target with a 10% budget burns it 5× too fast
+	if status.BurnRate < 4.99 || status.BurnRate > 5.01 {
+		t.Errorf("burn rate = %v, want 5", status.BurnRate)
+	}
+	if _, tracked := s.metrics.SLOStatuses()["product-browsing"]; tracked {
+		t.Error("group without a target should not be tracked")
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{
+		`nexus_slo_violation_seconds_total{group="checkout-flow",influenced="false"} 10`,
+		`nexus_slo_evaluated_seconds_total{group="checkout-flow",influenced="false"} 20`,
+		`nexus_slo_compliant{group="checkout-flow"} 0`,
+	} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s in:\n%s", want, out.Body.String())
+		}
+	}
+
+	s.dissolveGangs(context.Background())
+	if len(s.metrics.SLOStatuses()) != 0 {
+		t.Error("SLO gauges not cleared on dissolution")
+	}
+}
+
+func TestTrackSLOsDefaultTarget(t *testing.T) {
+	prom := fakePrometheus(t, math.NaN())
+	s := newTestScheduler(t, StateActive)
+	s.cfg.SLODefaultP95 = 200
+
+	s.trackSLOs() // no traffic: nothing accounted
+	if len(s.metrics.SLOStatuses()) != 0 {
+		t.Fatalf("groups without traffic were accounted: %v", s.metrics.SLOStatuses())
+	}
+
+	prom.SetDefault(150)
+	s.trackSLOs()
+	if status := s.metrics.SLOStatuses()["checkout-flow"]; !status.Compliant || status.TargetMs != 200 {
+		t.Errorf("status = %+v, want compliant against the 200ms default", status)
+	}
+}
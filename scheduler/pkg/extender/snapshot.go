@@ -0,0 +1,404 @@
+/*
+Cluster Snapshots
+=================
+GET /admin/snapshot (ADMIN_ADDR, admin token) dumps what NEXUS sees of
+the cluster as one JSON document, so an interesting live scheduling
+scenario can be reproduced and debugged offline:
+
+  nodes      Name, labels, taints, capacity, allocatable, conditions, images
+  pods       Name, namespace, labels, nexus.io/* annotations, node, phase,
+             container images and resources, owner, creation time
+  placement  Running pods by service and node
+  groups     The dependency graph's coordination groups
+  gangs      The active gangs with their node preferences and NexusPolicy
+
+plus the state, episode, spike class, activation level and formation
+strategy. The snapshot is sanitized: other annotations, environment,
+commands, volumes, addresses and node system info are left out. Pods are
+listed within MAX_PODS_CONSIDERED ("truncated" tells).
+
+`nexus-scheduler simulate` loads a snapshot into an in-memory cluster,
+restores the groups and gangs, and prints what Filter and Prioritize
+(see simulate.go) answer for a new replica:
+
+  curl -H "Authorization: Bearer $ADMIN_TOKEN" :9100/admin/snapshot > snap.json
+  nexus-scheduler simulate -snapshot snap.json -service checkoutservice
+  nexus-scheduler simulate -snapshot snap.json -pod default/cartservice-7d4f -gang checkout-flow
+
+-service copies an existing pod of the service, -pod the named pod; the
+copy is unscheduled and created now, so it counts as a new replica.
+Scoring settings come from the environment as in the scheduler;
+utilization scoring is off (no metrics API offline).
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/version"
+)
+
+// ClusterSnapshot is the sanitized cluster and gang state of an instance
+type ClusterSnapshot struct {
+	TakenAt     time.Time                 `json:"takenAt"`
+	Version     string                    `json:"version"`
+	State       string                    `json:"state"`
+	Level       ActivationLevel           `json:"level,omitempty"`
+	EpisodeID   string                    `json:"episodeId,omitempty"`
+	ActivatedAt time.Time                 `json:"activatedAt"`
+	SpikeClass  detector.SpikeClass       `json:"spikeClass,omitempty"`
+	Formation   string                    `json:"formation,omitempty"`
+	Groups      []graph.RuntimeGroup      `json:"groups"`
+	Gangs       []SnapshotGang            `json:"gangs"`
+	Nodes       []v1.Node                 `json:"nodes"`
+	Pods        []v1.Pod                  `json:"pods"`
+	Placement   map[string]map[string]int `json:"placement"`           // service → node → running pods
+	Truncated   bool                      `json:"truncated,omitempty"` // pods cut at MAX_PODS_CONSIDERED
+}
+
+// SnapshotGang is an active gang in a snapshot
+type SnapshotGang struct {
+	ID        string         `json:"id"`
+	Group     string         `json:"group"`
+	Members   []string       `json:"members"`
+	Missing   []string       `json:"missing,omitempty"`
+	NodePrefs map[string]int `json:"nodePrefs,omitempty"`
+	Policy    *gang.Policy   `json:"policy,omitempty"`
+}
+
+// SnapshotHandler serves the cluster snapshot (GET)
+func (s *NEXUSScheduler) SnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	snap, err := s.Snapshot(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.metrics.IncrementCounter("snapshots")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snap)
+}
+
+// Snapshot returns the sanitized cluster and gang state
+func (s *NEXUSScheduler) Snapshot(ctx context.Context) (*ClusterSnapshot, error) {
+	nodes, err := s.listSimulationNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+	pods, truncated, err := s.podLister.List(ctx, "list pods for a cluster snapshot", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+
+	snap := &ClusterSnapshot{
+		TakenAt:    time.Now().UTC(),
+		Version:    version.Get().Version,
+		State:      s.GetState().String(),
+		Groups:     s.depGraph.GetGroups(),
+		Gangs:      []SnapshotGang{},
+		Nodes:      make([]v1.Node, 0, len(nodes.Items)),
+		Pods:       make([]v1.Pod, 0, len(pods)),
+		Placement:  make(map[string]map[string]int),
+		Truncated:  truncated,
+		SpikeClass: s.SpikeClass(),
+		Formation:  s.FormationStrategy(),
+	}
+	if s.GetState() != StateIdle {
+		snap.Level = s.ActivationLevel()
+		snap.EpisodeID = s.EpisodeID()
+		snap.ActivatedAt = s.ActivatedAt()
+	}
+
+	degraded := s.gangManager.DegradedGangs()
+	for _, g := range s.gangManager.ActiveGangs() {
+		snap.Gangs = append(snap.Gangs, SnapshotGang{
+			ID:        g.ID,
+			Group:     g.Group,
+			Members:   g.Members,
+			Missing:   degraded[g.ID],
+			NodePrefs: s.gangManager.NodePreferences(g),
+			Policy:    g.Policy,
+		})
+	}
+	for i := range nodes.Items {
+		snap.Nodes = append(snap.Nodes, sanitizeNode(&nodes.Items[i]))
+	}
+	for i := range pods {
+		pod := sanitizePod(&pods[i])
+		snap.Pods = append(snap.Pods, pod)
+		if pod.Spec.NodeName == "" || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		svc := graph.ExtractServiceName(pod.Name)
+		if snap.Placement[svc] == nil {
+			snap.Placement[svc] = make(map[string]int)
+		}
+		snap.Placement[svc][pod.Spec.NodeName]++
+	}
+	sort.Slice(snap.Nodes, func(i, j int) bool { return snap.Nodes[i].Name < snap.Nodes[j].Name })
+	sort.Slice(snap.Pods, func(i, j int) bool {
+		if snap.Pods[i].Namespace != snap.Pods[j].Namespace {
+			return snap.Pods[i].Namespace < snap.Pods[j].Namespace
+		}
+		return snap.Pods[i].Name < snap.Pods[j].Name
+	})
+	return snap, nil
+}
+
+// sanitizeNode keeps the scheduling-relevant fields of a node
+func sanitizeNode(node *v1.Node) v1.Node {
+	conditions := make([]v1.NodeCondition, 0, len(node.Status.Conditions))
+	for _, c := range node.Status.Conditions {
+		conditions = append(conditions, v1.NodeCondition{Type: c.Type, Status: c.Status})
+	}
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: node.Name, Labels: node.Labels},
+		Spec:       v1.NodeSpec{Unschedulable: node.Spec.Unschedulable, Taints: node.Spec.Taints},
+		Status: v1.NodeStatus{
+			Capacity:    node.Status.Capacity,
+			Allocatable: node.Status.Allocatable,
+			Conditions:  conditions,
+			Images:      node.Status.Images,
+		},
+	}
+}
+
+// sanitizePod keeps the scheduling-relevant fields of a pod and only its
+// nexus.io/ annotations
+func sanitizePod(pod *v1.Pod) v1.Pod {
+	var annotations map[string]string
+	for key, value := range pod.Annotations {
+		if strings.HasPrefix(key, "nexus.io/") {
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[key] = value
+		}
+	}
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pod.Name,
+			Namespace:         pod.Namespace,
+			UID:               pod.UID,
+			Labels:            pod.Labels,
+			Annotations:       annotations,
+			OwnerReferences:   pod.OwnerReferences,
+			CreationTimestamp: pod.CreationTimestamp,
+		},
+		Spec: v1.PodSpec{
+			NodeName:          pod.Spec.NodeName,
+			SchedulerName:     pod.Spec.SchedulerName,
+			PriorityClassName: pod.Spec.PriorityClassName,
+			Priority:          pod.Spec.Priority,
+			NodeSelector:      pod.Spec.NodeSelector,
+			Affinity:          pod.Spec.Affinity,
+			Tolerations:       pod.Spec.Tolerations,
+			InitContainers:    sanitizeContainers(pod.Spec.InitContainers),
+			Containers:        sanitizeContainers(pod.Spec.Containers),
+		},
+		Status: v1.PodStatus{Phase: pod.Status.Phase, StartTime: pod.Status.StartTime},
+	}
+}
+
+// sanitizeContainers keeps the name, image and resources of each container
+func sanitizeContainers(containers []v1.Container) []v1.Container {
+	if len(containers) == 0 {
+		return nil
+	}
+	sanitized := make([]v1.Container, 0, len(containers))
+	for _, c := range containers {
+		sanitized = append(sanitized, v1.Container{Name: c.Name, Image: c.Image, Resources: c.Resources})
+	}
+	return sanitized
+}
+
+// RunSimulate implements the simulate subcommand and returns the exit code
+func RunSimulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	path := fs.String("snapshot", "", "cluster snapshot from GET /admin/snapshot (required)")
+	service := fs.String("service", "", "simulate a new replica of this service")
+	podName := fs.String("pod", "", "simulate a new replica copied from this pod ([namespace/]name)")
+	gangName := fs.String("gang", "", "evaluate as a member of this gang ID or group")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *path == "" || (*service == "") == (*podName == "") {
+		fmt.Fprintln(os.Stderr, "-snapshot and exactly one of -service or -pod are required")
+		return 2
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	snap, err := LoadSnapshot(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	template := snap.findPod(*service, *podName)
+	if template == nil {
+		fmt.Fprintf(os.Stderr, "no pod of service %q or named %q in the snapshot\n", *service, *podName)
+		return 1
+	}
+
+	silenceLogs()
+	cfg := config.LoadConfig()
+	s := NewSnapshotScheduler(snap, cfg)
+	nodes := &v1.NodeList{Items: snap.Nodes}
+	result, err := s.Simulate(context.Background(), &ExtenderArgs{Pod: newReplica(template), Nodes: nodes}, *gangName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(result)
+	return 0
+}
+
+// LoadSnapshot decodes a cluster snapshot
+func LoadSnapshot(r io.Reader) (*ClusterSnapshot, error) {
+	var snap ClusterSnapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// NewSnapshotScheduler returns a scheduler over an in-memory cluster
+// holding the snapshot, with its groups, gangs and state restored
+func NewSnapshotScheduler(snap *ClusterSnapshot, cfg *config.Config) *NEXUSScheduler {
+	cfg.StateRecovery = false
+	cfg.KEDATrigger = false
+	cfg.UtilizationScoring = false
+	s := NewNEXUSScheduler(snapshotClientset(snap), nil, cfg)
+	s.depGraph.Restore(snap.Groups)
+
+	state := parseSchedulerState(snap.State)
+	if state == StateIdle || len(snap.Gangs) == 0 {
+		return s
+	}
+	groups := make([]graph.RuntimeGroup, 0, len(snap.Gangs))
+	policies := make(map[string]gang.Policy)
+	for _, g := range snap.Gangs {
+		groups = append(groups, graph.RuntimeGroup{Name: g.Group, Services: g.Members, Missing: g.Missing})
+		if g.Policy != nil {
+			policies[g.Group] = *g.Policy
+		}
+	}
+	ctx := context.Background()
+	s.gangManager.SetStage(gang.GangStageDetected)
+	s.gangManager.SetStage(gang.GangStageGraphBuilt)
+	s.gangManager.FormGangs(groups)
+	s.gangManager.ApplyPolicies(policies)
+	s.planPlacements(ctx)
+	s.gangManager.SetStage(gang.GangStageScheduling)
+
+	s.startEpisode(snap.EpisodeID, snap.ActivatedAt)
+	s.setSpikeClass(snap.SpikeClass)
+	s.SetState(state)
+	if snap.Level != LevelNone {
+		s.setActivationLevel(snap.Level)
+	}
+	return s
+}
+
+// parseSchedulerState returns the state with the name (IDLE when unknown)
+func parseSchedulerState(name string) SchedulerState {
+	for _, state := range []SchedulerState{StateActive, StateDraining} {
+		if state.String() == name {
+			return state
+		}
+	}
+	return StateIdle
+}
+
+// snapshotClientset serves the snapshot's nodes and pods from memory
+func snapshotClientset(snap *ClusterSnapshot) *fake.Clientset {
+	objects := make([]k8sruntime.Object, 0, len(snap.Nodes)+len(snap.Pods))
+	podsByNode := make(map[string][]v1.Pod)
+	for i := range snap.Nodes {
+		objects = append(objects, &snap.Nodes[i])
+	}
+	for i := range snap.Pods {
+		objects = append(objects, &snap.Pods[i])
+		if node := snap.Pods[i].Spec.NodeName; node != "" {
+			podsByNode[node] = append(podsByNode[node], snap.Pods[i])
+		}
+	}
+
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		// The object tracker ignores field selectors; emulate spec.nodeName
+		fields := action.(k8stesting.ListAction).GetListRestrictions().Fields
+		if nodeName, ok := fields.RequiresExactMatch("spec.nodeName"); ok {
+			return true, &v1.PodList{Items: podsByNode[nodeName]}, nil
+		}
+		return false, nil, nil
+	})
+	return clientset
+}
+
+// findPod returns the named pod ([namespace/]name), or a scheduled pod of
+// the service (any pod of it when none is scheduled); nil when none
+func (snap *ClusterSnapshot) findPod(service, name string) *v1.Pod {
+	namespace := ""
+	if ns, n, ok := strings.Cut(name, "/"); ok {
+		namespace, name = ns, n
+	}
+	var found *v1.Pod
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		switch {
+		case name != "":
+			if pod.Name == name && (namespace == "" || pod.Namespace == namespace) {
+				return pod
+			}
+		case graph.ExtractServiceName(pod.Name) == service:
+			if pod.Spec.NodeName != "" {
+				return pod
+			}
+			if found == nil {
+				found = pod
+			}
+		}
+	}
+	return found
+}
+
+// newReplica returns an unscheduled copy of the pod created now
+func newReplica(template *v1.Pod) *v1.Pod {
+	pod := template.DeepCopy()
+	pod.Name = graph.ExtractServiceName(template.Name) + "-snapshot-replica"
+	pod.UID = "snapshot-replica"
+	pod.CreationTimestamp = metav1.Now()
+	pod.Spec.NodeName = ""
+	pod.Status = v1.PodStatus{Phase: v1.PodPending}
+	return pod
+}
@@ -0,0 +1,103 @@
+package extender
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestSnapshotReproducesSimulation(t *testing.T) {
+	cart := runningPod("cartservice-6d5c7b8f9-abcde", map[string]string{
+		"nexus.io/service-group": "checkout-flow",
+		"deploy.example.com/key": "secret",
+	})
+	cart.Spec.Containers = []v1.Container{{Name: "server", Image: "cartservice:v1", Env: []v1.EnvVar{{Name: "TOKEN", Value: "secret"}}}}
+	s := newTestScheduler(t, StateActive, *cart)
+	for _, name := range []string{"node-2", "node-1"} {
+		node := testNode(name)
+		node.Status.Addresses = []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}
+		if _, err := s.clientset.CoreV1().Nodes().Create(context.Background(), &node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.SnapshotHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/snapshot", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	snap, err := LoadSnapshot(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sanitized
+	if len(snap.Nodes) != 2 || snap.Nodes[0].Name != "node-1" || len(snap.Nodes[0].Status.Addresses) != 0 {
+		t.Errorf("nodes = %+v, want node-1 and node-2 without addresses", snap.Nodes)
+	}
+	if len(snap.Pods) != 1 {
+		t.Fatalf("pods = %+v, want the cartservice pod", snap.Pods)
+	}
+	if got := snap.Pods[0].Annotations; !reflect.DeepEqual(got, map[string]string{"nexus.io/service-group": "checkout-flow"}) {
+		t.Errorf("annotations = %v, want only the nexus.io/ ones", got)
+	}
+	if c := snap.Pods[0].Spec.Containers; len(c) != 1 || c[0].Image != "cartservice:v1" || c[0].Env != nil {
+		t.Errorf("containers = %+v, want the image without env", c)
+	}
+	if got := snap.Placement["cartservice"]["node-1"]; got != 1 {
+		t.Errorf("placement = %v, want cartservice on node-1", snap.Placement)
+	}
+	if len(snap.Gangs) != 1 || snap.Gangs[0].Group != "checkout-flow" || snap.State != "ACTIVE" {
+		t.Errorf("state %s, gangs %+v; want ACTIVE with checkout-flow", snap.State, snap.Gangs)
+	}
+
+	// Offline, a new checkoutservice replica scores as it does live
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkoutservice-7d9f8c6b5-x2k4p", Namespace: "default"}}
+	nodes := &v1.NodeList{Items: snap.Nodes}
+	live, err := s.Simulate(context.Background(), &ExtenderArgs{Pod: pod, Nodes: nodes}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.LoadConfig()
+	offline := NewSnapshotScheduler(snap, cfg)
+	replayed, err := offline.Simulate(context.Background(), &ExtenderArgs{Pod: newReplica(pod), Nodes: nodes}, "checkout-flow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed.Priorities, live.Priorities) || replayed.State != "ACTIVE" {
+		t.Errorf("offline %s %+v, live %+v; want the same scores", replayed.State, replayed.Priorities, live.Priorities)
+	}
+	if live.Priorities[0].Host != "node-1" || live.Priorities[0].Score <= live.Priorities[1].Score {
+		t.Errorf("priorities = %+v, want node-1 (cartservice) ahead", live.Priorities)
+	}
+}
+
+func TestSnapshotFindPod(t *testing.T) {
+	snap := &ClusterSnapshot{Pods: []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cartservice-1-a", Namespace: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cartservice-1-b", Namespace: "default"}, Spec: v1.PodSpec{NodeName: "node-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "frontend-1-a", Namespace: "shop"}},
+	}}
+
+	if pod := snap.findPod("cartservice", ""); pod == nil || pod.Name != "cartservice-1-b" {
+		t.Errorf("service pod = %v, want the scheduled cartservice-1-b", pod)
+	}
+	if pod := snap.findPod("", "shop/frontend-1-a"); pod == nil || pod.Namespace != "shop" {
+		t.Errorf("named pod = %v, want shop/frontend-1-a", pod)
+	}
+	if pod := snap.findPod("", "default/frontend-1-a"); pod != nil {
+		t.Errorf("named pod in the wrong namespace = %v, want none", pod.Name)
+	}
+	if replica := newReplica(&snap.Pods[1]); replica.Spec.NodeName != "" || replica.Name != "cartservice-snapshot-replica" {
+		t.Errorf("replica = %s on %q, want an unscheduled cartservice-snapshot-replica", replica.Name, replica.Spec.NodeName)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Unix Socket Listeners
+=====================
+When NEXUS runs as a sidecar in the kube-scheduler pod, a call to the
+extender never needs to leave the pod. An address of the form
+
+  EXTENDER_ADDR=unix:///run/nexus/extender.sock
+
+(ADMIN_ADDR likewise) serves the listener on a Unix domain socket instead
+of a TCP port: no TCP handshake, no loopback stack, the lowest-overhead
+configuration of the comparison tables (bench -transport inproc,tcp,unix).
+The socket file is created with mode 0660, so the socket's directory
+(typically an emptyDir shared by both containers) decides who can reach
+it; a socket left behind by a previous run is replaced, one still being
+served is not. The connection limits and metrics of connections.go apply
+unchanged.
+
+kube-scheduler's own extender client only dials http(s) URLs. A stock
+kube-scheduler sidecar keeps EXTENDER_ADDR on 127.0.0.1 (loopback, no
+network hop); the socket serves clients that can dial it, such as a
+scheduler built with a socket transport or curl --unix-socket.
+*/
+
+package extender
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// unixAddrPrefix marks a listen address as a Unix socket path
+const unixAddrPrefix = "unix:"
+
+// unixSocketPath returns the socket path of a "unix:///path" (or
+// "unix:/path") listen address
+func unixSocketPath(addr string) (string, bool) {
+	path, ok := strings.CutPrefix(addr, unixAddrPrefix)
+	if !ok {
+		return "", false
+	}
+	return "/" + strings.TrimLeft(path, "/"), true
+}
+
+// listen opens the listener of a TCP or Unix socket address
+func listen(addr string) (net.Listener, error) {
+	path, ok := unixSocketPath(addr)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set the mode of %s: %w", path, err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes a socket file nobody serves any more
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is already being served", path)
+	}
+	return os.Remove(path)
+}
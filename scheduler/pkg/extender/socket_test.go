@@ -0,0 +1,67 @@
+package extender
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnixSocketListener(t *testing.T) {
+	dir, err := os.MkdirTemp("", "nexus-") // socket paths are limited to ~100 bytes
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "extender.sock")
+
+	// A socket left behind by a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	s := newTestScheduler(t, StateIdle)
+	cfg := *s.cfg
+	cfg.ExtenderAddr = "unix://" + path
+	server := s.NewServers(&cfg)[0]
+	errs := make(chan error, 1)
+	go func() { errs <- s.ListenAndServe(server, &cfg) }()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	defer client.CloseIdleConnections()
+	waitFor(t, "the socket to serve", func() bool {
+		resp, err := client.Get("http://nexus/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+	if info, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0o660 {
+		t.Errorf("socket mode = %v, want 0660", info.Mode().Perm())
+	}
+
+	// A socket still being served is left alone
+	if _, err := listen(cfg.ExtenderAddr); err == nil || !strings.Contains(err.Error(), "already being served") {
+		t.Errorf("second listener on a served socket: %v", err)
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("listener stopped: %v", err)
+	default:
+	}
+}
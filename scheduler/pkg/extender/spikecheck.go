@@ -0,0 +1,97 @@
+/*
+Spike Check Cycle
+=================
+The spike watcher ticks every spikeCheckInterval but never runs a cycle
+(spike detection, SLO tracking, sweep sampling) on the ticker goroutine:
+
+  - each cycle starts after a random delay of up to SPIKE_CHECK_JITTER,
+    so NEXUS does not query Prometheus in lock-step with other tools
+    polling on round intervals
+  - spike detection gets SPIKE_CHECK_TIMEOUT to answer; queries still in
+    flight are cancelled and the check counts as inconclusive, leaving
+    the state unchanged (nexus_spike_check_timeouts_total)
+  - a tick arriving while the previous cycle still runs is skipped
+    (nexus_spike_checks_skipped_total) instead of queueing up behind it
+*/
+
+package extender
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// errSpikeCheckTimeout reports a detection abandoned at SPIKE_CHECK_TIMEOUT
+var errSpikeCheckTimeout = errors.New("spike check exceeded SPIKE_CHECK_TIMEOUT")
+
+// spikeCheckState tracks the running spike check cycle
+type spikeCheckState struct {
+	running atomic.Bool
+	started atomic.Int64 // unix nanoseconds of the running cycle
+}
+
+// startSpikeCheck runs a spike check cycle in the background, or skips the
+// tick when the previous cycle is still running
+func (s *NEXUSScheduler) startSpikeCheck(ctx context.Context) bool {
+	if !s.spikeCheck.running.CompareAndSwap(false, true) {
+		s.metrics.IncrementCounter("spike_checks_skipped")
+		klog.Warningf("Spike check skipped: previous cycle still running after %v",
+			time.Since(time.Unix(0, s.spikeCheck.started.Load())).Round(time.Millisecond))
+		return false
+	}
+	s.spikeCheck.started.Store(time.Now().UnixNano())
+
+	go func() {
+		defer s.spikeCheck.running.Store(false)
+		if !s.spikeCheckJitter(ctx) {
+			return
+		}
+		s.checkForSpike(ctx)
+		s.trackSLOs()
+		s.sampleSweep()
+	}()
+	return true
+}
+
+// spikeCheckJitter waits a random delay of up to SPIKE_CHECK_JITTER
+// (false when ctx ended first)
+func (s *NEXUSScheduler) spikeCheckJitter(ctx context.Context) bool {
+	if s.cfg.SpikeCheckJitter <= 0 {
+		return true
+	}
+	delay := time.Duration(rand.Int63n(int64(s.cfg.SpikeCheckJitter)))
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// spikeCheckContext bounds a spike detection by SPIKE_CHECK_TIMEOUT
+func (s *NEXUSScheduler) spikeCheckContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.cfg.SpikeCheckTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.cfg.SpikeCheckTimeout)
+}
+
+// spikeCheckExpired reports whether a detection ran out of budget (counted)
+// or was cancelled; its result is then inconclusive
+func (s *NEXUSScheduler) spikeCheckExpired(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		s.metrics.IncrementCounter("spike_check_timeouts")
+		klog.Warningf("Spike check abandoned after %v — state unchanged", s.cfg.SpikeCheckTimeout)
+		return errSpikeCheckTimeout
+	default:
+		return ctx.Err()
+	}
+}
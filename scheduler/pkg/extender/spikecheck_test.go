@@ -0,0 +1,161 @@
+package extender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowPrometheus answers queries only once release is closed (or the
+// request is cancelled), signalling each request received on started
+func slowPrometheus(t *testing.T, release <-chan struct{}) <-chan struct{} {
+	t.Helper()
+	started := make(chan struct{}, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0,"0"]}]}}`)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("PROMETHEUS_URL", srv.URL)
+	return started
+}
+
+func TestDetectSpikeTimeoutBudget(t *testing.T) {
+	slowPrometheus(t, make(chan struct{}))
+	s := newTestScheduler(t, StateActive)
+	s.cfg.SpikeCheckTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	if _, _, err := s.detectSpike(context.Background()); !errors.Is(err, errSpikeCheckTimeout) {
+		t.Fatalf("err = %v, want errSpikeCheckTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("detection took %v, want it cut at the 50ms budget", elapsed)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_spike_check_timeouts_total 1") {
+		t.Error("timeout not counted")
+	}
+}
+
+func TestSpikeCheckSkipsOverrunningCycle(t *testing.T) {
+	release := make(chan struct{})
+	started := slowPrometheus(t, release)
+	s := newTestScheduler(t, StateIdle)
+	s.cfg.SpikeCheckTimeout = 5 * time.Second
+	s.cfg.SpikeCheckJitter = 0
+
+	if !s.startSpikeCheck(context.Background()) {
+		t.Fatal("first cycle not started")
+	}
+	<-started // the cycle is waiting on Prometheus
+	if s.startSpikeCheck(context.Background()) {
+		t.Fatal("second cycle started while the first was running")
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_spike_checks_skipped_total 1") {
+		t.Error("skipped cycle not counted")
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for s.spikeCheck.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("cycle still running after Prometheus answered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !s.startSpikeCheck(context.Background()) {
+		t.Error("next cycle not started after the first finished")
+	}
+}
+
+func TestDetectorSignalMetrics(t *testing.T) {
+	fakePrometheus(t, 250)
+	s := newTestScheduler(t, StateIdle)
+	if _, _, err := s.detectSpike(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{
+		"nexus_detector_prometheus_up 1",
+		"nexus_detector_qps 250.000",
+		"nexus_detector_p95_latency_ms 250.000",
+		"nexus_detector_hpa_replica_increase NaN", // not evaluated: the error rate fired
+		`nexus_detector_threshold{signal="qps"} 1000.000`,
+	} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	// Unreachable Prometheus: nothing observed
+	t.Setenv("PROMETHEUS_URL", "http://127.0.0.1:1")
+	s = newTestScheduler(t, StateIdle)
+	s.detectSpike(context.Background())
+	out = httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{"nexus_detector_prometheus_up 0", "nexus_detector_qps NaN"} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("unreachable: missing %s", want)
+		}
+	}
+}
+
+func TestLocustSpikeSource(t *testing.T) {
+	stats := `{"state":"running","user_count":250,"total_rps":1500,"total_fail_per_sec":2,"fail_ratio":0.001,"current_response_time_percentiles":{"response_time_percentile_0.5":40,"response_time_percentile_0.95":null}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats/requests" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, stats)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("SPIKE_SOURCE", "locust")
+	t.Setenv("LOCUST_URL", srv.URL)
+	t.Setenv("PROMETHEUS_URL", "http://127.0.0.1:1") // not consulted for spike checks
+
+	s := newTestScheduler(t, StateIdle)
+	class, _, err := s.detectSpike(context.Background())
+	if err != nil || class != "traffic" {
+		t.Fatalf("class = %q, %v; want a traffic spike from the Locust RPS", class, err)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{
+		"nexus_detector_locust_up 1",
+		"nexus_detector_prometheus_up 0",
+		"nexus_detector_qps 1500.000",
+		"nexus_detector_error_rate 2.000",
+		"nexus_detector_p95_latency_ms 0.000",
+		"nexus_detector_locust_users 250.000",
+	} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	// Older Locust: no total_fail_per_sec, flat p95 field
+	stats = `{"state":"running","user_count":50,"total_rps":200,"fail_ratio":0.5,"current_response_time_percentile_95":900}`
+	if class, _, err := s.detectSpike(context.Background()); err != nil || class != "error" {
+		t.Errorf("class = %q, %v; want an error spike from 100 failures/s", class, err)
+	}
+}
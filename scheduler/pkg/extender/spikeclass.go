@@ -0,0 +1,71 @@
+/*
+Spike Class Policies
+====================
+Not every spike calls for the same placement. The detector classifies
+each spike by its most severe signal, and the class selects how gangs
+are placed for the rest of the episode:
+
+  traffic: default gang locality
+  latency: tighter co-location (locality scaled up, default ×1.5) — cut
+           the network hops on the slow request path
+  error:   spread members across nodes — a failing node or noisy
+           neighbour should not take the whole gang down with it
+
+Policies are configured with SPIKE_CLASS_POLICIES, per class and
+optionally per coordination group ("<group>/<class>" wins over
+"<class>"). The class is re-evaluated on every detector check while the
+episode lasts, so a traffic spike that starts burning the error budget
+switches to the error policy.
+
+A group's NexusPolicy (policy.go) overrides the class policy.
+While a spread policy applies, GANG_FILTER_STRICT is not enforced.
+*/
+
+package extender
+
+import (
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// SpikeClass returns the class of the current spike episode ("" when IDLE)
+func (s *NEXUSScheduler) SpikeClass() detector.SpikeClass {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.spikeClass
+}
+
+// setSpikeClass records the class of the current spike episode
+func (s *NEXUSScheduler) setSpikeClass(class detector.SpikeClass) {
+	s.stateMu.Lock()
+	previous := s.spikeClass
+	s.spikeClass = class
+	s.stateMu.Unlock()
+
+	s.metrics.SetSpikeClass(string(class))
+	if class != detector.SpikeClassNone {
+		s.updateEpisode(func(ep *EpisodeRecord) { ep.SpikeClass = class })
+	}
+	if previous != "" && class != "" && previous != class {
+		klog.Infof("Spike class changed: %s → %s", previous, class)
+	}
+}
+
+// spikePolicy returns the spike class policy for a gang in the current
+// episode, overridden by the gang's NexusPolicy
+func (s *NEXUSScheduler) spikePolicy(g *gang.Gang) config.SpikeClassPolicy {
+	return withGroupPolicy(s.cfg.SpikeClassPolicyFor(g.Group, string(s.SpikeClass())), g.Policy)
+}
+
+// localityFor combines the gang's spike class policy with the drain scale
+func (s *NEXUSScheduler) localityFor(g *gang.Gang, drainScale float64) scorer.Locality {
+	policy := s.spikePolicy(g)
+	return scorer.Locality{
+		Scale:  policy.LocalityScale * drainScale,
+		Spread: policy.Spread,
+	}
+}
@@ -0,0 +1,99 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/detector"
+)
+
+// prioritize posts the compat Prioritize request and returns the scores by host
+func prioritize(t *testing.T, s *NEXUSScheduler) map[string]int64 {
+	t.Helper()
+
+	body := `{"pod":` + compatPod + `,"nodes":` + compatNodes + `}`
+	rec := httptest.NewRecorder()
+	s.HandlePrioritize(rec, httptest.NewRequest(http.MethodPost, "/prioritize", strings.NewReader(body)))
+
+	var priorities []HostPriority
+	if err := json.Unmarshal(rec.Body.Bytes(), &priorities); err != nil {
+		t.Fatalf("decoding prioritize response: %v", err)
+	}
+	scores := make(map[string]int64, len(priorities))
+	for _, p := range priorities {
+		scores[p.Host] = p.Score
+	}
+	return scores
+}
+
+func TestSpikeClassPolicyFor(t *testing.T) {
+	cfg := &config.Config{SpikeClassPolicies: map[string]config.SpikeClassPolicy{
+		"latency":             {LocalityScale: 1.5},
+		"error":               {Spread: true},
+		"checkout-flow/error": {LocalityScale: 0.5},
+	}}
+
+	cases := []struct {
+		group, class string
+		want         config.SpikeClassPolicy
+	}{
+		{"checkout-flow", "traffic", config.SpikeClassPolicy{LocalityScale: 1}},
+		{"checkout-flow", "latency", config.SpikeClassPolicy{LocalityScale: 1.5}},
+		{"checkout-flow", "error", config.SpikeClassPolicy{LocalityScale: 0.5}},
+		{"browse-flow", "error", config.SpikeClassPolicy{LocalityScale: 1, Spread: true}},
+	}
+	for _, tc := range cases {
+		if got := cfg.SpikeClassPolicyFor(tc.group, tc.class); got != tc.want {
+			t.Errorf("SpikeClassPolicyFor(%s, %s) = %+v, want %+v", tc.group, tc.class, got, tc.want)
+		}
+	}
+}
+
+func TestPrioritizeSpikeClassPolicy(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.cfg.SpikeClassPolicies = map[string]config.SpikeClassPolicy{
+		"latency": {LocalityScale: 2},
+		"error":   {Spread: true},
+	}
+
+	// compatMember runs on node-2
+	s.setSpikeClass(detector.SpikeClassTraffic)
+	traffic := prioritize(t, s)
+	if traffic["node-2"] <= traffic["node-1"] {
+		t.Fatalf("traffic scores = %v, want node-2 (gang member) preferred", traffic)
+	}
+
+	s.setSpikeClass(detector.SpikeClassLatency)
+	latency := prioritize(t, s)
+	if gap, want := latency["node-2"]-latency["node-1"], 2*(traffic["node-2"]-traffic["node-1"]); gap != want {
+		t.Errorf("latency locality gap = %d, want %d (scale 2)", gap, want)
+	}
+
+	s.setSpikeClass(detector.SpikeClassError)
+	spread := prioritize(t, s)
+	if spread["node-1"] <= spread["node-2"] {
+		t.Errorf("error scores = %v, want node-1 (no gang member) preferred", spread)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), `nexus_spike_class{class="error"} 1`) {
+		t.Errorf("spike class not exported:\n%s", out.Body.String())
+	}
+}
+
+func TestFilterStrictSkippedWhenSpreading(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.gangFilterStrict = true
+	s.cfg.SpikeClassPolicies = map[string]config.SpikeClassPolicy{"error": {Spread: true}}
+	s.setSpikeClass(detector.SpikeClassError)
+
+	result := filter(t, s, "500m", testNode("node-1"), testNode("node-2"))
+	if len(result.Nodes.Items) != 2 {
+		t.Errorf("eligible nodes = %v, want both nodes while spreading", result.Nodes.Items)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Influence Sweep Experiment
+==========================
+With WEIGHT_SWEEP set (e.g. "0,0.5,1,2"), every new spike episode is
+assigned the next factor of the list, round-robin, and the Prioritize
+scores NEXUS returns during that episode are multiplied by it: 0 returns
+no opinion (the baseline), 1 is normal influence, above 1 lets NEXUS
+outweigh the other scheduler plugins. A drain reactivation keeps the
+episode's factor.
+
+Each spike check while an episode runs samples the overall p95 latency
+and attributes it to the episode's factor. GET /sweep reports the mean
+p95 per factor, the factor with the lowest mean, and its improvement
+over the baseline (factor 0 if swept, else the lowest factor).
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/metrics"
+)
+
+// sweepFactor accumulates the outcome of one influence factor
+type sweepFactor struct {
+	factor   float64
+	episodes int
+	samples  int
+	sumP95   float64
+}
+
+// weightSweep tracks the factor of the current episode and per-factor outcomes
+type weightSweep struct {
+	mu      sync.Mutex
+	factors []*sweepFactor
+	next    int          // index of the factor the next episode runs with
+	current *sweepFactor // nil outside episodes
+}
+
+// SweepReport is the /sweep response
+type SweepReport struct {
+	Enabled        bool                  `json:"enabled"`
+	CurrentFactor  *float64              `json:"currentFactor,omitempty"`
+	Results        []metrics.SweepResult `json:"results"`
+	BestFactor     *float64              `json:"bestFactor,omitempty"`
+	BaselineFactor *float64              `json:"baselineFactor,omitempty"`
+	ImprovementMs  float64               `json:"improvementMs"`
+	ImprovementPct float64               `json:"improvementPct"`
+}
+
+// sweepFactors builds the per-factor accumulators for the configured list
+func sweepFactors(factors []float64) []*sweepFactor {
+	accumulators := make([]*sweepFactor, 0, len(factors))
+	for _, f := range factors {
+		accumulators = append(accumulators, &sweepFactor{factor: f})
+	}
+	return accumulators
+}
+
+// startSweepEpisode assigns the next sweep factor to a new episode
+func (s *NEXUSScheduler) startSweepEpisode() {
+	s.sweep.mu.Lock()
+	defer s.sweep.mu.Unlock()
+	if len(s.sweep.factors) == 0 {
+		return
+	}
+	s.sweep.current = s.sweep.factors[s.sweep.next]
+	s.sweep.next = (s.sweep.next + 1) % len(s.sweep.factors)
+	s.sweep.current.episodes++
+	klog.Infof("Weight sweep: episode runs with influence factor %g", s.sweep.current.factor)
+	s.publishSweepLocked()
+}
+
+// endSweepEpisode stops attributing samples to the episode's factor
+func (s *NEXUSScheduler) endSweepEpisode() {
+	s.sweep.mu.Lock()
+	defer s.sweep.mu.Unlock()
+	s.sweep.current = nil
+}
+
+// influenceFactor is the multiplier applied to Prioritize scores (1 = sweep off)
+func (s *NEXUSScheduler) influenceFactor() float64 {
+	s.sweep.mu.Lock()
+	defer s.sweep.mu.Unlock()
+	if s.sweep.current == nil {
+		return 1
+	}
+	return s.sweep.current.factor
+}
+
+// scaleInfluence applies the episode's influence factor to extender scores
+func scaleInfluence(priorities []HostPriority, factor float64) []HostPriority {
+	if factor == 1 {
+		return priorities
+	}
+	for i := range priorities {
+		priorities[i].Score = int64(math.Round(float64(priorities[i].Score) * factor))
+	}
+	return priorities
+}
+
+// sampleSweep attributes the current p95 latency to the episode's factor
+func (s *NEXUSScheduler) sampleSweep() {
+	s.sweep.mu.Lock()
+	running := s.sweep.current != nil
+	s.sweep.mu.Unlock()
+	if !running {
+		return
+	}
+
+	p95, err := s.spikeDetector.P95Latency()
+	if err != nil {
+		klog.V(2).Infof("Weight sweep: failed to query p95 latency: %v", err)
+		return
+	}
+	if p95 == 0 || math.IsNaN(p95) {
+		return // no traffic in the window
+	}
+
+	s.sweep.mu.Lock()
+	defer s.sweep.mu.Unlock()
+	if s.sweep.current == nil {
+		return
+	}
+	s.sweep.current.samples++
+	s.sweep.current.sumP95 += p95
+	s.publishSweepLocked()
+}
+
+// publishSweepLocked pushes the sweep state to the metrics; s.sweep.mu must be held
+func (s *NEXUSScheduler) publishSweepLocked() {
+	current := 1.0
+	if s.sweep.current != nil {
+		current = s.sweep.current.factor
+	}
+	s.metrics.SetWeightSweep(current, s.sweepResultsLocked())
+}
+
+// sweepResultsLocked returns the per-factor outcomes; s.sweep.mu must be held
+func (s *NEXUSScheduler) sweepResultsLocked() []metrics.SweepResult {
+	results := make([]metrics.SweepResult, 0, len(s.sweep.factors))
+	for _, f := range s.sweep.factors {
+		r := metrics.SweepResult{Factor: f.factor, Episodes: f.episodes, Samples: f.samples}
+		if f.samples > 0 {
+			r.MeanP95Ms = f.sumP95 / float64(f.samples)
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Factor < results[j].Factor })
+	return results
+}
+
+// SweepReport summarises which influence factor yielded the lowest latency
+func (s *NEXUSScheduler) SweepReport() SweepReport {
+	s.sweep.mu.Lock()
+	defer s.sweep.mu.Unlock()
+
+	report := SweepReport{Enabled: len(s.sweep.factors) > 0, Results: s.sweepResultsLocked()}
+	if s.sweep.current != nil {
+		factor := s.sweep.current.factor
+		report.CurrentFactor = &factor
+	}
+
+	var best, baseline *metrics.SweepResult
+	for i := range report.Results {
+		r := &report.Results[i]
+		if r.Samples == 0 {
+			continue
+		}
+		if baseline == nil {
+			baseline = r // results are sorted: the lowest sampled factor (0 if swept)
+		}
+		if best == nil || r.MeanP95Ms < best.MeanP95Ms {
+			best = r
+		}
+	}
+	if best != nil {
+		report.BestFactor = &best.Factor
+		report.BaselineFactor = &baseline.Factor
+		report.ImprovementMs = baseline.MeanP95Ms - best.MeanP95Ms
+		if baseline.MeanP95Ms > 0 {
+			report.ImprovementPct = report.ImprovementMs / baseline.MeanP95Ms * 100
+		}
+	}
+	return report
+}
+
+// SweepHandler returns the influence sweep report
+func (s *NEXUSScheduler) SweepHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.SweepReport())
+}
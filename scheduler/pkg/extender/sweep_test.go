@@ -0,0 +1,77 @@
+package extender
+
+import (
+	"context"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWeightSweepScalesPrioritize(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.sweep.factors = sweepFactors([]float64{0, 2})
+
+	base := prioritize(t, s) // no episode factor yet: normal influence
+
+	s.startSweepEpisode()
+	for host, score := range prioritize(t, s) {
+		if score != 0 {
+			t.Errorf("factor 0: %s scored %d, want no opinion", host, score)
+		}
+	}
+
+	s.endSweepEpisode()
+	s.startSweepEpisode()
+	for host, score := range prioritize(t, s) {
+		if score != 2*base[host] {
+			t.Errorf("factor 2: %s scored %d, want %d", host, score, 2*base[host])
+		}
+	}
+
+	s.endSweepEpisode()
+	s.startSweepEpisode() // wraps around to the first factor
+	if got := s.influenceFactor(); got != 0 {
+		t.Errorf("third episode factor = %v, want 0", got)
+	}
+}
+
+func TestWeightSweepReport(t *testing.T) {
+	prom := fakePrometheus(t, 400)
+	s := newTestScheduler(t, StateActive)
+	s.sweep.factors = sweepFactors([]float64{0, 1})
+
+	s.startSweepEpisode() // factor 0
+	s.sampleSweep()
+	s.dissolveGangs(context.Background())
+
+	s.SetState(StateActive)
+	s.startSweepEpisode() // factor 1
+	prom.SetDefault(300)
+	s.sampleSweep()
+	prom.SetDefault(math.NaN()) // no traffic: not attributed
+	s.sampleSweep()
+
+	report := s.SweepReport()
+	if report.BestFactor == nil || *report.BestFactor != 1 {
+		t.Fatalf("best factor = %v, want 1 (report %+v)", report.BestFactor, report)
+	}
+	if *report.BaselineFactor != 0 || report.ImprovementMs != 100 || report.ImprovementPct != 25 {
+		t.Errorf("report = %+v, want 100ms (25%%) better than factor 0", report)
+	}
+	if report.Results[1].Samples != 1 {
+		t.Errorf("factor 1 samples = %d, want 1", report.Results[1].Samples)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{
+		`nexus_weight_sweep_factor 1`,
+		`nexus_weight_sweep_episodes_total{factor="0"} 1`,
+		`nexus_weight_sweep_p95_ms{factor="1"} 300`,
+	} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s in:\n%s", want, out.Body.String())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+System Pod Guard
+================
+Control-plane and daemon workloads are never influenced, whatever the
+dependency graph or a misconfigured spike says. Filter and Prioritize
+answer with no opinion, and the admission webhook does not label, for a
+pod that:
+
+  - runs in a SYSTEM_NAMESPACES namespace (default kube-system)
+  - uses a SYSTEM_PRIORITY_CLASSES priority class (default
+    system-node-critical, system-cluster-critical), or has a resolved
+    priority in the system-critical range
+  - matches INFLUENCE_EXCLUDE_SELECTOR (a label selector, e.g.
+    "app.kubernetes.io/component=daemon,tier notin (frontend)")
+
+The guard is checked before any gang lookup. An invalid selector is
+reported at startup and ignored; the namespace and priority checks still
+apply.
+*/
+
+package extender
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// systemCriticalPriority is the lowest priority reserved for system-critical
+// pods (scheduling.SystemCriticalPriority)
+const systemCriticalPriority = 2000000000
+
+// systemPodGuard decides which pods NEXUS must never influence
+type systemPodGuard struct {
+	namespaces      map[string]bool
+	priorityClasses map[string]bool
+	selector        labels.Selector // nil = no exclusion selector
+}
+
+// newSystemPodGuard builds the guard from the configuration
+func newSystemPodGuard(cfg *config.Config) *systemPodGuard {
+	g := &systemPodGuard{
+		namespaces:      make(map[string]bool, len(cfg.SystemNamespaces)),
+		priorityClasses: make(map[string]bool, len(cfg.SystemPriorityClasses)),
+	}
+	for _, ns := range cfg.SystemNamespaces {
+		g.namespaces[ns] = true
+	}
+	for _, pc := range cfg.SystemPriorityClasses {
+		g.priorityClasses[pc] = true
+	}
+	if cfg.InfluenceExcludeSelector != "" {
+		selector, err := labels.Parse(cfg.InfluenceExcludeSelector)
+		if err != nil {
+			klog.Warningf("Ignoring INFLUENCE_EXCLUDE_SELECTOR %q: %v", cfg.InfluenceExcludeSelector, err)
+		} else {
+			g.selector = selector
+		}
+	}
+	return g
+}
+
+// reason returns why a pod must not be influenced ("" = it may be)
+func (g *systemPodGuard) reason(pod *v1.Pod) string {
+	switch {
+	case g.namespaces[pod.Namespace]:
+		return "namespace " + pod.Namespace
+	case g.priorityClasses[pod.Spec.PriorityClassName]:
+		return "priority class " + pod.Spec.PriorityClassName
+	case pod.Spec.Priority != nil && *pod.Spec.Priority >= systemCriticalPriority:
+		return "system-critical priority"
+	case g.selector != nil && g.selector.Matches(labels.Set(pod.Labels)):
+		return "exclusion selector"
+	}
+	return ""
+}
+
+// systemPod reports whether a pod is protected by the system pod guard,
+// logging and counting the skip
+func (s *NEXUSScheduler) systemPod(phase string, pod *v1.Pod) bool {
+	reason := s.systemPods.reason(pod)
+	if reason == "" {
+		return false
+	}
+	klog.V(2).Infof("%s: Pod %s/%s is a system pod (%s) — no opinion", phase, pod.Namespace, pod.Name, reason)
+	s.metrics.IncrementCounter("system_pods_skipped")
+	return true
+}
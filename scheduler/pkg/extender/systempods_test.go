@@ -0,0 +1,74 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestSystemPodGuardReasons(t *testing.T) {
+	t.Setenv("SYSTEM_NAMESPACES", "")
+	t.Setenv("SYSTEM_PRIORITY_CLASSES", "")
+	cfg := config.LoadConfig()
+	cfg.InfluenceExcludeSelector = "app.kubernetes.io/component=daemon"
+	guard := newSystemPodGuard(cfg)
+
+	critical := int32(systemCriticalPriority)
+	cases := []struct {
+		name string
+		pod  v1.Pod
+		want bool
+	}{
+		{"workload", v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}}, false},
+		{"kube-system", v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"}}, true},
+		{"node critical", v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec:       v1.PodSpec{PriorityClassName: "system-node-critical"},
+		}, true},
+		{"critical priority", v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec:       v1.PodSpec{Priority: &critical},
+		}, true},
+		{"selector", v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Labels:    map[string]string{"app.kubernetes.io/component": "daemon"},
+		}}, true},
+	}
+	for _, tc := range cases {
+		if got := guard.reason(&tc.pod) != ""; got != tc.want {
+			t.Errorf("%s: protected = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// An invalid selector is ignored, the other checks still apply
+	cfg.InfluenceExcludeSelector = "tier in (("
+	guard = newSystemPodGuard(cfg)
+	if guard.selector != nil || guard.reason(&cases[1].pod) == "" {
+		t.Error("invalid selector not ignored")
+	}
+}
+
+func TestSystemPodsGetNoOpinion(t *testing.T) {
+	s := newTestScheduler(t, StateActive, compatMember)
+	s.gangFilterStrict = true
+	// compatPod is a gang member in "default"
+	s.systemPods = newSystemPodGuard(&config.Config{SystemNamespaces: []string{"default"}})
+
+	if scores := prioritize(t, s); scores["node-1"] != scores["node-2"] {
+		t.Errorf("scores = %v, want no opinion for a system pod", scores)
+	}
+	if result := filter(t, s, "500m", testNode("node-1"), testNode("node-2")); len(result.Nodes.Items) != 2 {
+		t.Errorf("eligible nodes = %v, want every node for a system pod", result.Nodes.Items)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	if !strings.Contains(out.Body.String(), "nexus_system_pods_skipped_total 2\n") {
+		t.Error("nexus_system_pods_skipped_total does not count both calls")
+	}
+}
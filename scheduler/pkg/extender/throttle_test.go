@@ -0,0 +1,44 @@
+package extender
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPrioritizeThrottledFallsBackToLastKnownCounts(t *testing.T) {
+	t.Setenv("KUBE_API_RETRY_STEPS", "1") // fail throttled calls without backoff
+	s := newTestScheduler(t, StateActive, compatMember)
+
+	live := prioritize(t, s)
+	if live["node-2"] <= live["node-1"] {
+		t.Fatalf("live scores = %v, want node-2 (gang member) preferred", live)
+	}
+
+	s.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, apierrors.NewTooManyRequests("slow down", 1)
+	})
+	if cached := prioritize(t, s); cached["node-1"] != live["node-1"] || cached["node-2"] != live["node-2"] {
+		t.Errorf("throttled scores = %v, want the last known good scores %v", cached, live)
+	}
+
+	// Without a usable count the gang preference is lost, but not silently
+	s.nodeScorer.ResetMemberCounts()
+	blind := prioritize(t, s)
+	if d := blind["node-2"] - blind["node-1"]; d != 1 && d != -1 {
+		t.Errorf("scores without counts = %v, want no locality preference (only a broken tie)", blind)
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{"nexus_degraded_decisions_total 2", "nexus_api_throttled_total 4"} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Score Tie-Breaking
+==================
+Nodes that tie on score leave the choice to kube-scheduler, which picks
+one of them at random, and the order of the nodes in the request varies
+between runs. Both hurt the reproducibility of experiments, so Prioritize
+returns its scores highest first and orders tied nodes with
+SCORE_TIE_BREAK:
+
+  name   → node name
+  hash   → FNV hash of pod and node (default): reproducible per pod, but
+           does not keep favouring the same node for every pod
+  random → seeded random order (SCORE_TIE_BREAK_SEED), for controlled
+           variance studies; reproducible for the same sequence of calls
+
+Every node in a tie but the first is lowered by its rank in the tie, so
+kube-scheduler sees a strict order. A node is never lowered to the next
+lower score, or below 0: breaking a tie never reorders nodes that did not
+tie, and a tie without room below it stays a tie from there on.
+*/
+
+package extender
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+// tieBreaker orders nodes with equal Prioritize scores
+type tieBreaker struct {
+	mode string
+
+	mu   sync.Mutex
+	rand *rand.Rand // random mode only
+}
+
+// newTieBreaker creates the tie-breaker for SCORE_TIE_BREAK
+func newTieBreaker(mode string, seed int64) *tieBreaker {
+	tb := &tieBreaker{mode: mode}
+	if mode == config.TieBreakRandom {
+		tb.rand = rand.New(rand.NewSource(seed))
+	}
+	return tb
+}
+
+// keys returns the sort key of each node among tied nodes (lower first)
+func (tb *tieBreaker) keys(pod *v1.Pod, priorities []HostPriority) map[string]uint64 {
+	keys := make(map[string]uint64, len(priorities))
+	switch tb.mode {
+	case config.TieBreakName:
+		return nil // compared by name
+	case config.TieBreakRandom:
+		// Drawn in node name order, so the request's order cannot matter
+		hosts := make([]string, 0, len(priorities))
+		for _, p := range priorities {
+			hosts = append(hosts, p.Host)
+		}
+		sort.Strings(hosts)
+		tb.mu.Lock()
+		for _, host := range hosts {
+			keys[host] = tb.rand.Uint64()
+		}
+		tb.mu.Unlock()
+	default:
+		for _, p := range priorities {
+			h := fnv.New64a()
+			h.Write([]byte(pod.Namespace + "/" + pod.Name + "/" + p.Host))
+			keys[p.Host] = h.Sum64()
+		}
+	}
+	return keys
+}
+
+// apply sorts priorities highest first, breaks the ties and reports
+// whether any tie was broken
+func (tb *tieBreaker) apply(pod *v1.Pod, priorities []HostPriority) bool {
+	keys := tb.keys(pod, priorities)
+	sort.SliceStable(priorities, func(i, j int) bool {
+		a, b := priorities[i], priorities[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if keys != nil && keys[a.Host] != keys[b.Host] {
+			return keys[a.Host] < keys[b.Host]
+		}
+		return a.Host < b.Host
+	})
+
+	broken := false
+	for start := 0; start < len(priorities); {
+		score := priorities[start].Score
+		end := start + 1
+		for end < len(priorities) && priorities[end].Score == score {
+			end++
+		}
+		next := int64(-1) // scores cannot go below 0
+		if end < len(priorities) {
+			next = priorities[end].Score
+		}
+		for rank := 1; rank < end-start; rank++ {
+			lower := int64(rank)
+			if lower > score-next-1 {
+				lower = score - next - 1
+			}
+			if lower > 0 {
+				priorities[start+rank].Score = score - lower
+				broken = true
+			}
+		}
+		start = end
+	}
+	return broken
+}
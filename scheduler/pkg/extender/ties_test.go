@@ -0,0 +1,69 @@
+package extender
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nexus-scheduler/pkg/config"
+)
+
+func TestTieBreakByName(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cartservice-abc", Namespace: "default"}}
+	cases := []struct {
+		name   string
+		in     []HostPriority
+		want   []HostPriority
+		broken bool
+	}{
+		{
+			name:   "ties ranked below the first",
+			in:     []HostPriority{{"node-c", 50}, {"node-a", 50}, {"node-d", 90}, {"node-b", 50}},
+			want:   []HostPriority{{"node-d", 90}, {"node-a", 50}, {"node-b", 49}, {"node-c", 48}},
+			broken: true,
+		},
+		{
+			name:   "never down to the next lower score",
+			in:     []HostPriority{{"node-c", 50}, {"node-b", 50}, {"node-a", 50}, {"node-d", 48}},
+			want:   []HostPriority{{"node-a", 50}, {"node-b", 49}, {"node-c", 49}, {"node-d", 48}},
+			broken: true,
+		},
+		{
+			name: "no opinion stays equal",
+			in:   []HostPriority{{"node-b", 0}, {"node-a", 0}},
+			want: []HostPriority{{"node-a", 0}, {"node-b", 0}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			broken := newTieBreaker(config.TieBreakName, 0).apply(pod, tc.in)
+			if !reflect.DeepEqual(tc.in, tc.want) || broken != tc.broken {
+				t.Errorf("apply = %v (broken %v), want %v (broken %v)", tc.in, broken, tc.want, tc.broken)
+			}
+		})
+	}
+}
+
+func TestTieBreakIgnoresRequestOrder(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cartservice-abc", Namespace: "default"}}
+	forward := func() []HostPriority {
+		return []HostPriority{{"node-1", 70}, {"node-2", 70}, {"node-3", 70}, {"node-4", 10}}
+	}
+	reversed := func() []HostPriority {
+		return []HostPriority{{"node-4", 10}, {"node-3", 70}, {"node-2", 70}, {"node-1", 70}}
+	}
+
+	for _, mode := range []string{config.TieBreakHash, config.TieBreakRandom} {
+		a, b := forward(), reversed()
+		newTieBreaker(mode, 42).apply(pod, a)
+		newTieBreaker(mode, 42).apply(pod, b)
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%s: %v for the request order, %v reversed", mode, a, b)
+		}
+		if a[0].Score != 70 || a[1].Score != 69 || a[2].Score != 68 || a[3].Host != "node-4" {
+			t.Errorf("%s: %v, want a strict order above node-4", mode, a)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Episode Tracing
+===============
+With OTEL_EXPORTER_OTLP_ENDPOINT set, every spike episode is recorded as
+one trace (pkg/export/otlp.go): the activation stages (detection, graph
+build, gang formation, pre-warm) as they complete, one span per ACTIVE or
+DRAINING window, and the dissolution. The trace is sent when the episode
+dissolves, in the background so it never delays the return to IDLE.
+
+Episodes resumed by restart recovery have lost their activation stages
+and are not traced.
+*/
+
+package extender
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/export"
+)
+
+// Span names of an episode trace
+const (
+	spanEpisode       = "nexus.episode"
+	spanDetection     = "nexus.detection"
+	spanGraphBuild    = "nexus.graph_build"
+	spanGangFormation = "nexus.gang_formation"
+	spanPrewarm       = "nexus.prewarm"
+	spanScheduling    = "nexus.scheduling"
+	spanDraining      = "nexus.draining"
+	spanDissolution   = "nexus.dissolution"
+)
+
+// episodeTrace collects the stage spans of the running episode
+type episodeTrace struct {
+	mu     sync.Mutex
+	start  time.Time     // zero = no episode traced
+	stages []export.Span // finished stages, oldest first
+	window *export.Span  // running ACTIVE or DRAINING window
+}
+
+// beginTrace starts the trace of an episode detected at start
+func (s *NEXUSScheduler) beginTrace(start time.Time) {
+	if s.traces == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.trace.start = start
+	s.trace.stages = nil
+	s.trace.window = nil
+}
+
+// traceStage records a finished stage of the traced episode
+func (s *NEXUSScheduler) traceStage(name string, start, end time.Time, attributes map[string]string) {
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	if s.trace.start.IsZero() {
+		return
+	}
+	s.trace.stages = append(s.trace.stages, export.Span{Name: name, Start: start, End: end, Attributes: attributes})
+}
+
+// traceWindow ends the running window at now and, unless name is "",
+// opens the next one
+func (s *NEXUSScheduler) traceWindow(name string, now time.Time) {
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	if s.trace.start.IsZero() {
+		return
+	}
+	if s.trace.window != nil {
+		s.trace.window.End = now
+		s.trace.stages = append(s.trace.stages, *s.trace.window)
+		s.trace.window = nil
+	}
+	if name != "" {
+		s.trace.window = &export.Span{Name: name, Start: now}
+	}
+}
+
+// finishTrace closes the episode span at end and returns every span, the
+// episode first (nil when no episode was traced)
+func (s *NEXUSScheduler) finishTrace(end time.Time, attributes map[string]string) []export.Span {
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	if s.trace.start.IsZero() {
+		return nil
+	}
+	spans := append([]export.Span{{Name: spanEpisode, Start: s.trace.start, End: end, Attributes: attributes}}, s.trace.stages...)
+	s.trace.start = time.Time{}
+	s.trace.stages = nil
+	s.trace.window = nil
+	return spans
+}
+
+// exportTrace finishes the episode trace and sends it in the background
+func (s *NEXUSScheduler) exportTrace(episodeID string, end time.Time, attributes map[string]string) {
+	if s.traces == nil {
+		return
+	}
+	spans := s.finishTrace(end, attributes)
+	if spans == nil {
+		klog.V(2).Infof("Episode %s not traced (resumed after a restart)", episodeID)
+		return
+	}
+
+	go func() {
+		if err := s.traces.Export(context.Background(), episodeID, spans); err != nil {
+			klog.Warningf("Episode trace export failed: %v", err)
+			s.metrics.IncrementCounter("trace_export_failures")
+			return
+		}
+		klog.V(2).Infof("Episode %s exported as a trace of %d spans", episodeID, len(spans))
+		s.metrics.IncrementCounter("traces_exported")
+	}()
+}
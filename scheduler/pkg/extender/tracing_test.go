@@ -0,0 +1,67 @@
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/export"
+)
+
+func TestEpisodeTrace(t *testing.T) {
+	received := make(chan []string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						Name string `json:"name"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var names []string
+		for _, span := range body.ResourceSpans[0].ScopeSpans[0].Spans {
+			names = append(names, span.Name)
+		}
+		received <- names
+	}))
+	defer srv.Close()
+
+	s := newTestScheduler(t, StateActive)
+	s.traces = export.NewTraceExporter(&config.Config{OTLPEndpoint: srv.URL, OTLPServiceName: "nexus-scheduler"})
+
+	start := time.Now().Add(-time.Minute)
+	s.beginTrace(start)
+	s.traceStage(spanDetection, start, start.Add(time.Second), nil)
+	s.traceStage(spanGraphBuild, start.Add(time.Second), start.Add(2*time.Second), nil)
+	s.traceStage(spanGangFormation, start.Add(2*time.Second), start.Add(3*time.Second), nil)
+	s.traceWindow(spanScheduling, start.Add(3*time.Second))
+	s.startDrain()
+	s.dissolveGangs(context.Background())
+
+	select {
+	case names := <-received:
+		want := []string{spanEpisode, spanDetection, spanGraphBuild, spanGangFormation, spanScheduling, spanDraining, spanDissolution}
+		if len(names) != len(want) {
+			t.Fatalf("spans = %v, want %v", names, want)
+		}
+		for i := range want {
+			if names[i] != want[i] {
+				t.Errorf("span %d = %s, want %s", i, names[i], want[i])
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trace exported")
+	}
+
+	// Episodes without a traced activation are not exported
+	if spans := s.finishTrace(time.Now(), nil); spans != nil {
+		t.Errorf("spans after export = %v, want none", spans)
+	}
+}
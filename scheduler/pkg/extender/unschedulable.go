@@ -0,0 +1,72 @@
+/*
+Unschedulable Nodes in Prioritize
+=================================
+kube-scheduler normally filters cordoned, tainted and pressured nodes
+before it asks for scores, but its snapshot can lag a cordon by a cycle
+and other extenders may pass nodes on. Prioritize therefore runs the same
+node checks as Filter (kube.Unschedulable) and scores only the nodes the
+pod can land on; the others score 0 and are marked unschedulable in the
+decision breakdown, so a cordoned node holding gang members never wins
+on locality. Normalization runs over the schedulable nodes only.
+
+Name-only requests (nodeCacheCapable) carry no node status and are scored
+as they come, as in Filter.
+*/
+
+package extender
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/kube"
+	"nexus-scheduler/pkg/scorer"
+)
+
+// splitSchedulable returns the candidate nodes the pod can land on and,
+// per candidate, whether it was left out
+func (s *NEXUSScheduler) splitSchedulable(ctx context.Context, pod *v1.Pod, args *ExtenderArgs, nodes *v1.NodeList) (*v1.NodeList, []bool) {
+	if args.Nodes == nil {
+		return nodes, nil
+	}
+	schedulable := &v1.NodeList{Items: make([]v1.Node, 0, len(nodes.Items))}
+	skipped := make([]bool, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if kube.NodeExcluded(node, s.cfg.NodeExcludeLabel) {
+			schedulable.Items = append(schedulable.Items, *node) // scored 0 by the scorer
+			continue
+		}
+		if problem, detail := kube.Unschedulable(pod, node); problem != "" {
+			klog.V(2).Infof("Prioritize: Pod %s: node %s scored 0 (%s)", pod.Name, node.Name, detail)
+			skipped[i] = true
+			continue
+		}
+		schedulable.Items = append(schedulable.Items, *node)
+	}
+	if n := len(nodes.Items) - len(schedulable.Items); n > 0 && !isSimulation(ctx) {
+		s.metrics.AddUnschedulableScored(n)
+	}
+	return schedulable, skipped
+}
+
+// withUnschedulable returns the breakdown of the schedulable nodes in the
+// order of all candidates, the left-out ones scoring 0
+func withUnschedulable(breakdown []scorer.ScoreBreakdown, nodes *v1.NodeList, skipped []bool) []scorer.ScoreBreakdown {
+	if skipped == nil {
+		return breakdown
+	}
+	all := make([]scorer.ScoreBreakdown, 0, len(nodes.Items))
+	next := 0
+	for i := range nodes.Items {
+		if skipped[i] {
+			all = append(all, scorer.ScoreBreakdown{Host: nodes.Items[i].Name, Unschedulable: true})
+			continue
+		}
+		all = append(all, breakdown[next])
+		next++
+	}
+	return all
+}
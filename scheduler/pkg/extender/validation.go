@@ -0,0 +1,180 @@
+/*
+Annotation Validating Webhook
+=============================
+A malformed nexus.io annotation is otherwise only noticed at spike time,
+when the dependency graph quietly comes out wrong. With
+VALIDATION_WEBHOOK_ENABLED, Deployment creates and updates are reviewed
+on their own TLS port (VALIDATION_WEBHOOK_ADDR, the gang label webhook's
+certificate) and rejected with the problems found in the pod template's
+annotations (see graph.ValidateAnnotations):
+
+  kubectl apply -f checkoutservice.yaml
+  admission webhook "annotations.nexus.io" denied the request: nexus.io
+  annotations of default/checkoutservice: nexus.io/depends-on lists
+  unknown service "cartsevice"
+
+Known services are the Deployments of the namespace and the application
+profile; cycles are checked against their depends-on annotations.
+Deployments without nexus.io annotations, and updates leaving them
+unchanged, are allowed without listing anything. If the Deployments
+cannot be listed the request is allowed with a warning. Reviews are
+counted in nexus_annotation_reviews_total{result}.
+*/
+
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+// Annotation review results
+const (
+	reviewAllowed   = "allowed"
+	reviewRejected  = "rejected"
+	reviewUnchecked = "unchecked"
+)
+
+// handleValidate processes Deployment admission reviews from the API server
+func (s *NEXUSScheduler) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		klog.Errorf("Failed to decode admission review: %v", err)
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = s.reviewAnnotations(r.Context(), review.Request)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// reviewAnnotations allows or rejects a Deployment by its nexus.io annotations
+func (s *NEXUSScheduler) reviewAnnotations(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return response
+	}
+
+	var deployment appsv1.Deployment
+	if err := json.Unmarshal(req.Object.Raw, &deployment); err != nil {
+		klog.Warningf("Validation webhook: failed to decode deployment: %v", err)
+		return response
+	}
+	if deployment.Namespace == "" {
+		deployment.Namespace = req.Namespace // not yet set on create
+	}
+	annotations := nexusAnnotations(deployment.Spec.Template.Annotations)
+	if len(annotations) == 0 {
+		return response
+	}
+	if req.Operation == admissionv1.Update {
+		var old appsv1.Deployment
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err == nil &&
+			reflect.DeepEqual(nexusAnnotations(old.Spec.Template.Annotations), annotations) {
+			return response // rollouts of unchanged annotations are not re-checked
+		}
+	}
+
+	known, edges, err := s.deployedServices(ctx, deployment.Namespace, deployment.Name)
+	if err != nil {
+		klog.Warningf("Validation webhook: allowing %s/%s unchecked: %v", deployment.Namespace, deployment.Name, err)
+		response.Warnings = []string{"nexus.io annotations not validated: " + err.Error()}
+		s.metrics.RecordAnnotationReview(reviewUnchecked)
+		return response
+	}
+
+	service := deploymentService(&deployment, s.serviceLabel)
+	problems := graph.ValidateAnnotations(service, annotations, known, edges)
+	if len(problems) == 0 {
+		s.metrics.RecordAnnotationReview(reviewAllowed)
+		return response
+	}
+
+	message := fmt.Sprintf("nexus.io annotations of %s/%s: %s", deployment.Namespace, deployment.Name, strings.Join(problems, "; "))
+	klog.V(2).Infof("Validation webhook: rejecting %s", message)
+	s.metrics.RecordAnnotationReview(reviewRejected)
+	response.Allowed = false
+	response.Result = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: message,
+		Reason:  metav1.StatusReasonInvalid,
+		Code:    http.StatusUnprocessableEntity,
+	}
+	return response
+}
+
+// nexusAnnotations returns the annotations the dependency graph reads
+func nexusAnnotations(annotations map[string]string) map[string]string {
+	selected := make(map[string]string)
+	for _, key := range []string{graph.AnnotationServiceGroup, graph.AnnotationDependsOn, graph.AnnotationSLOP95} {
+		if value, ok := annotations[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+// deployedServices returns the services of the namespace's Deployments and
+// their depends-on lists, except for the Deployment under review
+func (s *NEXUSScheduler) deployedServices(ctx context.Context, namespace, exclude string) (map[string]bool, map[string][]string, error) {
+	var list *appsv1.DeploymentList
+	err := s.apiGuard.Do(ctx, "list deployments for annotation validation", func(ctx context.Context) error {
+		var err error
+		list, err = s.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := make(map[string]bool, len(list.Items))
+	edges := make(map[string][]string)
+	for i := range list.Items {
+		d := &list.Items[i]
+		if d.Name == exclude {
+			continue
+		}
+		service := deploymentService(d, s.serviceLabel)
+		known[service] = true
+		for _, dep := range strings.Split(d.Spec.Template.Annotations[graph.AnnotationDependsOn], ",") {
+			if dep = strings.TrimSpace(dep); dep != "" {
+				edges[service] = append(edges[service], dep)
+			}
+		}
+	}
+	return known, edges, nil
+}
+
+// deploymentService is the service a Deployment's pods belong to: their
+// service label, or the Deployment name
+func deploymentService(d *appsv1.Deployment, labelKey string) string {
+	if svc := d.Spec.Template.Labels[labelKey]; svc != "" {
+		return svc
+	}
+	return d.Name
+}
+
+// StartValidationWebhookServer serves the validating webhook over TLS on its own port
+func (s *NEXUSScheduler) StartValidationWebhookServer(addr, certFile, keyFile string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate-deployments", s.handleValidate)
+
+	klog.Infof("Starting NEXUS annotation validating webhook on %s (TLS)", addr)
+	go func() {
+		if err := http.ListenAndServeTLS(addr, certFile, keyFile, mux); err != nil {
+			klog.Errorf("Validation webhook server on %s stopped: %v", addr, err)
+		}
+	}()
+}
@@ -0,0 +1,85 @@
+package extender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidationWebhook(t *testing.T) {
+	s := newTestScheduler(t, StateIdle)
+	ledger := annotatedDeployment("ledgerservice", map[string]string{"nexus.io/depends-on": "checkoutservice"})
+	if _, err := s.clientset.AppsV1().Deployments("default").Create(context.Background(), ledger, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	review := func(op admissionv1.Operation, d, old *appsv1.Deployment) *admissionv1.AdmissionResponse {
+		t.Helper()
+		req := &admissionv1.AdmissionRequest{UID: "u1", Operation: op, Namespace: "default"}
+		req.Object = k8sruntime.RawExtension{Raw: mustJSON(t, d)}
+		if old != nil {
+			req.OldObject = k8sruntime.RawExtension{Raw: mustJSON(t, old)}
+		}
+		body := mustJSON(t, admissionv1.AdmissionReview{Request: req})
+		rec := httptest.NewRecorder()
+		s.handleValidate(rec, httptest.NewRequest(http.MethodPost, "/validate-deployments", bytes.NewReader(body)))
+		var out admissionv1.AdmissionReview
+		if err := json.NewDecoder(rec.Body).Decode(&out); err != nil || out.Response == nil || out.Response.UID != "u1" {
+			t.Fatalf("review response %s: %v", rec.Body, err)
+		}
+		return out.Response
+	}
+
+	valid := annotatedDeployment("checkoutservice", map[string]string{
+		"nexus.io/service-group": "checkout-flow",
+		"nexus.io/depends-on":    "cartservice,paymentservice",
+	})
+	if resp := review(admissionv1.Create, valid, nil); !resp.Allowed {
+		t.Errorf("valid annotations rejected: %+v", resp.Result)
+	}
+
+	cyclic := annotatedDeployment("checkoutservice", map[string]string{"nexus.io/depends-on": "ledgerservice,cartsevice"})
+	resp := review(admissionv1.Update, cyclic, valid)
+	if resp.Allowed || resp.Result == nil || resp.Result.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("malformed annotations allowed: %+v", resp)
+	}
+	for _, want := range []string{`unknown service "cartsevice"`, "checkoutservice → ledgerservice → checkoutservice"} {
+		if !strings.Contains(resp.Result.Message, want) {
+			t.Errorf("message %q does not mention %q", resp.Result.Message, want)
+		}
+	}
+
+	// Unchanged annotations and Deployments without any are not checked
+	if resp := review(admissionv1.Update, cyclic, cyclic); !resp.Allowed {
+		t.Error("update with unchanged annotations rejected")
+	}
+	if resp := review(admissionv1.Create, annotatedDeployment("adservice", nil), nil); !resp.Allowed {
+		t.Error("deployment without annotations rejected")
+	}
+
+	out := httptest.NewRecorder()
+	s.metrics.WriteAllMetrics(out)
+	for _, want := range []string{`nexus_annotation_reviews_total{result="allowed"} 1`, `nexus_annotation_reviews_total{result="rejected"} 1`} {
+		if !strings.Contains(out.Body.String(), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
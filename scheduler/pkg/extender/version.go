@@ -0,0 +1,64 @@
+/*
+Version and Feature Flags
+=========================
+Experiment results are only comparable when it is known which build
+produced them and which optional subsystems were switched on:
+
+  GET /version → Build information (pkg/version) and enabled subsystems
+
+The same is exported as nexus_build_info and nexus_feature_enabled, so
+every scraped run carries its provenance.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/version"
+)
+
+// versionResponse is the /version response
+type versionResponse struct {
+	version.Info
+	Features map[string]bool `json:"features"`
+}
+
+// Features reports which optional subsystems the instance runs with
+func (s *NEXUSScheduler) Features() map[string]bool {
+	return map[string]bool{
+		"webhook":               s.cfg.WebhookEnabled,
+		"validation_webhook":    s.cfg.ValidationWebhookEnabled,
+		"nexus_policies":        s.policies.client != nil,
+		"pod_groups":            s.podGroups != nil,
+		"keda_trigger":          s.kedaWatcher != nil,
+		"eviction_protection":   s.protector != nil,
+		"affinity_hints":        s.hinter != nil,
+		"gang_member_labels":    s.labeler != nil,
+		"vpa_recommendations":   s.vpa != nil,
+		"hpa_cooldown":          s.hpa != nil,
+		"utilization_scoring":   s.cfg.UtilizationScoring,
+		"state_recovery":        s.stateStore != nil,
+		"decision_export":       s.decisions != nil,
+		"history_store":         s.store != nil,
+		"counter_snapshots":     s.cfg.MetricsSnapshotPath != "",
+		"weight_sweep":          len(s.sweep.factors) > 0,
+		"shadow_scorers":        s.shadows != nil,
+		"gang_member_cache":     s.cfg.GangMemberCache,
+		"node_failure_watch":    s.cfg.NodeFailureWatch,
+		"scheduling_latency":    s.cfg.SchedulingLatencyWatch,
+		"sharded_scoring":       s.cfg.ScoringShards > 1,
+		"placement_plans":       s.cfg.GangPlacement == config.GangPlacementPlanned,
+		"gang_filter_strict":    s.gangFilterStrict,
+		"scheduler_allowlist":   len(s.cfg.SchedulerNames) > 0,
+		"influence_preexisting": s.influencePreexisting,
+	}
+}
+
+// VersionHandler returns the build information and the enabled subsystems
+func (s *NEXUSScheduler) VersionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versionResponse{Info: version.Get(), Features: s.Features()})
+}
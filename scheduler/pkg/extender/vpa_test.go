@@ -0,0 +1,72 @@
+package extender
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+// fakeVPAClient returns a dynamic client holding one VPA for checkoutservice
+// recommending cpu for its "server" container
+func fakeVPAClient(cpu string) *dynamicfake.FakeDynamicClient {
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "checkoutservice-vpa", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "checkoutservice"},
+		},
+		"status": map[string]interface{}{
+			"recommendation": map[string]interface{}{
+				"containerRecommendations": []interface{}{
+					map[string]interface{}{
+						"containerName": "server",
+						"target":        map[string]interface{}{"cpu": cpu, "memory": "256Mi"},
+					},
+				},
+			},
+		},
+	}}
+
+	gvr := schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "VerticalPodAutoscalerList"}, vpa)
+}
+
+func TestFilterUsesVPARecommendation(t *testing.T) {
+	tests := []struct {
+		name     string
+		vpaCPU   string
+		wantFits bool
+	}{
+		{"no VPA", "", true},
+		{"recommendation below request", "250m", true},
+		{"recommendation fits node", "1500m", true},
+		{"recommendation exceeds node", "3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScheduler(t, StateActive)
+			if tt.vpaCPU != "" {
+				s.vpa = kube.NewVPAProvider(fakeVPAClient(tt.vpaCPU), s.apiGuard, time.Minute)
+			}
+
+			// The pod requests 500m against 2 allocatable CPUs
+			result := filter(t, s, "500m", testNode("node-1"))
+			fits := len(result.Nodes.Items) == 1
+			if fits != tt.wantFits {
+				t.Fatalf("node-1 eligible = %v, want %v (failed: %v)", fits, tt.wantFits, result.FailedNodes)
+			}
+			if !fits && reasonOf(result.FailedNodes["node-1"]) != ReasonInsufficientCapacity {
+				t.Errorf("failedNodes[node-1] = %q, want reason %s", result.FailedNodes["node-1"], ReasonInsufficientCapacity)
+			}
+		})
+	}
+}
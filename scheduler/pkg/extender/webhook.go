@@ -0,0 +1,156 @@
+/*
+Gang Label Mutating Webhook
+===========================
+Optional admission webhook that labels new pods of gang-member services
+with nexus.io/gang-id while a spike episode is ACTIVE (and, with
+COSCHEDULING_PODGROUPS, with the label joining the gang's PodGroup).
+
+This lets downstream tools (and the scorer) identify influenced pods
+precisely, and lets post-episode analysis select them by label:
+
+  kubectl get pods -l nexus.io/gang-id
+
+The webhook never rejects a pod: outside a spike window, or for pods
+that are not gang members, it allows the pod unchanged. Admission
+webhooks require TLS, so it is served on its own port (WEBHOOK_ADDR)
+with the certificate mounted at WEBHOOK_CERT_FILE / WEBHOOK_KEY_FILE.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+)
+
+// jsonPatchOp is a single RFC 6902 JSON patch operation
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// handleMutate processes pod CREATE admission reviews from the API server
+func (s *NEXUSScheduler) handleMutate(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		klog.Errorf("Failed to decode admission review: %v", err)
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionResponse{
+		UID:     review.Request.UID,
+		Allowed: true,
+	}
+
+	if patch := s.gangLabelPatch(review.Request); patch != nil {
+		data, err := json.Marshal(patch)
+		if err != nil {
+			klog.Errorf("Failed to encode gang label patch: %v", err)
+		} else {
+			patchType := admissionv1.PatchTypeJSONPatch
+			response.Patch = data
+			response.PatchType = &patchType
+		}
+	}
+
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// gangLabelPatch returns the JSON patch labelling a gang-member pod, or nil
+func (s *NEXUSScheduler) gangLabelPatch(req *admissionv1.AdmissionRequest) []jsonPatchOp {
+	if s.GetState() != StateActive || req.Operation != admissionv1.Create {
+		return nil
+	}
+
+	var pod v1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		klog.Warningf("Webhook: failed to decode pod: %v", err)
+		return nil
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = req.Namespace // not yet set on create
+	}
+	if s.systemPod("Webhook", &pod) {
+		return nil
+	}
+
+	serviceName := admissionServiceName(&pod, s.serviceLabel)
+	target := s.gangManager.GetGangForService(serviceName)
+	if target == nil {
+		return nil
+	}
+
+	klog.V(2).Infof("Webhook: labelling new %s pod in %s with %s=%s", serviceName, req.Namespace, gang.LabelGangID, target.ID)
+	s.metrics.IncrementCounter("webhook_pods_labeled")
+
+	labels := map[string]string{gang.LabelGangID: target.ID}
+	if s.podGroups != nil {
+		// Join the gang's coscheduling PodGroup
+		labels[s.podGroups.label] = podGroupName(target.ID)
+	}
+	if pod.Labels == nil {
+		return []jsonPatchOp{{
+			Op:    "add",
+			Path:  "/metadata/labels",
+			Value: labels,
+		}}
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	patch := make([]jsonPatchOp, 0, len(keys))
+	for _, key := range keys {
+		patch = append(patch, jsonPatchOp{
+			Op:    "add",
+			Path:  "/metadata/labels/" + escapeJSONPointer(key),
+			Value: labels[key],
+		})
+	}
+	return patch
+}
+
+// admissionServiceName determines a pod's service at admission time, when
+// Deployment pods only have a generateName ("checkoutservice-7d9f8c-")
+func admissionServiceName(pod *v1.Pod, labelKey string) string {
+	if svc := pod.Labels[labelKey]; svc != "" {
+		return svc
+	}
+	if pod.Name != "" {
+		return graph.ExtractServiceName(pod.Name)
+	}
+	return graph.ExtractServiceName(strings.TrimSuffix(pod.GenerateName, "-"))
+}
+
+// escapeJSONPointer escapes a key for use in a JSON patch path (RFC 6901)
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// StartWebhookServer serves the mutating webhook over TLS on its own port
+func (s *NEXUSScheduler) StartWebhookServer(addr, certFile, keyFile string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mutate-pods", s.handleMutate)
+
+	klog.Infof("Starting NEXUS gang label webhook on %s (TLS)", addr)
+	go func() {
+		if err := http.ListenAndServeTLS(addr, certFile, keyFile, mux); err != nil {
+			klog.Errorf("Webhook server on %s stopped: %v", addr, err)
+		}
+	}()
+}
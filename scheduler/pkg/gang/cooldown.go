@@ -0,0 +1,54 @@
+/*
+HPA-Informed Gang Cooldown
+==========================
+Each gang carries a lower bound for the cooldown before it may dissolve:
+the longest HPA scale-down stabilization window among its members, set
+when the gang forms (see pkg/kube/hpa.go). Gangs dissolve together, so
+the episode's cooldown is the longest bound of the active gangs.
+*/
+
+package gang
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ApplyCooldowns sets each active gang's cooldown to the longest window
+// among its members (service → window) and returns the longest overall
+func (gm *GangManager) ApplyCooldowns(windows map[string]time.Duration) time.Duration {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	var longest time.Duration
+	for _, gang := range gm.activeGangs {
+		gang.Cooldown = 0
+		for _, svc := range gang.Members {
+			if windows[svc] > gang.Cooldown {
+				gang.Cooldown = windows[svc]
+			}
+		}
+		if gang.Cooldown > 0 {
+			klog.Infof("Gang %s: cooldown at least %v (HPA scale-down stabilization)", gang.ID, gang.Cooldown)
+		}
+		if gang.Cooldown > longest {
+			longest = gang.Cooldown
+		}
+	}
+	return longest
+}
+
+// Cooldown returns the longest cooldown lower bound of the active gangs
+func (gm *GangManager) Cooldown() time.Duration {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	var longest time.Duration
+	for _, gang := range gm.activeGangs {
+		if gang.Cooldown > longest {
+			longest = gang.Cooldown
+		}
+	}
+	return longest
+}
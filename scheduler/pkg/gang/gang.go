@@ -0,0 +1,432 @@
+/*
+Gang Lifecycle Manager
+=====================
+Implements explicit temporary gang lifecycle as required by
+Section 2B of the professional review:
+
+  Stage 1: Spike detected
+  Stage 2: Dependency graph constructed
+  Stage 3: Critical path identified
+  Stage 4: Temporary gang created
+  Stage 5: Scheduling hint injected (via Extender)
+  Stage 6: Spike window ends
+  Stage 7: Gang dissolved, system returns to default scheduling
+
+The order is enforced by a state machine (see stage.go).
+
+KEY CONSTRAINT: Gangs are EPHEMERAL. They exist only in memory
+during the spike window and are completely dissolved afterward.
+
+Partial gangs: members the dependency graph found without live pods are
+tracked as missing and the gang is DEGRADED until each of them shows up
+(a first pod reaching Filter/Prioritize, e.g. a scale-from-zero replica).
+*/
+
+package gang
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// LabelGangID marks pods created for a gang during a spike episode
+const LabelGangID = "nexus.io/gang-id"
+
+// GangStage represents the current lifecycle stage
+type GangStage int
+
+const (
+	GangStageNone       GangStage = iota // No gang exists
+	GangStageDetected                    // Spike detected
+	GangStageGraphBuilt                  // Dependency graph constructed
+	GangStageFormed                      // Gang created
+	GangStageScheduling                  // Scheduling hints active
+	GangStageCooldown                    // Spike ended, waiting
+	GangStageDissolved                   // Gang dissolved
+)
+
+// String returns the lifecycle stage name used in logs, metrics and /status
+func (s GangStage) String() string {
+	switch s {
+	case GangStageNone:
+		return "NONE"
+	case GangStageDetected:
+		return "SPIKE_DETECTED"
+	case GangStageGraphBuilt:
+		return "GRAPH_BUILT"
+	case GangStageFormed:
+		return "GANG_FORMED"
+	case GangStageScheduling:
+		return "SCHEDULING"
+	case GangStageCooldown:
+		return "COOLDOWN"
+	case GangStageDissolved:
+		return "DISSOLVED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Gang represents a temporary group of services to be co-located
+type Gang struct {
+	ID        string         // Unique gang identifier
+	Group     string         // Coordination group the gang was formed from
+	Members   []string       // Service names in this gang
+	NodePrefs map[string]int // Node name → count of gang members on it
+	CreatedAt time.Time
+	Stage     GangStage
+
+	// Pods influenced this episode (per-episode influence budget)
+	Influenced map[types.UID]bool
+
+	// Members without live pods (degraded gang while non-empty)
+	Missing map[string]bool
+
+	// NexusPolicy of the gang's group, fixed at formation (nil = none)
+	Policy *Policy
+
+	// Lower bound of the cooldown before the gang may dissolve, from its
+	// members' HPA scale-down stabilization (0 = none, see cooldown.go)
+	Cooldown time.Duration
+
+	// Member pods counted in NodePrefs (pod → node and service) while the
+	// member counts are warm, i.e. kept current from pod events (see members.go)
+	placed map[types.UID]memberPlacement
+	warm   bool
+
+	// Target placement of new members (GANG_PLACEMENT=planned, see plan.go)
+	plan *PlacementPlan
+}
+
+// GangManager handles the formation and dissolution of temporary gangs
+type GangManager struct {
+	mu            sync.RWMutex
+	activeGangs   map[string]*Gang  // gangID → Gang
+	serviceToGang map[string]string // serviceName → gangID
+	stage         GangStage
+	maxGangs      int // 0 = unlimited
+	maxInfluence  int // pods influenced per gang per episode, 0 = unlimited
+	filter        graph.ServiceFilter
+	unavailable   map[string]bool // nodes whose members do not count (see nodes.go)
+	metrics       *metrics.NEXUSMetrics
+}
+
+// NewGangManager creates a new gang lifecycle manager
+func NewGangManager(metrics *metrics.NEXUSMetrics, maxGangs, maxInfluence int) *GangManager {
+	return &GangManager{
+		activeGangs:   make(map[string]*Gang),
+		serviceToGang: make(map[string]string),
+		unavailable:   make(map[string]bool),
+		stage:         GangStageNone,
+		maxGangs:      maxGangs,
+		maxInfluence:  maxInfluence,
+		metrics:       metrics,
+	}
+}
+
+// SetServiceFilter restricts the services gangs may contain
+func (gm *GangManager) SetServiceFilter(filter graph.ServiceFilter) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	gm.filter = filter
+}
+
+// GetStage returns the current gang lifecycle stage
+func (gm *GangManager) GetStage() GangStage {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	return gm.stage
+}
+
+// FormGangs creates temporary gangs from the dependency graph
+// This is called when a spike is detected and the DAG is built
+func (gm *GangManager) FormGangs(groups []graph.RuntimeGroup) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	formStart := time.Now()
+
+	// Clear any existing gangs first
+	gm.clearGangsLocked()
+
+	groups, filtered := gm.filter.Apply(groups)
+	if filtered > 0 {
+		klog.Infof("Gang formation: %d services left out by the service allowlist/denylist", filtered)
+		gm.metrics.AddFilteredServices(filtered)
+	}
+
+	if gm.maxGangs > 0 && len(groups) > gm.maxGangs {
+		klog.Warningf("Gang budget reached: forming %d of %d groups", gm.maxGangs, len(groups))
+		gm.metrics.IncrementCounter("budget_truncations")
+		groups = groups[:gm.maxGangs]
+	}
+
+	for _, group := range groups {
+		gangID := fmt.Sprintf("gang-%s-%d", group.Name, time.Now().UnixNano())
+
+		gang := &Gang{
+			ID:         gangID,
+			Group:      group.Name,
+			Members:    group.Services,
+			NodePrefs:  make(map[string]int),
+			CreatedAt:  time.Now(),
+			Stage:      GangStageFormed,
+			Influenced: make(map[types.UID]bool),
+			Missing:    make(map[string]bool, len(group.Missing)),
+		}
+		for _, svc := range group.Missing {
+			gang.Missing[svc] = true
+		}
+
+		gm.activeGangs[gangID] = gang
+
+		for _, svc := range group.Services {
+			gm.serviceToGang[svc] = gangID
+		}
+
+		if len(gang.Missing) > 0 {
+			klog.Warningf("GANG DEGRADED: %s with members %v, missing %v", gangID, group.Services, group.Missing)
+			gm.metrics.AddMissingMembers(len(gang.Missing))
+		} else {
+			klog.Infof("GANG FORMED: %s with members %v", gangID, group.Services)
+		}
+		gm.metrics.SetGangMissingMembers(gangID, len(gang.Missing))
+	}
+
+	gm.setStageLocked(GangStageFormed)
+
+	// Record formation latency
+	latencyMs := gm.metrics.GangFormationLatency.TimeSince(formStart)
+	klog.Infof("Gang formation completed in %.2fms (%d gangs)", latencyMs, len(groups))
+	gm.metrics.IncrementCounter("gangs_formed")
+}
+
+// GetGangForService returns the gang a service belongs to (if any)
+func (gm *GangManager) GetGangForService(serviceName string) *Gang {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	gangID, exists := gm.serviceToGang[serviceName]
+	if !exists {
+		return nil
+	}
+	return gm.activeGangs[gangID]
+}
+
+// GetGangForPod returns the gang a pod belongs to. Pods labelled by the
+// webhook with an active nexus.io/gang-id are matched directly; otherwise
+// the gang is looked up by the pod's service name.
+func (gm *GangManager) GetGangForPod(pod *v1.Pod) *Gang {
+	if gangID := pod.Labels[LabelGangID]; gangID != "" {
+		gm.mu.RLock()
+		gang := gm.activeGangs[gangID]
+		gm.mu.RUnlock()
+		if gang != nil {
+			return gang
+		}
+	}
+
+	serviceName := graph.ExtractServiceName(pod.Name)
+	return gm.GetGangForService(serviceName)
+}
+
+// GetGangMembers returns all service names in the same gang as the pod
+func (gm *GangManager) GetGangMembers(pod *v1.Pod) []string {
+	gang := gm.GetGangForPod(pod)
+	if gang == nil {
+		return nil
+	}
+	return gang.Members
+}
+
+// UpdateNodePreference records that a gang member was placed on a node
+func (gm *GangManager) UpdateNodePreference(serviceName, nodeName string) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	gangID, exists := gm.serviceToGang[serviceName]
+	if !exists {
+		return
+	}
+
+	gang := gm.activeGangs[gangID]
+	if gang != nil && !gang.warm { // warm counts follow the pod events instead
+		gang.NodePrefs[nodeName]++
+		klog.V(2).Infof("Updated node preference for gang %s: %s → %s (count: %d)",
+			gangID, serviceName, nodeName, gang.NodePrefs[nodeName])
+	}
+}
+
+// NodePreferences returns a copy of the gang's member counts by node
+func (gm *GangManager) NodePreferences(gang *Gang) map[string]int {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	prefs := make(map[string]int, len(gang.NodePrefs))
+	for node, count := range gang.NodePrefs {
+		prefs[node] = count
+	}
+	return prefs
+}
+
+// ConsumeInfluence charges a pod against its gang's per-episode influence
+// budget. Returns false once the budget is spent, after which NEXUS returns
+// no-opinion for further pods of the gang. Filter and Prioritize calls for
+// the same pod are charged once.
+func (gm *GangManager) ConsumeInfluence(gang *Gang, pod *v1.Pod) bool {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if gang.Influenced[pod.UID] {
+		return true
+	}
+	budget := gm.maxInfluence
+	if gang.Policy != nil && gang.Policy.MaxInfluence > 0 {
+		budget = gang.Policy.MaxInfluence
+	}
+	if budget > 0 && len(gang.Influenced) >= budget {
+		klog.V(2).Infof("Influence budget exhausted for gang %s (%d pods)", gang.ID, budget)
+		gm.metrics.IncrementCounter("influence_budget_exhausted")
+		return false
+	}
+
+	gang.Influenced[pod.UID] = true
+	gm.metrics.SetInfluenceBudgetUsed(gang.ID, len(gang.Influenced))
+	return true
+}
+
+// InfluenceLeft reports whether ConsumeInfluence would accept the pod,
+// without charging it
+func (gm *GangManager) InfluenceLeft(gang *Gang, pod *v1.Pod) bool {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	budget := gm.maxInfluence
+	if gang.Policy != nil && gang.Policy.MaxInfluence > 0 {
+		budget = gang.Policy.MaxInfluence
+	}
+	return gang.Influenced[pod.UID] || budget <= 0 || len(gang.Influenced) < budget
+}
+
+// ObserveMember records that a pod of a gang member reached the extender.
+// A member that was missing when the gang formed is present from now on.
+func (gm *GangManager) ObserveMember(gang *Gang, serviceName string) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if !gang.Missing[serviceName] {
+		return
+	}
+	delete(gang.Missing, serviceName)
+	gm.metrics.SetGangMissingMembers(gang.ID, len(gang.Missing))
+	gm.metrics.IncrementCounter("gang_members_arrived")
+	if len(gang.Missing) == 0 {
+		klog.Infof("Gang %s complete: missing member %s arrived", gang.ID, serviceName)
+	} else {
+		klog.Infof("Gang %s: missing member %s arrived (%d still missing)", gang.ID, serviceName, len(gang.Missing))
+	}
+}
+
+// DegradedGangs returns the missing members of every degraded gang (gangID → sorted services)
+func (gm *GangManager) DegradedGangs() map[string][]string {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	degraded := make(map[string][]string)
+	for id, gang := range gm.activeGangs {
+		if len(gang.Missing) == 0 {
+			continue
+		}
+		missing := make([]string, 0, len(gang.Missing))
+		for svc := range gang.Missing {
+			missing = append(missing, svc)
+		}
+		sort.Strings(missing)
+		degraded[id] = missing
+	}
+	return degraded
+}
+
+// InfluencedPods returns how many pods NEXUS influenced this episode for the
+// gang formed from a coordination group (0 when no such gang is active)
+func (gm *GangManager) InfluencedPods(group string) int {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	for _, gang := range gm.activeGangs {
+		if gang.Group == group {
+			return len(gang.Influenced)
+		}
+	}
+	return 0
+}
+
+// HasActiveGangs returns true if any gangs are currently active
+func (gm *GangManager) HasActiveGangs() bool {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	return len(gm.activeGangs) > 0
+}
+
+// GetActiveGangCount returns the number of active gangs
+func (gm *GangManager) GetActiveGangCount() int {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	return len(gm.activeGangs)
+}
+
+// ActiveGangs returns the active gangs in gang ID order
+func (gm *GangManager) ActiveGangs() []*Gang {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	gangs := make([]*Gang, 0, len(gm.activeGangs))
+	for _, gang := range gm.activeGangs {
+		gangs = append(gangs, gang)
+	}
+	sort.Slice(gangs, func(i, j int) bool { return gangs[i].ID < gangs[j].ID })
+	return gangs
+}
+
+// DissolveAll dissolves all active gangs and clears all in-memory data
+// This is called when the spike window ends and cooldown is complete
+func (gm *GangManager) DissolveAll() {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	gangCount := len(gm.activeGangs)
+	if gangCount == 0 {
+		return
+	}
+
+	gm.clearGangsLocked()
+	gm.unavailable = make(map[string]bool)
+	gm.setStageLocked(GangStageDissolved)
+
+	klog.Infof("GANGS DISSOLVED: %d gangs removed, all in-memory data freed", gangCount)
+	gm.metrics.IncrementCounter("gangs_dissolved")
+}
+
+// clearGangsLocked clears all gang data (must hold write lock)
+func (gm *GangManager) clearGangsLocked() {
+	gm.activeGangs = make(map[string]*Gang)
+	gm.serviceToGang = make(map[string]string)
+	gm.metrics.ResetInfluenceBudget()
+}
+
+// SetStage moves the gang lifecycle to stage; invalid transitions are
+// rejected (see stage.go)
+func (gm *GangManager) SetStage(stage GangStage) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	return gm.setStageLocked(stage)
+}
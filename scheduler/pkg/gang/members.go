@@ -0,0 +1,257 @@
+/*
+Warm Member Counts
+==================
+Scoring needs the number of gang members on every candidate node. Instead
+of listing the pods of each node on the Filter/Prioritize path, the
+extender lists all pods once when the gangs form (WarmMemberCounts) and
+feeds later pod events into the gangs (ObservePod, ForgetPod). While a
+gang is warm its NodePrefs are the member counts, and a lookup is a map
+read. CoolMemberCounts drops back to live pod lists, e.g. when the pod
+watch breaks until the next list catches the counts up again.
+
+A pod counts for a gang when it is bound to a node and its service name
+matches a member, the same rule as the live count in pkg/scorer.
+
+The warm counts also give each member's co-location pressure: the share
+of the gang's other member pods running on nodes without a pod of the
+member. It is 0 when every peer has a replica of the member next to it,
+and approaches 1 when the member runs apart from its gang, i.e. when
+another replica placed by NEXUS would land next to peers. MemberDensity
+hands the counts by node to nexus_gang_members_on_node, so co-location
+can be followed while it evolves. WarmServiceCount counts a subset of the
+members, e.g. the anchors of a NexusPolicy.
+*/
+
+package gang
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"nexus-scheduler/pkg/graph"
+)
+
+// memberPlacement is the node and service of a counted member pod
+type memberPlacement struct {
+	node    string
+	service string
+}
+
+// ColocationPressure returns the co-location pressure (0–1) of every member
+// of the warm gangs; members of cold gangs, and of gangs with a single
+// member service running, are left out
+func (gm *GangManager) ColocationPressure() map[string]float64 {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	pressure := make(map[string]float64)
+	for _, gang := range gm.activeGangs {
+		if !gang.warm {
+			continue
+		}
+		nodes := make(map[string]map[string]bool) // service → nodes running it
+		for _, placement := range gang.placed {
+			if gm.unavailable[placement.node] {
+				continue
+			}
+			if nodes[placement.service] == nil {
+				nodes[placement.service] = make(map[string]bool)
+			}
+			nodes[placement.service][placement.node] = true
+		}
+		if len(nodes) < 2 {
+			continue
+		}
+		for service, own := range nodes {
+			peers, apart := 0, 0
+			for _, placement := range gang.placed {
+				if placement.service == service || gm.unavailable[placement.node] {
+					continue
+				}
+				peers++
+				if !own[placement.node] {
+					apart++
+				}
+			}
+			value := float64(apart) / float64(peers)
+			if highest, ok := pressure[service]; !ok || value > highest {
+				pressure[service] = value // a service in several gangs reports the highest
+			}
+		}
+	}
+	return pressure
+}
+
+// WarmMemberCounts replaces the member counts of every active gang with
+// the counts in pods (a complete pod list) and marks the gangs warm
+func (gm *GangManager) WarmMemberCounts(pods []v1.Pod) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	for _, gang := range gm.activeGangs {
+		gang.NodePrefs = make(map[string]int)
+		gang.placed = make(map[types.UID]memberPlacement)
+		gang.warm = true
+	}
+	for i := range pods {
+		gm.observePodLocked(&pods[i])
+	}
+}
+
+// CoolMemberCounts marks every gang cold: counts are read live again
+func (gm *GangManager) CoolMemberCounts() {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	for _, gang := range gm.activeGangs {
+		gang.warm = false
+	}
+}
+
+// ObservePod applies an added or updated pod to the warm member counts
+func (gm *GangManager) ObservePod(pod *v1.Pod) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	gm.observePodLocked(pod)
+}
+
+// ForgetPod removes a deleted pod from the warm member counts
+func (gm *GangManager) ForgetPod(pod *v1.Pod) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	for _, gang := range gm.activeGangs {
+		if gang.warm {
+			gang.unplace(pod.UID)
+		}
+	}
+}
+
+// WarmMemberCount returns the gang members on a node, and false when the
+// gang is not warm and the count has to be read live
+func (gm *GangManager) WarmMemberCount(gang *Gang, node string) (int, bool) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	if !gang.warm {
+		return 0, false
+	}
+	if gm.unavailable[node] {
+		return 0, true
+	}
+	return gang.NodePrefs[node], true
+}
+
+// WarmServiceCount returns the gang's pods of the services on a node, and
+// false when the gang is not warm
+func (gm *GangManager) WarmServiceCount(gang *Gang, node string, services []string) (int, bool) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	if !gang.warm {
+		return 0, false
+	}
+	if gm.unavailable[node] {
+		return 0, true
+	}
+	count := 0
+	for _, placement := range gang.placed {
+		if placement.node != node {
+			continue
+		}
+		for _, service := range services {
+			if strings.EqualFold(placement.service, service) {
+				count++
+				break
+			}
+		}
+	}
+	return count, true
+}
+
+// WarmNodeCounts returns a copy of the gang's member counts by node, and
+// false when the gang is not warm
+func (gm *GangManager) WarmNodeCounts(gang *Gang) (map[string]int, bool) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	if !gang.warm {
+		return nil, false
+	}
+	counts := make(map[string]int, len(gang.NodePrefs))
+	for node, count := range gang.NodePrefs {
+		if !gm.unavailable[node] {
+			counts[node] = count
+		}
+	}
+	return counts, true
+}
+
+// MemberDensity returns the member counts of every warm gang by node
+// (gang ID → node → members), leaving out unavailable nodes and nodes
+// without members
+func (gm *GangManager) MemberDensity() map[string]map[string]int {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	density := make(map[string]map[string]int)
+	for id, gang := range gm.activeGangs {
+		if !gang.warm {
+			continue
+		}
+		nodes := make(map[string]int, len(gang.NodePrefs))
+		for node, count := range gang.NodePrefs {
+			if count > 0 && !gm.unavailable[node] {
+				nodes[node] = count
+			}
+		}
+		density[id] = nodes
+	}
+	return density
+}
+
+// observePodLocked counts a bound pod for the warm gangs it is a member of
+func (gm *GangManager) observePodLocked(pod *v1.Pod) {
+	if pod.Spec.NodeName == "" {
+		return
+	}
+	service := graph.ExtractServiceName(pod.Name)
+	for _, gang := range gm.activeGangs {
+		if !gang.warm || !gang.hasMember(service) {
+			continue
+		}
+		if placement, ok := gang.placed[pod.UID]; ok {
+			if placement.node == pod.Spec.NodeName {
+				continue
+			}
+			gang.unplace(pod.UID)
+		}
+		gang.placed[pod.UID] = memberPlacement{node: pod.Spec.NodeName, service: service}
+		gang.NodePrefs[pod.Spec.NodeName]++
+	}
+}
+
+// unplace removes a counted pod from the gang's member counts
+func (g *Gang) unplace(uid types.UID) {
+	placement, ok := g.placed[uid]
+	if !ok {
+		return
+	}
+	node := placement.node
+	delete(g.placed, uid)
+	if g.NodePrefs[node]--; g.NodePrefs[node] <= 0 {
+		delete(g.NodePrefs, node)
+	}
+}
+
+// hasMember reports whether service is a member of the gang
+func (g *Gang) hasMember(service string) bool {
+	for _, member := range g.Members {
+		if strings.EqualFold(service, member) {
+			return true
+		}
+	}
+	return false
+}
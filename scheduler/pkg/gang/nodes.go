@@ -0,0 +1,84 @@
+/*
+Unavailable Nodes
+=================
+A node hosting gang members can go NotReady mid-spike (or disappear). Its
+pods stay bound until the node lifecycle controller evicts them, minutes
+later, so their member counts would keep attracting new members to the
+failed node's neighbourhood. The extender marks such nodes unavailable
+(SetNodeAvailable, from a node watch, see pkg/extender/nodewatch.go):
+members on an unavailable node count as zero, warm or live, until the
+node is Ready again. The marks are dropped when the gangs dissolve.
+
+A node is contested when several active gangs prefer it; NodeRival names
+the strongest other gang for the priority arbitration of Prioritize (see
+pkg/extender/arbitration.go).
+*/
+
+package gang
+
+// SetNodeAvailable marks a node available or unavailable and reports
+// whether that changed its state
+func (gm *GangManager) SetNodeAvailable(node string, available bool) bool {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if available == !gm.unavailable[node] {
+		return false
+	}
+	if available {
+		delete(gm.unavailable, node)
+	} else {
+		gm.unavailable[node] = true
+	}
+	return true
+}
+
+// NodeAvailable reports whether gang members on a node count
+func (gm *GangManager) NodeAvailable(node string) bool {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	return !gm.unavailable[node]
+}
+
+// NodePreferred reports whether any gang prefers a node: warm members run
+// on it or its placement plan sends members to it
+func (gm *GangManager) NodePreferred(node string) bool {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	for _, gang := range gm.activeGangs {
+		if gang.warm && gang.NodePrefs[node] > 0 {
+			return true
+		}
+		if gang.plan != nil && gang.plan.Targets[node] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeRival returns the highest-priority other active gang that claims the
+// node — members on it or planned for it — or nil when none does. Rivals
+// of equal priority are ordered by gang ID.
+func (gm *GangManager) NodeRival(g *Gang, node string) *Gang {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	if gm.unavailable[node] {
+		return nil
+	}
+	var rival *Gang
+	for _, other := range gm.activeGangs {
+		if other == g || other.ID == g.ID {
+			continue
+		}
+		if other.NodePrefs[node] == 0 && (other.plan == nil || other.plan.Targets[node] == 0) {
+			continue
+		}
+		if rival == nil || other.Priority() > rival.Priority() ||
+			(other.Priority() == rival.Priority() && other.ID < rival.ID) {
+			rival = other
+		}
+	}
+	return rival
+}
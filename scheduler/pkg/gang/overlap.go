@@ -0,0 +1,263 @@
+/*
+Coordination Group Overlaps
+===========================
+Annotations can place one service in several groups (currencyservice in
+both checkout-flow and product-browsing). A service belongs to at most
+one gang, so before gangs form every overlap is resolved with
+GROUP_OVERLAP:
+
+  merge     Groups sharing a service form one gang, named after all of
+            them ("checkout-flow+product-browsing"), with the union of
+            their services and the tightest SLO (default)
+  priority  The shared service stays in the highest-priority group only:
+            the largest priority of the groups' NexusPolicies, then
+            the tightest SLO, then the group name
+  error     No gang forms from any group holding a shared service; the
+            other groups form as usual
+
+Overlaps are logged, counted in nexus_group_overlaps_total and reported
+with their resolution in /status until the episode ends.
+*/
+
+package gang
+
+import (
+	"sort"
+	"strings"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/graph"
+)
+
+// GroupOverlap is a service declared in more than one coordination group
+type GroupOverlap struct {
+	Service    string   `json:"service"`
+	Groups     []string `json:"groups"`
+	Resolution string   `json:"resolution"`
+	Gang       string   `json:"gang,omitempty"` // group whose gang holds the service ("" = none formed)
+}
+
+// GroupPriority returns the arbitration priority of a group (higher wins)
+type GroupPriority func(group string) float64
+
+// ResolveOverlaps detects the services shared by several groups and
+// resolves them with the GROUP_OVERLAP mode ("" = merge)
+func ResolveOverlaps(groups []graph.RuntimeGroup, mode string, priority GroupPriority) ([]graph.RuntimeGroup, []GroupOverlap) {
+	if mode == "" {
+		mode = config.GroupOverlapMerge
+	}
+	overlaps := findOverlaps(groups)
+	if len(overlaps) == 0 {
+		return groups, nil
+	}
+	for i := range overlaps {
+		overlaps[i].Resolution = mode
+	}
+
+	switch mode {
+	case config.GroupOverlapPriority:
+		return keepHighestPriority(groups, overlaps, priority), overlaps
+	case config.GroupOverlapError:
+		return dropOverlapping(groups, overlaps), overlaps
+	default:
+		return mergeOverlapping(groups, overlaps), overlaps
+	}
+}
+
+// findOverlaps returns the shared services in order of first appearance,
+// each with its groups in group order
+func findOverlaps(groups []graph.RuntimeGroup) []GroupOverlap {
+	byService := make(map[string][]string)
+	var order []string
+	for _, group := range groups {
+		for _, svc := range group.Services {
+			if _, seen := byService[svc]; !seen {
+				order = append(order, svc)
+			}
+			if names := byService[svc]; len(names) == 0 || names[len(names)-1] != group.Name {
+				byService[svc] = append(names, group.Name)
+			}
+		}
+	}
+
+	var overlaps []GroupOverlap
+	for _, svc := range order {
+		if len(byService[svc]) > 1 {
+			overlaps = append(overlaps, GroupOverlap{Service: svc, Groups: byService[svc]})
+		}
+	}
+	return overlaps
+}
+
+// mergeOverlapping merges every set of groups connected by a shared
+// service into one group, kept at the position of its first member
+func mergeOverlapping(groups []graph.RuntimeGroup, overlaps []GroupOverlap) []graph.RuntimeGroup {
+	index := make(map[string]int, len(groups))
+	parent := make([]int, len(groups))
+	for i, group := range groups {
+		index[group.Name] = i
+		parent[i] = i
+	}
+	root := func(i int) int {
+		for parent[i] != i {
+			i = parent[i]
+		}
+		return i
+	}
+	for _, overlap := range overlaps {
+		first := root(index[overlap.Groups[0]])
+		for _, name := range overlap.Groups[1:] {
+			if r := root(index[name]); r != first {
+				if r < first {
+					parent[first], first = r, r
+				} else {
+					parent[r] = first
+				}
+			}
+		}
+	}
+
+	sets := make(map[int][]graph.RuntimeGroup)
+	for i, group := range groups {
+		sets[root(i)] = append(sets[root(i)], group)
+	}
+	formed := make([]graph.RuntimeGroup, 0, len(sets))
+	gangs := make(map[string]string, len(groups))
+	for i := range groups {
+		set, ok := sets[i]
+		if !ok {
+			continue
+		}
+		merged := mergeGroups(set)
+		for _, group := range set {
+			gangs[group.Name] = merged.Name
+		}
+		formed = append(formed, merged)
+	}
+	for i := range overlaps {
+		overlaps[i].Gang = gangs[overlaps[i].Groups[0]]
+	}
+	return formed
+}
+
+// mergeGroups returns the union of the groups, named after all of them in
+// sorted order, with the tightest SLO
+func mergeGroups(set []graph.RuntimeGroup) graph.RuntimeGroup {
+	if len(set) == 1 {
+		return set[0]
+	}
+	names := make([]string, 0, len(set))
+	var merged graph.RuntimeGroup
+	for _, group := range set {
+		names = append(names, group.Name)
+		merged.Services = appendDistinct(merged.Services, group.Services)
+		merged.Missing = appendDistinct(merged.Missing, group.Missing)
+		merged.Isolated = appendDistinct(merged.Isolated, group.Isolated)
+		if group.SLOP95Ms > 0 && (merged.SLOP95Ms == 0 || group.SLOP95Ms < merged.SLOP95Ms) {
+			merged.SLOP95Ms = group.SLOP95Ms
+		}
+	}
+	sort.Strings(names)
+	merged.Name = strings.Join(names, "+")
+	return merged
+}
+
+// keepHighestPriority removes each shared service from every group but
+// the highest-priority one; groups left empty are dropped
+func keepHighestPriority(groups []graph.RuntimeGroup, overlaps []GroupOverlap, priority GroupPriority) []graph.RuntimeGroup {
+	byName := make(map[string]graph.RuntimeGroup, len(groups))
+	for _, group := range groups {
+		byName[group.Name] = group
+	}
+	outranks := func(a, b string) bool {
+		if priority != nil {
+			if pa, pb := priority(a), priority(b); pa != pb {
+				return pa > pb
+			}
+		}
+		if sa, sb := byName[a].SLOP95Ms, byName[b].SLOP95Ms; sa != sb {
+			switch {
+			case sa == 0:
+				return false
+			case sb == 0:
+				return true
+			}
+			return sa < sb
+		}
+		return a < b
+	}
+
+	removed := make(map[string]map[string]bool)
+	for i, overlap := range overlaps {
+		winner := overlap.Groups[0]
+		for _, name := range overlap.Groups[1:] {
+			if outranks(name, winner) {
+				winner = name
+			}
+		}
+		overlaps[i].Gang = winner
+		for _, name := range overlap.Groups {
+			if name == winner {
+				continue
+			}
+			if removed[name] == nil {
+				removed[name] = make(map[string]bool)
+			}
+			removed[name][overlap.Service] = true
+		}
+	}
+
+	formed := make([]graph.RuntimeGroup, 0, len(groups))
+	for _, group := range groups {
+		if drop := removed[group.Name]; len(drop) > 0 {
+			services := make([]string, 0, len(group.Services))
+			for _, svc := range group.Services {
+				if !drop[svc] {
+					services = append(services, svc)
+				}
+			}
+			if len(services) == 0 {
+				continue
+			}
+			group.Services = services
+			group.Missing = retained(group.Missing, services)
+			group.Isolated = retained(group.Isolated, services)
+		}
+		formed = append(formed, group)
+	}
+	return formed
+}
+
+// dropOverlapping removes every group holding a shared service
+func dropOverlapping(groups []graph.RuntimeGroup, overlaps []GroupOverlap) []graph.RuntimeGroup {
+	conflicting := make(map[string]bool)
+	for _, overlap := range overlaps {
+		for _, name := range overlap.Groups {
+			conflicting[name] = true
+		}
+	}
+	formed := make([]graph.RuntimeGroup, 0, len(groups))
+	for _, group := range groups {
+		if !conflicting[group.Name] {
+			formed = append(formed, group)
+		}
+	}
+	return formed
+}
+
+// appendDistinct appends the services of add not yet in list
+func appendDistinct(list, add []string) []string {
+	for _, svc := range add {
+		found := false
+		for _, existing := range list {
+			if existing == svc {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, svc)
+		}
+	}
+	return list
+}
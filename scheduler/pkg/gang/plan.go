@@ -0,0 +1,181 @@
+/*
+Placement Plans
+===============
+By default a gang's new members chase the current member counts: every
+pod goes where most members already are (greedy). With
+GANG_PLACEMENT=planned the extender instead computes an explicit plan
+when the gangs form (PlanPlacement): which nodes should receive how many
+new replicas of each member, given the capacity left on every node.
+
+The plan is a first-fit decreasing bin-packing of the expected new
+replicas, largest CPU request first, over the nodes ordered by
+
+  members already on the node (descending), free CPU (descending), name
+
+so the gang fills the node it is already concentrated on before spilling
+onto the next one, and uses as few nodes as the capacity allows.
+Replicas that fit no node are counted as unplaced.
+
+Progress is measured against the member counts at formation (Baseline):
+a node has received max(0, members now − baseline) planned pods, and its
+remaining slots are its target minus that. Steer turns the current
+member counts into remaining slots for scoring; once no candidate node
+has slots left the plan is spent and placement is greedy again.
+*/
+
+package gang
+
+import (
+	"sort"
+	"time"
+)
+
+// PlanMember is one member service to place: its expected new replicas
+// and the requests of one replica
+type PlanMember struct {
+	Service  string
+	Replicas int
+	MilliCPU int64
+	Memory   int64
+}
+
+// PlanNode is one node a plan may use and its free capacity
+type PlanNode struct {
+	Name     string
+	MilliCPU int64
+	Memory   int64
+	Members  int // gang members on the node at formation
+}
+
+// PlacementPlan is the target placement of a gang's new replicas
+type PlacementPlan struct {
+	Targets     map[string]int            `json:"targets"`     // node → planned new members
+	Assignments map[string]map[string]int `json:"assignments"` // service → node → planned replicas
+	Baseline    map[string]int            `json:"baseline"`    // node → members at formation
+	Unplaced    int                       `json:"unplaced"`    // replicas that fit no node
+	CreatedAt   time.Time                 `json:"createdAt"`
+}
+
+// PlanPlacement packs the members' replicas onto the nodes. The free
+// capacity of nodes is reduced by the planned replicas, so several gangs
+// can be planned one after another against the same nodes.
+func PlanPlacement(members []PlanMember, nodes []PlanNode) *PlacementPlan {
+	plan := &PlacementPlan{
+		Targets:     make(map[string]int),
+		Assignments: make(map[string]map[string]int),
+		Baseline:    make(map[string]int),
+		CreatedAt:   time.Now(),
+	}
+
+	order := make([]*PlanNode, len(nodes))
+	for i := range nodes {
+		order[i] = &nodes[i]
+		if nodes[i].Members > 0 {
+			plan.Baseline[nodes[i].Name] = nodes[i].Members
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.Members != b.Members {
+			return a.Members > b.Members
+		}
+		if a.MilliCPU != b.MilliCPU {
+			return a.MilliCPU > b.MilliCPU
+		}
+		return a.Name < b.Name
+	})
+
+	replicas := make([]PlanMember, len(members))
+	copy(replicas, members)
+	sort.SliceStable(replicas, func(i, j int) bool {
+		if replicas[i].MilliCPU != replicas[j].MilliCPU {
+			return replicas[i].MilliCPU > replicas[j].MilliCPU
+		}
+		return replicas[i].Service < replicas[j].Service
+	})
+
+	for _, member := range replicas {
+		for r := 0; r < member.Replicas; r++ {
+			node := firstFit(order, member)
+			if node == nil {
+				plan.Unplaced++
+				continue
+			}
+			node.MilliCPU -= member.MilliCPU
+			node.Memory -= member.Memory
+			plan.Targets[node.Name]++
+			if plan.Assignments[member.Service] == nil {
+				plan.Assignments[member.Service] = make(map[string]int)
+			}
+			plan.Assignments[member.Service][node.Name]++
+		}
+	}
+	return plan
+}
+
+// firstFit returns the first node with room for one replica (nil if none)
+func firstFit(nodes []*PlanNode, member PlanMember) *PlanNode {
+	for _, node := range nodes {
+		if node.MilliCPU >= member.MilliCPU && node.Memory >= member.Memory {
+			return node
+		}
+	}
+	return nil
+}
+
+// Remaining returns the planned slots a node has left given the gang
+// members on it now
+func (p *PlacementPlan) Remaining(node string, members int) int {
+	received := members - p.Baseline[node]
+	if received < 0 {
+		received = 0
+	}
+	if remaining := p.Targets[node] - received; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// FirstNode returns the candidate with the most remaining planned slots,
+// the node the plan concentrates the gang on (ties by name, "" = none)
+func (p *PlacementPlan) FirstNode(counts map[string]int, candidates []string) string {
+	first, most := "", 0
+	for _, node := range candidates {
+		remaining := p.Remaining(node, counts[node])
+		if remaining > most || (remaining > 0 && remaining == most && node < first) {
+			first, most = node, remaining
+		}
+	}
+	return first
+}
+
+// Steer maps the current member counts of the candidate nodes to their
+// remaining planned slots; false when no candidate has slots left (the
+// plan is spent, or none of its nodes is a candidate)
+func (p *PlacementPlan) Steer(counts map[string]int, candidates []string) (map[string]int, bool) {
+	slots := make(map[string]int, len(candidates))
+	steered := false
+	for _, node := range candidates {
+		if remaining := p.Remaining(node, counts[node]); remaining > 0 {
+			slots[node] = remaining
+			steered = true
+		}
+	}
+	return slots, steered
+}
+
+// Plan returns the placement plan of a gang (nil = greedy placement)
+func (gm *GangManager) Plan(gang *Gang) *PlacementPlan {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	return gang.plan
+}
+
+// SetPlans attaches placement plans to the active gangs (gang ID → plan)
+func (gm *GangManager) SetPlans(plans map[string]*PlacementPlan) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	for id, gang := range gm.activeGangs {
+		gang.plan = plans[id]
+	}
+}
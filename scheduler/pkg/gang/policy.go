@@ -0,0 +1,115 @@
+/*
+Group Scheduling Policies
+=========================
+A Policy is what a team declared for one coordination group in a
+NexusPolicy custom resource (see pkg/extender/policy.go). Policies are
+attached to gangs when the gang forms and stay fixed for the episode, so
+editing or deleting a NexusPolicy mid-spike only affects the next one.
+
+Every field is optional; unset fields fall back to the global settings.
+*/
+
+package gang
+
+import "k8s.io/klog/v2"
+
+// Placement preferences of a Policy
+const (
+	PlacementColocate = "colocate"
+	PlacementSpread   = "spread"
+)
+
+// Policy is the scheduling policy of one coordination group
+type Policy struct {
+	Source        string   `json:"source"` // "<namespace>/<name>" of the NexusPolicy
+	Group         string   `json:"group"`
+	SLOP95Ms      float64  `json:"sloP95Ms,omitempty"`      // latency SLO threshold (0 = annotation / SLO_DEFAULT_P95_MS)
+	Placement     string   `json:"placement,omitempty"`     // colocate or spread ("" = spike class policy)
+	FreshStart    string   `json:"freshStart,omitempty"`    // Filter while no node has members ("" = FRESH_GANG_PLACEMENT)
+	LocalityScale float64  `json:"localityScale,omitempty"` // locality multiplier (0 = spike class policy)
+	Weights       Weights  `json:"weights,omitempty"`
+	PriorityBoost float64  `json:"priorityBoost,omitempty"` // multiplier on the Prioritize scores (0 = 1)
+	Priority      int      `json:"priority,omitempty"`      // rank on nodes contested by other gangs (higher wins)
+	MaxInfluence  int      `json:"maxInfluence,omitempty"`  // pods influenced per episode (0 = MAX_INFLUENCED_PODS_PER_GANG)
+	Anchors       []string `json:"anchors,omitempty"`       // members the others are scored toward (data locality)
+}
+
+// Weights multiply the scoring components (0 = unset = 1)
+type Weights struct {
+	Locality    float64 `json:"locality,omitempty"`
+	Resource    float64 `json:"resource,omitempty"`
+	Utilization float64 `json:"utilization,omitempty"`
+}
+
+// Weight returns w, or 1 when unset
+func Weight(w float64) float64 {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// Weights returns the scoring weights of the gang's policy (unset = 1; a
+// nil gang has none)
+func (g *Gang) Weights() Weights {
+	if g == nil || g.Policy == nil {
+		return Weights{}
+	}
+	return g.Policy.Weights
+}
+
+// Anchors returns the members of the gang its policy names as anchors
+// (none for a nil gang)
+func (g *Gang) Anchors() []string {
+	if g == nil || g.Policy == nil {
+		return nil
+	}
+	var anchors []string
+	for _, anchor := range g.Policy.Anchors {
+		if g.hasMember(anchor) {
+			anchors = append(anchors, anchor)
+		}
+	}
+	return anchors
+}
+
+// Priority returns the arbitration priority of the gang (0 without a policy
+// or gang)
+func (g *Gang) Priority() int {
+	if g == nil || g.Policy == nil {
+		return 0
+	}
+	return g.Policy.Priority
+}
+
+// ApplyPolicies attaches the policy of each gang's group (group → policy)
+// to the active gangs and returns how many gangs got one
+func (gm *GangManager) ApplyPolicies(policies map[string]Policy) int {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	applied := 0
+	for _, gang := range gm.activeGangs {
+		gang.Policy = nil
+		if policy, ok := policies[gang.Group]; ok {
+			gang.Policy = &policy
+			applied++
+			klog.Infof("Gang %s: NexusPolicy %s applied", gang.ID, policy.Source)
+		}
+	}
+	return applied
+}
+
+// GroupPolicy returns the policy of the active gang formed from a group
+// (nil when none)
+func (gm *GangManager) GroupPolicy(group string) *Policy {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	for _, gang := range gm.activeGangs {
+		if gang.Group == group {
+			return gang.Policy
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Gang Lifecycle State Machine
+============================
+The seven-stage lifecycle is enforced, not just reported. Allowed
+transitions:
+
+  NONE           → SPIKE_DETECTED
+  SPIKE_DETECTED → GRAPH_BUILT | NONE (activation aborted)
+  GRAPH_BUILT    → GANG_FORMED | NONE (graph construction failed)
+                                 | COOLDOWN (spike ended, no gang formed)
+  GANG_FORMED    → SCHEDULING
+  SCHEDULING     → COOLDOWN
+  COOLDOWN       → SCHEDULING (new spike while draining)
+                 | DISSOLVED | NONE (no gang to dissolve)
+  DISSOLVED      → NONE
+
+Setting the current stage again is a no-op. Any other transition is
+logged, counted in nexus_gang_invalid_stage_transitions_total and
+rejected: the stage stays where it was. Time spent in each stage is
+exported as nexus_gang_stage_seconds_total{stage}.
+*/
+
+package gang
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
+// stageTransitions lists the stages each stage may move on to
+var stageTransitions = map[GangStage][]GangStage{
+	GangStageNone:       {GangStageDetected},
+	GangStageDetected:   {GangStageGraphBuilt, GangStageNone},
+	GangStageGraphBuilt: {GangStageFormed, GangStageNone, GangStageCooldown},
+	GangStageFormed:     {GangStageScheduling},
+	GangStageScheduling: {GangStageCooldown},
+	GangStageCooldown:   {GangStageScheduling, GangStageDissolved, GangStageNone},
+	GangStageDissolved:  {GangStageNone},
+}
+
+// ValidTransition reports whether the lifecycle may move from one stage to another
+func ValidTransition(from, to GangStage) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range stageTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// setStageLocked moves the lifecycle to stage (must hold write lock)
+func (gm *GangManager) setStageLocked(stage GangStage) error {
+	if stage == gm.stage {
+		return nil
+	}
+	if !ValidTransition(gm.stage, stage) {
+		klog.Warningf("Rejected invalid gang lifecycle transition %s → %s", gm.stage, stage)
+		gm.metrics.IncrementCounter("invalid_stage_transitions")
+		return fmt.Errorf("invalid gang lifecycle transition %s → %s", gm.stage, stage)
+	}
+
+	klog.V(2).Infof("Gang lifecycle stage: %s → %s", gm.stage, stage)
+	gm.stage = stage
+	gm.metrics.SetGangStage(stage.String())
+	return nil
+}
@@ -0,0 +1,101 @@
+package gang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/metrics"
+)
+
+func TestStageLifecycle(t *testing.T) {
+	m := metrics.NewNEXUSMetrics()
+	gm := NewGangManager(m, 0, 0)
+
+	steps := []GangStage{GangStageDetected, GangStageGraphBuilt}
+	for _, stage := range steps {
+		if err := gm.SetStage(stage); err != nil {
+			t.Fatalf("SetStage(%s): %v", stage, err)
+		}
+	}
+	gm.FormGangs(strategyGroups)
+	if gm.GetStage() != GangStageFormed {
+		t.Fatalf("stage = %s after FormGangs, want GANG_FORMED", gm.GetStage())
+	}
+	for _, stage := range []GangStage{GangStageScheduling, GangStageCooldown, GangStageScheduling, GangStageCooldown} {
+		if err := gm.SetStage(stage); err != nil {
+			t.Fatalf("SetStage(%s): %v", stage, err)
+		}
+	}
+	gm.DissolveAll()
+	if gm.GetStage() != GangStageDissolved {
+		t.Fatalf("stage = %s after DissolveAll, want DISSOLVED", gm.GetStage())
+	}
+	if err := gm.SetStage(GangStageNone); err != nil {
+		t.Fatalf("SetStage(NONE): %v", err)
+	}
+
+	var buf bytes.Buffer
+	m.WriteAllMetrics(&buf)
+	out := buf.String()
+	if !strings.Contains(out, "nexus_gang_invalid_stage_transitions_total 0\n") {
+		t.Error("valid lifecycle counted invalid transitions")
+	}
+	for _, stage := range []string{"NONE", "SPIKE_DETECTED", "GRAPH_BUILT", "GANG_FORMED", "SCHEDULING", "COOLDOWN", "DISSOLVED"} {
+		if !strings.Contains(out, `nexus_gang_stage_seconds_total{stage="`+stage+`"}`) {
+			t.Errorf("no time-in-stage series for %s", stage)
+		}
+	}
+	if !strings.Contains(out, `nexus_gang_stage{stage="NONE"} 1`) {
+		t.Error("nexus_gang_stage does not report NONE")
+	}
+}
+
+func TestStageInvalidTransitions(t *testing.T) {
+	cases := []struct {
+		name string
+		path []GangStage // valid steps from NONE
+		next GangStage
+	}{
+		{"scheduling without a spike", nil, GangStageScheduling},
+		{"dissolve without cooldown", []GangStage{GangStageDetected, GangStageGraphBuilt}, GangStageDissolved},
+		{"detect twice", []GangStage{GangStageDetected, GangStageGraphBuilt}, GangStageDetected},
+		{"back to formed from cooldown", []GangStage{GangStageDetected, GangStageGraphBuilt, GangStageCooldown}, GangStageFormed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := metrics.NewNEXUSMetrics()
+			gm := NewGangManager(m, 0, 0)
+			for _, stage := range tc.path {
+				if err := gm.SetStage(stage); err != nil {
+					t.Fatalf("SetStage(%s): %v", stage, err)
+				}
+			}
+			before := gm.GetStage()
+			if err := gm.SetStage(tc.next); err == nil {
+				t.Fatalf("%s → %s accepted", before, tc.next)
+			}
+			if gm.GetStage() != before {
+				t.Errorf("stage = %s after rejected transition, want %s", gm.GetStage(), before)
+			}
+
+			var buf bytes.Buffer
+			m.WriteAllMetrics(&buf)
+			if !strings.Contains(buf.String(), "nexus_gang_invalid_stage_transitions_total 1\n") {
+				t.Error("rejected transition not counted")
+			}
+		})
+	}
+}
+
+func TestFormGangsKeepsStageOutsideLifecycle(t *testing.T) {
+	gm := NewGangManager(metrics.NewNEXUSMetrics(), 0, 0)
+	gm.FormGangs([]graph.RuntimeGroup{strategyGroups[0]})
+	if gm.GetActiveGangCount() != 1 {
+		t.Fatalf("active gangs = %d, want 1", gm.GetActiveGangCount())
+	}
+	if gm.GetStage() != GangStageNone {
+		t.Errorf("stage = %s, want NONE: gangs formed without a detected spike", gm.GetStage())
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Gang Formation Strategies
+=========================
+A GangFormationStrategy turns the coordination groups of the dependency
+graph into the groups that actually become gangs, so the research can
+compare formation policies on the same spike:
+
+  per-group      One gang per coordination group (default)
+  merged         A single gang holding every service of every group
+  critical-path  Per group, only the longest depends-on chain between
+                 its members (heaviest by traffic on ties); groups
+                 without a chain of two or more services stay whole
+  top-k          Per group, only the K services with the most traffic
+                 (group order when no traffic is known)
+
+The strategy is chosen per episode (GANG_FORMATION_STRATEGY, or pinned
+at runtime through the admin API for the next episode).
+*/
+
+package gang
+
+import (
+	"fmt"
+	"sort"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/graph"
+)
+
+// mergedGroupName names the coordination group of the single merged gang
+const mergedGroupName = "merged"
+
+// FormationInput is the runtime information a strategy may use
+type FormationInput struct {
+	Dependencies map[string][]string       // service → direct dependencies
+	Traffic      func() map[string]float64 // service → current QPS (queried on demand, may be nil)
+}
+
+// GangFormationStrategy selects the groups gangs are formed from
+type GangFormationStrategy interface {
+	Name() string
+	Form(groups []graph.RuntimeGroup, input FormationInput) []graph.RuntimeGroup
+}
+
+// NewFormationStrategy returns the named strategy (topK is used by top-k)
+func NewFormationStrategy(name string, topK int) (GangFormationStrategy, error) {
+	switch name {
+	case config.GangFormationPerGroup, "":
+		return perGroupStrategy{}, nil
+	case config.GangFormationMerged:
+		return mergedStrategy{}, nil
+	case config.GangFormationCriticalPath:
+		return criticalPathStrategy{}, nil
+	case config.GangFormationTopK:
+		if topK < 1 {
+			topK = 1
+		}
+		return topKStrategy{k: topK}, nil
+	default:
+		return nil, fmt.Errorf("unknown gang formation strategy %q", name)
+	}
+}
+
+// perGroupStrategy forms one gang per coordination group
+type perGroupStrategy struct{}
+
+func (perGroupStrategy) Name() string { return config.GangFormationPerGroup }
+
+func (perGroupStrategy) Form(groups []graph.RuntimeGroup, _ FormationInput) []graph.RuntimeGroup {
+	return groups
+}
+
+// mergedStrategy forms a single gang from all groups
+type mergedStrategy struct{}
+
+func (mergedStrategy) Name() string { return config.GangFormationMerged }
+
+func (mergedStrategy) Form(groups []graph.RuntimeGroup, _ FormationInput) []graph.RuntimeGroup {
+	if len(groups) == 0 {
+		return groups
+	}
+
+	merged := graph.RuntimeGroup{Name: mergedGroupName}
+	seen := make(map[string]bool)
+	missing := make(map[string]bool)
+	for _, group := range groups {
+		for _, svc := range group.Services {
+			if !seen[svc] {
+				seen[svc] = true
+				merged.Services = append(merged.Services, svc)
+			}
+		}
+		for _, svc := range group.Missing {
+			if !missing[svc] {
+				missing[svc] = true
+				merged.Missing = append(merged.Missing, svc)
+			}
+		}
+		// The tightest declared SLO applies to the merged gang
+		if group.SLOP95Ms > 0 && (merged.SLOP95Ms == 0 || group.SLOP95Ms < merged.SLOP95Ms) {
+			merged.SLOP95Ms = group.SLOP95Ms
+		}
+	}
+	return []graph.RuntimeGroup{merged}
+}
+
+// criticalPathStrategy keeps the longest dependency chain of each group
+type criticalPathStrategy struct{}
+
+func (criticalPathStrategy) Name() string { return config.GangFormationCriticalPath }
+
+func (criticalPathStrategy) Form(groups []graph.RuntimeGroup, input FormationInput) []graph.RuntimeGroup {
+	var traffic map[string]float64
+	if input.Traffic != nil {
+		traffic = input.Traffic()
+	}
+
+	formed := make([]graph.RuntimeGroup, 0, len(groups))
+	for _, group := range groups {
+		path := longestChain(group.Services, input.Dependencies, traffic)
+		if len(path) >= 2 {
+			group.Services = path
+			group.Missing = retained(group.Missing, path)
+		}
+		formed = append(formed, group)
+	}
+	return formed
+}
+
+// longestChain returns the longest depends-on path between members, the
+// one carrying the most traffic on ties. Each service is visited once per
+// path, so dependency cycles terminate.
+func longestChain(members []string, deps map[string][]string, traffic map[string]float64) []string {
+	inGroup := make(map[string]bool, len(members))
+	for _, svc := range members {
+		inGroup[svc] = true
+	}
+
+	var best []string
+	bestTraffic := 0.0
+	onPath := make(map[string]bool)
+	var walk func(svc string, path []string, load float64)
+	walk = func(svc string, path []string, load float64) {
+		path = append(path, svc)
+		load += traffic[svc]
+		onPath[svc] = true
+		defer delete(onPath, svc)
+
+		extended := false
+		for _, dep := range deps[svc] {
+			if inGroup[dep] && !onPath[dep] {
+				extended = true
+				walk(dep, path, load)
+			}
+		}
+		if !extended && (len(path) > len(best) || (len(path) == len(best) && load > bestTraffic)) {
+			best = append([]string(nil), path...)
+			bestTraffic = load
+		}
+	}
+
+	for _, svc := range members {
+		walk(svc, nil, 0)
+	}
+	return best
+}
+
+// topKStrategy keeps the K busiest services of each group
+type topKStrategy struct {
+	k int
+}
+
+func (topKStrategy) Name() string { return config.GangFormationTopK }
+
+func (s topKStrategy) Form(groups []graph.RuntimeGroup, input FormationInput) []graph.RuntimeGroup {
+	var traffic map[string]float64
+	if input.Traffic != nil {
+		traffic = input.Traffic()
+	}
+
+	formed := make([]graph.RuntimeGroup, 0, len(groups))
+	for _, group := range groups {
+		if len(group.Services) > s.k {
+			services := append([]string(nil), group.Services...)
+			sort.SliceStable(services, func(i, j int) bool { return traffic[services[i]] > traffic[services[j]] })
+			group.Services = services[:s.k]
+			group.Missing = retained(group.Missing, group.Services)
+		}
+		formed = append(formed, group)
+	}
+	return formed
+}
+
+// retained keeps the missing services that are still gang members
+func retained(missing, members []string) []string {
+	kept := make([]string, 0, len(missing))
+	for _, svc := range missing {
+		for _, member := range members {
+			if svc == member {
+				kept = append(kept, svc)
+				break
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
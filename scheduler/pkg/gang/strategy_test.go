@@ -0,0 +1,112 @@
+package gang
+
+import (
+	"reflect"
+	"testing"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/graph"
+)
+
+var strategyGroups = []graph.RuntimeGroup{
+	{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice", "paymentservice", "currencyservice"}, SLOP95Ms: 300},
+	{Name: "product-browsing", Services: []string{"frontend", "productcatalogservice", "currencyservice"}, SLOP95Ms: 200},
+}
+
+var strategyInput = FormationInput{
+	Dependencies: map[string][]string{
+		"checkoutservice": {"cartservice", "paymentservice"},
+		"paymentservice":  {"currencyservice"},
+		"cartservice":     {"checkoutservice"}, // cycle: must terminate
+	},
+	Traffic: func() map[string]float64 {
+		return map[string]float64{"frontend": 90, "productcatalogservice": 40, "currencyservice": 60, "cartservice": 30}
+	},
+}
+
+func TestFormationStrategies(t *testing.T) {
+	cases := []struct {
+		name string
+		want []graph.RuntimeGroup
+	}{
+		{config.GangFormationPerGroup, strategyGroups},
+		{config.GangFormationMerged, []graph.RuntimeGroup{{
+			Name:     "merged",
+			Services: []string{"checkoutservice", "cartservice", "paymentservice", "currencyservice", "frontend", "productcatalogservice"},
+			SLOP95Ms: 200,
+		}}},
+		{config.GangFormationCriticalPath, []graph.RuntimeGroup{
+			{Name: "checkout-flow", Services: []string{"cartservice", "checkoutservice", "paymentservice", "currencyservice"}, SLOP95Ms: 300},
+			strategyGroups[1], // no chain between members: kept whole
+		}},
+		{config.GangFormationTopK, []graph.RuntimeGroup{
+			{Name: "checkout-flow", Services: []string{"currencyservice", "cartservice"}, SLOP95Ms: 300},
+			{Name: "product-browsing", Services: []string{"frontend", "currencyservice"}, SLOP95Ms: 200},
+		}},
+	}
+
+	for _, tc := range cases {
+		strategy, err := NewFormationStrategy(tc.name, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strategy.Form(strategyGroups, strategyInput); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := NewFormationStrategy("random", 2); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
+
+func TestResolveOverlaps(t *testing.T) {
+	groups := append([]graph.RuntimeGroup{{Name: "ads", Services: []string{"adservice"}}}, strategyGroups...)
+	cases := []struct {
+		mode     string
+		priority GroupPriority
+		want     []graph.RuntimeGroup
+		gang     string
+	}{
+		{config.GroupOverlapMerge, nil, []graph.RuntimeGroup{
+			groups[0],
+			{
+				Name:     "checkout-flow+product-browsing",
+				Services: []string{"checkoutservice", "cartservice", "paymentservice", "currencyservice", "frontend", "productcatalogservice"},
+				SLOP95Ms: 200,
+			},
+		}, "checkout-flow+product-browsing"},
+		// Tightest SLO wins without policy priorities
+		{config.GroupOverlapPriority, nil, []graph.RuntimeGroup{
+			groups[0],
+			{Name: "checkout-flow", Services: []string{"checkoutservice", "cartservice", "paymentservice"}, SLOP95Ms: 300},
+			groups[2],
+		}, "product-browsing"},
+		{config.GroupOverlapPriority, func(group string) float64 {
+			if group == "checkout-flow" {
+				return 2
+			}
+			return 1
+		}, []graph.RuntimeGroup{
+			groups[0],
+			groups[1],
+			{Name: "product-browsing", Services: []string{"frontend", "productcatalogservice"}, SLOP95Ms: 200},
+		}, "checkout-flow"},
+		{config.GroupOverlapError, nil, groups[:1], ""},
+	}
+
+	for _, tc := range cases {
+		got, overlaps := ResolveOverlaps(groups, tc.mode, tc.priority)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tc.mode, got, tc.want)
+		}
+		want := []GroupOverlap{{Service: "currencyservice", Groups: []string{"checkout-flow", "product-browsing"}, Resolution: tc.mode, Gang: tc.gang}}
+		if !reflect.DeepEqual(overlaps, want) {
+			t.Errorf("%s: overlaps = %+v, want %+v", tc.mode, overlaps, want)
+		}
+	}
+
+	if got, overlaps := ResolveOverlaps(groups[:2], config.GroupOverlapError, nil); len(got) != 2 || overlaps != nil {
+		t.Errorf("disjoint groups changed: %+v, overlaps %+v", got, overlaps)
+	}
+}
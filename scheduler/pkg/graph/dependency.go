@@ -0,0 +1,539 @@
+/*
+Dependency Graph Engine
+======================
+Builds an in-memory Directed Acyclic Graph (DAG) from pod annotations
+at RUNTIME during spike events. The graph is ephemeral - it exists
+only during the spike window and is cleared on dissolution.
+
+Required by Section 2D of the professional review:
+"Graph must not be static YAML-only. The graph must exist in
+memory only during spike window."
+
+Annotations used:
+  nexus.io/depends-on: "paymentservice,currencyservice"
+  nexus.io/service-group: "checkout-flow"
+  nexus.io/slo-p95-ms: "300"   (optional group latency SLO; lowest wins)
+
+If no annotations are found, falls back to the dependency patterns of the
+application profiles (Online Boutique unless APP_PROFILES says otherwise,
+see profiles.go) for the research experiment.
+
+Services declared in a group (directly or as a dependency) without any
+live pod among those listed are reported in the group's Missing list, so
+gangs do not silently count on members that are not deployed. Presence
+is only judged when the listing was complete (no pod budget truncation).
+
+Services the service filter does not permit (GANG_SERVICE_ALLOWLIST,
+GANG_SERVICE_DENYLIST, see servicefilter.go) never enter a group.
+Members NetworkPolicies cut off from the rest of their group are
+reported, or removed, with GRAPH_NETWORK_POLICY (see netpol.go).
+With GRAPH_ISTIO, Istio routes add dependencies and groups (mesh.go).
+*/
+
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+const (
+	// Annotation keys for dependency declaration
+	AnnotationDependsOn    = "nexus.io/depends-on"
+	AnnotationServiceGroup = "nexus.io/service-group"
+	AnnotationSLOP95       = "nexus.io/slo-p95-ms"
+)
+
+// RuntimeGroup represents a dynamically-discovered coordination group
+type RuntimeGroup struct {
+	Name     string   `json:"name"`
+	Services []string `json:"services"`
+	SLOP95Ms float64  `json:"sloP95Ms,omitempty"` // target p95 latency (0 = no SLO declared)
+	Missing  []string `json:"missing,omitempty"`  // services without live pods when the graph was built
+	Isolated []string `json:"isolated,omitempty"` // members NetworkPolicies cut off from the others (netpol.go)
+}
+
+// DependencyGraph builds and holds the in-memory service DAG
+type DependencyGraph struct {
+	podLister    *kube.PodLister
+	serviceLabel string // pod label holding the service name (scoped builds)
+	maxDepth     int    // depends-on hops pulled into a group (1 = direct deps only)
+	groups       []RuntimeGroup
+	edges        map[string]map[string]bool // service → direct dependencies
+	sloTargets   map[string]float64         // group → declared p95 target (ms)
+	present      map[string]bool            // services with live pods among those listed
+	filter       ServiceFilter              // services that may join groups
+	presenceOK   bool                       // listing was complete: absence means not deployed
+	built        bool
+
+	// Network path check of group members (netpol.go)
+	netpol        NetworkPolicySource
+	netpolEnforce bool
+	endpoints     map[string]Endpoint // service → one live pod's namespace and labels
+
+	// Dependencies and groups read from the service mesh (mesh.go)
+	mesh MeshRouteSource
+}
+
+// NewDependencyGraph creates a new (empty) dependency graph
+func NewDependencyGraph(podLister *kube.PodLister, serviceLabel string, maxDepth int) *DependencyGraph {
+	return &DependencyGraph{
+		podLister:    podLister,
+		serviceLabel: serviceLabel,
+		maxDepth:     maxDepth,
+		groups:       make([]RuntimeGroup, 0),
+		edges:        make(map[string]map[string]bool),
+		built:        false,
+	}
+}
+
+// SetServiceFilter restricts the services groups may contain
+func (dg *DependencyGraph) SetServiceFilter(filter ServiceFilter) {
+	dg.filter = filter
+}
+
+// BuildFromAnnotations scans all pods in the cluster for nexus.io annotations
+// and constructs the dependency graph at runtime
+func (dg *DependencyGraph) BuildFromAnnotations(ctx context.Context) error {
+	klog.Info("Building dependency graph from pod annotations...")
+
+	// List pods across all namespaces (paginated, within the pod budget)
+	pods, truncated, err := dg.podLister.List(ctx, "list pods for dependency graph", metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	if truncated {
+		klog.Warningf("Dependency graph built from the first %d pods only (pod budget reached)", len(pods))
+	}
+
+	// Build groups from annotations
+	groupMap := make(map[string]map[string]bool) // groupName → set of services
+	dg.edges = make(map[string]map[string]bool)
+	dg.sloTargets = make(map[string]float64)
+	dg.present = make(map[string]bool)
+	dg.endpoints = make(map[string]Endpoint)
+	dg.presenceOK = !truncated
+	for i := range pods {
+		dg.addPod(groupMap, &pods[i])
+	}
+	routes := dg.listMeshRoutes(ctx)
+	dg.addMeshEdges(routes)
+	dg.addMeshGroups(groupMap, routes, nil)
+
+	dg.setGroups(groupMap, nil)
+	dg.checkNetworkPaths(ctx)
+	return nil
+}
+
+// BuildForServices constructs the dependency graph only from the pods of the
+// services implicated by the spike signal plus their transitive depends-on
+// dependencies. Pods are fetched by label selector one dependency level at a
+// time, so graph-build latency scales with the spike, not the cluster.
+func (dg *DependencyGraph) BuildForServices(ctx context.Context, services []string) error {
+	klog.Infof("Building dependency graph scoped to spiking services %v...", services)
+
+	groupMap := make(map[string]map[string]bool) // groupName → set of services
+	dg.edges = make(map[string]map[string]bool)
+	dg.sloTargets = make(map[string]float64)
+	dg.present = make(map[string]bool)
+	dg.endpoints = make(map[string]Endpoint)
+	dg.presenceOK = true
+	visited := make(map[string]bool)
+	routes := dg.listMeshRoutes(ctx)
+	dg.addMeshEdges(routes)
+	frontier := meshScope(services, routes)
+
+	for len(frontier) > 0 {
+		for _, svc := range frontier {
+			visited[svc] = true
+		}
+
+		selector, err := serviceSelector(dg.serviceLabel, frontier)
+		if err != nil {
+			return err
+		}
+
+		pods, truncated, err := dg.podLister.List(ctx, "list pods for spiking services", metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return err
+		}
+		if truncated {
+			klog.Warningf("Scoped dependency graph truncated at %d pods (pod budget reached)", len(pods))
+			dg.presenceOK = false
+		}
+
+		// Queue dependencies we have not fetched yet for the next level
+		next := make([]string, 0)
+		queued := make(map[string]bool)
+		for i := range pods {
+			dg.addPod(groupMap, &pods[i])
+			for _, dep := range podDependencies(&pods[i]) {
+				if !visited[dep] && !queued[dep] {
+					queued[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		for _, svc := range frontier {
+			for dep := range dg.edges[svc] {
+				if !visited[dep] && !queued[dep] {
+					queued[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		frontier = next
+	}
+	dg.addMeshGroups(groupMap, routes, services)
+
+	dg.setGroups(groupMap, services)
+	dg.checkNetworkPaths(ctx)
+	return nil
+}
+
+// addPod records a pod's depends-on edges and, if it carries a
+// nexus.io/service-group annotation, adds its service to that group.
+// Dependencies are pulled into groups later by the transitive closure.
+func (dg *DependencyGraph) addPod(groupMap map[string]map[string]bool, pod *v1.Pod) {
+	serviceName := ExtractServiceName(pod.Name)
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		if dg.present == nil {
+			dg.present = make(map[string]bool)
+		}
+		dg.present[serviceName] = true
+		if _, ok := dg.endpoints[serviceName]; !ok && dg.endpoints != nil {
+			dg.endpoints[serviceName] = Endpoint{Namespace: pod.Namespace, Labels: labels.Set(pod.Labels)}
+		}
+	}
+
+	if pod.Annotations == nil {
+		return
+	}
+
+	// Record dependencies declared via depends-on
+	for _, dep := range podDependencies(pod) {
+		dg.addEdge(serviceName, dep)
+	}
+
+	// Check for service group annotation
+	groupName := pod.Annotations[AnnotationServiceGroup]
+	if groupName == "" {
+		return
+	}
+
+	if _, exists := groupMap[groupName]; !exists {
+		groupMap[groupName] = make(map[string]bool)
+	}
+	groupMap[groupName][serviceName] = true
+
+	if target := podSLOTarget(pod); target > 0 {
+		if current, ok := dg.sloTargets[groupName]; !ok || target < current {
+			dg.sloTargets[groupName] = target
+		}
+	}
+}
+
+// podSLOTarget parses the nexus.io/slo-p95-ms annotation of a pod (0 = none)
+func podSLOTarget(pod *v1.Pod) float64 {
+	raw, ok := pod.Annotations[AnnotationSLOP95]
+	if !ok {
+		return 0
+	}
+	target, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || target <= 0 {
+		klog.V(2).Infof("Pod %s/%s: ignoring invalid %s=%q", pod.Namespace, pod.Name, AnnotationSLOP95, raw)
+		return 0
+	}
+	return target
+}
+
+// dependencyClosure returns roots plus every service reachable from them
+// through depends-on edges within maxDepth hops. Each service is visited
+// once, so dependency cycles (a → b → a) terminate.
+func (dg *DependencyGraph) dependencyClosure(roots []string, maxDepth int) []string {
+	visited := make(map[string]bool, len(roots))
+	closure := make([]string, 0, len(roots))
+	frontier := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if !dg.filter.Permits(root) {
+			klog.V(2).Infof("Service %s is not permitted in gangs, not a group root", root)
+			continue
+		}
+		if !visited[root] {
+			visited[root] = true
+			closure = append(closure, root)
+			frontier = append(frontier, root)
+		}
+	}
+
+	for depth := 0; depth < maxDepth && len(frontier) > 0; depth++ {
+		next := make([]string, 0)
+		for _, svc := range frontier {
+			for dep := range dg.edges[svc] {
+				if visited[dep] {
+					klog.V(3).Infof("Dependency %s → %s already in closure (shared or cyclic), skipping", svc, dep)
+					continue
+				}
+				if !dg.filter.Permits(dep) {
+					klog.V(2).Infof("Dependency %s → %s is not permitted in gangs, not followed", svc, dep)
+					continue
+				}
+				visited[dep] = true
+				closure = append(closure, dep)
+				next = append(next, dep)
+			}
+		}
+		frontier = next
+	}
+
+	return closure
+}
+
+// podDependencies parses the nexus.io/depends-on annotation of a pod
+func podDependencies(pod *v1.Pod) []string {
+	depsStr, ok := pod.Annotations[AnnotationDependsOn]
+	if !ok {
+		return nil
+	}
+
+	deps := make([]string, 0)
+	for _, dep := range strings.Split(depsStr, ",") {
+		dep = strings.TrimSpace(dep)
+		if dep != "" {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// serviceSelector builds a "<label> in (svc1,svc2,...)" label selector
+func serviceSelector(labelKey string, services []string) (string, error) {
+	req, err := labels.NewRequirement(labelKey, selection.In, services)
+	if err != nil {
+		return "", fmt.Errorf("invalid service selector: %w", err)
+	}
+	return labels.NewSelector().Add(*req).String(), nil
+}
+
+// setGroups converts the discovered group map into RuntimeGroups.
+// When scope is non-empty and no annotations were found, only the
+// experiment default groups containing a scoped service are kept.
+func (dg *DependencyGraph) setGroups(groupMap map[string]map[string]bool, scope []string) {
+	dg.groups = make([]RuntimeGroup, 0, len(groupMap))
+	for name, services := range groupMap {
+		roots := make([]string, 0, len(services))
+		for svc := range services {
+			roots = append(roots, svc)
+		}
+		sort.Strings(roots)
+
+		// Pull in dependencies (and their dependencies) up to maxDepth hops
+		svcList := dg.dependencyClosure(roots, dg.maxDepth)
+		if len(svcList) == 0 {
+			klog.Infof("Coordination group '%s' has no permitted services, skipping", name)
+			continue
+		}
+
+		dg.groups = append(dg.groups, RuntimeGroup{
+			Name:     name,
+			Services: svcList,
+			SLOP95Ms: dg.sloTargets[name],
+		})
+		klog.Infof("Discovered coordination group '%s': %v", name, svcList)
+	}
+
+	// If no annotations found, use well-known defaults for the experiment
+	if len(groupMap) == 0 {
+		klog.Info("No annotations found, using the application profiles' dependencies")
+		dg.loadExperimentDefaults()
+		if len(scope) > 0 {
+			dg.groups = filterGroupsByServices(dg.groups, scope)
+		}
+	}
+
+	dg.markMissing()
+	dg.built = true
+	klog.Infof("Dependency graph built: %d coordination groups", len(dg.groups))
+}
+
+// markMissing records the members of each group without live pods
+func (dg *DependencyGraph) markMissing() {
+	if !dg.presenceOK {
+		return
+	}
+	for i := range dg.groups {
+		group := &dg.groups[i]
+		group.Missing = nil
+		for _, svc := range group.Services {
+			if !dg.present[svc] {
+				group.Missing = append(group.Missing, svc)
+			}
+		}
+		if len(group.Missing) > 0 {
+			klog.Warningf("Coordination group '%s': members %v have no live pods", group.Name, group.Missing)
+		}
+	}
+}
+
+// filterGroupsByServices keeps groups containing at least one of the services.
+// All groups are kept if none match, so a spike never ends up with no gangs.
+func filterGroupsByServices(groups []RuntimeGroup, services []string) []RuntimeGroup {
+	wanted := make(map[string]bool, len(services))
+	for _, svc := range services {
+		wanted[svc] = true
+	}
+
+	filtered := make([]RuntimeGroup, 0, len(groups))
+	for _, group := range groups {
+		for _, svc := range group.Services {
+			if wanted[svc] {
+				filtered = append(filtered, group)
+				break
+			}
+		}
+	}
+
+	if len(filtered) == 0 {
+		return groups
+	}
+	return filtered
+}
+
+// loadExperimentDefaults sets up the groups of the application profiles
+// matching the listed pods (all profiles if none matches). These are used
+// ONLY when no pod annotations exist (experiment mode)
+func (dg *DependencyGraph) loadExperimentDefaults() {
+	profiles := Profiles()
+	matched := make([]AppProfile, 0, len(profiles))
+	for _, p := range profiles {
+		for svc, endpoint := range dg.endpoints {
+			if p.Service(svc) != nil && p.Matches(endpoint.Namespace, endpoint.Labels) {
+				matched = append(matched, p)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = profiles
+	}
+	groups := make([]RuntimeGroup, 0)
+	for _, p := range matched {
+		groups = append(groups, p.Groups()...)
+	}
+	dg.groups, _ = dg.filter.Apply(groups)
+
+	klog.Info("Loaded experiment defaults:")
+	for _, group := range dg.groups {
+		klog.Infof("  - %s: %v", group.Name, group.Services)
+	}
+}
+
+// GetGroups returns all discovered coordination groups
+func (dg *DependencyGraph) GetGroups() []RuntimeGroup {
+	return dg.groups
+}
+
+// Dependencies returns the direct depends-on edges of the graph (service →
+// sorted dependencies); empty when groups came from experiment defaults
+func (dg *DependencyGraph) Dependencies() map[string][]string {
+	deps := make(map[string][]string, len(dg.edges))
+	for svc, edges := range dg.edges {
+		for dep := range edges {
+			deps[svc] = append(deps[svc], dep)
+		}
+		sort.Strings(deps[svc])
+	}
+	return deps
+}
+
+// IsBuilt returns whether the graph has been constructed
+func (dg *DependencyGraph) IsBuilt() bool {
+	return dg.built
+}
+
+// GetGroup returns the coordination group for a given pod
+func (dg *DependencyGraph) GetGroup(pod *v1.Pod) *RuntimeGroup {
+	serviceName := ExtractServiceName(pod.Name)
+	for i := range dg.groups {
+		for _, svc := range dg.groups[i].Services {
+			if svc == serviceName {
+				return &dg.groups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// IsInGroup checks if a pod belongs to any coordination group
+func (dg *DependencyGraph) IsInGroup(pod *v1.Pod) bool {
+	return dg.GetGroup(pod) != nil
+}
+
+// GetGroupMembers returns all service names in the same group as the pod
+func (dg *DependencyGraph) GetGroupMembers(pod *v1.Pod) []string {
+	group := dg.GetGroup(pod)
+	if group == nil {
+		return []string{}
+	}
+	return group.Services
+}
+
+// Restore loads previously discovered groups (used for restart recovery)
+func (dg *DependencyGraph) Restore(groups []RuntimeGroup) {
+	dg.groups = groups
+	dg.built = true
+	klog.Infof("Dependency graph restored: %d coordination groups", len(dg.groups))
+}
+
+// Clear frees all in-memory graph data
+// Called when spike window ends and gang is dissolved
+func (dg *DependencyGraph) Clear() {
+	dg.groups = make([]RuntimeGroup, 0)
+	dg.edges = make(map[string]map[string]bool)
+	dg.present = nil
+	dg.endpoints = nil
+	dg.presenceOK = false
+	dg.built = false
+	klog.Info("Dependency graph cleared — all in-memory DAG data freed")
+}
+
+// ExtractServiceName extracts the service name from a pod name
+// Online Boutique pods follow pattern: servicename-hash-hash
+func ExtractServiceName(podName string) string {
+	parts := strings.Split(podName, "-")
+	if len(parts) >= 3 {
+		// Handle multi-word service names like "redis-cart"
+		// Try to match known patterns
+		for i := len(parts) - 1; i >= 2; i-- {
+			candidate := strings.Join(parts[:i], "-")
+			if IsKnownService(candidate) {
+				return candidate
+			}
+		}
+		// Default: use first part
+		return parts[0]
+	}
+	if len(parts) > 0 {
+		return parts[0]
+	}
+	return podName
+}
+
+// IsKnownService checks if a name matches a service of an application
+// profile
+func IsKnownService(name string) bool {
+	return ProfileService(name) != nil
+}
@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExtractServiceName(t *testing.T) {
+	tests := []struct {
+		podName string
+		want    string
+	}{
+		{"checkoutservice-7d9f8c6b5-x2k4p", "checkoutservice"},
+		{"redis-cart-5b8f9c7d6-abcde", "redis-cart"},
+		{"frontend-6c8d5f7b9-qwert", "frontend"},
+		{"unknownsvc-123-456", "unknownsvc"},
+		{"standalone", "standalone"},
+		{"two-parts", "two"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractServiceName(tt.podName); got != tt.want {
+			t.Errorf("ExtractServiceName(%q) = %q, want %q", tt.podName, got, tt.want)
+		}
+	}
+}
+
+func FuzzExtractServiceName(f *testing.F) {
+	for _, seed := range []string{
+		"checkoutservice-7d9f8c6b5-x2k4p",
+		"redis-cart-5b8f9c7d6-abcde",
+		"frontend",
+		"---",
+		"-leading-dash",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, podName string) {
+		got := ExtractServiceName(podName)
+
+		// The service name is always a prefix of the pod name, ending at a dash
+		if !strings.HasPrefix(podName, got) {
+			t.Fatalf("ExtractServiceName(%q) = %q is not a prefix", podName, got)
+		}
+		if len(got) < len(podName) && podName[len(got)] != '-' {
+			t.Fatalf("ExtractServiceName(%q) = %q does not end at a dash", podName, got)
+		}
+
+		// Deployment pods of a known service always map back to that service
+		if IsKnownService(got) {
+			if again := ExtractServiceName(got + "-5b8f9c7d6-abcde"); again != got {
+				t.Fatalf("known service %q re-extracted as %q", got, again)
+			}
+		}
+	})
+}
+
+func TestGroupSLOTarget(t *testing.T) {
+	dg := &DependencyGraph{edges: map[string]map[string]bool{}, sloTargets: map[string]float64{}}
+	groupMap := make(map[string]map[string]bool)
+
+	for name, slo := range map[string]string{
+		"checkoutservice-7d9f8c6b5-x2k4p": "400",
+		"cartservice-6d5c7b8f9-abcde":     "250",
+		"paymentservice-5f6d7c8b9-zxcvb":  "not-a-number",
+	} {
+		dg.addPod(groupMap, &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{AnnotationServiceGroup: "checkout-flow", AnnotationSLOP95: slo},
+		}})
+	}
+	dg.setGroups(groupMap, nil)
+
+	if len(dg.groups) != 1 || dg.groups[0].SLOP95Ms != 250 {
+		t.Fatalf("groups = %+v, want checkout-flow with the lowest valid target (250ms)", dg.groups)
+	}
+}
+
+func TestGroupMissingMembers(t *testing.T) {
+	dg := &DependencyGraph{edges: map[string]map[string]bool{}, sloTargets: map[string]float64{}, maxDepth: 2, presenceOK: true}
+	groupMap := make(map[string]map[string]bool)
+
+	dg.addPod(groupMap, &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "checkoutservice-7d9f8c6b5-x2k4p",
+		Annotations: map[string]string{AnnotationServiceGroup: "checkout-flow", AnnotationDependsOn: "paymentservice,currencyservice"},
+	}})
+	dg.addPod(groupMap, &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "paymentservice-5f6d7c8b9-zxcvb"},
+	})
+	dg.addPod(groupMap, &v1.Pod{ // completed pods do not count as deployed
+		ObjectMeta: metav1.ObjectMeta{Name: "currencyservice-6c7d8e9f0-qwert"},
+		Status:     v1.PodStatus{Phase: v1.PodSucceeded},
+	})
+	dg.setGroups(groupMap, nil)
+
+	if len(dg.groups) != 1 || len(dg.groups[0].Services) != 3 {
+		t.Fatalf("groups = %+v, want checkout-flow with its two dependencies", dg.groups)
+	}
+	if missing := dg.groups[0].Missing; len(missing) != 1 || missing[0] != "currencyservice" {
+		t.Errorf("missing = %v, want [currencyservice]", missing)
+	}
+
+	// A truncated listing cannot prove absence
+	dg.presenceOK = false
+	dg.setGroups(groupMap, nil)
+	if missing := dg.groups[0].Missing; len(missing) != 0 {
+		t.Errorf("missing = %v after a truncated listing, want none", missing)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Mesh Discovery
+==============
+In a mesh whose routing already encodes the request flow, writing the
+same dependencies again as pod annotations is busywork. With
+GRAPH_ISTIO=true every graph build also reads the Istio routing config
+(pkg/kube/istio.go) on top of the annotations:
+
+  - a route matching callers by sourceLabels (GRAPH_SERVICE_LABEL) makes
+    each caller depend on every destination of that route
+  - a VirtualService routing its host to other services makes the host
+    depend on them
+  - a VirtualService or DestinationRule annotated nexus.io/service-group
+    adds its hosts and destinations to that group
+
+Mesh edges are followed like depends-on annotations, up to
+DEPENDENCY_DEPTH hops. A scoped build (GRAPH_SCOPE=spike) only takes the
+mesh groups holding a spiking service, and fetches all their members.
+Builds whose routes cannot be listed use the annotations alone.
+*/
+
+package graph
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+// MeshRouteSource lists the service mesh routing config
+type MeshRouteSource interface {
+	Routes(ctx context.Context) ([]kube.MeshRoute, error)
+}
+
+// SetMeshRoutes reads dependencies and groups from the mesh routes on
+// every build
+func (dg *DependencyGraph) SetMeshRoutes(source MeshRouteSource) {
+	dg.mesh = source
+}
+
+// listMeshRoutes returns the mesh routes (nil without a source or when
+// they cannot be listed)
+func (dg *DependencyGraph) listMeshRoutes(ctx context.Context) []kube.MeshRoute {
+	if dg.mesh == nil {
+		return nil
+	}
+	routes, err := dg.mesh.Routes(ctx)
+	if err != nil {
+		klog.Warningf("Mesh routes not read, using annotations only: %v", err)
+		return nil
+	}
+	klog.V(2).Infof("Read %d mesh routes for the dependency graph", len(routes))
+	return routes
+}
+
+// addMeshEdges records the dependencies the mesh routes declare
+func (dg *DependencyGraph) addMeshEdges(routes []kube.MeshRoute) {
+	for _, route := range routes {
+		callers := route.Hosts
+		if len(route.SourceLabels) > 0 {
+			callers = nil
+			for _, selector := range route.SourceLabels {
+				if svc := selector[dg.serviceLabel]; svc != "" {
+					callers = append(callers, svc)
+				}
+			}
+		}
+		for _, caller := range callers {
+			for _, dest := range route.Destinations {
+				if dest != caller {
+					dg.addEdge(caller, dest)
+				}
+			}
+		}
+	}
+}
+
+// meshScope returns the services plus every member of the mesh groups
+// holding one of them
+func meshScope(services []string, routes []kube.MeshRoute) []string {
+	spiking := make(map[string]bool, len(services))
+	for _, svc := range services {
+		spiking[svc] = true
+	}
+
+	seen := make(map[string]bool, len(services))
+	scope := make([]string, 0, len(services))
+	add := func(svc string) {
+		if !seen[svc] {
+			seen[svc] = true
+			scope = append(scope, svc)
+		}
+	}
+	for _, svc := range services {
+		add(svc)
+	}
+	for _, members := range meshGroups(routes) {
+		if !containsAny(members, spiking) {
+			continue
+		}
+		for svc := range members {
+			add(svc)
+		}
+	}
+	return scope
+}
+
+// addMeshGroups adds the members of the annotated mesh routes to their
+// groups; a non-empty scope keeps only the groups holding a scoped service
+func (dg *DependencyGraph) addMeshGroups(groupMap map[string]map[string]bool, routes []kube.MeshRoute, scope []string) {
+	wanted := make(map[string]bool, len(scope))
+	for _, svc := range scope {
+		wanted[svc] = true
+	}
+
+	for name, members := range meshGroups(routes) {
+		if len(scope) > 0 && !containsAny(members, wanted) {
+			continue
+		}
+		if _, exists := groupMap[name]; !exists {
+			groupMap[name] = make(map[string]bool)
+		}
+		for svc := range members {
+			groupMap[name][svc] = true
+		}
+	}
+}
+
+// meshGroups returns the services of the annotated mesh routes by group
+func meshGroups(routes []kube.MeshRoute) map[string]map[string]bool {
+	groups := make(map[string]map[string]bool)
+	for _, route := range routes {
+		name := route.Annotations[AnnotationServiceGroup]
+		if name == "" {
+			continue
+		}
+		if _, exists := groups[name]; !exists {
+			groups[name] = make(map[string]bool)
+		}
+		for _, svc := range append(append([]string{}, route.Hosts...), route.Destinations...) {
+			groups[name][svc] = true
+		}
+	}
+	return groups
+}
+
+// containsAny reports whether the set holds one of the wanted services
+func containsAny(set, wanted map[string]bool) bool {
+	for svc := range set {
+		if wanted[svc] {
+			return true
+		}
+	}
+	return false
+}
+
+// addEdge records that service depends on dep
+func (dg *DependencyGraph) addEdge(service, dep string) {
+	if _, exists := dg.edges[service]; !exists {
+		dg.edges[service] = make(map[string]bool)
+	}
+	dg.edges[service][dep] = true
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+// fakeMesh is a fixed MeshRouteSource
+type fakeMesh struct {
+	routes []kube.MeshRoute
+	err    error
+}
+
+func (f fakeMesh) Routes(context.Context) ([]kube.MeshRoute, error) {
+	return f.routes, f.err
+}
+
+// checkoutRoutes route the checkout flow through the mesh
+var checkoutRoutes = []kube.MeshRoute{
+	{
+		Kind:         "VirtualService",
+		Name:         "checkout",
+		Annotations:  map[string]string{AnnotationServiceGroup: "checkout-flow"},
+		Hosts:        []string{"checkoutservice"},
+		Destinations: []string{"checkoutservice"},
+	},
+	{
+		Kind:         "VirtualService",
+		Name:         "payment",
+		Hosts:        []string{"paymentservice"},
+		SourceLabels: []map[string]string{{"app": "checkoutservice"}},
+		Destinations: []string{"paymentservice"},
+	},
+	{
+		Kind:         "VirtualService",
+		Name:         "cart",
+		Hosts:        []string{"cart"},
+		Destinations: []string{"cartservice"},
+	},
+}
+
+func TestMeshDiscovery(t *testing.T) {
+	dg := &DependencyGraph{edges: map[string]map[string]bool{}, sloTargets: map[string]float64{}, serviceLabel: "app", maxDepth: 2}
+	dg.SetMeshRoutes(fakeMesh{routes: checkoutRoutes})
+	groupMap := make(map[string]map[string]bool)
+
+	routes := dg.listMeshRoutes(context.Background())
+	dg.addMeshEdges(routes)
+	dg.addMeshGroups(groupMap, routes, nil)
+	dg.setGroups(groupMap, nil)
+
+	if len(dg.groups) != 1 || !reflect.DeepEqual(dg.groups[0].Services, []string{"checkoutservice", "paymentservice"}) {
+		t.Fatalf("groups = %+v, want checkout-flow with paymentservice pulled in by sourceLabels", dg.groups)
+	}
+	if deps := dg.Dependencies(); !reflect.DeepEqual(deps["cart"], []string{"cartservice"}) || len(deps["checkoutservice"]) != 1 {
+		t.Errorf("dependencies = %v, want cart → cartservice and checkoutservice → paymentservice", deps)
+	}
+
+	// Scoped builds take only the groups of a spiking service
+	groupMap = make(map[string]map[string]bool)
+	dg.addMeshGroups(groupMap, routes, []string{"cartservice"})
+	if len(groupMap) != 0 {
+		t.Errorf("scoped groups = %v, want none", groupMap)
+	}
+	if scope := meshScope([]string{"checkoutservice"}, routes); !reflect.DeepEqual(scope, []string{"checkoutservice"}) {
+		t.Errorf("mesh scope = %v, want [checkoutservice]", scope)
+	}
+
+	// Unlisted routes leave the annotations alone
+	dg.SetMeshRoutes(fakeMesh{err: errors.New("the server could not find the requested resource")})
+	if routes := dg.listMeshRoutes(context.Background()); routes != nil {
+		t.Errorf("routes after failed listing = %v, want nil", routes)
+	}
+}
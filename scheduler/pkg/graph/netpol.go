@@ -0,0 +1,201 @@
+/*
+Network Paths Between Group Members
+===================================
+A mis-annotated group can put services together that NetworkPolicies
+keep from ever talking to each other; co-locating them buys nothing.
+With GRAPH_NETWORK_POLICY set, every graph build checks each member of a
+group for an allowed path to or from at least one other member:
+
+  traffic a → b is allowed when a's egress policies (if any select a)
+  admit b and b's ingress policies (if any select b) admit a
+
+Members are represented by the namespace and labels of one live pod of
+the service seen while building. Ports are not compared (a path on any
+port counts) and ipBlock peers never match a pod. A member with no path
+is reported in the group's Isolated list and logged as a warning
+("warn"); "enforce" also removes it from the group, and a group left
+without members is dropped. Members without a live pod, and builds whose
+policies cannot be listed, are not judged.
+*/
+
+package graph
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// NetworkPolicySource lists NetworkPolicies and namespace labels
+type NetworkPolicySource interface {
+	List(ctx context.Context) ([]networkingv1.NetworkPolicy, map[string]labels.Set, error)
+}
+
+// Endpoint is the namespace and labels of one pod of a service
+type Endpoint struct {
+	Namespace string
+	Labels    labels.Set
+}
+
+// SetNetworkPolicies enables the network path check of group members;
+// with enforce, members without a path are removed from their group
+func (dg *DependencyGraph) SetNetworkPolicies(source NetworkPolicySource, enforce bool) {
+	dg.netpol = source
+	dg.netpolEnforce = enforce
+}
+
+// checkNetworkPaths records, and with enforce removes, the group members
+// without an allowed network path to or from another member
+func (dg *DependencyGraph) checkNetworkPaths(ctx context.Context) {
+	if dg.netpol == nil {
+		return
+	}
+	policies, namespaceLabels, err := dg.netpol.List(ctx)
+	if err != nil {
+		klog.Warningf("Network paths of group members not checked: %v", err)
+		return
+	}
+
+	kept := dg.groups[:0]
+	for _, group := range dg.groups {
+		group.Isolated = isolatedMembers(group.Services, dg.endpoints, policies, namespaceLabels)
+		if len(group.Isolated) > 0 {
+			klog.Warningf("Coordination group '%s': NetworkPolicies allow members %v no path to any other member", group.Name, group.Isolated)
+			if dg.netpolEnforce {
+				group.Services = without(group.Services, group.Isolated)
+				group.Missing = without(group.Missing, group.Isolated)
+			}
+		}
+		if len(group.Services) == 0 {
+			klog.Warningf("Coordination group '%s' has no members left with a network path, skipping", group.Name)
+			continue
+		}
+		kept = append(kept, group)
+	}
+	dg.groups = kept
+}
+
+// isolatedMembers returns the services with an endpoint that have no
+// allowed path to or from any other service with an endpoint
+func isolatedMembers(services []string, endpoints map[string]Endpoint, policies []networkingv1.NetworkPolicy, namespaceLabels map[string]labels.Set) []string {
+	var isolated []string
+	for _, svc := range services {
+		from, ok := endpoints[svc]
+		if !ok {
+			continue
+		}
+		judged, connected := false, false
+		for _, other := range services {
+			to, ok := endpoints[other]
+			if other == svc || !ok {
+				continue
+			}
+			judged = true
+			if PathAllowed(from, to, policies, namespaceLabels) || PathAllowed(to, from, policies, namespaceLabels) {
+				connected = true
+				break
+			}
+		}
+		if judged && !connected {
+			isolated = append(isolated, svc)
+		}
+	}
+	return isolated
+}
+
+// PathAllowed reports whether the policies allow traffic from one
+// endpoint to another
+func PathAllowed(from, to Endpoint, policies []networkingv1.NetworkPolicy, namespaceLabels map[string]labels.Set) bool {
+	egressSelected, egressAllowed := false, false
+	ingressSelected, ingressAllowed := false, false
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Namespace == from.Namespace && hasPolicyType(policy, networkingv1.PolicyTypeEgress) && selects(&policy.Spec.PodSelector, from.Labels) {
+			egressSelected = true
+			for _, rule := range policy.Spec.Egress {
+				if peersMatch(rule.To, policy.Namespace, to, namespaceLabels) {
+					egressAllowed = true
+				}
+			}
+		}
+		if policy.Namespace == to.Namespace && hasPolicyType(policy, networkingv1.PolicyTypeIngress) && selects(&policy.Spec.PodSelector, to.Labels) {
+			ingressSelected = true
+			for _, rule := range policy.Spec.Ingress {
+				if peersMatch(rule.From, policy.Namespace, from, namespaceLabels) {
+					ingressAllowed = true
+				}
+			}
+		}
+	}
+	return (!egressSelected || egressAllowed) && (!ingressSelected || ingressAllowed)
+}
+
+// hasPolicyType reports whether a policy restricts traffic of the given
+// direction (policyTypes unset: ingress, plus egress with egress rules)
+func hasPolicyType(policy *networkingv1.NetworkPolicy, policyType networkingv1.PolicyType) bool {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return policyType == networkingv1.PolicyTypeIngress ||
+			(policyType == networkingv1.PolicyTypeEgress && len(policy.Spec.Egress) > 0)
+	}
+	for _, t := range policy.Spec.PolicyTypes {
+		if t == policyType {
+			return true
+		}
+	}
+	return false
+}
+
+// peersMatch reports whether a rule's peers admit the endpoint (no peers
+// admit every endpoint)
+func peersMatch(peers []networkingv1.NetworkPolicyPeer, policyNamespace string, endpoint Endpoint, namespaceLabels map[string]labels.Set) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+			continue // ipBlock
+		}
+		if peer.NamespaceSelector != nil {
+			if !selects(peer.NamespaceSelector, namespaceLabels[endpoint.Namespace]) {
+				continue
+			}
+		} else if endpoint.Namespace != policyNamespace {
+			continue
+		}
+		if peer.PodSelector == nil || selects(peer.PodSelector, endpoint.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// selects reports whether a label selector matches set (invalid
+// selectors match nothing)
+func selects(selector *metav1.LabelSelector, set labels.Set) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(set)
+}
+
+// without returns services minus the removed ones
+func without(services, removed []string) []string {
+	drop := make(map[string]bool, len(removed))
+	for _, svc := range removed {
+		drop[svc] = true
+	}
+	kept := make([]string, 0, len(services))
+	for _, svc := range services {
+		if !drop[svc] {
+			kept = append(kept, svc)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// testNamespaces are the namespace labels of the tests
+var testNamespaces = map[string]labels.Set{"shop": {"team": "shop"}, "lab": {"team": "lab"}}
+
+// fakePolicies is a fixed NetworkPolicySource
+type fakePolicies struct {
+	policies []networkingv1.NetworkPolicy
+	err      error
+}
+
+func (f fakePolicies) List(context.Context) ([]networkingv1.NetworkPolicy, map[string]labels.Set, error) {
+	return f.policies, testNamespaces, f.err
+}
+
+// ingressOnly admits traffic to app=to only from the given peers
+func ingressOnly(namespace, to string, from ...networkingv1.NetworkPolicyPeer) networkingv1.NetworkPolicy {
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-" + to, Namespace: namespace},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": to}},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: from}},
+		},
+	}
+}
+
+// denyEgress blocks all egress of app=from
+func denyEgress(namespace, from string) networkingv1.NetworkPolicy {
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-" + from, Namespace: namespace},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": from}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		},
+	}
+}
+
+func podPeer(app string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}}
+}
+
+func endpoint(namespace, app string) Endpoint {
+	return Endpoint{Namespace: namespace, Labels: labels.Set{"app": app}}
+}
+
+func TestPathAllowed(t *testing.T) {
+	lab := networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "lab"}}}
+	cases := []struct {
+		name     string
+		policies []networkingv1.NetworkPolicy
+		from, to Endpoint
+		want     bool
+	}{
+		{"no policies", nil, endpoint("shop", "a"), endpoint("shop", "b"), true},
+		{"ingress admits", []networkingv1.NetworkPolicy{ingressOnly("shop", "b", podPeer("a"))}, endpoint("shop", "a"), endpoint("shop", "b"), true},
+		{"ingress excludes", []networkingv1.NetworkPolicy{ingressOnly("shop", "b", podPeer("c"))}, endpoint("shop", "a"), endpoint("shop", "b"), false},
+		{"pod peer is same namespace", []networkingv1.NetworkPolicy{ingressOnly("shop", "b", podPeer("a"))}, endpoint("lab", "a"), endpoint("shop", "b"), false},
+		{"namespace peer", []networkingv1.NetworkPolicy{ingressOnly("shop", "b", lab)}, endpoint("lab", "a"), endpoint("shop", "b"), true},
+		{"egress denied", []networkingv1.NetworkPolicy{denyEgress("shop", "a")}, endpoint("shop", "a"), endpoint("shop", "b"), false},
+		{"other pod unaffected", []networkingv1.NetworkPolicy{denyEgress("shop", "a")}, endpoint("shop", "b"), endpoint("shop", "a"), true},
+	}
+	for _, tc := range cases {
+		if got := PathAllowed(tc.from, tc.to, tc.policies, testNamespaces); got != tc.want {
+			t.Errorf("%s: PathAllowed = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheckNetworkPaths(t *testing.T) {
+	// c may neither reach nor be reached by a or b
+	policies := []networkingv1.NetworkPolicy{
+		ingressOnly("shop", "c", podPeer("nobody")),
+		denyEgress("shop", "c"),
+	}
+	for _, enforce := range []bool{false, true} {
+		dg := &DependencyGraph{
+			groups: []RuntimeGroup{{Name: "checkout-flow", Services: []string{"a", "b", "c", "d"}, Missing: []string{"d"}}},
+			endpoints: map[string]Endpoint{
+				"a": endpoint("shop", "a"),
+				"b": endpoint("shop", "b"),
+				"c": endpoint("shop", "c"),
+			},
+		}
+		dg.SetNetworkPolicies(fakePolicies{policies: policies}, enforce)
+		dg.checkNetworkPaths(context.Background())
+
+		group := dg.groups[0]
+		if !reflect.DeepEqual(group.Isolated, []string{"c"}) {
+			t.Errorf("enforce %v: isolated = %v, want [c]", enforce, group.Isolated)
+		}
+		want := []string{"a", "b", "c", "d"}
+		if enforce {
+			want = []string{"a", "b", "d"}
+		}
+		if !reflect.DeepEqual(group.Services, want) {
+			t.Errorf("enforce %v: services = %v, want %v", enforce, group.Services, want)
+		}
+	}
+
+	// Unlisted policies leave the groups unjudged
+	dg := &DependencyGraph{groups: []RuntimeGroup{{Name: "g", Services: []string{"a", "c"}}}, endpoints: map[string]Endpoint{"a": endpoint("shop", "a"), "c": endpoint("shop", "c")}}
+	dg.SetNetworkPolicies(fakePolicies{policies: policies, err: errors.New("forbidden")}, true)
+	dg.checkNetworkPaths(context.Background())
+	if len(dg.groups) != 1 || len(dg.groups[0].Isolated) != 0 || len(dg.groups[0].Services) != 2 {
+		t.Errorf("groups after failed listing = %+v, want unchanged", dg.groups)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Application Profile
+===================
+The embedded description of the Online Boutique deployment the research
+experiment runs against: every service (Deployment name = service name),
+the coordination group it belongs to and the depends-on edges within its
+group. It is the single source of the experiment defaults used when no
+pod carries annotations, and of the annotations `nexus-scheduler annotate`
+writes onto the Deployments' pod templates.
+
+Only edges between members of the same group are declared: a depends-on
+target is pulled into the group by the dependency closure, so an edge to
+e.g. shippingservice would grow checkout-flow beyond the experiment's
+group.
+
+Further applications can be profiled alongside it (APP_PROFILES, see
+profiles.go).
+*/
+
+package graph
+
+import "strings"
+
+// ServiceProfile describes one service of an application profile
+type ServiceProfile struct {
+	Name      string   `json:"name"`
+	Group     string   `json:"group,omitempty"`     // coordination group ("" = not coordinated)
+	DependsOn []string `json:"dependsOn,omitempty"` // dependencies within the group
+}
+
+// AppProfile describes the services of an application
+type AppProfile struct {
+	Name       string            `json:"name"`
+	Namespaces []string          `json:"namespaces,omitempty"` // namespaces the application runs in (empty = any)
+	Selector   map[string]string `json:"selector,omitempty"`   // labels of its pods (empty = any)
+	Services   []ServiceProfile  `json:"services"`
+
+	// Per-service spike QPS threshold of its services (0 = threshold profile's)
+	ServiceQPSThreshold float64 `json:"serviceQpsThreshold,omitempty"`
+}
+
+// OnlineBoutique is the profile of the Online Boutique demo application
+var OnlineBoutique = AppProfile{
+	Name: "online-boutique",
+	Services: []ServiceProfile{
+		{Name: "cartservice", Group: "checkout-flow"},
+		{Name: "paymentservice", Group: "checkout-flow"},
+		{Name: "checkoutservice", Group: "checkout-flow", DependsOn: []string{"cartservice", "paymentservice", "currencyservice"}},
+		{Name: "currencyservice", Group: "checkout-flow"},
+		{Name: "frontend", Group: "product-browsing", DependsOn: []string{"productcatalogservice", "recommendationservice"}},
+		{Name: "productcatalogservice", Group: "product-browsing"},
+		{Name: "recommendationservice", Group: "product-browsing", DependsOn: []string{"productcatalogservice"}},
+		{Name: "emailservice"},
+		{Name: "shippingservice"},
+		{Name: "adservice"},
+		{Name: "redis-cart"},
+		{Name: "loadgenerator"},
+	},
+}
+
+// Service returns the profile of a service (nil if not part of the application)
+func (p AppProfile) Service(name string) *ServiceProfile {
+	for i := range p.Services {
+		if p.Services[i].Name == name {
+			return &p.Services[i]
+		}
+	}
+	return nil
+}
+
+// Groups returns the coordination groups of the profile, in the order
+// their first member is listed
+func (p AppProfile) Groups() []RuntimeGroup {
+	groups := make([]RuntimeGroup, 0)
+	index := make(map[string]int)
+	for _, svc := range p.Services {
+		if svc.Group == "" {
+			continue
+		}
+		i, ok := index[svc.Group]
+		if !ok {
+			i = len(groups)
+			index[svc.Group] = i
+			groups = append(groups, RuntimeGroup{Name: svc.Group})
+		}
+		groups[i].Services = append(groups[i].Services, svc.Name)
+	}
+	return groups
+}
+
+// Annotations returns the nexus.io annotations of a service's pods; a key
+// mapped to "" is not set for the service
+func (s ServiceProfile) Annotations() map[string]string {
+	return map[string]string{
+		AnnotationServiceGroup: s.Group,
+		AnnotationDependsOn:    strings.Join(s.DependsOn, ","),
+	}
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOnlineBoutiqueProfileAnnotationsFormItsGroups(t *testing.T) {
+	// Pods annotated from the profile must form the groups the profile
+	// declares, so annotating the cluster matches the experiment defaults
+	dg := &DependencyGraph{edges: map[string]map[string]bool{}, sloTargets: map[string]float64{}, maxDepth: 1}
+	groupMap := make(map[string]map[string]bool)
+	for _, svc := range OnlineBoutique.Services {
+		annotations := make(map[string]string)
+		for key, value := range svc.Annotations() {
+			if value != "" {
+				annotations[key] = value
+			}
+		}
+		dg.addPod(groupMap, &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        svc.Name + "-7d9f8c6b5-x2k4p",
+			Annotations: annotations,
+		}})
+	}
+	dg.setGroups(groupMap, nil)
+
+	want := make(map[string][]string)
+	for _, group := range OnlineBoutique.Groups() {
+		want[group.Name] = sortedCopy(group.Services)
+	}
+	got := make(map[string][]string)
+	for _, group := range dg.groups {
+		got[group.Name] = sortedCopy(group.Services)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups from profile annotations = %v, want %v", got, want)
+	}
+	if services := want["checkout-flow"]; len(services) != 4 {
+		t.Errorf("checkout-flow = %v, want the 4 experiment services", services)
+	}
+}
+
+// sortedCopy returns a sorted copy of a service list
+func sortedCopy(services []string) []string {
+	out := append([]string(nil), services...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,179 @@
+/*
+Application Profiles
+====================
+One NEXUS deployment can serve several benchmark applications in the same
+cluster. APP_PROFILES holds a JSON list of application profiles, each
+matched to its pods by namespace and pod labels and carrying its own
+groups and per-service spike threshold:
+
+  [{"name": "online-boutique", "namespaces": ["shop"]},
+   {"name": "bookinfo", "namespaces": ["books"], "serviceQpsThreshold": 40,
+    "services": [
+      {"name": "productpage", "group": "review-flow", "dependsOn": ["reviews", "details"]},
+      {"name": "reviews", "group": "review-flow"},
+      {"name": "details", "group": "review-flow"},
+      {"name": "ratings"}]}]
+
+A profile named online-boutique without services stands for the embedded
+profile (profile.go). Unset, the embedded profile is the only one.
+
+Services are identified by name across the cluster (pod names, spike
+attribution), so a service or group may only be declared by one profile.
+When no pod carries annotations, the groups of the profiles matching the
+listed pods are the experiment defaults, or of every profile if none
+matches. A profile's serviceQpsThreshold replaces the active threshold
+profile's per-service QPS threshold for its services.
+*/
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// activeProfiles holds the application profiles in use and an index of
+// their services
+var activeProfiles = struct {
+	mu       sync.RWMutex
+	profiles []AppProfile
+	services map[string]int // service → index of its profile
+}{
+	profiles: []AppProfile{OnlineBoutique},
+	services: serviceIndex([]AppProfile{OnlineBoutique}),
+}
+
+// ParseProfiles parses an APP_PROFILES list; "" is the embedded Online
+// Boutique profile alone
+func ParseProfiles(raw string) ([]AppProfile, error) {
+	if raw == "" {
+		return []AppProfile{OnlineBoutique}, nil
+	}
+	var profiles []AppProfile
+	if err := json.Unmarshal([]byte(raw), &profiles); err != nil {
+		return nil, fmt.Errorf("invalid APP_PROFILES: %w", err)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("APP_PROFILES lists no profiles")
+	}
+
+	names := make(map[string]bool)
+	services := make(map[string]string) // service → profile
+	groups := make(map[string]string)   // group → profile
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Name == "" || names[p.Name] {
+			return nil, fmt.Errorf("APP_PROFILES: profile %d has an empty or repeated name %q", i, p.Name)
+		}
+		names[p.Name] = true
+		if p.Name == OnlineBoutique.Name && len(p.Services) == 0 {
+			p.Services = OnlineBoutique.Services
+		}
+		if p.ServiceQPSThreshold < 0 {
+			return nil, fmt.Errorf("APP_PROFILES: profile %s has a negative serviceQpsThreshold", p.Name)
+		}
+		for _, svc := range p.Services {
+			if svc.Name == "" {
+				return nil, fmt.Errorf("APP_PROFILES: profile %s has a service without a name", p.Name)
+			}
+			if other, ok := services[svc.Name]; ok {
+				return nil, fmt.Errorf("APP_PROFILES: service %s is declared by profiles %s and %s", svc.Name, other, p.Name)
+			}
+			services[svc.Name] = p.Name
+			if other, ok := groups[svc.Group]; ok && svc.Group != "" && other != p.Name {
+				return nil, fmt.Errorf("APP_PROFILES: group %s is declared by profiles %s and %s", svc.Group, other, p.Name)
+			}
+			groups[svc.Group] = p.Name
+		}
+	}
+	return profiles, nil
+}
+
+// SetProfiles replaces the application profiles in use
+func SetProfiles(profiles []AppProfile) {
+	activeProfiles.mu.Lock()
+	defer activeProfiles.mu.Unlock()
+	activeProfiles.profiles = profiles
+	activeProfiles.services = serviceIndex(profiles)
+}
+
+// Profiles returns the application profiles in use
+func Profiles() []AppProfile {
+	activeProfiles.mu.RLock()
+	defer activeProfiles.mu.RUnlock()
+	return activeProfiles.profiles
+}
+
+// LookupProfile returns the profile of the given name
+func LookupProfile(name string) (AppProfile, bool) {
+	for _, p := range Profiles() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return AppProfile{}, false
+}
+
+// ProfileService returns the profile of a service in any application (nil
+// if no profile declares it)
+func ProfileService(name string) *ServiceProfile {
+	activeProfiles.mu.RLock()
+	defer activeProfiles.mu.RUnlock()
+	i, ok := activeProfiles.services[name]
+	if !ok {
+		return nil
+	}
+	return activeProfiles.profiles[i].Service(name)
+}
+
+// ServiceQPSThresholds returns the per-service spike QPS threshold of the
+// services of every profile that sets one
+func ServiceQPSThresholds() map[string]float64 {
+	thresholds := make(map[string]float64)
+	for _, p := range Profiles() {
+		if p.ServiceQPSThreshold <= 0 {
+			continue
+		}
+		for _, svc := range p.Services {
+			thresholds[svc.Name] = p.ServiceQPSThreshold
+		}
+	}
+	return thresholds
+}
+
+// Matches reports whether a pod of the namespace with the labels belongs
+// to the application
+func (p AppProfile) Matches(namespace string, podLabels map[string]string) bool {
+	if len(p.Namespaces) > 0 {
+		found := false
+		for _, ns := range p.Namespaces {
+			if ns == namespace {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for key, value := range p.Selector {
+		if podLabels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// serviceIndex maps every service of the profiles to its profile's index
+func serviceIndex(profiles []AppProfile) map[string]int {
+	index := make(map[string]int)
+	for i, p := range profiles {
+		for _, svc := range p.Services {
+			if _, ok := index[svc.Name]; !ok {
+				index[svc.Name] = i
+			}
+		}
+	}
+	return index
+}
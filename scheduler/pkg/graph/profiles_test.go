@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const testProfiles = `[
+  {"name": "online-boutique", "namespaces": ["shop"]},
+  {"name": "bookinfo", "namespaces": ["books"], "selector": {"bench": "bookinfo"}, "serviceQpsThreshold": 40,
+   "services": [
+     {"name": "productpage", "group": "review-flow", "dependsOn": ["reviews-v2"]},
+     {"name": "reviews-v2", "group": "review-flow"},
+     {"name": "ratings"}]}]`
+
+// useProfiles installs the profiles of raw for the test
+func useProfiles(t *testing.T, raw string) {
+	t.Helper()
+	profiles, err := ParseProfiles(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProfiles(profiles)
+	t.Cleanup(func() { SetProfiles([]AppProfile{OnlineBoutique}) })
+}
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := ParseProfiles(testProfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || len(profiles[0].Services) != len(OnlineBoutique.Services) {
+		t.Errorf("online-boutique without services not expanded: %+v", profiles[0])
+	}
+
+	for raw, want := range map[string]string{
+		`[]`: "lists no profiles",
+		`[{"name": "a", "services": [{"name": "x"}]}, {"name": "b", "services": [{"name": "x"}]}]`:                             "service x is declared by profiles a and b",
+		`[{"name": "a", "services": [{"name": "x", "group": "g"}]}, {"name": "b", "services": [{"name": "y", "group": "g"}]}]`: "group g is declared by profiles a and b",
+		`[{"name": "a"}, {"name": "a"}]`: "repeated name",
+		`{`:                              "invalid APP_PROFILES",
+	} {
+		if _, err := ParseProfiles(raw); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseProfiles(%s) error = %v, want %q", raw, err, want)
+		}
+	}
+}
+
+func TestMultipleProfiles(t *testing.T) {
+	useProfiles(t, testProfiles)
+
+	if got := ExtractServiceName("reviews-v2-7f9c4d8b6-x2k4p"); got != "reviews-v2" {
+		t.Errorf("ExtractServiceName = %q, want reviews-v2", got)
+	}
+	if want := map[string]float64{"productpage": 40, "reviews-v2": 40, "ratings": 40}; !reflect.DeepEqual(ServiceQPSThresholds(), want) {
+		t.Errorf("ServiceQPSThresholds = %v, want %v", ServiceQPSThresholds(), want)
+	}
+
+	// Experiment defaults come from the profiles matching the listed pods
+	defaults := func(endpoints map[string]Endpoint) []string {
+		dg := &DependencyGraph{endpoints: endpoints}
+		dg.loadExperimentDefaults()
+		names := make([]string, 0, len(dg.groups))
+		for _, g := range dg.groups {
+			names = append(names, g.Name)
+		}
+		return names
+	}
+	books := Endpoint{Namespace: "books", Labels: labels.Set{"bench": "bookinfo"}}
+	if got := defaults(map[string]Endpoint{"productpage": books}); !reflect.DeepEqual(got, []string{"review-flow"}) {
+		t.Errorf("bookinfo groups = %v", got)
+	}
+	unlabelled := Endpoint{Namespace: "books"}
+	shop := Endpoint{Namespace: "shop"}
+	if got := defaults(map[string]Endpoint{"productpage": unlabelled, "frontend": shop}); !reflect.DeepEqual(got, []string{"checkout-flow", "product-browsing"}) {
+		t.Errorf("online-boutique groups = %v", got)
+	}
+	if got := defaults(nil); len(got) != 3 {
+		t.Errorf("groups without matching pods = %v, want every profile's", got)
+	}
+}
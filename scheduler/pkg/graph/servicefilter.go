@@ -0,0 +1,96 @@
+/*
+Service Filter
+==============
+Restricts which services may ever become gang members. With an allowlist
+only the listed services can join a coordination group; the denylist
+(default loadgenerator) removes services whatever the annotations or
+experiment defaults say, so test infrastructure annotated into a group is
+never pulled into a gang:
+
+  GANG_SERVICE_ALLOWLIST=checkoutservice,cartservice,paymentservice
+  GANG_SERVICE_DENYLIST=loadgenerator
+
+A filtered service is neither a group root nor followed as a depends-on
+target, so the dependencies reachable only through it stay out too. The
+gang manager applies the same filter at formation time, covering groups
+restored after a restart or merged by a formation strategy.
+*/
+
+package graph
+
+import "k8s.io/klog/v2"
+
+// ServiceFilter decides which services may become gang members
+type ServiceFilter struct {
+	allow map[string]bool // nil = every service not denied
+	deny  map[string]bool
+}
+
+// NewServiceFilter builds a filter from an allowlist (empty = allow all)
+// and a denylist; the denylist wins
+func NewServiceFilter(allow, deny []string) ServiceFilter {
+	var f ServiceFilter
+	if len(allow) > 0 {
+		f.allow = make(map[string]bool, len(allow))
+		for _, svc := range allow {
+			f.allow[svc] = true
+		}
+	}
+	if len(deny) > 0 {
+		f.deny = make(map[string]bool, len(deny))
+		for _, svc := range deny {
+			f.deny[svc] = true
+		}
+	}
+	return f
+}
+
+// Permits reports whether a service may become a gang member
+func (f ServiceFilter) Permits(service string) bool {
+	if f.deny[service] {
+		return false
+	}
+	return f.allow == nil || f.allow[service]
+}
+
+// Apply removes the services the filter does not permit from groups,
+// dropping groups left empty; it returns the kept groups and the number of
+// services removed
+func (f ServiceFilter) Apply(groups []RuntimeGroup) ([]RuntimeGroup, int) {
+	if f.allow == nil && f.deny == nil {
+		return groups, 0
+	}
+	kept := make([]RuntimeGroup, 0, len(groups))
+	removed := 0
+	for _, group := range groups {
+		services := make([]string, 0, len(group.Services))
+		for _, svc := range group.Services {
+			if f.Permits(svc) {
+				services = append(services, svc)
+			} else {
+				removed++
+			}
+		}
+		if len(services) == 0 {
+			klog.V(2).Infof("Coordination group '%s' has no permitted services, dropping it", group.Name)
+			continue
+		}
+		if len(services) < len(group.Services) {
+			group.Services = services
+			group.Missing = permitted(f, group.Missing)
+		}
+		kept = append(kept, group)
+	}
+	return kept, removed
+}
+
+// permitted returns the services of list the filter permits
+func permitted(f ServiceFilter, list []string) []string {
+	var out []string
+	for _, svc := range list {
+		if f.Permits(svc) {
+			out = append(out, svc)
+		}
+	}
+	return out
+}
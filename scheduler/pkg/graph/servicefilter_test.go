@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceFilterAtGraphBuild(t *testing.T) {
+	build := func(filter ServiceFilter) map[string][]string {
+		dg := &DependencyGraph{edges: map[string]map[string]bool{}, sloTargets: map[string]float64{}, maxDepth: 2, filter: filter}
+		groupMap := make(map[string]map[string]bool)
+		for name, annotations := range map[string]map[string]string{
+			"loadgenerator-5d8f7c6b9-aaaaa": {AnnotationServiceGroup: "product-browsing", AnnotationDependsOn: "frontend"},
+			"frontend-6c7d8e9f0-bbbbb":      {AnnotationDependsOn: "productcatalogservice"},
+			"cartservice-7d9f8c6b5-ccccc":   {AnnotationServiceGroup: "checkout-flow", AnnotationDependsOn: "redis-cart"},
+		} {
+			dg.addPod(groupMap, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}})
+		}
+		dg.setGroups(groupMap, nil)
+
+		groups := make(map[string][]string)
+		for _, g := range dg.groups {
+			groups[g.Name] = sortedCopy(g.Services)
+		}
+		return groups
+	}
+
+	// Without a filter loadgenerator pulls frontend and the catalog in
+	if got := build(ServiceFilter{})["product-browsing"]; len(got) != 3 {
+		t.Fatalf("unfiltered product-browsing = %v, want loadgenerator and its dependencies", got)
+	}
+
+	// Denied: no root, no traversal through it, the group disappears
+	got := build(NewServiceFilter(nil, []string{"loadgenerator"}))
+	want := map[string][]string{"checkout-flow": {"cartservice", "redis-cart"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("denylist groups = %v, want %v", got, want)
+	}
+
+	// Allowlist: dependencies outside it are not followed
+	got = build(NewServiceFilter([]string{"cartservice", "frontend", "loadgenerator"}, []string{"loadgenerator"}))
+	want = map[string][]string{"checkout-flow": {"cartservice"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("allowlist groups = %v, want %v", got, want)
+	}
+}
+
+func TestServiceFilterApply(t *testing.T) {
+	filter := NewServiceFilter(nil, []string{"loadgenerator", "redis-cart"})
+	groups, removed := filter.Apply([]RuntimeGroup{
+		{Name: "checkout-flow", Services: []string{"cartservice", "redis-cart"}, Missing: []string{"redis-cart"}},
+		{Name: "load", Services: []string{"loadgenerator"}},
+	})
+	if removed != 2 || len(groups) != 1 {
+		t.Fatalf("Apply kept %+v and removed %d, want checkout-flow only and 2 removed", groups, removed)
+	}
+	if g := groups[0]; !reflect.DeepEqual(g.Services, []string{"cartservice"}) || len(g.Missing) != 0 {
+		t.Errorf("checkout-flow = %+v, want cartservice without missing members", g)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Annotation Validation
+=====================
+The dependency graph is only built at spike time, and it tolerates bad
+annotations: an empty group is ignored, an unknown dependency becomes a
+Missing member, a cycle ends the closure walk and an unparsable SLO is
+dropped. ValidateAnnotations finds those mistakes up front, for the
+validating admission webhook, from one service's pod template
+annotations and the depends-on edges of the services already deployed:
+
+  nexus.io/service-group   set but empty
+  nexus.io/depends-on      empty entries, the service itself, services
+                           neither deployed nor in the application
+                           profile, and edges closing a cycle
+  nexus.io/slo-p95-ms      not a positive number
+*/
+
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateAnnotations returns the problems of a service's nexus.io
+// annotations (none = valid). known holds the deployed services and edges
+// their depends-on lists; service's own entry in edges is ignored.
+func ValidateAnnotations(service string, annotations map[string]string, known map[string]bool, edges map[string][]string) []string {
+	var problems []string
+
+	if group, ok := annotations[AnnotationServiceGroup]; ok && strings.TrimSpace(group) == "" {
+		problems = append(problems, fmt.Sprintf("%s is empty", AnnotationServiceGroup))
+	}
+
+	if raw, ok := annotations[AnnotationSLOP95]; ok {
+		if target, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err != nil || target <= 0 {
+			problems = append(problems, fmt.Sprintf("%s=%q is not a positive number of milliseconds", AnnotationSLOP95, raw))
+		}
+	}
+
+	raw, ok := annotations[AnnotationDependsOn]
+	if !ok {
+		return problems
+	}
+	var deps []string
+	for _, dep := range strings.Split(raw, ",") {
+		dep = strings.TrimSpace(dep)
+		switch {
+		case dep == "":
+			problems = append(problems, fmt.Sprintf("%s=%q has an empty entry", AnnotationDependsOn, raw))
+		case dep == service:
+			problems = append(problems, fmt.Sprintf("%s lists %s itself", AnnotationDependsOn, service))
+		case !known[dep] && !IsKnownService(dep):
+			problems = append(problems, fmt.Sprintf("%s lists unknown service %q", AnnotationDependsOn, dep))
+		default:
+			deps = append(deps, dep)
+		}
+	}
+	if cycle := dependencyCycle(service, deps, edges); cycle != nil {
+		problems = append(problems, fmt.Sprintf("%s forms a cycle: %s", AnnotationDependsOn, strings.Join(cycle, " → ")))
+	}
+	return problems
+}
+
+// dependencyCycle returns a depends-on path from service back to itself
+// through deps and the other services' edges (nil = acyclic)
+func dependencyCycle(service string, deps []string, edges map[string][]string) []string {
+	visited := map[string]bool{service: true}
+	var walk func(path []string, next []string) []string
+	walk = func(path []string, next []string) []string {
+		next = append([]string(nil), next...)
+		sort.Strings(next) // report the same cycle every time
+		for _, dep := range next {
+			if dep == service {
+				return append(path, dep)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if cycle := walk(append(path, dep), edges[dep]); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return walk([]string{service}, deps)
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateAnnotations(t *testing.T) {
+	known := map[string]bool{"ledgerservice": true}
+	edges := map[string][]string{
+		"paymentservice":  {"ledgerservice"},
+		"ledgerservice":   {"checkoutservice"},
+		"checkoutservice": {"cartservice"}, // replaced by the validated annotations
+	}
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        []string
+	}{
+		{"valid", map[string]string{
+			AnnotationServiceGroup: "checkout-flow",
+			AnnotationDependsOn:    "cartservice, currencyservice",
+			AnnotationSLOP95:       "300",
+		}, nil},
+		{"no annotations", nil, nil},
+		{"empty group", map[string]string{AnnotationServiceGroup: " "}, []string{
+			"nexus.io/service-group is empty",
+		}},
+		{"bad SLO", map[string]string{AnnotationSLOP95: "fast"}, []string{
+			`nexus.io/slo-p95-ms="fast" is not a positive number of milliseconds`,
+		}},
+		{"bad entries", map[string]string{AnnotationDependsOn: "cartservice,,checkoutservice,cartsevice"}, []string{
+			`nexus.io/depends-on="cartservice,,checkoutservice,cartsevice" has an empty entry`,
+			"nexus.io/depends-on lists checkoutservice itself",
+			`nexus.io/depends-on lists unknown service "cartsevice"`,
+		}},
+		{"cycle", map[string]string{AnnotationDependsOn: "paymentservice"}, []string{
+			"nexus.io/depends-on forms a cycle: checkoutservice → paymentservice → ledgerservice → checkoutservice",
+		}},
+	}
+	for _, tc := range cases {
+		got := ValidateAnnotations("checkoutservice", tc.annotations, known, edges)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: problems = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
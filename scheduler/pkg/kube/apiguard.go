@@ -0,0 +1,228 @@
+/*
+Kubernetes API Guard
+====================
+Wraps every Kubernetes API call made on the scheduling path with
+exponential backoff and a circuit breaker, so that a NEXUS bug or a
+huge spike can never hammer the API server.
+
+Supports the "low control-plane overhead" claim:
+  - Client-side QPS/Burst limits (configured on the rest.Config)
+  - Retriable errors (429, 5xx, timeouts) are retried with backoff;
+    429s are counted separately so throttling is visible (IsThrottled)
+  - After N consecutive failures the breaker OPENS and calls fail
+    fast until the cooldown elapses; one probe call is then allowed
+    (HALF_OPEN) and its outcome closes or re-opens the breaker
+  - A call abandoned by its caller (cancelled or past its deadline)
+    counts neither way, so a short SPIKE_CHECK_TIMEOUT cannot open the
+    breaker against a healthy API server
+*/
+
+package kube
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// ErrCircuitOpen is returned when the breaker rejects a call without trying it
+var ErrCircuitOpen = errors.New("kubernetes API circuit breaker is open")
+
+// BreakerState represents the circuit breaker state
+type BreakerState int
+
+// Breaker states: CLOSED (calls allowed), OPEN (fail fast), HALF_OPEN (one probe)
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+// String returns the breaker state name used in logs, metrics and /status
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "CLOSED"
+	case BreakerOpen:
+		return "OPEN"
+	case BreakerHalfOpen:
+		return "HALF_OPEN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// APIGuard applies backoff and circuit breaking to Kubernetes API calls
+type APIGuard struct {
+	mu                  sync.Mutex
+	state               BreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	probeInFlight       bool
+
+	failureThreshold int
+	cooldown         time.Duration
+	backoff          wait.Backoff
+	metrics          *metrics.NEXUSMetrics
+}
+
+// NewAPIGuard creates an API guard from the resolved configuration
+func NewAPIGuard(cfg *config.Config, metrics *metrics.NEXUSMetrics) *APIGuard {
+	return &APIGuard{
+		state:            BreakerClosed,
+		failureThreshold: cfg.APIBreakerThreshold,
+		cooldown:         cfg.APIBreakerCooldown,
+		backoff: wait.Backoff{
+			Duration: cfg.APIRetryInitialBackoff,
+			Factor:   2.0,
+			Jitter:   0.1,
+			Steps:    cfg.APIRetrySteps,
+			Cap:      cfg.APIRetryMaxBackoff,
+		},
+		metrics: metrics,
+	}
+}
+
+// Do runs fn, retrying retriable API errors with exponential backoff.
+// The name is used for logging only.
+func (g *APIGuard) Do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	if !g.allow() {
+		g.metrics.IncrementCounter("api_circuit_rejected")
+		return ErrCircuitOpen
+	}
+
+	backoff := g.backoff
+	for {
+		err := fn(ctx)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up (cancellation, SPIKE_CHECK_TIMEOUT): the
+			// call says nothing about the API server either way
+			g.releaseProbe()
+			return ctx.Err()
+		}
+		if err == nil || !isRetriableAPIError(err) {
+			// A non-retriable error (NotFound, Forbidden, ...) still means
+			// the API server answered — it says nothing about its health
+			g.recordSuccess()
+			return err
+		}
+
+		if apierrors.IsTooManyRequests(err) {
+			g.metrics.IncrementCounter("api_throttled")
+		}
+		if backoff.Steps <= 1 {
+			klog.Warningf("Kubernetes API call %s failed after retries: %v", name, err)
+			g.recordFailure()
+			return err
+		}
+
+		delay := backoff.Step()
+		g.metrics.IncrementCounter("api_retries")
+		klog.V(2).Infof("Kubernetes API call %s failed (%v), retrying in %v", name, err, delay)
+
+		select {
+		case <-ctx.Done():
+			g.releaseProbe()
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// State returns the current breaker state (thread-safe)
+func (g *APIGuard) State() BreakerState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.state
+}
+
+// allow reports whether a call may proceed, moving OPEN → HALF_OPEN after cooldown
+func (g *APIGuard) allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.state {
+	case BreakerOpen:
+		if time.Since(g.openedAt) < g.cooldown {
+			return false
+		}
+		g.setStateLocked(BreakerHalfOpen)
+		g.probeInFlight = true
+		return true
+	case BreakerHalfOpen:
+		// Only a single probe call is allowed while half-open
+		if g.probeInFlight {
+			return false
+		}
+		g.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordSuccess resets the failure count and closes the breaker
+func (g *APIGuard) recordSuccess() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.consecutiveFailures = 0
+	g.probeInFlight = false
+	g.setStateLocked(BreakerClosed)
+}
+
+// releaseProbe ends a call that neither succeeded nor failed, letting the
+// next call probe a HALF_OPEN breaker
+func (g *APIGuard) releaseProbe() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.probeInFlight = false
+}
+
+// recordFailure counts a failure and opens the breaker when the threshold is hit
+func (g *APIGuard) recordFailure() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.consecutiveFailures++
+	g.probeInFlight = false
+
+	if g.state == BreakerHalfOpen || g.consecutiveFailures >= g.failureThreshold {
+		if g.state != BreakerOpen {
+			g.metrics.IncrementCounter("api_circuit_opened")
+		}
+		g.openedAt = time.Now()
+		g.setStateLocked(BreakerOpen)
+	}
+}
+
+// setStateLocked transitions the breaker (must hold lock)
+func (g *APIGuard) setStateLocked(state BreakerState) {
+	if g.state != state {
+		klog.Infof("Kubernetes API circuit breaker: %s → %s", g.state, state)
+		g.state = state
+		g.metrics.SetAPIBreakerState(int(state))
+	}
+}
+
+// IsThrottled reports whether a guarded call failed because the API server
+// is shedding load: 429 Too Many Requests, or the breaker it opened
+func IsThrottled(err error) bool {
+	return apierrors.IsTooManyRequests(err) || errors.Is(err, ErrCircuitOpen)
+}
+
+// isRetriableAPIError reports whether an error indicates API server pressure
+func isRetriableAPIError(err error) bool {
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// newTestGuard returns a guard opening after one failure, with short backoffs
+func newTestGuard(cooldown time.Duration) *APIGuard {
+	cfg := config.LoadConfig()
+	cfg.APIBreakerThreshold = 1
+	cfg.APIBreakerCooldown = cooldown
+	cfg.APIRetrySteps = 3
+	cfg.APIRetryInitialBackoff = 5 * time.Millisecond
+	return NewAPIGuard(cfg, metrics.NewNEXUSMetrics())
+}
+
+func TestAPIGuardIgnoresCallerDeadline(t *testing.T) {
+	g := newTestGuard(time.Minute)
+
+	// The caller's deadline expires while the call retries
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err := g.Do(ctx, "slow call", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do = %v, want the caller's deadline", err)
+	}
+	if g.State() != BreakerClosed {
+		t.Errorf("breaker %s after the caller's deadline, want CLOSED", g.State())
+	}
+
+	// The deadline expires during the backoff after a retriable error
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err = g.Do(ctx, "throttled call", func(context.Context) error {
+		return apierrors.NewTooManyRequests("slow down", 1)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || g.State() != BreakerClosed {
+		t.Errorf("Do = %v, breaker %s; want the caller's deadline and CLOSED", err, g.State())
+	}
+
+	// A deadline of the call itself still counts against the API server
+	g.Do(context.Background(), "timed out call", func(context.Context) error {
+		return context.DeadlineExceeded
+	})
+	if g.State() != BreakerOpen {
+		t.Errorf("breaker %s after failed retries, want OPEN", g.State())
+	}
+}
+
+func TestAPIGuardCancelledProbe(t *testing.T) {
+	g := newTestGuard(time.Millisecond)
+	g.Do(context.Background(), "failing call", func(context.Context) error {
+		return apierrors.NewServiceUnavailable("down")
+	})
+	if g.State() != BreakerOpen {
+		t.Fatalf("breaker %s, want OPEN", g.State())
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// The probe's caller cancels before the server answers
+	ctx, cancel := context.WithCancel(context.Background())
+	err := g.Do(ctx, "cancelled probe", func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) || g.State() != BreakerHalfOpen {
+		t.Fatalf("Do = %v, breaker %s; want Canceled and still HALF_OPEN", err, g.State())
+	}
+
+	// The next call may probe, and its answer closes the breaker
+	if err := g.Do(context.Background(), "probe", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("probe after a cancelled probe = %v, want it allowed", err)
+	}
+	if g.State() != BreakerClosed {
+		t.Errorf("breaker %s after a successful probe, want CLOSED", g.State())
+	}
+}
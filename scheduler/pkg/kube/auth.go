@@ -0,0 +1,152 @@
+/*
+API Credential Refresh
+======================
+Multi-day experiment runs outlive their credentials: projected service
+account tokens rotate, and kubeconfig tokens or CA bundles get replaced
+underneath a running process. client-go re-reads a token file, but a
+transport built from credentials that no longer work keeps failing with
+401 Unauthorized until the process restarts.
+
+AuthRefresher sits in the client transport. After KUBE_API_AUTH_REBUILD_AFTER
+consecutive 401s it loads the credentials again (in-cluster config or
+KUBECONFIG) and sends every later request of every client through a
+transport built from them, at most once per authRebuildInterval. The
+credentials count as failing (nexus_api_auth_ok 0, "apiAuth" in /status)
+from that many consecutive 401s until a request is accepted again.
+*/
+
+package kube
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// authRebuildInterval is the minimum time between two transport rebuilds
+const authRebuildInterval = 30 * time.Second
+
+// AuthStatus reports the state of the Kubernetes API credentials
+type AuthStatus struct {
+	OK              bool
+	Unauthorized    int64 // 401 responses
+	Rebuilds        int64 // transports rebuilt from fresh credentials
+	RebuildFailures int64 // credential reloads that failed
+}
+
+// AuthRefresher rebuilds the API client transport from freshly loaded
+// credentials after persistent 401s
+type AuthRefresher struct {
+	load      func() (*rest.Config, error)
+	threshold int // consecutive 401s before a rebuild (0 = never rebuild)
+
+	failures        atomic.Int64 // consecutive 401s
+	unauthorized    atomic.Int64
+	rebuilds        atomic.Int64
+	rebuildFailures atomic.Int64
+
+	mu          sync.Mutex
+	fresh       http.RoundTripper // nil until the first rebuild
+	lastRebuild time.Time
+}
+
+// NewAuthRefresher creates a refresher that reloads credentials with load
+func NewAuthRefresher(load func() (*rest.Config, error), threshold int) *AuthRefresher {
+	return &AuthRefresher{load: load, threshold: threshold}
+}
+
+// Wrap installs the refresher in the transport of clients built from cfg
+func (a *AuthRefresher) Wrap(cfg *rest.Config) {
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &authRoundTripper{refresher: a, base: rt}
+	})
+}
+
+// Status returns the credential state
+func (a *AuthRefresher) Status() AuthStatus {
+	limit := int64(a.threshold)
+	if limit <= 0 {
+		limit = 1 // no rebuilds: any 401 streak is a failure
+	}
+	return AuthStatus{
+		OK:              a.failures.Load() < limit,
+		Unauthorized:    a.unauthorized.Load(),
+		Rebuilds:        a.rebuilds.Load(),
+		RebuildFailures: a.rebuildFailures.Load(),
+	}
+}
+
+// observe records the status code of an API response
+func (a *AuthRefresher) observe(code int) {
+	if code != http.StatusUnauthorized {
+		if a.failures.Load() != 0 {
+			a.failures.Store(0)
+		}
+		return
+	}
+
+	a.unauthorized.Add(1)
+	if n := a.failures.Add(1); a.threshold > 0 && n >= int64(a.threshold) {
+		a.rebuild(n)
+	}
+}
+
+// rebuild reloads the credentials and swaps in a transport built from them
+func (a *AuthRefresher) rebuild(failures int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Since(a.lastRebuild) < authRebuildInterval {
+		return
+	}
+	a.lastRebuild = time.Now()
+
+	cfg, err := a.load()
+	var rt http.RoundTripper
+	if err == nil {
+		rt, err = rest.TransportFor(cfg)
+	}
+	if err != nil {
+		a.rebuildFailures.Add(1)
+		klog.Errorf("Kubernetes API: %d consecutive 401s and reloading credentials failed: %v", failures, err)
+		return
+	}
+
+	a.fresh = rt
+	a.rebuilds.Add(1)
+	klog.Warningf("Kubernetes API: %d consecutive 401s, rebuilt the client transport from reloaded credentials", failures)
+}
+
+// transport returns the rebuilt transport (nil = none yet)
+func (a *AuthRefresher) transport() http.RoundTripper {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.fresh
+}
+
+// authRoundTripper routes requests through the rebuilt transport once there is one
+type authRoundTripper struct {
+	refresher *AuthRefresher
+	base      http.RoundTripper
+}
+
+// RoundTrip sends the request and records its status
+func (t *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.base
+	if fresh := t.refresher.transport(); fresh != nil {
+		// The stale credentials were set by the client's own auth wrapper;
+		// the rebuilt transport sets the fresh ones
+		req = req.Clone(req.Context())
+		req.Header.Del("Authorization")
+		rt = fresh
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err == nil {
+		t.refresher.observe(resp.StatusCode)
+	}
+	return resp, err
+}
@@ -0,0 +1,23 @@
+/*
+Excluded Nodes
+==============
+Operators opt a node out of NEXUS influence (control-plane-adjacent or
+spot nodes, for example) by setting NODE_EXCLUDE_LABEL ("nexus.io/exclude"
+by default) to "true" as a node label or annotation. NEXUS never filters
+such a node, never counts it as a gang member node and never prefers it.
+*/
+
+package kube
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// NodeExcluded reports whether the node carries key="true" as a label or
+// annotation (key "" = exclusion disabled)
+func NodeExcluded(node *v1.Node, key string) bool {
+	if key == "" {
+		return false
+	}
+	return node.Labels[key] == "true" || node.Annotations[key] == "true"
+}
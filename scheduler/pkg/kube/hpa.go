@@ -0,0 +1,70 @@
+/*
+HPA Scale-Down Stabilization
+============================
+After a spike the HorizontalPodAutoscaler keeps the replica count up for
+its scale-down stabilization window and only then removes replicas. Gangs
+dissolved before that happens lose their co-location right while the
+autoscaler is still churning the members' pods.
+
+Reads behavior.scaleDown.stabilizationWindowSeconds of every HPA
+(autoscaling/v2), keyed by scaleTargetRef name, which is taken as the
+service name (Deployment name == service name in Online Boutique). An HPA
+without the field gets the controller default of 5 minutes.
+*/
+
+package kube
+
+import (
+	"context"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultScaleDownStabilization is the HPA controller's scale-down
+// stabilization window when an HPA does not set one
+const DefaultScaleDownStabilization = 5 * time.Minute
+
+// HPAStabilization reads the scale-down stabilization windows of HPAs
+type HPAStabilization struct {
+	client    kubernetes.Interface
+	apiGuard  *APIGuard
+	namespace string // "" = all namespaces
+}
+
+// NewHPAStabilization creates a reader for HPA scale-down stabilization windows
+func NewHPAStabilization(client kubernetes.Interface, apiGuard *APIGuard, namespace string) *HPAStabilization {
+	return &HPAStabilization{client: client, apiGuard: apiGuard, namespace: namespace}
+}
+
+// ScaleDownWindows returns the scale-down stabilization window per scale
+// target name, the longest one when several HPAs share a name
+func (hs *HPAStabilization) ScaleDownWindows(ctx context.Context) (map[string]time.Duration, error) {
+	var list *autoscalingv2.HorizontalPodAutoscalerList
+	err := hs.apiGuard.Do(ctx, "list horizontalpodautoscalers", func(ctx context.Context) error {
+		var err error
+		list, err = hs.client.AutoscalingV2().HorizontalPodAutoscalers(hs.namespace).List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make(map[string]time.Duration, len(list.Items))
+	for _, hpa := range list.Items {
+		target := hpa.Spec.ScaleTargetRef.Name
+		if target == "" {
+			continue
+		}
+		window := DefaultScaleDownStabilization
+		if b := hpa.Spec.Behavior; b != nil && b.ScaleDown != nil && b.ScaleDown.StabilizationWindowSeconds != nil {
+			window = time.Duration(*b.ScaleDown.StabilizationWindowSeconds) * time.Second
+		}
+		if window > windows[target] {
+			windows[target] = window
+		}
+	}
+	return windows, nil
+}
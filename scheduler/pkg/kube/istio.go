@@ -0,0 +1,190 @@
+/*
+Istio Route Lister
+==================
+Reads Istio VirtualServices and DestinationRules (networking.istio.io/v1beta1)
+for the dependency graph's mesh discovery (GRAPH_ISTIO, see
+pkg/graph/mesh.go). Each object is reduced to the services it names:
+
+  Hosts         VirtualService spec.hosts, DestinationRule spec.host
+  SourceLabels  VirtualService spec.{http,tcp,tls}[].match[].sourceLabels
+  Destinations  VirtualService spec.{http,tcp,tls}[].route[].destination.host
+                and spec.http[].mirror.host
+
+Hosts are reduced to their service name ("cartservice",
+"cartservice.shop" and "cartservice.shop.svc.cluster.local" all name
+cartservice); wildcard hosts and hosts outside the cluster domain are
+dropped. Both kinds are listed once per graph build, through the API
+guard like every other read; a cluster without the Istio CRDs installed
+fails the listing.
+*/
+
+package kube
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Istio networking resources read for mesh discovery
+var (
+	virtualServiceGVR = schema.GroupVersionResource{
+		Group:    "networking.istio.io",
+		Version:  "v1beta1",
+		Resource: "virtualservices",
+	}
+	destinationRuleGVR = schema.GroupVersionResource{
+		Group:    "networking.istio.io",
+		Version:  "v1beta1",
+		Resource: "destinationrules",
+	}
+)
+
+// MeshRoute is the service topology one Istio object declares
+type MeshRoute struct {
+	Kind         string // VirtualService or DestinationRule
+	Namespace    string
+	Name         string
+	Annotations  map[string]string
+	Hosts        []string            // services the object applies to
+	SourceLabels []map[string]string // caller selectors of its routes
+	Destinations []string            // services its routes send traffic to
+}
+
+// IstioRouteLister lists VirtualServices and DestinationRules
+type IstioRouteLister struct {
+	client   dynamic.Interface
+	apiGuard *APIGuard
+}
+
+// NewIstioRouteLister creates an Istio route lister
+func NewIstioRouteLister(client dynamic.Interface, apiGuard *APIGuard) *IstioRouteLister {
+	return &IstioRouteLister{client: client, apiGuard: apiGuard}
+}
+
+// Routes returns every VirtualService and DestinationRule in the cluster
+func (l *IstioRouteLister) Routes(ctx context.Context) ([]MeshRoute, error) {
+	virtualServices, err := l.list(ctx, "list istio virtualservices", virtualServiceGVR)
+	if err != nil {
+		return nil, err
+	}
+	destinationRules, err := l.list(ctx, "list istio destinationrules", destinationRuleGVR)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]MeshRoute, 0, len(virtualServices)+len(destinationRules))
+	for i := range virtualServices {
+		routes = append(routes, parseVirtualService(&virtualServices[i]))
+	}
+	for i := range destinationRules {
+		routes = append(routes, parseDestinationRule(&destinationRules[i]))
+	}
+	return routes, nil
+}
+
+// list returns every object of the resource across all namespaces
+func (l *IstioRouteLister) list(ctx context.Context, op string, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	var list *unstructured.UnstructuredList
+	err := l.apiGuard.Do(ctx, op, func(ctx context.Context) error {
+		var err error
+		list, err = l.client.Resource(gvr).Namespace("").List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// parseVirtualService reads the hosts, caller selectors and route
+// destinations of a VirtualService
+func parseVirtualService(obj *unstructured.Unstructured) MeshRoute {
+	route := meshRoute("VirtualService", obj)
+	hosts, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hosts")
+	route.Hosts = serviceHosts(hosts)
+
+	var destinations []string
+	for _, kind := range []string{"http", "tcp", "tls"} {
+		rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", kind)
+		for _, r := range rules {
+			rule, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			matches, _, _ := unstructured.NestedSlice(rule, "match")
+			for _, m := range matches {
+				match, ok := m.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if selector, found, _ := unstructured.NestedStringMap(match, "sourceLabels"); found && len(selector) > 0 {
+					route.SourceLabels = append(route.SourceLabels, selector)
+				}
+			}
+			targets, _, _ := unstructured.NestedSlice(rule, "route")
+			for _, t := range targets {
+				if target, ok := t.(map[string]interface{}); ok {
+					host, _, _ := unstructured.NestedString(target, "destination", "host")
+					destinations = append(destinations, host)
+				}
+			}
+			if mirror, found, _ := unstructured.NestedString(rule, "mirror", "host"); found {
+				destinations = append(destinations, mirror)
+			}
+		}
+	}
+	route.Destinations = serviceHosts(destinations)
+	return route
+}
+
+// parseDestinationRule reads the host of a DestinationRule
+func parseDestinationRule(obj *unstructured.Unstructured) MeshRoute {
+	route := meshRoute("DestinationRule", obj)
+	host, _, _ := unstructured.NestedString(obj.Object, "spec", "host")
+	route.Hosts = serviceHosts([]string{host})
+	return route
+}
+
+// meshRoute returns the identity of an Istio object
+func meshRoute(kind string, obj *unstructured.Unstructured) MeshRoute {
+	return MeshRoute{
+		Kind:        kind,
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+		Annotations: obj.GetAnnotations(),
+	}
+}
+
+// serviceHosts returns the sorted, distinct service names of the hosts
+func serviceHosts(hosts []string) []string {
+	seen := make(map[string]bool, len(hosts))
+	services := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if svc := serviceHost(host); svc != "" && !seen[svc] {
+			seen[svc] = true
+			services = append(services, svc)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// serviceHost returns the service an Istio host names ("" for wildcard
+// and external hosts): "svc", "svc.ns" and "svc.ns.svc[.<domain>]"
+func serviceHost(host string) string {
+	host = strings.TrimSpace(host)
+	if host == "" || strings.Contains(host, "*") {
+		return ""
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) <= 2 || parts[2] == "svc" {
+		return parts[0]
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+/*
+Ephemeral State Budget
+======================
+Guards the memory footprint of the in-memory graph and gangs on very
+large clusters. Pod List calls are paginated and stop once the
+configured object budget is reached, so a spike on a 10k-pod cluster
+can never make NEXUS allocate an unbounded PodList.
+
+Limits (see Config):
+  MAX_PODS_CONSIDERED → pods read per graph build / node count
+  MAX_GANGS           → gangs formed per spike episode
+  MAX_NODES_SCANNED   → nodes evaluated per Filter/Prioritize call
+  LIST_PAGE_SIZE      → page size for paginated List calls
+*/
+
+package kube
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// PodLister lists pods page by page within a fixed object budget
+type PodLister struct {
+	clientset kubernetes.Interface
+	apiGuard  *APIGuard
+	metrics   *metrics.NEXUSMetrics
+	pageSize  int64
+	maxPods   int
+}
+
+// NewPodLister creates a budgeted, paginated pod lister
+func NewPodLister(clientset kubernetes.Interface, apiGuard *APIGuard, metrics *metrics.NEXUSMetrics, cfg *config.Config) *PodLister {
+	return &PodLister{
+		clientset: clientset,
+		apiGuard:  apiGuard,
+		metrics:   metrics,
+		pageSize:  int64(cfg.ListPageSize),
+		maxPods:   cfg.MaxPodsConsidered,
+	}
+}
+
+// List returns pods matching opts, reading at most maxPods objects.
+// Each page goes through the API guard. truncated is true when the
+// budget was exhausted before the server ran out of results.
+func (pl *PodLister) List(ctx context.Context, name string, opts metav1.ListOptions) (pods []v1.Pod, truncated bool, err error) {
+	pods, _, truncated, err = pl.ListVersioned(ctx, name, opts)
+	return pods, truncated, err
+}
+
+// ListVersioned is List that also returns the resource version of the
+// list, to start a watch from
+func (pl *PodLister) ListVersioned(ctx context.Context, name string, opts metav1.ListOptions) (pods []v1.Pod, resourceVersion string, truncated bool, err error) {
+	opts.Limit = pl.pageSize
+	opts.Continue = ""
+
+	for {
+		var page *v1.PodList
+		err := pl.apiGuard.Do(ctx, name, func(ctx context.Context) error {
+			var err error
+			page, err = pl.clientset.CoreV1().Pods("").List(ctx, opts)
+			return err
+		})
+		if err != nil {
+			return pods, resourceVersion, truncated, err
+		}
+		if resourceVersion == "" {
+			resourceVersion = page.ResourceVersion // continued pages share the first page's snapshot
+		}
+
+		for i := range page.Items {
+			if pl.maxPods > 0 && len(pods) >= pl.maxPods {
+				pl.metrics.IncrementCounter("budget_truncations")
+				return pods, resourceVersion, true, nil
+			}
+			pods = append(pods, page.Items[i])
+		}
+
+		if page.Continue == "" {
+			return pods, resourceVersion, false, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// CapNodes returns at most max nodes (0 = unlimited) and whether nodes were dropped
+func CapNodes(nodes []v1.Node, max int) ([]v1.Node, bool) {
+	if max <= 0 || len(nodes) <= max {
+		return nodes, false
+	}
+	return nodes[:max], true
+}
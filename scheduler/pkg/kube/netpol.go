@@ -0,0 +1,59 @@
+/*
+NetworkPolicy Lister
+====================
+Reads the cluster's NetworkPolicies and namespace labels for the
+dependency graph's network path check (GRAPH_NETWORK_POLICY, see
+pkg/graph/netpol.go). Both are listed once per graph build, through the
+API guard like every other read.
+*/
+
+package kube
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NetworkPolicyLister lists NetworkPolicies and namespace labels
+type NetworkPolicyLister struct {
+	clientset kubernetes.Interface
+	apiGuard  *APIGuard
+}
+
+// NewNetworkPolicyLister creates a NetworkPolicy lister
+func NewNetworkPolicyLister(clientset kubernetes.Interface, apiGuard *APIGuard) *NetworkPolicyLister {
+	return &NetworkPolicyLister{clientset: clientset, apiGuard: apiGuard}
+}
+
+// List returns every NetworkPolicy and the labels of every namespace
+func (l *NetworkPolicyLister) List(ctx context.Context) ([]networkingv1.NetworkPolicy, map[string]labels.Set, error) {
+	var policies *networkingv1.NetworkPolicyList
+	err := l.apiGuard.Do(ctx, "list network policies", func(ctx context.Context) error {
+		var err error
+		policies, err = l.clientset.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	namespaceLabels := make(map[string]labels.Set)
+	err = l.apiGuard.Do(ctx, "list namespaces", func(ctx context.Context) error {
+		namespaces, err := l.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, ns := range namespaces.Items {
+			namespaceLabels[ns.Name] = labels.Set(ns.Labels)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return policies.Items, namespaceLabels, nil
+}
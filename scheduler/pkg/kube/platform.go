@@ -0,0 +1,107 @@
+/*
+Node Platforms
+==============
+Mixed clusters run linux/amd64, linux/arm64 and windows nodes side by
+side; a gang pod whose images only exist for one platform must never be
+steered onto another. A pod's platform constraints come from:
+
+  spec.os.name                              → os
+  nodeSelector kubernetes.io/{os,arch}      → os / arch
+  required node affinity on the same keys   → os / arch (In / NotIn)
+  annotation nexus.io/platforms             → image platforms, e.g.
+                                              "linux/amd64,linux/arm64"
+
+and are compared with the node's kubernetes.io/os and kubernetes.io/arch
+labels. A node without a label is not rejected on that key, and a pod
+without constraints runs anywhere.
+*/
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AnnotationPlatforms lists the os/arch pairs a pod's images are built for
+const AnnotationPlatforms = "nexus.io/platforms"
+
+// PlatformMismatch returns why the node's os/arch cannot run the pod, or ""
+// when it can
+func PlatformMismatch(pod *v1.Pod, node *v1.Node) string {
+	nodeOS, nodeArch := node.Labels[v1.LabelOSStable], node.Labels[v1.LabelArchStable]
+
+	if pod.Spec.OS != nil && nodeOS != "" && string(pod.Spec.OS.Name) != nodeOS {
+		return fmt.Sprintf("pod requires os %s, node is %s", pod.Spec.OS.Name, nodeOS)
+	}
+	for _, key := range []string{v1.LabelOSStable, v1.LabelArchStable} {
+		if want, ok := pod.Spec.NodeSelector[key]; ok && node.Labels[key] != "" && node.Labels[key] != want {
+			return fmt.Sprintf("pod selects %s=%s, node is %s", key, want, node.Labels[key])
+		}
+	}
+	if !platformAffinityMatches(pod, node) {
+		return fmt.Sprintf("required node affinity excludes %s/%s", nodeOS, nodeArch)
+	}
+
+	if platforms := strings.TrimSpace(pod.Annotations[AnnotationPlatforms]); platforms != "" {
+		for _, platform := range strings.Split(platforms, ",") {
+			imageOS, arch, _ := strings.Cut(strings.TrimSpace(platform), "/")
+			if (nodeOS == "" || imageOS == nodeOS) && (nodeArch == "" || arch == "" || arch == nodeArch) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("images are built for %s, node is %s/%s", platforms, nodeOS, nodeArch)
+	}
+	return ""
+}
+
+// platformAffinityMatches evaluates the os/arch expressions of the pod's
+// required node affinity: terms are ORed, expressions within a term ANDed,
+// and expressions on other keys are left to kube-scheduler
+func platformAffinityMatches(pod *v1.Pod, node *v1.Node) bool {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil ||
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		return true
+	}
+	for _, term := range terms {
+		if platformTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// platformTermMatches reports whether every os/arch expression of a term
+// holds for the node
+func platformTermMatches(term v1.NodeSelectorTerm, node *v1.Node) bool {
+	for _, expr := range term.MatchExpressions {
+		if expr.Key != v1.LabelOSStable && expr.Key != v1.LabelArchStable {
+			continue
+		}
+		value, ok := node.Labels[expr.Key]
+		if !ok {
+			continue
+		}
+		in := false
+		for _, v := range expr.Values {
+			in = in || v == value
+		}
+		switch expr.Operator {
+		case v1.NodeSelectorOpIn:
+			if !in {
+				return false
+			}
+		case v1.NodeSelectorOpNotIn:
+			if in {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+/*
+Node Schedulability
+===================
+The node-level checks kube-scheduler's own plugins apply before NEXUS is
+asked, repeated so that Filter never keeps and Prioritize never prefers a
+node the pod cannot land on (kube-scheduler's node snapshot can lag a
+cordon or a new taint by a scheduling cycle):
+
+  cordoned   spec.unschedulable or the node.kubernetes.io/unschedulable
+             taint, unless the pod tolerates that taint
+  not-ready  Ready condition missing or not True
+  pressure   MemoryPressure, DiskPressure or PIDPressure True, unless the
+             pod tolerates the matching node.kubernetes.io/*-pressure taint
+             (set by the node lifecycle controller shortly after)
+  taint      a NoSchedule or NoExecute taint the pod does not tolerate
+
+PreferNoSchedule taints are preferences, not restrictions, and pass.
+*/
+
+package kube
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Reasons a node cannot take a pod
+const (
+	NodeCordoned         = "cordoned"
+	NodeNotReady         = "not-ready"
+	NodeUnderPressure    = "pressure"
+	NodeUntoleratedTaint = "taint"
+)
+
+// pressureTaints maps each pressure condition to the taint it turns into
+var pressureTaints = []struct {
+	condition v1.NodeConditionType
+	taint     string
+}{
+	{v1.NodeMemoryPressure, v1.TaintNodeMemoryPressure},
+	{v1.NodeDiskPressure, v1.TaintNodeDiskPressure},
+	{v1.NodePIDPressure, v1.TaintNodePIDPressure},
+}
+
+// Unschedulable returns why the pod cannot be placed on the node and a
+// detail message, or "" when it can
+func Unschedulable(pod *v1.Pod, node *v1.Node) (string, string) {
+	cordonTaint := &v1.Taint{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}
+	if node.Spec.Unschedulable && !tolerates(pod, cordonTaint) {
+		return NodeCordoned, "node is cordoned"
+	}
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].Key == v1.TaintNodeUnschedulable && !tolerates(pod, &node.Spec.Taints[i]) {
+			return NodeCordoned, "node is cordoned"
+		}
+	}
+
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			ready = condition.Status == v1.ConditionTrue
+		}
+	}
+	if !ready {
+		return NodeNotReady, "node Ready condition is not True"
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		for _, p := range pressureTaints {
+			if condition.Type == p.condition && !tolerates(pod, &v1.Taint{Key: p.taint, Effect: v1.TaintEffectNoSchedule}) {
+				return NodeUnderPressure, fmt.Sprintf("node has %s", condition.Type)
+			}
+		}
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule || taint.Key == v1.TaintNodeUnschedulable {
+			continue
+		}
+		if !tolerates(pod, taint) {
+			return NodeUntoleratedTaint, fmt.Sprintf("taint %s not tolerated", taint.ToString())
+		}
+	}
+	return "", ""
+}
+
+// tolerates reports whether one of the pod's tolerations tolerates taint
+func tolerates(pod *v1.Pod, taint *v1.Taint) bool {
+	for i := range pod.Spec.Tolerations {
+		if pod.Spec.Tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+/*
+Node Utilization Provider
+=========================
+Reads actual node CPU/memory usage from metrics-server
+(metrics.k8s.io/v1beta1) so the scorer can penalize nodes that are
+nominally allocatable but practically saturated during a spike.
+
+Usage is cached for UTILIZATION_CACHE_TTL and refreshed lazily on the
+first scoring call after expiry, so at most one metrics API request
+is made per TTL regardless of Prioritize call volume.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// nodeMetricsPath is the metrics-server endpoint for node usage
+const nodeMetricsPath = "/apis/metrics.k8s.io/v1beta1/nodes"
+
+// nodeMetricsList mirrors the subset of metrics.k8s.io NodeMetricsList we need
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Usage map[string]resource.Quantity `json:"usage"`
+	} `json:"items"`
+}
+
+// NodeUsage is the observed resource usage of a node
+type NodeUsage struct {
+	CPUMillis   int64
+	MemoryBytes int64
+}
+
+// UtilizationProvider caches node usage from metrics-server
+type UtilizationProvider struct {
+	client   rest.Interface
+	apiGuard *APIGuard
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	usage     map[string]NodeUsage
+	fetchedAt time.Time
+}
+
+// NewUtilizationProvider creates a metrics-server backed usage provider
+func NewUtilizationProvider(client rest.Interface, apiGuard *APIGuard, ttl time.Duration) *UtilizationProvider {
+	return &UtilizationProvider{
+		client:   client,
+		apiGuard: apiGuard,
+		ttl:      ttl,
+		usage:    make(map[string]NodeUsage),
+	}
+}
+
+// Usage returns the cached usage for a node, refreshing the cache if stale
+func (up *UtilizationProvider) Usage(ctx context.Context, nodeName string) (NodeUsage, bool) {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	if time.Since(up.fetchedAt) > up.ttl {
+		if err := up.refreshLocked(ctx); err != nil {
+			klog.Warningf("Failed to refresh node utilization (using last known values): %v", err)
+		}
+		// Even on failure, wait a full TTL before retrying
+		up.fetchedAt = time.Now()
+	}
+
+	usage, ok := up.usage[nodeName]
+	return usage, ok
+}
+
+// Refresh re-reads node usage now, restarting the TTL (activation pre-warm)
+func (up *UtilizationProvider) Refresh(ctx context.Context) error {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	err := up.refreshLocked(ctx)
+	up.fetchedAt = time.Now()
+	return err
+}
+
+// refreshLocked fetches node usage from metrics-server (must hold lock)
+func (up *UtilizationProvider) refreshLocked(ctx context.Context) error {
+	var raw []byte
+	err := up.apiGuard.Do(ctx, "get node metrics", func(ctx context.Context) error {
+		var err error
+		raw, err = up.client.Get().AbsPath(nodeMetricsPath).DoRaw(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var list nodeMetricsList
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return fmt.Errorf("failed to decode node metrics: %w", err)
+	}
+
+	usage := make(map[string]NodeUsage, len(list.Items))
+	for _, item := range list.Items {
+		cpu := item.Usage["cpu"]
+		mem := item.Usage["memory"]
+		usage[item.Metadata.Name] = NodeUsage{
+			CPUMillis:   cpu.MilliValue(),
+			MemoryBytes: mem.Value(),
+		}
+	}
+	up.usage = usage
+	klog.V(3).Infof("Node utilization refreshed for %d nodes", len(usage))
+	return nil
+}
@@ -0,0 +1,155 @@
+/*
+VPA Recommendation Provider
+===========================
+Reads Vertical Pod Autoscaler recommendations (autoscaling.k8s.io/v1) so
+the Filter resource-fit check can use the requests a gang member is about
+to be resized to, not the ones it was created with. Without this a
+co-located placement that fits now becomes infeasible minutes later when
+VPA evicts and recreates the pod with larger requests.
+
+Each VPA's per-container "target" recommendation is keyed by namespace
+and targetRef name; the targetRef name is taken as the service name,
+matching Online Boutique where Deployment name == service name.
+
+Recommendations are cached for VPA_CACHE_TTL and refreshed lazily, so at
+most one list call is made per TTL regardless of Filter call volume.
+*/
+
+package kube
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// vpaGVR identifies VerticalPodAutoscalers
+var vpaGVR = schema.GroupVersionResource{
+	Group:    "autoscaling.k8s.io",
+	Version:  "v1",
+	Resource: "verticalpodautoscalers",
+}
+
+// ContainerRecommendations maps container name → recommended target requests
+type ContainerRecommendations map[string]v1.ResourceList
+
+// VPAProvider caches VPA target recommendations per workload
+type VPAProvider struct {
+	client   dynamic.Interface
+	apiGuard *APIGuard
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	targets   map[string]ContainerRecommendations // "namespace/target" → containers
+	fetchedAt time.Time
+}
+
+// NewVPAProvider creates a VerticalPodAutoscaler backed recommendation provider
+func NewVPAProvider(client dynamic.Interface, apiGuard *APIGuard, ttl time.Duration) *VPAProvider {
+	return &VPAProvider{
+		client:   client,
+		apiGuard: apiGuard,
+		ttl:      ttl,
+		targets:  make(map[string]ContainerRecommendations),
+	}
+}
+
+// Recommendations returns the cached recommendations for a workload,
+// refreshing the cache if stale
+func (vp *VPAProvider) Recommendations(ctx context.Context, namespace, target string) (ContainerRecommendations, bool) {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	if time.Since(vp.fetchedAt) > vp.ttl {
+		if err := vp.refreshLocked(ctx); err != nil {
+			klog.Warningf("Failed to refresh VPA recommendations (using last known values): %v", err)
+		}
+		// Even on failure, wait a full TTL before retrying
+		vp.fetchedAt = time.Now()
+	}
+
+	recs, ok := vp.targets[namespace+"/"+target]
+	return recs, ok
+}
+
+// Refresh re-reads the recommendations now, restarting the TTL
+// (activation pre-warm)
+func (vp *VPAProvider) Refresh(ctx context.Context) error {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+	err := vp.refreshLocked(ctx)
+	vp.fetchedAt = time.Now()
+	return err
+}
+
+// refreshLocked re-reads every VPA's target recommendation (must hold mu)
+func (vp *VPAProvider) refreshLocked(ctx context.Context) error {
+	var list *unstructured.UnstructuredList
+	err := vp.apiGuard.Do(ctx, "list verticalpodautoscalers", func(ctx context.Context) error {
+		var err error
+		list, err = vp.client.Resource(vpaGVR).Namespace("").List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	targets := make(map[string]ContainerRecommendations, len(list.Items))
+	for _, obj := range list.Items {
+		target, found, _ := unstructured.NestedString(obj.Object, "spec", "targetRef", "name")
+		if !found || target == "" {
+			continue
+		}
+		if recs := parseContainerRecommendations(&obj); len(recs) > 0 {
+			targets[obj.GetNamespace()+"/"+target] = recs
+		}
+	}
+
+	vp.targets = targets
+	return nil
+}
+
+// parseContainerRecommendations reads status.recommendation.containerRecommendations[].target
+func parseContainerRecommendations(obj *unstructured.Unstructured) ContainerRecommendations {
+	entries, found, _ := unstructured.NestedSlice(obj.Object, "status", "recommendation", "containerRecommendations")
+	if !found {
+		return nil
+	}
+
+	recs := make(ContainerRecommendations, len(entries))
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := entry["containerName"].(string)
+		target, _ := entry["target"].(map[string]interface{})
+		if name == "" || target == nil {
+			continue
+		}
+
+		list := v1.ResourceList{}
+		for resourceName, raw := range target {
+			str, ok := raw.(string)
+			if !ok {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(str)
+			if err != nil {
+				klog.V(2).Infof("VPA %s/%s: ignoring invalid %s recommendation %q", obj.GetNamespace(), obj.GetName(), resourceName, str)
+				continue
+			}
+			list[v1.ResourceName(resourceName)] = quantity
+		}
+		recs[name] = list
+	}
+	return recs
+}
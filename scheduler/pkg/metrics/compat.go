@@ -0,0 +1,112 @@
+/*
+Metric Name Compatibility
+=========================
+Experiment dashboards and recording rules outlive releases: renaming a
+metric family mid-study silently empties their panels. Every rename is
+therefore recorded in metricRenames (old name → new name) and bumps
+SchemaVersion, which is exported as the metrics_schema label of
+nexus_build_info so queries can tell the layouts apart.
+
+With METRICS_LEGACY_NAMES=true (the default) /metrics keeps serving each
+renamed family under its old name as well, with the same samples and a
+HELP text pointing at the new name:
+
+  # HELP nexus_old_total Deprecated: renamed to nexus_new_total. ...
+  # TYPE nexus_old_total counter
+  nexus_old_total 12
+
+Once dashboards have moved over, METRICS_LEGACY_NAMES=false drops the old
+names. Legacy copies are only added to the /metrics exposition; counter
+snapshots and the generated dashboard see the current names alone.
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SchemaVersion is the version of the metric naming, bumped with every
+// entry added to metricRenames
+const SchemaVersion = 1
+
+// metricRenames maps the old name of every renamed family to its current
+// name. Entries are kept for at least one release after the rename.
+var metricRenames = map[string]string{}
+
+// SetLegacyNames serves the renamed families under their old names too
+func (m *NEXUSMetrics) SetLegacyNames(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.legacyNames = nil
+	if enabled {
+		m.legacyNames = metricRenames
+	}
+}
+
+// WriteExposition writes the /metrics exposition: every metric as
+// WriteAllMetrics does, plus the legacy names of renamed families
+func (m *NEXUSMetrics) WriteExposition(w io.Writer) {
+	m.mu.Lock()
+	renames := m.legacyNames
+	m.mu.Unlock()
+	if len(renames) == 0 {
+		m.WriteAllMetrics(w)
+		return
+	}
+
+	var buf bytes.Buffer
+	m.WriteAllMetrics(&buf)
+	writeWithLegacyNames(w, &buf, renames)
+}
+
+// writeWithLegacyNames copies the exposition in r to w, following each
+// renamed family with a copy under its old name
+func writeWithLegacyNames(w io.Writer, r io.Reader, renames map[string]string) {
+	oldNames := make(map[string]string, len(renames))
+	for old, current := range renames {
+		oldNames[current] = old
+	}
+
+	var family []string
+	name := ""
+	flush := func() {
+		old, renamed := oldNames[name]
+		if !renamed {
+			return
+		}
+		for _, line := range family {
+			fmt.Fprintln(w, legacyLine(line, name, old))
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if fields := strings.Fields(line); len(fields) > 2 && fields[0] == "#" && fields[1] == "HELP" {
+			flush()
+			family, name = nil, fields[2]
+		}
+		family = append(family, line)
+		fmt.Fprintln(w, line)
+	}
+	flush()
+}
+
+// legacyLine rewrites one line of the family current under its old name
+func legacyLine(line, current, old string) string {
+	if help := "# HELP " + current + " "; strings.HasPrefix(line, help) {
+		return fmt.Sprintf("# HELP %s Deprecated: renamed to %s. %s", old, current, strings.TrimPrefix(line, help))
+	}
+	if typ := "# TYPE " + current + " "; strings.HasPrefix(line, typ) {
+		return "# TYPE " + old + " " + strings.TrimPrefix(line, typ)
+	}
+	if strings.HasPrefix(line, current) {
+		return old + strings.TrimPrefix(line, current) // _bucket, _sum and _count keep their suffix
+	}
+	return line
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLegacyMetricNames(t *testing.T) {
+	m := NewNEXUSMetrics()
+	m.IncrementCounter("spike_events")
+	m.ActivationLatency.Observe(3)
+	m.legacyNames = map[string]string{
+		"nexus_spikes_total":  "nexus_spike_events_total",
+		"nexus_activation_ms": "nexus_activation_latency_ms",
+	}
+
+	var out bytes.Buffer
+	m.WriteExposition(&out)
+	for _, line := range []string{
+		"nexus_spike_events_total 1",
+		"# HELP nexus_spikes_total Deprecated: renamed to nexus_spike_events_total.",
+		"# TYPE nexus_spikes_total counter",
+		"nexus_spikes_total 1",
+		`nexus_activation_ms_bucket{le="5"} 1`,
+		"nexus_activation_ms_count 1",
+		`metrics_schema="1"`,
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("exposition missing %q", line)
+		}
+	}
+
+	// Snapshots and the dashboard only see the current names
+	if _, ok := m.CounterSnapshot()["nexus_spikes_total"]; ok {
+		t.Error("counter snapshot holds a legacy name")
+	}
+
+	m.SetLegacyNames(false)
+	out.Reset()
+	m.WriteExposition(&out)
+	if strings.Contains(out.String(), "nexus_spikes_total") {
+		t.Error("legacy name served with METRICS_LEGACY_NAMES=false")
+	}
+}
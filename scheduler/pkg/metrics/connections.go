@@ -0,0 +1,103 @@
+/*
+Extender Connection Metrics
+===========================
+kube-scheduler keeps its extender connections alive between calls; when
+it cannot (the server closed an idle connection, keep-alive disabled,
+EXTENDER_MAX_CONNECTIONS reached) every call pays for a new TCP
+connection, which shows up as tail latency in the extender histograms.
+These count the connections of the extender listener and how many
+requests reused one.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// connectionMetrics holds the extender listener connection counts
+type connectionMetrics struct {
+	mu          sync.Mutex
+	opened      int64
+	open        int64
+	requests    int64 // requests on a fresh connection
+	reused      int64 // requests on a connection that served one before
+	limitWaits  int64 // accepts held back by EXTENDER_MAX_CONNECTIONS
+	maxConns    int
+	idleTimeout float64
+}
+
+// SetConnectionLimits records the extender listener's connection limit
+// (0 = unlimited) and idle timeout in seconds
+func (m *NEXUSMetrics) SetConnectionLimits(maxConns int, idleTimeoutSeconds float64) {
+	m.conns.mu.Lock()
+	defer m.conns.mu.Unlock()
+	m.conns.maxConns = maxConns
+	m.conns.idleTimeout = idleTimeoutSeconds
+}
+
+// ConnectionOpened counts a new extender connection
+func (m *NEXUSMetrics) ConnectionOpened() {
+	m.conns.mu.Lock()
+	defer m.conns.mu.Unlock()
+	m.conns.opened++
+	m.conns.open++
+}
+
+// ConnectionClosed counts a closed extender connection
+func (m *NEXUSMetrics) ConnectionClosed() {
+	m.conns.mu.Lock()
+	defer m.conns.mu.Unlock()
+	m.conns.open--
+}
+
+// ConnectionRequest counts a request and whether its connection was reused
+func (m *NEXUSMetrics) ConnectionRequest(reused bool) {
+	m.conns.mu.Lock()
+	defer m.conns.mu.Unlock()
+	if reused {
+		m.conns.reused++
+	} else {
+		m.conns.requests++
+	}
+}
+
+// ConnectionLimitWait counts an accept held back by the connection limit
+func (m *NEXUSMetrics) ConnectionLimitWait() {
+	m.conns.mu.Lock()
+	defer m.conns.mu.Unlock()
+	m.conns.limitWaits++
+}
+
+// write emits the connection metric families in Prometheus format
+func (c *connectionMetrics) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP nexus_extender_connections_opened_total Connections accepted by the extender listener\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_connections_opened_total counter\n")
+	fmt.Fprintf(w, "nexus_extender_connections_opened_total %d\n", c.opened)
+
+	fmt.Fprintf(w, "# HELP nexus_extender_connections_open Connections currently open on the extender listener\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_connections_open gauge\n")
+	fmt.Fprintf(w, "nexus_extender_connections_open %d\n", c.open)
+
+	fmt.Fprintf(w, "# HELP nexus_extender_connection_requests_total Extender listener requests, by whether the connection was reused\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_connection_requests_total counter\n")
+	fmt.Fprintf(w, "nexus_extender_connection_requests_total{connection=\"new\"} %d\n", c.requests)
+	fmt.Fprintf(w, "nexus_extender_connection_requests_total{connection=\"reused\"} %d\n", c.reused)
+
+	fmt.Fprintf(w, "# HELP nexus_extender_connection_limit_waits_total Connections that waited for a slot under EXTENDER_MAX_CONNECTIONS\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_connection_limit_waits_total counter\n")
+	fmt.Fprintf(w, "nexus_extender_connection_limit_waits_total %d\n", c.limitWaits)
+
+	fmt.Fprintf(w, "# HELP nexus_extender_max_connections Connection limit of the extender listener (0 = unlimited)\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_max_connections gauge\n")
+	fmt.Fprintf(w, "nexus_extender_max_connections %d\n", c.maxConns)
+
+	fmt.Fprintf(w, "# HELP nexus_extender_idle_timeout_seconds Idle timeout of extender keep-alive connections (0 = keep-alive disabled)\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_idle_timeout_seconds gauge\n")
+	fmt.Fprintf(w, "nexus_extender_idle_timeout_seconds %s\n", formatFloat(c.idleTimeout))
+}
@@ -0,0 +1,193 @@
+/*
+Grafana Dashboard Export
+========================
+Generates a ready-to-import Grafana dashboard for every metric NEXUS
+exposes. The panel list is derived from the /metrics output itself (the
+HELP/TYPE lines written by WriteAllMetrics), so a metric added there
+shows up in the dashboard without any further change:
+
+  histogram → p50/p95/p99 panel (unit ms)
+  gauge     → current value, one series per label set
+  counter   → per-second rate, one series per label set
+
+Panels are grouped into rows by type and query a "datasource" template
+variable, so the same JSON works against any Prometheus data source.
+
+  nexus-scheduler --export-dashboard > nexus-dashboard.json
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MetricDesc describes one exposed metric family
+type MetricDesc struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "counter", "gauge" or "histogram"
+	Help string `json:"help"`
+}
+
+// Describe returns the metric families written by WriteAllMetrics, in output order
+func (m *NEXUSMetrics) Describe() []MetricDesc {
+	var buf bytes.Buffer
+	m.WriteAllMetrics(&buf)
+
+	help := make(map[string]string)
+	descs := make([]MetricDesc, 0)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 4)
+		if len(fields) < 4 || fields[0] != "#" {
+			continue
+		}
+		switch fields[1] {
+		case "HELP":
+			help[fields[2]] = fields[3]
+		case "TYPE":
+			descs = append(descs, MetricDesc{Name: fields[2], Type: fields[3], Help: help[fields[2]]})
+		}
+	}
+	return descs
+}
+
+// dashboardRows orders the panel rows by metric type
+var dashboardRows = []struct {
+	metricType string
+	title      string
+}{
+	{"histogram", "Latency"},
+	{"gauge", "State"},
+	{"counter", "Events (per second)"},
+}
+
+// Panel layout on Grafana's 24-column grid
+const (
+	panelWidth  = 8
+	panelHeight = 8
+)
+
+// WriteDashboard writes the Grafana dashboard JSON for the current metric set
+func WriteDashboard(w io.Writer) error {
+	data, err := json.MarshalIndent(Dashboard(NewNEXUSMetrics().Describe()), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Dashboard builds a Grafana dashboard model with one panel per metric
+func Dashboard(descs []MetricDesc) map[string]interface{} {
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+
+	panels := make([]map[string]interface{}, 0, len(descs)+len(dashboardRows))
+	id, y := 1, 0
+	for _, row := range dashboardRows {
+		members := make([]MetricDesc, 0)
+		for _, d := range descs {
+			if d.Type == row.metricType {
+				members = append(members, d)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		panels = append(panels, map[string]interface{}{
+			"id":        id,
+			"type":      "row",
+			"title":     row.title,
+			"collapsed": false,
+			"gridPos":   map[string]int{"x": 0, "y": y, "w": 24, "h": 1},
+			"panels":    []interface{}{},
+		})
+		id++
+		y++
+
+		for i, d := range members {
+			unit, targets := panelQueries(d)
+			panels = append(panels, map[string]interface{}{
+				"id":          id,
+				"type":        "timeseries",
+				"title":       strings.TrimPrefix(d.Name, "nexus_"),
+				"description": d.Help,
+				"datasource":  datasource,
+				"gridPos": map[string]int{
+					"x": (i % (24 / panelWidth)) * panelWidth,
+					"y": y + (i/(24/panelWidth))*panelHeight,
+					"w": panelWidth,
+					"h": panelHeight,
+				},
+				"fieldConfig": map[string]interface{}{
+					"defaults":  map[string]interface{}{"unit": unit},
+					"overrides": []interface{}{},
+				},
+				"options": map[string]interface{}{
+					"legend":  map[string]interface{}{"displayMode": "list", "placement": "bottom", "showLegend": true},
+					"tooltip": map[string]interface{}{"mode": "multi"},
+				},
+				"targets": targets,
+			})
+			id++
+		}
+		y += ((len(members) + 24/panelWidth - 1) / (24 / panelWidth)) * panelHeight
+	}
+
+	return map[string]interface{}{
+		"uid":           "nexus-scheduler",
+		"title":         "NEXUS Scheduler",
+		"description":   "Generated by nexus-scheduler --export-dashboard",
+		"tags":          []string{"nexus", "scheduler"},
+		"editable":      true,
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-1h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{{
+				"name":  "datasource",
+				"label": "Prometheus",
+				"type":  "datasource",
+				"query": "prometheus",
+			}},
+		},
+		"panels": panels,
+	}
+}
+
+// panelQueries returns the unit and PromQL targets for one metric family
+func panelQueries(d MetricDesc) (string, []map[string]string) {
+	target := func(refID, expr, legend string) map[string]string {
+		return map[string]string{"refId": refID, "expr": expr, "legendFormat": legend}
+	}
+
+	switch d.Type {
+	case "histogram":
+		unit := "none"
+		if strings.HasSuffix(d.Name, "_ms") {
+			unit = "ms"
+		}
+		quantile := func(q string) string {
+			return fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s_bucket[$__rate_interval])))", q, d.Name)
+		}
+		return unit, []map[string]string{
+			target("A", quantile("0.5"), "p50"),
+			target("B", quantile("0.95"), "p95"),
+			target("C", quantile("0.99"), "p99"),
+		}
+	case "counter":
+		return "ops", []map[string]string{target("A", fmt.Sprintf("rate(%s[$__rate_interval])", d.Name), "__auto")}
+	default:
+		unit := "none"
+		if strings.HasSuffix(d.Name, "_bytes") {
+			unit = "bytes"
+		}
+		return unit, []map[string]string{target("A", d.Name, "__auto")}
+	}
+}
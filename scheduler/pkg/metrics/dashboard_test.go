@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDashboardCoversEveryMetric(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDashboard(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var dashboard struct {
+		Panels []struct {
+			Type    string `json:"type"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &dashboard); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+
+	queried := func(name string) bool {
+		for _, p := range dashboard.Panels {
+			for _, target := range p.Targets {
+				if target.Expr == name || strings.Contains(target.Expr, name+"[") {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// Every family on /metrics must be queried by some panel
+	var exposition bytes.Buffer
+	NewNEXUSMetrics().WriteAllMetrics(&exposition)
+	families := 0
+	for _, line := range strings.Split(exposition.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[1] != "TYPE" {
+			continue
+		}
+		families++
+		name := fields[2]
+		if fields[3] == "histogram" {
+			name += "_bucket"
+		}
+		if !queried(name) {
+			t.Errorf("metric %s (%s) has no dashboard panel", fields[2], fields[3])
+		}
+	}
+	if families == 0 {
+		t.Fatal("no metric families found in /metrics output")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Detector Signal Metrics
+=======================
+The values the spike detector observed on its latest check, and the
+thresholds it compared them against, so every activation decision can be
+reconstructed from the scrape history. A signal whose query failed (or
+that the check did not evaluate) is exported as NaN; while Prometheus is
+unreachable nexus_detector_prometheus_up is 0 and every signal is NaN.
+nexus_detector_spike_score combines them (pkg/detector/score.go).
+With SPIKE_SOURCE=locust the signals come from the Locust web API and
+nexus_detector_locust_up reports whether it answered.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// DetectorSignals is what the spike detector observed on one check
+type DetectorSignals struct {
+	PrometheusUp bool
+	LocustUp     bool
+	QPS          float64 // NaN = not observed
+	ErrorRate    float64
+	P95Ms        float64
+	HPAIncrease  float64
+	Users        float64            // Locust users
+	Score        float64            // weighted spike score
+	Thresholds   map[string]float64 // signal → threshold of the active profile
+	At           time.Time
+}
+
+// detectorMetrics holds the latest detector observation
+type detectorMetrics struct {
+	mu      sync.Mutex
+	signals *DetectorSignals // nil until the first check
+}
+
+// SetDetectorSignals records the latest detector observation
+func (m *NEXUSMetrics) SetDetectorSignals(signals DetectorSignals) {
+	m.detector.mu.Lock()
+	defer m.detector.mu.Unlock()
+	m.detector.signals = &signals
+}
+
+// write emits the detector metric families in Prometheus format
+func (d *detectorMetrics) write(w io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	signals := DetectorSignals{QPS: math.NaN(), ErrorRate: math.NaN(), P95Ms: math.NaN(), HPAIncrease: math.NaN(), Users: math.NaN(), Score: math.NaN()}
+	if d.signals != nil {
+		signals = *d.signals
+	}
+
+	up := 0
+	if signals.PrometheusUp {
+		up = 1
+	}
+	fmt.Fprintf(w, "# HELP nexus_detector_prometheus_up 1 if Prometheus answered the detector's last check\n")
+	fmt.Fprintf(w, "# TYPE nexus_detector_prometheus_up gauge\n")
+	fmt.Fprintf(w, "nexus_detector_prometheus_up %d\n", up)
+
+	locustUp := 0
+	if signals.LocustUp {
+		locustUp = 1
+	}
+	fmt.Fprintf(w, "# HELP nexus_detector_locust_up 1 if the Locust web API answered the detector's last check (SPIKE_SOURCE=locust)\n")
+	fmt.Fprintf(w, "# TYPE nexus_detector_locust_up gauge\n")
+	fmt.Fprintf(w, "nexus_detector_locust_up %d\n", locustUp)
+
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+	}
+	gauge("nexus_detector_qps", "Cluster-wide QPS seen by the last spike check (NaN = not observed)", signals.QPS)
+	gauge("nexus_detector_error_rate", "5xx rate seen by the last spike check (NaN = not observed)", signals.ErrorRate)
+	gauge("nexus_detector_p95_latency_ms", "p95 latency seen by the last spike check in ms (NaN = not observed)", signals.P95Ms)
+	gauge("nexus_detector_hpa_replica_increase", "HPA replica increase seen by the last spike check (NaN = not observed)", signals.HPAIncrease)
+	gauge("nexus_detector_locust_users", "Locust users seen by the last spike check (NaN = not observed)", signals.Users)
+	gauge("nexus_detector_spike_score", "Weighted spike score of the last spike check, 1 = at the thresholds (NaN = not observed)", signals.Score)
+
+	var at float64
+	if !signals.At.IsZero() {
+		at = float64(signals.At.UnixNano()) / 1e9
+	}
+	gauge("nexus_detector_last_check_timestamp_seconds", "Unix time of the last spike check (0 = none yet)", at)
+
+	fmt.Fprintf(w, "# HELP nexus_detector_threshold Threshold of the active profile per signal (score = SPIKE_SCORE_THRESHOLD in score mode)\n")
+	fmt.Fprintf(w, "# TYPE nexus_detector_threshold gauge\n")
+	for _, signal := range []string{"qps", "errors", "p95", "score"} {
+		if threshold, ok := signals.Thresholds[signal]; ok {
+			fmt.Fprintf(w, "nexus_detector_threshold{signal=\"%s\"} %s\n", signal, formatFloat(threshold))
+		}
+	}
+}
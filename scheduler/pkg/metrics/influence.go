@@ -0,0 +1,128 @@
+/*
+Influence Metrics
+=================
+Scoring a pod is not the same as changing where it lands: kube-scheduler
+adds NEXUS's scores to its own plugins' and may bind elsewhere, and a
+pod whose candidates all score the same was not steered at all. These
+separate the two, per coordination group (the "gang" label) and service:
+
+  nexus_pods_scored_total          gang member pods NEXUS scored
+  nexus_pods_bound_total{outcome}  scored pods seen bound, by whether
+                                   NEXUS's score decided the node:
+    influenced   bound to the node NEXUS scored strictly highest
+    overridden   bound to a node NEXUS scored lower than another, or
+                 did not score
+    indifferent  bound to a node tied for NEXUS's highest score
+  nexus_influence_rate             influenced ÷ bound, over all pods
+
+Bindings are seen through the gang member pod watch (GANG_MEMBER_CACHE);
+without it pods are only counted as scored.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Binding outcomes of a scored pod
+const (
+	InfluenceInfluenced  = "influenced"
+	InfluenceOverridden  = "overridden"
+	InfluenceIndifferent = "indifferent"
+)
+
+// influenceOutcomes are the binding outcomes in exposition order
+var influenceOutcomes = []string{InfluenceInfluenced, InfluenceOverridden, InfluenceIndifferent}
+
+// influenceKey is one gang (group) and service
+type influenceKey struct {
+	gang    string
+	service string
+}
+
+// influenceCounts are the pods of one gang and service
+type influenceCounts struct {
+	scored int64
+	bound  map[string]int64 // outcome → pods
+}
+
+// influenceMetrics holds the scored and bound pod counts
+type influenceMetrics struct {
+	mu     sync.Mutex
+	counts map[influenceKey]*influenceCounts
+}
+
+// countsFor returns the counts of a gang and service; the caller holds mu
+func (f *influenceMetrics) countsFor(gang, service string) *influenceCounts {
+	if f.counts == nil {
+		f.counts = make(map[influenceKey]*influenceCounts)
+	}
+	key := influenceKey{gang, service}
+	if f.counts[key] == nil {
+		f.counts[key] = &influenceCounts{bound: make(map[string]int64, len(influenceOutcomes))}
+	}
+	return f.counts[key]
+}
+
+// IncrementPodScored counts a gang member pod scored by Prioritize
+func (m *NEXUSMetrics) IncrementPodScored(gang, service string) {
+	m.influence.mu.Lock()
+	defer m.influence.mu.Unlock()
+	m.influence.countsFor(gang, service).scored++
+}
+
+// IncrementPodBound counts a scored pod seen bound, with its outcome
+func (m *NEXUSMetrics) IncrementPodBound(gang, service, outcome string) {
+	m.influence.mu.Lock()
+	defer m.influence.mu.Unlock()
+	m.influence.countsFor(gang, service).bound[outcome]++
+}
+
+// write emits the influence metric families in Prometheus format
+func (f *influenceMetrics) write(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]influenceKey, 0, len(f.counts))
+	for key := range f.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].gang != keys[j].gang {
+			return keys[i].gang < keys[j].gang
+		}
+		return keys[i].service < keys[j].service
+	})
+
+	fmt.Fprintf(w, "# HELP nexus_pods_scored_total Gang member pods scored by Prioritize, by gang (group) and service\n")
+	fmt.Fprintf(w, "# TYPE nexus_pods_scored_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(w, "nexus_pods_scored_total{gang=\"%s\",service=\"%s\"} %d\n", key.gang, key.service, f.counts[key].scored)
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_pods_bound_total Scored pods seen bound, by whether NEXUS's score decided the node (influenced, overridden, indifferent)\n")
+	fmt.Fprintf(w, "# TYPE nexus_pods_bound_total counter\n")
+	bound, influenced := int64(0), int64(0)
+	for _, key := range keys {
+		for _, outcome := range influenceOutcomes {
+			n := f.counts[key].bound[outcome]
+			fmt.Fprintf(w, "nexus_pods_bound_total{gang=\"%s\",service=\"%s\",outcome=\"%s\"} %d\n", key.gang, key.service, outcome, n)
+			bound += n
+			if outcome == InfluenceInfluenced {
+				influenced += n
+			}
+		}
+	}
+
+	rate := 0.0
+	if bound > 0 {
+		rate = float64(influenced) / float64(bound)
+	}
+	fmt.Fprintf(w, "# HELP nexus_influence_rate Fraction of bound scored pods placed on the node NEXUS scored strictly highest\n")
+	fmt.Fprintf(w, "# TYPE nexus_influence_rate gauge\n")
+	fmt.Fprintf(w, "nexus_influence_rate %s\n", formatFloat(rate))
+}
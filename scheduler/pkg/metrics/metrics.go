@@ -0,0 +1,1691 @@
+/*
+NEXUS Research Metrics
+=====================
+Provides precision latency histograms for empirically proving
+low control-plane overhead. All metrics are exposed at /metrics
+in Prometheus-compatible text format.
+
+Required by Section 2F of the professional review:
+"We must empirically show low control-plane overhead later."
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/version"
+)
+
+// Default histogram bucket boundaries (ms)
+var (
+	// DefaultLatencyBuckets cover control-loop latencies (activation, gang formation)
+	DefaultLatencyBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000}
+
+	// ExtenderLatencyBuckets resolve the sub-millisecond overhead range that
+	// Filter/Prioritize calls fall into, which a 1ms lowest bucket would hide
+	ExtenderLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250}
+
+	// SchedulingLatencyBuckets cover pod creation to binding, queueing included
+	SchedulingLatencyBuckets = []float64{50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000}
+)
+
+// LatencyHistogram tracks latency measurements with histogram buckets
+type LatencyHistogram struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	labels  string    // constant labels of one series of a family ("" = none)
+	buckets []float64 // upper bucket boundaries in ms (le), sorted ascending
+	counts  []int64   // non-cumulative count per bucket, last entry is +Inf
+	sum     float64
+	count   int64
+
+	// Rolling window of the most recent observations for /status quantiles
+	window     []float64
+	windowNext int
+}
+
+// quantileWindowSize is the number of recent observations kept per histogram
+const quantileWindowSize = 1024
+
+// LatencySummary holds rolling latency quantiles in milliseconds
+type LatencySummary struct {
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Samples int     `json:"samples"`
+}
+
+// NewLatencyHistogram creates a histogram with the given bucket boundaries.
+// Boundaries can be overridden per histogram through the environment variable
+// HISTOGRAM_BUCKETS_<NAME> (name upper-cased, without the "nexus_" prefix),
+// e.g. HISTOGRAM_BUCKETS_EXTENDER_FILTER_LATENCY_MS="0.1,0.5,1,5".
+func NewLatencyHistogram(name, help string, buckets []float64) *LatencyHistogram {
+	envKey := "HISTOGRAM_BUCKETS_" + strings.ToUpper(strings.TrimPrefix(name, "nexus_"))
+	buckets = normalizeBuckets(config.EnvFloatList(envKey, buckets))
+	return &LatencyHistogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		counts:  make([]int64, len(buckets)+1), // +1 for +Inf
+		window:  make([]float64, 0, quantileWindowSize),
+	}
+}
+
+// normalizeBuckets sorts boundaries and drops duplicates and non-finite values
+func normalizeBuckets(buckets []float64) []float64 {
+	out := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if !math.IsNaN(b) && !math.IsInf(b, 0) {
+			out = append(out, b)
+		}
+	}
+	sort.Float64s(out)
+
+	unique := out[:0]
+	for i, b := range out {
+		if i == 0 || b != out[i-1] {
+			unique = append(unique, b)
+		}
+	}
+	return unique
+}
+
+// Observe records a latency measurement in milliseconds.
+// A value lands in the first bucket whose upper bound is >= the value,
+// matching Prometheus "le" semantics once counts are accumulated on output.
+func (h *LatencyHistogram) Observe(ms float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sum += ms
+	h.count++
+
+	// SearchFloat64s returns len(buckets) for values above every bound (+Inf)
+	h.counts[sort.SearchFloat64s(h.buckets, ms)]++
+
+	// Ring buffer: overwrite the oldest sample once the window is full
+	if len(h.window) < quantileWindowSize {
+		h.window = append(h.window, ms)
+	} else {
+		h.window[h.windowNext] = ms
+	}
+	h.windowNext = (h.windowNext + 1) % quantileWindowSize
+}
+
+// Quantiles returns p50/p95/p99 over the rolling window of recent observations
+func (h *LatencyHistogram) Quantiles() LatencySummary {
+	h.mu.Lock()
+	sorted := make([]float64, len(h.window))
+	copy(sorted, h.window)
+	h.mu.Unlock()
+
+	sort.Float64s(sorted)
+	return LatencySummary{
+		P50:     quantile(sorted, 0.50),
+		P95:     quantile(sorted, 0.95),
+		P99:     quantile(sorted, 0.99),
+		Samples: len(sorted),
+	}
+}
+
+// quantile returns the nearest-rank q-quantile of an ascending slice
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// TimeSince returns milliseconds elapsed since start and records it
+func (h *LatencyHistogram) TimeSince(start time.Time) float64 {
+	ms := float64(time.Since(start).Nanoseconds()) / 1e6
+	h.Observe(ms)
+	return ms
+}
+
+// WritePrometheus writes the histogram in Prometheus text format
+func (h *LatencyHistogram) WritePrometheus(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", h.name)
+	h.writeSamples(w)
+}
+
+// writeSamples writes the bucket, sum and count lines of the histogram
+func (h *LatencyHistogram) writeSamples(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prefix, labels := "", ""
+	if h.labels != "" {
+		prefix, labels = h.labels+",", "{"+h.labels+"}"
+	}
+	cumulativeCount := int64(0)
+	for i, boundary := range h.buckets {
+		cumulativeCount += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", h.name, prefix, strconv.FormatFloat(boundary, 'g', -1, 64), cumulativeCount)
+	}
+	cumulativeCount += h.counts[len(h.buckets)]
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", h.name, prefix, cumulativeCount)
+	fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels, h.count)
+}
+
+// NEXUSMetrics holds all research-grade metrics
+type NEXUSMetrics struct {
+	// How fast NEXUS detected the spike and transitioned to ACTIVE
+	ActivationLatency *LatencyHistogram
+
+	// Time spent pre-warming scoring caches on activation
+	ActivationPrewarm *LatencyHistogram
+
+	// How fast NEXUS built the dependency graph and formed the gang
+	GangFormationLatency *LatencyHistogram
+
+	// Overhead added to the default scheduler's Filter phase
+	ExtenderFilterLatency *LatencyHistogram
+
+	// Overhead added to the default scheduler's Prioritize phase
+	ExtenderPrioritizeLatency *LatencyHistogram
+
+	// Round trip of the synthetic Filter calls to the extender listener
+	SelfProbeLatency *LatencyHistogram
+
+	// Gang-member pod creation to binding, by scheduler state at binding
+	schedulingLatency map[string]*LatencyHistogram
+
+	// Counters
+	mu              sync.Mutex
+	spikeEvents     int64
+	gangsFormed     int64
+	gangsDisssolved int64
+	filterCalls     int64
+	prioritizeCalls int64
+	stateChanges    int64
+	currentState    string
+
+	// Kubernetes API guard
+	apiRetries         int64
+	apiThrottled       int64
+	apiCircuitOpened   int64
+	apiCircuitRejected int64
+	apiBreakerState    int
+	apiAuth            APIAuth
+
+	// Prioritize calls scored from last known good member counts
+	degradedDecisions int64
+
+	// Ephemeral state budget
+	budgetTruncations int64
+
+	// Restart recovery
+	stateRecoveries int64
+
+	// External activation triggers
+	kedaTriggers int64
+
+	// Synthetic spikes injected through the admin API
+	spikesInjected int64
+
+	// Prioritize calls whose score ties were broken (SCORE_TIE_BREAK)
+	scoreTiesBroken int64
+
+	// Per-decision log lines dropped by DECISION_LOG_SAMPLE/DECISION_LOG_RATE
+	decisionLogsSuppressed int64
+
+	// Warm gang member counts (pod list at formation, then pod watch)
+	memberCacheWarmups int64
+	memberCacheResyncs int64
+
+	// Nodes that went NotReady or were deleted mid-episode, and the
+	// placement plans recomputed for them
+	nodeFailures     int64
+	placementReplans int64
+
+	// Gang label webhook
+	webhookPodsLabeled int64
+
+	// nexus.io annotation admission reviews, by result
+	annotationReviews map[string]int64
+
+	// Self-probe calls to the extender listener, by result, and the last one
+	selfProbes  map[string]int64
+	selfProbeUp int
+
+	// Pods skipped because they existed before activation
+	preexistingSkipped int64
+
+	// Extender calls for pods of scheduler profiles outside SCHEDULER_NAMES
+	schedulerSkipped int64
+
+	// Extender and webhook calls for pods protected by the system pod guard
+	systemPodsSkipped int64
+
+	// Filter rejections by reason code
+	filterRejections map[string]int64
+
+	// Candidate nodes Prioritize scored 0 as unschedulable for the pod
+	unschedulableScored int64
+
+	// Prioritize calls for pods outside every gang, by fallback policy
+	fallbackDecisions map[string]int64
+
+	// Extender calls that could not be evaluated, by "endpoint/class"
+	extenderErrors map[string]int64
+
+	// Gang member pods given eviction protection
+	podsProtected int64
+
+	// Gang-member pods given the gang and episode labels
+	memberPodsLabeled int64
+
+	// Gang-member Deployments given and relieved of affinity hints
+	affinityHintsAdded   int64
+	affinityHintsRemoved int64
+
+	// Filter capacity checks that used a larger VPA recommendation
+	vpaAdjusted int64
+
+	// Per-group latency SLO tracking (see slo.go)
+	slo sloMetrics
+
+	// Influence sweep experiment (see sweep.go)
+	sweep sweepMetrics
+
+	// Counter values restored from a snapshot (see persist.go)
+	restored restoredCounters
+
+	// Shadow scorer decisions and divergence (see shadow.go)
+	shadow shadowMetrics
+
+	// Extender request/response bytes (see payload.go)
+	payload payloadMetrics
+
+	// Extender listener connections and reuse (see connections.go)
+	conns connectionMetrics
+
+	// Pods scored vs pods whose placement NEXUS decided (see influence.go)
+	influence influenceMetrics
+
+	// Latest spike detector observation (see detector.go)
+	detector detectorMetrics
+
+	// Decision export
+	decisionsExported      int64
+	decisionsDropped       int64
+	decisionFilesUploaded  int64
+	decisionUploadFailures int64
+
+	// History store (see pkg/store)
+	historyStored  int64
+	historyDropped int64
+	historyPruned  int64
+	historyBytes   int64
+
+	// Episode traces sent to OTEL_EXPORTER_OTLP_ENDPOINT
+	tracesExported      int64
+	traceExportFailures int64
+
+	// What-if evaluations served by POST /simulate
+	simulations int64
+
+	// Cluster snapshots served by GET /admin/snapshot
+	snapshots int64
+
+	// Counter snapshot writes that failed (see persist.go)
+	counterSnapshotFailures int64
+
+	// Quiet period before the gangs drain or dissolve (seconds)
+	cooldownSeconds float64
+
+	// Active spike detection threshold profile
+	thresholdProfile string
+
+	// Class of the current spike ("" = none) and activations per class
+	spikeClass       string
+	spikeClassEvents map[string]int64
+
+	// Activation level of the current episode ("" = none), escalations
+	// to ENFORCING and Filter calls answered without filtering while ADVISORY
+	activationLevel       string
+	activationEscalations int64
+	advisoryFilters       int64
+
+	// Activations per cause ("signal/source"), and per HPA ("namespace/name")
+	// for hpa-triggered ones
+	spikeTriggers  map[string]int64
+	hpaActivations map[string]int64
+
+	// Gang lifecycle stage, since when it holds and seconds spent in
+	// earlier stints per stage
+	gangStage          string
+	gangStageSince     time.Time
+	gangStageSeconds   map[string]float64
+	invalidTransitions int64
+
+	// Gang formation strategy of the current episode ("" = none)
+	formationStrategy string
+
+	// Optional subsystems the instance runs with (nexus_feature_enabled)
+	features map[string]bool
+
+	// Post-spike drain period
+	drainsStarted    int64
+	drainReactivated int64
+	drainDecisions   int64
+	protocolMismatch int64
+
+	// Per-episode influence budget
+	influenceExhausted int64
+	influenceUsed      map[string]int // gangID → pods influenced this episode
+	influenceBudget    int
+
+	// Co-location pressure of gang member services (HPA scaling signal)
+	colocationPressure map[string]float64
+
+	// Members of each warm gang by node (gang ID → node → members)
+	gangDensity map[string]map[string]int
+
+	// Gang members without live pods (partial gangs)
+	missingMembers      map[string]int // gangID → members still missing
+	missingMembersTotal int64          // members found missing at gang formation
+	isolatedMembers     int64          // group members without a network path (GRAPH_NETWORK_POLICY)
+	filteredServices    int64          // services left out by the allowlist/denylist at formation
+	missingArrived      int64          // missing members whose first pod showed up
+
+	// Placement plans (GANG_PLACEMENT=planned)
+	placementPlans        int64
+	placementPlanFailures int64
+	unplacedReplicas      int64 // planned replicas that fit no node
+	plannedDecisions      int64 // Prioritize decisions steered by a plan
+
+	// Prioritize decisions scored toward the gang's anchor members
+	anchoredDecisions int64
+
+	// Activation flapping back-off
+	flapBackoff    bool
+	flapBackoffs   int64
+	flapSuppressed int64
+
+	// Latency budget self-disable (LATENCY_BUDGET)
+	latencyExceeded     bool
+	selfDisabled        bool
+	selfDisables        int64
+	selfDisabledAnswers int64 // Filter/Prioritize calls answered with no opinion
+
+	// Spike window extensions and refused extensions by reason
+	spikeExtensions   int64
+	extensionsRefused map[string]int64
+
+	// Services found in several coordination groups, by resolution
+	groupOverlaps map[string]int64
+
+	// Contested node scores arbitrated by gang priority, by outcome
+	arbitrations map[string]int64
+
+	// Filter calls of gangs starting fresh, by FRESH_GANG_PLACEMENT policy
+	freshGangFilters map[string]int64
+
+	// Admin episode activate/deactivate requests, by "action/result"
+	episodeRequests map[string]int64
+
+	// Renamed families also served under their old names (compat.go)
+	legacyNames map[string]string
+
+	// Coscheduling PodGroups created and deleted for gangs
+	podGroupsCreated int64
+	podGroupsDeleted int64
+
+	// Spike check cycles skipped (previous one still running) or over budget
+	spikeChecksSkipped int64
+	spikeCheckTimeouts int64
+
+	// NexusPolicy custom resources
+	policies        int   // valid policies loaded
+	policyRejected  int64 // invalid policies ignored
+	policiesApplied int64 // gangs formed with a policy
+}
+
+// NewNEXUSMetrics initializes all research metrics
+func NewNEXUSMetrics() *NEXUSMetrics {
+	return &NEXUSMetrics{
+		ActivationLatency: NewLatencyHistogram(
+			"nexus_activation_latency_ms",
+			"Time from spike detection trigger to ACTIVE state (ms)",
+			DefaultLatencyBuckets,
+		),
+		ActivationPrewarm: NewLatencyHistogram(
+			"nexus_activation_prewarm_ms",
+			"Time to resync scoring caches and seed gang node tables on activation (ms)",
+			DefaultLatencyBuckets,
+		),
+		GangFormationLatency: NewLatencyHistogram(
+			"nexus_gang_formation_latency_ms",
+			"Time to build dependency DAG and form temporary gang (ms)",
+			DefaultLatencyBuckets,
+		),
+		ExtenderFilterLatency: NewLatencyHistogram(
+			"nexus_extender_filter_latency_ms",
+			"Overhead added to kube-scheduler Filter phase (ms)",
+			ExtenderLatencyBuckets,
+		),
+		ExtenderPrioritizeLatency: NewLatencyHistogram(
+			"nexus_extender_prioritize_latency_ms",
+			"Overhead added to kube-scheduler Prioritize phase (ms)",
+			ExtenderLatencyBuckets,
+		),
+		SelfProbeLatency: NewLatencyHistogram(
+			"nexus_self_probe_latency_ms",
+			"Round trip of the self-probe's synthetic Filter call to the extender listener (ms)",
+			ExtenderLatencyBuckets,
+		),
+		currentState:       "IDLE",
+		thresholdProfile:   "default",
+		apiAuth:            APIAuth{OK: true},
+		influenceUsed:      make(map[string]int),
+		colocationPressure: make(map[string]float64),
+		filterRejections:   make(map[string]int64),
+		fallbackDecisions:  make(map[string]int64),
+		extensionsRefused:  make(map[string]int64),
+		groupOverlaps:      make(map[string]int64),
+		arbitrations:       make(map[string]int64),
+		freshGangFilters:   make(map[string]int64),
+		annotationReviews:  make(map[string]int64),
+		selfProbes:         make(map[string]int64),
+		schedulingLatency:  make(map[string]*LatencyHistogram),
+		episodeRequests:    make(map[string]int64),
+		extenderErrors:     make(map[string]int64),
+		spikeClassEvents:   make(map[string]int64),
+		spikeTriggers:      make(map[string]int64),
+		hpaActivations:     make(map[string]int64),
+		gangStage:          "NONE",
+		gangStageSince:     time.Now(),
+		gangStageSeconds:   make(map[string]float64),
+	}
+}
+
+// IncrementCounter safely increments a named counter
+func (m *NEXUSMetrics) IncrementCounter(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch name {
+	case "spike_events":
+		m.spikeEvents++
+	case "gangs_formed":
+		m.gangsFormed++
+	case "gangs_dissolved":
+		m.gangsDisssolved++
+	case "invalid_stage_transitions":
+		m.invalidTransitions++
+	case "filter_calls":
+		m.filterCalls++
+	case "prioritize_calls":
+		m.prioritizeCalls++
+	case "state_changes":
+		m.stateChanges++
+	case "api_retries":
+		m.apiRetries++
+	case "api_throttled":
+		m.apiThrottled++
+	case "degraded_decisions":
+		m.degradedDecisions++
+	case "api_circuit_opened":
+		m.apiCircuitOpened++
+	case "api_circuit_rejected":
+		m.apiCircuitRejected++
+	case "budget_truncations":
+		m.budgetTruncations++
+	case "placement_plans":
+		m.placementPlans++
+	case "placement_plan_failures":
+		m.placementPlanFailures++
+	case "planned_decisions":
+		m.plannedDecisions++
+	case "anchored_decisions":
+		m.anchoredDecisions++
+	case "state_recoveries":
+		m.stateRecoveries++
+	case "keda_triggers":
+		m.kedaTriggers++
+	case "spikes_injected":
+		m.spikesInjected++
+	case "score_ties_broken":
+		m.scoreTiesBroken++
+	case "decision_logs_suppressed":
+		m.decisionLogsSuppressed++
+	case "member_cache_warmups":
+		m.memberCacheWarmups++
+	case "member_cache_resyncs":
+		m.memberCacheResyncs++
+	case "node_failures":
+		m.nodeFailures++
+	case "placement_replans":
+		m.placementReplans++
+	case "webhook_pods_labeled":
+		m.webhookPodsLabeled++
+	case "preexisting_skipped":
+		m.preexistingSkipped++
+	case "scheduler_skipped":
+		m.schedulerSkipped++
+	case "system_pods_skipped":
+		m.systemPodsSkipped++
+	case "influence_budget_exhausted":
+		m.influenceExhausted++
+	case "gang_members_arrived":
+		m.missingArrived++
+	case "flap_backoffs":
+		m.flapBackoffs++
+	case "flap_suppressed":
+		m.flapSuppressed++
+	case "self_disables":
+		m.selfDisables++
+	case "self_disabled_calls":
+		m.selfDisabledAnswers++
+	case "spike_extensions":
+		m.spikeExtensions++
+	case "podgroups_created":
+		m.podGroupsCreated++
+	case "podgroups_deleted":
+		m.podGroupsDeleted++
+	case "spike_checks_skipped":
+		m.spikeChecksSkipped++
+	case "spike_check_timeouts":
+		m.spikeCheckTimeouts++
+	case "policy_rejected":
+		m.policyRejected++
+	case "drains_started":
+		m.drainsStarted++
+	case "drain_reactivations":
+		m.drainReactivated++
+	case "activation_escalations":
+		m.activationEscalations++
+	case "advisory_filters":
+		m.advisoryFilters++
+	case "drain_decisions":
+		m.drainDecisions++
+	case "protocol_mismatches":
+		m.protocolMismatch++
+	case "pods_protected":
+		m.podsProtected++
+	case "member_pods_labeled":
+		m.memberPodsLabeled++
+	case "affinity_hints_added":
+		m.affinityHintsAdded++
+	case "affinity_hints_removed":
+		m.affinityHintsRemoved++
+	case "vpa_adjusted_checks":
+		m.vpaAdjusted++
+	case "decisions_exported":
+		m.decisionsExported++
+	case "decisions_dropped":
+		m.decisionsDropped++
+	case "history_stored":
+		m.historyStored++
+	case "history_dropped":
+		m.historyDropped++
+	case "history_segments_pruned":
+		m.historyPruned++
+	case "decision_files_uploaded":
+		m.decisionFilesUploaded++
+	case "decision_upload_failures":
+		m.decisionUploadFailures++
+	case "counter_snapshot_failures":
+		m.counterSnapshotFailures++
+	case "traces_exported":
+		m.tracesExported++
+	case "trace_export_failures":
+		m.traceExportFailures++
+	case "simulations":
+		m.simulations++
+	case "snapshots":
+		m.snapshots++
+	}
+}
+
+// SetState updates the current state label
+func (m *NEXUSMetrics) SetState(state string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.currentState = state
+}
+
+// SetThresholdProfile records the active spike detection profile
+func (m *NEXUSMetrics) SetThresholdProfile(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.thresholdProfile = name
+}
+
+// SetFlapBackoff records whether activation is suppressed by the flapping back-off
+func (m *NEXUSMetrics) SetFlapBackoff(active bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flapBackoff = active
+}
+
+// SetLatencyBudget records whether the extender p99 exceeds LATENCY_BUDGET
+// and whether NEXUS disabled itself because of it
+func (m *NEXUSMetrics) SetLatencyBudget(exceeded, disabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencyExceeded = exceeded
+	m.selfDisabled = disabled
+}
+
+// SetPolicies records the number of valid NexusPolicy resources loaded
+func (m *NEXUSMetrics) SetPolicies(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies = count
+}
+
+// SetHistoryStoreBytes records the size of the history store segments
+func (m *NEXUSMetrics) SetHistoryStoreBytes(bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.historyBytes = bytes
+}
+
+// AddPoliciesApplied counts gangs formed with a NexusPolicy
+func (m *NEXUSMetrics) AddPoliciesApplied(gangs int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policiesApplied += int64(gangs)
+}
+
+// SetSpikeClass records the class of the current spike ("" = none)
+func (m *NEXUSMetrics) SetSpikeClass(class string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spikeClass = class
+}
+
+// SetGangStage records a gang lifecycle transition, adding the time spent
+// in the previous stage
+func (m *NEXUSMetrics) SetGangStage(stage string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.gangStageSeconds[m.gangStage] += now.Sub(m.gangStageSince).Seconds()
+	m.gangStage = stage
+	m.gangStageSince = now
+}
+
+// SetCooldown records the quiet period before the gangs drain or dissolve
+func (m *NEXUSMetrics) SetCooldown(seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cooldownSeconds = seconds
+}
+
+// SetFormationStrategy records the gang formation strategy of the current episode ("" = none)
+func (m *NEXUSMetrics) SetFormationStrategy(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.formationStrategy = name
+}
+
+// SetFeatures records which optional subsystems are enabled
+func (m *NEXUSMetrics) SetFeatures(features map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.features = make(map[string]bool, len(features))
+	for name, enabled := range features {
+		m.features[name] = enabled
+	}
+}
+
+// SetActivationLevel records the activation level of the current episode
+// ("" = none)
+func (m *NEXUSMetrics) SetActivationLevel(level string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activationLevel = level
+}
+
+// IncrementSpikeClass counts an activation caused by a spike of the given class
+func (m *NEXUSMetrics) IncrementSpikeClass(class string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spikeClassEvents[class]++
+}
+
+// IncrementSpikeTrigger counts an activation caused by a signal from a source
+func (m *NEXUSMetrics) IncrementSpikeTrigger(signal, source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spikeTriggers[signal+"/"+source]++
+}
+
+// IncrementHPAActivation counts an activation caused by a scale-up of the
+// HPA namespace/name
+func (m *NEXUSMetrics) IncrementHPAActivation(target string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hpaActivations[target]++
+}
+
+// IncrementFallbackDecision counts a pod outside every gang scored by a
+// fallback policy during an episode
+func (m *NEXUSMetrics) IncrementFallbackDecision(policy string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallbackDecisions[policy]++
+}
+
+// RecordExtensionRefused counts a spike window extension refused for the
+// given reason
+func (m *NEXUSMetrics) RecordExtensionRefused(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extensionsRefused[reason]++
+}
+
+// AddGroupOverlaps counts services found in several coordination groups
+// at formation, by the resolution applied
+func (m *NEXUSMetrics) AddGroupOverlaps(resolution string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groupOverlaps[resolution] += int64(n)
+}
+
+// RecordArbitration counts a contested node score arbitrated by gang
+// priority, by outcome (won or yielded)
+func (m *NEXUSMetrics) RecordArbitration(outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.arbitrations[outcome]++
+}
+
+// RecordFreshGangFilter counts a Filter call of a gang starting fresh by
+// the policy applied
+func (m *NEXUSMetrics) RecordFreshGangFilter(policy string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.freshGangFilters[policy]++
+}
+
+const (
+	schedulingLatencyName = "nexus_pod_scheduling_latency_ms"
+	schedulingLatencyHelp = "Gang-member pod creation to binding by kube-scheduler, by scheduler state at binding (ms)"
+)
+
+// ObserveSchedulingLatency records a gang-member pod's creation to binding
+// latency under the scheduler state it was bound in
+func (m *NEXUSMetrics) ObserveSchedulingLatency(state string, ms float64) {
+	m.mu.Lock()
+	h := m.schedulingLatency[state]
+	if h == nil {
+		h = NewLatencyHistogram(schedulingLatencyName, schedulingLatencyHelp, SchedulingLatencyBuckets)
+		h.labels = fmt.Sprintf("state=\"%s\"", state)
+		m.schedulingLatency[state] = h
+	}
+	m.mu.Unlock()
+	h.Observe(ms)
+}
+
+// RecordAnnotationReview counts a validating webhook review of a
+// Deployment's nexus.io annotations by its result
+func (m *NEXUSMetrics) RecordAnnotationReview(result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.annotationReviews[result]++
+}
+
+// RecordSelfProbe counts a self-probe call by its result ("ok" or the
+// failure) and records whether the listener answered correctly
+func (m *NEXUSMetrics) RecordSelfProbe(result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.selfProbes[result]++
+	m.selfProbeUp = 0
+	if result == "ok" {
+		m.selfProbeUp = 1
+	}
+}
+
+// RecordAdminEpisodeRequest counts an admin activate or deactivate request
+// by its result
+func (m *NEXUSMetrics) RecordAdminEpisodeRequest(action, result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.episodeRequests[action+"/"+result]++
+}
+
+// IncrementFilterRejection counts a node rejected by Filter with the given reason code
+func (m *NEXUSMetrics) IncrementFilterRejection(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filterRejections[reason]++
+}
+
+// IncrementExtenderError counts a Filter/Prioritize call that failed with an error class
+func (m *NEXUSMetrics) IncrementExtenderError(endpoint, class string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extenderErrors[endpoint+"/"+class]++
+}
+
+// SetInfluenceBudget records the configured per-gang influence budget
+func (m *NEXUSMetrics) SetInfluenceBudget(budget int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.influenceBudget = budget
+}
+
+// SetInfluenceBudgetUsed updates the number of pods a gang has influenced
+func (m *NEXUSMetrics) SetInfluenceBudgetUsed(gangID string, used int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.influenceUsed[gangID] = used
+}
+
+// SetColocationPressure replaces the co-location pressure of the gang
+// member services; services reported before and missing now drop to 0,
+// so HPAs scaling on the signal keep reading a value between episodes
+func (m *NEXUSMetrics) SetColocationPressure(pressure map[string]float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for service := range m.colocationPressure {
+		m.colocationPressure[service] = 0
+	}
+	for service, value := range pressure {
+		m.colocationPressure[service] = value
+	}
+}
+
+// SetGangDensity replaces the member counts of the gangs by node; gangs
+// and nodes left out drop their series
+func (m *NEXUSMetrics) SetGangDensity(density map[string]map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gangDensity = density
+}
+
+// ResetInfluenceBudget clears per-gang budget consumption (gang dissolution)
+func (m *NEXUSMetrics) ResetInfluenceBudget() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.influenceUsed = make(map[string]int)
+	m.missingMembers = nil
+}
+
+// SetGangMissingMembers records how many members of a gang have no live pods
+func (m *NEXUSMetrics) SetGangMissingMembers(gangID string, missing int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.missingMembers == nil {
+		m.missingMembers = make(map[string]int)
+	}
+	m.missingMembers[gangID] = missing
+}
+
+// AddMissingMembers counts members found missing when a gang formed
+func (m *NEXUSMetrics) AddMissingMembers(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.missingMembersTotal += int64(n)
+}
+
+// AddIsolatedMembers counts group members found without a network path to
+// any other member when the graph was built
+func (m *NEXUSMetrics) AddIsolatedMembers(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.isolatedMembers += int64(n)
+}
+
+// AddFilteredServices counts services the service allowlist/denylist left
+// out of a gang at formation
+func (m *NEXUSMetrics) AddFilteredServices(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filteredServices += int64(n)
+}
+
+// AddUnschedulableScored counts candidate nodes Prioritize scored 0
+// because the pod cannot land on them
+func (m *NEXUSMetrics) AddUnschedulableScored(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unschedulableScored += int64(n)
+}
+
+// AddUnplacedReplicas counts planned replicas no node had room for
+func (m *NEXUSMetrics) AddUnplacedReplicas(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unplacedReplicas += int64(n)
+}
+
+// APIAuth is the state of the Kubernetes API credentials
+type APIAuth struct {
+	OK              bool
+	Unauthorized    int64
+	Rebuilds        int64
+	RebuildFailures int64
+}
+
+// SetAPIAuth records the state of the Kubernetes API credentials
+func (m *NEXUSMetrics) SetAPIAuth(auth APIAuth) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiAuth = auth
+}
+
+// SetAPIBreakerState updates the Kubernetes API circuit breaker gauge
+func (m *NEXUSMetrics) SetAPIBreakerState(state int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiBreakerState = state
+}
+
+// writeMetrics writes the in-process metrics in Prometheus format
+func (m *NEXUSMetrics) writeMetrics(w io.Writer) {
+	// Histograms
+	m.ActivationLatency.WritePrometheus(w)
+	m.ActivationPrewarm.WritePrometheus(w)
+	m.GangFormationLatency.WritePrometheus(w)
+	m.ExtenderFilterLatency.WritePrometheus(w)
+	m.ExtenderPrioritizeLatency.WritePrometheus(w)
+	m.SelfProbeLatency.WritePrometheus(w)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", schedulingLatencyName, schedulingLatencyHelp)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", schedulingLatencyName)
+	latencyStates := make([]string, 0, len(m.schedulingLatency))
+	for state := range m.schedulingLatency {
+		latencyStates = append(latencyStates, state)
+	}
+	sort.Strings(latencyStates)
+	for _, state := range latencyStates {
+		m.schedulingLatency[state].writeSamples(w)
+	}
+
+	// State gauge
+	stateValue := 0
+	switch m.currentState {
+	case "ACTIVE":
+		stateValue = 1
+	case "DRAINING":
+		stateValue = 2
+	}
+	fmt.Fprintf(w, "# HELP nexus_scheduler_state Current scheduler state (0=IDLE, 1=ACTIVE, 2=DRAINING)\n")
+	fmt.Fprintf(w, "# TYPE nexus_scheduler_state gauge\n")
+	fmt.Fprintf(w, "nexus_scheduler_state %d\n", stateValue)
+
+	fmt.Fprintf(w, "# HELP nexus_cooldown_seconds Quiet period before the gangs drain or dissolve\n")
+	fmt.Fprintf(w, "# TYPE nexus_cooldown_seconds gauge\n")
+	fmt.Fprintf(w, "nexus_cooldown_seconds %g\n", m.cooldownSeconds)
+
+	fmt.Fprintf(w, "# HELP nexus_threshold_profile Active spike detection threshold profile (always 1)\n")
+	fmt.Fprintf(w, "# TYPE nexus_threshold_profile gauge\n")
+	fmt.Fprintf(w, "nexus_threshold_profile{profile=\"%s\"} 1\n", m.thresholdProfile)
+
+	spikeClass := m.spikeClass
+	if spikeClass == "" {
+		spikeClass = "none"
+	}
+	fmt.Fprintf(w, "# HELP nexus_spike_class Class of the current spike (always 1, \"none\" outside spikes)\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_class gauge\n")
+	fmt.Fprintf(w, "nexus_spike_class{class=\"%s\"} 1\n", spikeClass)
+
+	activationLevel := strings.ToLower(m.activationLevel)
+	if activationLevel == "" {
+		activationLevel = "none"
+	}
+	fmt.Fprintf(w, "# HELP nexus_activation_level Activation level of the current episode (always 1, \"none\" outside spikes)\n")
+	fmt.Fprintf(w, "# TYPE nexus_activation_level gauge\n")
+	fmt.Fprintf(w, "nexus_activation_level{level=\"%s\"} 1\n", activationLevel)
+
+	fmt.Fprintf(w, "# HELP nexus_gang_stage Current gang lifecycle stage (always 1)\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_stage gauge\n")
+	fmt.Fprintf(w, "nexus_gang_stage{stage=\"%s\"} 1\n", m.gangStage)
+
+	formationStrategy := m.formationStrategy
+	if formationStrategy == "" {
+		formationStrategy = "none"
+	}
+	fmt.Fprintf(w, "# HELP nexus_gang_formation_strategy Gang formation strategy of the current episode (always 1, \"none\" outside spikes)\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_formation_strategy gauge\n")
+	fmt.Fprintf(w, "nexus_gang_formation_strategy{strategy=\"%s\"} 1\n", formationStrategy)
+
+	build := version.Get()
+	fmt.Fprintf(w, "# HELP nexus_build_info Build of the running binary (always 1)\n")
+	fmt.Fprintf(w, "# TYPE nexus_build_info gauge\n")
+	fmt.Fprintf(w, "nexus_build_info{version=\"%s\",git_sha=\"%s\",build_date=\"%s\",go_version=\"%s\",metrics_schema=\"%d\"} 1\n",
+		build.Version, build.GitSHA, build.BuildDate, build.GoVersion, SchemaVersion)
+
+	fmt.Fprintf(w, "# HELP nexus_feature_enabled Optional subsystems the instance runs with (1 = enabled)\n")
+	fmt.Fprintf(w, "# TYPE nexus_feature_enabled gauge\n")
+	features := make([]string, 0, len(m.features))
+	for name := range m.features {
+		features = append(features, name)
+	}
+	sort.Strings(features)
+	for _, name := range features {
+		enabled := 0
+		if m.features[name] {
+			enabled = 1
+		}
+		fmt.Fprintf(w, "nexus_feature_enabled{feature=\"%s\"} %d\n", name, enabled)
+	}
+
+	// Counters
+	fmt.Fprintf(w, "# HELP nexus_spike_events_total Total spike events detected\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_events_total counter\n")
+	fmt.Fprintf(w, "nexus_spike_events_total %d\n", m.spikeEvents)
+
+	fmt.Fprintf(w, "# HELP nexus_spike_class_events_total Activations by spike class\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_class_events_total counter\n")
+	classes := make([]string, 0, len(m.spikeClassEvents))
+	for class := range m.spikeClassEvents {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Fprintf(w, "nexus_spike_class_events_total{class=\"%s\"} %d\n", class, m.spikeClassEvents[class])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_spike_triggers_total Activations by the signal and source that caused them\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_triggers_total counter\n")
+	triggerKeys := make([]string, 0, len(m.spikeTriggers))
+	for key := range m.spikeTriggers {
+		triggerKeys = append(triggerKeys, key)
+	}
+	sort.Strings(triggerKeys)
+	for _, key := range triggerKeys {
+		signal, source, _ := strings.Cut(key, "/")
+		fmt.Fprintf(w, "nexus_spike_triggers_total{signal=\"%s\",source=\"%s\"} %d\n", signal, source, m.spikeTriggers[key])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_hpa_activations_total Activations caused by a scale-up of the HPA\n")
+	fmt.Fprintf(w, "# TYPE nexus_hpa_activations_total counter\n")
+	hpaTargets := make([]string, 0, len(m.hpaActivations))
+	for target := range m.hpaActivations {
+		hpaTargets = append(hpaTargets, target)
+	}
+	sort.Strings(hpaTargets)
+	for _, target := range hpaTargets {
+		namespace, name, _ := strings.Cut(target, "/")
+		fmt.Fprintf(w, "nexus_hpa_activations_total{namespace=\"%s\",hpa=\"%s\"} %d\n", namespace, name, m.hpaActivations[target])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_gangs_formed_total Total gangs formed\n")
+	fmt.Fprintf(w, "# TYPE nexus_gangs_formed_total counter\n")
+	fmt.Fprintf(w, "nexus_gangs_formed_total %d\n", m.gangsFormed)
+
+	fmt.Fprintf(w, "# HELP nexus_gangs_dissolved_total Total gangs dissolved\n")
+	fmt.Fprintf(w, "# TYPE nexus_gangs_dissolved_total counter\n")
+	fmt.Fprintf(w, "nexus_gangs_dissolved_total %d\n", m.gangsDisssolved)
+
+	fmt.Fprintf(w, "# HELP nexus_gang_stage_seconds_total Time spent in each gang lifecycle stage, including the current one\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_stage_seconds_total counter\n")
+	stageSeconds := make(map[string]float64, len(m.gangStageSeconds)+1)
+	for stage, seconds := range m.gangStageSeconds {
+		stageSeconds[stage] = seconds
+	}
+	stageSeconds[m.gangStage] += time.Since(m.gangStageSince).Seconds()
+	stages := make([]string, 0, len(stageSeconds))
+	for stage := range stageSeconds {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	for _, stage := range stages {
+		fmt.Fprintf(w, "nexus_gang_stage_seconds_total{stage=\"%s\"} %.3f\n", stage, stageSeconds[stage])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_gang_invalid_stage_transitions_total Gang lifecycle transitions rejected as invalid\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_invalid_stage_transitions_total counter\n")
+	fmt.Fprintf(w, "nexus_gang_invalid_stage_transitions_total %d\n", m.invalidTransitions)
+
+	fmt.Fprintf(w, "# HELP nexus_filter_calls_total Total filter endpoint calls\n")
+	fmt.Fprintf(w, "# TYPE nexus_filter_calls_total counter\n")
+	fmt.Fprintf(w, "nexus_filter_calls_total %d\n", m.filterCalls)
+
+	fmt.Fprintf(w, "# HELP nexus_prioritize_calls_total Total prioritize endpoint calls\n")
+	fmt.Fprintf(w, "# TYPE nexus_prioritize_calls_total counter\n")
+	fmt.Fprintf(w, "nexus_prioritize_calls_total %d\n", m.prioritizeCalls)
+
+	fmt.Fprintf(w, "# HELP nexus_state_changes_total Total IDLE/ACTIVE state transitions\n")
+	fmt.Fprintf(w, "# TYPE nexus_state_changes_total counter\n")
+	fmt.Fprintf(w, "nexus_state_changes_total %d\n", m.stateChanges)
+
+	// Kubernetes API guard
+	fmt.Fprintf(w, "# HELP nexus_api_retries_total Total retried Kubernetes API calls\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_retries_total counter\n")
+	fmt.Fprintf(w, "nexus_api_retries_total %d\n", m.apiRetries)
+
+	fmt.Fprintf(w, "# HELP nexus_api_throttled_total Kubernetes API calls answered with 429 Too Many Requests\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_throttled_total counter\n")
+	fmt.Fprintf(w, "nexus_api_throttled_total %d\n", m.apiThrottled)
+
+	fmt.Fprintf(w, "# HELP nexus_degraded_decisions_total Prioritize calls that could not read live gang member counts\n")
+	fmt.Fprintf(w, "# TYPE nexus_degraded_decisions_total counter\n")
+	fmt.Fprintf(w, "nexus_degraded_decisions_total %d\n", m.degradedDecisions)
+
+	fmt.Fprintf(w, "# HELP nexus_api_circuit_opened_total Times the Kubernetes API circuit breaker opened\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_circuit_opened_total counter\n")
+	fmt.Fprintf(w, "nexus_api_circuit_opened_total %d\n", m.apiCircuitOpened)
+
+	fmt.Fprintf(w, "# HELP nexus_api_circuit_rejected_total Kubernetes API calls rejected by the open breaker\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_circuit_rejected_total counter\n")
+	fmt.Fprintf(w, "nexus_api_circuit_rejected_total %d\n", m.apiCircuitRejected)
+
+	fmt.Fprintf(w, "# HELP nexus_api_circuit_state Kubernetes API circuit breaker state (0=CLOSED, 1=OPEN, 2=HALF_OPEN)\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_circuit_state gauge\n")
+	fmt.Fprintf(w, "nexus_api_circuit_state %d\n", m.apiBreakerState)
+
+	authOK := 0
+	if m.apiAuth.OK {
+		authOK = 1
+	}
+	fmt.Fprintf(w, "# HELP nexus_api_auth_ok 0 while Kubernetes API calls keep failing with 401 Unauthorized\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_auth_ok gauge\n")
+	fmt.Fprintf(w, "nexus_api_auth_ok %d\n", authOK)
+
+	fmt.Fprintf(w, "# HELP nexus_api_unauthorized_total Kubernetes API responses with 401 Unauthorized\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_unauthorized_total counter\n")
+	fmt.Fprintf(w, "nexus_api_unauthorized_total %d\n", m.apiAuth.Unauthorized)
+
+	fmt.Fprintf(w, "# HELP nexus_api_auth_rebuilds_total API client transports rebuilt from reloaded credentials\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_auth_rebuilds_total counter\n")
+	fmt.Fprintf(w, "nexus_api_auth_rebuilds_total %d\n", m.apiAuth.Rebuilds)
+
+	fmt.Fprintf(w, "# HELP nexus_api_auth_rebuild_failures_total Credential reloads that failed\n")
+	fmt.Fprintf(w, "# TYPE nexus_api_auth_rebuild_failures_total counter\n")
+	fmt.Fprintf(w, "nexus_api_auth_rebuild_failures_total %d\n", m.apiAuth.RebuildFailures)
+
+	// Ephemeral state budget
+	fmt.Fprintf(w, "# HELP nexus_budget_truncations_total Times a pod/gang budget limit truncated ephemeral state\n")
+	fmt.Fprintf(w, "# TYPE nexus_budget_truncations_total counter\n")
+	fmt.Fprintf(w, "nexus_budget_truncations_total %d\n", m.budgetTruncations)
+
+	fmt.Fprintf(w, "# HELP nexus_memory_bytes Go heap bytes currently allocated by the extender\n")
+	fmt.Fprintf(w, "# TYPE nexus_memory_bytes gauge\n")
+	fmt.Fprintf(w, "nexus_memory_bytes %d\n", processMemoryBytes())
+
+	fmt.Fprintf(w, "# HELP nexus_state_recoveries_total Spike episodes resumed after an extender restart\n")
+	fmt.Fprintf(w, "# TYPE nexus_state_recoveries_total counter\n")
+	fmt.Fprintf(w, "nexus_state_recoveries_total %d\n", m.stateRecoveries)
+
+	fmt.Fprintf(w, "# HELP nexus_keda_triggers_total Spike checks triggered by active KEDA ScaledObjects\n")
+	fmt.Fprintf(w, "# TYPE nexus_keda_triggers_total counter\n")
+	fmt.Fprintf(w, "nexus_keda_triggers_total %d\n", m.kedaTriggers)
+
+	fmt.Fprintf(w, "# HELP nexus_spikes_injected_total Synthetic spikes injected through the admin API\n")
+	fmt.Fprintf(w, "# TYPE nexus_spikes_injected_total counter\n")
+	fmt.Fprintf(w, "nexus_spikes_injected_total %d\n", m.spikesInjected)
+
+	fmt.Fprintf(w, "# HELP nexus_score_ties_broken_total Prioritize calls whose tied node scores were broken\n")
+	fmt.Fprintf(w, "# TYPE nexus_score_ties_broken_total counter\n")
+	fmt.Fprintf(w, "nexus_score_ties_broken_total %d\n", m.scoreTiesBroken)
+
+	fmt.Fprintf(w, "# HELP nexus_decision_logs_suppressed_total Per-decision Filter/Prioritize log lines dropped by sampling or rate limiting\n")
+	fmt.Fprintf(w, "# TYPE nexus_decision_logs_suppressed_total counter\n")
+	fmt.Fprintf(w, "nexus_decision_logs_suppressed_total %d\n", m.decisionLogsSuppressed)
+
+	fmt.Fprintf(w, "# HELP nexus_member_cache_warmups_total Pod lists that warmed the gang member counts\n")
+	fmt.Fprintf(w, "# TYPE nexus_member_cache_warmups_total counter\n")
+	fmt.Fprintf(w, "nexus_member_cache_warmups_total %d\n", m.memberCacheWarmups)
+
+	fmt.Fprintf(w, "# HELP nexus_member_cache_resyncs_total Gang member pod watches that ended and were re-listed\n")
+	fmt.Fprintf(w, "# TYPE nexus_member_cache_resyncs_total counter\n")
+	fmt.Fprintf(w, "nexus_member_cache_resyncs_total %d\n", m.memberCacheResyncs)
+
+	fmt.Fprintf(w, "# HELP nexus_node_failures_total Nodes that went NotReady or were deleted while gangs were active\n")
+	fmt.Fprintf(w, "# TYPE nexus_node_failures_total counter\n")
+	fmt.Fprintf(w, "nexus_node_failures_total %d\n", m.nodeFailures)
+
+	fmt.Fprintf(w, "# HELP nexus_placement_replans_total Placement plans recomputed after a preferred node became unavailable\n")
+	fmt.Fprintf(w, "# TYPE nexus_placement_replans_total counter\n")
+	fmt.Fprintf(w, "nexus_placement_replans_total %d\n", m.placementReplans)
+
+	fmt.Fprintf(w, "# HELP nexus_webhook_pods_labeled_total Pods labelled with nexus.io/gang-id by the webhook\n")
+	fmt.Fprintf(w, "# TYPE nexus_webhook_pods_labeled_total counter\n")
+	fmt.Fprintf(w, "nexus_webhook_pods_labeled_total %d\n", m.webhookPodsLabeled)
+
+	fmt.Fprintf(w, "# HELP nexus_annotation_reviews_total Deployment nexus.io annotation admission reviews, by result\n")
+	fmt.Fprintf(w, "# TYPE nexus_annotation_reviews_total counter\n")
+	reviewResults := make([]string, 0, len(m.annotationReviews))
+	for result := range m.annotationReviews {
+		reviewResults = append(reviewResults, result)
+	}
+	sort.Strings(reviewResults)
+	for _, result := range reviewResults {
+		fmt.Fprintf(w, "nexus_annotation_reviews_total{result=\"%s\"} %d\n", result, m.annotationReviews[result])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_self_probes_total Synthetic Filter calls of the self-probe to the extender listener, by result\n")
+	fmt.Fprintf(w, "# TYPE nexus_self_probes_total counter\n")
+	probeResults := make([]string, 0, len(m.selfProbes))
+	for result := range m.selfProbes {
+		probeResults = append(probeResults, result)
+	}
+	sort.Strings(probeResults)
+	for _, result := range probeResults {
+		fmt.Fprintf(w, "nexus_self_probes_total{result=\"%s\"} %d\n", result, m.selfProbes[result])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_self_probe_up Whether the last self-probe call got a valid Filter response (1) or not (0)\n")
+	fmt.Fprintf(w, "# TYPE nexus_self_probe_up gauge\n")
+	fmt.Fprintf(w, "nexus_self_probe_up %d\n", m.selfProbeUp)
+
+	fmt.Fprintf(w, "# HELP nexus_preexisting_pods_skipped_total Filter calls for pods created before activation (not influenced)\n")
+	fmt.Fprintf(w, "# TYPE nexus_preexisting_pods_skipped_total counter\n")
+	fmt.Fprintf(w, "nexus_preexisting_pods_skipped_total %d\n", m.preexistingSkipped)
+
+	fmt.Fprintf(w, "# HELP nexus_scheduler_profile_skipped_total Extender calls for pods of scheduler profiles outside SCHEDULER_NAMES\n")
+	fmt.Fprintf(w, "# TYPE nexus_scheduler_profile_skipped_total counter\n")
+	fmt.Fprintf(w, "nexus_scheduler_profile_skipped_total %d\n", m.schedulerSkipped)
+
+	fmt.Fprintf(w, "# HELP nexus_system_pods_skipped_total Extender and webhook calls for system pods NEXUS never influences\n")
+	fmt.Fprintf(w, "# TYPE nexus_system_pods_skipped_total counter\n")
+	fmt.Fprintf(w, "nexus_system_pods_skipped_total %d\n", m.systemPodsSkipped)
+
+	fmt.Fprintf(w, "# HELP nexus_filter_rejections_total Nodes rejected by Filter, by reason code\n")
+	fmt.Fprintf(w, "# TYPE nexus_filter_rejections_total counter\n")
+	reasons := make([]string, 0, len(m.filterRejections))
+	for reason := range m.filterRejections {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(w, "nexus_filter_rejections_total{reason=\"%s\"} %d\n", reason, m.filterRejections[reason])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_prioritize_unschedulable_nodes_total Candidate nodes Prioritize scored 0 because the pod cannot land on them\n")
+	fmt.Fprintf(w, "# TYPE nexus_prioritize_unschedulable_nodes_total counter\n")
+	fmt.Fprintf(w, "nexus_prioritize_unschedulable_nodes_total %d\n", m.unschedulableScored)
+
+	fmt.Fprintf(w, "# HELP nexus_fallback_decisions_total Prioritize calls for pods outside every gang during an episode, by fallback policy\n")
+	fmt.Fprintf(w, "# TYPE nexus_fallback_decisions_total counter\n")
+	policies := make([]string, 0, len(m.fallbackDecisions))
+	for policy := range m.fallbackDecisions {
+		policies = append(policies, policy)
+	}
+	sort.Strings(policies)
+	for _, policy := range policies {
+		fmt.Fprintf(w, "nexus_fallback_decisions_total{policy=\"%s\"} %d\n", policy, m.fallbackDecisions[policy])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_extender_errors_total Filter/Prioritize calls NEXUS could not evaluate, by endpoint and error class\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_errors_total counter\n")
+	errorKeys := make([]string, 0, len(m.extenderErrors))
+	for key := range m.extenderErrors {
+		errorKeys = append(errorKeys, key)
+	}
+	sort.Strings(errorKeys)
+	for _, key := range errorKeys {
+		endpoint, class, _ := strings.Cut(key, "/")
+		fmt.Fprintf(w, "nexus_extender_errors_total{endpoint=\"%s\",class=\"%s\"} %d\n", endpoint, class, m.extenderErrors[key])
+	}
+
+	// Per-episode influence budget
+	fmt.Fprintf(w, "# HELP nexus_influence_budget_pods Configured pods influenced per gang per episode (0=unlimited)\n")
+	fmt.Fprintf(w, "# TYPE nexus_influence_budget_pods gauge\n")
+	fmt.Fprintf(w, "nexus_influence_budget_pods %d\n", m.influenceBudget)
+
+	fmt.Fprintf(w, "# HELP nexus_influence_budget_used Pods influenced by each active gang this episode\n")
+	fmt.Fprintf(w, "# TYPE nexus_influence_budget_used gauge\n")
+	gangIDs := make([]string, 0, len(m.influenceUsed))
+	for gangID := range m.influenceUsed {
+		gangIDs = append(gangIDs, gangID)
+	}
+	sort.Strings(gangIDs)
+	for _, gangID := range gangIDs {
+		fmt.Fprintf(w, "nexus_influence_budget_used{gang=\"%s\"} %d\n", gangID, m.influenceUsed[gangID])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_gang_colocation_pressure Share of a member service's gang peers running on nodes without a replica of it (0-1)\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_colocation_pressure gauge\n")
+	pressureServices := make([]string, 0, len(m.colocationPressure))
+	for service := range m.colocationPressure {
+		pressureServices = append(pressureServices, service)
+	}
+	sort.Strings(pressureServices)
+	for _, service := range pressureServices {
+		fmt.Fprintf(w, "nexus_gang_colocation_pressure{service=\"%s\"} %s\n", service, formatFloat(m.colocationPressure[service]))
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_gang_members_on_node Members of each active gang running on each node\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_members_on_node gauge\n")
+	densityGangs := make([]string, 0, len(m.gangDensity))
+	for gangID := range m.gangDensity {
+		densityGangs = append(densityGangs, gangID)
+	}
+	sort.Strings(densityGangs)
+	for _, gangID := range densityGangs {
+		densityNodes := make([]string, 0, len(m.gangDensity[gangID]))
+		for node := range m.gangDensity[gangID] {
+			densityNodes = append(densityNodes, node)
+		}
+		sort.Strings(densityNodes)
+		for _, node := range densityNodes {
+			fmt.Fprintf(w, "nexus_gang_members_on_node{node=\"%s\",gang=\"%s\"} %d\n", node, gangID, m.gangDensity[gangID][node])
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_gang_missing_members Members of each active gang without live pods\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_missing_members gauge\n")
+	gangIDs = gangIDs[:0]
+	degraded := 0
+	for gangID, missing := range m.missingMembers {
+		gangIDs = append(gangIDs, gangID)
+		if missing > 0 {
+			degraded++
+		}
+	}
+	sort.Strings(gangIDs)
+	for _, gangID := range gangIDs {
+		fmt.Fprintf(w, "nexus_gang_missing_members{gang=\"%s\"} %d\n", gangID, m.missingMembers[gangID])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_degraded_gangs Active gangs with at least one missing member\n")
+	fmt.Fprintf(w, "# TYPE nexus_degraded_gangs gauge\n")
+	fmt.Fprintf(w, "nexus_degraded_gangs %d\n", degraded)
+
+	fmt.Fprintf(w, "# HELP nexus_gang_missing_members_total Gang members without live pods when their gang formed\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_missing_members_total counter\n")
+	fmt.Fprintf(w, "nexus_gang_missing_members_total %d\n", m.missingMembersTotal)
+
+	fmt.Fprintf(w, "# HELP nexus_graph_isolated_members_total Group members NetworkPolicies left without a path to any other member (GRAPH_NETWORK_POLICY)\n")
+	fmt.Fprintf(w, "# TYPE nexus_graph_isolated_members_total counter\n")
+	fmt.Fprintf(w, "nexus_graph_isolated_members_total %d\n", m.isolatedMembers)
+
+	fmt.Fprintf(w, "# HELP nexus_gang_services_filtered_total Services left out of gangs at formation by the service allowlist/denylist\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_services_filtered_total counter\n")
+	fmt.Fprintf(w, "nexus_gang_services_filtered_total %d\n", m.filteredServices)
+
+	fmt.Fprintf(w, "# HELP nexus_gang_members_arrived_total Missing gang members whose first pod arrived during the episode\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_members_arrived_total counter\n")
+	fmt.Fprintf(w, "nexus_gang_members_arrived_total %d\n", m.missingArrived)
+
+	fmt.Fprintf(w, "# HELP nexus_placement_plans_total Gang placement plans computed at formation\n")
+	fmt.Fprintf(w, "# TYPE nexus_placement_plans_total counter\n")
+	fmt.Fprintf(w, "nexus_placement_plans_total %d\n", m.placementPlans)
+
+	fmt.Fprintf(w, "# HELP nexus_placement_plan_failures_total Formations placed greedily because the plan inputs could not be listed\n")
+	fmt.Fprintf(w, "# TYPE nexus_placement_plan_failures_total counter\n")
+	fmt.Fprintf(w, "nexus_placement_plan_failures_total %d\n", m.placementPlanFailures)
+
+	fmt.Fprintf(w, "# HELP nexus_placement_plan_unplaced_total Planned replicas no node had capacity for\n")
+	fmt.Fprintf(w, "# TYPE nexus_placement_plan_unplaced_total counter\n")
+	fmt.Fprintf(w, "nexus_placement_plan_unplaced_total %d\n", m.unplacedReplicas)
+
+	fmt.Fprintf(w, "# HELP nexus_planned_decisions_total Prioritize decisions scored toward a placement plan\n")
+	fmt.Fprintf(w, "# TYPE nexus_planned_decisions_total counter\n")
+	fmt.Fprintf(w, "nexus_planned_decisions_total %d\n", m.plannedDecisions)
+
+	fmt.Fprintf(w, "# HELP nexus_anchored_decisions_total Prioritize decisions scored toward the gang's anchor members\n")
+	fmt.Fprintf(w, "# TYPE nexus_anchored_decisions_total counter\n")
+	fmt.Fprintf(w, "nexus_anchored_decisions_total %d\n", m.anchoredDecisions)
+
+	fmt.Fprintf(w, "# HELP nexus_influence_budget_exhausted_total Decisions returned no-opinion because the gang budget was spent\n")
+	fmt.Fprintf(w, "# TYPE nexus_influence_budget_exhausted_total counter\n")
+	fmt.Fprintf(w, "nexus_influence_budget_exhausted_total %d\n", m.influenceExhausted)
+
+	flapBackoff := 0
+	if m.flapBackoff {
+		flapBackoff = 1
+	}
+	fmt.Fprintf(w, "# HELP nexus_flap_backoff 1 while activation is suppressed after repeated flapping\n")
+	fmt.Fprintf(w, "# TYPE nexus_flap_backoff gauge\n")
+	fmt.Fprintf(w, "nexus_flap_backoff %d\n", flapBackoff)
+
+	fmt.Fprintf(w, "# HELP nexus_flap_backoffs_total Times activation flapping put NEXUS into back-off\n")
+	fmt.Fprintf(w, "# TYPE nexus_flap_backoffs_total counter\n")
+	fmt.Fprintf(w, "nexus_flap_backoffs_total %d\n", m.flapBackoffs)
+
+	fmt.Fprintf(w, "# HELP nexus_flap_suppressed_activations_total Spikes not acted on because of the flapping back-off\n")
+	fmt.Fprintf(w, "# TYPE nexus_flap_suppressed_activations_total counter\n")
+	fmt.Fprintf(w, "nexus_flap_suppressed_activations_total %d\n", m.flapSuppressed)
+
+	latencyExceeded, selfDisabled := 0, 0
+	if m.latencyExceeded {
+		latencyExceeded = 1
+	}
+	if m.selfDisabled {
+		selfDisabled = 1
+	}
+	fmt.Fprintf(w, "# HELP nexus_latency_budget_exceeded 1 while the Filter or Prioritize p99 exceeds LATENCY_BUDGET\n")
+	fmt.Fprintf(w, "# TYPE nexus_latency_budget_exceeded gauge\n")
+	fmt.Fprintf(w, "nexus_latency_budget_exceeded %d\n", latencyExceeded)
+
+	fmt.Fprintf(w, "# HELP nexus_self_disabled 1 while NEXUS answers every call with no opinion after a sustained latency regression\n")
+	fmt.Fprintf(w, "# TYPE nexus_self_disabled gauge\n")
+	fmt.Fprintf(w, "nexus_self_disabled %d\n", selfDisabled)
+
+	fmt.Fprintf(w, "# HELP nexus_self_disables_total Times a sustained latency regression made NEXUS disable itself\n")
+	fmt.Fprintf(w, "# TYPE nexus_self_disables_total counter\n")
+	fmt.Fprintf(w, "nexus_self_disables_total %d\n", m.selfDisables)
+
+	fmt.Fprintf(w, "# HELP nexus_self_disabled_calls_total Filter and Prioritize calls answered with no opinion while self-disabled\n")
+	fmt.Fprintf(w, "# TYPE nexus_self_disabled_calls_total counter\n")
+	fmt.Fprintf(w, "nexus_self_disabled_calls_total %d\n", m.selfDisabledAnswers)
+
+	fmt.Fprintf(w, "# HELP nexus_spike_extensions_total Cooldown checks and drain re-detections that extended a spike episode\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_extensions_total counter\n")
+	fmt.Fprintf(w, "nexus_spike_extensions_total %d\n", m.spikeExtensions)
+
+	fmt.Fprintf(w, "# HELP nexus_spike_extensions_refused_total Spike episode extensions refused, by reason (limit or threshold)\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_extensions_refused_total counter\n")
+	refusals := make([]string, 0, len(m.extensionsRefused))
+	for reason := range m.extensionsRefused {
+		refusals = append(refusals, reason)
+	}
+	sort.Strings(refusals)
+	for _, reason := range refusals {
+		fmt.Fprintf(w, "nexus_spike_extensions_refused_total{reason=\"%s\"} %d\n", reason, m.extensionsRefused[reason])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_group_overlaps_total Services declared in several coordination groups at gang formation, by resolution\n")
+	fmt.Fprintf(w, "# TYPE nexus_group_overlaps_total counter\n")
+	resolutions := make([]string, 0, len(m.groupOverlaps))
+	for resolution := range m.groupOverlaps {
+		resolutions = append(resolutions, resolution)
+	}
+	sort.Strings(resolutions)
+	for _, resolution := range resolutions {
+		fmt.Fprintf(w, "nexus_group_overlaps_total{resolution=\"%s\"} %d\n", resolution, m.groupOverlaps[resolution])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_gang_arbitrations_total Node scores contested by another gang and arbitrated by gang priority, by outcome\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_arbitrations_total counter\n")
+	outcomes := make([]string, 0, len(m.arbitrations))
+	for outcome := range m.arbitrations {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	for _, outcome := range outcomes {
+		fmt.Fprintf(w, "nexus_gang_arbitrations_total{outcome=\"%s\"} %d\n", outcome, m.arbitrations[outcome])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_fresh_gang_filters_total Filter calls for gangs no node runs members of yet, by FRESH_GANG_PLACEMENT policy\n")
+	fmt.Fprintf(w, "# TYPE nexus_fresh_gang_filters_total counter\n")
+	freshPolicies := make([]string, 0, len(m.freshGangFilters))
+	for policy := range m.freshGangFilters {
+		freshPolicies = append(freshPolicies, policy)
+	}
+	sort.Strings(freshPolicies)
+	for _, policy := range freshPolicies {
+		fmt.Fprintf(w, "nexus_fresh_gang_filters_total{policy=\"%s\"} %d\n", policy, m.freshGangFilters[policy])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_admin_episode_requests_total Admin episode activate/deactivate requests, by action and result\n")
+	fmt.Fprintf(w, "# TYPE nexus_admin_episode_requests_total counter\n")
+	requestKeys := make([]string, 0, len(m.episodeRequests))
+	for key := range m.episodeRequests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Strings(requestKeys)
+	for _, key := range requestKeys {
+		action, result, _ := strings.Cut(key, "/")
+		fmt.Fprintf(w, "nexus_admin_episode_requests_total{action=\"%s\",result=\"%s\"} %d\n", action, result, m.episodeRequests[key])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_podgroups_created_total Coscheduling PodGroups created for active gangs\n")
+	fmt.Fprintf(w, "# TYPE nexus_podgroups_created_total counter\n")
+	fmt.Fprintf(w, "nexus_podgroups_created_total %d\n", m.podGroupsCreated)
+
+	fmt.Fprintf(w, "# HELP nexus_podgroups_deleted_total Coscheduling PodGroups deleted after the gangs dissolved\n")
+	fmt.Fprintf(w, "# TYPE nexus_podgroups_deleted_total counter\n")
+	fmt.Fprintf(w, "nexus_podgroups_deleted_total %d\n", m.podGroupsDeleted)
+
+	fmt.Fprintf(w, "# HELP nexus_spike_checks_skipped_total Spike check cycles skipped because the previous one was still running\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_checks_skipped_total counter\n")
+	fmt.Fprintf(w, "nexus_spike_checks_skipped_total %d\n", m.spikeChecksSkipped)
+
+	fmt.Fprintf(w, "# HELP nexus_spike_check_timeouts_total Spike detections abandoned after exceeding SPIKE_CHECK_TIMEOUT\n")
+	fmt.Fprintf(w, "# TYPE nexus_spike_check_timeouts_total counter\n")
+	fmt.Fprintf(w, "nexus_spike_check_timeouts_total %d\n", m.spikeCheckTimeouts)
+
+	fmt.Fprintf(w, "# HELP nexus_policies Valid NexusPolicy resources loaded\n")
+	fmt.Fprintf(w, "# TYPE nexus_policies gauge\n")
+	fmt.Fprintf(w, "nexus_policies %d\n", m.policies)
+
+	fmt.Fprintf(w, "# HELP nexus_policies_rejected_total NexusPolicy resources ignored as invalid\n")
+	fmt.Fprintf(w, "# TYPE nexus_policies_rejected_total counter\n")
+	fmt.Fprintf(w, "nexus_policies_rejected_total %d\n", m.policyRejected)
+
+	fmt.Fprintf(w, "# HELP nexus_policy_gangs_total Gangs formed with a NexusPolicy applied\n")
+	fmt.Fprintf(w, "# TYPE nexus_policy_gangs_total counter\n")
+	fmt.Fprintf(w, "nexus_policy_gangs_total %d\n", m.policiesApplied)
+
+	fmt.Fprintf(w, "# HELP nexus_drains_started_total Spike episodes that entered the post-spike drain period\n")
+	fmt.Fprintf(w, "# TYPE nexus_drains_started_total counter\n")
+	fmt.Fprintf(w, "nexus_drains_started_total %d\n", m.drainsStarted)
+
+	fmt.Fprintf(w, "# HELP nexus_drain_reactivations_total Drain periods interrupted by a new spike (back to ACTIVE)\n")
+	fmt.Fprintf(w, "# TYPE nexus_drain_reactivations_total counter\n")
+	fmt.Fprintf(w, "nexus_drain_reactivations_total %d\n", m.drainReactivated)
+
+	fmt.Fprintf(w, "# HELP nexus_activation_escalations_total Episodes escalated from ADVISORY to ENFORCING by a severe spike\n")
+	fmt.Fprintf(w, "# TYPE nexus_activation_escalations_total counter\n")
+	fmt.Fprintf(w, "nexus_activation_escalations_total %d\n", m.activationEscalations)
+
+	fmt.Fprintf(w, "# HELP nexus_advisory_filter_calls_total Filter calls answered with every node kept while ADVISORY\n")
+	fmt.Fprintf(w, "# TYPE nexus_advisory_filter_calls_total counter\n")
+	fmt.Fprintf(w, "nexus_advisory_filter_calls_total %d\n", m.advisoryFilters)
+
+	fmt.Fprintf(w, "# HELP nexus_drain_decisions_total Prioritize decisions made with reduced locality while draining\n")
+	fmt.Fprintf(w, "# TYPE nexus_drain_decisions_total counter\n")
+	fmt.Fprintf(w, "nexus_drain_decisions_total %d\n", m.drainDecisions)
+
+	fmt.Fprintf(w, "# HELP nexus_extender_protocol_mismatches_total Extender requests whose node format differs from EXTENDER_PROTOCOL\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_protocol_mismatches_total counter\n")
+	fmt.Fprintf(w, "nexus_extender_protocol_mismatches_total %d\n", m.protocolMismatch)
+
+	fmt.Fprintf(w, "# HELP nexus_pods_eviction_protected_total Gang member pods annotated against descheduler eviction\n")
+	fmt.Fprintf(w, "# TYPE nexus_pods_eviction_protected_total counter\n")
+	fmt.Fprintf(w, "nexus_pods_eviction_protected_total %d\n", m.podsProtected)
+
+	fmt.Fprintf(w, "# HELP nexus_gang_member_pods_labeled_total Gang member pods given the nexus.io/gang and nexus.io/episode labels\n")
+	fmt.Fprintf(w, "# TYPE nexus_gang_member_pods_labeled_total counter\n")
+	fmt.Fprintf(w, "nexus_gang_member_pods_labeled_total %d\n", m.memberPodsLabeled)
+
+	fmt.Fprintf(w, "# HELP nexus_affinity_hints_added_total Gang member deployments given a soft pod affinity hint\n")
+	fmt.Fprintf(w, "# TYPE nexus_affinity_hints_added_total counter\n")
+	fmt.Fprintf(w, "nexus_affinity_hints_added_total %d\n", m.affinityHintsAdded)
+
+	fmt.Fprintf(w, "# HELP nexus_affinity_hints_removed_total Deployments whose affinity hint was removed\n")
+	fmt.Fprintf(w, "# TYPE nexus_affinity_hints_removed_total counter\n")
+	fmt.Fprintf(w, "nexus_affinity_hints_removed_total %d\n", m.affinityHintsRemoved)
+
+	fmt.Fprintf(w, "# HELP nexus_vpa_adjusted_checks_total Filter capacity checks using a VPA recommendation above current requests\n")
+	fmt.Fprintf(w, "# TYPE nexus_vpa_adjusted_checks_total counter\n")
+	fmt.Fprintf(w, "nexus_vpa_adjusted_checks_total %d\n", m.vpaAdjusted)
+
+	// Decision export
+	fmt.Fprintf(w, "# HELP nexus_decisions_exported_total Prioritize decisions written to the decision export\n")
+	fmt.Fprintf(w, "# TYPE nexus_decisions_exported_total counter\n")
+	fmt.Fprintf(w, "nexus_decisions_exported_total %d\n", m.decisionsExported)
+
+	fmt.Fprintf(w, "# HELP nexus_decisions_dropped_total Decisions not exported because the buffer was full or the write failed\n")
+	fmt.Fprintf(w, "# TYPE nexus_decisions_dropped_total counter\n")
+	fmt.Fprintf(w, "nexus_decisions_dropped_total %d\n", m.decisionsDropped)
+
+	fmt.Fprintf(w, "# HELP nexus_decision_files_uploaded_total Rotated decision files uploaded to S3\n")
+	fmt.Fprintf(w, "# TYPE nexus_decision_files_uploaded_total counter\n")
+	fmt.Fprintf(w, "nexus_decision_files_uploaded_total %d\n", m.decisionFilesUploaded)
+
+	fmt.Fprintf(w, "# HELP nexus_decision_upload_failures_total Decision file uploads that failed (file kept on the volume)\n")
+	fmt.Fprintf(w, "# TYPE nexus_decision_upload_failures_total counter\n")
+	fmt.Fprintf(w, "nexus_decision_upload_failures_total %d\n", m.decisionUploadFailures)
+
+	// History store
+	fmt.Fprintf(w, "# HELP nexus_history_store_entries_total Episode and decision entries written to the history store\n")
+	fmt.Fprintf(w, "# TYPE nexus_history_store_entries_total counter\n")
+	fmt.Fprintf(w, "nexus_history_store_entries_total %d\n", m.historyStored)
+
+	fmt.Fprintf(w, "# HELP nexus_history_store_dropped_total Entries not stored because the buffer was full or the write failed\n")
+	fmt.Fprintf(w, "# TYPE nexus_history_store_dropped_total counter\n")
+	fmt.Fprintf(w, "nexus_history_store_dropped_total %d\n", m.historyDropped)
+
+	fmt.Fprintf(w, "# HELP nexus_history_store_segments_pruned_total Segments deleted by the retention age or size limit\n")
+	fmt.Fprintf(w, "# TYPE nexus_history_store_segments_pruned_total counter\n")
+	fmt.Fprintf(w, "nexus_history_store_segments_pruned_total %d\n", m.historyPruned)
+
+	fmt.Fprintf(w, "# HELP nexus_history_store_bytes Size of the history store segments\n")
+	fmt.Fprintf(w, "# TYPE nexus_history_store_bytes gauge\n")
+	fmt.Fprintf(w, "nexus_history_store_bytes %d\n", m.historyBytes)
+
+	fmt.Fprintf(w, "# HELP nexus_episode_traces_exported_total Spike episodes exported as OTLP traces\n")
+	fmt.Fprintf(w, "# TYPE nexus_episode_traces_exported_total counter\n")
+	fmt.Fprintf(w, "nexus_episode_traces_exported_total %d\n", m.tracesExported)
+
+	fmt.Fprintf(w, "# HELP nexus_episode_trace_export_failures_total Episode traces the OTLP endpoint did not accept\n")
+	fmt.Fprintf(w, "# TYPE nexus_episode_trace_export_failures_total counter\n")
+	fmt.Fprintf(w, "nexus_episode_trace_export_failures_total %d\n", m.traceExportFailures)
+
+	fmt.Fprintf(w, "# HELP nexus_simulations_total What-if evaluations served by POST /simulate (not counted as Filter/Prioritize activity)\n")
+	fmt.Fprintf(w, "# TYPE nexus_simulations_total counter\n")
+	fmt.Fprintf(w, "nexus_simulations_total %d\n", m.simulations)
+
+	fmt.Fprintf(w, "# HELP nexus_snapshots_total Cluster snapshots served by GET /admin/snapshot\n")
+	fmt.Fprintf(w, "# TYPE nexus_snapshots_total counter\n")
+	fmt.Fprintf(w, "nexus_snapshots_total %d\n", m.snapshots)
+
+	fmt.Fprintf(w, "# HELP nexus_counter_snapshot_failures_total Counter snapshot writes that failed (METRICS_SNAPSHOT_PATH)\n")
+	fmt.Fprintf(w, "# TYPE nexus_counter_snapshot_failures_total counter\n")
+	fmt.Fprintf(w, "nexus_counter_snapshot_failures_total %d\n", m.counterSnapshotFailures)
+
+	m.slo.write(w)
+	m.sweep.write(w)
+	m.shadow.write(w)
+	m.payload.write(w)
+	m.conns.write(w)
+	m.influence.write(w)
+	m.detector.write(w)
+}
+
+// formatFloat formats a float for Prometheus output
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprintf("%.3f", f)
+}
+
+// processMemoryBytes reports the Go heap currently in use by the extender
+func processMemoryBytes() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
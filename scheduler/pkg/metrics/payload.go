@@ -0,0 +1,84 @@
+/*
+Extender Payload Metrics
+========================
+Bytes of the kube-scheduler extender requests and responses, per endpoint
+and direction: as sent over the wire (per content encoding, identity or
+gzip) and after decoding. The ratio of the two is the compression saving
+EXTENDER_GZIP buys; the decoded size is the JSON NEXUS serialises.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// payloadKey identifies a payload series
+type payloadKey struct {
+	endpoint  string // filter, prioritize
+	direction string // request, response
+}
+
+// payloadMetrics holds the extender payload byte counts
+type payloadMetrics struct {
+	mu      sync.Mutex
+	wire    map[payloadKey]map[string]int64 // → encoding → bytes
+	decoded map[payloadKey]int64
+}
+
+// ObservePayload counts one extender request or response body
+func (m *NEXUSMetrics) ObservePayload(endpoint, direction, encoding string, wireBytes, decodedBytes int64) {
+	m.payload.mu.Lock()
+	defer m.payload.mu.Unlock()
+	if m.payload.wire == nil {
+		m.payload.wire = make(map[payloadKey]map[string]int64)
+		m.payload.decoded = make(map[payloadKey]int64)
+	}
+	key := payloadKey{endpoint: endpoint, direction: direction}
+	if m.payload.wire[key] == nil {
+		m.payload.wire[key] = make(map[string]int64)
+	}
+	m.payload.wire[key][encoding] += wireBytes
+	m.payload.decoded[key] += decodedBytes
+}
+
+// write emits the payload metric families in Prometheus format
+func (p *payloadMetrics) write(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]payloadKey, 0, len(p.decoded))
+	for key := range p.decoded {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].direction < keys[j].direction
+	})
+
+	fmt.Fprintf(w, "# HELP nexus_extender_payload_bytes_total Extender request and response bytes on the wire, by content encoding\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_payload_bytes_total counter\n")
+	for _, key := range keys {
+		encodings := make([]string, 0, len(p.wire[key]))
+		for encoding := range p.wire[key] {
+			encodings = append(encodings, encoding)
+		}
+		sort.Strings(encodings)
+		for _, encoding := range encodings {
+			fmt.Fprintf(w, "nexus_extender_payload_bytes_total{endpoint=\"%s\",direction=\"%s\",encoding=\"%s\"} %d\n",
+				key.endpoint, key.direction, encoding, p.wire[key][encoding])
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_extender_payload_decoded_bytes_total Extender request and response bytes after decoding (JSON size)\n")
+	fmt.Fprintf(w, "# TYPE nexus_extender_payload_decoded_bytes_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(w, "nexus_extender_payload_decoded_bytes_total{endpoint=\"%s\",direction=\"%s\"} %d\n",
+			key.endpoint, key.direction, p.decoded[key])
+	}
+}
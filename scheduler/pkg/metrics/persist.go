@@ -0,0 +1,206 @@
+/*
+Persistent Counters
+===================
+Counters and histograms restart from zero with the process, which breaks
+long experiment series that read raw totals. A counter snapshot records
+the value of every counter and histogram series as exposed (gauges are
+current state and are not kept):
+
+  {"nexus_gangs_formed_total": {"nexus_gangs_formed_total": 12},
+   "nexus_extender_filter_latency_ms": {"nexus_extender_filter_latency_ms_bucket{le=\"1\"}": 840, ...}}
+
+After RestoreCounters, every series is exposed as its in-process value
+plus the restored value, so totals continue across restarts. Series of
+the snapshot the process has not produced yet are exposed with their
+restored value, and kept in the next snapshot.
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CounterSnapshot maps metric family → series (name and labels) → value
+type CounterSnapshot map[string]map[string]float64
+
+// restoredCounters holds the values restored from a snapshot
+type restoredCounters struct {
+	mu      sync.Mutex
+	offsets CounterSnapshot
+}
+
+// RestoreCounters continues counters and histograms from a snapshot
+func (m *NEXUSMetrics) RestoreCounters(snapshot CounterSnapshot) {
+	m.restored.mu.Lock()
+	defer m.restored.mu.Unlock()
+	m.restored.offsets = snapshot
+}
+
+// CounterSnapshot returns the current counter and histogram series,
+// restored values included
+func (m *NEXUSMetrics) CounterSnapshot() CounterSnapshot {
+	var buf bytes.Buffer
+	m.WriteAllMetrics(&buf)
+
+	snapshot := make(CounterSnapshot)
+	family, persisted := "", false
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, metricType, ok := typeLine(line); ok {
+			family, persisted = name, metricType == "counter" || metricType == "histogram"
+			continue
+		}
+		if !persisted || strings.HasPrefix(line, "#") {
+			continue
+		}
+		series, value, ok := parseSample(line)
+		if !ok {
+			continue
+		}
+		if snapshot[family] == nil {
+			snapshot[family] = make(map[string]float64)
+		}
+		snapshot[family][series] = value
+	}
+	return snapshot
+}
+
+// WriteAllMetrics writes all NEXUS metrics in Prometheus format
+func (m *NEXUSMetrics) WriteAllMetrics(w io.Writer) {
+	m.restored.mu.Lock()
+	offsets := m.restored.offsets
+	m.restored.mu.Unlock()
+	if len(offsets) == 0 {
+		m.writeMetrics(w)
+		return
+	}
+
+	var buf bytes.Buffer
+	m.writeMetrics(&buf)
+	writeWithOffsets(w, &buf, offsets)
+}
+
+// writeWithOffsets copies the exposition in r to w, adding the restored
+// value to each counter and histogram series
+func writeWithOffsets(w io.Writer, r io.Reader, offsets CounterSnapshot) {
+	family := ""
+	seen := make(map[string]bool)
+	flush := func() {
+		// Restored series of the family not produced by this process yet
+		pending := make([]string, 0)
+		for series := range offsets[family] {
+			if !seen[series] {
+				pending = append(pending, series)
+			}
+		}
+		sort.Strings(pending)
+		for _, series := range pending {
+			fmt.Fprintf(w, "%s %s\n", series, formatSample(offsets[family][series]))
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# HELP ") {
+			flush()
+			family, seen = "", make(map[string]bool)
+		}
+		if name, _, ok := typeLine(line); ok {
+			family = name
+		}
+
+		series, value, ok := parseSample(line)
+		offset, restored := offsets[family][series]
+		if !ok || !restored {
+			fmt.Fprintln(w, line)
+			continue
+		}
+		seen[series] = true
+		fmt.Fprintf(w, "%s %s\n", series, formatSample(value+offset))
+	}
+	flush()
+}
+
+// typeLine parses a "# TYPE <name> <type>" line
+func typeLine(line string) (string, string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "#" || fields[1] != "TYPE" {
+		return "", "", false
+	}
+	return fields[2], fields[3], true
+}
+
+// parseSample splits a sample line into series and value
+func parseSample(line string) (string, float64, bool) {
+	if strings.HasPrefix(line, "#") {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(line, ' ')
+	if i <= 0 {
+		return "", 0, false
+	}
+	value, err := strconv.ParseFloat(line[i+1:], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return line[:i], value, true
+}
+
+// formatSample formats a restored value, without decimals for whole numbers
+func formatSample(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// SaveCounterSnapshot writes a snapshot to path, replacing it atomically
+func SaveCounterSnapshot(path string, snapshot CounterSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode counter snapshot: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".counters-*")
+	if err != nil {
+		return fmt.Errorf("failed to create counter snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write counter snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write counter snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace counter snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadCounterSnapshot reads a snapshot from path; a missing file is an
+// empty snapshot
+func LoadCounterSnapshot(path string) (CounterSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read counter snapshot: %w", err)
+	}
+	var snapshot CounterSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode counter snapshot: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,60 @@
+/*
+Shadow Scorer Metrics
+=====================
+Per shadow scorer (SHADOW_SCORERS): the Prioritize decisions it evaluated
+and how many of them preferred a different node than the primary formula.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// shadowMetrics holds the per-scorer shadow decision counts
+type shadowMetrics struct {
+	mu        sync.Mutex
+	decisions map[string]int64
+	divergent map[string]int64
+}
+
+// ObserveShadowDecision counts one decision evaluated by a shadow scorer
+func (m *NEXUSMetrics) ObserveShadowDecision(scorer string, divergent bool) {
+	m.shadow.mu.Lock()
+	defer m.shadow.mu.Unlock()
+	if m.shadow.decisions == nil {
+		m.shadow.decisions = make(map[string]int64)
+		m.shadow.divergent = make(map[string]int64)
+	}
+	m.shadow.decisions[scorer]++
+	if divergent {
+		m.shadow.divergent[scorer]++
+	}
+}
+
+// write emits the shadow scorer metric families in Prometheus format
+func (s *shadowMetrics) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.decisions))
+	for name := range s.decisions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "# HELP nexus_shadow_decisions_total Prioritize decisions evaluated by each shadow scorer\n")
+	fmt.Fprintf(w, "# TYPE nexus_shadow_decisions_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "nexus_shadow_decisions_total{scorer=\"%s\"} %d\n", name, s.decisions[name])
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_shadow_divergent_decisions_total Decisions where a shadow scorer preferred a different node than the primary\n")
+	fmt.Fprintf(w, "# TYPE nexus_shadow_divergent_decisions_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "nexus_shadow_divergent_decisions_total{scorer=\"%s\"} %d\n", name, s.divergent[name])
+	}
+}
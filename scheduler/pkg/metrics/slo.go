@@ -0,0 +1,136 @@
+/*
+SLO Metrics
+===========
+Per coordination group latency SLO gauges (current episode) and
+evaluated/violating seconds counters, labelled by whether NEXUS had
+influenced the group's pods, so evaluations can compare SLO-violation
+minutes with and without NEXUS placement.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// SLOStatus is the latest latency SLO evaluation of a coordination group
+type SLOStatus struct {
+	TargetMs         float64 `json:"targetMs"`
+	P95Ms            float64 `json:"p95Ms"`
+	Compliant        bool    `json:"compliant"`
+	BurnRate         float64 `json:"burnRate"`         // episode violation share / error budget
+	ViolationMinutes float64 `json:"violationMinutes"` // this episode
+}
+
+// sloKey identifies a per-group SLO counter series
+type sloKey struct {
+	group      string
+	influenced bool
+}
+
+// sloMetrics holds the per-group SLO gauges and counters
+type sloMetrics struct {
+	mu        sync.Mutex
+	status    map[string]SLOStatus // group → latest evaluation (current episode)
+	evaluated map[sloKey]float64   // seconds evaluated
+	violated  map[sloKey]float64   // seconds over target
+}
+
+// SetSLOStatus records the latest SLO evaluation of a group
+func (m *NEXUSMetrics) SetSLOStatus(group string, status SLOStatus) {
+	m.slo.mu.Lock()
+	defer m.slo.mu.Unlock()
+	if m.slo.status == nil {
+		m.slo.status = make(map[string]SLOStatus)
+	}
+	m.slo.status[group] = status
+}
+
+// SLOStatuses returns the latest SLO evaluation of every tracked group
+func (m *NEXUSMetrics) SLOStatuses() map[string]SLOStatus {
+	m.slo.mu.Lock()
+	defer m.slo.mu.Unlock()
+	statuses := make(map[string]SLOStatus, len(m.slo.status))
+	for group, status := range m.slo.status {
+		statuses[group] = status
+	}
+	return statuses
+}
+
+// ClearSLOStatus drops the per-episode SLO gauges (gang dissolution)
+func (m *NEXUSMetrics) ClearSLOStatus() {
+	m.slo.mu.Lock()
+	defer m.slo.mu.Unlock()
+	m.slo.status = nil
+}
+
+// AddSLOSeconds counts evaluated and violating seconds for a group, split by
+// whether NEXUS had influenced any of the group's pods this episode
+func (m *NEXUSMetrics) AddSLOSeconds(group string, influenced bool, evaluated, violated float64) {
+	m.slo.mu.Lock()
+	defer m.slo.mu.Unlock()
+	if m.slo.evaluated == nil {
+		m.slo.evaluated = make(map[sloKey]float64)
+		m.slo.violated = make(map[sloKey]float64)
+	}
+	key := sloKey{group: group, influenced: influenced}
+	m.slo.evaluated[key] += evaluated
+	m.slo.violated[key] += violated
+}
+
+// write emits the SLO metric families in Prometheus format
+func (s *sloMetrics) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]string, 0, len(s.status))
+	for group := range s.status {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	gauge := func(name, help string, value func(SLOStatus) float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		for _, group := range groups {
+			fmt.Fprintf(w, "%s{group=\"%s\"} %s\n", name, group, formatFloat(value(s.status[group])))
+		}
+	}
+	gauge("nexus_slo_target_ms", "Declared p95 latency target per coordination group (ms)",
+		func(st SLOStatus) float64 { return st.TargetMs })
+	gauge("nexus_slo_p95_ms", "Last observed p95 latency per coordination group (ms)",
+		func(st SLOStatus) float64 { return st.P95Ms })
+	gauge("nexus_slo_compliant", "1 if the group's last observed p95 met its target, else 0",
+		func(st SLOStatus) float64 {
+			if st.Compliant {
+				return 1
+			}
+			return 0
+		})
+	gauge("nexus_slo_burn_rate", "Share of the episode over target divided by the error budget (1 = budget spent exactly)",
+		func(st SLOStatus) float64 { return st.BurnRate })
+
+	keys := make([]sloKey, 0, len(s.evaluated))
+	for key := range s.evaluated {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].group != keys[j].group {
+			return keys[i].group < keys[j].group
+		}
+		return !keys[i].influenced && keys[j].influenced
+	})
+
+	counter := func(name, help string, values map[sloKey]float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", name)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s{group=\"%s\",influenced=\"%t\"} %s\n", name, key.group, key.influenced, formatFloat(values[key]))
+		}
+	}
+	counter("nexus_slo_evaluated_seconds_total", "Seconds of spike episodes in which the group's SLO was evaluated", s.evaluated)
+	counter("nexus_slo_violation_seconds_total", "Seconds of spike episodes in which the group's p95 exceeded its target", s.violated)
+}
@@ -0,0 +1,67 @@
+/*
+Weight Sweep Metrics
+====================
+Outcome of the influence sweep experiment (WEIGHT_SWEEP): the factor the
+current episode runs with, and per factor the number of episodes and the
+mean p95 latency observed while they ran.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// SweepResult is the accumulated outcome of one sweep factor
+type SweepResult struct {
+	Factor    float64 `json:"factor"`
+	Episodes  int     `json:"episodes"`
+	Samples   int     `json:"samples"`
+	MeanP95Ms float64 `json:"meanP95Ms"` // 0 until the first sample
+}
+
+// sweepMetrics holds the latest weight sweep snapshot
+type sweepMetrics struct {
+	mu      sync.Mutex
+	enabled bool
+	current float64
+	results []SweepResult
+}
+
+// SetWeightSweep records the current sweep factor and per-factor results
+func (m *NEXUSMetrics) SetWeightSweep(current float64, results []SweepResult) {
+	m.sweep.mu.Lock()
+	defer m.sweep.mu.Unlock()
+	m.sweep.enabled = true
+	m.sweep.current = current
+	m.sweep.results = append([]SweepResult(nil), results...)
+}
+
+// write emits the weight sweep metric families in Prometheus format
+func (s *sweepMetrics) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP nexus_weight_sweep_factor Influence factor applied to Prioritize scores this episode\n")
+	fmt.Fprintf(w, "# TYPE nexus_weight_sweep_factor gauge\n")
+	if s.enabled {
+		fmt.Fprintf(w, "nexus_weight_sweep_factor %s\n", formatFloat(s.current))
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_weight_sweep_episodes_total Spike episodes run with each influence factor\n")
+	fmt.Fprintf(w, "# TYPE nexus_weight_sweep_episodes_total counter\n")
+	for _, r := range s.results {
+		fmt.Fprintf(w, "nexus_weight_sweep_episodes_total{factor=\"%s\"} %d\n", strconv.FormatFloat(r.Factor, 'g', -1, 64), r.Episodes)
+	}
+
+	fmt.Fprintf(w, "# HELP nexus_weight_sweep_p95_ms Mean p95 latency during episodes run with each influence factor (ms)\n")
+	fmt.Fprintf(w, "# TYPE nexus_weight_sweep_p95_ms gauge\n")
+	for _, r := range s.results {
+		if r.Samples > 0 {
+			fmt.Fprintf(w, "nexus_weight_sweep_p95_ms{factor=\"%s\"} %s\n", strconv.FormatFloat(r.Factor, 'g', -1, 64), formatFloat(r.MeanP95Ms))
+		}
+	}
+}
@@ -0,0 +1,398 @@
+/*
+Fake Prometheus
+===============
+An httptest-based stand-in for the Prometheus query API (GET
+/api/v1/query and /api/v1/query_range), for detector tests and for local
+runs without a monitoring stack (nexus-scheduler --fake-prometheus).
+Every query is answered from programmable series, keyed by the exact
+PromQL string:
+
+  Set(query, v)              → one sample without labels
+  SetVector(query, label, m) → one sample per label value
+  SetRaw(query, body)        → the body as is (parse failures)
+  SetStatus(query, code)     → an HTTP error for that query
+  SetRange(query, points)    → the points of a range query (replay)
+  SetDefault(v)              → one sample for every query without series
+
+A range query without SetRange points repeats the instant sample at every
+step.
+
+Unknown queries return an empty vector (or the SetDefault sample), except
+"up", which succeeds so the detector's reachability probe passes. SetDelay injects latency before
+every answer (bounded by the request context), SetDown fails every
+request with 503 as an unreachable Prometheus would.
+
+Series can also be driven from outside, by query or by a name registered
+with Alias:
+
+  PUT /-/series?query=<promql>  body: value
+  PUT /-/series/<name>          body: value
+  PUT /-/delay                  body: duration, e.g. 300ms
+*/
+
+package promtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// answer is how the fake responds to one query
+type answer struct {
+	body   string // raw response body ("" = built from samples)
+	status int    // HTTP status (0 = 200)
+	label  string
+	values map[string]float64 // label value → sample ("" = no labels)
+}
+
+// Server is a fake Prometheus query API
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	answers  map[string]answer
+	fallback *answer // answer to queries without series (nil = empty vector)
+	ranges   map[string][]Point
+	aliases  map[string]string // name → query
+	delay    time.Duration
+	down     bool
+	queries  map[string]int // query → times asked
+}
+
+// NewServer starts a fake Prometheus on a random local port
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Listen starts a fake Prometheus on addr (e.g. 127.0.0.1:9095)
+func Listen(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := newServer()
+	s.Server = httptest.NewUnstartedServer(s)
+	s.Server.Listener.Close()
+	s.Server.Listener = l
+	s.Server.Start()
+	return s, nil
+}
+
+// newServer creates the unstarted fake
+func newServer() *Server {
+	return &Server{
+		answers: make(map[string]answer),
+		ranges:  make(map[string][]Point),
+		aliases: make(map[string]string),
+		queries: make(map[string]int),
+	}
+}
+
+// Set answers query with a single sample without labels
+func (s *Server) Set(query string, value float64) {
+	s.setAnswer(query, answer{values: map[string]float64{"": value}})
+}
+
+// SetVector answers query with one sample per value of label
+func (s *Server) SetVector(query, label string, values map[string]float64) {
+	copied := make(map[string]float64, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	s.setAnswer(query, answer{label: label, values: copied})
+}
+
+// SetRaw answers query with body as is
+func (s *Server) SetRaw(query, body string) {
+	s.setAnswer(query, answer{body: body})
+}
+
+// SetStatus answers query with an HTTP error status
+func (s *Server) SetStatus(query string, status int) {
+	s.setAnswer(query, answer{status: status})
+}
+
+// SetDefault answers every query without its own series with a single
+// sample without labels ("up" still reports the fake as up)
+func (s *Server) SetDefault(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = &answer{values: map[string]float64{"": value}}
+}
+
+// Point is one sample of a range query
+type Point struct {
+	At    time.Time
+	Value float64
+}
+
+// SetRange answers range queries for query with the points in the range
+func (s *Server) SetRange(query string, points []Point) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ranges[query] = append([]Point(nil), points...)
+}
+
+// Clear removes the answer for query (an empty vector from then on)
+func (s *Server) Clear(query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.answers, query)
+	delete(s.ranges, query)
+}
+
+// Alias registers a name for query, for PUT /-/series/<name>
+func (s *Server) Alias(name, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases[name] = query
+}
+
+// SetDelay makes every answer wait d (0 = none)
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// SetDown makes every request fail with 503 while down
+func (s *Server) SetDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// Queries returns how often query was asked
+func (s *Server) Queries(query string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries[query]
+}
+
+func (s *Server) setAnswer(query string, a answer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answers[query] = a
+}
+
+// ServeHTTP answers instant and range queries and the control endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/-/") {
+		s.serveControl(w, r)
+		return
+	}
+	if r.URL.Path != "/api/v1/query" && r.URL.Path != "/api/v1/query_range" {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.FormValue("query")
+	s.mu.Lock()
+	s.queries[query]++
+	a, ok := s.answers[query]
+	points, ranged := s.ranges[query]
+	fallback, delay, down := s.fallback, s.delay, s.down
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if down {
+		http.Error(w, "fake prometheus is down", http.StatusServiceUnavailable)
+		return
+	}
+	switch {
+	case !ok && query == "up":
+		a = answer{label: "job", values: map[string]float64{"fake": 1}}
+	case !ok && fallback != nil:
+		a = *fallback
+	}
+	if a.status != 0 {
+		http.Error(w, http.StatusText(a.status), a.status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if a.body != "" {
+		io.WriteString(w, a.body)
+		return
+	}
+	if r.URL.Path == "/api/v1/query_range" {
+		start, end, step, err := parseRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !ranged {
+			points = repeatedPoints(a, start, end, step)
+		}
+		json.NewEncoder(w).Encode(matrixResponse(points, start, end))
+		return
+	}
+	json.NewEncoder(w).Encode(vectorResponse(a))
+}
+
+// serveControl handles PUT /-/series and PUT /-/delay
+func (s *Server) serveControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "use PUT", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<10))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	raw := strings.TrimSpace(string(body))
+
+	if r.URL.Path == "/-/delay" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetDelay(d)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	query := r.URL.Query().Get("query")
+	if name := strings.TrimPrefix(r.URL.Path, "/-/series/"); name != r.URL.Path {
+		s.mu.Lock()
+		query = s.aliases[name]
+		s.mu.Unlock()
+		if query == "" {
+			http.Error(w, fmt.Sprintf("unknown series %q", name), http.StatusNotFound)
+			return
+		}
+	} else if r.URL.Path != "/-/series" || query == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.Set(query, value)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sample is one element of an instant vector
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+// vectorResponse builds a successful instant-vector response
+func vectorResponse(a answer) interface{} {
+	keys := make([]string, 0, len(a.values))
+	for k := range a.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	now := float64(time.Now().UnixNano()) / 1e9
+	result := make([]sample, 0, len(keys))
+	for _, k := range keys {
+		metric := map[string]string{}
+		if k != "" {
+			metric[a.label] = k
+		}
+		result = append(result, sample{
+			Metric: metric,
+			Value:  [2]interface{}{now, strconv.FormatFloat(a.values[k], 'f', -1, 64)},
+		})
+	}
+	return map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "vector", "result": result},
+	}
+}
+
+// parseRange reads the start, end (unix seconds) and step (seconds or a
+// duration) of a range query
+func parseRange(r *http.Request) (time.Time, time.Time, time.Duration, error) {
+	start, err := parseUnix(r.FormValue("start"))
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := parseUnix(r.FormValue("end"))
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid end: %w", err)
+	}
+	raw := r.FormValue("step")
+	step, err := time.ParseDuration(raw)
+	if err != nil {
+		seconds, ferr := strconv.ParseFloat(raw, 64)
+		if ferr != nil {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid step %q", raw)
+		}
+		step = time.Duration(seconds * float64(time.Second))
+	}
+	if step <= 0 || end.Before(start) {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid range")
+	}
+	return start, end, step, nil
+}
+
+// parseUnix parses a unix timestamp in (fractional) seconds
+func parseUnix(raw string) (time.Time, error) {
+	seconds, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(seconds*1e9)), nil
+}
+
+// repeatedPoints repeats the unlabelled instant sample at every step
+func repeatedPoints(a answer, start, end time.Time, step time.Duration) []Point {
+	value, ok := a.values[""]
+	if !ok {
+		return nil
+	}
+	var points []Point
+	for at := start; !at.After(end); at = at.Add(step) {
+		points = append(points, Point{At: at, Value: value})
+	}
+	return points
+}
+
+// matrixResponse builds a successful range response with one series
+// holding the points between start and end
+func matrixResponse(points []Point, start, end time.Time) interface{} {
+	values := make([][2]interface{}, 0, len(points))
+	for _, p := range points {
+		if p.At.Before(start) || p.At.After(end) {
+			continue
+		}
+		values = append(values, [2]interface{}{
+			float64(p.At.UnixNano()) / 1e9,
+			strconv.FormatFloat(p.Value, 'f', -1, 64),
+		})
+	}
+	result := []map[string]interface{}{}
+	if len(values) > 0 {
+		result = append(result, map[string]interface{}{"metric": map[string]string{}, "values": values})
+	}
+	return map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "matrix", "result": result},
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Anchor Members
+==============
+Locality treats the members of a gang symmetrically: a node attracts a
+pod by how many members of any service run on it. For gangs built around
+a stateful service (redis-cart behind cartservice, a DNS or cache tier)
+the data decides where the gang should be, not the stateless replicas
+that happen to run somewhere. A NexusPolicy can name such members:
+
+  spec:
+    group: cart-flow
+    anchors: [redis-cart]
+
+While a gang has anchors, locality counts only the anchors' pods on each
+candidate (the nearest topology level of TOPOLOGY_LOCALITY_LEVELS pulls
+towards the anchor's rack or zone), so every other member is scored
+toward the anchors rather than toward each other. Anchor pods themselves
+get no locality: they are the fixed points the gang forms around and
+land by resources alone. Anchors that are not members of the gang are
+ignored; while no anchor pod runs on a candidate the symmetric counts
+apply. Anchors do not apply to spread placement or while a placement plan
+steers the gang (GANG_PLACEMENT=planned).
+*/
+
+package scorer
+
+import (
+	"context"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+)
+
+// anchorCounts returns the anchor pods of the gang on each candidate node,
+// and false when the gang has no anchors or none runs on a candidate. For
+// an anchor pod the counts are empty. Nodes whose count could not be read
+// live are added to degraded.
+func (ns *NodeScorer) anchorCounts(ctx context.Context, pod *v1.Pod, nodes []v1.Node, g *gang.Gang, degraded map[string]bool) (map[string]int, bool) {
+	if g == nil {
+		return nil, false
+	}
+	anchors := g.Anchors()
+	if len(anchors) == 0 {
+		return nil, false
+	}
+	if isAnchor(pod, anchors) {
+		return map[string]int{}, true
+	}
+
+	counts := make(map[string]int, len(nodes))
+	placed := false
+	for i := range nodes {
+		count, live := ns.anchorCount(ctx, &nodes[i], g, anchors)
+		counts[nodes[i].Name] = count
+		placed = placed || count > 0
+		if !live {
+			degraded[nodes[i].Name] = true
+		}
+	}
+	return counts, placed
+}
+
+// anchorCount returns the anchor pods of the gang on a node and whether
+// the count was read live
+func (ns *NodeScorer) anchorCount(ctx context.Context, node *v1.Node, g *gang.Gang, anchors []string) (int, bool) {
+	if !ns.gangManager.NodeAvailable(node.Name) {
+		return 0, true
+	}
+	if count, warm := ns.gangManager.WarmServiceCount(g, node.Name, anchors); warm {
+		return count, true
+	}
+	return ns.listedCount(ctx, node, g.ID+"/anchors", anchors)
+}
+
+// isAnchor reports whether the pod belongs to one of the anchor services
+func isAnchor(pod *v1.Pod, anchors []string) bool {
+	service := graph.ExtractServiceName(pod.Name)
+	for _, anchor := range anchors {
+		if strings.EqualFold(service, anchor) {
+			return true
+		}
+	}
+	return false
+}
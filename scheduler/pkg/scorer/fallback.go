@@ -0,0 +1,120 @@
+/*
+Non-Member Scoring
+==================
+During an episode, a pod that belongs to no gang is scored by the
+fallback policy of its deployment (NON_GANG_FALLBACK, or the
+nexus.io/fallback pod template annotation) instead of getting no opinion:
+
+  resource → the resource and utilization components only, as for a
+             gang member without locality
+  spread   → resource scoring plus the headroom below the node holding
+             the most gang capacity, so the pod keeps off the nodes the
+             gangs are filling
+
+A node's gang capacity is the members of every active gang running on it
+plus the slots their placement plans still reserve there, put through the
+locality curve. Excluded nodes and nodes past MAX_NODES_SCANNED score 0,
+as for gang members.
+*/
+
+package scorer
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/kube"
+)
+
+// ScoreNonMember scores nodes for a pod outside every gang: resource
+// scoring only, and with spread the headroom below the node holding the
+// most gang capacity
+func (ns *NodeScorer) ScoreNonMember(ctx context.Context, pod *v1.Pod, nodes *v1.NodeList, spread bool) []ScoreBreakdown {
+	candidates := make([]v1.Node, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		if !kube.NodeExcluded(&nodes.Items[i], ns.excludeLabel) {
+			candidates = append(candidates, nodes.Items[i])
+		}
+	}
+	scanned, _ := kube.CapNodes(candidates, ns.maxNodes)
+
+	var reserved map[string]int
+	degraded := make(map[string]bool)
+	ceiling := int64(0)
+	if spread {
+		reserved, degraded = ns.gangCapacity(ctx, scanned)
+		for i := range scanned {
+			if value := ns.localityValue(float64(reserved[scanned[i].Name])); value > ceiling {
+				ceiling = value
+			}
+		}
+	}
+	inScan := make(map[string]bool, len(scanned))
+	for i := range scanned {
+		inScan[scanned[i].Name] = true
+	}
+
+	breakdown := make([]ScoreBreakdown, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		breakdown[i] = ScoreBreakdown{Host: node.Name}
+		if kube.NodeExcluded(node, ns.excludeLabel) {
+			breakdown[i].Excluded = true
+			continue
+		}
+		if !inScan[node.Name] {
+			continue
+		}
+
+		localityScore := int64(0)
+		if spread {
+			localityScore = ceiling - ns.localityValue(float64(reserved[node.Name]))
+		}
+		resourceScore := ns.calculateResourceScore(node, pod)
+		utilizationPenalty := ns.calculateUtilizationPenalty(ctx, node)
+		totalScore := localityScore + resourceScore - utilizationPenalty
+		if totalScore < 0 {
+			totalScore = 0
+		}
+
+		klog.V(3).Infof("Non-member score for node %s: headroom=%d, resource=%d, utilization=-%d, total=%d",
+			node.Name, localityScore, resourceScore, utilizationPenalty, totalScore)
+		breakdown[i] = ScoreBreakdown{
+			Host:        node.Name,
+			Locality:    localityScore,
+			Resource:    resourceScore,
+			Utilization: -utilizationPenalty,
+			Total:       totalScore,
+			Scanned:     true,
+			Degraded:    degraded[node.Name],
+		}
+	}
+
+	normalize(breakdown)
+	return breakdown
+}
+
+// gangCapacity returns the members of every active gang on each node
+// plus the slots their placement plans still reserve there, and the
+// nodes whose member count was not read live
+func (ns *NodeScorer) gangCapacity(ctx context.Context, nodes []v1.Node) (map[string]int, map[string]bool) {
+	reserved := make(map[string]int, len(nodes))
+	degraded := make(map[string]bool)
+	for _, g := range ns.gangManager.ActiveGangs() {
+		counts, stale := ns.countGangMembers(ctx, nodes, g)
+		plan := ns.gangManager.Plan(g)
+		for i := range nodes {
+			name := nodes[i].Name
+			reserved[name] += counts[name]
+			if plan != nil {
+				reserved[name] += plan.Remaining(name, counts[name])
+			}
+			if stale[name] {
+				degraded[name] = true
+			}
+		}
+	}
+	return reserved, degraded
+}
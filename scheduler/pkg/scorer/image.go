@@ -0,0 +1,94 @@
+/*
+Image Locality
+==============
+Co-locating a gang member with its dependencies buys nothing while the
+pod sits in ContainerCreating pulling its image, and during a spike the
+pull often takes longer than the scheduling. With IMAGE_LOCALITY_WEIGHT
+set, nodes that already hold the pod's images (node.Status.Images) earn
+
+  Image = IMAGE_LOCALITY_WEIGHT × cached images / images of the pod
+
+counting init containers. References are compared fully qualified, so
+"frontend:v1" in a pod spec matches "docker.io/library/frontend:v1" on
+the node. Digest references only match the same digest.
+*/
+
+package scorer
+
+import (
+	"math"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// calculateImageScore scores a node by the share of the pod's container
+// images it already holds
+func (ns *NodeScorer) calculateImageScore(node *v1.Node, pod *v1.Pod) int64 {
+	if ns.imageWeight <= 0 {
+		return 0
+	}
+	images := podImages(pod)
+	if len(images) == 0 {
+		return 0
+	}
+
+	cached := make(map[string]bool)
+	for _, image := range node.Status.Images {
+		for _, name := range image.Names {
+			cached[normalizedImage(name)] = true
+		}
+	}
+	present := 0
+	for _, image := range images {
+		if cached[image] {
+			present++
+		}
+	}
+	return int64(math.Round(ns.imageWeight * float64(present) / float64(len(images))))
+}
+
+// podImages returns the distinct, fully qualified images of the pod's
+// init and regular containers
+func podImages(pod *v1.Pod) []string {
+	seen := make(map[string]bool)
+	images := make([]string, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			if c.Image == "" {
+				continue
+			}
+			if image := normalizedImage(c.Image); !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// normalizedImage returns the fully qualified form of an image reference:
+// "nginx" → "docker.io/library/nginx:latest", a digest reference without
+// its tag
+func normalizedImage(image string) string {
+	ref, digest, _ := strings.Cut(image, "@")
+	domain, path, found := strings.Cut(ref, "/")
+	if !found || (!strings.ContainsAny(domain, ".:") && domain != "localhost") {
+		domain, path = "docker.io", ref
+	}
+	if domain == "docker.io" && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+
+	name, tag := path, ""
+	if i := strings.LastIndex(path, ":"); i > strings.LastIndex(path, "/") {
+		name, tag = path[:i], path[i+1:]
+	}
+	switch {
+	case digest != "":
+		return domain + "/" + name + "@" + digest
+	case tag == "":
+		return domain + "/" + name + ":latest"
+	}
+	return domain + "/" + name + ":" + tag
+}
@@ -0,0 +1,118 @@
+/*
+Last Known Good Member Counts
+=============================
+Spikes load the control plane, so the pod lists behind gang member counts
+are the first calls the API server throttles (429) or the API guard cuts
+off. Instead of scoring such a node as if no gang members ran on it, the
+scorer falls back to the last count it read for the node and gang, when
+that is younger than SCORE_LAST_GOOD_MAX_AGE, and marks the node's score
+breakdown degraded. A node without a usable count still scores no
+locality, but is marked degraded too, so the loss of knowledge is never
+silent. Counts are forgotten when the gangs dissolve.
+
+Counts are kept in stripes keyed by node-name hash, so sharded scoring
+(see shard.go) does not serialise on a single lock.
+*/
+
+package scorer
+
+import (
+	"sync"
+	"time"
+)
+
+// memberCountKey identifies a gang member count
+type memberCountKey struct {
+	node   string
+	gangID string
+}
+
+// memberCount is a gang member count read from the API server
+type memberCount struct {
+	count int
+	at    time.Time
+}
+
+// lastGoodStripes is the number of independently locked count stripes
+const lastGoodStripes = 16
+
+// lastGoodStripe holds the counts of the nodes hashing to it
+type lastGoodStripe struct {
+	mu     sync.Mutex
+	counts map[memberCountKey]memberCount
+}
+
+// lastGoodCounts remembers the latest successful gang member counts
+type lastGoodCounts struct {
+	maxAge  time.Duration // 0 = no fallback
+	stripes [lastGoodStripes]lastGoodStripe
+}
+
+// stripe returns the stripe holding a node's counts
+func (lg *lastGoodCounts) stripe(node string) *lastGoodStripe {
+	return &lg.stripes[shardOf(node, lastGoodStripes)]
+}
+
+// store records a count read at now
+func (lg *lastGoodCounts) store(node, gangID string, count int, now time.Time) {
+	if lg.maxAge <= 0 {
+		return
+	}
+	st := lg.stripe(node)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.counts == nil {
+		st.counts = make(map[memberCountKey]memberCount)
+	}
+	st.counts[memberCountKey{node: node, gangID: gangID}] = memberCount{count: count, at: now}
+}
+
+// load returns the last count for the node and gang if it is still usable
+func (lg *lastGoodCounts) load(node, gangID string, now time.Time) (memberCount, bool) {
+	st := lg.stripe(node)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	c, ok := st.counts[memberCountKey{node: node, gangID: gangID}]
+	if !ok || now.Sub(c.at) > lg.maxAge {
+		return memberCount{}, false
+	}
+	return c, true
+}
+
+// reset forgets every count
+func (lg *lastGoodCounts) reset() {
+	for i := range lg.stripes {
+		st := &lg.stripes[i]
+		st.mu.Lock()
+		st.counts = nil
+		st.mu.Unlock()
+	}
+}
+
+// ResetMemberCounts forgets the last known good member counts (gangs dissolved)
+func (ns *NodeScorer) ResetMemberCounts() {
+	ns.lastGood.reset()
+}
+
+// SeedMemberCounts records a gang's member counts by node as last known
+// good, so a throttled first decision does not score without locality
+// (activation pre-warm)
+func (ns *NodeScorer) SeedMemberCounts(gangID string, counts map[string]int) {
+	now := time.Now()
+	for node, count := range counts {
+		ns.lastGood.store(node, gangID, count, now)
+	}
+}
+
+// ForgetNode forgets the last known good member counts of a node (node
+// unavailable)
+func (ns *NodeScorer) ForgetNode(node string) {
+	st := ns.lastGood.stripe(node)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for key := range st.counts {
+		if key.node == node {
+			delete(st.counts, key)
+		}
+	}
+}
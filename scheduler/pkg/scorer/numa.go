@@ -0,0 +1,109 @@
+/*
+NUMA Alignment
+==============
+Co-location packs a gang onto few nodes. For members that pin CPUs
+(Guaranteed QoS with whole-CPU requests, which the kubelet's static CPU
+manager gives exclusive cores) on nodes whose topology manager insists
+on NUMA alignment, packing can backfire: once the node's NUMA cells are
+taken by pinned peers the kubelet rejects the pod at admission
+(TopologyAffinityError) instead of running it unaligned.
+
+The node object does not report the kubelet's topology manager policy,
+so nodes declare it in labels (set by hand or by a node-feature-discovery
+rule):
+
+  nexus.io/topology-manager-policy   none, best-effort, restricted or
+                                     single-numa-node
+  nexus.io/numa-nodes                NUMA cells of the node (default 1)
+
+With NUMA_ALIGNMENT_WEIGHT set, Prioritize scores a pinned pod on a
+restricted or single-numa-node node
+
+  NUMA = −NUMA_ALIGNMENT_WEIGHT × min(1, pinned CPUs after placement /
+                                         allocatable CPUs)
+
+where the pinned CPUs are the pod's plus one pod's worth for every gang
+member already on the node (members of a pinned gang share a shape), so
+the pull towards peers weakens as their cells fill up. A pod needing more
+CPUs than one cell holds cannot be aligned on the node at all: it gets
+the full penalty and no locality. Other pods and nodes score 0.
+*/
+
+package scorer
+
+import (
+	"math"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Node labels declaring the kubelet's NUMA alignment
+const (
+	LabelTopologyManagerPolicy = "nexus.io/topology-manager-policy"
+	LabelNUMANodes             = "nexus.io/numa-nodes"
+)
+
+// numaAdjustment returns the NUMA penalty (≤ 0) of placing pod on node
+// next to members gang members, and whether the pod cannot be aligned there
+func (ns *NodeScorer) numaAdjustment(node *v1.Node, pod *v1.Pod, members int) (int64, bool) {
+	if ns.numaWeight <= 0 || !strictTopologyPolicy(node.Labels[LabelTopologyManagerPolicy]) {
+		return 0, false
+	}
+	pinned := pinnedCPUs(pod)
+	allocatable := node.Status.Allocatable.Cpu().Value()
+	if pinned == 0 || allocatable <= 0 {
+		return 0, false
+	}
+
+	cells := int64(1)
+	if n, err := strconv.ParseInt(node.Labels[LabelNUMANodes], 10, 64); err == nil && n > 1 {
+		cells = n
+	}
+	if pinned > allocatable/cells {
+		return -int64(math.Round(ns.numaWeight)), true
+	}
+	share := math.Min(1, float64(pinned*int64(members+1))/float64(allocatable))
+	return -int64(math.Round(ns.numaWeight * share)), false
+}
+
+// strictTopologyPolicy reports whether the kubelet rejects pods it cannot
+// align under the topology manager policy
+func strictTopologyPolicy(policy string) bool {
+	return policy == "restricted" || policy == "single-numa-node"
+}
+
+// pinnedCPUs returns the exclusive CPUs the static CPU manager gives the
+// pod: the whole-CPU requests of its containers if the pod is Guaranteed,
+// else 0
+func pinnedCPUs(pod *v1.Pod) int64 {
+	if !guaranteed(pod) {
+		return 0
+	}
+	pinned := int64(0)
+	for _, c := range pod.Spec.Containers {
+		if cpu := c.Resources.Limits.Cpu(); cpu.MilliValue()%1000 == 0 {
+			pinned += cpu.Value()
+		}
+	}
+	return pinned
+}
+
+// guaranteed reports whether every container of the pod sets CPU and
+// memory limits with requests equal to them (Guaranteed QoS)
+func guaranteed(pod *v1.Pod) bool {
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				limit, ok := c.Resources.Limits[name]
+				if !ok || limit.IsZero() {
+					return false
+				}
+				if request, ok := c.Resources.Requests[name]; ok && request.Cmp(limit) != 0 {
+					return false
+				}
+			}
+		}
+	}
+	return len(pod.Spec.Containers) > 0
+}
@@ -0,0 +1,493 @@
+/*
+Node Scorer
+===========
+Scores nodes based on gang member locality for the Scheduler Extender.
+The scoring formula prioritizes co-location of dependent services.
+
+Scoring Formula:
+  Score = Locality(GangMembersOnNode) + (AvailableCPU × 10) + (AvailableMemory × 1)
+          − UtilizationPenalty (optional, observed usage from metrics-server)
+          + Image (optional, cached container images, see image.go)
+          − NUMA (optional, pinned-CPU pods on NUMA-aligned nodes, see numa.go)
+
+  Locality(n) = LOCALITY_WEIGHT × curve(min(n, LOCALITY_MEMBER_CAP))
+    n = members on the node + Σ weight(level) × members in the same
+        topology domain (TOPOLOGY_LOCALITY_LEVELS, nearest level wins)
+    linear: n            (default — 100 points per member)
+    sqrt:   √n           (diminishing returns)
+    log:    log2(1 + n)  (strongly diminishing returns)
+
+This ensures that nodes hosting more gang members are strongly preferred,
+with resource availability as a secondary tiebreaker. The sub-linear
+curves and the member cap make co-location benefits saturate, so a node
+that already hosts many members stops attracting more (hotspot avoidance).
+
+Topology levels let clusters with a known network hierarchy express that
+a node in the same rack (or behind the same switch) as gang members is
+almost as good as the same node: e.g. "rack=0.8,switch=0.5" counts a
+member on a same-rack node as 0.8 of a co-located member. Domains are
+evaluated over the candidate nodes of the current request.
+
+During the post-spike drain period the locality component is scaled
+down (DRAIN_LOCALITY_SCALE) so the preference fades instead of stopping
+abruptly.
+
+The spike class policy (SPIKE_CLASS_POLICIES) can scale locality further
+(e.g. tighter co-location during latency spikes) or invert it to spread
+members: each node then scores Locality(busiest candidate) − Locality(n),
+so the nodes with the fewest gang members nearby win.
+
+With GANG_PLACEMENT=planned, locality counts the slots the gang's
+placement plan still has on each node instead of its members, until the
+plan is spent (see pkg/gang/plan.go). Otherwise a gang whose NexusPolicy
+names anchor members counts only the anchors' pods (see anchor.go).
+
+Member counts come from the gang's warm counts while the extender keeps
+them current from pod events (GANG_MEMBER_CACHE, see pkg/gang/members.go);
+otherwise the node's pods are listed on the request path. Large calls can
+split the per-node work across goroutines (SCORING_SHARDS, see shard.go).
+
+The extender converts each node's Total into a HostPriority score.
+*/
+
+package scorer
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/gang"
+	"nexus-scheduler/pkg/graph"
+	"nexus-scheduler/pkg/kube"
+)
+
+// NodeScorer scores nodes based on gang locality and resource availability
+type NodeScorer struct {
+	gangManager *gang.GangManager
+	podLister   *kube.PodLister
+	maxNodes    int
+
+	// Nodes opted out of NEXUS influence (see kube.NodeExcluded)
+	excludeLabel string
+
+	// Per-node work split across goroutines (see shard.go)
+	shards        int
+	shardMinNodes int
+
+	// Member counts standing in for throttled pod lists (see lastgood.go)
+	lastGood lastGoodCounts
+
+	// Locality curve (hotspot avoidance)
+	localityWeight    float64
+	localityCurve     string
+	localityMemberCap int // 0 = uncapped
+
+	// Network topology levels, nearest first (empty = node-level only)
+	topologyLevels []config.TopologyLevel
+
+	// Observed utilization penalty (nil provider = disabled)
+	utilization       *kube.UtilizationProvider
+	utilizationWeight float64
+
+	// Points for cached container images (0 = off, see image.go)
+	imageWeight float64
+
+	// Penalty for pinned-CPU pods on full NUMA-aligned nodes (0 = off, see numa.go)
+	numaWeight float64
+}
+
+// NewNodeScorer creates a new node scorer
+func NewNodeScorer(gangManager *gang.GangManager, podLister *kube.PodLister, utilization *kube.UtilizationProvider, cfg *config.Config) *NodeScorer {
+	return &NodeScorer{
+		utilization:       utilization,
+		utilizationWeight: cfg.UtilizationPenaltyWeight,
+		gangManager:       gangManager,
+		podLister:         podLister,
+		maxNodes:          cfg.MaxNodesScanned,
+		excludeLabel:      cfg.NodeExcludeLabel,
+		shards:            cfg.ScoringShards,
+		shardMinNodes:     cfg.ScoringShardMinNodes,
+		lastGood:          lastGoodCounts{maxAge: cfg.LastGoodMaxAge},
+		localityWeight:    cfg.LocalityWeight,
+		localityCurve:     cfg.LocalityCurve,
+		localityMemberCap: cfg.LocalityMemberCap,
+		topologyLevels:    cfg.TopologyLevels,
+		imageWeight:       cfg.ImageLocalityWeight,
+		numaWeight:        cfg.NUMAAlignmentWeight,
+	}
+}
+
+// maxExtenderPriority is the kube-scheduler extender score range upper bound
+const maxExtenderPriority = 10
+
+// ScoreBreakdown records how each scoring component contributed to a node's score
+type ScoreBreakdown struct {
+	Host        string  `json:"host"`
+	Locality    int64   `json:"locality"`
+	Topology    int64   `json:"topology"` // part of locality from same-domain neighbours (negative when spreading)
+	Resource    int64   `json:"resource"`
+	Utilization int64   `json:"utilization"` // negative: penalty for observed usage
+	Image       int64   `json:"image"`       // cached container images of the pod
+	NUMA        int64   `json:"numa"`        // negative: penalty for pinned CPUs on NUMA-aligned nodes
+	Total       int64   `json:"total"`
+	Normalized  float64 `json:"normalized"`         // total scaled to [0, maxExtenderPriority] within this call
+	Scanned     bool    `json:"scanned"`            // false when skipped by the node budget
+	Excluded    bool    `json:"excluded,omitempty"` // node opted out of NEXUS influence (scored 0)
+	Degraded    bool    `json:"degraded,omitempty"` // member count not read live (API throttled or failing)
+	Planned     bool    `json:"planned,omitempty"`  // locality from the gang's placement plan (GANG_PLACEMENT=planned)
+	Anchored    bool    `json:"anchored,omitempty"` // locality from the gang's anchor members (NexusPolicy anchors)
+
+	Unschedulable bool `json:"unschedulable,omitempty"` // cordoned, tainted or pressured for the pod (scored 0)
+}
+
+// Locality shapes the locality component of a scoring decision
+type Locality struct {
+	Scale  float64 // multiplier (1 = full, <1 = draining, >1 = tighter)
+	Spread bool    // invert the preference: fewer gang members scores higher
+
+	ceiling int64 // highest locality value among candidates (spread only)
+}
+
+// Score scores all nodes for a pod and returns the per-component breakdown,
+// in node order. Total is the extender score for each node; excluded nodes
+// score 0 and do not count against the node budget.
+// Only the first maxNodes nodes are scored; the rest get a neutral score of 0.
+func (ns *NodeScorer) Score(ctx context.Context, pod *v1.Pod, nodes *v1.NodeList, gang *gang.Gang, locality Locality) []ScoreBreakdown {
+	candidates := make([]v1.Node, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		if !kube.NodeExcluded(&nodes.Items[i], ns.excludeLabel) {
+			candidates = append(candidates, nodes.Items[i])
+		}
+	}
+	scanned, _ := kube.CapNodes(candidates, ns.maxNodes)
+	shards := ns.sharding(len(scanned))
+	var memberCounts map[string]int
+	var degraded map[string]bool
+	if shards > 1 {
+		memberCounts, degraded = ns.countGangMembersSharded(ctx, scanned, gang, shards)
+	} else {
+		memberCounts, degraded = ns.countGangMembers(ctx, scanned, gang)
+	}
+	weights := gang.Weights() // NexusPolicy of the gang's group
+
+	// A placement plan with slots left replaces the member counts by the
+	// slots each node still has
+	planned := false
+	if gang != nil && !locality.Spread {
+		if plan := ns.gangManager.Plan(gang); plan != nil {
+			if slots, ok := plan.Steer(memberCounts, nodeNames(scanned)); ok {
+				memberCounts, planned = slots, true
+			}
+		}
+	}
+
+	// Otherwise anchor members placed on the candidates attract the others
+	// in their place (see anchor.go)
+	anchored := false
+	if !planned && !locality.Spread {
+		if counts, ok := ns.anchorCounts(ctx, pod, scanned, gang, degraded); ok {
+			memberCounts, anchored = counts, true
+		}
+	}
+
+	if locality.Spread {
+		for i := range scanned {
+			if score, _ := ns.calculateLocalityScore(&scanned[i], scanned, memberCounts); score > locality.ceiling {
+				locality.ceiling = score
+			}
+		}
+	}
+
+	// Excluded nodes and nodes past the budget keep a zero breakdown
+	breakdown := make([]ScoreBreakdown, len(nodes.Items))
+	scored := make([]int, 0, len(scanned))
+	for i := range nodes.Items {
+		breakdown[i] = ScoreBreakdown{Host: nodes.Items[i].Name}
+		switch {
+		case kube.NodeExcluded(&nodes.Items[i], ns.excludeLabel):
+			breakdown[i].Excluded = true
+		case len(scored) < len(scanned):
+			scored = append(scored, i)
+		}
+	}
+	score := func(i int) {
+		b := ns.scoreNode(ctx, pod, &nodes.Items[i], scanned, memberCounts, locality, weights)
+		b.Degraded = degraded[nodes.Items[i].Name]
+		b.Planned = planned
+		b.Anchored = anchored
+		breakdown[i] = b
+	}
+	if shards > 1 {
+		names := make([]string, len(scored))
+		for j, i := range scored {
+			names[j] = nodes.Items[i].Name
+		}
+		runSharded(names, shards, func(j int) { score(scored[j]) })
+	} else {
+		for _, i := range scored {
+			score(i)
+		}
+	}
+
+	normalize(breakdown)
+	return breakdown
+}
+
+// normalize scales the totals of a call to [0, maxExtenderPriority]
+func normalize(breakdown []ScoreBreakdown) {
+	maxTotal := int64(0)
+	for i := range breakdown {
+		if breakdown[i].Total > maxTotal {
+			maxTotal = breakdown[i].Total
+		}
+	}
+	if maxTotal > 0 {
+		for i := range breakdown {
+			breakdown[i].Normalized = float64(breakdown[i].Total) / float64(maxTotal) * maxExtenderPriority
+		}
+	}
+}
+
+// scoreNode calculates the placement score for a pod on a specific node
+func (ns *NodeScorer) scoreNode(ctx context.Context, pod *v1.Pod, node *v1.Node, candidates []v1.Node, memberCounts map[string]int, locality Locality, weights gang.Weights) ScoreBreakdown {
+	localityScore, topologyScore := ns.calculateLocalityScore(node, candidates, memberCounts)
+	numaPenalty, unaligned := ns.numaAdjustment(node, pod, memberCounts[node.Name])
+	if locality.Spread {
+		// Headroom below the busiest candidate; topology then counts against the node
+		localityScore = locality.ceiling - localityScore
+		topologyScore = -topologyScore
+	}
+	if scale := locality.Scale * gang.Weight(weights.Locality); scale != 1 {
+		localityScore = int64(math.Round(float64(localityScore) * scale))
+		topologyScore = int64(math.Round(float64(topologyScore) * scale))
+	}
+	if unaligned {
+		localityScore, topologyScore = 0, 0 // no pull towards a node that cannot admit the pod
+	}
+	resourceScore := weighted(ns.calculateResourceScore(node, pod), weights.Resource)
+	utilizationPenalty := weighted(ns.calculateUtilizationPenalty(ctx, node), weights.Utilization)
+	imageScore := ns.calculateImageScore(node, pod)
+
+	totalScore := localityScore + resourceScore + imageScore + numaPenalty - utilizationPenalty
+	if totalScore < 0 {
+		totalScore = 0
+	}
+
+	klog.V(3).Infof("Score for node %s: locality=%d (topology=%d), resource=%d, image=%d, numa=%d, utilization=-%d, total=%d",
+		node.Name, localityScore, topologyScore, resourceScore, imageScore, numaPenalty, utilizationPenalty, totalScore)
+
+	return ScoreBreakdown{
+		Host:        node.Name,
+		Locality:    localityScore,
+		Topology:    topologyScore,
+		Resource:    resourceScore,
+		Utilization: -utilizationPenalty,
+		Image:       imageScore,
+		NUMA:        numaPenalty,
+		Total:       totalScore,
+		Scanned:     true,
+	}
+}
+
+// weighted applies a policy weight to a scoring component
+func weighted(score int64, weight float64) int64 {
+	if weight == 0 || weight == 1 {
+		return score
+	}
+	return int64(math.Round(float64(score) * weight))
+}
+
+// calculateLocalityScore scores a node based on how many gang members run on it
+// (and, with topology levels, near it). Also returns the topology share.
+// With the default linear curve: gang members on node × 100 — this heavily favors co-location
+func (ns *NodeScorer) calculateLocalityScore(node *v1.Node, candidates []v1.Node, memberCounts map[string]int) (int64, int64) {
+	own := float64(memberCounts[node.Name])
+	effective := own + ns.topologyMembers(node, candidates, memberCounts)
+
+	score := ns.localityValue(effective)
+	return score, score - ns.localityValue(own)
+}
+
+// topologyMembers sums the weighted gang members on other candidate nodes
+// sharing a topology domain with node. Each neighbour counts once, at the
+// weight of the nearest level it shares.
+func (ns *NodeScorer) topologyMembers(node *v1.Node, candidates []v1.Node, memberCounts map[string]int) float64 {
+	if len(ns.topologyLevels) == 0 {
+		return 0
+	}
+
+	weighted := 0.0
+	for i := range candidates {
+		other := &candidates[i]
+		count := memberCounts[other.Name]
+		if other.Name == node.Name || count == 0 {
+			continue
+		}
+		for _, level := range ns.topologyLevels {
+			domain, ok := node.Labels[level.Key]
+			if ok && other.Labels[level.Key] == domain {
+				weighted += level.Weight * float64(count)
+				break
+			}
+		}
+	}
+	return weighted
+}
+
+// localityValue applies the member cap and diminishing-returns curve
+func (ns *NodeScorer) localityValue(n float64) int64 {
+	if ns.localityMemberCap > 0 && n > float64(ns.localityMemberCap) {
+		n = float64(ns.localityMemberCap)
+	}
+
+	switch ns.localityCurve {
+	case config.LocalityCurveSqrt:
+		n = math.Sqrt(n)
+	case config.LocalityCurveLog:
+		n = math.Log2(1 + n)
+	}
+
+	return int64(math.Round(ns.localityWeight * n))
+}
+
+// countGangMembers counts gang members on each candidate node, and reports
+// the nodes whose count could not be read live
+func (ns *NodeScorer) countGangMembers(ctx context.Context, nodes []v1.Node, gang *gang.Gang) (map[string]int, map[string]bool) {
+	counts := make(map[string]int, len(nodes))
+	degraded := make(map[string]bool)
+	for i := range nodes {
+		count, live := ns.memberCount(ctx, &nodes[i], gang)
+		counts[nodes[i].Name] = count
+		if !live {
+			degraded[nodes[i].Name] = true
+		}
+	}
+	return counts, degraded
+}
+
+// CountGangMembersOnNode counts how many gang member pods are running on a
+// node, falling back to the last known good count when the list fails
+func (ns *NodeScorer) CountGangMembersOnNode(ctx context.Context, node *v1.Node, gang *gang.Gang) int {
+	count, _ := ns.memberCount(ctx, node, gang)
+	return count
+}
+
+// memberCount returns the gang members on a node and whether the count was
+// read live; otherwise it is the last known good count (or 0 without one)
+func (ns *NodeScorer) memberCount(ctx context.Context, node *v1.Node, gang *gang.Gang) (int, bool) {
+	if gang == nil || len(gang.Members) == 0 {
+		return 0, true
+	}
+	if !ns.gangManager.NodeAvailable(node.Name) {
+		return 0, true // NotReady or gone mid-episode (see pkg/gang/nodes.go)
+	}
+	if count, warm := ns.gangManager.WarmMemberCount(gang, node.Name); warm {
+		return count, true // kept current from pod events (GANG_MEMBER_CACHE)
+	}
+	return ns.listedCount(ctx, node, gang.ID, gang.Members)
+}
+
+// listedCount lists the pods running on a node and counts those of the
+// services, falling back to the last known good count under key
+func (ns *NodeScorer) listedCount(ctx context.Context, node *v1.Node, key string, services []string) (int, bool) {
+	// List pods running on this node (paginated, within the pod budget)
+	pods, _, err := ns.podLister.List(ctx, "list pods on "+node.Name, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node.Name,
+	})
+	if err != nil {
+		cause := "failed"
+		if kube.IsThrottled(err) {
+			cause = "throttled"
+		}
+		if last, ok := ns.lastGood.load(node.Name, key, time.Now()); ok {
+			klog.Warningf("Pod list on node %s %s (%v), scoring from last known count %d (%v old)",
+				node.Name, cause, err, last.count, time.Since(last.at).Round(time.Second))
+			return last.count, false
+		}
+		klog.Warningf("Pod list on node %s %s (%v) and no recent count, scoring without locality", node.Name, cause, err)
+		return 0, false
+	}
+
+	// Count matching gang members
+	count := 0
+	for _, pod := range pods {
+		podService := graph.ExtractServiceName(pod.Name)
+		for _, service := range services {
+			if strings.EqualFold(podService, service) {
+				count++
+				break
+			}
+		}
+	}
+
+	ns.lastGood.store(node.Name, key, count, time.Now())
+	return count, true
+}
+
+// calculateResourceScore scores based on available CPU and memory
+// CPU weight: 10 points per 100m available
+// Memory weight: 1 point per 100Mi available
+func (ns *NodeScorer) calculateResourceScore(node *v1.Node, pod *v1.Pod) int64 {
+	alloc := node.Status.Allocatable
+
+	cpuMillis := alloc.Cpu().MilliValue()
+	memBytes := alloc.Memory().Value()
+
+	// Normalize CPU: 10 points per 100m (1 core = 100 points)
+	cpuScore := int64(cpuMillis / 100 * 10)
+
+	// Normalize Memory: 1 point per 100Mi
+	memScore := int64(memBytes / (100 * 1024 * 1024))
+
+	// Cap individual scores to prevent overwhelming locality
+	if cpuScore > 100 {
+		cpuScore = 100
+	}
+	if memScore > 50 {
+		memScore = 50
+	}
+
+	return cpuScore + memScore
+}
+
+// RefreshUtilization re-reads observed node usage ahead of the first
+// decisions of an episode (no-op when utilization scoring is off)
+func (ns *NodeScorer) RefreshUtilization(ctx context.Context) error {
+	if ns.utilization == nil {
+		return nil
+	}
+	return ns.utilization.Refresh(ctx)
+}
+
+// calculateUtilizationPenalty penalizes nodes by their observed usage
+// (metrics-server), so nominally allocatable but saturated nodes lose points:
+//
+//	Penalty = UTILIZATION_PENALTY_WEIGHT × max(cpuUsed/cpuAllocatable, memUsed/memAllocatable)
+func (ns *NodeScorer) calculateUtilizationPenalty(ctx context.Context, node *v1.Node) int64 {
+	if ns.utilization == nil {
+		return 0
+	}
+
+	usage, ok := ns.utilization.Usage(ctx, node.Name)
+	if !ok {
+		return 0
+	}
+
+	alloc := node.Status.Allocatable
+	fraction := 0.0
+	if cpu := alloc.Cpu().MilliValue(); cpu > 0 {
+		fraction = math.Max(fraction, float64(usage.CPUMillis)/float64(cpu))
+	}
+	if mem := alloc.Memory().Value(); mem > 0 {
+		fraction = math.Max(fraction, float64(usage.MemoryBytes)/float64(mem))
+	}
+
+	return int64(math.Round(ns.utilizationWeight * math.Min(fraction, 1)))
+}
@@ -0,0 +1,386 @@
+/*
+History Store
+=============
+Persists episodes and Prioritize decisions beyond the in-memory history
+rings, so week-long experiment campaigns keep every decision without the
+scheduler's memory growing with them. Entries are appended as JSON lines
+to segment files in HISTORY_STORE_DIR (a mounted volume):
+
+  history-<UTC start time>.jsonl   one {"kind", "time", "episode", "data"}
+                                   object per line
+
+A new segment is started every HISTORY_STORE_SEGMENT, or once the active
+one reaches a tenth of HISTORY_STORE_MAX_MB. Closed segments are deleted
+when their newest entry is older than HISTORY_STORE_RETENTION, and oldest
+first while the store exceeds HISTORY_STORE_MAX_MB; the active segment is
+never deleted.
+
+Appending never blocks the scheduling path: entries go through a bounded
+buffer and are dropped (and counted) when the writer falls behind. Query
+reads the segments newest first and skips a partially written last line.
+An embedded database (bbolt, SQLite) would need a new module dependency;
+the segments are plain files that jq and pandas.read_json(lines=True)
+read directly.
+*/
+
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+)
+
+// Entry kinds
+const (
+	KindEpisode  = "episode"
+	KindDecision = "decision"
+)
+
+const (
+	segmentPrefix = "history-"
+	segmentSuffix = ".jsonl"
+	segmentLayout = "20060102T150405.000Z"
+)
+
+// Entry is one stored record; Data is the JSON of the episode or decision
+type Entry struct {
+	Kind    string          `json:"kind"`
+	Time    time.Time       `json:"time"`
+	Episode string          `json:"episode,omitempty"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Query selects stored entries (zero fields match everything)
+type Query struct {
+	Kind    string
+	Episode string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// matches reports whether e is selected by the query
+func (q Query) matches(e Entry) bool {
+	return (q.Kind == "" || e.Kind == q.Kind) &&
+		(q.Episode == "" || e.Episode == q.Episode) &&
+		(q.Since.IsZero() || !e.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || !e.Time.After(q.Until))
+}
+
+// segment is one file of the store
+type segment struct {
+	path  string
+	first time.Time // time in the file name
+	last  time.Time // newest entry (modification time for existing files)
+	bytes int64
+}
+
+// Store appends entries to segment files and prunes them by age and size
+type Store struct {
+	dir       string
+	rotate    time.Duration
+	retention time.Duration
+	maxBytes  int64
+	entries   chan Entry
+	metrics   *metrics.NEXUSMetrics
+
+	mu       sync.Mutex
+	segments []segment // oldest first; the last one is active while file is open
+
+	// Writer state (owned by Run)
+	file *os.File
+}
+
+// Open creates the store directory and indexes the segments already in it
+func Open(cfg *config.Config, metrics *metrics.NEXUSMetrics) (*Store, error) {
+	if err := os.MkdirAll(cfg.HistoryStoreDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history store directory: %w", err)
+	}
+
+	buffer := cfg.HistoryStoreBuffer
+	if buffer <= 0 {
+		buffer = 1
+	}
+	s := &Store{
+		dir:       cfg.HistoryStoreDir,
+		rotate:    cfg.HistoryStoreSegment,
+		retention: cfg.HistoryStoreRetention,
+		maxBytes:  int64(cfg.HistoryStoreMaxMB) << 20,
+		entries:   make(chan Entry, buffer),
+		metrics:   metrics,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.prune(time.Now())
+	return s, nil
+}
+
+// load indexes the existing segment files
+func (s *Store) load() error {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read history store directory: %w", err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		first, err := time.Parse(segmentLayout, strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, segment{
+			path:  filepath.Join(s.dir, name),
+			first: first,
+			last:  info.ModTime(),
+			bytes: info.Size(),
+		})
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].first.Before(s.segments[j].first) })
+	s.updateBytes()
+	return nil
+}
+
+// Append queues an entry for storage, dropping it if the buffer is full
+func (s *Store) Append(e Entry) {
+	select {
+	case s.entries <- e:
+	default:
+		s.metrics.IncrementCounter("history_dropped")
+	}
+}
+
+// Run writes queued entries until ctx is done, rotating and pruning segments
+func (s *Store) Run(ctx context.Context) {
+	rotate := s.rotate
+	if rotate <= 0 {
+		rotate = time.Hour
+	}
+	ticker := time.NewTicker(rotate)
+	defer ticker.Stop()
+
+	klog.Infof("History store: writing to %s (segments of %v, retention %v, max %d MB)",
+		s.dir, rotate, s.retention, s.maxBytes>>20)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.drain()
+			s.closeFile()
+			return
+		case e := <-s.entries:
+			s.write(e)
+		case now := <-ticker.C:
+			s.closeFile()
+			s.prune(now)
+		}
+	}
+}
+
+// drain writes whatever is still buffered
+func (s *Store) drain() {
+	for {
+		select {
+		case e := <-s.entries:
+			s.write(e)
+		default:
+			return
+		}
+	}
+}
+
+// write appends one entry, starting a new segment if needed
+func (s *Store) write(e Entry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		klog.Warningf("History store: failed to encode %s entry: %v", e.Kind, err)
+		s.metrics.IncrementCounter("history_dropped")
+		return
+	}
+	line = append(line, '\n')
+
+	if s.file == nil {
+		if err := s.openFile(e.Time); err != nil {
+			klog.Warningf("History store: %v", err)
+			s.metrics.IncrementCounter("history_dropped")
+			return
+		}
+	}
+	if _, err := s.file.Write(line); err != nil {
+		klog.Warningf("History store: failed to write %s: %v", s.file.Name(), err)
+		s.metrics.IncrementCounter("history_dropped")
+		return
+	}
+	s.metrics.IncrementCounter("history_stored")
+
+	s.mu.Lock()
+	active := &s.segments[len(s.segments)-1]
+	active.bytes += int64(len(line))
+	if e.Time.After(active.last) {
+		active.last = e.Time
+	}
+	full := s.maxBytes > 0 && active.bytes >= s.maxBytes/10
+	s.updateBytes()
+	s.mu.Unlock()
+
+	if full {
+		s.closeFile()
+		s.prune(time.Now())
+	}
+}
+
+// openFile starts a new segment named after the first entry's time
+func (s *Store) openFile(start time.Time) error {
+	name := filepath.Join(s.dir, segmentPrefix+start.UTC().Format(segmentLayout)+segmentSuffix)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.segments); n > 0 && s.segments[n-1].path == name {
+		s.segments = s.segments[:n-1] // reopened within the same millisecond
+	}
+	s.segments = append(s.segments, segment{path: name, first: start, last: start, bytes: info.Size()})
+	s.file = file
+	return nil
+}
+
+// closeFile closes the active segment
+func (s *Store) closeFile() {
+	if s.file == nil {
+		return
+	}
+	if err := s.file.Close(); err != nil {
+		klog.Warningf("History store: failed to close %s: %v", s.file.Name(), err)
+	}
+	s.file = nil
+}
+
+// prune deletes closed segments past the retention or beyond the size limit
+func (s *Store) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	closed := len(s.segments)
+	if s.file != nil {
+		closed-- // the active segment is kept
+	}
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.bytes
+	}
+
+	kept := s.segments[:0]
+	for i, seg := range s.segments {
+		expired := s.retention > 0 && now.Sub(seg.last) > s.retention
+		oversize := s.maxBytes > 0 && total > s.maxBytes
+		if i < closed && (expired || oversize) {
+			if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+				klog.Warningf("History store: failed to delete %s: %v", seg.path, err)
+			} else {
+				total -= seg.bytes
+				s.metrics.IncrementCounter("history_segments_pruned")
+				klog.V(2).Infof("History store: deleted %s", seg.path)
+				continue
+			}
+		}
+		kept = append(kept, seg)
+	}
+	s.segments = kept
+	s.updateBytes()
+}
+
+// updateBytes publishes the store size (s.mu held)
+func (s *Store) updateBytes() {
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.bytes
+	}
+	s.metrics.SetHistoryStoreBytes(total)
+}
+
+// Query returns the matching entries, newest first
+func (s *Store) Query(q Query) ([]Entry, error) {
+	s.mu.Lock()
+	segments := append([]segment(nil), s.segments...)
+	s.mu.Unlock()
+
+	var result []Entry
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		if !q.Until.IsZero() && seg.first.After(q.Until) {
+			continue
+		}
+		if !q.Since.IsZero() && seg.last.Before(q.Since) {
+			break // older segments end earlier still
+		}
+		entries, err := readSegment(seg.path, q)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(entries) - 1; j >= 0; j-- {
+			result = append(result, entries[j])
+			if q.Limit > 0 && len(result) >= q.Limit {
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+// readSegment returns the matching entries of one segment, oldest first
+func readSegment(path string, q Query) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil // pruned since the index was copied
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var e Entry
+			if json.Unmarshal(line, &e) == nil && q.matches(e) {
+				entries = append(entries, e)
+			}
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nexus-scheduler/pkg/config"
+	"nexus-scheduler/pkg/metrics"
+)
+
+var testStart = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T, dir string, maxMB int, retention time.Duration) *Store {
+	t.Helper()
+	cfg := &config.Config{
+		HistoryStoreDir:       dir,
+		HistoryStoreSegment:   time.Hour,
+		HistoryStoreRetention: retention,
+		HistoryStoreMaxMB:     maxMB,
+		HistoryStoreBuffer:    8,
+	}
+	s, err := Open(cfg, metrics.NewNEXUSMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testEntry(kind, episode string, minute int) Entry {
+	data, _ := json.Marshal(map[string]int{"minute": minute})
+	return Entry{Kind: kind, Time: testStart.Add(time.Duration(minute) * time.Minute), Episode: episode, Data: data}
+}
+
+func TestStoreQuery(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, dir, 0, 0)
+	s.write(testEntry(KindEpisode, "episode-1", 0))
+	s.write(testEntry(KindDecision, "episode-1", 1))
+	s.write(testEntry(KindDecision, "episode-1", 2))
+	s.closeFile()
+	s.write(testEntry(KindDecision, "episode-2", 3))
+
+	all, err := s.Query(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 || all[0].Episode != "episode-2" || all[3].Kind != KindEpisode {
+		t.Fatalf("all = %+v, want 4 entries newest first", all)
+	}
+
+	decisions, _ := s.Query(Query{Kind: KindDecision, Episode: "episode-1", Limit: 1})
+	if len(decisions) != 1 || !decisions[0].Time.Equal(testStart.Add(2*time.Minute)) {
+		t.Errorf("decisions = %+v, want the newest episode-1 decision", decisions)
+	}
+	window, _ := s.Query(Query{Since: testStart.Add(time.Minute), Until: testStart.Add(2 * time.Minute)})
+	if len(window) != 2 {
+		t.Errorf("window = %+v, want minutes 1 and 2", window)
+	}
+
+	// A partially written line is skipped, and the index survives a restart
+	s.closeFile()
+	f, err := os.OpenFile(s.segments[len(s.segments)-1].path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"kind":"decision","ti`)
+	f.Close()
+	reopened := newTestStore(t, dir, 0, 0)
+	if all, _ := reopened.Query(Query{}); len(all) != 4 {
+		t.Errorf("after reopen = %d entries, want 4", len(all))
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, dir, 0, 24*time.Hour)
+	s.write(testEntry(KindDecision, "episode-1", 0))
+	s.closeFile()
+	s.write(testEntry(KindDecision, "episode-2", 60))
+
+	s.prune(testStart.Add(25 * time.Hour))
+	entries, _ := s.Query(Query{})
+	if len(entries) != 1 || entries[0].Episode != "episode-2" {
+		t.Errorf("entries = %+v, want only the active segment", entries)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "history-*.jsonl"))
+	if len(files) != 1 {
+		t.Errorf("files = %v, want the expired segment deleted", files)
+	}
+}
+
+func TestStoreSizeLimit(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), 1, 0)
+	s.maxBytes = 1000 // segments rotate at 100 bytes
+	for i := 0; i < 30; i++ {
+		s.write(testEntry(KindDecision, "episode-1", i))
+	}
+
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.bytes
+	}
+	if total > s.maxBytes || len(s.segments) < 2 {
+		t.Errorf("%d bytes in %d segments, want at most %d", total, len(s.segments), s.maxBytes)
+	}
+	entries, _ := s.Query(Query{})
+	if len(entries) == 0 || !entries[0].Time.Equal(testStart.Add(29*time.Minute)) {
+		t.Errorf("newest entry = %+v, want minute 29 kept", entries)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Build Information
+=================
+Identifies the binary that produced an experiment's results. The release
+build stamps the version, git SHA and build date through -ldflags (see
+Dockerfile):
+
+  go build -ldflags "-X nexus-scheduler/pkg/version.GitSHA=$(git rev-parse HEAD) \
+                     -X nexus-scheduler/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+
+Builds without the flags fall back to the VCS stamp the Go toolchain
+embeds when building inside a git checkout (revision and commit time),
+and report "unknown" without one. Exported as nexus_build_info and at
+GET /version.
+*/
+
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags "-X nexus-scheduler/pkg/version.<Name>=..."
+var (
+	Version   = "v2.0"
+	GitSHA    = ""
+	BuildDate = ""
+)
+
+// unknown is reported for build details that were not stamped
+const unknown = "unknown"
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"gitSha"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	info := Info{Version: Version, GitSHA: GitSHA, BuildDate: BuildDate, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitSHA == "":
+				info.GitSHA = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	if info.GitSHA == "" {
+		info.GitSHA = unknown
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = unknown
+	}
+	return info
+}